@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"GateKeeper/models"
+	"GateKeeper/services"
+)
+
+// APIKeyHeader is the request header carrying an API key
+const APIKeyHeader = "X-Api-Key"
+
+// APIKeyAuthenticator resolves API keys to users; AuthService implements it
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string, required ...string) (*models.APIKeyIdentity, error)
+}
+
+// Ensure AuthService implements APIKeyAuthenticator interface
+var _ APIKeyAuthenticator = (*services.AuthService)(nil)
+
+// APIKeyMiddleware authenticates requests carrying an X-Api-Key header
+type APIKeyMiddleware struct {
+	authenticator APIKeyAuthenticator
+}
+
+// NewAPIKeyMiddleware creates a middleware authenticating API keys with authenticator
+func NewAPIKeyMiddleware(authenticator APIKeyAuthenticator) *APIKeyMiddleware {
+	return &APIKeyMiddleware{authenticator: authenticator}
+}
+
+// RequireAPIKey returns middleware rejecting requests without a valid API
+// key with 401, and keys lacking any of scopes with 403. The key's owner is
+// passed to next in the request context.
+func (m *APIKeyMiddleware) RequireAPIKey(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(APIKeyHeader)
+			if key == "" {
+				WriteProblem(w, http.StatusUnauthorized, "missing_api_key", APIKeyHeader+" header is required")
+				return
+			}
+
+			identity, err := m.authenticator.AuthenticateAPIKey(r.Context(), key, scopes...)
+			switch {
+			case errors.Is(err, services.ErrInsufficientScope):
+				WriteProblem(w, http.StatusForbidden, "insufficient_scope", err.Error())
+				return
+			case err != nil:
+				WriteProblem(w, http.StatusUnauthorized, "invalid_api_key", "API key is invalid")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), &identity.User)))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"GateKeeper/models"
+	"GateKeeper/services"
+)
+
+// stubAuthenticator accepts the key "good" for testUser with the users:read scope
+type stubAuthenticator struct{}
+
+func (stubAuthenticator) AuthenticateAPIKey(_ context.Context, key string, required ...string) (*models.APIKeyIdentity, error) {
+	if key != "good" {
+		return nil, services.ErrInvalidAPIKey
+	}
+	scopes := []string{"users:read"}
+	for _, scope := range required {
+		if !slices.Contains(scopes, scope) {
+			return nil, fmt.Errorf("%w: %s", services.ErrInsufficientScope, scope)
+		}
+	}
+	return &models.APIKeyIdentity{User: *testUser, KeyID: 1, Scopes: scopes}, nil
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	m := NewAPIKeyMiddleware(stubAuthenticator{})
+
+	for _, tc := range []struct {
+		name    string
+		key     string
+		scopes  []string
+		status  int
+		problem string
+	}{
+		{"valid key", "good", nil, http.StatusOK, ""},
+		{"held scope", "good", []string{"users:read"}, http.StatusOK, ""},
+		{"missing header", "", nil, http.StatusUnauthorized, "urn:gatekeeper:missing_api_key"},
+		{"invalid key", "bad", nil, http.StatusUnauthorized, "urn:gatekeeper:invalid_api_key"},
+		{"missing scope", "good", []string{"users:write"}, http.StatusForbidden, "urn:gatekeeper:insufficient_scope"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			if tc.key != "" {
+				req.Header.Set(APIKeyHeader, tc.key)
+			}
+			rec := httptest.NewRecorder()
+			m.RequireAPIKey(tc.scopes...)(whoami).ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.status)
+			}
+			if tc.problem == "" {
+				if rec.Body.String() != testUser.Email {
+					t.Errorf("body = %q, want the key's owner in the downstream context", rec.Body.String())
+				}
+			} else if problem := decodeProblem(t, rec); problem.Type != tc.problem {
+				t.Errorf("problem type = %q, want %q", problem.Type, tc.problem)
+			}
+		})
+	}
+}
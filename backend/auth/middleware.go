@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"GateKeeper/models"
+	"GateKeeper/services"
+	"GateKeeper/validation"
+)
+
+// TokenValidator validates access tokens; AuthService implements it
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (*models.UserResponse, error)
+}
+
+// Ensure AuthService implements TokenValidator interface
+var _ TokenValidator = (*services.AuthService)(nil)
+
+// contextKey is the type of the request context key holding the user
+type contextKey struct{}
+
+// userKey is the request context key holding the authenticated user
+var userKey = contextKey{}
+
+// Problem is an RFC 7807 problem document, the error envelope of the API
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+
+	// Errors lists the invalid fields of a request that failed validation
+	Errors []validation.FieldError `json:"errors,omitempty"`
+}
+
+// Middleware authenticates requests carrying a Bearer access token
+type Middleware struct {
+	validator TokenValidator
+}
+
+// NewMiddleware creates an authentication middleware validating tokens with validator
+func NewMiddleware(validator TokenValidator) *Middleware {
+	return &Middleware{validator: validator}
+}
+
+// RequireAuth rejects requests without a valid Bearer token with 401 and
+// passes the authenticated user to next in the request context
+func (m *Middleware) RequireAuth(next http.Handler) http.Handler {
+	return m.authenticate(next, true)
+}
+
+// OptionalAuth attaches the user to the request context if a Bearer token is
+// present and serves anonymous requests unchanged. A token that is present
+// but invalid is still rejected with 401.
+func (m *Middleware) OptionalAuth(next http.Handler) http.Handler {
+	return m.authenticate(next, false)
+}
+
+// RequireRole returns middleware that authenticates like RequireAuth and
+// rejects users without role with 403
+func (m *Middleware) RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return m.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := UserFromContext(r.Context())
+			if !ok || user.Role != role {
+				WriteProblem(w, http.StatusForbidden, "insufficient_role", "the "+role+" role is required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
+
+// authenticate returns the handler shared by RequireAuth and OptionalAuth
+func (m *Middleware) authenticate(next http.Handler, required bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			if required {
+				writeProblem(w, "missing_token", "Authorization header is required")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scheme, token, ok := strings.Cut(header, " ")
+		token = strings.TrimSpace(token)
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			writeProblem(w, "malformed_token", "Authorization header must use the Bearer scheme")
+			return
+		}
+
+		user, err := m.validator.ValidateToken(r.Context(), token)
+		switch {
+		case errors.Is(err, services.ErrTokenExpired):
+			writeProblem(w, "expired_token", "access token has expired")
+			return
+		case err != nil:
+			writeProblem(w, "invalid_token", "access token is invalid")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+	})
+}
+
+// WithUser returns a copy of ctx carrying the authenticated user
+func WithUser(ctx context.Context, user *models.UserResponse) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// UserFromContext returns the authenticated user attached by the middleware,
+// or false for anonymous requests
+func UserFromContext(ctx context.Context) (*models.UserResponse, bool) {
+	user, ok := ctx.Value(userKey).(*models.UserResponse)
+	return user, ok && user != nil
+}
+
+// writeProblem writes a 401 problem document with a Bearer challenge
+func writeProblem(w http.ResponseWriter, code, detail string) {
+	challenge := "Bearer"
+	if code != "missing_token" {
+		challenge += ` error="` + bearerError(code) + `"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	WriteProblem(w, http.StatusUnauthorized, code, detail)
+}
+
+// WriteProblem writes a problem document with the given status. code
+// identifies the problem type and detail explains this occurrence.
+func WriteProblem(w http.ResponseWriter, status int, code, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{
+		Type:   "urn:gatekeeper:" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+}
+
+// WriteValidationProblem writes a 400 problem document listing the fields
+// that failed validation
+func WriteValidationProblem(w http.ResponseWriter, err *validation.Error) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(Problem{
+		Type:   "urn:gatekeeper:validation_failed",
+		Title:  http.StatusText(http.StatusBadRequest),
+		Status: http.StatusBadRequest,
+		Detail: err.Error(),
+		Errors: err.Fields,
+	})
+}
+
+// bearerError maps a problem code to an RFC 6750 error code
+func bearerError(code string) string {
+	if code == "malformed_token" {
+		return "invalid_request"
+	}
+	return "invalid_token"
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"GateKeeper/models"
+	"GateKeeper/services"
+)
+
+// stubValidator accepts the tokens in users and fails the others with the
+// error in errs, or ErrInvalidToken
+type stubValidator struct {
+	users map[string]*models.UserResponse
+	errs  map[string]error
+}
+
+func (v stubValidator) ValidateToken(_ context.Context, token string) (*models.UserResponse, error) {
+	if user, ok := v.users[token]; ok {
+		return user, nil
+	}
+	if err, ok := v.errs[token]; ok {
+		return nil, err
+	}
+	return nil, services.ErrInvalidToken
+}
+
+// testUser is the user behind the stub's valid token
+var testUser = &models.UserResponse{ID: 7, Email: "user@example.com", Username: "user", Role: "user"}
+
+// newStubMiddleware returns a middleware accepting "good" for testUser and
+// rejecting "expired" as expired
+func newStubMiddleware() *Middleware {
+	return NewMiddleware(stubValidator{
+		users: map[string]*models.UserResponse{"good": testUser},
+		errs:  map[string]error{"expired": services.ErrTokenExpired},
+	})
+}
+
+// whoami writes the email of the user in the request context, or "anonymous"
+var whoami = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if user, ok := UserFromContext(r.Context()); ok {
+		io.WriteString(w, user.Email)
+		return
+	}
+	io.WriteString(w, "anonymous")
+})
+
+// serve sends a GET with the Authorization header, if not empty, to h
+func serve(h http.Handler, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeProblem decodes the problem document in rec
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", got)
+	}
+	var problem Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	return problem
+}
+
+func TestRequireAuth(t *testing.T) {
+	h := newStubMiddleware().RequireAuth(whoami)
+
+	for _, tc := range []struct {
+		name          string
+		authorization string
+		problem       string
+		challenge     string
+	}{
+		{"missing header", "", "urn:gatekeeper:missing_token", "Bearer"},
+		{"other scheme", "Basic dXNlcjpwYXNz", "urn:gatekeeper:malformed_token", `Bearer error="invalid_request"`},
+		{"empty token", "Bearer ", "urn:gatekeeper:malformed_token", `Bearer error="invalid_request"`},
+		{"no scheme", "good", "urn:gatekeeper:malformed_token", `Bearer error="invalid_request"`},
+		{"invalid token", "Bearer junk", "urn:gatekeeper:invalid_token", `Bearer error="invalid_token"`},
+		{"expired token", "Bearer expired", "urn:gatekeeper:expired_token", `Bearer error="invalid_token"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(h, tc.authorization)
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want 401", rec.Code)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tc.challenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tc.challenge)
+			}
+			problem := decodeProblem(t, rec)
+			if problem.Type != tc.problem || problem.Status != http.StatusUnauthorized || problem.Title != "Unauthorized" {
+				t.Errorf("problem = %+v, want type %s with status 401", problem, tc.problem)
+			}
+		})
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		// The scheme is case-insensitive
+		for _, authorization := range []string{"Bearer good", "bearer good"} {
+			rec := serve(h, authorization)
+			if rec.Code != http.StatusOK || rec.Body.String() != testUser.Email {
+				t.Errorf("%q: got %d %q, want 200 with the user in the downstream context", authorization, rec.Code, rec.Body.String())
+			}
+		}
+	})
+}
+
+func TestOptionalAuth(t *testing.T) {
+	h := newStubMiddleware().OptionalAuth(whoami)
+
+	for _, tc := range []struct {
+		name          string
+		authorization string
+		status        int
+		body          string
+	}{
+		{"anonymous", "", http.StatusOK, "anonymous"},
+		{"valid token", "Bearer good", http.StatusOK, testUser.Email},
+		{"invalid token", "Bearer junk", http.StatusUnauthorized, ""},
+		{"malformed header", "Basic x", http.StatusUnauthorized, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(h, tc.authorization)
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.status)
+			}
+			if tc.body != "" && rec.Body.String() != tc.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tc.body)
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	admin := &models.UserResponse{ID: 1, Email: "admin@example.com", Role: "admin"}
+	m := NewMiddleware(stubValidator{users: map[string]*models.UserResponse{"good": testUser, "admin": admin}})
+	h := m.RequireRole("admin")(whoami)
+
+	if rec := serve(h, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status = %d, want 401", rec.Code)
+	}
+	rec := serve(h, "Bearer good")
+	if rec.Code != http.StatusForbidden || decodeProblem(t, rec).Type != "urn:gatekeeper:insufficient_role" {
+		t.Errorf("user: status = %d, want 403 insufficient_role", rec.Code)
+	}
+	if rec := serve(h, "Bearer admin"); rec.Code != http.StatusOK || rec.Body.String() != admin.Email {
+		t.Errorf("admin: got %d %q, want 200", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRequireAuthWithAuthService(t *testing.T) {
+	ctx := context.Background()
+	s := services.NewAuthService()
+	if _, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "a@example.com", Username: "alice", Password: "secret1"}); err != nil {
+		t.Fatal(err)
+	}
+	login, err := s.LoginUser(ctx, models.LoginRequest{Email: "a@example.com", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewMiddleware(s).RequireAuth(whoami)
+	if rec := serve(h, "Bearer "+login.AccessToken); rec.Code != http.StatusOK || rec.Body.String() != "a@example.com" {
+		t.Fatalf("got %d %q, want 200 for the issued token", rec.Code, rec.Body.String())
+	}
+
+	if err := s.DeactivateUser(ctx, login.User.ID); err != nil {
+		t.Fatal(err)
+	}
+	if rec := serve(h, "Bearer "+login.AccessToken); rec.Code != http.StatusUnauthorized {
+		t.Errorf("deactivated user: status = %d, want 401", rec.Code)
+	}
+}
+
+func TestUserFromContext(t *testing.T) {
+	if _, ok := UserFromContext(context.Background()); ok {
+		t.Error("UserFromContext found a user in an empty context")
+	}
+	if _, ok := UserFromContext(WithUser(context.Background(), nil)); ok {
+		t.Error("UserFromContext reported a nil user")
+	}
+	if user, ok := UserFromContext(WithUser(context.Background(), testUser)); !ok || user != testUser {
+		t.Errorf("UserFromContext = %v, %v; want the attached user", user, ok)
+	}
+}
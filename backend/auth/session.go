@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/services"
+)
+
+// SessionResolver resolves session IDs to users; AuthService implements it
+type SessionResolver interface {
+	ResolveSession(ctx context.Context, sessionID string) (*models.UserResponse, error)
+}
+
+// Ensure AuthService implements SessionResolver interface
+var _ SessionResolver = (*services.AuthService)(nil)
+
+// CookieConfig sets the attributes of the session cookie
+type CookieConfig struct {
+	Name     string
+	Path     string
+	Domain   string
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+// DefaultCookieConfig returns a Secure, HttpOnly, SameSite=Lax cookie named
+// gk_session scoped to the whole site
+func DefaultCookieConfig() CookieConfig {
+	return CookieConfig{
+		Name:     "gk_session",
+		Path:     "/",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// SessionMiddleware authenticates requests carrying a session cookie
+type SessionMiddleware struct {
+	resolver SessionResolver
+	cookie   CookieConfig
+}
+
+// NewSessionMiddleware creates a middleware resolving session cookies
+// described by cookie with resolver
+func NewSessionMiddleware(resolver SessionResolver, cookie CookieConfig) *SessionMiddleware {
+	return &SessionMiddleware{resolver: resolver, cookie: cookie}
+}
+
+// RequireSession rejects requests without a valid session cookie with 401
+// and passes the session's user to next in the request context. Every
+// resolved request extends the session's idle timeout.
+func (m *SessionMiddleware) RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessionID := m.SessionID(r)
+		if sessionID == "" {
+			WriteProblem(w, http.StatusUnauthorized, "missing_session", "session cookie is required")
+			return
+		}
+
+		user, err := m.resolver.ResolveSession(r.Context(), sessionID)
+		switch {
+		case errors.Is(err, services.ErrUserDeactivated):
+			m.ClearCookie(w)
+			WriteProblem(w, http.StatusForbidden, "user_deactivated", err.Error())
+			return
+		case err != nil:
+			m.ClearCookie(w)
+			WriteProblem(w, http.StatusUnauthorized, "invalid_session", "session is invalid or has expired")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+	})
+}
+
+// SessionID returns the session ID carried by r, or ""
+func (m *SessionMiddleware) SessionID(r *http.Request) string {
+	cookie, err := r.Cookie(m.cookie.Name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// SetCookie sends the session cookie, expiring at the session's absolute timeout
+func (m *SessionMiddleware) SetCookie(w http.ResponseWriter, sessionID string, expiresAt time.Time) {
+	http.SetCookie(w, m.newCookie(sessionID, expiresAt))
+}
+
+// ClearCookie tells the client to drop the session cookie
+func (m *SessionMiddleware) ClearCookie(w http.ResponseWriter) {
+	cookie := m.newCookie("", time.Unix(0, 0))
+	cookie.MaxAge = -1
+	http.SetCookie(w, cookie)
+}
+
+// newCookie returns the session cookie with the configured attributes
+func (m *SessionMiddleware) newCookie(value string, expiresAt time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.cookie.Name,
+		Value:    value,
+		Path:     m.cookie.Path,
+		Domain:   m.cookie.Domain,
+		Expires:  expiresAt,
+		Secure:   m.cookie.Secure,
+		HttpOnly: m.cookie.HttpOnly,
+		SameSite: m.cookie.SameSite,
+	}
+}
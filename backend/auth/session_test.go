@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/services"
+)
+
+// stubResolver accepts the session "good" for testUser and "inactive" for a
+// deactivated user
+type stubResolver struct{}
+
+func (stubResolver) ResolveSession(_ context.Context, sessionID string) (*models.UserResponse, error) {
+	switch sessionID {
+	case "good":
+		return testUser, nil
+	case "inactive":
+		return nil, services.ErrUserDeactivated
+	}
+	return nil, services.ErrInvalidSession
+}
+
+// serveSession sends a GET with the session cookie, if not empty, to h
+func serveSession(h http.Handler, sessionID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	if sessionID != "" {
+		req.AddCookie(&http.Cookie{Name: "gk_session", Value: sessionID})
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// sessionCookie returns the session cookie set on rec, or nil
+func sessionCookie(rec *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "gk_session" {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestRequireSession(t *testing.T) {
+	h := NewSessionMiddleware(stubResolver{}, DefaultCookieConfig()).RequireSession(whoami)
+
+	rec := serveSession(h, "good")
+	if rec.Code != http.StatusOK || rec.Body.String() != testUser.Email {
+		t.Errorf("valid session: got %d %q, want 200 with the user in the downstream context", rec.Code, rec.Body.String())
+	}
+
+	for _, tc := range []struct {
+		name      string
+		sessionID string
+		status    int
+		problem   string
+		cleared   bool
+	}{
+		{"missing cookie", "", http.StatusUnauthorized, "urn:gatekeeper:missing_session", false},
+		{"invalid session", "expired", http.StatusUnauthorized, "urn:gatekeeper:invalid_session", true},
+		{"deactivated user", "inactive", http.StatusForbidden, "urn:gatekeeper:user_deactivated", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serveSession(h, tc.sessionID)
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.status)
+			}
+			if problem := decodeProblem(t, rec); problem.Type != tc.problem {
+				t.Errorf("problem type = %q, want %q", problem.Type, tc.problem)
+			}
+			if cookie := sessionCookie(rec); (cookie != nil) != tc.cleared || (cookie != nil && cookie.MaxAge >= 0) {
+				t.Errorf("cookie = %v, cleared should be %v", cookie, tc.cleared)
+			}
+		})
+	}
+}
+
+func TestSessionCookie(t *testing.T) {
+	config := CookieConfig{Name: "gk_session", Path: "/app", Domain: "example.com", Secure: true, HttpOnly: true, SameSite: http.SameSiteStrictMode}
+	m := NewSessionMiddleware(stubResolver{}, config)
+
+	rec := httptest.NewRecorder()
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	m.SetCookie(rec, "id", expiresAt)
+	cookie := sessionCookie(rec)
+	if cookie == nil {
+		t.Fatal("SetCookie set no session cookie")
+	}
+	if cookie.Value != "id" || cookie.Path != "/app" || cookie.Domain != "example.com" ||
+		!cookie.Secure || !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode || !cookie.Expires.Equal(expiresAt) {
+		t.Errorf("cookie = %+v, want the configured attributes", cookie)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	if got := m.SessionID(req); got != "id" {
+		t.Errorf("SessionID = %q, want id", got)
+	}
+	if got := m.SessionID(httptest.NewRequest(http.MethodGet, "/", nil)); got != "" {
+		t.Errorf("SessionID without a cookie = %q, want empty", got)
+	}
+}
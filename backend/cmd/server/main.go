@@ -1,16 +1,341 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"GateKeeper/auth"
 	"GateKeeper/configurations"
+	"GateKeeper/db"
+	"GateKeeper/handlers"
+	"GateKeeper/health"
+	"GateKeeper/migrations"
+	"GateKeeper/models"
+	"GateKeeper/oauth"
+	"GateKeeper/repository"
+	"GateKeeper/services"
 )
 
+// shutdownTimeout bounds how long in-flight requests may take on shutdown
+const shutdownTimeout = 15 * time.Second
+
+// defaultRequestTimeout bounds how long a request may take unless
+// REQUEST_TIMEOUT overrides it
+const defaultRequestTimeout = 30 * time.Second
+
+// connectTimeout bounds how long connecting to the database may take at startup
+const connectTimeout = 10 * time.Second
+
+// queryAttempts bounds how often a query failing transiently, e.g. while
+// the database fails over, is attempted
+const queryAttempts = 3
+
+// healthCheckTimeout bounds each readiness check
+const healthCheckTimeout = 2 * time.Second
+
+// replicaCheckInterval is how often read replicas are checked, so reads
+// avoid the unhealthy ones
+const replicaCheckInterval = 10 * time.Second
+
+// purgeInterval is how often users deleted longer ago than the retention
+// window are purged, and expired refresh tokens pruned
+const purgeInterval = time.Hour
+
 func main() {
-	log.Println("Testing Supabase connection...")
+	migrate := flag.String("migrate", "", "apply (up) or revert (down) database migrations, or list the pending ones (pending), then exit")
+	migrateSteps := flag.Int("migrate-steps", 1, "how many migrations -migrate=down reverts")
+	flag.Parse()
+	if *migrate != "" && os.Getenv("DB_HOST") == "" {
+		log.Fatal("-migrate needs a database; set DB_HOST")
+	}
+
+	addr := os.Getenv("ADDR")
+	if addr == "" {
+		addr = ":8080"
+	}
+
+	tokens := services.DefaultTokenConfig()
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		tokens.Secret = []byte(secret)
+	} else {
+		log.Println("JWT_SECRET not set; using a random secret, tokens will not survive a restart")
+	}
+
+	// Store users, sessions, refresh tokens and API keys, and the audit
+	// trail, in Postgres when DB_HOST is set (see
+	// configurations.DatabaseConfigFromEnv); otherwise keep them in memory
+	// and write the audit trail to the log
+	var users repository.UserRepository
+	var sessions repository.SessionStore
+	var refreshTokens repository.RefreshTokenStore
+	var apiKeys repository.APIKeyStore
+	var auditor services.AuthAuditLogger
+	var routing *db.RoutingPool
+
+	// The server is ready once every component in readiness is up; the
+	// database stays down until its pool is established
+	readiness := health.NewAggregator(healthCheckTimeout)
+	if os.Getenv("DB_HOST") != "" {
+		database := health.NewDatabaseChecker(healthCheckTimeout)
+		readiness.Add("database", database)
+
+		dbConfig, err := configurations.DatabaseConfigFromEnv()
+		if err != nil {
+			log.Fatalf("Invalid database configuration: %v", err)
+		}
+		connectCtx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+		pool, err := configurations.NewDatabasePool(connectCtx, dbConfig)
+		cancel()
+		if err != nil {
+			log.Fatalf("Failed to connect to the database: %v", err)
+		}
+		defer pool.Close()
+
+		// Run -migrate and exit, or apply pending migrations at startup when
+		// AUTO_MIGRATE=true, for development
+		if *migrate != "" {
+			if err := migrateDatabase(pool, *migrate, *migrateSteps); err != nil {
+				log.Fatalf("Migration failed: %v", err)
+			}
+			return
+		}
+		if os.Getenv("AUTO_MIGRATE") == "true" {
+			if err := migrateDatabase(pool, "up", 0); err != nil {
+				log.Fatalf("Migration failed: %v", err)
+			}
+		}
+		database.SetDB(pool)
+
+		// Listing and searching users read from the replicas in
+		// DB_REPLICA_HOSTS while any is healthy, everything else uses pool
+		var replicas []db.Replica
+		for _, replicaConfig := range dbConfig.ReplicaConfigs() {
+			connectCtx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+			replicaPool, err := configurations.NewDatabasePool(connectCtx, replicaConfig)
+			cancel()
+			if err != nil {
+				log.Fatalf("Failed to connect to the replica %s: %v", replicaConfig.Host, err)
+			}
+			defer replicaPool.Close()
+
+			checker := health.NewDatabaseChecker(healthCheckTimeout)
+			checker.SetDB(replicaPool)
+			replicas = append(replicas, db.Replica{Querier: replicaPool, Health: checker})
+		}
+		routing = db.NewRoutingPool(pool, replicas...)
+		queries := db.NewRetryQuerier(routing, db.NewRetryPolicy(queryAttempts))
+		users = repository.NewPostgresUserRepository(queries)
+		sessions = repository.NewPostgresSessionStore(queries)
+		refreshTokens = repository.NewPostgresRefreshTokenStore(queries)
+		apiKeys = repository.NewPostgresAPIKeyStore(queries)
+		auditor = repository.NewPostgresAuditLog(queries)
+	} else {
+		log.Println("DB_HOST not set; users are kept in memory and will not survive a restart")
+		users = repository.NewMemoryUserRepository()
+		sessions = repository.NewMemorySessionStore()
+		refreshTokens = repository.NewMemoryRefreshTokenStore()
+		apiKeys = repository.NewMemoryAPIKeyStore()
+		auditor = services.NewSlogAuditLogger(nil)
+	}
+
+	// Hash new passwords with Argon2id when PASSWORD_HASH=argon2id, else with
+	// bcrypt at BCRYPT_COST; weaker stored hashes are upgraded on login
+	opts := []services.Option{
+		services.WithSessions(services.DefaultSessionConfig(), sessions),
+		services.WithRefreshTokens(refreshTokens),
+		services.WithAPIKeys(apiKeys),
+		services.WithAuditLogger(auditor),
+	}
+	if os.Getenv("PASSWORD_HASH") == "argon2id" {
+		hasher, err := services.NewArgon2idHasher(services.DefaultArgon2Params())
+		if err != nil {
+			log.Fatalf("Invalid password hashing parameters: %v", err)
+		}
+		opts = append(opts, services.WithPasswordHasher(hasher))
+	} else if raw := os.Getenv("BCRYPT_COST"); raw != "" {
+		cost, err := strconv.Atoi(raw)
+		if err != nil {
+			log.Fatalf("Invalid BCRYPT_COST %q: %v", raw, err)
+		}
+		hasher, err := services.NewBcryptHasher(cost)
+		if err != nil {
+			log.Fatalf("Invalid BCRYPT_COST: %v", err)
+		}
+		opts = append(opts, services.WithPasswordHasher(hasher))
+	}
+
+	// Users with an email in ADMIN_EMAILS (comma-separated) are admins once
+	// the email is verified, whether they sign in with OAuth later or already
+	// exist; an unverified account is left alone, since anyone could have
+	// registered it
+	var adminEmails []string
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			adminEmails = append(adminEmails, email)
+		}
+	}
+	opts = append(opts, services.WithAdminEmails(adminEmails...))
+
+	// Deleted users can be restored for DELETED_USER_RETENTION, 30 days by
+	// default, before they are purged
+	if raw := os.Getenv("DELETED_USER_RETENTION"); raw != "" {
+		retention, err := time.ParseDuration(raw)
+		if err != nil || retention < 0 {
+			log.Fatalf("Invalid DELETED_USER_RETENTION %q", raw)
+		}
+		opts = append(opts, services.WithDeletedRetention(retention))
+	}
+
+	authService := services.NewAuthServiceWithConfig(users, tokens, opts...)
+	for _, email := range adminEmails {
+		user, err := authService.GetUserByEmail(context.Background(), email)
+		if errors.Is(err, services.ErrUserNotFound) {
+			continue
+		} else if err != nil {
+			log.Fatalf("Failed to look up admin %s: %v", email, err)
+		}
+		if user.Role == models.RoleAdmin || !user.EmailVerified {
+			continue
+		}
+		if err := authService.SetUserRole(context.Background(), user.ID, models.RoleAdmin); err != nil {
+			log.Fatalf("Failed to make %s an admin: %v", email, err)
+		}
+	}
+
+	// Session cookies are Secure unless COOKIE_INSECURE=true, for local HTTP development
+	cookie := auth.DefaultCookieConfig()
+	if os.Getenv("COOKIE_INSECURE") == "true" {
+		cookie.Secure = false
+	}
+	// READINESS_URLS lists critical upstreams as comma-separated name=url
+	// pairs whose health URLs must answer for the server to be ready
+	for _, upstream := range strings.Split(os.Getenv("READINESS_URLS"), ",") {
+		if upstream = strings.TrimSpace(upstream); upstream == "" {
+			continue
+		}
+		name, url, ok := strings.Cut(upstream, "=")
+		if !ok {
+			log.Fatalf("Invalid READINESS_URLS entry %q, want name=url", upstream)
+		}
+		readiness.Add(name, health.NewHTTPChecker(url, &http.Client{Timeout: healthCheckTimeout}))
+	}
+
+	mux := http.NewServeMux()
+	probes := handlers.NewHealthHandler(readiness).Routes()
+	mux.Handle("/healthz", probes)
+	mux.Handle("/readyz", probes)
+	mux.Handle("/", handlers.NewAuthHandlerWithConfig(authService, cookie).Routes())
+
+	// Offer social login for each provider whose client credentials are set;
+	// callbacks go to OAUTH_REDIRECT_BASE/auth/oauth/{provider}/callback
+	redirectBase := os.Getenv("OAUTH_REDIRECT_BASE")
+	if redirectBase == "" {
+		redirectBase = "http://localhost" + addr
+	}
+	var providers []oauth.Provider
+	if id := os.Getenv("GOOGLE_CLIENT_ID"); id != "" {
+		providers = append(providers, oauth.Google(id, os.Getenv("GOOGLE_CLIENT_SECRET"), redirectBase+"/auth/oauth/google/callback"))
+	}
+	if id := os.Getenv("GITHUB_CLIENT_ID"); id != "" {
+		providers = append(providers, oauth.GitHub(id, os.Getenv("GITHUB_CLIENT_SECRET"), redirectBase+"/auth/oauth/github/callback"))
+	}
+	if len(providers) > 0 {
+		// OAuth state is signed with OAUTH_STATE_SECRET, falling back to the JWT secret
+		stateKey := tokens.Secret
+		if secret := os.Getenv("OAUTH_STATE_SECRET"); secret != "" {
+			stateKey = []byte(secret)
+		}
+		flow := oauth.NewFlow(authService, stateKey, &http.Client{Timeout: 10 * time.Second}, providers...)
+		mux.Handle("/auth/oauth/", handlers.NewOAuthHandler(flow).Routes())
+	}
+
+	requestTimeout := defaultRequestTimeout
+	if raw := os.Getenv("REQUEST_TIMEOUT"); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil || timeout <= 0 {
+			log.Fatalf("Invalid REQUEST_TIMEOUT %q", raw)
+		}
+		requestTimeout = timeout
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handlers.WithRequestTimeout(mux, requestTimeout),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Stop on SIGINT/SIGTERM, letting in-flight requests finish
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go authService.RunPurgeJob(ctx, purgeInterval)
+	if routing != nil {
+		go routing.RunHealthChecks(ctx, replicaCheckInterval)
+	}
+
+	go func() {
+		log.Printf("Listening on %s", addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Fatalf("Graceful shutdown failed: %v", err)
+	}
+	log.Println("Server stopped")
+}
 
-	// Connect to Supabase
-	configurations.ConnectToSupabase()
+// migrateDatabase applies the pending migrations (mode up), reverts the last
+// steps applied ones (mode down) or lists the pending ones (mode pending)
+func migrateDatabase(pool *configurations.Pool, mode string, steps int) error {
+	ctx := context.Background()
+	embedded, err := migrations.Embedded()
+	if err != nil {
+		return err
+	}
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	runner := migrations.NewRunner(conn, embedded)
 
-	log.Println("🎉 Connection test completed successfully!")
+	var done []migrations.Migration
+	verb := ""
+	switch mode {
+	case "up":
+		done, err = runner.Up(ctx)
+		verb = "Applied"
+	case "down":
+		done, err = runner.Down(ctx, steps)
+		verb = "Reverted"
+	case "pending":
+		done, err = runner.Pending(ctx)
+		verb = "Pending"
+	default:
+		return fmt.Errorf("unknown -migrate mode %q, want up, down or pending", mode)
+	}
+	for _, migration := range done {
+		log.Printf("%s migration %04d_%s", verb, migration.Version, migration.Name)
+	}
+	if err == nil && len(done) == 0 {
+		log.Println("No migrations to run")
+	}
+	return err
 }
@@ -0,0 +1,34 @@
+//go:build integration
+
+package configurations
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestDatabasePoolIntegration connects to the database described by the DB_*
+// variables; run it with go test -tags integration against a test database
+func TestDatabasePoolIntegration(t *testing.T) {
+	cfg, err := DatabaseConfigFromEnv()
+	if err != nil {
+		t.Fatalf("DatabaseConfigFromEnv: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	pool, err := NewDatabasePool(ctx, cfg)
+	if err != nil {
+		t.Fatalf("NewDatabasePool: %v", err)
+	}
+	defer pool.Close()
+
+	if err := Ping(ctx, pool); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	var one int
+	if err := pool.QueryRow(ctx, "SELECT 1").Scan(&one); err != nil || one != 1 {
+		t.Fatalf("SELECT 1 = %d, %v", one, err)
+	}
+}
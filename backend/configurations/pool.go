@@ -0,0 +1,188 @@
+package configurations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"GateKeeper/repository"
+)
+
+// DefaultAcquireTimeout is how long queries wait for a free connection
+// unless DatabaseConfig.AcquireTimeout says otherwise
+const DefaultAcquireTimeout = 5 * time.Second
+
+// ErrAcquireTimeout is wrapped by every *AcquireTimeoutError
+var ErrAcquireTimeout = errors.New("timed out waiting for a database connection")
+
+// AcquireTimeoutError is returned when every pooled connection stayed busy
+// for the whole acquire timeout
+type AcquireTimeoutError struct {
+	// Timeout is how long the caller waited
+	Timeout time.Duration
+}
+
+// Error implements the error interface
+func (e *AcquireTimeoutError) Error() string {
+	return fmt.Sprintf("%v after %v", ErrAcquireTimeout, e.Timeout)
+}
+
+// Unwrap returns ErrAcquireTimeout
+func (e *AcquireTimeoutError) Unwrap() error {
+	return ErrAcquireTimeout
+}
+
+// Pool is a pgxpool.Pool whose Exec, Query and QueryRow stop waiting for a
+// connection after the acquire timeout, so a saturated pool fails requests
+// instead of stalling them. It satisfies repository.DBTX.
+type Pool struct {
+	*pgxpool.Pool
+
+	acquireTimeout  time.Duration
+	acquireTimeouts atomic.Int64
+}
+
+// Ensure Pool implements repository.DBTX interface
+var _ repository.DBTX = (*Pool)(nil)
+
+// PoolStats is a snapshot of the pool's connections and acquisitions, for metrics
+type PoolStats struct {
+	MaxConns      int32 `json:"max_conns"`
+	TotalConns    int32 `json:"total_conns"`
+	AcquiredConns int32 `json:"acquired_conns"`
+	IdleConns     int32 `json:"idle_conns"`
+
+	// AcquireCount counts successful acquisitions, EmptyAcquireCount those
+	// that had to wait for a connection, CanceledAcquireCount those given
+	// up by the caller and AcquireTimeouts those that hit the acquire timeout
+	AcquireCount         int64 `json:"acquire_count"`
+	EmptyAcquireCount    int64 `json:"empty_acquire_count"`
+	CanceledAcquireCount int64 `json:"canceled_acquire_count"`
+	AcquireTimeouts      int64 `json:"acquire_timeouts"`
+
+	// AcquireWaitDuration is the total time spent in successful acquisitions
+	AcquireWaitDuration time.Duration `json:"acquire_wait_duration_ns"`
+}
+
+// Acquire returns a connection from the pool, waiting at most the acquire
+// timeout, after which it fails with an *AcquireTimeoutError
+func (p *Pool) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	acquireCtx, cancel := context.WithTimeoutCause(ctx, p.acquireTimeout, ErrAcquireTimeout)
+	defer cancel()
+
+	conn, err := p.Pool.Acquire(acquireCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(context.Cause(acquireCtx), ErrAcquireTimeout) {
+		p.acquireTimeouts.Add(1)
+		return nil, &AcquireTimeoutError{Timeout: p.acquireTimeout}
+	}
+	return conn, err
+}
+
+// Exec acquires a connection and runs sql on it
+func (p *Pool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	conn, err := p.Acquire(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	defer conn.Release()
+
+	return conn.Exec(ctx, sql, args...)
+}
+
+// Query acquires a connection and runs sql on it. The connection returns to
+// the pool once the rows are closed or read to the end.
+func (p *Pool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	conn, err := p.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		conn.Release()
+		return nil, err
+	}
+	return &releasingRows{Rows: rows, conn: conn}, nil
+}
+
+// QueryRow acquires a connection and runs sql on it. The connection returns
+// to the pool once the row is scanned.
+func (p *Pool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	conn, err := p.Acquire(ctx)
+	if err != nil {
+		return errRow{err: err}
+	}
+	return &releasingRow{row: conn.QueryRow(ctx, sql, args...), conn: conn}
+}
+
+// Stats returns a snapshot of the pool's statistics
+func (p *Pool) Stats() PoolStats {
+	stat := p.Pool.Stat()
+	return PoolStats{
+		MaxConns:             stat.MaxConns(),
+		TotalConns:           stat.TotalConns(),
+		AcquiredConns:        stat.AcquiredConns(),
+		IdleConns:            stat.IdleConns(),
+		AcquireCount:         stat.AcquireCount(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+		AcquireTimeouts:      p.acquireTimeouts.Load(),
+		AcquireWaitDuration:  stat.AcquireDuration(),
+	}
+}
+
+// releasingRows releases its connection when closed or exhausted
+type releasingRows struct {
+	pgx.Rows
+	conn *pgxpool.Conn
+	once sync.Once
+}
+
+// Next advances to the next row, releasing the connection after the last
+func (r *releasingRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.release()
+	return false
+}
+
+// Close closes the rows and releases the connection
+func (r *releasingRows) Close() {
+	r.Rows.Close()
+	r.release()
+}
+
+// release returns the connection to the pool, once
+func (r *releasingRows) release() {
+	r.once.Do(r.conn.Release)
+}
+
+// releasingRow releases its connection once scanned
+type releasingRow struct {
+	row  pgx.Row
+	conn *pgxpool.Conn
+}
+
+// Scan scans the row and releases the connection
+func (r *releasingRow) Scan(dest ...any) error {
+	defer r.conn.Release()
+	return r.row.Scan(dest...)
+}
+
+// errRow is a pgx.Row whose Scan fails with err
+type errRow struct {
+	err error
+}
+
+// Scan returns the row's error
+func (r errRow) Scan(dest ...any) error {
+	return r.err
+}
@@ -0,0 +1,162 @@
+package configurations
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// fakePostgres starts a server speaking just enough of the Postgres protocol
+// for pgx to connect, ping and run empty queries, and returns a config for it
+func fakePostgres(t *testing.T) DatabaseConfig {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakePostgres(conn)
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return DatabaseConfig{Host: host, Port: portNumber, User: "u", DBName: "auth", SSLMode: "disable"}
+}
+
+// serveFakePostgres accepts the startup without authentication and answers
+// every simple query with an empty response
+func serveFakePostgres(conn net.Conn) {
+	defer conn.Close()
+	backend := pgproto3.NewBackend(conn, conn)
+	if _, err := backend.ReceiveStartupMessage(); err != nil {
+		return
+	}
+	backend.Send(&pgproto3.AuthenticationOk{})
+	backend.Send(&pgproto3.BackendKeyData{ProcessID: 1, SecretKey: 2})
+	backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	if backend.Flush() != nil {
+		return
+	}
+	for {
+		msg, err := backend.Receive()
+		if err != nil {
+			return
+		}
+		switch msg.(type) {
+		case *pgproto3.Query:
+			backend.Send(&pgproto3.EmptyQueryResponse{})
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+			if backend.Flush() != nil {
+				return
+			}
+		case *pgproto3.Terminate:
+			return
+		}
+	}
+}
+
+func TestPoolRespectsMaxConns(t *testing.T) {
+	cfg := fakePostgres(t)
+	cfg.MaxConns = 3
+	cfg.AcquireTimeout = 100 * time.Millisecond
+	pool, err := NewDatabasePool(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewDatabasePool: %v", err)
+	}
+	defer pool.Close()
+
+	const goroutines = 20
+	var (
+		mu       sync.Mutex
+		held     []*pgxpool.Conn
+		timeouts int
+		wg       sync.WaitGroup
+	)
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := pool.Acquire(context.Background())
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				var timeout *AcquireTimeoutError
+				if !errors.As(err, &timeout) || !errors.Is(err, ErrAcquireTimeout) || timeout.Timeout != cfg.AcquireTimeout {
+					t.Errorf("Acquire = %v, want an *AcquireTimeoutError", err)
+				}
+				timeouts++
+				return
+			}
+			held = append(held, conn)
+		}()
+	}
+	wg.Wait()
+
+	// Nothing is released until every goroutine is done, so exactly MaxConns succeed
+	stats := pool.Stats()
+	if len(held) != 3 || timeouts != goroutines-3 {
+		t.Errorf("%d acquired and %d timed out, want 3 and %d", len(held), timeouts, goroutines-3)
+	}
+	if stats.MaxConns != 3 || stats.AcquiredConns != 3 || stats.TotalConns > 3 || stats.AcquireTimeouts != goroutines-3 {
+		t.Errorf("Stats = %+v, want 3 acquired of 3 and %d timeouts", stats, goroutines-3)
+	}
+
+	if _, err := pool.Exec(context.Background(), ""); !errors.Is(err, ErrAcquireTimeout) {
+		t.Errorf("Exec on a saturated pool = %v, want ErrAcquireTimeout", err)
+	}
+	if err := pool.QueryRow(context.Background(), "").Scan(); !errors.Is(err, ErrAcquireTimeout) {
+		t.Errorf("QueryRow on a saturated pool = %v, want ErrAcquireTimeout", err)
+	}
+
+	for _, conn := range held {
+		conn.Release()
+	}
+	if _, err := pool.Exec(context.Background(), ""); err != nil {
+		t.Errorf("Exec after releasing: %v", err)
+	}
+	if stats := pool.Stats(); stats.AcquiredConns != 0 || stats.IdleConns == 0 || stats.AcquireCount < 4 {
+		t.Errorf("Stats after releasing = %+v, want idle connections and none acquired", stats)
+	}
+}
+
+func TestPoolAcquireCancelled(t *testing.T) {
+	cfg := fakePostgres(t)
+	cfg.MaxConns = 1
+	cfg.AcquireTimeout = time.Minute
+	pool, err := NewDatabasePool(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewDatabasePool: %v", err)
+	}
+	defer pool.Close()
+
+	conn, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Release()
+
+	// A caller giving up is not an acquire timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(ctx); err == nil || errors.Is(err, ErrAcquireTimeout) {
+		t.Errorf("Acquire with a cancelled context = %v, want the context's error", err)
+	}
+	if stats := pool.Stats(); stats.AcquireTimeouts != 0 || stats.CanceledAcquireCount != 1 {
+		t.Errorf("Stats = %+v, want one cancelled acquisition and no timeouts", stats)
+	}
+}
@@ -0,0 +1,275 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE codes of server errors that may go away on retry
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+	adminShutdown        = "57P01"
+	crashShutdown        = "57P02"
+	cannotConnectNow     = "57P03"
+
+	// connectionException is the class of connection failure codes
+	connectionException = "08"
+)
+
+// RetryPolicy decides whether and when to retry a failed query. It has the
+// semantics of the data plane's resiliency.RetryPolicy, which is internal
+// to that module: attempts are numbered from 0, at most MaxAttempts are
+// made, the first retry is immediate and later ones back off exponentially.
+// Only transient errors (see IsTransient) are retried.
+type RetryPolicy struct {
+	maxAttempts  int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	multiplier   float64
+}
+
+// NewRetryPolicy creates a policy making at most maxAttempts attempts,
+// backing off from 50ms up to 1s
+func NewRetryPolicy(maxAttempts int) *RetryPolicy {
+	return NewRetryPolicyWithConfig(maxAttempts, 50*time.Millisecond, time.Second, 2.0)
+}
+
+// NewRetryPolicyWithConfig creates a policy with custom backoff. At least
+// one attempt is always made.
+func NewRetryPolicyWithConfig(maxAttempts int, initialDelay, maxDelay time.Duration, multiplier float64) *RetryPolicy {
+	return &RetryPolicy{
+		maxAttempts:  max(maxAttempts, 1),
+		initialDelay: initialDelay,
+		maxDelay:     maxDelay,
+		multiplier:   multiplier,
+	}
+}
+
+// ShouldRetry reports whether a query that failed with err on attempt
+// should be tried again
+func (rp *RetryPolicy) ShouldRetry(err error, attempt int) bool {
+	return attempt < rp.maxAttempts-1 && IsTransient(err)
+}
+
+// GetDelay returns how long to wait after attempt before the next one
+func (rp *RetryPolicy) GetDelay(attempt int) time.Duration {
+	if attempt == 0 {
+		return 0
+	}
+	delay := time.Duration(float64(rp.initialDelay) * math.Pow(rp.multiplier, float64(attempt-1)))
+	return min(delay, rp.maxDelay)
+}
+
+// MaxAttempts returns the maximum number of attempts
+func (rp *RetryPolicy) MaxAttempts() int {
+	return rp.maxAttempts
+}
+
+// IsTransient reports whether err may go away on retry: a lost or refused
+// connection, a serialization failure or deadlock, or the server shutting
+// down or starting up, as during a failover. Constraint violations, syntax
+// errors, other server errors and cancellations are not transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case serializationFailure, deadlockDetected, adminShutdown, crashShutdown, cannotConnectNow:
+			return true
+		}
+		return strings.HasPrefix(pgErr.Code, connectionException)
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return pgconn.SafeToRetry(err) || errors.As(err, &connectErr) || errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE)
+}
+
+// mayHaveApplied reports whether the statement that failed with err may
+// have taken effect anyway: the server reports the errors it rolled back,
+// but a connection lost after sending the statement leaves it unknown
+func mayHaveApplied(err error) bool {
+	var pgErr *pgconn.PgError
+	var connectErr *pgconn.ConnectError
+	return !errors.As(err, &pgErr) && !errors.As(err, &connectErr) && !pgconn.SafeToRetry(err)
+}
+
+// readOnly reports whether sql is a SELECT, which is safe to run twice
+func readOnly(sql string) bool {
+	keyword, _, _ := strings.Cut(strings.TrimLeft(sql, " \t\r\n("), " ")
+	return strings.EqualFold(strings.TrimSpace(keyword), "SELECT")
+}
+
+// RetryQuerier is a Querier retrying transient failures of the queries it
+// runs on another Querier, e.g. a pool. A statement other than a SELECT is
+// retried after a lost connection only if it cannot have been applied,
+// unless RetryWrites is set. Queries inside a WithTx transaction bypass the
+// RetryQuerier, since a failed statement aborts its transaction; use
+// RetryQuerier.WithTx to retry the transaction as a whole. Errors reported
+// while reading the rows of Query, after it returned, are not retried.
+type RetryQuerier struct {
+	querier Querier
+	policy  *RetryPolicy
+	writes  bool
+
+	retries   atomic.Int64
+	recovered atomic.Int64
+	exhausted atomic.Int64
+}
+
+// Ensure RetryQuerier implements Querier interface
+var _ Querier = (*RetryQuerier)(nil)
+
+// RetryOption configures a RetryQuerier
+type RetryOption func(*RetryQuerier)
+
+// RetryWrites retries every statement after a lost connection, even if it
+// may already have been applied. Use it only when every statement run
+// through the querier is idempotent.
+func RetryWrites() RetryOption {
+	return func(q *RetryQuerier) {
+		q.writes = true
+	}
+}
+
+// NewRetryQuerier creates a querier running queries on querier and
+// retrying them as policy says
+func NewRetryQuerier(querier Querier, policy *RetryPolicy, opts ...RetryOption) *RetryQuerier {
+	q := &RetryQuerier{querier: querier, policy: policy}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// RetryStats counts the retries of a RetryQuerier, for metrics
+type RetryStats struct {
+	// Retries counts the attempts after the first, Recovered the queries
+	// that succeeded after a retry and Exhausted those that still failed
+	// with a transient error after the last attempt
+	Retries   int64 `json:"retries"`
+	Recovered int64 `json:"recovered"`
+	Exhausted int64 `json:"exhausted"`
+}
+
+// Stats returns a snapshot of the retry counts
+func (q *RetryQuerier) Stats() RetryStats {
+	return RetryStats{
+		Retries:   q.retries.Load(),
+		Recovered: q.recovered.Load(),
+		Exhausted: q.exhausted.Load(),
+	}
+}
+
+// Exec runs sql, retrying transient failures
+func (q *RetryQuerier) Exec(ctx context.Context, sql string, args ...any) (tag pgconn.CommandTag, err error) {
+	err = q.do(ctx, q.statementRetryable(sql), func() (err error) {
+		tag, err = q.querier.Exec(ctx, sql, args...)
+		return err
+	})
+	return tag, err
+}
+
+// Query runs sql, retrying transient failures to send it
+func (q *RetryQuerier) Query(ctx context.Context, sql string, args ...any) (rows pgx.Rows, err error) {
+	err = q.do(ctx, q.statementRetryable(sql), func() (err error) {
+		rows, err = q.querier.Query(ctx, sql, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRow runs sql when the row is scanned, retrying transient failures
+func (q *RetryQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return &retryRow{querier: q, ctx: ctx, sql: sql, args: args}
+}
+
+// WithTx runs fn in a transaction begun on beginner like the package-level
+// WithTx, and runs the whole transaction again if it fails transiently. fn
+// must be safe to run more than once. A failed commit is retried only if
+// the transaction cannot have been committed, unless RetryWrites is set. A
+// nested call joins the outer transaction and leaves retrying to the
+// outermost call.
+func (q *RetryQuerier) WithTx(ctx context.Context, beginner Beginner, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	if _, nested := ctx.Value(txContextKey{}).(pgx.Tx); nested {
+		return WithTx(ctx, beginner, fn)
+	}
+	retryable := func(err error) bool {
+		return q.writes || !errors.Is(err, errCommitFailed) || !mayHaveApplied(err)
+	}
+	return q.do(ctx, retryable, func() error {
+		return WithTx(ctx, beginner, fn)
+	})
+}
+
+// statementRetryable returns whether a failure of sql may be retried
+func (q *RetryQuerier) statementRetryable(sql string) func(err error) bool {
+	return func(err error) bool {
+		return q.writes || readOnly(sql) || !mayHaveApplied(err)
+	}
+}
+
+// do calls attempt until it succeeds, fails with an error the policy or
+// retryable rejects, or the attempts run out
+func (q *RetryQuerier) do(ctx context.Context, retryable func(err error) bool, attempt func() error) error {
+	for n := 0; ; n++ {
+		if n > 0 {
+			q.retries.Add(1)
+		}
+		err := attempt()
+		if err == nil {
+			if n > 0 {
+				q.recovered.Add(1)
+			}
+			return nil
+		}
+		if !q.policy.ShouldRetry(err, n) || !retryable(err) {
+			if IsTransient(err) && retryable(err) {
+				// Only running out of attempts stopped the retries
+				q.exhausted.Add(1)
+			}
+			return err
+		}
+
+		// Wait for the backoff unless the caller gives up first
+		timer := time.NewTimer(q.policy.GetDelay(n))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// retryRow runs its query when scanned
+type retryRow struct {
+	querier *RetryQuerier
+	ctx     context.Context
+	sql     string
+	args    []any
+}
+
+// Scan runs the query and scans its row, retrying transient failures
+func (r *retryRow) Scan(dest ...any) error {
+	q := r.querier
+	return q.do(r.ctx, q.statementRetryable(r.sql), func() error {
+		return q.querier.QueryRow(r.ctx, r.sql, r.args...).Scan(dest...)
+	})
+}
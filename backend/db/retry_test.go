@@ -0,0 +1,259 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// failingQuerier fails its calls with errs in turn, then succeeds
+type failingQuerier struct {
+	errs  []error
+	calls int
+}
+
+// next returns the error of the next call
+func (q *failingQuerier) next() error {
+	q.calls++
+	if len(q.errs) == 0 {
+		return nil
+	}
+	err := q.errs[0]
+	q.errs = q.errs[1:]
+	return err
+}
+
+func (q *failingQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, q.next()
+}
+
+func (q *failingQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return nil, q.next()
+}
+
+func (q *failingQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return errRow{err: q.next()}
+}
+
+// errRow is a pgx.Row whose Scan fails with err, if not nil
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...any) error { return r.err }
+
+// unsentError is a failure pgx reports as safe to retry, because the
+// statement never reached the server
+type unsentError struct{}
+
+func (unsentError) Error() string     { return "failed to write query" }
+func (unsentError) SafeToRetry() bool { return true }
+
+// pgError returns a server error with the SQLSTATE code
+func pgError(code string) error {
+	return &pgconn.PgError{Code: code}
+}
+
+func TestIsTransient(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", pgError("40001"), true},
+		{"deadlock", pgError("40P01"), true},
+		{"admin shutdown", pgError("57P01"), true},
+		{"cannot connect now", pgError("57P03"), true},
+		{"connection failure", pgError("08006"), true},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"connection refused", syscall.ECONNREFUSED, true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"not sent", unsentError{}, true},
+		{"unique violation", pgError("23505"), false},
+		{"foreign key violation", pgError("23503"), false},
+		{"syntax error", pgError("42601"), false},
+		{"undefined table", pgError("42P01"), false},
+		{"cancelled", context.Canceled, false},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), false},
+		{"no rows", pgx.ErrNoRows, false},
+		{"nil", nil, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsTransient(tc.err); got != tc.want {
+				t.Errorf("IsTransient(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetryQuerierDecisions(t *testing.T) {
+	reset := fmt.Errorf("read: %w", syscall.ECONNRESET)
+	for _, tc := range []struct {
+		name  string
+		sql   string
+		err   error
+		opts  []RetryOption
+		retry bool
+	}{
+		{"serialization failure in a select", "SELECT 1", pgError("40001"), nil, true},
+		{"serialization failure in an insert", "INSERT INTO t VALUES (1)", pgError("40001"), nil, true},
+		{"deadlock in an update", "UPDATE t SET a = 1", pgError("40P01"), nil, true},
+		{"failover", "DELETE FROM t", pgError("57P01"), nil, true},
+		{"unique violation", "INSERT INTO t VALUES (1)", pgError("23505"), nil, false},
+		{"syntax error", "SELEC 1", pgError("42601"), nil, false},
+		{"connection reset in a select", " (select 1)", reset, nil, true},
+		{"connection reset in an insert", "INSERT INTO t VALUES (1)", reset, nil, false},
+		{"connection reset in an insert, opted in", "INSERT INTO t VALUES (1)", reset, []RetryOption{RetryWrites()}, true},
+		{"lost connection in an update", "UPDATE t SET a = 1", io.ErrUnexpectedEOF, nil, false},
+		{"insert never sent", "INSERT INTO t VALUES (1)", unsentError{}, nil, true},
+		{"cancelled", "SELECT 1", context.Canceled, nil, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for method, run := range map[string]func(q *RetryQuerier) error{
+				"Exec": func(q *RetryQuerier) error {
+					_, err := q.Exec(context.Background(), tc.sql)
+					return err
+				},
+				"Query": func(q *RetryQuerier) error {
+					_, err := q.Query(context.Background(), tc.sql)
+					return err
+				},
+				"QueryRow": func(q *RetryQuerier) error {
+					return q.QueryRow(context.Background(), tc.sql).Scan()
+				},
+			} {
+				fake := &failingQuerier{errs: []error{tc.err}}
+				q := NewRetryQuerier(fake, NewRetryPolicy(3), tc.opts...)
+				err := run(q)
+
+				if tc.retry {
+					if err != nil || fake.calls != 2 || q.Stats() != (RetryStats{Retries: 1, Recovered: 1}) {
+						t.Errorf("%s = %v after %d calls with stats %+v, want success on the retry", method, err, fake.calls, q.Stats())
+					}
+				} else if !errors.Is(err, tc.err) || fake.calls != 1 || q.Stats() != (RetryStats{}) {
+					t.Errorf("%s = %v after %d calls with stats %+v, want the error without a retry", method, err, fake.calls, q.Stats())
+				}
+			}
+		})
+	}
+}
+
+func TestRetryQuerierExhausted(t *testing.T) {
+	fake := &failingQuerier{errs: []error{pgError("40001"), pgError("40001"), pgError("40001"), pgError("40001")}}
+	q := NewRetryQuerier(fake, NewRetryPolicyWithConfig(3, time.Millisecond, time.Millisecond, 2))
+
+	if _, err := q.Exec(context.Background(), "UPDATE t SET a = 1"); !IsTransient(err) || fake.calls != 3 {
+		t.Fatalf("Exec = %v after %d calls, want the transient error after 3", err, fake.calls)
+	}
+	if stats := q.Stats(); stats != (RetryStats{Retries: 2, Exhausted: 1}) {
+		t.Errorf("Stats = %+v, want 2 retries and 1 exhausted", stats)
+	}
+}
+
+func TestRetryQuerierStopsWhenCancelled(t *testing.T) {
+	fake := &failingQuerier{errs: []error{pgError("40001"), pgError("40001"), pgError("40001")}}
+	q := NewRetryQuerier(fake, NewRetryPolicyWithConfig(5, time.Hour, time.Hour, 2))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// The first retry is immediate, the second waits an hour
+	start := time.Now()
+	if _, err := q.Exec(ctx, "SELECT 1"); !IsTransient(err) || fake.calls != 2 {
+		t.Fatalf("Exec = %v after %d calls, want the last error after 2", err, fake.calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Exec took %v, want it to stop waiting when the context ended", elapsed)
+	}
+}
+
+func TestRetryPolicyDelays(t *testing.T) {
+	policy := NewRetryPolicyWithConfig(6, 10*time.Millisecond, 50*time.Millisecond, 2)
+	for attempt, want := range []time.Duration{0, 10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond} {
+		if got := policy.GetDelay(attempt); got != want {
+			t.Errorf("GetDelay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+	if NewRetryPolicy(0).MaxAttempts() != 1 {
+		t.Error("a policy must make at least one attempt")
+	}
+}
+
+// sequenceTx is a transaction whose commit fails with commitErr
+type sequenceTx struct {
+	pgx.Tx
+	commitErr error
+	committed bool
+}
+
+func (tx *sequenceTx) Commit(context.Context) error {
+	tx.committed = true
+	return tx.commitErr
+}
+
+func (tx *sequenceTx) Rollback(context.Context) error { return nil }
+
+// sequenceBeginner begins txs in turn
+type sequenceBeginner struct {
+	txs   []*sequenceTx
+	begun int
+}
+
+func (b *sequenceBeginner) Begin(context.Context) (pgx.Tx, error) {
+	tx := b.txs[b.begun]
+	b.begun++
+	return tx, nil
+}
+
+func TestRetryQuerierWithTx(t *testing.T) {
+	q := NewRetryQuerier(&failingQuerier{}, NewRetryPolicy(3))
+	succeed := func(context.Context, pgx.Tx) error { return nil }
+
+	t.Run("transaction rerun after a serialization failure", func(t *testing.T) {
+		beginner := &sequenceBeginner{txs: []*sequenceTx{{}, {}}}
+		runs := 0
+		err := q.WithTx(context.Background(), beginner, func(ctx context.Context, tx pgx.Tx) error {
+			if runs++; runs == 1 {
+				return pgError("40001")
+			}
+			return nil
+		})
+		if err != nil || runs != 2 || !beginner.txs[1].committed {
+			t.Errorf("WithTx = %v after %d runs, want the second run committed", err, runs)
+		}
+	})
+
+	t.Run("commit that may have applied is not retried", func(t *testing.T) {
+		beginner := &sequenceBeginner{txs: []*sequenceTx{{commitErr: io.ErrUnexpectedEOF}, {}}}
+		if err := q.WithTx(context.Background(), beginner, succeed); !errors.Is(err, io.ErrUnexpectedEOF) || beginner.begun != 1 {
+			t.Errorf("WithTx = %v after %d transactions, want the commit error without a retry", err, beginner.begun)
+		}
+	})
+
+	t.Run("commit rejected by the server is retried", func(t *testing.T) {
+		beginner := &sequenceBeginner{txs: []*sequenceTx{{commitErr: pgError("40001")}, {}}}
+		if err := q.WithTx(context.Background(), beginner, succeed); err != nil || beginner.begun != 2 {
+			t.Errorf("WithTx = %v after %d transactions, want success on the second", err, beginner.begun)
+		}
+	})
+
+	t.Run("nested call leaves retrying to the outer call", func(t *testing.T) {
+		beginner := &sequenceBeginner{txs: []*sequenceTx{{}, {}}}
+		innerRuns := 0
+		err := WithTx(context.Background(), beginner, func(ctx context.Context, tx pgx.Tx) error {
+			return q.WithTx(ctx, beginner, func(context.Context, pgx.Tx) error {
+				innerRuns++
+				return pgError("40001")
+			})
+		})
+		if !IsTransient(err) || innerRuns != 1 || beginner.begun != 1 {
+			t.Errorf("WithTx = %v after %d inner runs and %d transactions, want one run", err, innerRuns, beginner.begun)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"GateKeeper/health"
+)
+
+// Pool is a Querier that also begins transactions, e.g. a
+// *configurations.Pool
+type Pool interface {
+	Querier
+	Beginner
+}
+
+// Replica is a read replica and the checker of its health
+type Replica struct {
+	Querier Querier
+	Health  health.Checker
+}
+
+// readOnlyContextKey is the context key under which WithReadOnly marks a context
+type readOnlyContextKey struct{}
+
+// WithReadOnly returns a copy of ctx whose queries only read, so a
+// RoutingPool may run them on a replica, which can lag behind the primary
+func WithReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyContextKey{}, true)
+}
+
+// isReadOnly reports whether ctx was marked by WithReadOnly
+func isReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyContextKey{}).(bool)
+	return readOnly
+}
+
+// RoutingPool sends queries in a context marked by WithReadOnly to its
+// healthy replicas in turn, and every other query to the primary. Reads
+// fall back to the primary while no replica is healthy. Transactions are
+// always begun on the primary, and queries in a context carrying a
+// transaction run in it.
+type RoutingPool struct {
+	primary  Pool
+	replicas []Replica
+	next     atomic.Uint64
+}
+
+// Ensure RoutingPool implements Pool interface
+var _ Pool = (*RoutingPool)(nil)
+
+// NewRoutingPool creates a pool routing to primary and replicas. A replica
+// takes reads once its checker reports it healthy; see RunHealthChecks.
+func NewRoutingPool(primary Pool, replicas ...Replica) *RoutingPool {
+	return &RoutingPool{primary: primary, replicas: replicas}
+}
+
+// Writer returns the primary
+func (p *RoutingPool) Writer() Querier {
+	return p.primary
+}
+
+// Reader returns the next healthy replica, or the primary if none is healthy
+func (p *RoutingPool) Reader() Querier {
+	n := uint64(len(p.replicas))
+	if n == 0 {
+		return p.primary
+	}
+	start := p.next.Add(1)
+	for i := range n {
+		if replica := p.replicas[(start+i)%n]; replica.Health.IsHealthy() {
+			return replica.Querier
+		}
+	}
+	return p.primary
+}
+
+// route returns where to run a query in ctx
+func (p *RoutingPool) route(ctx context.Context) Querier {
+	if tx, ok := ctx.Value(txContextKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	if isReadOnly(ctx) {
+		return p.Reader()
+	}
+	return p.primary
+}
+
+// Exec runs sql where ctx routes it
+func (p *RoutingPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return p.route(ctx).Exec(ctx, sql, args...)
+}
+
+// Query runs sql where ctx routes it
+func (p *RoutingPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return p.route(ctx).Query(ctx, sql, args...)
+}
+
+// QueryRow runs sql where ctx routes it
+func (p *RoutingPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return p.route(ctx).QueryRow(ctx, sql, args...)
+}
+
+// Begin begins a transaction on the primary, even in a read-only context
+func (p *RoutingPool) Begin(ctx context.Context) (pgx.Tx, error) {
+	return p.primary.Begin(ctx)
+}
+
+// RunHealthChecks checks every replica now and then every interval until
+// ctx is done, so Reader sees which are healthy
+func (p *RoutingPool) RunHealthChecks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, replica := range p.replicas {
+			// The checker records the outcome for IsHealthy
+			_ = replica.Health.Check(ctx)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// calls records where queries ran
+type calls []string
+
+func (c *calls) add(name string) { *c = append(*c, name) }
+
+func (c *calls) String() string { return strings.Join(*c, " ") }
+
+// namedQuerier records its name for every query and begins tx
+type namedQuerier struct {
+	name  string
+	calls *calls
+	tx    pgx.Tx
+}
+
+func (q namedQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	q.calls.add(q.name)
+	return pgconn.CommandTag{}, nil
+}
+
+func (q namedQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	q.calls.add(q.name)
+	return nil, nil
+}
+
+func (q namedQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	q.calls.add(q.name)
+	return errRow{}
+}
+
+func (q namedQuerier) Begin(ctx context.Context) (pgx.Tx, error) {
+	q.calls.add("begin " + q.name)
+	return q.tx, nil
+}
+
+// recordingTx records "tx" for every statement run in it
+type recordingTx struct {
+	pgx.Tx
+	calls *calls
+}
+
+func (tx recordingTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tx.calls.add("tx")
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx recordingTx) Commit(context.Context) error   { return nil }
+func (tx recordingTx) Rollback(context.Context) error { return nil }
+
+// fakeHealth is a checker whose health is set by the test
+type fakeHealth struct {
+	up      atomic.Bool
+	checked atomic.Int32
+}
+
+func (h *fakeHealth) Check(ctx context.Context) error {
+	h.checked.Add(1)
+	return nil
+}
+
+func (h *fakeHealth) IsHealthy() bool { return h.up.Load() }
+
+// newRoutingTest returns a pool routing to a primary and two replicas, up,
+// recording their queries in the returned calls
+func newRoutingTest() (*RoutingPool, *calls, *fakeHealth, *fakeHealth) {
+	recorded := &calls{}
+	first, second := &fakeHealth{}, &fakeHealth{}
+	first.up.Store(true)
+	second.up.Store(true)
+	pool := NewRoutingPool(namedQuerier{name: "primary", calls: recorded, tx: recordingTx{calls: recorded}},
+		Replica{Querier: namedQuerier{name: "replica1", calls: recorded}, Health: first},
+		Replica{Querier: namedQuerier{name: "replica2", calls: recorded}, Health: second})
+	return pool, recorded, first, second
+}
+
+func TestRoutingPoolRoutesReads(t *testing.T) {
+	pool, recorded, _, _ := newRoutingTest()
+	readOnly := WithReadOnly(context.Background())
+
+	pool.Exec(readOnly, "SELECT 1")
+	pool.Query(readOnly, "SELECT 1")
+	pool.QueryRow(readOnly, "SELECT 1").Scan()
+	pool.Exec(readOnly, "SELECT 1")
+	if got := recorded.String(); got != "replica2 replica1 replica2 replica1" {
+		t.Errorf("reads ran on %s, want them spread over both replicas", got)
+	}
+
+	*recorded = nil
+	pool.Exec(context.Background(), "UPDATE users SET is_active = false")
+	pool.Query(context.Background(), "SELECT 1")
+	if got := recorded.String(); got != "primary primary" {
+		t.Errorf("unmarked queries ran on %s, want the primary", got)
+	}
+	if pool.Writer() != pool.primary {
+		t.Error("Writer does not return the primary")
+	}
+}
+
+func TestRoutingPoolFallsBack(t *testing.T) {
+	pool, recorded, first, second := newRoutingTest()
+	readOnly := WithReadOnly(context.Background())
+
+	first.up.Store(false)
+	pool.Exec(readOnly, "SELECT 1")
+	pool.Exec(readOnly, "SELECT 1")
+	if got := recorded.String(); got != "replica2 replica2" {
+		t.Errorf("reads ran on %s, want only the healthy replica", got)
+	}
+
+	*recorded = nil
+	second.up.Store(false)
+	pool.Exec(readOnly, "SELECT 1")
+	if got := recorded.String(); got != "primary" {
+		t.Errorf("reads with every replica down ran on %s, want the primary", got)
+	}
+
+	*recorded = nil
+	first.up.Store(true)
+	pool.Exec(readOnly, "SELECT 1")
+	if got := recorded.String(); got != "replica1" {
+		t.Errorf("reads after recovery ran on %s, want the recovered replica", got)
+	}
+
+	noReplicas := NewRoutingPool(pool.primary)
+	if noReplicas.Reader() != pool.primary {
+		t.Error("Reader without replicas does not return the primary")
+	}
+}
+
+func TestRoutingPoolPinsTransactions(t *testing.T) {
+	pool, recorded, _, _ := newRoutingTest()
+
+	// Even a read-only transaction runs on the primary
+	err := WithTx(WithReadOnly(context.Background()), pool, func(ctx context.Context, tx pgx.Tx) error {
+		if _, err := pool.Exec(ctx, "SELECT 1"); err != nil {
+			return err
+		}
+		_, err := pool.Exec(ctx, "UPDATE users SET is_active = true")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if got := recorded.String(); got != "begin primary tx tx" {
+		t.Errorf("calls = %s, want the transaction begun on the primary and every statement in it", got)
+	}
+}
+
+func TestRoutingPoolRunHealthChecks(t *testing.T) {
+	pool, _, first, second := newRoutingTest()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The replicas are checked once before the cancelled context stops the loop
+	pool.RunHealthChecks(ctx, time.Hour)
+	if first.checked.Load() != 1 || second.checked.Load() != 1 {
+		t.Errorf("replicas checked %d and %d times, want once each", first.checked.Load(), second.checked.Load())
+	}
+}
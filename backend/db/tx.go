@@ -0,0 +1,75 @@
+// Package db runs multi-step database work in transactions that the
+// Postgres repositories pick up from the context. The in-memory
+// repositories have no transactions and ignore it.
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Querier runs queries. It is satisfied by *pgx.Conn, *pgxpool.Pool,
+// *configurations.Pool and pgx.Tx.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Beginner starts transactions, e.g. a *pgxpool.Pool
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// errCommitFailed wraps the error of a failed commit
+var errCommitFailed = errors.New("failed to commit transaction")
+
+// txContextKey is the context key under which WithTx stores its transaction
+type txContextKey struct{}
+
+// WithTx runs fn in a transaction begun on beginner, committing if fn
+// returns nil and rolling back if it fails or panics; a panic is re-raised
+// after the rollback. The ctx passed to fn carries the transaction, so
+// repository calls made with it join the transaction. Nested calls reuse
+// the outer transaction, which the outermost call commits or rolls back.
+func WithTx(ctx context.Context, beginner Beginner, fn func(ctx context.Context, tx pgx.Tx) error) (err error) {
+	if tx, ok := ctx.Value(txContextKey{}).(pgx.Tx); ok {
+		return fn(ctx, tx)
+	}
+
+	tx, err := beginner.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+			panic(p)
+		}
+		if err != nil {
+			if rollbackErr := tx.Rollback(context.WithoutCancel(ctx)); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+				err = errors.Join(err, fmt.Errorf("failed to roll back transaction: %w", rollbackErr))
+			}
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txContextKey{}, tx), tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: %w", errCommitFailed, err)
+	}
+	return nil
+}
+
+// From returns the transaction in ctx, if WithTx started one, or fallback
+func From(ctx context.Context, fallback Querier) Querier {
+	if tx, ok := ctx.Value(txContextKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return fallback
+}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeTable is a table of rows that transactions insert into on commit
+type fakeTable struct {
+	rows []string
+}
+
+// fakeTx buffers its inserts until it is committed
+type fakeTx struct {
+	pgx.Tx
+	table      *fakeTable
+	pending    []string
+	commitErr  error
+	committed  int
+	rolledBack int
+}
+
+// Exec inserts its argument
+func (tx *fakeTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tx.pending = append(tx.pending, args[0].(string))
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	tx.committed++
+	if tx.commitErr != nil {
+		return tx.commitErr
+	}
+	tx.table.rows = append(tx.table.rows, tx.pending...)
+	tx.pending = nil
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	if tx.committed > 0 {
+		return pgx.ErrTxClosed
+	}
+	tx.rolledBack++
+	tx.pending = nil
+	return nil
+}
+
+// fakeBeginner begins tx
+type fakeBeginner struct {
+	tx    *fakeTx
+	err   error
+	begun int
+}
+
+func (b *fakeBeginner) Begin(ctx context.Context) (pgx.Tx, error) {
+	b.begun++
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.tx, nil
+}
+
+// newFakeBeginner returns a beginner of transactions inserting into a new table
+func newFakeBeginner() (*fakeBeginner, *fakeTable) {
+	table := &fakeTable{}
+	return &fakeBeginner{tx: &fakeTx{table: table}}, table
+}
+
+// insert inserts row with the querier in ctx
+func insert(ctx context.Context, row string) error {
+	_, err := From(ctx, nil).Exec(ctx, "INSERT INTO rows VALUES ($1)", row)
+	return err
+}
+
+func TestWithTxCommits(t *testing.T) {
+	beginner, table := newFakeBeginner()
+
+	err := WithTx(context.Background(), beginner, func(ctx context.Context, tx pgx.Tx) error {
+		if From(ctx, nil) != tx {
+			t.Error("From does not return the transaction in ctx")
+		}
+		if err := insert(ctx, "user"); err != nil {
+			return err
+		}
+		return insert(ctx, "audit event")
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if !slices.Equal(table.rows, []string{"user", "audit event"}) || beginner.tx.committed != 1 || beginner.tx.rolledBack != 0 {
+		t.Errorf("rows = %v after %d commits and %d rollbacks, want both rows committed once", table.rows, beginner.tx.committed, beginner.tx.rolledBack)
+	}
+}
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	beginner, table := newFakeBeginner()
+	failure := errors.New("verification token failed")
+
+	err := WithTx(context.Background(), beginner, func(ctx context.Context, tx pgx.Tx) error {
+		if err := insert(ctx, "user"); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithTx = %v, want the second step's error", err)
+	}
+	// The first step's row is gone with the transaction
+	if len(table.rows) != 0 || beginner.tx.committed != 0 || beginner.tx.rolledBack != 1 {
+		t.Errorf("rows = %v after %d commits and %d rollbacks, want none committed and one rollback", table.rows, beginner.tx.committed, beginner.tx.rolledBack)
+	}
+}
+
+func TestWithTxRollsBackOnPanic(t *testing.T) {
+	beginner, table := newFakeBeginner()
+
+	defer func() {
+		if p := recover(); p != "step two panicked" {
+			t.Fatalf("recovered %v, want the panic re-raised", p)
+		}
+		if len(table.rows) != 0 || beginner.tx.committed != 0 || beginner.tx.rolledBack != 1 {
+			t.Errorf("rows = %v after %d commits and %d rollbacks, want a rollback only", table.rows, beginner.tx.committed, beginner.tx.rolledBack)
+		}
+	}()
+	_ = WithTx(context.Background(), beginner, func(ctx context.Context, tx pgx.Tx) error {
+		if err := insert(ctx, "user"); err != nil {
+			return err
+		}
+		panic("step two panicked")
+	})
+}
+
+func TestWithTxNested(t *testing.T) {
+	beginner, table := newFakeBeginner()
+	failure := errors.New("outer step failed")
+
+	err := WithTx(context.Background(), beginner, func(ctx context.Context, outer pgx.Tx) error {
+		err := WithTx(ctx, beginner, func(ctx context.Context, inner pgx.Tx) error {
+			if inner != outer {
+				t.Error("the nested call began a transaction of its own")
+			}
+			return insert(ctx, "user")
+		})
+		if err != nil {
+			return err
+		}
+		// The nested call did not commit
+		if beginner.tx.committed != 0 {
+			t.Error("the nested call committed the outer transaction")
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) || beginner.begun != 1 || len(table.rows) != 0 || beginner.tx.rolledBack != 1 {
+		t.Errorf("WithTx = %v with %d begun and rows %v, want one rolled back transaction", err, beginner.begun, table.rows)
+	}
+}
+
+func TestWithTxFailures(t *testing.T) {
+	beginFailure := errors.New("connection refused")
+	beginner := &fakeBeginner{err: beginFailure}
+	called := false
+	err := WithTx(context.Background(), beginner, func(context.Context, pgx.Tx) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, beginFailure) || called {
+		t.Errorf("WithTx with a failing Begin = %v, called %v; want the error without calling fn", err, called)
+	}
+
+	beginner, table := newFakeBeginner()
+	commitFailure := errors.New("serialization failure")
+	beginner.tx.commitErr = commitFailure
+	err = WithTx(context.Background(), beginner, func(ctx context.Context, tx pgx.Tx) error {
+		return insert(ctx, "user")
+	})
+	if !errors.Is(err, commitFailure) || !errors.Is(err, errCommitFailed) || len(table.rows) != 0 {
+		t.Errorf("WithTx with a failing Commit = %v, rows %v; want the commit error and no rows", err, table.rows)
+	}
+}
+
+func TestFrom(t *testing.T) {
+	beginner, _ := newFakeBeginner()
+	fallback := &fakeTx{}
+	if From(context.Background(), fallback) != fallback {
+		t.Error("From without a transaction does not return the fallback")
+	}
+	_ = WithTx(context.Background(), beginner, func(ctx context.Context, tx pgx.Tx) error {
+		if From(ctx, fallback) != tx {
+			t.Error("From in WithTx does not return the transaction")
+		}
+		return nil
+	})
+}
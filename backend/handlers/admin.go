@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"GateKeeper/auth"
+	"GateKeeper/models"
+)
+
+// LockoutStatus handles GET /admin/lockouts/{email}
+func (h *AuthHandler) LockoutStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.GetLockoutStatus(requestContext(r), r.PathValue("email"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// UnlockAccount handles DELETE /admin/lockouts/{email}
+func (h *AuthHandler) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	admin, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.WriteProblem(w, http.StatusUnauthorized, "missing_token", "authentication required")
+		return
+	}
+
+	if err := h.service.UnlockAccount(requestContext(r), r.PathValue("email"), admin.ID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SearchUsers handles GET /admin/users?email=&username=&created_after=&created_before=&active=&role=
+// with the paging and sorting parameters of GET /users. Dates are RFC 3339
+// timestamps or YYYY-MM-DD days.
+func (h *AuthHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, err := queryInt(r, "limit", 0)
+	if err != nil {
+		auth.WriteProblem(w, http.StatusBadRequest, "validation_failed", "limit must be an integer")
+		return
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		auth.WriteProblem(w, http.StatusBadRequest, "validation_failed", "offset must be an integer")
+		return
+	}
+	createdAfter, err := queryTime(r, "created_after")
+	if err != nil {
+		auth.WriteProblem(w, http.StatusBadRequest, "validation_failed", "created_after must be a date or RFC 3339 timestamp")
+		return
+	}
+	createdBefore, err := queryTime(r, "created_before")
+	if err != nil {
+		auth.WriteProblem(w, http.StatusBadRequest, "validation_failed", "created_before must be a date or RFC 3339 timestamp")
+		return
+	}
+	var active *bool
+	if raw := query.Get("active"); raw != "" {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			auth.WriteProblem(w, http.StatusBadRequest, "validation_failed", "active must be true or false")
+			return
+		}
+		active = &value
+	}
+
+	page, err := h.service.SearchUsers(requestContext(r), models.SearchParams{
+		ListUsersParams: models.ListUsersParams{
+			Limit:         limit,
+			Offset:        offset,
+			SortBy:        query.Get("sort"),
+			SortDir:       query.Get("dir"),
+			EmailContains: query.Get("email"),
+		},
+		UsernameContains: query.Get("username"),
+		CreatedAfter:     createdAfter,
+		CreatedBefore:    createdBefore,
+		Active:           active,
+		Role:             query.Get("role"),
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// ListDeletedUsers handles GET /admin/users/deleted
+func (h *AuthHandler) ListDeletedUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.service.ListDeletedUsers(requestContext(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+// RestoreUser handles POST /admin/users/{id}/restore
+func (h *AuthHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		auth.WriteProblem(w, http.StatusBadRequest, "validation_failed", "id must be an integer")
+		return
+	}
+
+	if err := h.service.RestoreUser(requestContext(r), userID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// queryTime parses a date or RFC 3339 timestamp query parameter, returning
+// the zero time if it is absent
+func queryTime(r *http.Request, key string) (time.Time, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if day, err := time.Parse(time.DateOnly, raw); err == nil {
+		return day, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
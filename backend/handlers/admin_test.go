@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"GateKeeper/services"
+)
+
+func TestAdminLockouts(t *testing.T) {
+	config := services.DefaultLoginLimitConfig()
+	config.MaxAttempts = 0
+	config.LockoutThreshold = 3
+	s := newTestServer(t, services.WithLoginLimits(config, nil))
+
+	adminToken := s.admin("admin@example.com", "admin")
+	s.register("user@example.com", "user")
+	userToken := s.login("user@example.com", "secret1")
+
+	for range 3 {
+		s.do(http.MethodPost, "/auth/login", "", `{"email":"user@example.com","password":"wrong12"}`)
+	}
+	if status, _ := s.do(http.MethodPost, "/auth/login", "", `{"email":"user@example.com","password":"secret1"}`); status != http.StatusTooManyRequests {
+		t.Fatalf("login while locked: status %d, want 429", status)
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		if status, _ := s.do(method, "/admin/lockouts/user@example.com", userToken, ""); status != http.StatusForbidden {
+			t.Errorf("%s as a user: status %d, want 403", method, status)
+		}
+	}
+
+	status, body := s.do(http.MethodGet, "/admin/lockouts/User@Example.com", adminToken, "")
+	if status != http.StatusOK {
+		t.Fatalf("lockout status: status %d, body %v", status, body)
+	}
+	if body["locked_until"] == nil || body["lockouts"] != 1.0 {
+		t.Errorf("lockout status = %v, want one lockout in force", body)
+	}
+	if attempts, _ := body["recent_attempts"].([]any); len(attempts) != 3 {
+		t.Errorf("recent attempts = %v, want 3", body["recent_attempts"])
+	}
+
+	if status, _ := s.do(http.MethodDelete, "/admin/lockouts/user@example.com", adminToken, ""); status != http.StatusNoContent {
+		t.Fatalf("unlock: status %d, want 204", status)
+	}
+	s.login("user@example.com", "secret1")
+
+	// Unlocking leaves the password and the tokens issued before alone
+	if status, _ := s.do(http.MethodGet, "/auth/me", userToken, ""); status != http.StatusOK {
+		t.Errorf("earlier token after unlock: status %d, want 200", status)
+	}
+}
+
+func TestAdminSearchUsers(t *testing.T) {
+	s := newTestServer(t)
+	adminToken := s.admin("admin@example.com", "admin")
+	s.register("ada@example.com", "ada")
+	bob := s.register("bob@example.org", "bob")
+	userToken := s.login("ada@example.com", "secret1")
+	if err := s.service.DeactivateUser(context.Background(), bob); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		query string
+		want  string
+	}{
+		{"", "admin ada bob"},
+		{"?email=EXAMPLE.COM", "admin ada"},
+		{"?username=b", "bob"},
+		{"?role=admin", "admin"},
+		{"?active=false", "bob"},
+		{"?role=user&active=true&created_after=2020-01-01&email=A", "ada"},
+		{"?created_before=2020-01-01T00:00:00Z", ""},
+		{"?email=" + url.QueryEscape("' OR '1'='1"), ""},
+		{"?limit=1&offset=1&sort=username", "admin"},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			status, body := s.do(http.MethodGet, "/admin/users"+tc.query, adminToken, "")
+			if status != http.StatusOK {
+				t.Fatalf("status %d, body %v", status, body)
+			}
+			items, _ := body["items"].([]any)
+			var names []string
+			for _, item := range items {
+				names = append(names, item.(map[string]any)["username"].(string))
+			}
+			if got := strings.Join(names, " "); got != tc.want {
+				t.Errorf("users = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	for _, query := range []string{"?active=maybe", "?role=root", "?created_after=yesterday", "?created_after=2030-01-01&created_before=2020-01-01", "?limit=x"} {
+		if status, _ := s.do(http.MethodGet, "/admin/users"+query, adminToken, ""); status != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, status)
+		}
+	}
+	if status, _ := s.do(http.MethodGet, "/admin/users", userToken, ""); status != http.StatusForbidden {
+		t.Errorf("as a user: status %d, want 403", status)
+	}
+}
+
+func TestAdminRestoreUser(t *testing.T) {
+	s := newTestServer(t)
+	adminToken := s.admin("admin@example.com", "admin")
+	id := s.register("user@example.com", "user")
+	userToken := s.login("user@example.com", "secret1")
+
+	if status, _ := s.do(http.MethodDelete, "/auth/me", userToken, `{"password":"secret1"}`); status != http.StatusNoContent {
+		t.Fatalf("delete: status %d, want 204", status)
+	}
+	if status, _ := s.do(http.MethodPost, "/auth/login", "", `{"email":"user@example.com","password":"secret1"}`); status != http.StatusUnauthorized {
+		t.Errorf("login after delete: status %d, want 401", status)
+	}
+	if status, _ := s.do(http.MethodGet, "/admin/users/deleted", adminToken, ""); status != http.StatusOK {
+		t.Errorf("list deleted: status %d, want 200", status)
+	}
+
+	restore := fmt.Sprintf("/admin/users/%d/restore", id)
+	s.register("other@example.com", "other")
+	if status, _ := s.do(http.MethodPost, restore, s.login("other@example.com", "secret1"), ""); status != http.StatusForbidden {
+		t.Errorf("restore as a user: status %d, want 403", status)
+	}
+	if status, _ := s.do(http.MethodPost, "/admin/users/x/restore", adminToken, ""); status != http.StatusBadRequest {
+		t.Errorf("restore with a bad id: status %d, want 400", status)
+	}
+	if status, _ := s.do(http.MethodPost, restore, adminToken, ""); status != http.StatusNoContent {
+		t.Fatalf("restore: status %d, want 204", status)
+	}
+	s.login("user@example.com", "secret1")
+	if status, _ := s.do(http.MethodPost, restore, adminToken, ""); status != http.StatusNotFound {
+		t.Errorf("restoring again: status %d, want 404", status)
+	}
+}
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"GateKeeper/auth"
+	"GateKeeper/configurations"
+	"GateKeeper/models"
+	"GateKeeper/services"
+	"GateKeeper/validation"
+)
+
+// maxBodyBytes caps the size of JSON request bodies
+const maxBodyBytes = 1 << 20
+
+// statusClientClosedRequest is the non-standard status for requests the
+// client abandoned
+const statusClientClosedRequest = 499
+
+// AuthHandler exposes AuthService over HTTP
+type AuthHandler struct {
+	service    *services.AuthService
+	middleware *auth.Middleware
+	sessions   *auth.SessionMiddleware
+}
+
+// NewAuthHandler creates an AuthHandler for service with the default session cookie
+func NewAuthHandler(service *services.AuthService) *AuthHandler {
+	return NewAuthHandlerWithConfig(service, auth.DefaultCookieConfig())
+}
+
+// NewAuthHandlerWithConfig creates an AuthHandler for service whose session
+// cookie has the attributes in cookie
+func NewAuthHandlerWithConfig(service *services.AuthService, cookie auth.CookieConfig) *AuthHandler {
+	return &AuthHandler{
+		service:    service,
+		middleware: auth.NewMiddleware(service),
+		sessions:   auth.NewSessionMiddleware(service, cookie),
+	}
+}
+
+// Routes returns the router serving the auth API:
+//
+//	POST   /auth/register             register a user
+//	POST   /auth/login                log in, returning access and refresh tokens
+//	POST   /auth/refresh              exchange a refresh token for a new pair
+//	GET    /auth/me                   the authenticated user (protected)
+//	PATCH  /auth/me                   update the authenticated user's profile (protected)
+//	DELETE /auth/me                   delete the authenticated user's account (protected)
+//	POST   /auth/password             change the password (protected)
+//	POST   /auth/sessions             log in, starting a cookie session
+//	GET    /auth/sessions/current     the session's user (session cookie)
+//	DELETE /auth/sessions/current     log out, ending the cookie session
+//	POST   /auth/api-keys             create an API key, returning it once (protected)
+//	GET    /auth/api-keys             list the user's API keys (protected)
+//	DELETE /auth/api-keys/{id}        revoke an API key (protected)
+//	GET    /users                     list users, paged, sorted and filtered (protected)
+//	GET    /admin/users               search users by email, username, creation, status and role (admin)
+//	GET    /admin/users/deleted       deleted users not yet purged (admin)
+//	POST   /admin/users/{id}/restore  restore a deleted user (admin)
+//	GET    /admin/lockouts/{email}    an account's failed logins and lockout (admin)
+//	DELETE /admin/lockouts/{email}    unlock a locked account (admin)
+func (h *AuthHandler) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /auth/register", h.Register)
+	mux.HandleFunc("POST /auth/login", h.Login)
+	mux.HandleFunc("POST /auth/refresh", h.Refresh)
+	mux.Handle("GET /auth/me", h.middleware.RequireAuth(http.HandlerFunc(h.Me)))
+	mux.Handle("PATCH /auth/me", h.middleware.RequireAuth(http.HandlerFunc(h.UpdateMe)))
+	mux.Handle("DELETE /auth/me", h.middleware.RequireAuth(http.HandlerFunc(h.DeleteMe)))
+	mux.Handle("POST /auth/password", h.middleware.RequireAuth(http.HandlerFunc(h.ChangePassword)))
+	mux.HandleFunc("POST /auth/sessions", h.CreateSession)
+	mux.Handle("GET /auth/sessions/current", h.sessions.RequireSession(http.HandlerFunc(h.Me)))
+	mux.HandleFunc("DELETE /auth/sessions/current", h.DeleteSession)
+	mux.Handle("POST /auth/api-keys", h.middleware.RequireAuth(http.HandlerFunc(h.CreateAPIKey)))
+	mux.Handle("GET /auth/api-keys", h.middleware.RequireAuth(http.HandlerFunc(h.ListAPIKeys)))
+	mux.Handle("DELETE /auth/api-keys/{id}", h.middleware.RequireAuth(http.HandlerFunc(h.RevokeAPIKey)))
+	mux.Handle("GET /users", h.middleware.RequireAuth(http.HandlerFunc(h.ListUsers)))
+	mux.Handle("GET /admin/users", h.middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(h.SearchUsers)))
+	mux.Handle("GET /admin/users/deleted", h.middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(h.ListDeletedUsers)))
+	mux.Handle("POST /admin/users/{id}/restore", h.middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(h.RestoreUser)))
+	mux.Handle("GET /admin/lockouts/{email}", h.middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(h.LockoutStatus)))
+	mux.Handle("DELETE /admin/lockouts/{email}", h.middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(h.UnlockAccount)))
+	return mux
+}
+
+// Register handles POST /auth/register
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateUserRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	user, err := h.service.CreateUser(requestContext(r), req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, user)
+}
+
+// Login handles POST /auth/login
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	response, err := h.service.LoginUser(requestContext(r), req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// CreateSession handles POST /auth/sessions, sending the session ID as a cookie
+func (h *AuthHandler) CreateSession(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	session, err := h.service.CreateSession(requestContext(r), req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	h.sessions.SetCookie(w, session.SessionID, session.ExpiresAt)
+	writeJSON(w, http.StatusCreated, session)
+}
+
+// DeleteSession handles DELETE /auth/sessions/current. It succeeds even
+// without a live session, so logging out is idempotent.
+func (h *AuthHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	if sessionID := h.sessions.SessionID(r); sessionID != "" {
+		if err := h.service.DeleteSession(requestContext(r), sessionID); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	h.sessions.ClearCookie(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Refresh handles POST /auth/refresh
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	response, err := h.service.RefreshToken(requestContext(r), req.RefreshToken)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Me handles GET /auth/me
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.WriteProblem(w, http.StatusUnauthorized, "missing_token", "authentication required")
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+// UpdateMe handles PATCH /auth/me; omitted fields are left unchanged
+func (h *AuthHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.WriteProblem(w, http.StatusUnauthorized, "missing_token", "authentication required")
+		return
+	}
+
+	var req models.UpdateUserRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	updated, err := h.service.UpdateUser(requestContext(r), user.ID, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteMe handles DELETE /auth/me, which requires the password in the body
+func (h *AuthHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.WriteProblem(w, http.StatusUnauthorized, "missing_token", "authentication required")
+		return
+	}
+
+	var req models.DeleteUserRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	err := h.service.DeleteUser(requestContext(r), user.ID, req.Password)
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		auth.WriteProblem(w, http.StatusForbidden, "invalid_credentials", "password is incorrect")
+		return
+	} else if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangePassword handles POST /auth/password. All sessions, including the
+// caller's, are signed out on success.
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.WriteProblem(w, http.StatusUnauthorized, "missing_token", "authentication required")
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	err := h.service.ChangePassword(requestContext(r), user.ID, req.CurrentPassword, req.NewPassword)
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		auth.WriteProblem(w, http.StatusForbidden, "invalid_credentials", "current password is incorrect")
+		return
+	} else if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateAPIKey handles POST /auth/api-keys
+func (h *AuthHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.WriteProblem(w, http.StatusUnauthorized, "missing_token", "authentication required")
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	key, err := h.service.CreateAPIKey(requestContext(r), user.ID, req.Name, req.Scopes, ttl)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, key)
+}
+
+// ListAPIKeys handles GET /auth/api-keys
+func (h *AuthHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.WriteProblem(w, http.StatusUnauthorized, "missing_token", "authentication required")
+		return
+	}
+
+	keys, err := h.service.ListAPIKeys(requestContext(r), user.ID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// RevokeAPIKey handles DELETE /auth/api-keys/{id}
+func (h *AuthHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.WriteProblem(w, http.StatusUnauthorized, "missing_token", "authentication required")
+		return
+	}
+
+	keyID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		auth.WriteProblem(w, http.StatusBadRequest, "validation_failed", "id must be an integer")
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(requestContext(r), user.ID, keyID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListUsers handles GET /users?limit=&offset=&sort=&dir=&active=&email=
+func (h *AuthHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, err := queryInt(r, "limit", 0)
+	if err != nil {
+		auth.WriteProblem(w, http.StatusBadRequest, "validation_failed", "limit must be an integer")
+		return
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		auth.WriteProblem(w, http.StatusBadRequest, "validation_failed", "offset must be an integer")
+		return
+	}
+
+	page, err := h.service.ListUsers(requestContext(r), models.ListUsersParams{
+		Limit:         limit,
+		Offset:        offset,
+		SortBy:        query.Get("sort"),
+		SortDir:       query.Get("dir"),
+		ActiveOnly:    query.Get("active") == "true",
+		EmailContains: query.Get("email"),
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// writeServiceError maps an AuthService error to a problem document
+func writeServiceError(w http.ResponseWriter, err error) {
+	var invalid *validation.Error
+	switch {
+	case errors.As(err, &invalid):
+		auth.WriteValidationProblem(w, invalid)
+	case errors.Is(err, services.ErrRequestCancelled) && errors.Is(err, context.DeadlineExceeded):
+		auth.WriteProblem(w, http.StatusGatewayTimeout, "request_timeout", "request took too long")
+	case errors.Is(err, services.ErrRequestCancelled):
+		// The client has gone; nginx's 499 records that in access logs
+		auth.WriteProblem(w, statusClientClosedRequest, "request_cancelled", err.Error())
+	case errors.Is(err, services.ErrUserExists):
+		auth.WriteProblem(w, http.StatusConflict, "user_exists", err.Error())
+	case errors.Is(err, services.ErrUsernameTaken):
+		auth.WriteProblem(w, http.StatusConflict, "username_taken", err.Error())
+	case errors.Is(err, services.ErrUpdateConflict):
+		auth.WriteProblem(w, http.StatusConflict, "update_conflict", err.Error())
+	case errors.Is(err, services.ErrInvalidCredentials):
+		auth.WriteProblem(w, http.StatusUnauthorized, "invalid_credentials", err.Error())
+	case errors.Is(err, services.ErrTooManyAttempts):
+		var limited *services.TooManyAttemptsError
+		if errors.As(err, &limited) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		}
+		auth.WriteProblem(w, http.StatusTooManyRequests, "too_many_attempts", err.Error())
+	case errors.Is(err, services.ErrWeakPassword), errors.Is(err, services.ErrPasswordReused),
+		errors.Is(err, services.ErrInvalidEmail), errors.Is(err, services.ErrInvalidUsername),
+		errors.Is(err, services.ErrInvalidListParams), errors.Is(err, services.ErrInvalidAPIKeyRequest),
+		errors.Is(err, services.ErrInvalidRole):
+		auth.WriteProblem(w, http.StatusBadRequest, "validation_failed", err.Error())
+	case errors.Is(err, services.ErrUserDeactivated):
+		auth.WriteProblem(w, http.StatusForbidden, "user_deactivated", err.Error())
+	case errors.Is(err, services.ErrAPIKeyNotFound):
+		auth.WriteProblem(w, http.StatusNotFound, "api_key_not_found", err.Error())
+	case errors.Is(err, services.ErrUserNotFound):
+		auth.WriteProblem(w, http.StatusNotFound, "user_not_found", err.Error())
+	case errors.Is(err, services.ErrInvalidSession):
+		auth.WriteProblem(w, http.StatusUnauthorized, "invalid_session", "session is invalid or has expired")
+	case errors.Is(err, services.ErrRefreshTokenExpired):
+		auth.WriteProblem(w, http.StatusUnauthorized, "expired_refresh_token", err.Error())
+	case errors.Is(err, services.ErrInvalidRefreshToken), errors.Is(err, services.ErrRefreshTokenReused):
+		auth.WriteProblem(w, http.StatusUnauthorized, "invalid_refresh_token", "refresh token is invalid")
+	case errors.Is(err, configurations.ErrAcquireTimeout):
+		auth.WriteProblem(w, http.StatusServiceUnavailable, "database_busy", "the database is busy, try again later")
+	default:
+		auth.WriteProblem(w, http.StatusInternalServerError, "internal_error", "internal server error")
+	}
+}
+
+// requestContext returns r's context carrying the client's IP address and
+// user agent and the authenticated user, for login throttling and auditing
+func requestContext(r *http.Request) context.Context {
+	ctx := services.WithClientKey(r.Context(), clientIP(r))
+	ctx = services.WithUserAgent(ctx, r.UserAgent())
+	if user, ok := auth.UserFromContext(ctx); ok {
+		ctx = services.WithActor(ctx, user.ID)
+	}
+	return ctx
+}
+
+// clientIP returns the IP address of the client that sent r
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// decodeJSON decodes the request body into v and checks its validate tags,
+// writing a 400 on failure
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		auth.WriteProblem(w, http.StatusBadRequest, "invalid_body", "request body must be a valid JSON object")
+		return false
+	}
+
+	var invalid *validation.Error
+	if err := validation.Struct(v); errors.As(err, &invalid) {
+		auth.WriteValidationProblem(w, invalid)
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// queryInt parses an integer query parameter, returning def if it is absent
+func queryInt(r *http.Request, key string, def int) (int, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return def, nil
+	}
+	return strconv.Atoi(raw)
+}
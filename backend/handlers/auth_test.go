@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// problemType returns the type of a decoded problem document
+func problemType(body map[string]any) string {
+	problemType, _ := body["type"].(string)
+	return problemType
+}
+
+func TestRegister(t *testing.T) {
+	s := newTestServer(t)
+
+	status, body := s.do(http.MethodPost, "/auth/register", "", `{"email":"ada@example.com","username":"ada","password":"secret1"}`)
+	if status != http.StatusCreated {
+		t.Fatalf("register: status %d, body %v", status, body)
+	}
+	if body["email"] != "ada@example.com" || body["username"] != "ada" || body["id"] == nil {
+		t.Errorf("register returned %v, want the created user", body)
+	}
+	if _, ok := body["password"]; ok {
+		t.Error("register returned the password")
+	}
+
+	for _, tc := range []struct {
+		name    string
+		body    string
+		status  int
+		problem string
+	}{
+		{"duplicate email", `{"email":"ADA@example.com","username":"ada2","password":"secret1"}`, http.StatusConflict, "urn:gatekeeper:user_exists"},
+		{"invalid email", `{"email":"not-an-email","username":"bob","password":"secret1"}`, http.StatusBadRequest, "urn:gatekeeper:validation_failed"},
+		{"missing password", `{"email":"bob@example.com","username":"bob"}`, http.StatusBadRequest, "urn:gatekeeper:validation_failed"},
+		{"malformed JSON", `{"email":`, http.StatusBadRequest, "urn:gatekeeper:invalid_body"},
+		{"unknown field", `{"email":"bob@example.com","username":"bob","password":"secret1","admin":true}`, http.StatusBadRequest, "urn:gatekeeper:invalid_body"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			status, body := s.do(http.MethodPost, "/auth/register", "", tc.body)
+			if status != tc.status || problemType(body) != tc.problem {
+				t.Errorf("status %d, problem %q; want %d %q", status, problemType(body), tc.status, tc.problem)
+			}
+			if body["status"] != float64(tc.status) || body["title"] == "" {
+				t.Errorf("problem document = %v, want the status and a title", body)
+			}
+		})
+	}
+}
+
+func TestLoginAndMe(t *testing.T) {
+	s := newTestServer(t)
+	id := s.register("ada@example.com", "ada")
+
+	status, body := s.do(http.MethodPost, "/auth/login", "", `{"email":"ada@example.com","password":"secret1"}`)
+	if status != http.StatusOK {
+		t.Fatalf("login: status %d, body %v", status, body)
+	}
+	token, _ := body["access_token"].(string)
+	if token == "" || body["token_type"] != "Bearer" || body["refresh_token"] == "" || body["expires_at"] == nil {
+		t.Fatalf("login returned %v, want access and refresh tokens", body)
+	}
+	if user, _ := body["user"].(map[string]any); user["id"] != float64(id) {
+		t.Errorf("login user = %v, want id %d", body["user"], id)
+	}
+
+	if status, body := s.do(http.MethodPost, "/auth/login", "", `{"email":"ada@example.com","password":"wrong12"}`); status != http.StatusUnauthorized ||
+		problemType(body) != "urn:gatekeeper:invalid_credentials" {
+		t.Errorf("wrong password: status %d, body %v; want 401 invalid_credentials", status, body)
+	}
+	if status, _ := s.do(http.MethodPost, "/auth/login", "", `{"email":"nobody@example.com","password":"secret1"}`); status != http.StatusUnauthorized {
+		t.Errorf("unknown email: status %d, want 401", status)
+	}
+
+	status, body = s.do(http.MethodGet, "/auth/me", token, "")
+	if status != http.StatusOK || body["email"] != "ada@example.com" {
+		t.Errorf("me: status %d, body %v; want the logged in user", status, body)
+	}
+	if status, body := s.do(http.MethodGet, "/auth/me", "", ""); status != http.StatusUnauthorized || problemType(body) != "urn:gatekeeper:missing_token" {
+		t.Errorf("me without a token: status %d, body %v; want 401 missing_token", status, body)
+	}
+	if status, _ := s.do(http.MethodGet, "/auth/me", "junk", ""); status != http.StatusUnauthorized {
+		t.Errorf("me with an invalid token: status %d, want 401", status)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	s := newTestServer(t)
+	s.register("ada@example.com", "ada")
+	_, login := s.do(http.MethodPost, "/auth/login", "", `{"email":"ada@example.com","password":"secret1"}`)
+	refreshToken := login["refresh_token"].(string)
+
+	status, body := s.do(http.MethodPost, "/auth/refresh", "", `{"refresh_token":"`+refreshToken+`"}`)
+	if status != http.StatusOK {
+		t.Fatalf("refresh: status %d, body %v", status, body)
+	}
+	if body["refresh_token"] == refreshToken || body["access_token"] == "" {
+		t.Errorf("refresh returned %v, want a new token pair", body)
+	}
+	if status, _ := s.do(http.MethodGet, "/auth/me", body["access_token"].(string), ""); status != http.StatusOK {
+		t.Errorf("me with the refreshed token: status %d, want 200", status)
+	}
+
+	// The rotated token cannot be used again
+	if status, body := s.do(http.MethodPost, "/auth/refresh", "", `{"refresh_token":"`+refreshToken+`"}`); status != http.StatusUnauthorized ||
+		problemType(body) != "urn:gatekeeper:invalid_refresh_token" {
+		t.Errorf("reused refresh token: status %d, body %v; want 401 invalid_refresh_token", status, body)
+	}
+	if status, _ := s.do(http.MethodPost, "/auth/refresh", "", `{"refresh_token":"unknown"}`); status != http.StatusUnauthorized {
+		t.Errorf("unknown refresh token: status %d, want 401", status)
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	s := newTestServer(t)
+	for _, name := range []string{"ada", "bob", "cyd"} {
+		s.register(name+"@example.com", name)
+	}
+	token := s.login("ada@example.com", "secret1")
+
+	if status, _ := s.do(http.MethodGet, "/users", "", ""); status != http.StatusUnauthorized {
+		t.Errorf("without a token: status %d, want 401", status)
+	}
+
+	status, body := s.do(http.MethodGet, "/users?limit=2&offset=1&sort=email", token, "")
+	if status != http.StatusOK {
+		t.Fatalf("list: status %d, body %v", status, body)
+	}
+	items, _ := body["items"].([]any)
+	if body["total"] != 3.0 || body["limit"] != 2.0 || body["offset"] != 1.0 || len(items) != 2 {
+		t.Fatalf("page = %v, want items 2-3 of 3", body)
+	}
+	if first, _ := items[0].(map[string]any); first["email"] != "bob@example.com" {
+		t.Errorf("first item = %v, want bob sorted by email", items[0])
+	}
+
+	for _, query := range []string{"limit=x", "offset=x", "sort=password"} {
+		if status, _ := s.do(http.MethodGet, "/users?"+query, token, ""); status != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, status)
+		}
+	}
+}
+
+func TestValidationProblemListsFields(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, tc := range []struct {
+		path   string
+		body   string
+		fields string
+	}{
+		{"/auth/register", `{"email":"bad","username":"ab","password":"secret1"}`, "email:email username:min"},
+		{"/auth/register", `{}`, "email:required username:required password:required"},
+		{"/auth/login", `{"email":"","password":""}`, "email:required password:required"},
+	} {
+		status, body := s.do(http.MethodPost, tc.path, "", tc.body)
+		if status != http.StatusBadRequest || problemType(body) != "urn:gatekeeper:validation_failed" {
+			t.Errorf("%s %s: status %d, body %v; want 400 validation_failed", tc.path, tc.body, status, body)
+			continue
+		}
+		errors, _ := body["errors"].([]any)
+		var fields []string
+		for _, e := range errors {
+			field, _ := e.(map[string]any)
+			if field["message"] == "" {
+				t.Errorf("field error %v has no message", field)
+			}
+			fields = append(fields, fmt.Sprintf("%v:%v", field["field"], field["rule"]))
+		}
+		if got := strings.Join(fields, " "); got != tc.fields {
+			t.Errorf("%s %s: errors %s, want %s", tc.path, tc.body, got, tc.fields)
+		}
+	}
+}
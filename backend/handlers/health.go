@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"GateKeeper/health"
+)
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	readiness *health.Aggregator
+}
+
+// NewHealthHandler creates a HealthHandler whose readiness probe runs the
+// checks of readiness
+func NewHealthHandler(readiness *health.Aggregator) *HealthHandler {
+	return &HealthHandler{readiness: readiness}
+}
+
+// Routes returns the router serving the probes:
+//
+//	GET /healthz  200 while the process is serving; depends on nothing else
+//	GET /readyz   200 if every component is up, else 503, detailing each
+func (h *HealthHandler) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", h.Live)
+	mux.HandleFunc("GET /readyz", h.Ready)
+	return mux
+}
+
+// Live handles GET /healthz
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, health.Report{Status: health.StatusUp, Components: map[string]health.ComponentReport{}})
+}
+
+// Ready handles GET /readyz
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.readiness.Run(r.Context())
+	status := http.StatusOK
+	if report.Status != health.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
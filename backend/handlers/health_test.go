@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"GateKeeper/health"
+)
+
+// fakeRow scans 1, or fails with err
+type fakeRow struct {
+	err error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*int) = 1
+	return nil
+}
+
+// fakeDB answers SELECT 1 until it is down, then hangs until the query's
+// context is done
+type fakeDB struct {
+	down atomic.Bool
+}
+
+func (db *fakeDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if db.down.Load() {
+		<-ctx.Done()
+		return fakeRow{err: ctx.Err()}
+	}
+	return fakeRow{}
+}
+
+func TestHealthProbes(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	database := health.NewDatabaseChecker(50 * time.Millisecond)
+	readiness := health.NewAggregator(time.Second)
+	readiness.Add("database", database)
+	readiness.Add("upstream", health.NewHTTPChecker(upstream.URL, nil))
+	server := httptest.NewServer(NewHealthHandler(readiness).Routes())
+	defer server.Close()
+
+	// probe returns the status and report of GET path
+	probe := func(path string) (int, health.Report) {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var report health.Report
+		if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+		return resp.StatusCode, report
+	}
+	// requireLive checks that liveness stays green
+	requireLive := func() {
+		t.Helper()
+		if status, report := probe("/healthz"); status != http.StatusOK || report.Status != health.StatusUp {
+			t.Errorf("/healthz = %d %+v, want 200 up", status, report)
+		}
+	}
+
+	status, report := probe("/readyz")
+	if status != http.StatusServiceUnavailable || report.Components["database"].Error == "" {
+		t.Errorf("/readyz before the pool = %d %+v, want 503 with the database down", status, report)
+	}
+	requireLive()
+
+	db := &fakeDB{}
+	database.SetDB(db)
+	status, report = probe("/readyz")
+	if status != http.StatusOK || report.Status != health.StatusUp {
+		t.Errorf("/readyz = %d %+v, want 200 up", status, report)
+	}
+	for _, name := range []string{"database", "upstream"} {
+		if component := report.Components[name]; component.Status != health.StatusUp || component.LatencyMS < 0 {
+			t.Errorf("%s = %+v, want up with a latency", name, component)
+		}
+	}
+
+	db.down.Store(true)
+	status, report = probe("/readyz")
+	if status != http.StatusServiceUnavailable || report.Components["database"].Status != health.StatusDown ||
+		report.Components["upstream"].Status != health.StatusUp {
+		t.Errorf("/readyz with the database down = %d %+v, want 503 with only the database down", status, report)
+	}
+	requireLive()
+
+	db.down.Store(false)
+	if status, _ := probe("/readyz"); status != http.StatusOK {
+		t.Errorf("/readyz after recovery = %d, want 200", status)
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+	"GateKeeper/services"
+)
+
+// nopAuditLogger discards audit events
+type nopAuditLogger struct{}
+
+func (nopAuditLogger) Log(context.Context, models.AuthEvent) error { return nil }
+
+// testServer serves an AuthHandler's routes for a test
+type testServer struct {
+	t       *testing.T
+	service *services.AuthService
+	server  *httptest.Server
+}
+
+// newTestServer serves a service with an in-memory user store, a cheap
+// password hasher and no audit output, configured further by opts
+func newTestServer(t *testing.T, opts ...services.Option) *testServer {
+	t.Helper()
+	hasher, err := services.NewBcryptHasher(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = append([]services.Option{services.WithPasswordHasher(hasher), services.WithAuditLogger(nopAuditLogger{})}, opts...)
+	service := services.NewAuthServiceWithConfig(repository.NewMemoryUserRepository(), services.DefaultTokenConfig(), opts...)
+	server := httptest.NewServer(NewAuthHandler(service).Routes())
+	t.Cleanup(server.Close)
+	return &testServer{t: t, service: service, server: server}
+}
+
+// do sends a request with body, authenticated by token unless empty, and
+// returns the status and the decoded JSON response, if any
+func (s *testServer) do(method, path, token, body string) (int, map[string]any) {
+	s.t.Helper()
+	req, err := http.NewRequest(method, s.server.URL+path, strings.NewReader(body))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := s.server.Client().Do(req)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp.StatusCode, decoded
+}
+
+// register registers a user with password "secret1" and returns their ID
+func (s *testServer) register(email, username string) int {
+	s.t.Helper()
+	status, body := s.do(http.MethodPost, "/auth/register", "",
+		`{"email":"`+email+`","username":"`+username+`","password":"secret1"}`)
+	if status != http.StatusCreated {
+		s.t.Fatalf("register %s: status %d, body %v", email, status, body)
+	}
+	return int(body["id"].(float64))
+}
+
+// login logs in with password and returns the access token
+func (s *testServer) login(email, password string) string {
+	s.t.Helper()
+	status, body := s.do(http.MethodPost, "/auth/login", "", `{"email":"`+email+`","password":"`+password+`"}`)
+	if status != http.StatusOK {
+		s.t.Fatalf("login %s: status %d, body %v", email, status, body)
+	}
+	return body["access_token"].(string)
+}
+
+// admin registers an admin and returns their access token
+func (s *testServer) admin(email, username string) string {
+	s.t.Helper()
+	id := s.register(email, username)
+	if err := s.service.SetUserRole(context.Background(), id, models.RoleAdmin); err != nil {
+		s.t.Fatal(err)
+	}
+	return s.login(email, "secret1")
+}
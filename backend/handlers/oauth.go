@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"GateKeeper/auth"
+	"GateKeeper/oauth"
+)
+
+// OAuthHandler exposes social login through an oauth.Flow over HTTP
+type OAuthHandler struct {
+	flow *oauth.Flow
+}
+
+// NewOAuthHandler creates an OAuthHandler for flow
+func NewOAuthHandler(flow *oauth.Flow) *OAuthHandler {
+	return &OAuthHandler{flow: flow}
+}
+
+// Routes returns the router serving social login:
+//
+//	GET /auth/oauth/{provider}           redirect to the provider to log in
+//	GET /auth/oauth/{provider}/callback  complete the login, returning access and refresh tokens
+func (h *OAuthHandler) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /auth/oauth/{provider}", h.Begin)
+	mux.HandleFunc("GET /auth/oauth/{provider}/callback", h.Callback)
+	return mux
+}
+
+// Begin handles GET /auth/oauth/{provider}
+func (h *OAuthHandler) Begin(w http.ResponseWriter, r *http.Request) {
+	redirectURL, err := h.flow.BeginOAuth(r.PathValue("provider"))
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+	http.Redirect(w, r, redirectURL, http.StatusFound)
+}
+
+// Callback handles GET /auth/oauth/{provider}/callback
+func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if reason := query.Get("error"); reason != "" {
+		auth.WriteProblem(w, http.StatusUnauthorized, "oauth_denied", "provider denied authorization: "+reason)
+		return
+	}
+
+	response, err := h.flow.CompleteOAuth(requestContext(r), r.PathValue("provider"), query.Get("code"), query.Get("state"))
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// writeOAuthError maps flow errors to responses, deferring to writeServiceError
+func writeOAuthError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, oauth.ErrUnknownProvider):
+		auth.WriteProblem(w, http.StatusNotFound, "unknown_provider", err.Error())
+	case errors.Is(err, oauth.ErrInvalidState):
+		auth.WriteProblem(w, http.StatusBadRequest, "invalid_state", err.Error())
+	case errors.Is(err, oauth.ErrEmailNotVerified):
+		auth.WriteProblem(w, http.StatusForbidden, "email_not_verified", err.Error())
+	case errors.Is(err, oauth.ErrProvider):
+		auth.WriteProblem(w, http.StatusBadGateway, "provider_error", "identity provider request failed")
+	default:
+		writeServiceError(w, err)
+	}
+}
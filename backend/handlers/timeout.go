@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// WithRequestTimeout gives every request handled by next a context that
+// ends after timeout; AuthService abandons work for expired requests and
+// the handlers answer 504
+func WithRequestTimeout(next http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAbandonedRequests(t *testing.T) {
+	s := newTestServer(t)
+	s.register("ada@example.com", "ada")
+	routes := NewAuthHandler(s.service).Routes()
+	login := `{"email":"ada@example.com","password":"secret1"}`
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, tc := range []struct {
+		name    string
+		handler http.Handler
+		ctx     context.Context
+		status  int
+		problem string
+	}{
+		{"timed out", WithRequestTimeout(routes, time.Nanosecond), context.Background(), http.StatusGatewayTimeout, "urn:gatekeeper:request_timeout"},
+		{"client gone", routes, cancelled, statusClientClosedRequest, "urn:gatekeeper:request_cancelled"},
+		{"in time", WithRequestTimeout(routes, time.Minute), context.Background(), http.StatusOK, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequestWithContext(tc.ctx, http.MethodPost, "/auth/login", strings.NewReader(login))
+			rec := httptest.NewRecorder()
+			tc.handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.status)
+			}
+			var body map[string]any
+			json.NewDecoder(rec.Body).Decode(&body)
+			if tc.problem != "" && problemType(body) != tc.problem {
+				t.Errorf("problem type = %q, want %q", problemType(body), tc.problem)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// Package health checks the components the server depends on, for the
+// liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNotEstablished is returned by a DatabaseChecker before it is given a database
+var ErrNotEstablished = errors.New("database pool not established")
+
+// Checker checks one component. It has the shape of the data plane's
+// IHealthChecker, which is internal to that module.
+type Checker interface {
+	// Check performs a health check and returns an error if unhealthy
+	Check(ctx context.Context) error
+
+	// IsHealthy reports whether the last Check succeeded
+	IsHealthy() bool
+}
+
+// Ensure the checkers implement Checker interface
+var (
+	_ Checker = (*DatabaseChecker)(nil)
+	_ Checker = (*HTTPChecker)(nil)
+)
+
+// lastResult remembers whether the last check succeeded
+type lastResult struct {
+	healthy atomic.Bool
+}
+
+// record stores the outcome of err and returns err
+func (l *lastResult) record(err error) error {
+	l.healthy.Store(err == nil)
+	return err
+}
+
+// IsHealthy reports whether the last check succeeded
+func (l *lastResult) IsHealthy() bool {
+	return l.healthy.Load()
+}
+
+// Querier is the part of a pgx connection or pool DatabaseChecker uses
+type Querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// DatabaseChecker checks the database by running SELECT 1
+type DatabaseChecker struct {
+	lastResult
+	timeout time.Duration
+	db      atomic.Pointer[Querier]
+}
+
+// NewDatabaseChecker creates a checker giving the database timeout to
+// answer. It fails with ErrNotEstablished until SetDB is called.
+func NewDatabaseChecker(timeout time.Duration) *DatabaseChecker {
+	return &DatabaseChecker{timeout: timeout}
+}
+
+// SetDB sets the database to check, once its pool is established
+func (c *DatabaseChecker) SetDB(db Querier) {
+	c.db.Store(&db)
+}
+
+// Check runs SELECT 1 against the database
+func (c *DatabaseChecker) Check(ctx context.Context) error {
+	db := c.db.Load()
+	if db == nil {
+		return c.record(ErrNotEstablished)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	var one int
+	if err := (*db).QueryRow(ctx, "SELECT 1").Scan(&one); err != nil {
+		return c.record(fmt.Errorf("database unreachable: %w", err))
+	}
+	return c.record(nil)
+}
+
+// HTTPChecker checks an upstream service by requesting its health URL
+type HTTPChecker struct {
+	lastResult
+	url    string
+	client *http.Client
+}
+
+// NewHTTPChecker creates a checker requesting url with client, or with
+// http.DefaultClient if nil. Any 2xx or 3xx answer is healthy.
+func NewHTTPChecker(url string, client *http.Client) *HTTPChecker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPChecker{url: url, client: client}
+}
+
+// Check requests the health URL
+func (c *HTTPChecker) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return c.record(err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return c.record(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return c.record(fmt.Errorf("%s answered %s", c.url, resp.Status))
+	}
+	return c.record(nil)
+}
+
+// Component statuses
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// ComponentReport is the result of checking one component
+type ComponentReport struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the result of checking every component
+type Report struct {
+	// Status is StatusUp if every component is up
+	Status     string                     `json:"status"`
+	Components map[string]ComponentReport `json:"components"`
+}
+
+// Aggregator runs named checks concurrently
+type Aggregator struct {
+	timeout time.Duration
+	names   []string
+	checks  map[string]Checker
+}
+
+// NewAggregator creates an aggregator giving each check timeout to finish
+func NewAggregator(timeout time.Duration) *Aggregator {
+	return &Aggregator{timeout: timeout, checks: make(map[string]Checker)}
+}
+
+// Add registers checker under name, replacing any checker with that name.
+// Checks must be added before Run is first called.
+func (a *Aggregator) Add(name string, checker Checker) {
+	if _, exists := a.checks[name]; !exists {
+		a.names = append(a.names, name)
+	}
+	a.checks[name] = checker
+}
+
+// Run checks every component concurrently, each within the timeout
+func (a *Aggregator) Run(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Components: make(map[string]ComponentReport, len(a.names))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range a.names {
+		checker := a.checks[name]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, a.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := checker.Check(checkCtx)
+			component := ComponentReport{
+				Status:    StatusUp,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				component.Status = StatusDown
+				component.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[name] = component
+			if err != nil {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
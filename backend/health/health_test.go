@@ -0,0 +1,133 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeRow scans 1, or fails with err
+type fakeRow struct {
+	err error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*int) = 1
+	return nil
+}
+
+// fakeDB answers SELECT 1 until it is down, then hangs until the query's
+// context is done, like an unreachable database
+type fakeDB struct {
+	down atomic.Bool
+}
+
+func (db *fakeDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if db.down.Load() {
+		<-ctx.Done()
+		return fakeRow{err: ctx.Err()}
+	}
+	return fakeRow{}
+}
+
+// checkFunc is a Checker running a function
+type checkFunc func(ctx context.Context) error
+
+func (f checkFunc) Check(ctx context.Context) error { return f(ctx) }
+func (f checkFunc) IsHealthy() bool                 { return true }
+
+func TestDatabaseChecker(t *testing.T) {
+	ctx := context.Background()
+	checker := NewDatabaseChecker(20 * time.Millisecond)
+
+	if err := checker.Check(ctx); !errors.Is(err, ErrNotEstablished) || checker.IsHealthy() {
+		t.Fatalf("Check before SetDB = %v, want ErrNotEstablished", err)
+	}
+
+	db := &fakeDB{}
+	checker.SetDB(db)
+	if err := checker.Check(ctx); err != nil || !checker.IsHealthy() {
+		t.Fatalf("Check = %v, want healthy", err)
+	}
+
+	db.down.Store(true)
+	start := time.Now()
+	if err := checker.Check(ctx); !errors.Is(err, context.DeadlineExceeded) || checker.IsHealthy() {
+		t.Fatalf("Check with the database down = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Check took %v, want it bounded by its 20ms timeout", elapsed)
+	}
+}
+
+func TestHTTPChecker(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusNoContent)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer upstream.Close()
+
+	checker := NewHTTPChecker(upstream.URL, nil)
+	if err := checker.Check(context.Background()); err != nil || !checker.IsHealthy() {
+		t.Fatalf("Check = %v, want healthy", err)
+	}
+	status.Store(http.StatusServiceUnavailable)
+	if err := checker.Check(context.Background()); err == nil || checker.IsHealthy() {
+		t.Fatal("Check succeeded on a 503")
+	}
+
+	upstream.Close()
+	if err := NewHTTPChecker(upstream.URL, nil).Check(context.Background()); err == nil {
+		t.Error("Check succeeded with the upstream gone")
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	aggregator := NewAggregator(50 * time.Millisecond)
+	aggregator.Add("fast", checkFunc(func(context.Context) error { return nil }))
+	aggregator.Add("failing", checkFunc(func(context.Context) error { return errors.New("broken") }))
+	for _, name := range []string{"slow", "slow too"} {
+		aggregator.Add(name, checkFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+	}
+
+	start := time.Now()
+	report := aggregator.Run(context.Background())
+	// The two slow checks time out together, not one after the other
+	if elapsed := time.Since(start); elapsed > 90*time.Millisecond {
+		t.Errorf("Run took %v, want the checks run concurrently", elapsed)
+	}
+
+	if report.Status != StatusDown || len(report.Components) != 4 {
+		t.Fatalf("report = %+v, want down with 4 components", report)
+	}
+	if fast := report.Components["fast"]; fast.Status != StatusUp || fast.Error != "" {
+		t.Errorf("fast = %+v, want up", fast)
+	}
+	if failing := report.Components["failing"]; failing.Status != StatusDown || failing.Error != "broken" {
+		t.Errorf("failing = %+v, want down with its error", failing)
+	}
+	if slow := report.Components["slow"]; slow.Status != StatusDown || slow.LatencyMS < 50 {
+		t.Errorf("slow = %+v, want down after the 50ms timeout", slow)
+	}
+
+	healthy := NewAggregator(time.Second)
+	healthy.Add("fast", checkFunc(func(context.Context) error { return nil }))
+	// Adding a name again replaces the check
+	healthy.Add("fast", checkFunc(func(context.Context) error { return nil }))
+	if report := healthy.Run(context.Background()); report.Status != StatusUp || len(report.Components) != 1 {
+		t.Errorf("report = %+v, want up with 1 component", report)
+	}
+}
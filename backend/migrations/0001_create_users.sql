@@ -0,0 +1,11 @@
+-- Users of the auth service
+CREATE TABLE IF NOT EXISTS users (
+    id          SERIAL PRIMARY KEY,
+    email       TEXT        NOT NULL,
+    username    TEXT        NOT NULL,
+    password    TEXT        NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    is_active   BOOLEAN     NOT NULL DEFAULT TRUE,
+    CONSTRAINT users_email_key UNIQUE (email)
+);
@@ -0,0 +1,3 @@
+-- Access tokens issued before password_changed_at are rejected
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMPTZ NOT NULL DEFAULT 'epoch';
@@ -0,0 +1,3 @@
+-- Set once the user confirms their email; cleared when the email changes
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,16 @@
+-- Per-user API keys; only the SHA-256 hash of each key is stored
+CREATE TABLE IF NOT EXISTS api_keys (
+    id            SERIAL      PRIMARY KEY,
+    user_id       INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name          TEXT        NOT NULL,
+    prefix        TEXT        NOT NULL,
+    hash          TEXT        NOT NULL,
+    scopes        TEXT[]      NOT NULL DEFAULT '{}',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at    TIMESTAMPTZ,
+    last_used_at  TIMESTAMPTZ,
+    revoked_at    TIMESTAMPTZ,
+    CONSTRAINT api_keys_prefix_key UNIQUE (prefix)
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
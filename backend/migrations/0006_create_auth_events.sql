@@ -0,0 +1,29 @@
+-- Append-only audit trail of authentication events. User IDs are not
+-- foreign keys so events outlive deleted users.
+CREATE TABLE IF NOT EXISTS auth_events (
+    id           BIGSERIAL   PRIMARY KEY,
+    type         TEXT        NOT NULL,
+    outcome      TEXT        NOT NULL,
+    reason       TEXT        NOT NULL DEFAULT '',
+    actor_id     INTEGER,
+    target_id    INTEGER,
+    email        TEXT        NOT NULL DEFAULT '',
+    ip           TEXT        NOT NULL DEFAULT '',
+    user_agent   TEXT        NOT NULL DEFAULT '',
+    occurred_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS auth_events_target_id_idx ON auth_events (target_id, occurred_at);
+CREATE INDEX IF NOT EXISTS auth_events_occurred_at_idx ON auth_events (occurred_at);
+
+-- Make the trail immutable
+CREATE OR REPLACE FUNCTION auth_events_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS auth_events_immutable ON auth_events;
+CREATE TRIGGER auth_events_immutable
+    BEFORE UPDATE OR DELETE ON auth_events
+    FOR EACH ROW EXECUTE FUNCTION auth_events_immutable();
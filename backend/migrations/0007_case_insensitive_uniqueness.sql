@@ -0,0 +1,9 @@
+-- Emails are stored trimmed and lowercased and are unique ignoring case;
+-- usernames keep their case but are unique ignoring it too. Normalizing
+-- fails if existing accounts collide, which have to be merged first.
+UPDATE users SET email = lower(btrim(email)) WHERE email <> lower(btrim(email));
+UPDATE users SET username = btrim(username) WHERE username <> btrim(username);
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_key ON users (lower(email));
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_lower_key ON users (lower(username));
@@ -0,0 +1,10 @@
+-- Deleted users are purged, as the indexes over every user would not hold
+-- with them
+DELETE FROM users WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS users_deleted_at_idx;
+DROP INDEX IF EXISTS users_email_lower_key;
+DROP INDEX IF EXISTS users_username_lower_key;
+CREATE UNIQUE INDEX users_email_lower_key ON users (lower(email));
+CREATE UNIQUE INDEX users_username_lower_key ON users (lower(username));
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,2 @@
+-- Listings are ordered by creation time then ID unless asked otherwise
+CREATE INDEX IF NOT EXISTS users_created_at_id_idx ON users (created_at, id) WHERE deleted_at IS NULL;
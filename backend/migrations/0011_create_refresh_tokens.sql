@@ -0,0 +1,16 @@
+-- Refresh tokens, keyed by the SHA-256 hash of the token. A family groups
+-- the tokens rotated from one login, so reuse of a rotated token can revoke
+-- them all.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    hash        TEXT        PRIMARY KEY,
+    user_id     INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family      TEXT        NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at  TIMESTAMPTZ NOT NULL,
+    rotated     BOOLEAN     NOT NULL DEFAULT false,
+    revoked     BOOLEAN     NOT NULL DEFAULT false
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_idx ON refresh_tokens (family);
+CREATE INDEX IF NOT EXISTS refresh_tokens_expires_at_idx ON refresh_tokens (expires_at);
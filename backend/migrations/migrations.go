@@ -0,0 +1,306 @@
+// Package migrations manages the database schema. The schema changes are
+// the numbered SQL files in this directory, embedded into the binary:
+//
+//	NNNN_name.sql       applies version NNNN
+//	NNNN_name.down.sql  reverts it, if it can be reverted
+//
+// Applied versions are recorded in the schema_migrations table. Each file
+// runs in a transaction together with its record, unless its first line is
+// "-- migrate: no-transaction", e.g. for CREATE INDEX CONCURRENTLY.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockKey is the advisory lock key held while migrating, so replicas
+// starting together apply each migration once. The value is arbitrary.
+const lockKey int64 = 4826361077
+
+// noTransaction marks a migration that cannot run in a transaction
+const noTransaction = "-- migrate: no-transaction"
+
+var (
+	// ErrIrreversible is returned by Down for a migration without a down file
+	ErrIrreversible = errors.New("migration cannot be reverted")
+
+	// ErrUnknownVersion is returned when the database records a version
+	// this binary has no migration for, e.g. after a rollback of the binary
+	ErrUnknownVersion = errors.New("database has an unknown migration version")
+)
+
+// fileName matches migration file names
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)(\.down)?\.sql$`)
+
+// Migration is one schema change
+type Migration struct {
+	Version int
+	Name    string
+
+	// Up applies the change and Down, if not empty, reverts it
+	Up   string
+	Down string
+}
+
+// transactional reports whether sql may run in a transaction
+func transactional(sql string) bool {
+	firstLine, _, _ := strings.Cut(sql, "\n")
+	return strings.TrimSpace(firstLine) != noTransaction
+}
+
+// Embedded returns the migrations built into the binary, ordered by version
+func Embedded() ([]Migration, error) {
+	return Load(files)
+}
+
+// Load reads the migration files at the root of fsys, ordered by version.
+// Other files are ignored.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+		target := &migration.Up
+		if match[3] != "" {
+			target = &migration.Down
+		}
+		if *target != "" {
+			return nil, fmt.Errorf("migration %d has two %s files", version, entry.Name())
+		}
+		*target = string(content)
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has a down file but no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+// Conn is a single database connection, e.g. a *pgx.Conn or a
+// *pgxpool.Conn; the advisory lock is held on it while migrating
+type Conn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Runner applies and reverts migrations on a database
+type Runner struct {
+	conn       Conn
+	migrations []Migration
+}
+
+// NewRunner creates a runner for migrations, ordered by version, on conn
+func NewRunner(conn Conn, migrations []Migration) *Runner {
+	return &Runner{conn: conn, migrations: migrations}
+}
+
+// Pending returns the migrations Up would apply, without applying them. It
+// only reads the database: without schema_migrations, every migration is
+// pending.
+func (r *Runner) Pending(ctx context.Context) ([]Migration, error) {
+	exists, err := r.tableExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return r.pending(nil), nil
+	}
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.pending(applied), nil
+}
+
+// Up applies the pending migrations in order and returns them. It stops at
+// the first failure, leaving the migrations before it applied.
+func (r *Runner) Up(ctx context.Context) (done []Migration, err error) {
+	err = r.locked(ctx, func(applied map[int]bool) error {
+		for _, migration := range r.pending(applied) {
+			if err := r.run(ctx, migration.Up,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+				migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// them. It fails with ErrIrreversible before reverting anything if one of
+// them has no down file.
+func (r *Runner) Down(ctx context.Context, steps int) (done []Migration, err error) {
+	err = r.locked(ctx, func(applied map[int]bool) error {
+		var revert []Migration
+		for i := len(r.migrations) - 1; i >= 0 && len(revert) < steps; i-- {
+			if applied[r.migrations[i].Version] {
+				revert = append(revert, r.migrations[i])
+			}
+		}
+		for _, migration := range revert {
+			if migration.Down == "" {
+				return fmt.Errorf("%w: %d_%s", ErrIrreversible, migration.Version, migration.Name)
+			}
+		}
+
+		for _, migration := range revert {
+			if err := r.run(ctx, migration.Down,
+				`DELETE FROM schema_migrations WHERE version = $1`, migration.Version); err != nil {
+				return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// locked calls fn with the applied versions while holding the advisory lock
+func (r *Runner) locked(ctx context.Context, fn func(applied map[int]bool) error) error {
+	if _, err := r.conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer func() {
+		// Unlock even if the caller has gone, or the connection keeps the lock
+		_, _ = r.conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, lockKey)
+	}()
+
+	if err := r.ensureTable(ctx); err != nil {
+		return err
+	}
+	// Read the versions under the lock, after any concurrent runner finished
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(applied)
+}
+
+// run executes sql and then record, in one transaction unless sql opts out
+func (r *Runner) run(ctx context.Context, sql string, record string, args ...any) error {
+	if !transactional(sql) {
+		if _, err := r.conn.Exec(ctx, sql); err != nil {
+			return err
+		}
+		_, err := r.conn.Exec(ctx, record, args...)
+		return err
+	}
+
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ensureTable creates schema_migrations if it does not exist
+func (r *Runner) ensureTable(ctx context.Context) error {
+	_, err := r.conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version     INTEGER     PRIMARY KEY,
+		name        TEXT        NOT NULL,
+		applied_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// tableExists reports whether schema_migrations exists on the search path
+func (r *Runner) tableExists(ctx context.Context) (bool, error) {
+	rows, err := r.conn.Query(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up schema_migrations: %w", err)
+	}
+	exists, err := pgx.CollectExactlyOneRow(rows, pgx.RowTo[bool])
+	if err != nil {
+		return false, fmt.Errorf("failed to look up schema_migrations: %w", err)
+	}
+	return exists, nil
+}
+
+// applied returns the versions recorded in schema_migrations, failing with
+// ErrUnknownVersion if one has no migration
+func (r *Runner) applied(ctx context.Context) (map[int]bool, error) {
+	rows, err := r.conn.Query(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	versions, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	applied := make(map[int]bool, len(versions))
+	for _, version := range versions {
+		known := slices.ContainsFunc(r.migrations, func(m Migration) bool { return m.Version == version })
+		if !known {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+		}
+		applied[version] = true
+	}
+	return applied, nil
+}
+
+// pending returns the migrations not in applied, in order
+func (r *Runner) pending(applied map[int]bool) []Migration {
+	var pending []Migration
+	for _, migration := range r.migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
@@ -0,0 +1,326 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// testDatabaseEnv names the variable holding the URL of a scratch Postgres
+// database for the runner tests, which are skipped without it. Each test
+// migrates a schema of its own, dropped afterwards.
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
+// testChain is a small chain of migrations; applying one twice fails
+var testChain = fstest.MapFS{
+	"0001_create_widgets.sql":      {Data: []byte(`CREATE TABLE widgets (id SERIAL PRIMARY KEY)`)},
+	"0001_create_widgets.down.sql": {Data: []byte(`DROP TABLE widgets`)},
+	"0002_add_name.sql":            {Data: []byte(`ALTER TABLE widgets ADD COLUMN name TEXT`)},
+	"0002_add_name.down.sql":       {Data: []byte(`ALTER TABLE widgets DROP COLUMN name`)},
+	"0003_index_name.sql":          {Data: []byte("-- migrate: no-transaction\nCREATE INDEX CONCURRENTLY widgets_name ON widgets (name)")},
+	"0003_index_name.down.sql":     {Data: []byte(`DROP INDEX widgets_name`)},
+	"README.md":                    {Data: []byte("not a migration")},
+}
+
+// testSchema creates a schema for the test and returns a function
+// connecting to the test database with it as the search path, or skips
+func testSchema(t *testing.T) func() *pgx.Conn {
+	t.Helper()
+	url := os.Getenv(testDatabaseEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseEnv)
+	}
+
+	ctx := context.Background()
+	schema := fmt.Sprintf("migrations_test_%d", time.Now().UnixNano())
+	connect := func() *pgx.Conn {
+		t.Helper()
+		conn, err := pgx.Connect(ctx, url)
+		if err != nil {
+			t.Fatalf("connect to the test database: %v", err)
+		}
+		t.Cleanup(func() { conn.Close(context.Background()) })
+		if _, err := conn.Exec(ctx, "SET search_path TO "+schema); err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+
+	admin := connect()
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE") })
+	return connect
+}
+
+// versions returns the versions of migrations
+func versions(migrations []Migration) string {
+	var versions []int
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	return fmt.Sprint(versions)
+}
+
+func TestEmbedded(t *testing.T) {
+	migrations, err := Embedded()
+	if err != nil {
+		t.Fatalf("Embedded: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("migration %d has version %d, want versions numbered from 1 without gaps", i, migration.Version)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			t.Errorf("migration %d_%s lacks an up or down file", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testChain)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if versions(migrations) != "[1 2 3]" || migrations[0].Name != "create_widgets" || migrations[1].Down == "" {
+		t.Errorf("Load = %+v, want the three migrations in order", migrations)
+	}
+	if transactional(migrations[2].Up) || !transactional(migrations[0].Up) {
+		t.Error("only the CREATE INDEX CONCURRENTLY migration should run outside a transaction")
+	}
+
+	for _, tc := range []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"down without up", fstest.MapFS{"0001_a.down.sql": {Data: []byte("x")}}},
+		{"two names", fstest.MapFS{"0001_a.sql": {Data: []byte("x")}, "0001_b.sql": {Data: []byte("y")}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Load(tc.files); err == nil {
+				t.Fatal("Load succeeded")
+			}
+		})
+	}
+}
+
+func TestRunner(t *testing.T) {
+	connect := testSchema(t)
+	ctx := context.Background()
+	migrations, err := Load(testChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := connect()
+	runner := NewRunner(conn, migrations)
+
+	pending, err := runner.Pending(ctx)
+	if err != nil || versions(pending) != "[1 2 3]" {
+		t.Fatalf("Pending = %s, %v; want every migration", versions(pending), err)
+	}
+	// Pending only reads the database
+	var created bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&created); err != nil || created {
+		t.Errorf("schema_migrations exists after Pending = %v, %v; want it left uncreated", created, err)
+	}
+	done, err := runner.Up(ctx)
+	if err != nil || versions(done) != "[1 2 3]" {
+		t.Fatalf("Up = %s, %v; want every migration applied", versions(done), err)
+	}
+	if _, err := conn.Exec(ctx, `INSERT INTO widgets (name) VALUES ('a')`); err != nil {
+		t.Errorf("the migrated table is unusable: %v", err)
+	}
+
+	// Re-running applies nothing
+	if done, err := runner.Up(ctx); err != nil || len(done) != 0 {
+		t.Errorf("second Up = %s, %v; want nothing applied", versions(done), err)
+	}
+	if pending, _ := runner.Pending(ctx); len(pending) != 0 {
+		t.Errorf("Pending = %s, want none", versions(pending))
+	}
+
+	done, err = runner.Down(ctx, 2)
+	if err != nil || versions(done) != "[3 2]" {
+		t.Fatalf("Down(2) = %s, %v; want 3 then 2 reverted", versions(done), err)
+	}
+	if pending, _ := runner.Pending(ctx); versions(pending) != "[2 3]" {
+		t.Errorf("Pending after Down = %s, want [2 3]", versions(pending))
+	}
+	if done, err := runner.Up(ctx); err != nil || versions(done) != "[2 3]" {
+		t.Errorf("Up after Down = %s, %v; want 2 and 3 reapplied", versions(done), err)
+	}
+}
+
+func TestRunnerErrors(t *testing.T) {
+	connect := testSchema(t)
+	ctx := context.Background()
+	migrations, err := Load(testChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := connect()
+	if _, err := NewRunner(conn, migrations).Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	irreversible := append([]Migration(nil), migrations...)
+	irreversible[1].Down = ""
+	if done, err := NewRunner(conn, irreversible).Down(ctx, 3); !errors.Is(err, ErrIrreversible) || len(done) != 0 {
+		t.Errorf("Down past an irreversible migration = %s, %v; want ErrIrreversible and nothing reverted", versions(done), err)
+	}
+
+	// A binary that predates migration 3
+	if _, err := NewRunner(conn, migrations[:2]).Pending(ctx); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("Pending with an unknown applied version = %v, want ErrUnknownVersion", err)
+	}
+
+	// A failing migration is rolled back with its record
+	broken := append(migrations[:3:3], Migration{Version: 4, Name: "broken", Up: `ALTER TABLE widgets ADD COLUMN size INT; SELECT missing`})
+	if _, err := NewRunner(conn, broken).Up(ctx); err == nil {
+		t.Fatal("Up applied a broken migration")
+	}
+	if pending, _ := NewRunner(conn, broken).Pending(ctx); versions(pending) != "[4]" {
+		t.Errorf("Pending after a failure = %s, want [4]", versions(pending))
+	}
+	var columns int
+	if err := conn.QueryRow(ctx, `SELECT count(*) FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'widgets' AND column_name = 'size'`).Scan(&columns); err != nil || columns != 0 {
+		t.Errorf("size columns = %d, %v; want the failed migration rolled back", columns, err)
+	}
+}
+
+func TestRunnerLock(t *testing.T) {
+	connect := testSchema(t)
+	ctx := context.Background()
+	migrations, err := Load(testChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another replica holds the lock, so Up waits for it
+	holder := connect()
+	if _, err := holder.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		t.Fatal(err)
+	}
+	waiter := connect()
+	result := make(chan error, 1)
+	go func() {
+		_, err := NewRunner(waiter, migrations).Up(ctx)
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		t.Fatalf("Up returned %v while another runner held the lock", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if _, err := holder.Exec(ctx, `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-result; err != nil {
+		t.Fatalf("Up after the lock was released: %v", err)
+	}
+
+	// Replicas starting together apply each migration once between them
+	if _, err := NewRunner(holder, migrations).Down(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	const replicas = 5
+	var (
+		mu      sync.Mutex
+		applied []Migration
+		wg      sync.WaitGroup
+	)
+	conns := make([]*pgx.Conn, replicas)
+	for i := range conns {
+		conns[i] = connect()
+	}
+	for _, conn := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := NewRunner(conn, migrations).Up(ctx)
+			if err != nil {
+				t.Errorf("concurrent Up: %v", err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			applied = append(applied, done...)
+		}()
+	}
+	wg.Wait()
+	if versions(applied) != "[1 2 3]" {
+		t.Errorf("replicas applied %s between them, want each migration once", versions(applied))
+	}
+}
+
+// readOnlyConn is a Conn answering the schema_migrations lookup with
+// exists, and failing the test on any write
+type readOnlyConn struct {
+	t      *testing.T
+	exists bool
+}
+
+func (c readOnlyConn) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	c.t.Errorf("Exec(%q) on a read-only runner", sql)
+	return pgconn.CommandTag{}, errors.New("read only")
+}
+
+func (c readOnlyConn) Query(_ context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	if strings.Contains(sql, "to_regclass") {
+		return &boolRows{values: []bool{c.exists}}, nil
+	}
+	return &boolRows{}, nil
+}
+
+func (c readOnlyConn) Begin(context.Context) (pgx.Tx, error) {
+	c.t.Error("Begin on a read-only runner")
+	return nil, errors.New("read only")
+}
+
+// boolRows is a single-column result set of booleans
+type boolRows struct {
+	values []bool
+	row    int
+}
+
+func (r *boolRows) Close()                                       {}
+func (r *boolRows) Err() error                                   { return nil }
+func (r *boolRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (r *boolRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (r *boolRows) RawValues() [][]byte                          { return nil }
+func (r *boolRows) Conn() *pgx.Conn                              { return nil }
+func (r *boolRows) Values() ([]any, error)                       { return []any{r.values[r.row-1]}, nil }
+
+func (r *boolRows) Next() bool {
+	r.row++
+	return r.row <= len(r.values)
+}
+
+func (r *boolRows) Scan(dest ...any) error {
+	*dest[0].(*bool) = r.values[r.row-1]
+	return nil
+}
+
+func TestPendingWithoutTable(t *testing.T) {
+	migrations, err := Load(testChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := NewRunner(readOnlyConn{t: t}, migrations).Pending(context.Background())
+	if err != nil || versions(pending) != "[1 2 3]" {
+		t.Fatalf("Pending = %s, %v; want every migration", versions(pending), err)
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -13,8 +14,28 @@ type User struct {
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 	IsActive  bool      `json:"is_active" db:"is_active"`
+
+	// PasswordChangedAt is when the password was last changed; access
+	// tokens issued before it are rejected
+	PasswordChangedAt time.Time `json:"-" db:"password_changed_at"`
+
+	// EmailVerified is true once the user has confirmed Email
+	EmailVerified bool `json:"email_verified" db:"email_verified"`
+
+	// Role is RoleUser or RoleAdmin
+	Role string `json:"role" db:"role"`
+
+	// DeletedAt is when the user deleted their account; deleted users are
+	// left out of lookups and listings until restored or purged
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // CreateUserRequest represents the request payload for creating a user
 type CreateUserRequest struct {
 	Email    string `json:"email" validate:"required,email"`
@@ -28,6 +49,108 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// ChangePasswordRequest represents the request payload for changing the password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+// UpdateUserRequest represents the request payload for updating a user's
+// profile; nil fields are left unchanged
+type UpdateUserRequest struct {
+	Email    *string `json:"email,omitempty" validate:"omitempty,email"`
+	Username *string `json:"username,omitempty" validate:"omitempty,min=3,max=50"`
+}
+
+// DeleteUserRequest represents the request payload for deleting one's own account
+type DeleteUserRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// LoginResponse represents the response payload for a successful login
+type LoginResponse struct {
+	User             UserResponse `json:"user"`
+	AccessToken      string       `json:"access_token"`
+	TokenType        string       `json:"token_type"`
+	ExpiresAt        time.Time    `json:"expires_at"`
+	RefreshToken     string       `json:"refresh_token"`
+	RefreshExpiresAt time.Time    `json:"refresh_expires_at"`
+}
+
+// Session is a server-side login session. ID is the SHA-256 hash of the
+// opaque session ID held by the client.
+type Session struct {
+	ID         string    `json:"-" db:"id"`
+	UserID     int       `json:"user_id" db:"user_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// RefreshToken is an issued refresh token. Hash is the SHA-256 hash of the
+// opaque token held by the client, and Family groups the tokens rotated
+// from one login.
+type RefreshToken struct {
+	Hash      string    `json:"-" db:"hash"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Family    string    `json:"-" db:"family"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	Rotated   bool      `json:"rotated" db:"rotated"`
+	Revoked   bool      `json:"revoked" db:"revoked"`
+}
+
+// SessionResponse represents the response payload for a created session;
+// the session ID itself is sent as a cookie
+type SessionResponse struct {
+	User      UserResponse `json:"user"`
+	SessionID string       `json:"-"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
+
+// APIKey is a long-lived credential owned by a user. Only the SHA-256 hash
+// of the key is stored; Prefix identifies the key without revealing it.
+type APIKey struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	Hash       string     `json:"-" db:"hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// CreateAPIKeyRequest represents the request payload for creating an API key
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes"`
+
+	// TTLSeconds is the key's lifetime; zero means it never expires
+	TTLSeconds int64 `json:"ttl_seconds" validate:"min=0"`
+}
+
+// CreatedAPIKey represents the response payload for a created API key. Key
+// is the plaintext key, which is never shown again.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyIdentity is the result of authenticating with an API key
+type APIKeyIdentity struct {
+	User   UserResponse
+	KeyID  int
+	Scopes []string
+}
+
+// RefreshRequest represents the request payload for refreshing tokens
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
 // UserResponse represents the response payload for user data (without sensitive info)
 type UserResponse struct {
 	ID        int       `json:"id"`
@@ -35,6 +158,155 @@ type UserResponse struct {
 	Username  string    `json:"username"`
 	CreatedAt time.Time `json:"created_at"`
 	IsActive  bool      `json:"is_active"`
+
+	EmailVerified bool       `json:"email_verified"`
+	Role          string     `json:"role"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ListUsersParams selects a page of a user listing
+type ListUsersParams struct {
+	// Limit is the page size; Offset is the number of users to skip
+	Limit  int
+	Offset int
+
+	// SortBy is one of id, email, username, created_at (the default) or
+	// updated_at; SortDir is asc or desc. Ties are broken by ID.
+	SortBy  string
+	SortDir string
+
+	// ActiveOnly leaves out deactivated users
+	ActiveOnly bool
+
+	// EmailContains keeps only users whose email contains it, case-insensitively
+	EmailContains string
+}
+
+// SearchParams selects a page of the users matching search criteria. Empty
+// criteria match every user, as in a plain listing.
+type SearchParams struct {
+	ListUsersParams
+
+	// UsernameContains keeps only users whose username contains it, case-insensitively
+	UsernameContains string
+
+	// CreatedAfter and CreatedBefore, unless zero, keep only users created at
+	// or after CreatedAfter and before CreatedBefore
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+
+	// Active, if set, keeps only active or only deactivated users
+	Active *bool
+
+	// Role, if set, keeps only users with that role
+	Role string
+}
+
+// UserPage represents one page of a user listing
+type UserPage struct {
+	Items  []UserResponse `json:"items"`
+	Total  int            `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+// LoginAttempt describes a failed login
+type LoginAttempt struct {
+	Time      time.Time `json:"time"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
+
+// LockoutStatus reports the failed login history of an account
+type LockoutStatus struct {
+	Email string `json:"email"`
+
+	// FailedAttempts counts failed logins in the current throttling window
+	// and ConsecutiveFailures those since the last success or lockout
+	FailedAttempts      int `json:"failed_attempts"`
+	ConsecutiveFailures int `json:"consecutive_failures"`
+
+	// Lockouts counts lockouts since the last successful login; LockedUntil
+	// is set while the account is locked
+	Lockouts    int        `json:"lockouts"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+
+	// RecentAttempts lists the latest failed logins, oldest first
+	RecentAttempts []LoginAttempt `json:"recent_attempts"`
+}
+
+// AuthEventType names an audited authentication event
+type AuthEventType string
+
+// Audited authentication events
+const (
+	EventRegister       AuthEventType = "register"
+	EventLogin          AuthEventType = "login"
+	EventExternalLogin  AuthEventType = "external_login"
+	EventLockout        AuthEventType = "lockout"
+	EventTokenRefresh   AuthEventType = "token_refresh"
+	EventPasswordChange AuthEventType = "password_change"
+	EventProfileUpdate  AuthEventType = "profile_update"
+	EventDeactivate     AuthEventType = "deactivate"
+	EventReactivate     AuthEventType = "reactivate"
+	EventDelete         AuthEventType = "delete"
+	EventLogout         AuthEventType = "logout"
+	EventAPIKeyCreate   AuthEventType = "api_key_create"
+	EventAPIKeyRevoke   AuthEventType = "api_key_revoke"
+	EventUnlock         AuthEventType = "unlock"
+	EventRoleChange     AuthEventType = "role_change"
+	EventRestore        AuthEventType = "restore"
+)
+
+// AuthOutcome is whether an audited operation succeeded
+type AuthOutcome string
+
+// Outcomes of audited operations
+const (
+	OutcomeSuccess AuthOutcome = "success"
+	OutcomeFailure AuthOutcome = "failure"
+)
+
+// AuthEvent is one entry of the authentication audit trail. It never holds
+// passwords or token values.
+type AuthEvent struct {
+	Type    AuthEventType `json:"type"`
+	Outcome AuthOutcome   `json:"outcome"`
+
+	// Reason is a short code explaining a failure, e.g. invalid_credentials
+	Reason string `json:"reason,omitempty"`
+
+	// ActorID is the user performing the operation and TargetID the user it
+	// affects; either is 0 when unknown
+	ActorID  int `json:"actor_id,omitempty"`
+	TargetID int `json:"target_id,omitempty"`
+
+	// Email is the email the operation named, for events about users that
+	// may not exist, such as failed logins
+	Email string `json:"email,omitempty"`
+
+	// IP and UserAgent describe the client that made the request
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+
+	Time time.Time `json:"time"`
+}
+
+// NormalizeEmail returns the form emails are stored and looked up in:
+// trimmed and lowercased, so addresses differing only in case are one account
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizeUsername returns the form usernames are stored in: trimmed, with
+// their case kept for display
+func NormalizeUsername(username string) string {
+	return strings.TrimSpace(username)
+}
+
+// UsernameKey returns the form usernames are unique in, ignoring case
+func UsernameKey(username string) string {
+	return strings.ToLower(NormalizeUsername(username))
 }
 
 // ToResponse converts a User model to UserResponse (removes sensitive data)
@@ -45,5 +317,9 @@ func (u *User) ToResponse() UserResponse {
 		Username:  u.Username,
 		CreatedAt: u.CreatedAt,
 		IsActive:  u.IsActive,
+
+		EmailVerified: u.EmailVerified,
+		Role:          u.Role,
+		DeletedAt:     u.DeletedAt,
 	}
 }
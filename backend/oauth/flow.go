@@ -0,0 +1,299 @@
+package oauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/services"
+)
+
+// Errors returned by Flow
+var (
+	// ErrUnknownProvider is returned for a provider name that is not configured
+	ErrUnknownProvider = errors.New("unknown OAuth provider")
+
+	// ErrInvalidState is returned when the state parameter is malformed,
+	// tampered with, expired or issued for another provider
+	ErrInvalidState = errors.New("invalid OAuth state")
+
+	// ErrEmailNotVerified is returned when the provider has no verified email for the user
+	ErrEmailNotVerified = errors.New("OAuth provider did not return a verified email")
+
+	// ErrProvider is returned when a call to the provider fails
+	ErrProvider = errors.New("OAuth provider request failed")
+)
+
+// stateTTL bounds how long a user may take to authorize us
+const stateTTL = 10 * time.Minute
+
+// maxResponseBytes caps the size of provider responses
+const maxResponseBytes = 1 << 20
+
+// ExternalLoginer logs in users verified by an identity provider; AuthService implements it
+type ExternalLoginer interface {
+	LoginExternal(ctx context.Context, email, username string) (*models.LoginResponse, error)
+}
+
+// Ensure AuthService implements ExternalLoginer interface
+var _ ExternalLoginer = (*services.AuthService)(nil)
+
+// Flow runs the authorization-code flow against the configured providers
+// and logs the user in with our own tokens
+type Flow struct {
+	loginer   ExternalLoginer
+	stateKey  []byte
+	client    *http.Client
+	providers map[string]Provider
+}
+
+// NewFlow creates a flow signing its state parameters with stateKey and
+// calling providers with client (http.DefaultClient if nil)
+func NewFlow(loginer ExternalLoginer, stateKey []byte, client *http.Client, providers ...Provider) *Flow {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	flow := &Flow{
+		loginer:   loginer,
+		stateKey:  stateKey,
+		client:    client,
+		providers: make(map[string]Provider, len(providers)),
+	}
+	for _, provider := range providers {
+		flow.providers[provider.Name] = provider
+	}
+	return flow
+}
+
+// state is the payload of the signed state parameter
+type state struct {
+	Provider  string `json:"p"`
+	Nonce     string `json:"n"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// BeginOAuth returns the provider's authorization URL to redirect the user
+// to, carrying a signed state parameter that CompleteOAuth verifies
+func (f *Flow) BeginOAuth(providerName string) (string, error) {
+	provider, ok := f.providers[providerName]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	signed, err := f.signState(state{
+		Provider:  provider.Name,
+		Nonce:     base64.RawURLEncoding.EncodeToString(nonce),
+		ExpiresAt: time.Now().Add(stateTTL).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(provider.AuthURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid auth URL for %s: %w", provider.Name, err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", provider.ClientID)
+	query.Set("redirect_uri", provider.RedirectURL)
+	query.Set("scope", strings.Join(provider.Scopes, " "))
+	query.Set("state", signed)
+	authURL.RawQuery = query.Encode()
+	return authURL.String(), nil
+}
+
+// CompleteOAuth handles the provider's callback: it verifies state,
+// exchanges code for an access token, fetches the user's profile and logs
+// in the local user with the profile's verified email, creating it if needed
+func (f *Flow) CompleteOAuth(ctx context.Context, providerName, code, rawState string) (*models.LoginResponse, error) {
+	provider, ok := f.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	if err := f.verifyState(rawState, provider.Name); err != nil {
+		return nil, err
+	}
+	if code == "" {
+		return nil, fmt.Errorf("%w: missing authorization code", ErrProvider)
+	}
+
+	accessToken, err := f.exchange(ctx, provider, code)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := f.fetchProfile(ctx, provider, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Email == "" || !profile.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	return f.loginer.LoginExternal(ctx, profile.Email, profile.Username)
+}
+
+// signState encodes s as "<payload>.<signature>", both base64url
+func (f *Flow) signState(s state) (string, error) {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode state: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(f.mac(encoded)), nil
+}
+
+// verifyState checks the signature, expiry and provider of a state parameter
+func (f *Flow) verifyState(raw, providerName string) error {
+	encoded, signature, ok := strings.Cut(raw, ".")
+	if !ok {
+		return ErrInvalidState
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sum, f.mac(encoded)) {
+		return ErrInvalidState
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidState
+	}
+	var s state
+	if err := json.Unmarshal(payload, &s); err != nil {
+		return ErrInvalidState
+	}
+	if s.Provider != providerName || time.Now().Unix() > s.ExpiresAt {
+		return ErrInvalidState
+	}
+	return nil
+}
+
+// mac returns the HMAC-SHA256 of data under the state key
+func (f *Flow) mac(data string) []byte {
+	h := hmac.New(sha256.New, f.stateKey)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// exchange trades an authorization code for an access token
+func (f *Flow) exchange(ctx context.Context, provider Provider, code string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.RedirectURL},
+		"client_id":     {provider.ClientID},
+		"client_secret": {provider.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := f.doJSON(req, &token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("%w: token exchange failed: %s", ErrProvider, token.Error)
+	}
+	return token.AccessToken, nil
+}
+
+// fetchProfile reads the user's profile, and their emails if the provider
+// lists them separately
+func (f *Flow) fetchProfile(ctx context.Context, provider Provider, accessToken string) (*Profile, error) {
+	var info struct {
+		Sub           string      `json:"sub"`
+		ID            json.Number `json:"id"`
+		Email         string      `json:"email"`
+		EmailVerified bool        `json:"email_verified"`
+		Login         string      `json:"login"`
+		Name          string      `json:"name"`
+	}
+	if err := f.getJSON(ctx, provider.UserInfoURL, accessToken, &info); err != nil {
+		return nil, err
+	}
+
+	profile := &Profile{
+		Subject:       info.Sub,
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		Username:      info.Login,
+	}
+	if profile.Subject == "" {
+		profile.Subject = info.ID.String()
+	}
+	if profile.Username == "" {
+		profile.Username = info.Name
+	}
+
+	if provider.EmailsURL != "" {
+		var emails []struct {
+			Email    string `json:"email"`
+			Primary  bool   `json:"primary"`
+			Verified bool   `json:"verified"`
+		}
+		if err := f.getJSON(ctx, provider.EmailsURL, accessToken, &emails); err != nil {
+			return nil, err
+		}
+		profile.Email, profile.EmailVerified = "", false
+		for _, email := range emails {
+			if email.Primary && email.Verified {
+				profile.Email, profile.EmailVerified = email.Email, true
+			}
+		}
+	}
+	return profile, nil
+}
+
+// getJSON GETs url with the access token and decodes the JSON response into v
+func (f *Flow) getJSON(ctx context.Context, url, accessToken string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	return f.doJSON(req, v)
+}
+
+// doJSON sends req and decodes a 2xx JSON response into v
+func (f *Flow) doJSON(req *http.Request, v interface{}) error {
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: %s returned %s", ErrProvider, req.URL.Host, resp.Status)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: invalid response from %s: %v", ErrProvider, req.URL.Host, err)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+	"GateKeeper/services"
+)
+
+// nopAuditLogger discards audit events
+type nopAuditLogger struct{}
+
+func (nopAuditLogger) Log(context.Context, models.AuthEvent) error { return nil }
+
+// fakeProvider is an OAuth provider accepting the code "good-code" and
+// serving profile, and emails if set, for the access token it issues
+type fakeProvider struct {
+	*httptest.Server
+	profile map[string]any
+	emails  []map[string]any
+}
+
+// newFakeProvider starts a provider serving profile
+func newFakeProvider(t *testing.T, profile map[string]any) *fakeProvider {
+	t.Helper()
+	p := &fakeProvider{profile: profile}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good-code" || r.FormValue("client_secret") != "secret" ||
+			r.FormValue("grant_type") != "authorization_code" || r.FormValue("redirect_uri") != "https://app.example.com/callback" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "provider-token"})
+	})
+	mux.HandleFunc("GET /userinfo", p.authorized(func(w http.ResponseWriter) { json.NewEncoder(w).Encode(p.profile) }))
+	mux.HandleFunc("GET /emails", p.authorized(func(w http.ResponseWriter) { json.NewEncoder(w).Encode(p.emails) }))
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// authorized serves requests carrying the issued access token with serve
+func (p *fakeProvider) authorized(serve func(http.ResponseWriter)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer provider-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		serve(w)
+	}
+}
+
+// config returns the provider's configuration under name
+func (p *fakeProvider) config(name string) Provider {
+	provider := Provider{
+		Name:         name,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		AuthURL:      p.URL + "/authorize?prompt=consent",
+		TokenURL:     p.URL + "/token",
+		UserInfoURL:  p.URL + "/userinfo",
+		RedirectURL:  "https://app.example.com/callback",
+		Scopes:       []string{"openid", "email"},
+	}
+	if p.emails != nil {
+		provider.EmailsURL = p.URL + "/emails"
+	}
+	return provider
+}
+
+// newTestFlow returns a flow logging in through a new in-memory AuthService
+func newTestFlow(t *testing.T, providers ...Provider) (*Flow, *services.AuthService) {
+	t.Helper()
+	hasher, err := services.NewBcryptHasher(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := services.NewAuthServiceWithConfig(repository.NewMemoryUserRepository(), services.DefaultTokenConfig(),
+		services.WithPasswordHasher(hasher), services.WithAuditLogger(nopAuditLogger{}))
+	return NewFlow(s, []byte("state-key"), nil, providers...), s
+}
+
+// beginState starts the flow for provider and returns the state parameter
+func beginState(t *testing.T, flow *Flow, provider string) string {
+	t.Helper()
+	redirect, err := flow.BeginOAuth(provider)
+	if err != nil {
+		t.Fatalf("BeginOAuth: %v", err)
+	}
+	u, err := url.Parse(redirect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query().Get("state")
+}
+
+// googleProfile is a verified OpenID Connect profile
+var googleProfile = map[string]any{"sub": "123", "email": "Ada@Example.com", "email_verified": true, "name": "ada"}
+
+func TestBeginOAuth(t *testing.T) {
+	provider := newFakeProvider(t, googleProfile)
+	flow, _ := newTestFlow(t, provider.config("google"))
+
+	redirect, err := flow.BeginOAuth("google")
+	if err != nil {
+		t.Fatalf("BeginOAuth: %v", err)
+	}
+	u, err := url.Parse(redirect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if u.Path != "/authorize" || query.Get("prompt") != "consent" || query.Get("response_type") != "code" ||
+		query.Get("client_id") != "client" || query.Get("redirect_uri") != "https://app.example.com/callback" ||
+		query.Get("scope") != "openid email" || query.Get("state") == "" {
+		t.Errorf("redirect = %s, want the authorization URL with our parameters", redirect)
+	}
+	if other := beginState(t, flow, "google"); other == query.Get("state") {
+		t.Error("two flows got the same state")
+	}
+
+	if _, err := flow.BeginOAuth("myspace"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("BeginOAuth(unknown) = %v, want ErrUnknownProvider", err)
+	}
+}
+
+func TestCompleteOAuthState(t *testing.T) {
+	provider := newFakeProvider(t, googleProfile)
+	flow, _ := newTestFlow(t, provider.config("google"), provider.config("github"))
+	otherKey := NewFlow(nil, []byte("another-key"), nil, provider.config("google"))
+	valid := beginState(t, flow, "google")
+	payload, signature, _ := strings.Cut(valid, ".")
+
+	expired, err := flow.signState(state{Provider: "google", Nonce: "n", ExpiresAt: time.Now().Add(-time.Second).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered, err := flow.signState(state{Provider: "google", Nonce: "n", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tamperedPayload, _, _ := strings.Cut(tampered, ".")
+
+	for _, tc := range []struct {
+		name     string
+		provider string
+		state    string
+	}{
+		{"empty", "google", ""},
+		{"unsigned", "google", payload},
+		{"tampered payload", "google", tamperedPayload + "." + signature},
+		{"tampered signature", "google", payload + ".AAAA"},
+		{"signed with another key", "google", beginState(t, otherKey, "google")},
+		{"issued for another provider", "github", valid},
+		{"expired", "google", expired},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := flow.CompleteOAuth(context.Background(), tc.provider, "good-code", tc.state); !errors.Is(err, ErrInvalidState) {
+				t.Fatalf("CompleteOAuth = %v, want ErrInvalidState", err)
+			}
+		})
+	}
+}
+
+func TestCompleteOAuthCreatesUser(t *testing.T) {
+	ctx := context.Background()
+	provider := newFakeProvider(t, googleProfile)
+	flow, s := newTestFlow(t, provider.config("google"))
+
+	login, err := flow.CompleteOAuth(ctx, "google", "good-code", beginState(t, flow, "google"))
+	if err != nil {
+		t.Fatalf("CompleteOAuth: %v", err)
+	}
+	if login.User.Email != "ada@example.com" || login.User.Username != "ada" || !login.User.EmailVerified {
+		t.Errorf("user = %+v, want a verified ada@example.com", login.User)
+	}
+	if user, err := s.ValidateToken(ctx, login.AccessToken); err != nil || user.ID != login.User.ID {
+		t.Errorf("ValidateToken(issued token) = %v, %v; want the new user", user, err)
+	}
+
+	// Logging in again finds the same user
+	again, err := flow.CompleteOAuth(ctx, "google", "good-code", beginState(t, flow, "google"))
+	if err != nil || again.User.ID != login.User.ID {
+		t.Errorf("second CompleteOAuth = %v, %v; want user %d", again, err, login.User.ID)
+	}
+}
+
+func TestCompleteOAuthLinksExistingUser(t *testing.T) {
+	ctx := context.Background()
+	provider := newFakeProvider(t, googleProfile)
+	flow, s := newTestFlow(t, provider.config("google"))
+	existing, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "ada@example.com", Username: "lovelace", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	login, err := flow.CompleteOAuth(ctx, "google", "good-code", beginState(t, flow, "google"))
+	if err != nil {
+		t.Fatalf("CompleteOAuth: %v", err)
+	}
+	if login.User.ID != existing.ID || login.User.Username != "lovelace" || !login.User.EmailVerified {
+		t.Errorf("user = %+v, want the existing user %d, now verified", login.User, existing.ID)
+	}
+	// Whoever set the password before the email was verified loses it
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "ada@example.com", Password: "secret1"}); !errors.Is(err, services.ErrInvalidCredentials) {
+		t.Errorf("password login after linking = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestCompleteOAuthEmails(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		profile map[string]any
+		emails  []map[string]any
+		want    error
+	}{
+		{"unverified profile email", map[string]any{"sub": "1", "email": "ada@example.com", "email_verified": false}, nil, ErrEmailNotVerified},
+		{"no profile email", map[string]any{"sub": "1"}, nil, ErrEmailNotVerified},
+		{"verified primary email", map[string]any{"id": 1, "login": "ada"}, []map[string]any{
+			{"email": "old@example.com", "primary": false, "verified": true},
+			{"email": "ada@example.com", "primary": true, "verified": true},
+		}, nil},
+		{"unverified primary email", map[string]any{"id": 1, "login": "ada", "email": "ada@example.com"}, []map[string]any{
+			{"email": "ada@example.com", "primary": true, "verified": false},
+		}, ErrEmailNotVerified},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := newFakeProvider(t, tc.profile)
+			provider.emails = tc.emails
+			flow, _ := newTestFlow(t, provider.config("github"))
+
+			login, err := flow.CompleteOAuth(context.Background(), "github", "good-code", beginState(t, flow, "github"))
+			if !errors.Is(err, tc.want) || (tc.want == nil && err != nil) {
+				t.Fatalf("CompleteOAuth = %v, want %v", err, tc.want)
+			}
+			if tc.want == nil && login.User.Email != "ada@example.com" {
+				t.Errorf("user email = %q, want the verified primary email", login.User.Email)
+			}
+		})
+	}
+}
+
+func TestCompleteOAuthProviderErrors(t *testing.T) {
+	provider := newFakeProvider(t, googleProfile)
+	broken := provider.config("broken")
+	broken.UserInfoURL = provider.URL + "/missing"
+	flow, _ := newTestFlow(t, provider.config("google"), broken)
+
+	if _, err := flow.CompleteOAuth(context.Background(), "google", "bad-code", beginState(t, flow, "google")); !errors.Is(err, ErrProvider) {
+		t.Errorf("CompleteOAuth(bad code) = %v, want ErrProvider", err)
+	}
+	if _, err := flow.CompleteOAuth(context.Background(), "google", "", beginState(t, flow, "google")); !errors.Is(err, ErrProvider) {
+		t.Errorf("CompleteOAuth(no code) = %v, want ErrProvider", err)
+	}
+	if _, err := flow.CompleteOAuth(context.Background(), "broken", "good-code", beginState(t, flow, "broken")); !errors.Is(err, ErrProvider) {
+		t.Errorf("CompleteOAuth(failing userinfo) = %v, want ErrProvider", err)
+	}
+	if _, err := flow.CompleteOAuth(context.Background(), "myspace", "good-code", "state"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("CompleteOAuth(unknown provider) = %v, want ErrUnknownProvider", err)
+	}
+}
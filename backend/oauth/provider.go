@@ -0,0 +1,73 @@
+package oauth
+
+// Provider describes an OAuth 2.0 identity provider supporting the
+// authorization-code flow
+type Provider struct {
+	// Name identifies the provider in URLs, e.g. "google"
+	Name string
+
+	// ClientID and ClientSecret are the credentials of our OAuth app
+	ClientID     string
+	ClientSecret string
+
+	// AuthURL is where the user is sent to authorize us
+	AuthURL string
+
+	// TokenURL exchanges an authorization code for an access token
+	TokenURL string
+
+	// UserInfoURL returns the user's profile for an access token
+	UserInfoURL string
+
+	// EmailsURL, if set, lists the user's email addresses with their
+	// verification state, for providers whose profile lacks it
+	EmailsURL string
+
+	// RedirectURL is our callback URL registered with the provider
+	RedirectURL string
+
+	// Scopes are the scopes requested
+	Scopes []string
+}
+
+// Google returns the configuration of Google's OpenID Connect endpoints
+func Google(clientID, clientSecret, redirectURL string) Provider {
+	return Provider{
+		Name:         "google",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:     "https://oauth2.googleapis.com/token",
+		UserInfoURL:  "https://openidconnect.googleapis.com/v1/userinfo",
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+}
+
+// GitHub returns the configuration of GitHub's OAuth endpoints
+func GitHub(clientID, clientSecret, redirectURL string) Provider {
+	return Provider{
+		Name:         "github",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthURL:      "https://github.com/login/oauth/authorize",
+		TokenURL:     "https://github.com/login/oauth/access_token",
+		UserInfoURL:  "https://api.github.com/user",
+		EmailsURL:    "https://api.github.com/user/emails",
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"read:user", "user:email"},
+	}
+}
+
+// Profile is the part of a provider's user profile we use
+type Profile struct {
+	// Subject is the provider's ID for the user
+	Subject string
+
+	// Email is the user's email and EmailVerified whether the provider verified it
+	Email         string
+	EmailVerified bool
+
+	// Username is the user's login or display name
+	Username string
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"GateKeeper/models"
+)
+
+// APIKeyStore stores API keys. Lookups return ErrNotFound for unknown keys.
+// Implementations must be safe for concurrent use.
+type APIKeyStore interface {
+	// Create stores a new key and sets its ID
+	Create(ctx context.Context, key *models.APIKey) error
+
+	// GetByPrefix returns the key with the given prefix
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+
+	// ListForUser returns the user's keys, revoked ones included, ordered by ID
+	ListForUser(ctx context.Context, userID int) ([]*models.APIKey, error)
+
+	// Revoke marks the user's key revoked at revokedAt
+	Revoke(ctx context.Context, userID, id int, revokedAt time.Time) error
+
+	// Touch records that the key was used at usedAt
+	Touch(ctx context.Context, id int, usedAt time.Time) error
+}
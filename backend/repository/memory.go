@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"GateKeeper/models"
+)
+
+// MemoryUserRepository is an in-memory UserRepository, for tests and demos
+type MemoryUserRepository struct {
+	// mu guards all fields below; Create holds it across the duplicate
+	// checks, ID assignment and insert
+	mu     sync.RWMutex
+	nextID int
+	byID   map[int]*models.User
+
+	// byEmail and byUsername index users by normalized email and username key
+	byEmail    map[string]*models.User
+	byUsername map[string]*models.User
+
+	// deleted holds the deleted users by ID, outside the indexes above
+	deleted map[int]*models.User
+}
+
+// Ensure MemoryUserRepository implements UserRepository interface
+var _ UserRepository = (*MemoryUserRepository)(nil)
+
+// NewMemoryUserRepository creates an empty in-memory repository
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		byID:       make(map[int]*models.User),
+		byEmail:    make(map[string]*models.User),
+		byUsername: make(map[string]*models.User),
+		deleted:    make(map[int]*models.User),
+	}
+}
+
+// Create stores a copy of user and sets its ID
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byEmail[models.NormalizeEmail(user.Email)]; exists {
+		return ErrDuplicateEmail
+	}
+	if _, exists := r.byUsername[models.UsernameKey(user.Username)]; exists {
+		return ErrDuplicateUsername
+	}
+	r.nextID++
+	user.ID = r.nextID
+
+	stored := *user
+	r.index(&stored)
+	return nil
+}
+
+// GetByEmail returns a copy of the user with the given email, ignoring case
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, exists := r.byEmail[models.NormalizeEmail(email)]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	found := *user
+	return &found, nil
+}
+
+// GetByID returns a copy of the user with the given ID
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, exists := r.byID[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	found := *user
+	return &found, nil
+}
+
+// Update replaces the stored user with a copy of user
+func (r *MemoryUserRepository) Update(ctx context.Context, user *models.User, unmodifiedSince time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, exists := r.byID[user.ID]
+	if !exists {
+		return ErrNotFound
+	}
+	if !current.UpdatedAt.Equal(unmodifiedSince) {
+		return ErrConflict
+	}
+	if other, taken := r.byEmail[models.NormalizeEmail(user.Email)]; taken && other.ID != user.ID {
+		return ErrDuplicateEmail
+	}
+	if other, taken := r.byUsername[models.UsernameKey(user.Username)]; taken && other.ID != user.ID {
+		return ErrDuplicateUsername
+	}
+
+	stored := *user
+	r.unindex(current)
+	if stored.DeletedAt != nil {
+		r.deleted[stored.ID] = &stored
+	} else {
+		r.index(&stored)
+	}
+	return nil
+}
+
+// List returns copies of the page of users selected by params
+func (r *MemoryUserRepository) List(ctx context.Context, params models.ListUsersParams) ([]*models.User, int, error) {
+	return r.Search(ctx, models.SearchParams{ListUsersParams: params})
+}
+
+// Search returns copies of the page of users matching params
+func (r *MemoryUserRepository) Search(ctx context.Context, params models.SearchParams) ([]*models.User, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*models.User, 0, len(r.byID))
+	scanned := 0
+	for _, user := range r.byID {
+		// Stop scanning large stores once the caller has gone
+		if scanned++; scanned%listCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, 0, err
+			}
+		}
+		if !memoryMatches(user, params) {
+			continue
+		}
+		found := *user
+		users = append(users, &found)
+	}
+
+	less := memoryLess(params.SortBy)
+	desc := params.SortDir == "desc"
+	sort.Slice(users, func(i, j int) bool {
+		a, b := users[i], users[j]
+		if desc {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return a.ID < b.ID
+	})
+
+	total := len(users)
+	if params.Offset >= total {
+		return []*models.User{}, total, nil
+	}
+	return users[params.Offset:min(params.Offset+params.Limit, total)], total, nil
+}
+
+// listCheckInterval is how many users List scans between context checks
+const listCheckInterval = 256
+
+// memoryMatches reports whether user meets the criteria of params
+func memoryMatches(user *models.User, params models.SearchParams) bool {
+	switch {
+	case params.ActiveOnly && !user.IsActive:
+		return false
+	case params.Active != nil && user.IsActive != *params.Active:
+		return false
+	case params.Role != "" && user.Role != params.Role:
+		return false
+	case !params.CreatedAfter.IsZero() && user.CreatedAt.Before(params.CreatedAfter):
+		return false
+	case !params.CreatedBefore.IsZero() && !user.CreatedAt.Before(params.CreatedBefore):
+		return false
+	case !containsFold(user.Email, params.EmailContains):
+		return false
+	case !containsFold(user.Username, params.UsernameContains):
+		return false
+	}
+	return true
+}
+
+// containsFold reports whether s contains substr, ignoring case
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// memoryLess returns the ordering of users by the given sort field, by
+// DefaultSortField if it is unknown
+func memoryLess(sortBy string) func(a, b *models.User) bool {
+	switch sortBy {
+	case "id":
+		return func(a, b *models.User) bool { return a.ID < b.ID }
+	case "email":
+		return func(a, b *models.User) bool { return a.Email < b.Email }
+	case "username":
+		return func(a, b *models.User) bool { return a.Username < b.Username }
+	case "updated_at":
+		return func(a, b *models.User) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	default:
+		return func(a, b *models.User) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	}
+}
+
+// Deactivate marks the user inactive
+func (r *MemoryUserRepository) Deactivate(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, exists := r.byID[id]
+	if !exists {
+		return ErrNotFound
+	}
+	user.IsActive = false
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+// Reactivate marks the user active again
+func (r *MemoryUserRepository) Reactivate(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, exists := r.byID[id]
+	if !exists {
+		return ErrNotFound
+	}
+	user.IsActive = true
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+// ListDeleted returns copies of the deleted users, ordered by DeletedAt then ID
+func (r *MemoryUserRepository) ListDeleted(ctx context.Context) ([]*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*models.User, 0, len(r.deleted))
+	for _, user := range r.deleted {
+		found := *user
+		users = append(users, &found)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		a, b := users[i], users[j]
+		if !a.DeletedAt.Equal(*b.DeletedAt) {
+			return a.DeletedAt.Before(*b.DeletedAt)
+		}
+		return a.ID < b.ID
+	})
+	return users, nil
+}
+
+// Restore undeletes the user if it was deleted at or after deletedSince
+func (r *MemoryUserRepository) Restore(ctx context.Context, id int, deletedSince time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, exists := r.deleted[id]
+	if !exists || user.DeletedAt.Before(deletedSince) {
+		return ErrNotFound
+	}
+	if _, taken := r.byEmail[models.NormalizeEmail(user.Email)]; taken {
+		return ErrDuplicateEmail
+	}
+	if _, taken := r.byUsername[models.UsernameKey(user.Username)]; taken {
+		return ErrDuplicateUsername
+	}
+
+	delete(r.deleted, id)
+	user.DeletedAt = nil
+	user.UpdatedAt = time.Now()
+	r.index(user)
+	return nil
+}
+
+// Purge removes the users deleted before deletedBefore
+func (r *MemoryUserRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for id, user := range r.deleted {
+		if user.DeletedAt.Before(deletedBefore) {
+			delete(r.deleted, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// index adds user to the maps; r.mu must be held
+func (r *MemoryUserRepository) index(user *models.User) {
+	r.byID[user.ID] = user
+	r.byEmail[models.NormalizeEmail(user.Email)] = user
+	r.byUsername[models.UsernameKey(user.Username)] = user
+}
+
+// unindex removes user from the maps; r.mu must be held
+func (r *MemoryUserRepository) unindex(user *models.User) {
+	delete(r.byID, user.ID)
+	delete(r.byEmail, models.NormalizeEmail(user.Email))
+	delete(r.byUsername, models.UsernameKey(user.Username))
+}
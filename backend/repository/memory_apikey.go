@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"GateKeeper/models"
+)
+
+// MemoryAPIKeyStore is an in-memory APIKeyStore, for tests and demos
+type MemoryAPIKeyStore struct {
+	mu       sync.RWMutex
+	nextID   int
+	keys     map[int]*models.APIKey
+	byPrefix map[string]*models.APIKey
+}
+
+// Ensure MemoryAPIKeyStore implements APIKeyStore interface
+var _ APIKeyStore = (*MemoryAPIKeyStore)(nil)
+
+// NewMemoryAPIKeyStore creates an empty in-memory API key store
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{
+		keys:     make(map[int]*models.APIKey),
+		byPrefix: make(map[string]*models.APIKey),
+	}
+}
+
+// Create stores a copy of key and sets its ID
+func (m *MemoryAPIKeyStore) Create(ctx context.Context, key *models.APIKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	key.ID = m.nextID
+
+	stored := copyAPIKey(key)
+	m.keys[stored.ID] = stored
+	m.byPrefix[stored.Prefix] = stored
+	return nil
+}
+
+// GetByPrefix returns a copy of the key with the given prefix
+func (m *MemoryAPIKeyStore) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, exists := m.byPrefix[prefix]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return copyAPIKey(key), nil
+}
+
+// ListForUser returns copies of the user's keys ordered by ID
+func (m *MemoryAPIKeyStore) ListForUser(ctx context.Context, userID int) ([]*models.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := []*models.APIKey{}
+	for _, key := range m.keys {
+		if key.UserID == userID {
+			keys = append(keys, copyAPIKey(key))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+// Revoke marks the user's key revoked at revokedAt
+func (m *MemoryAPIKeyStore) Revoke(ctx context.Context, userID, id int, revokedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, exists := m.keys[id]
+	if !exists || key.UserID != userID {
+		return ErrNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &revokedAt
+	}
+	return nil
+}
+
+// Touch records that the key was used at usedAt
+func (m *MemoryAPIKeyStore) Touch(ctx context.Context, id int, usedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, exists := m.keys[id]
+	if !exists {
+		return ErrNotFound
+	}
+	key.LastUsedAt = &usedAt
+	return nil
+}
+
+// copyAPIKey returns a deep copy of key
+func copyAPIKey(key *models.APIKey) *models.APIKey {
+	copied := *key
+	copied.Scopes = slices.Clone(key.Scopes)
+	return &copied
+}
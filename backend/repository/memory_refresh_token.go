@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"GateKeeper/models"
+)
+
+// memoryPruneInterval is how often MemoryRefreshTokenStore.Create drops
+// expired tokens
+const memoryPruneInterval = time.Minute
+
+// MemoryRefreshTokenStore is an in-memory RefreshTokenStore, for tests and demos
+type MemoryRefreshTokenStore struct {
+	mu        sync.RWMutex
+	tokens    map[string]*models.RefreshToken
+	nextPrune time.Time
+}
+
+// Ensure MemoryRefreshTokenStore implements RefreshTokenStore interface
+var _ RefreshTokenStore = (*MemoryRefreshTokenStore)(nil)
+
+// NewMemoryRefreshTokenStore creates an empty in-memory refresh token store
+func NewMemoryRefreshTokenStore() *MemoryRefreshTokenStore {
+	return &MemoryRefreshTokenStore{tokens: make(map[string]*models.RefreshToken)}
+}
+
+// Create stores a copy of token, dropping expired tokens at most once a
+// minute
+func (m *MemoryRefreshTokenStore) Create(ctx context.Context, token *models.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now := time.Now(); now.After(m.nextPrune) {
+		m.deleteExpiredLocked(now)
+		m.nextPrune = now.Add(memoryPruneInterval)
+	}
+
+	stored := *token
+	m.tokens[stored.Hash] = &stored
+	return nil
+}
+
+// Get returns a copy of the token with the given hash
+func (m *MemoryRefreshTokenStore) Get(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	token, exists := m.tokens[hash]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	found := *token
+	return &found, nil
+}
+
+// Rotate marks the token rotated unless it already was or was revoked
+func (m *MemoryRefreshTokenStore) Rotate(ctx context.Context, hash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, exists := m.tokens[hash]
+	switch {
+	case !exists:
+		return ErrNotFound
+	case token.Rotated || token.Revoked:
+		return ErrTokenRotated
+	}
+	token.Rotated = true
+	return nil
+}
+
+// Revoke marks the token revoked
+func (m *MemoryRefreshTokenStore) Revoke(ctx context.Context, hash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, exists := m.tokens[hash]
+	if !exists {
+		return ErrNotFound
+	}
+	token.Revoked = true
+	return nil
+}
+
+// RevokeFamily revokes every token of family and returns how many were live
+func (m *MemoryRefreshTokenStore) RevokeFamily(ctx context.Context, family string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	revoked := 0
+	for _, token := range m.tokens {
+		if token.Family != family || token.Revoked {
+			continue
+		}
+		if !token.Rotated {
+			revoked++
+		}
+		token.Revoked = true
+	}
+	return revoked, nil
+}
+
+// RevokeAllForUser revokes every token of the user
+func (m *MemoryRefreshTokenStore) RevokeAllForUser(ctx context.Context, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, token := range m.tokens {
+		if token.UserID == userID {
+			token.Revoked = true
+		}
+	}
+	return nil
+}
+
+// DeleteExpired removes the tokens that expired before
+func (m *MemoryRefreshTokenStore) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.deleteExpiredLocked(before), nil
+}
+
+// deleteExpiredLocked removes the tokens that expired before and returns
+// how many it removed. The caller must hold m.mu.
+func (m *MemoryRefreshTokenStore) deleteExpiredLocked(before time.Time) int {
+	deleted := 0
+	for hash, token := range m.tokens {
+		if token.ExpiresAt.Before(before) {
+			delete(m.tokens, hash)
+			deleted++
+		}
+	}
+	return deleted
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"GateKeeper/models"
+)
+
+func TestMemoryRefreshTokenStoreRotateOnce(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryRefreshTokenStore()
+	if err := store.Create(ctx, &models.RefreshToken{Hash: "h", UserID: 1, Family: "f", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	var rotated atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch err := store.Rotate(ctx, "h"); {
+			case err == nil:
+				rotated.Add(1)
+			case !errors.Is(err, ErrTokenRotated):
+				t.Errorf("Rotate = %v, want ErrTokenRotated", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if rotated.Load() != 1 {
+		t.Fatalf("%d callers rotated the token, want 1", rotated.Load())
+	}
+
+	if err := store.Rotate(ctx, "unknown"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Rotate(unknown) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryRefreshTokenStoreRevokeFamily(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryRefreshTokenStore()
+	expires := time.Now().Add(time.Hour)
+	for _, token := range []models.RefreshToken{
+		{Hash: "rotated", Family: "f", ExpiresAt: expires, Rotated: true},
+		{Hash: "live", Family: "f", ExpiresAt: expires},
+		{Hash: "other", Family: "g", ExpiresAt: expires},
+	} {
+		if err := store.Create(ctx, &token); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	revoked, err := store.RevokeFamily(ctx, "f")
+	if err != nil || revoked != 1 {
+		t.Fatalf("RevokeFamily = %d, %v, want 1 live token revoked", revoked, err)
+	}
+	for hash, want := range map[string]bool{"rotated": true, "live": true, "other": false} {
+		token, err := store.Get(ctx, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.Revoked != want {
+			t.Errorf("%s revoked = %v, want %v", hash, token.Revoked, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"GateKeeper/models"
+)
+
+// MemorySessionStore is an in-memory SessionStore, for tests and demos
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*models.Session
+}
+
+// Ensure MemorySessionStore implements SessionStore interface
+var _ SessionStore = (*MemorySessionStore)(nil)
+
+// NewMemorySessionStore creates an empty in-memory session store
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]*models.Session)}
+}
+
+// Create stores a copy of session, dropping expired sessions
+func (m *MemorySessionStore) Create(ctx context.Context, session *models.Session) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for id, stored := range m.sessions {
+		if now.After(stored.ExpiresAt) {
+			delete(m.sessions, id)
+		}
+	}
+
+	stored := *session
+	m.sessions[stored.ID] = &stored
+	return nil
+}
+
+// Get returns a copy of the session with the given ID
+func (m *MemorySessionStore) Get(ctx context.Context, id string) (*models.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	found := *session
+	return &found, nil
+}
+
+// Refresh records activity on the session at lastSeenAt
+func (m *MemorySessionStore) Refresh(ctx context.Context, id string, lastSeenAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[id]
+	if !exists {
+		return ErrNotFound
+	}
+	session.LastSeenAt = lastSeenAt
+	return nil
+}
+
+// Delete removes the session
+func (m *MemorySessionStore) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, id)
+	return nil
+}
+
+// DeleteAllForUser removes every session of the user
+func (m *MemorySessionStore) DeleteAllForUser(ctx context.Context, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, session := range m.sessions {
+		if session.UserID == userID {
+			delete(m.sessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"GateKeeper/models"
+)
+
+// countdownContext is canceled once Err has been called calls times,
+// like a request abandoned partway through a slow operation
+type countdownContext struct {
+	context.Context
+	calls int
+}
+
+func (c *countdownContext) Err() error {
+	if c.calls--; c.calls < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestMemoryListStopsWhenCancelled(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	for i := 0; i < 3*listCheckInterval; i++ {
+		mustCreate(t, repo, fmt.Sprintf("user%d@example.com", i), fmt.Sprintf("user%d", i))
+	}
+
+	// The entry check passes and the first check while scanning fails
+	ctx := &countdownContext{Context: context.Background(), calls: 1}
+	if _, _, err := repo.List(ctx, models.ListUsersParams{Limit: 10}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("List = %v, want context.Canceled from mid-scan", err)
+	}
+
+	if _, total, err := repo.List(context.Background(), models.ListUsersParams{Limit: 10}); err != nil || total != 3*listCheckInterval {
+		t.Fatalf("List = %d, %v; want all %d users", total, err, 3*listCheckInterval)
+	}
+}
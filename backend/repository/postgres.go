@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"GateKeeper/db"
+	"GateKeeper/models"
+)
+
+// uniqueViolation is the Postgres error code for unique constraint violations
+const uniqueViolation = "23505"
+
+// emailIndex and usernameIndex are the unique indexes on the emails and
+// usernames of users that are not deleted (see
+// migrations/0009_soft_delete_users.sql)
+const (
+	emailIndex    = "users_email_lower_key"
+	usernameIndex = "users_username_lower_key"
+)
+
+// DBTX is the subset of pgx used by the Postgres stores. It is satisfied by
+// *pgx.Conn, *pgxpool.Pool, pgx.Tx and *configurations.Pool. The user
+// repository, session store and API key store run their queries in the
+// transaction db.WithTx put in the context, if any; the audit log always
+// uses its own db, so events outlive a rolled back transaction.
+type DBTX = db.Querier
+
+// PostgresUserRepository stores users in the users table
+// (see migrations/0001_create_users.sql)
+type PostgresUserRepository struct {
+	db DBTX
+}
+
+// Ensure PostgresUserRepository implements UserRepository interface
+var _ UserRepository = (*PostgresUserRepository)(nil)
+
+// NewPostgresUserRepository creates a repository backed by db
+func NewPostgresUserRepository(db DBTX) *PostgresUserRepository {
+	return &PostgresUserRepository{db: db}
+}
+
+// userColumns lists the columns of models.User, which collectUser and
+// collectUsers match to its fields by their db tags
+const userColumns = "id, email, username, password, created_at, updated_at, is_active, password_changed_at, email_verified, role, deleted_at"
+
+// Create inserts user and sets its ID
+func (r *PostgresUserRepository) Create(ctx context.Context, user *models.User) error {
+	err := db.From(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO users (email, username, password, created_at, updated_at, is_active, password_changed_at, email_verified, role)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 RETURNING id`,
+		user.Email, user.Username, user.Password, user.CreatedAt, user.UpdatedAt, user.IsActive,
+		user.PasswordChangedAt, user.EmailVerified, user.Role,
+	).Scan(&user.ID)
+	if err != nil {
+		return mapError("create user", err)
+	}
+	return nil
+}
+
+// GetByEmail returns the user with the given email, ignoring case
+func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	rows, err := db.From(ctx, r.db).Query(ctx, `SELECT `+userColumns+` FROM users WHERE lower(email) = $1 AND deleted_at IS NULL`, models.NormalizeEmail(email))
+	user, err := collectUser(rows, err)
+	if err != nil {
+		return nil, mapError("get user by email", err)
+	}
+	return user, nil
+}
+
+// GetByID returns the user with the given ID
+func (r *PostgresUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	rows, err := db.From(ctx, r.db).Query(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1 AND deleted_at IS NULL`, id)
+	user, err := collectUser(rows, err)
+	if err != nil {
+		return nil, mapError("get user by id", err)
+	}
+	return user, nil
+}
+
+// Update saves every mutable column of user, if updated_at still equals unmodifiedSince
+func (r *PostgresUserRepository) Update(ctx context.Context, user *models.User, unmodifiedSince time.Time) error {
+	tag, err := db.From(ctx, r.db).Exec(ctx,
+		`UPDATE users
+		 SET email = $2, username = $3, password = $4, updated_at = $5, is_active = $6,
+		     password_changed_at = $7, email_verified = $8, role = $9, deleted_at = $10
+		 WHERE id = $1 AND updated_at = $11 AND deleted_at IS NULL`,
+		user.ID, user.Email, user.Username, user.Password, user.UpdatedAt, user.IsActive,
+		user.PasswordChangedAt, user.EmailVerified, user.Role, user.DeletedAt, unmodifiedSince,
+	)
+	if err != nil {
+		return mapError("update user", err)
+	}
+	if tag.RowsAffected() == 0 {
+		// Tell a missing user from a concurrent modification
+		var exists bool
+		if err := db.From(ctx, r.db).QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`, user.ID).Scan(&exists); err != nil {
+			return mapError("update user", err)
+		}
+		if exists {
+			return ErrConflict
+		}
+		return ErrNotFound
+	}
+	return nil
+}
+
+// sortColumns maps sort fields to columns; only these are interpolated into SQL
+var sortColumns = map[string]string{
+	"id":         "id",
+	"email":      "email",
+	"username":   "username",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// likeEscaper escapes the LIKE wildcards in a search term
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// List returns the page of users selected by params
+func (r *PostgresUserRepository) List(ctx context.Context, params models.ListUsersParams) ([]*models.User, int, error) {
+	return r.Search(ctx, models.SearchParams{ListUsersParams: params})
+}
+
+// Search returns the page of users matching params. Every criterion is
+// passed as a query parameter; only sort columns from sortColumns are
+// interpolated into the SQL.
+func (r *PostgresUserRepository) Search(ctx context.Context, params models.SearchParams) ([]*models.User, int, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []any
+	filter := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if params.ActiveOnly {
+		conditions = append(conditions, "is_active")
+	}
+	if params.Active != nil {
+		filter("is_active = $%d", *params.Active)
+	}
+	if params.Role != "" {
+		filter("role = $%d", params.Role)
+	}
+	if !params.CreatedAfter.IsZero() {
+		filter("created_at >= $%d", params.CreatedAfter)
+	}
+	if !params.CreatedBefore.IsZero() {
+		filter("created_at < $%d", params.CreatedBefore)
+	}
+	if params.EmailContains != "" {
+		filter("email ILIKE $%d", "%"+likeEscaper.Replace(params.EmailContains)+"%")
+	}
+	if params.UsernameContains != "" {
+		filter("username ILIKE $%d", "%"+likeEscaper.Replace(params.UsernameContains)+"%")
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	if err := db.From(ctx, r.db).QueryRow(ctx, `SELECT count(*) FROM users`+where, args...).Scan(&total); err != nil {
+		return nil, 0, mapError("count users", err)
+	}
+
+	column, ok := sortColumns[params.SortBy]
+	if !ok {
+		column = sortColumns[DefaultSortField]
+	}
+	dir := "ASC"
+	if params.SortDir == "desc" {
+		dir = "DESC"
+	}
+	args = append(args, params.Limit, params.Offset)
+	query := fmt.Sprintf(`SELECT %s FROM users%s ORDER BY %s %s, id %s LIMIT $%d OFFSET $%d`,
+		userColumns, where, column, dir, dir, len(args)-1, len(args))
+
+	rows, err := db.From(ctx, r.db).Query(ctx, query, args...)
+	users, err := collectUsers(rows, err)
+	if err != nil {
+		return nil, 0, mapError("list users", err)
+	}
+	return users, total, nil
+}
+
+// Deactivate marks the user inactive
+func (r *PostgresUserRepository) Deactivate(ctx context.Context, id int) error {
+	tag, err := db.From(ctx, r.db).Exec(ctx, `UPDATE users SET is_active = FALSE, updated_at = now() WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return mapError("deactivate user", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Reactivate marks the user active again
+func (r *PostgresUserRepository) Reactivate(ctx context.Context, id int) error {
+	tag, err := db.From(ctx, r.db).Exec(ctx, `UPDATE users SET is_active = TRUE, updated_at = now() WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return mapError("reactivate user", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListDeleted returns the deleted users, ordered by deleted_at then ID
+func (r *PostgresUserRepository) ListDeleted(ctx context.Context) ([]*models.User, error) {
+	rows, err := db.From(ctx, r.db).Query(ctx,
+		`SELECT `+userColumns+` FROM users WHERE deleted_at IS NOT NULL ORDER BY deleted_at, id`)
+	users, err := collectUsers(rows, err)
+	if err != nil {
+		return nil, mapError("list deleted users", err)
+	}
+	return users, nil
+}
+
+// Restore undeletes the user if it was deleted at or after deletedSince
+func (r *PostgresUserRepository) Restore(ctx context.Context, id int, deletedSince time.Time) error {
+	tag, err := db.From(ctx, r.db).Exec(ctx,
+		`UPDATE users SET deleted_at = NULL, updated_at = now() WHERE id = $1 AND deleted_at >= $2`,
+		id, deletedSince)
+	if err != nil {
+		return mapError("restore user", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Purge removes the users deleted before deletedBefore; their sessions and
+// API keys go with them
+func (r *PostgresUserRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	tag, err := db.From(ctx, r.db).Exec(ctx, `DELETE FROM users WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		return 0, mapError("purge users", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// collectUser returns the single user in rows, the result of a query
+// selecting userColumns, or pgx.ErrNoRows. Columns are matched to fields by
+// name, so their order does not matter.
+func collectUser(rows pgx.Rows, err error) (*models.User, error) {
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[models.User])
+}
+
+// collectUsers returns the users in rows, the result of a query selecting
+// userColumns, matching columns to fields by name
+func collectUsers(rows pgx.Rows, err error) ([]*models.User, error) {
+	if err != nil {
+		return nil, err
+	}
+	users, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[models.User])
+	if err != nil {
+		return nil, err
+	}
+	if users == nil {
+		users = []*models.User{}
+	}
+	return users, nil
+}
+
+// mapError translates pgx errors into repository errors
+func mapError(op string, err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		switch pgErr.ConstraintName {
+		case emailIndex:
+			return ErrDuplicateEmail
+		case usernameIndex:
+			return ErrDuplicateUsername
+		}
+	}
+	return fmt.Errorf("failed to %s: %w", op, err)
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"GateKeeper/db"
+	"GateKeeper/models"
+)
+
+// PostgresAPIKeyStore stores API keys in the api_keys table
+// (see migrations/0005_create_api_keys.sql)
+type PostgresAPIKeyStore struct {
+	db DBTX
+}
+
+// Ensure PostgresAPIKeyStore implements APIKeyStore interface
+var _ APIKeyStore = (*PostgresAPIKeyStore)(nil)
+
+// NewPostgresAPIKeyStore creates an API key store backed by db
+func NewPostgresAPIKeyStore(db DBTX) *PostgresAPIKeyStore {
+	return &PostgresAPIKeyStore{db: db}
+}
+
+// apiKeyColumns lists the columns scanned by scanAPIKey, in order
+const apiKeyColumns = "id, user_id, name, prefix, hash, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+// Create inserts key and sets its ID
+func (p *PostgresAPIKeyStore) Create(ctx context.Context, key *models.APIKey) error {
+	err := db.From(ctx, p.db).QueryRow(ctx,
+		`INSERT INTO api_keys (user_id, name, prefix, hash, scopes, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id`,
+		key.UserID, key.Name, key.Prefix, key.Hash, key.Scopes, key.CreatedAt, key.ExpiresAt,
+	).Scan(&key.ID)
+	if err != nil {
+		return mapError("create api key", err)
+	}
+	return nil
+}
+
+// GetByPrefix returns the key with the given prefix
+func (p *PostgresAPIKeyStore) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	row := db.From(ctx, p.db).QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = $1`, prefix)
+	key, err := scanAPIKey(row)
+	if err != nil {
+		return nil, mapError("get api key", err)
+	}
+	return key, nil
+}
+
+// ListForUser returns the user's keys ordered by ID
+func (p *PostgresAPIKeyStore) ListForUser(ctx context.Context, userID int) ([]*models.APIKey, error) {
+	rows, err := db.From(ctx, p.db).Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		return nil, mapError("list api keys", err)
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, mapError("list api keys", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapError("list api keys", err)
+	}
+	return keys, nil
+}
+
+// Revoke marks the user's key revoked at revokedAt
+func (p *PostgresAPIKeyStore) Revoke(ctx context.Context, userID, id int, revokedAt time.Time) error {
+	tag, err := db.From(ctx, p.db).Exec(ctx,
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $3) WHERE id = $1 AND user_id = $2`,
+		id, userID, revokedAt)
+	if err != nil {
+		return mapError("revoke api key", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Touch records that the key was used at usedAt
+func (p *PostgresAPIKeyStore) Touch(ctx context.Context, id int, usedAt time.Time) error {
+	if _, err := db.From(ctx, p.db).Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, usedAt); err != nil {
+		return mapError("touch api key", err)
+	}
+	return nil
+}
+
+// scanAPIKey scans a row selected with apiKeyColumns
+func scanAPIKey(row pgx.Row) (*models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &key.Scopes,
+		&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+
+	"GateKeeper/models"
+)
+
+// PostgresAuditLog appends authentication audit events to the auth_events
+// table (see migrations/0006_create_auth_events.sql), which rejects updates
+// and deletes
+type PostgresAuditLog struct {
+	db DBTX
+}
+
+// NewPostgresAuditLog creates an audit log backed by db
+func NewPostgresAuditLog(db DBTX) *PostgresAuditLog {
+	return &PostgresAuditLog{db: db}
+}
+
+// Log inserts event
+func (p *PostgresAuditLog) Log(ctx context.Context, event models.AuthEvent) error {
+	_, err := p.db.Exec(ctx,
+		`INSERT INTO auth_events (type, outcome, reason, actor_id, target_id, email, ip, user_agent, occurred_at)
+		 VALUES ($1, $2, $3, NULLIF($4, 0), NULLIF($5, 0), $6, $7, $8, $9)`,
+		string(event.Type), string(event.Outcome), event.Reason, event.ActorID, event.TargetID,
+		event.Email, event.IP, event.UserAgent, event.Time,
+	)
+	if err != nil {
+		return mapError("log auth event", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"GateKeeper/db"
+	"GateKeeper/models"
+)
+
+// PostgresRefreshTokenStore stores refresh tokens in the refresh_tokens
+// table (see migrations/0011_create_refresh_tokens.sql)
+type PostgresRefreshTokenStore struct {
+	db DBTX
+}
+
+// Ensure PostgresRefreshTokenStore implements RefreshTokenStore interface
+var _ RefreshTokenStore = (*PostgresRefreshTokenStore)(nil)
+
+// NewPostgresRefreshTokenStore creates a refresh token store backed by db
+func NewPostgresRefreshTokenStore(db DBTX) *PostgresRefreshTokenStore {
+	return &PostgresRefreshTokenStore{db: db}
+}
+
+// Create inserts token
+func (p *PostgresRefreshTokenStore) Create(ctx context.Context, token *models.RefreshToken) error {
+	_, err := db.From(ctx, p.db).Exec(ctx,
+		`INSERT INTO refresh_tokens (hash, user_id, family, created_at, expires_at, rotated, revoked)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		token.Hash, token.UserID, token.Family, token.CreatedAt, token.ExpiresAt, token.Rotated, token.Revoked,
+	)
+	if err != nil {
+		return mapError("create refresh token", err)
+	}
+	return nil
+}
+
+// Get returns the token with the given hash
+func (p *PostgresRefreshTokenStore) Get(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := db.From(ctx, p.db).QueryRow(ctx,
+		`SELECT hash, user_id, family, created_at, expires_at, rotated, revoked
+		 FROM refresh_tokens WHERE hash = $1`, hash,
+	).Scan(&token.Hash, &token.UserID, &token.Family, &token.CreatedAt, &token.ExpiresAt, &token.Rotated, &token.Revoked)
+	if err != nil {
+		return nil, mapError("get refresh token", err)
+	}
+	return &token, nil
+}
+
+// Rotate marks the token rotated unless it already was or was revoked; the
+// conditional update lets only one of concurrent callers succeed
+func (p *PostgresRefreshTokenStore) Rotate(ctx context.Context, hash string) error {
+	tag, err := db.From(ctx, p.db).Exec(ctx,
+		`UPDATE refresh_tokens SET rotated = true WHERE hash = $1 AND NOT rotated AND NOT revoked`, hash)
+	if err != nil {
+		return mapError("rotate refresh token", err)
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := p.Get(ctx, hash); err != nil {
+			return err
+		}
+		return ErrTokenRotated
+	}
+	return nil
+}
+
+// Revoke marks the token revoked
+func (p *PostgresRefreshTokenStore) Revoke(ctx context.Context, hash string) error {
+	tag, err := db.From(ctx, p.db).Exec(ctx, `UPDATE refresh_tokens SET revoked = true WHERE hash = $1`, hash)
+	if err != nil {
+		return mapError("revoke refresh token", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RevokeFamily revokes every token of family and returns how many were live
+func (p *PostgresRefreshTokenStore) RevokeFamily(ctx context.Context, family string) (int, error) {
+	var revoked int
+	err := db.From(ctx, p.db).QueryRow(ctx,
+		`WITH revoked AS (
+			UPDATE refresh_tokens SET revoked = true
+			WHERE family = $1 AND NOT revoked
+			RETURNING rotated
+		)
+		SELECT count(*) FILTER (WHERE NOT rotated) FROM revoked`, family,
+	).Scan(&revoked)
+	if err != nil {
+		return 0, mapError("revoke refresh token family", err)
+	}
+	return revoked, nil
+}
+
+// RevokeAllForUser revokes every token of the user
+func (p *PostgresRefreshTokenStore) RevokeAllForUser(ctx context.Context, userID int) error {
+	_, err := db.From(ctx, p.db).Exec(ctx,
+		`UPDATE refresh_tokens SET revoked = true WHERE user_id = $1 AND NOT revoked`, userID)
+	if err != nil {
+		return mapError("revoke user refresh tokens", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes the tokens that expired before
+func (p *PostgresRefreshTokenStore) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	tag, err := db.From(ctx, p.db).Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, mapError("delete expired refresh tokens", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"GateKeeper/db"
+	"GateKeeper/models"
+)
+
+// PostgresSessionStore stores sessions in the sessions table
+// (see migrations/0004_create_sessions.sql)
+type PostgresSessionStore struct {
+	db DBTX
+}
+
+// Ensure PostgresSessionStore implements SessionStore interface
+var _ SessionStore = (*PostgresSessionStore)(nil)
+
+// NewPostgresSessionStore creates a session store backed by db
+func NewPostgresSessionStore(db DBTX) *PostgresSessionStore {
+	return &PostgresSessionStore{db: db}
+}
+
+// Create inserts session, dropping expired sessions
+func (p *PostgresSessionStore) Create(ctx context.Context, session *models.Session) error {
+	if _, err := db.From(ctx, p.db).Exec(ctx, `DELETE FROM sessions WHERE expires_at < now()`); err != nil {
+		return mapError("prune sessions", err)
+	}
+	_, err := db.From(ctx, p.db).Exec(ctx,
+		`INSERT INTO sessions (id, user_id, created_at, last_seen_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		session.ID, session.UserID, session.CreatedAt, session.LastSeenAt, session.ExpiresAt,
+	)
+	if err != nil {
+		return mapError("create session", err)
+	}
+	return nil
+}
+
+// Get returns the session with the given ID
+func (p *PostgresSessionStore) Get(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	err := db.From(ctx, p.db).QueryRow(ctx,
+		`SELECT id, user_id, created_at, last_seen_at, expires_at FROM sessions WHERE id = $1`, id,
+	).Scan(&session.ID, &session.UserID, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt)
+	if err != nil {
+		return nil, mapError("get session", err)
+	}
+	return &session, nil
+}
+
+// Refresh records activity on the session at lastSeenAt
+func (p *PostgresSessionStore) Refresh(ctx context.Context, id string, lastSeenAt time.Time) error {
+	tag, err := db.From(ctx, p.db).Exec(ctx, `UPDATE sessions SET last_seen_at = $2 WHERE id = $1`, id, lastSeenAt)
+	if err != nil {
+		return mapError("refresh session", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes the session
+func (p *PostgresSessionStore) Delete(ctx context.Context, id string) error {
+	if _, err := db.From(ctx, p.db).Exec(ctx, `DELETE FROM sessions WHERE id = $1`, id); err != nil {
+		return mapError("delete session", err)
+	}
+	return nil
+}
+
+// DeleteAllForUser removes every session of the user
+func (p *PostgresSessionStore) DeleteAllForUser(ctx context.Context, userID int) error {
+	if _, err := db.From(ctx, p.db).Exec(ctx, `DELETE FROM sessions WHERE user_id = $1`, userID); err != nil {
+		return mapError("delete user sessions", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"GateKeeper/db"
+	"GateKeeper/models"
+)
+
+func TestUserColumnsMatchModel(t *testing.T) {
+	var tags []string
+	userType := reflect.TypeOf(models.User{})
+	for i := range userType.NumField() {
+		if tag := userType.Field(i).Tag.Get("db"); tag != "" && tag != "-" {
+			tags = append(tags, tag)
+		}
+	}
+
+	columns := strings.Split(userColumns, ", ")
+	if strings.Join(columns, " ") != strings.Join(tags, " ") {
+		t.Errorf("userColumns = %v, want the db tags of models.User %v", columns, tags)
+	}
+}
+
+func TestPostgresUserRepositoryCancelledMidQuery(t *testing.T) {
+	ctx := context.Background()
+	locker := testDatabase(t)
+
+	// A cancelled query may leave its connection unusable, so each gets its own
+	ops := map[string]func(ctx context.Context, repo UserRepository) error{
+		"GetByID": func(ctx context.Context, repo UserRepository) error {
+			_, err := repo.GetByID(ctx, 1)
+			return err
+		},
+		"Create": func(ctx context.Context, repo UserRepository) error {
+			return repo.Create(ctx, newTestUser("bob@example.com", "bob"))
+		},
+		"List": func(ctx context.Context, repo UserRepository) error {
+			_, _, err := repo.List(ctx, models.ListUsersParams{Limit: 10})
+			return err
+		},
+		"Deactivate": func(ctx context.Context, repo UserRepository) error {
+			return repo.Deactivate(ctx, 1)
+		},
+	}
+	repos := make(map[string]UserRepository, len(ops))
+	for name := range ops {
+		// testDatabase empties the table, so the user is created afterwards
+		repos[name] = NewPostgresUserRepository(testDatabase(t))
+	}
+	mustCreate(t, NewPostgresUserRepository(locker), "ada@example.com", "ada")
+
+	// Every query blocks on the lock until its deadline
+	tx, err := locker.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, "LOCK TABLE users IN ACCESS EXCLUSIVE MODE"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, op := range ops {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			if err := op(ctx, repos[name]); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("%s = %v, want context.DeadlineExceeded", name, err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("%s took %v, want it to return soon after the 100ms deadline", name, elapsed)
+			}
+		})
+	}
+}
+
+func TestPostgresUserRepositoryWithTx(t *testing.T) {
+	ctx := context.Background()
+	conn := testDatabase(t)
+	repo := NewPostgresUserRepository(conn)
+	failure := errors.New("second step failed")
+
+	err := db.WithTx(ctx, conn, func(ctx context.Context, tx pgx.Tx) error {
+		if err := repo.Create(ctx, newTestUser("ada@example.com", "ada")); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithTx = %v, want the second step's error", err)
+	}
+	if _, err := repo.GetByEmail(ctx, "ada@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByEmail after the rollback = %v, want ErrNotFound", err)
+	}
+
+	err = db.WithTx(ctx, conn, func(ctx context.Context, tx pgx.Tx) error {
+		if err := repo.Create(ctx, newTestUser("ada@example.com", "ada")); err != nil {
+			return err
+		}
+		// A duplicate fails the transaction, taking ada with it
+		return repo.Create(ctx, newTestUser("ADA@example.com", "ada2"))
+	})
+	if !errors.Is(err, ErrDuplicateEmail) {
+		t.Fatalf("WithTx = %v, want ErrDuplicateEmail", err)
+	}
+	if _, total, _ := repo.List(ctx, models.ListUsersParams{Limit: 10}); total != 0 {
+		t.Errorf("%d users after the rollback, want none", total)
+	}
+
+	if err := db.WithTx(ctx, conn, func(ctx context.Context, tx pgx.Tx) error {
+		return repo.Create(ctx, newTestUser("ada@example.com", "ada"))
+	}); err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if _, err := repo.GetByEmail(ctx, "ada@example.com"); err != nil {
+		t.Errorf("GetByEmail after the commit: %v", err)
+	}
+}
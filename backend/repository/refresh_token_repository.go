@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"GateKeeper/models"
+)
+
+// ErrTokenRotated is returned by RefreshTokenStore.Rotate for a token that
+// was already rotated or revoked
+var ErrTokenRotated = errors.New("refresh token was already rotated or revoked")
+
+// RefreshTokenStore stores refresh tokens by the hash of the token. Get
+// returns ErrNotFound for unknown tokens. Implementations must be safe for
+// concurrent use.
+type RefreshTokenStore interface {
+	// Create stores a new token
+	Create(ctx context.Context, token *models.RefreshToken) error
+
+	// Get returns the token with the given hash
+	Get(ctx context.Context, hash string) (*models.RefreshToken, error)
+
+	// Rotate marks the token rotated, failing with ErrTokenRotated if it
+	// already was, or was revoked, so only one caller rotates a token
+	Rotate(ctx context.Context, hash string) error
+
+	// Revoke marks the token revoked
+	Revoke(ctx context.Context, hash string) error
+
+	// RevokeFamily revokes every token of family and returns how many were
+	// neither rotated nor revoked before
+	RevokeFamily(ctx context.Context, family string) (int, error)
+
+	// RevokeAllForUser revokes every token of the user
+	RevokeAllForUser(ctx context.Context, userID int) error
+
+	// DeleteExpired removes the tokens that expired before and returns how
+	// many it removed
+	DeleteExpired(ctx context.Context, before time.Time) (int, error)
+}
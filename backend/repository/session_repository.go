@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"GateKeeper/models"
+)
+
+// SessionStore stores login sessions by the hash of their ID. Get returns
+// ErrNotFound for unknown sessions. Implementations must be safe for
+// concurrent use.
+type SessionStore interface {
+	// Create stores a new session
+	Create(ctx context.Context, session *models.Session) error
+
+	// Get returns the session with the given ID
+	Get(ctx context.Context, id string) (*models.Session, error)
+
+	// Refresh records activity on the session at lastSeenAt
+	Refresh(ctx context.Context, id string, lastSeenAt time.Time) error
+
+	// Delete removes the session
+	Delete(ctx context.Context, id string) error
+
+	// DeleteAllForUser removes every session of the user
+	DeleteAllForUser(ctx context.Context, userID int) error
+}
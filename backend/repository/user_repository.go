@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"GateKeeper/models"
+)
+
+// Errors returned by UserRepository implementations
+var (
+	// ErrNotFound is returned when no user matches the lookup
+	ErrNotFound = errors.New("user not found")
+
+	// ErrDuplicateEmail is returned when creating or updating a user would
+	// give two users the same email, ignoring case
+	ErrDuplicateEmail = errors.New("email already in use")
+
+	// ErrDuplicateUsername is returned when creating or updating a user would
+	// give two users the same username, ignoring case
+	ErrDuplicateUsername = errors.New("username already in use")
+
+	// ErrConflict is returned by Update when the user was modified since it was read
+	ErrConflict = errors.New("user was modified concurrently")
+)
+
+// SortFields lists the fields users can be sorted by
+var SortFields = []string{"id", "email", "username", "created_at", "updated_at"}
+
+// DefaultSortField is the field users are sorted by when none is given.
+// Listings are always totally ordered, with ties broken by ID, so repeated
+// calls without intervening writes return the same users in the same order.
+const DefaultSortField = "created_at"
+
+// UserRepository stores users. Emails are unique as normalized by
+// models.NormalizeEmail and usernames as normalized by models.UsernameKey,
+// among users that are not deleted. Deleted users, those with DeletedAt
+// set, are only seen by ListDeleted, Restore and Purge.
+// Implementations must be safe for concurrent use.
+type UserRepository interface {
+	// Create stores a new user and sets its ID. The duplicate checks and the
+	// insert are atomic, and IDs are unique even under concurrent calls.
+	Create(ctx context.Context, user *models.User) error
+
+	// GetByEmail returns the user with the given email, ignoring case
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+
+	// GetByID returns the user with the given ID
+	GetByID(ctx context.Context, id int) (*models.User, error)
+
+	// Update saves changes to an existing user if its stored UpdatedAt still
+	// equals unmodifiedSince, the UpdatedAt it had when read, and fails with
+	// ErrConflict otherwise. Setting DeletedAt deletes the user.
+	Update(ctx context.Context, user *models.User, unmodifiedSince time.Time) error
+
+	// List returns the page of users selected by params, which must already be
+	// validated, and the total number of users matching its filters
+	List(ctx context.Context, params models.ListUsersParams) ([]*models.User, int, error)
+
+	// Search is List with the further criteria of params, which must already
+	// be validated, and returns the total number of matching users
+	Search(ctx context.Context, params models.SearchParams) ([]*models.User, int, error)
+
+	// Deactivate marks the user inactive
+	Deactivate(ctx context.Context, id int) error
+
+	// Reactivate marks the user active again
+	Reactivate(ctx context.Context, id int) error
+
+	// ListDeleted returns the deleted users, ordered by DeletedAt then ID
+	ListDeleted(ctx context.Context) ([]*models.User, error)
+
+	// Restore undeletes the user if it was deleted at or after deletedSince,
+	// and fails with ErrNotFound otherwise
+	Restore(ctx context.Context, id int, deletedSince time.Time) error
+
+	// Purge removes the users deleted before deletedBefore and returns how
+	// many it removed
+	Purge(ctx context.Context, deletedBefore time.Time) (int, error)
+}
@@ -0,0 +1,474 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"GateKeeper/migrations"
+	"GateKeeper/models"
+)
+
+// testDatabaseEnv names the variable holding the URL of a scratch Postgres
+// database for the Postgres repository tests, which are skipped without it.
+// The tests migrate the database and truncate its tables.
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
+// testDatabase connects to the test database, migrated and with no users,
+// or skips the test
+func testDatabase(t *testing.T) *pgx.Conn {
+	t.Helper()
+	url := os.Getenv(testDatabaseEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseEnv)
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect to the test database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close(context.Background()) })
+
+	all, err := migrations.Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.NewRunner(conn, all).Up(ctx); err != nil {
+		t.Fatalf("migrate the test database: %v", err)
+	}
+	if _, err := conn.Exec(ctx, "TRUNCATE users RESTART IDENTITY CASCADE"); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// newTestUser returns an active user ready to be created. Times are
+// truncated to the microsecond precision of Postgres.
+func newTestUser(email, username string) *models.User {
+	now := time.Now().Truncate(time.Microsecond)
+	return &models.User{
+		Email:             email,
+		Username:          username,
+		Password:          "hash",
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		IsActive:          true,
+		PasswordChangedAt: now,
+		Role:              models.RoleUser,
+	}
+}
+
+// mustCreate creates a user, failing the test on error
+func mustCreate(t *testing.T, repo UserRepository, email, username string) *models.User {
+	t.Helper()
+	user := newTestUser(email, username)
+	if err := repo.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create(%s): %v", email, err)
+	}
+	return user
+}
+
+// testUserRepository runs the UserRepository contract against the
+// repositories newRepo returns, each of them empty
+func testUserRepository(t *testing.T, newRepo func(t *testing.T) UserRepository) {
+	ctx := context.Background()
+
+	t.Run("create and get", func(t *testing.T) {
+		repo := newRepo(t)
+		first := mustCreate(t, repo, "Ada@Example.com", "ada")
+		second := mustCreate(t, repo, "bob@example.com", "bob")
+		if first.ID == 0 || second.ID == first.ID {
+			t.Fatalf("IDs = %d and %d, want distinct IDs", first.ID, second.ID)
+		}
+
+		byID, err := repo.GetByID(ctx, first.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if byID.Email != "Ada@Example.com" || byID.Username != "ada" || byID.Password != "hash" ||
+			!byID.IsActive || byID.Role != models.RoleUser || !byID.CreatedAt.Equal(first.CreatedAt) {
+			t.Errorf("GetByID = %+v, want the created user", byID)
+		}
+
+		byEmail, err := repo.GetByEmail(ctx, "ada@EXAMPLE.com")
+		if err != nil || byEmail.ID != first.ID {
+			t.Errorf("GetByEmail ignoring case = %v, %v; want user %d", byEmail, err, first.ID)
+		}
+
+		if _, err := repo.GetByID(ctx, second.ID+100); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByID(unknown) = %v, want ErrNotFound", err)
+		}
+		if _, err := repo.GetByEmail(ctx, "nobody@example.com"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByEmail(unknown) = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("duplicate email", func(t *testing.T) {
+		repo := newRepo(t)
+		mustCreate(t, repo, "ada@example.com", "ada")
+		if err := repo.Create(ctx, newTestUser("ADA@example.com", "ada2")); !errors.Is(err, ErrDuplicateEmail) {
+			t.Errorf("Create(same email) = %v, want ErrDuplicateEmail", err)
+		}
+		if err := repo.Create(ctx, newTestUser("ada2@example.com", "ADA")); !errors.Is(err, ErrDuplicateUsername) {
+			t.Errorf("Create(same username) = %v, want ErrDuplicateUsername", err)
+		}
+
+		bob := mustCreate(t, repo, "bob@example.com", "bob")
+		read, err := repo.GetByID(ctx, bob.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		read.Email = "Ada@example.com"
+		if err := repo.Update(ctx, read, read.UpdatedAt); !errors.Is(err, ErrDuplicateEmail) {
+			t.Errorf("Update(taken email) = %v, want ErrDuplicateEmail", err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		repo := newRepo(t)
+		created := mustCreate(t, repo, "ada@example.com", "ada")
+		read, err := repo.GetByID(ctx, created.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		unmodifiedSince := read.UpdatedAt
+		read.Username = "lovelace"
+		read.Email = "lovelace@example.com"
+		read.UpdatedAt = unmodifiedSince.Add(time.Second)
+		if err := repo.Update(ctx, read, unmodifiedSince); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		updated, err := repo.GetByEmail(ctx, "lovelace@example.com")
+		if err != nil || updated.Username != "lovelace" || !updated.UpdatedAt.Equal(read.UpdatedAt) {
+			t.Errorf("after Update = %+v, %v; want the new email, username and UpdatedAt", updated, err)
+		}
+		if _, err := repo.GetByEmail(ctx, "ada@example.com"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByEmail(old email) = %v, want ErrNotFound", err)
+		}
+
+		// A second writer holding the old version loses
+		read.Username = "stale"
+		if err := repo.Update(ctx, read, unmodifiedSince); !errors.Is(err, ErrConflict) {
+			t.Errorf("Update(stale) = %v, want ErrConflict", err)
+		}
+
+		missing := newTestUser("x@example.com", "xyz")
+		missing.ID = created.ID + 100
+		if err := repo.Update(ctx, missing, missing.UpdatedAt); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Update(unknown) = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, name := range []string{"cyd", "ada", "bob", "dee"} {
+			mustCreate(t, repo, name+"@example.com", name)
+		}
+		dee, _ := repo.GetByEmail(ctx, "dee@example.com")
+		if err := repo.Deactivate(ctx, dee.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tc := range []struct {
+			name   string
+			params models.ListUsersParams
+			want   []string
+			total  int
+		}{
+			{"created order", models.ListUsersParams{Limit: 10}, []string{"cyd", "ada", "bob", "dee"}, 4},
+			{"page", models.ListUsersParams{Limit: 2, Offset: 1, SortBy: "username"}, []string{"bob", "cyd"}, 4},
+			{"descending", models.ListUsersParams{Limit: 10, SortBy: "email", SortDir: "desc"}, []string{"dee", "cyd", "bob", "ada"}, 4},
+			{"active only", models.ListUsersParams{Limit: 10, SortBy: "username", ActiveOnly: true}, []string{"ada", "bob", "cyd"}, 3},
+			{"email filter", models.ListUsersParams{Limit: 10, EmailContains: "B@EX"}, []string{"bob"}, 1},
+			{"past the end", models.ListUsersParams{Limit: 10, Offset: 10}, []string{}, 4},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				users, total, err := repo.List(ctx, tc.params)
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				names := make([]string, len(users))
+				for i, user := range users {
+					names[i] = user.Username
+				}
+				if fmt.Sprint(names) != fmt.Sprint(tc.want) || total != tc.total {
+					t.Errorf("List = %v of %d, want %v of %d", names, total, tc.want, tc.total)
+				}
+			})
+		}
+	})
+
+	t.Run("ties ordered by ID", func(t *testing.T) {
+		repo := newRepo(t)
+		at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		var want, wantDesc []int
+		for i := range 6 {
+			user := newTestUser(fmt.Sprintf("user%d@example.com", i), fmt.Sprintf("user%d", i))
+			// Three users created at the same instant, then three an hour later
+			user.CreatedAt = at.Add(time.Duration(i/3) * time.Hour)
+			if err := repo.Create(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+			want = append(want, user.ID)
+			wantDesc = append([]int{user.ID}, wantDesc...)
+		}
+
+		for _, tc := range []struct {
+			name   string
+			params models.ListUsersParams
+			want   []int
+		}{
+			{"default", models.ListUsersParams{Limit: 10}, want},
+			{"created_at", models.ListUsersParams{Limit: 10, SortBy: "created_at"}, want},
+			{"descending", models.ListUsersParams{Limit: 10, SortBy: "created_at", SortDir: "desc"}, wantDesc},
+			{"pages", models.ListUsersParams{Limit: 2, Offset: 2}, want[2:4]},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				for range 5 {
+					users, _, err := repo.List(ctx, tc.params)
+					if err != nil {
+						t.Fatalf("List: %v", err)
+					}
+					ids := make([]int, len(users))
+					for i, user := range users {
+						ids[i] = user.ID
+					}
+					if fmt.Sprint(ids) != fmt.Sprint(tc.want) {
+						t.Fatalf("List = %v, want %v", ids, tc.want)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		repo := newRepo(t)
+		base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i, u := range []struct {
+			email, username, role string
+			active                bool
+		}{
+			{"alice@x.com", "Alice", models.RoleAdmin, true},
+			{"bob@y.com", "bob_1", models.RoleUser, false},
+			{"carol@x.com", "carol%", models.RoleUser, true},
+			{"o'brien@x.com", "obrien", models.RoleUser, true},
+		} {
+			user := newTestUser(u.email, u.username)
+			user.Role, user.IsActive = u.role, u.active
+			user.CreatedAt = base.AddDate(0, 0, 5*i)
+			if err := repo.Create(ctx, user); err != nil {
+				t.Fatalf("Create(%s): %v", u.email, err)
+			}
+		}
+
+		yes, no := true, false
+		day := func(n int) time.Time { return base.AddDate(0, 0, n) }
+		for _, tc := range []struct {
+			name   string
+			params models.SearchParams
+			want   string
+		}{
+			{"no criteria", models.SearchParams{}, "Alice bob_1 carol% obrien"},
+			{"email", models.SearchParams{ListUsersParams: models.ListUsersParams{EmailContains: "X.COM"}}, "Alice carol% obrien"},
+			{"username", models.SearchParams{UsernameContains: "B"}, "bob_1 obrien"},
+			{"inactive", models.SearchParams{Active: &no}, "bob_1"},
+			{"active", models.SearchParams{Active: &yes}, "Alice carol% obrien"},
+			{"role", models.SearchParams{Role: models.RoleAdmin}, "Alice"},
+			{"created after, inclusive", models.SearchParams{CreatedAfter: day(5)}, "bob_1 carol% obrien"},
+			{"created before, exclusive", models.SearchParams{CreatedBefore: day(5)}, "Alice"},
+			{"combined", models.SearchParams{CreatedAfter: day(5), CreatedBefore: day(11), Active: &yes}, "carol%"},
+			{"combined with paging", models.SearchParams{ListUsersParams: models.ListUsersParams{EmailContains: "x.com", Offset: 1, SortBy: "username", SortDir: "desc"}, Role: models.RoleUser}, "carol%"},
+			{"SQL in email", models.SearchParams{ListUsersParams: models.ListUsersParams{EmailContains: "' OR 1=1 --"}}, ""},
+			{"SQL in username", models.SearchParams{UsernameContains: "'; DROP TABLE users; --"}, ""},
+			{"quote", models.SearchParams{ListUsersParams: models.ListUsersParams{EmailContains: "o'b"}}, "obrien"},
+			{"LIKE wildcard", models.SearchParams{UsernameContains: "%"}, "carol%"},
+			{"LIKE single character", models.SearchParams{UsernameContains: "b_"}, "bob_1"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				if tc.params.Limit == 0 {
+					tc.params.Limit = 10
+				}
+				users, total, err := repo.Search(ctx, tc.params)
+				if err != nil {
+					t.Fatalf("Search: %v", err)
+				}
+				names := make([]string, len(users))
+				for i, user := range users {
+					names[i] = user.Username
+				}
+				if got := strings.Join(names, " "); got != tc.want {
+					t.Errorf("Search = %q, want %q", got, tc.want)
+				}
+				if tc.params.Offset == 0 && total != len(users) {
+					t.Errorf("total = %d, want %d", total, len(users))
+				}
+			})
+		}
+
+		// The users table survived the injection-shaped inputs
+		if _, total, err := repo.Search(ctx, models.SearchParams{ListUsersParams: models.ListUsersParams{Limit: 10}}); err != nil || total != 4 {
+			t.Errorf("Search after injection attempts = %d, %v; want 4 users", total, err)
+		}
+	})
+
+	t.Run("soft delete", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustCreate(t, repo, "ada@example.com", "ada")
+		old := mustCreate(t, repo, "bob@example.com", "bob")
+		now := time.Now()
+
+		// deleteAt deletes u as if at the given time
+		deleteAt := func(u *models.User, at time.Time) {
+			t.Helper()
+			at = at.UTC().Truncate(time.Microsecond)
+			u.DeletedAt = &at
+			if err := repo.Update(ctx, u, u.UpdatedAt); err != nil {
+				t.Fatalf("Update(deleted): %v", err)
+			}
+		}
+		deleteAt(user, now)
+		deleteAt(old, now.Add(-time.Hour))
+
+		if _, err := repo.GetByID(ctx, user.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByID(deleted) = %v, want ErrNotFound", err)
+		}
+		if _, err := repo.GetByEmail(ctx, "ada@example.com"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByEmail(deleted) = %v, want ErrNotFound", err)
+		}
+		if _, total, err := repo.List(ctx, models.ListUsersParams{Limit: 10}); err != nil || total != 0 {
+			t.Errorf("List total = %d, %v; want deleted users left out", total, err)
+		}
+		deleted, err := repo.ListDeleted(ctx)
+		if err != nil || len(deleted) != 2 || deleted[0].ID != old.ID || deleted[1].ID != user.ID {
+			t.Fatalf("ListDeleted = %v, %v; want bob then ada", deleted, err)
+		}
+
+		// The email of a deleted user is free until it is restored
+		again := mustCreate(t, repo, "ADA@example.com", "ada2")
+		if err := repo.Restore(ctx, user.ID, now.Add(-time.Minute)); !errors.Is(err, ErrDuplicateEmail) {
+			t.Errorf("Restore over a new registration = %v, want ErrDuplicateEmail", err)
+		}
+		again.DeletedAt = &now
+		if err := repo.Update(ctx, again, again.UpdatedAt); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := repo.Restore(ctx, old.ID, now.Add(-time.Minute)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Restore(deleted before the window) = %v, want ErrNotFound", err)
+		}
+		if err := repo.Restore(ctx, user.ID, now.Add(-time.Minute)); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		if read, err := repo.GetByID(ctx, user.ID); err != nil || read.DeletedAt != nil {
+			t.Errorf("GetByID after Restore = %+v, %v; want the user undeleted", read, err)
+		}
+
+		purged, err := repo.Purge(ctx, now.Add(-time.Minute))
+		if err != nil || purged != 1 {
+			t.Fatalf("Purge = %d, %v; want bob purged", purged, err)
+		}
+		if deleted, _ := repo.ListDeleted(ctx); len(deleted) != 1 || deleted[0].ID != again.ID {
+			t.Errorf("ListDeleted after Purge = %v, want only ada2", deleted)
+		}
+		mustCreate(t, repo, "bob@example.com", "bob")
+	})
+
+	t.Run("deactivate", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustCreate(t, repo, "ada@example.com", "ada")
+		if err := repo.Deactivate(ctx, user.ID); err != nil {
+			t.Fatalf("Deactivate: %v", err)
+		}
+		read, err := repo.GetByID(ctx, user.ID)
+		if err != nil || read.IsActive || !read.UpdatedAt.After(user.UpdatedAt) {
+			t.Errorf("after Deactivate = %+v, %v; want inactive with a later UpdatedAt", read, err)
+		}
+		if err := repo.Reactivate(ctx, user.ID); err != nil {
+			t.Fatalf("Reactivate: %v", err)
+		}
+		if read, _ := repo.GetByID(ctx, user.ID); !read.IsActive {
+			t.Error("user still inactive after Reactivate")
+		}
+		if err := repo.Deactivate(ctx, user.ID+100); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Deactivate(unknown) = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustCreate(t, repo, "ada@example.com", "ada")
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		calls := map[string]func() error{
+			"Create": func() error { return repo.Create(cancelled, newTestUser("bob@example.com", "bob")) },
+			"GetByEmail": func() error {
+				_, err := repo.GetByEmail(cancelled, "ada@example.com")
+				return err
+			},
+			"GetByID": func() error {
+				_, err := repo.GetByID(cancelled, user.ID)
+				return err
+			},
+			"Update": func() error { return repo.Update(cancelled, user, user.UpdatedAt) },
+			"List": func() error {
+				_, _, err := repo.List(cancelled, models.ListUsersParams{Limit: 10})
+				return err
+			},
+			"Deactivate": func() error { return repo.Deactivate(cancelled, user.ID) },
+		}
+		for name, call := range calls {
+			if err := call(); !errors.Is(err, context.Canceled) {
+				t.Errorf("%s = %v, want context.Canceled", name, err)
+			}
+		}
+		if _, err := repo.GetByEmail(ctx, "bob@example.com"); !errors.Is(err, ErrNotFound) {
+			t.Error("a cancelled Create stored the user")
+		}
+	})
+}
+
+func TestMemoryUserRepository(t *testing.T) {
+	testUserRepository(t, func(*testing.T) UserRepository {
+		return NewMemoryUserRepository()
+	})
+}
+
+func TestPostgresUserRepository(t *testing.T) {
+	testUserRepository(t, func(t *testing.T) UserRepository {
+		return NewPostgresUserRepository(testDatabase(t))
+	})
+}
+
+func TestMapError(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"no rows", pgx.ErrNoRows, ErrNotFound},
+		{"duplicate email", &pgconn.PgError{Code: uniqueViolation, ConstraintName: emailIndex}, ErrDuplicateEmail},
+		{"duplicate username", &pgconn.PgError{Code: uniqueViolation, ConstraintName: usernameIndex}, ErrDuplicateUsername},
+		{"cancelled", context.Canceled, context.Canceled},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := mapError("query", tc.err); !errors.Is(err, tc.want) {
+				t.Errorf("mapError = %v, want %v", err, tc.want)
+			}
+		})
+	}
+
+	other := &pgconn.PgError{Code: uniqueViolation, ConstraintName: "users_pkey"}
+	if err := mapError("insert user", other); errors.Is(err, ErrDuplicateEmail) || !errors.As(err, new(*pgconn.PgError)) {
+		t.Errorf("mapError(other constraint) = %v, want the wrapped Postgres error", err)
+	}
+}
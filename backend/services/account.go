@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// DeletionPolicy selects what DeleteUser does with the user record, which
+// is kept, marked deleted, until it is purged
+type DeletionPolicy int
+
+const (
+	// DeleteRetain keeps the email, username and password until the record
+	// is purged, so RestoreUser brings the account back as it was
+	DeleteRetain DeletionPolicy = iota
+
+	// DeleteAnonymize scrubs the email, username and password and
+	// deactivates the record at once; restoring it brings back only the ID
+	DeleteAnonymize
+)
+
+// WithDeletionPolicy sets what DeleteUser does with the user record
+func WithDeletionPolicy(policy DeletionPolicy) Option {
+	return func(s *AuthService) {
+		s.deletionPolicy = policy
+	}
+}
+
+// DeactivateUser deactivates the user and revokes their refresh tokens.
+// Access tokens stop validating at once, and the user can no longer log in
+// until reactivated.
+func (s *AuthService) DeactivateUser(ctx context.Context, userID int) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuthEvent{Type: models.EventDeactivate, TargetID: userID}, err)
+	}()
+
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	if err := s.users.Deactivate(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	// The user is deactivated; revoke even if the caller has gone
+	return s.RevokeAllForUser(context.WithoutCancel(ctx), userID)
+}
+
+// ReactivateUser lets a deactivated user log in again
+func (s *AuthService) ReactivateUser(ctx context.Context, userID int) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuthEvent{Type: models.EventReactivate, TargetID: userID}, err)
+	}()
+
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	if err := s.users.Reactivate(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteUser deletes the user's own account after confirming their password.
+// A wrong password, or an unknown user, fails with ErrInvalidCredentials.
+// All of the user's tokens are revoked, and the record is marked deleted,
+// and anonymized if the deletion policy says so, until it is purged. Either
+// way the email can be registered again.
+func (s *AuthService) DeleteUser(ctx context.Context, userID int, password string) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuthEvent{Type: models.EventDelete, ActorID: userID, TargetID: userID}, err)
+	}()
+
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidCredentials
+	} else if err != nil {
+		return err
+	}
+
+	// Check password
+	if ok, err := s.checkPassword(ctx, user, password); err != nil {
+		return err
+	} else if !ok {
+		return ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	unmodifiedSince := user.UpdatedAt
+	if s.deletionPolicy == DeleteAnonymize {
+		user.Email = fmt.Sprintf("deleted-user-%d@invalid", user.ID)
+		user.Username = fmt.Sprintf("deleted-user-%d", user.ID)
+		user.Password = ""
+		user.IsActive = false
+		user.EmailVerified = false
+		user.PasswordChangedAt = now
+	}
+	user.UpdatedAt = now
+	user.DeletedAt = &now
+	err = s.users.Update(ctx, user, unmodifiedSince)
+	switch {
+	case errors.Is(err, repository.ErrConflict):
+		return ErrUpdateConflict
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrInvalidCredentials
+	case err != nil:
+		return err
+	}
+
+	// The account is gone; revoke even if the caller has gone
+	return s.RevokeAllForUser(context.WithoutCancel(ctx), userID)
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"GateKeeper/models"
+)
+
+func TestDeactivateUser(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	login := loginTestUser(t, s)
+	id := login.User.ID
+	if _, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "other@example.com", Username: "other", Password: "secret1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DeactivateUser(ctx, id); err != nil {
+		t.Fatalf("DeactivateUser: %v", err)
+	}
+	if _, err := s.ValidateToken(ctx, login.AccessToken); !errors.Is(err, ErrUserDeactivated) {
+		t.Errorf("ValidateToken = %v, want ErrUserDeactivated", err)
+	}
+	if _, err := s.RefreshToken(ctx, login.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("RefreshToken = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"}); !errors.Is(err, ErrUserDeactivated) {
+		t.Errorf("LoginUser = %v, want ErrUserDeactivated", err)
+	}
+
+	page, err := s.ListUsers(ctx, models.ListUsersParams{ActiveOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || page.Items[0].Email != "other@example.com" {
+		t.Errorf("active users = %+v, want only other@example.com", page.Items)
+	}
+	if page, _ := s.ListUsers(ctx, models.ListUsersParams{}); page.Total != 2 {
+		t.Errorf("all users total = %d, want 2", page.Total)
+	}
+
+	if err := s.ReactivateUser(ctx, id); err != nil {
+		t.Fatalf("ReactivateUser: %v", err)
+	}
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"}); err != nil {
+		t.Errorf("LoginUser after reactivation: %v", err)
+	}
+
+	if err := s.DeactivateUser(ctx, id+100); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("DeactivateUser(unknown) = %v, want ErrUserNotFound", err)
+	}
+	if err := s.ReactivateUser(ctx, id+100); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("ReactivateUser(unknown) = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy DeletionPolicy
+	}{
+		{"retain", DeleteRetain},
+		{"anonymize", DeleteAnonymize},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestService(t, WithDeletionPolicy(tc.policy))
+			login := loginTestUser(t, s)
+			id := login.User.ID
+
+			if err := s.DeleteUser(ctx, id, "wrong12"); !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("DeleteUser(wrong password) = %v, want ErrInvalidCredentials", err)
+			}
+			if err := s.DeleteUser(ctx, id, "secret1"); err != nil {
+				t.Fatalf("DeleteUser: %v", err)
+			}
+
+			if _, err := s.ValidateToken(ctx, login.AccessToken); err == nil {
+				t.Error("the deleted user's access token still validates")
+			}
+			if _, err := s.RefreshToken(ctx, login.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Errorf("RefreshToken = %v, want ErrInvalidRefreshToken", err)
+			}
+			if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"}); !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("LoginUser = %v, want ErrInvalidCredentials", err)
+			}
+
+			// The email and username are free again
+			again, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "user@example.com", Username: "user", Password: "secret2"})
+			if err != nil {
+				t.Fatalf("re-registering the deleted email: %v", err)
+			}
+			if again.ID == id {
+				t.Errorf("re-registration reused ID %d", id)
+			}
+			if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret2"}); err != nil {
+				t.Errorf("login to the new account: %v", err)
+			}
+		})
+	}
+}
+
+func TestDeleteUserAnonymizes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, WithDeletionPolicy(DeleteAnonymize))
+	id := loginTestUser(t, s).User.ID
+
+	if err := s.DeleteUser(ctx, id, "secret1"); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	deleted, err := s.users.ListDeleted(ctx)
+	if err != nil || len(deleted) != 1 {
+		t.Fatalf("ListDeleted = %v, %v; want the deleted user", deleted, err)
+	}
+	if user := deleted[0]; user.Email == "user@example.com" || user.Username == "user" || user.Password != "" || user.IsActive || user.DeletedAt == nil {
+		t.Errorf("anonymized record = %+v, want the email, username and password scrubbed", user)
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// API key errors returned by the API key methods
+var (
+	// ErrInvalidAPIKey is returned for malformed, unknown, revoked or expired API keys
+	ErrInvalidAPIKey = errors.New("invalid API key")
+
+	// ErrAPIKeyNotFound is returned when revoking a key the user does not own
+	ErrAPIKeyNotFound = errors.New("API key not found")
+
+	// ErrInsufficientScope is returned when an API key lacks a required scope
+	ErrInsufficientScope = errors.New("API key lacks the required scope")
+
+	// ErrInvalidAPIKeyRequest is returned for an empty name or scope or a negative TTL
+	ErrInvalidAPIKeyRequest = errors.New("invalid API key request")
+)
+
+// apiKeyPrefix starts every API key, so leaked keys are easy to recognize
+const apiKeyPrefix = "gk_"
+
+// WithAPIKeys sets the store holding API keys. A nil store keeps keys in memory.
+func WithAPIKeys(store repository.APIKeyStore) Option {
+	return func(s *AuthService) {
+		if store == nil {
+			store = repository.NewMemoryAPIKeyStore()
+		}
+		s.apiKeys = store
+	}
+}
+
+// CreateAPIKey creates an API key for the user with the given scopes, valid
+// for ttl or forever if ttl is zero. The plaintext key is only returned here;
+// the store keeps its SHA-256 hash and a prefix identifying it.
+func (s *AuthService) CreateAPIKey(ctx context.Context, userID int, name string, scopes []string, ttl time.Duration) (_ *models.CreatedAPIKey, err error) {
+	defer func() {
+		s.audit(ctx, models.AuthEvent{Type: models.EventAPIKeyCreate, ActorID: userID, TargetID: userID}, err)
+	}()
+
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPIKeyRequest)
+	case ttl < 0:
+		return nil, fmt.Errorf("%w: ttl must not be negative", ErrInvalidAPIKeyRequest)
+	case slices.Contains(scopes, ""):
+		return nil, fmt.Errorf("%w: scopes must not be empty", ErrInvalidAPIKeyRequest)
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrUserDeactivated
+	}
+
+	// The key is "gk_<prefix>_<secret>"; the prefix is stored in clear for lookup
+	prefixBytes := make([]byte, 6)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	prefix := hex.EncodeToString(prefixBytes)
+	secret, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	plaintext := apiKeyPrefix + prefix + "_" + secret
+
+	now := time.Now()
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hashToken(plaintext),
+		Scopes:    append([]string{}, scopes...),
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := s.apiKeys.Create(ctx, key); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAPIKey{APIKey: *key, Key: plaintext}, nil
+}
+
+// ListAPIKeys returns the user's API keys, revoked and expired ones included
+func (s *AuthService) ListAPIKeys(ctx context.Context, userID int) (_ []*models.APIKey, err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.apiKeys.ListForUser(ctx, userID)
+}
+
+// RevokeAPIKey revokes one of the user's API keys; it stops authenticating at once
+func (s *AuthService) RevokeAPIKey(ctx context.Context, userID, keyID int) (err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	err = s.apiKeys.Revoke(ctx, userID, keyID, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		err = ErrAPIKeyNotFound
+	}
+	s.audit(ctx, models.AuthEvent{Type: models.EventAPIKeyRevoke, ActorID: userID, TargetID: userID}, err)
+	return err
+}
+
+// AuthenticateAPIKey resolves an API key to its owner and checks that it
+// carries every scope in required. Malformed, unknown, revoked and expired
+// keys, and keys of deactivated users, all fail with ErrInvalidAPIKey; a
+// missing scope fails with ErrInsufficientScope. Successful uses are
+// recorded as the key's last use.
+func (s *AuthService) AuthenticateAPIKey(ctx context.Context, plaintext string, required ...string) (_ *models.APIKeyIdentity, err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	rest, ok := strings.CutPrefix(plaintext, apiKeyPrefix)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	prefix, _, ok := strings.Cut(rest, "_")
+	if !ok || prefix == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeys.GetByPrefix(ctx, prefix)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	switch {
+	case subtle.ConstantTimeCompare([]byte(hashToken(plaintext)), []byte(key.Hash)) != 1:
+		return nil, ErrInvalidAPIKey
+	case key.RevokedAt != nil:
+		return nil, ErrInvalidAPIKey
+	case key.ExpiresAt != nil && !now.Before(*key.ExpiresAt):
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.users.GetByID(ctx, key.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrInvalidAPIKey
+	}
+
+	for _, scope := range required {
+		if !slices.Contains(key.Scopes, scope) {
+			return nil, fmt.Errorf("%w: %s", ErrInsufficientScope, scope)
+		}
+	}
+
+	if err := s.apiKeys.Touch(ctx, key.ID, now); err != nil {
+		log.Printf("failed to record use of API key %d: %v", key.ID, err)
+	}
+
+	return &models.APIKeyIdentity{
+		User:   user.ToResponse(),
+		KeyID:  key.ID,
+		Scopes: key.Scopes,
+	}, nil
+}
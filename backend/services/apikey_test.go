@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"GateKeeper/models"
+)
+
+// createAPIKey creates an API key for the user with id, failing the test on error
+func createAPIKey(t *testing.T, s *AuthService, id int, scopes []string, ttl time.Duration) *models.CreatedAPIKey {
+	t.Helper()
+	key, err := s.CreateAPIKey(context.Background(), id, "ci", scopes, ttl)
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	return key
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	id := loginTestUser(t, s).User.ID
+
+	created := createAPIKey(t, s, id, []string{"users:read"}, 0)
+	if !strings.HasPrefix(created.Key, "gk_"+created.Prefix+"_") || created.ExpiresAt != nil {
+		t.Errorf("created key %q with prefix %q, want gk_<prefix>_<secret> without expiry", created.Key, created.Prefix)
+	}
+
+	identity, err := s.AuthenticateAPIKey(ctx, created.Key, "users:read")
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey: %v", err)
+	}
+	if identity.User.ID != id || identity.KeyID != created.ID {
+		t.Errorf("identity = %+v, want user %d and key %d", identity, id, created.ID)
+	}
+
+	keys, err := s.ListAPIKeys(ctx, id)
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("ListAPIKeys = %v, %v; want the created key", keys, err)
+	}
+	stored := keys[0]
+	if stored.LastUsedAt == nil {
+		t.Error("LastUsedAt not recorded after authenticating")
+	}
+	if stored.Hash == created.Key || strings.Contains(stored.Hash, created.Key[len(created.Key)-10:]) {
+		t.Error("the store keeps the plaintext key")
+	}
+
+	// The stored form does not authenticate
+	for _, key := range []string{stored.Hash, "gk_" + stored.Prefix + "_" + stored.Hash} {
+		if _, err := s.AuthenticateAPIKey(ctx, key); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("AuthenticateAPIKey(%q) = %v, want ErrInvalidAPIKey", key, err)
+		}
+	}
+}
+
+func TestCreateAPIKeyRejected(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	id := loginTestUser(t, s).User.ID
+
+	for _, tc := range []struct {
+		name   string
+		userID int
+		key    string
+		scopes []string
+		ttl    time.Duration
+		want   error
+	}{
+		{"empty name", id, " ", nil, 0, ErrInvalidAPIKeyRequest},
+		{"negative ttl", id, "ci", nil, -time.Second, ErrInvalidAPIKeyRequest},
+		{"empty scope", id, "ci", []string{"users:read", ""}, 0, ErrInvalidAPIKeyRequest},
+		{"unknown user", id + 100, "ci", nil, 0, ErrUserNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := s.CreateAPIKey(ctx, tc.userID, tc.key, tc.scopes, tc.ttl); !errors.Is(err, tc.want) {
+				t.Fatalf("CreateAPIKey = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPIKeyScopes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	id := loginTestUser(t, s).User.ID
+	key := createAPIKey(t, s, id, []string{"users:read", "users:write"}, 0).Key
+
+	for _, tc := range []struct {
+		name     string
+		required []string
+		want     error
+	}{
+		{"no scopes required", nil, nil},
+		{"held scope", []string{"users:read"}, nil},
+		{"all held scopes", []string{"users:read", "users:write"}, nil},
+		{"missing scope", []string{"users:read", "admin"}, ErrInsufficientScope},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := s.AuthenticateAPIKey(ctx, key, tc.required...); !errors.Is(err, tc.want) || (tc.want == nil && err != nil) {
+				t.Fatalf("AuthenticateAPIKey = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPIKeyFailsClosed(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	id := loginTestUser(t, s).User.ID
+	other, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "other@example.com", Username: "other", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revoked := createAPIKey(t, s, id, nil, 0)
+	if err := s.RevokeAPIKey(ctx, other.ID, revoked.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("RevokeAPIKey(another user's key) = %v, want ErrAPIKeyNotFound", err)
+	}
+	if _, err := s.AuthenticateAPIKey(ctx, revoked.Key); err != nil {
+		t.Fatalf("AuthenticateAPIKey before revocation: %v", err)
+	}
+	if err := s.RevokeAPIKey(ctx, id, revoked.ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+
+	expired := createAPIKey(t, s, id, nil, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	deactivated := createAPIKey(t, s, other.ID, nil, 0)
+	if err := s.DeactivateUser(ctx, other.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := createAPIKey(t, s, id, nil, 0).Key
+	for _, tc := range []struct {
+		name string
+		key  string
+	}{
+		{"revoked", revoked.Key},
+		{"expired", expired.Key},
+		{"deactivated owner", deactivated.Key},
+		{"no prefix", strings.TrimPrefix(valid, "gk_")},
+		{"no separator", "gk_abc"},
+		{"unknown prefix", "gk_000000000000_secret"},
+		{"wrong secret", valid[:len(valid)-4] + "AAAA"},
+		{"empty", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := s.AuthenticateAPIKey(ctx, tc.key); !errors.Is(err, ErrInvalidAPIKey) {
+				t.Fatalf("AuthenticateAPIKey = %v, want ErrInvalidAPIKey", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+	"GateKeeper/validation"
+)
+
+// AuthAuditLogger records the authentication audit trail. Log failures are
+// reported but never fail the audited operation.
+type AuthAuditLogger interface {
+	Log(ctx context.Context, event models.AuthEvent) error
+}
+
+// Ensure the audit loggers implement AuthAuditLogger interface
+var (
+	_ AuthAuditLogger = (*SlogAuditLogger)(nil)
+	_ AuthAuditLogger = (*repository.PostgresAuditLog)(nil)
+)
+
+// WithAuditLogger sets the audit logger. A nil logger logs events with slog.Default.
+func WithAuditLogger(logger AuthAuditLogger) Option {
+	return func(s *AuthService) {
+		if logger == nil {
+			logger = NewSlogAuditLogger(nil)
+		}
+		s.auditor = logger
+	}
+}
+
+// SlogAuditLogger writes audit events as structured log records
+type SlogAuditLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogAuditLogger creates an audit logger writing to logger, or to
+// slog.Default if nil
+func NewSlogAuditLogger(logger *slog.Logger) *SlogAuditLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogAuditLogger{logger: logger}
+}
+
+// Log writes event at info level, failures at warn level
+func (l *SlogAuditLogger) Log(ctx context.Context, event models.AuthEvent) error {
+	level := slog.LevelInfo
+	if event.Outcome == models.OutcomeFailure {
+		level = slog.LevelWarn
+	}
+	l.logger.LogAttrs(ctx, level, "auth event",
+		slog.String("type", string(event.Type)),
+		slog.String("outcome", string(event.Outcome)),
+		slog.String("reason", event.Reason),
+		slog.Int("actor_id", event.ActorID),
+		slog.Int("target_id", event.TargetID),
+		slog.String("email", event.Email),
+		slog.String("ip", event.IP),
+		slog.String("user_agent", event.UserAgent),
+		slog.Time("time", event.Time),
+	)
+	return nil
+}
+
+// userAgentContextKey is the context key under which WithUserAgent stores the user agent
+type userAgentContextKey struct{}
+
+// WithUserAgent returns a copy of ctx carrying the caller's User-Agent,
+// recorded in audit events
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, userAgentContextKey{}, userAgent)
+}
+
+// userAgentFrom returns the user agent stored by WithUserAgent, or ""
+func userAgentFrom(ctx context.Context) string {
+	userAgent, _ := ctx.Value(userAgentContextKey{}).(string)
+	return userAgent
+}
+
+// actorContextKey is the context key under which WithActor stores the actor
+type actorContextKey struct{}
+
+// WithActor returns a copy of ctx naming the authenticated user making the
+// call, recorded in audit events of operations on other users
+func WithActor(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, userID)
+}
+
+// audit records event with the outcome of err and the request details in
+// ctx. An actor set with WithActor overrides event.ActorID.
+func (s *AuthService) audit(ctx context.Context, event models.AuthEvent, err error) {
+	event.Outcome = models.OutcomeSuccess
+	if err != nil {
+		event.Outcome = models.OutcomeFailure
+		event.Reason = auditReason(err)
+	}
+	if actor, ok := ctx.Value(actorContextKey{}).(int); ok {
+		event.ActorID = actor
+	}
+	event.IP = clientKeyFrom(ctx)
+	event.UserAgent = userAgentFrom(ctx)
+	event.Time = time.Now()
+
+	// Record the event even if the request was canceled meanwhile
+	if logErr := s.auditor.Log(context.WithoutCancel(ctx), event); logErr != nil {
+		log.Printf("failed to record %s audit event: %v", event.Type, logErr)
+	}
+}
+
+// auditReason returns the audit reason code for err
+func auditReason(err error) string {
+	switch {
+	case errors.Is(err, ErrRequestCancelled), errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded):
+		return "request_cancelled"
+	case errors.Is(err, ErrInvalidCredentials):
+		return "invalid_credentials"
+	case errors.Is(err, ErrTooManyAttempts):
+		return "too_many_attempts"
+	case errors.Is(err, ErrUserDeactivated):
+		return "user_deactivated"
+	case errors.Is(err, ErrUserExists):
+		return "user_exists"
+	case errors.Is(err, ErrUsernameTaken):
+		return "username_taken"
+	case errors.Is(err, ErrUserNotFound):
+		return "user_not_found"
+	case errors.Is(err, ErrWeakPassword):
+		return "weak_password"
+	case errors.Is(err, ErrPasswordReused):
+		return "password_reused"
+	case errors.Is(err, validation.ErrInvalid), errors.Is(err, ErrInvalidEmail),
+		errors.Is(err, ErrInvalidUsername), errors.Is(err, ErrInvalidAPIKeyRequest),
+		errors.Is(err, ErrInvalidRole):
+		return "validation_failed"
+	case errors.Is(err, ErrUpdateConflict):
+		return "update_conflict"
+	case errors.Is(err, ErrAPIKeyNotFound):
+		return "api_key_not_found"
+	case errors.Is(err, ErrRefreshTokenReused):
+		return "refresh_token_reused"
+	case errors.Is(err, ErrRefreshTokenExpired):
+		return "refresh_token_expired"
+	case errors.Is(err, ErrInvalidRefreshToken):
+		return "invalid_refresh_token"
+	default:
+		return "internal_error"
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"GateKeeper/models"
+)
+
+// failingAuditLogger fails every event
+type failingAuditLogger struct{}
+
+func (failingAuditLogger) Log(context.Context, models.AuthEvent) error {
+	return errors.New("audit store unavailable")
+}
+
+func TestAuditTrail(t *testing.T) {
+	audit := &recordingAuditLogger{}
+	s := newTestService(t, WithAuditLogger(audit))
+	ctx := WithUserAgent(WithClientKey(context.Background(), "192.0.2.1"), "test-agent")
+	start := time.Now()
+
+	user, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "user@example.com", Username: "user", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "wrong12"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("LoginUser(wrong password) = %v", err)
+	}
+	login, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ChangePassword(ctx, user.ID, "secret1", "secret2"); err != nil {
+		t.Fatal(err)
+	}
+
+	id := user.ID
+	want := []models.AuthEvent{
+		{Type: models.EventRegister, Outcome: models.OutcomeSuccess, ActorID: id, TargetID: id, Email: "user@example.com"},
+		{Type: models.EventLogin, Outcome: models.OutcomeFailure, Reason: "invalid_credentials", Email: "user@example.com"},
+		{Type: models.EventLogin, Outcome: models.OutcomeSuccess, ActorID: id, TargetID: id, Email: "user@example.com"},
+		{Type: models.EventPasswordChange, Outcome: models.OutcomeSuccess, ActorID: id, TargetID: id},
+	}
+	if len(audit.events) != len(want) {
+		t.Fatalf("audited %d events, want %d: %+v", len(audit.events), len(want), audit.events)
+	}
+	for i, event := range audit.events {
+		if event.Time.Before(start) || event.IP != "192.0.2.1" || event.UserAgent != "test-agent" {
+			t.Errorf("event %d = %+v, want the time and client details", i, event)
+		}
+		event.Time, event.IP, event.UserAgent = time.Time{}, "", ""
+		if event != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, event, want[i])
+		}
+	}
+
+	// Events never hold passwords or tokens
+	encoded, err := json.Marshal(audit.events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"secret1", "secret2", "wrong12", login.AccessToken, login.RefreshToken} {
+		if strings.Contains(string(encoded), secret) {
+			t.Errorf("audit events contain %q", secret)
+		}
+	}
+}
+
+func TestAuditActor(t *testing.T) {
+	audit := &recordingAuditLogger{}
+	s := newTestService(t, WithAuditLogger(audit))
+	id := loginTestUser(t, s).User.ID
+
+	if err := s.DeactivateUser(WithActor(context.Background(), 99), id); err != nil {
+		t.Fatal(err)
+	}
+	last := audit.events[len(audit.events)-1]
+	if last.Type != models.EventDeactivate || last.ActorID != 99 || last.TargetID != id {
+		t.Errorf("event = %+v, want a deactivation of %d by 99", last, id)
+	}
+}
+
+func TestAuditFailureDoesNotFailOperation(t *testing.T) {
+	s := newTestService(t, WithAuditLogger(failingAuditLogger{}))
+	login := loginTestUser(t, s)
+	if err := s.ChangePassword(context.Background(), login.User.ID, "secret1", "secret2"); err != nil {
+		t.Fatalf("ChangePassword with a failing audit logger: %v", err)
+	}
+}
+
+func TestSlogAuditLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSlogAuditLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	for _, event := range []models.AuthEvent{
+		{Type: models.EventLogin, Outcome: models.OutcomeSuccess, ActorID: 1, TargetID: 1, IP: "192.0.2.1"},
+		{Type: models.EventLogin, Outcome: models.OutcomeFailure, Reason: "invalid_credentials", Email: "user@example.com"},
+	} {
+		if err := logger.Log(context.Background(), event); err != nil {
+			t.Fatalf("Log: %v", err)
+		}
+	}
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("logged %d records, want 2", len(records))
+	}
+	if records[0]["level"] != "INFO" || records[0]["type"] != "login" || records[0]["ip"] != "192.0.2.1" {
+		t.Errorf("success record = %v, want an info login record", records[0])
+	}
+	if records[1]["level"] != "WARN" || records[1]["reason"] != "invalid_credentials" || records[1]["email"] != "user@example.com" {
+		t.Errorf("failure record = %v, want a warning with the reason", records[1])
+	}
+}
+
+func TestAuditReason(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{ErrInvalidCredentials, "invalid_credentials"},
+		{&TooManyAttemptsError{RetryAfter: time.Second}, "too_many_attempts"},
+		{context.Canceled, "request_cancelled"},
+		{ErrWeakPassword, "weak_password"},
+		{ErrRefreshTokenReused, "refresh_token_reused"},
+		{errors.New("boom"), "internal_error"},
+	} {
+		if got := auditReason(tc.err); got != tc.want {
+			t.Errorf("auditReason(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
@@ -4,97 +4,443 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"GateKeeper/db"
 	"GateKeeper/models"
-	"golang.org/x/crypto/bcrypt"
+	"GateKeeper/repository"
+	"GateKeeper/validation"
+)
+
+// Errors returned by AuthService
+var (
+	// ErrUserExists is returned when registering an email that is already
+	// taken, ignoring case
+	ErrUserExists = errors.New("user with this email already exists")
+
+	// ErrUsernameTaken is returned when registering a username that is
+	// already taken, ignoring case
+	ErrUsernameTaken = errors.New("username is already taken")
+
+	// ErrInvalidCredentials is returned when the email or password is wrong
+	ErrInvalidCredentials = errors.New("invalid email or password")
+
+	// ErrUserDeactivated is returned when the user account has been deactivated
+	ErrUserDeactivated = errors.New("user account is deactivated")
+
+	// ErrUserNotFound is returned when no user matches the lookup
+	ErrUserNotFound = errors.New("user not found")
 )
 
 // AuthService handles authentication-related business logic
 type AuthService struct {
-	// In a real microservices architecture, this would be a database client
-	// For demonstration, we'll use an in-memory store
-	users map[string]*models.User
+	// users stores the user accounts
+	users repository.UserRepository
+
+	// tokens configures the access tokens issued on login
+	tokens TokenConfig
+
+	// hasher hashes new passwords
+	hasher PasswordHasher
+
+	// limiter throttles failed logins and locks accounts; unlockNotifier,
+	// if set, is told when an admin unlocks one
+	limiter        *loginLimiter
+	unlockNotifier UnlockNotifier
+
+	// adminEmails holds the normalized emails of admins, who are promoted
+	// once their email is verified
+	adminEmails map[string]bool
+
+	// verifier is asked to verify changed email addresses
+	verifier EmailVerifier
+
+	// deletionPolicy selects what DeleteUser does with the record, and
+	// deletedRetention how long deleted users can be restored before purging
+	deletionPolicy   DeletionPolicy
+	deletedRetention time.Duration
+
+	// sessions holds server-side sessions, expiring per sessionConfig
+	sessions      repository.SessionStore
+	sessionConfig SessionConfig
+
+	// apiKeys holds the users' API keys
+	apiKeys repository.APIKeyStore
+
+	// auditor records the authentication audit trail
+	auditor AuthAuditLogger
+
+	// refreshTokens holds the issued refresh tokens
+	refreshTokens repository.RefreshTokenStore
+}
+
+// Option configures an AuthService
+type Option func(*AuthService)
+
+// WithLoginLimits sets the login throttling and lockout policy and the store
+// holding attempt counters. A nil store keeps counters in memory.
+func WithLoginLimits(config LoginLimitConfig, store AttemptStore) Option {
+	return func(s *AuthService) {
+		s.limiter = newLoginLimiter(config, store)
+	}
 }
 
-// NewAuthService creates a new authentication service
+// NewAuthService creates a new authentication service with an in-memory
+// user store and DefaultTokenConfig
 func NewAuthService() *AuthService {
-	return &AuthService{
-		users: make(map[string]*models.User),
+	return NewAuthServiceWithConfig(repository.NewMemoryUserRepository(), DefaultTokenConfig())
+}
+
+// NewAuthServiceWithConfig creates a new authentication service storing
+// users in users and issuing access tokens according to tokens. Logins are
+// limited by DefaultLoginLimitConfig unless overridden with WithLoginLimits,
+// and audited with slog unless overridden with WithAuditLogger.
+func NewAuthServiceWithConfig(users repository.UserRepository, tokens TokenConfig, opts ...Option) *AuthService {
+	s := &AuthService{
+		users:         users,
+		tokens:        tokens,
+		hasher:        BcryptHasher{},
+		limiter:       newLoginLimiter(DefaultLoginLimitConfig(), nil),
+		verifier:      logEmailVerifier{},
+		sessions:      repository.NewMemorySessionStore(),
+		sessionConfig: DefaultSessionConfig(),
+		apiKeys:       repository.NewMemoryAPIKeyStore(),
+		auditor:       NewSlogAuditLogger(nil),
+		adminEmails:   make(map[string]bool),
+		refreshTokens: repository.NewMemoryRefreshTokenStore(),
+
+		deletedRetention: defaultDeletedRetention,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
-// CreateUser creates a new user with the provided details
-func (s *AuthService) CreateUser(ctx context.Context, req models.CreateUserRequest) (*models.UserResponse, error) {
-	// Check if user already exists
-	if _, exists := s.users[req.Email]; exists {
-		return nil, errors.New("user with this email already exists")
+// CreateUser creates a new user with the provided details. The email is
+// normalized with models.NormalizeEmail and the username with
+// models.NormalizeUsername before validation and storage. An invalid
+// request fails with a *validation.Error listing the invalid fields.
+func (s *AuthService) CreateUser(ctx context.Context, req models.CreateUserRequest) (response *models.UserResponse, err error) {
+	defer func() {
+		event := models.AuthEvent{Type: models.EventRegister, Email: req.Email}
+		if response != nil {
+			event.ActorID, event.TargetID = response.ID, response.ID
+		}
+		s.audit(ctx, event, err)
+	}()
+
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	req.Email = models.NormalizeEmail(req.Email)
+	req.Username = models.NormalizeUsername(req.Username)
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	// Check if user already exists, to skip hashing for obvious duplicates;
+	// Create repeats the check atomically with the insert
+	if _, err := s.users.GetByEmail(ctx, req.Email); err == nil {
+		return nil, ErrUserExists
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	if err := validatePassword(req.Password); err != nil {
+		return nil, err
 	}
 
 	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hashPassword(ctx, req.Password)
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+		return nil, err
 	}
 
 	// Create user model
+	now := time.Now()
 	user := &models.User{
-		ID:        len(s.users) + 1, // Simple ID generation for demo
 		Email:     req.Email,
 		Username:  req.Username,
-		Password:  string(hashedPassword),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Password:  hashedPassword,
+		CreatedAt: now,
+		UpdatedAt: now,
 		IsActive:  true,
+		Role:      models.RoleUser,
+
+		PasswordChangedAt: now,
 	}
 
-	// Store user (in real app, this would be saved to database)
-	s.users[req.Email] = user
+	// Store user; the repository assigns the ID and rejects the email or
+	// username if taken, even by a concurrent registration
+	if err := s.users.Create(ctx, user); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateEmail):
+			return nil, ErrUserExists
+		case errors.Is(err, repository.ErrDuplicateUsername):
+			return nil, ErrUsernameTaken
+		}
+		return nil, err
+	}
 
 	// Return user response (without password)
-	response := user.ToResponse()
-	return &response, nil
+	created := user.ToResponse()
+	return &created, nil
+}
+
+// LoginUser authenticates a user with email and password and issues access and refresh tokens.
+// An invalid request fails with a *validation.Error without counting as a failed login.
+// Failed logins are counted per account and per client (see WithClientKey);
+// once limited, LoginUser fails with a *TooManyAttemptsError without checking
+// the password. A successful login resets the account's counters.
+func (s *AuthService) LoginUser(ctx context.Context, req models.LoginRequest) (_ *models.LoginResponse, err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	user, err := s.login(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue access and refresh tokens
+	return s.issueTokens(ctx, user, "")
 }
 
-// LoginUser authenticates a user with email and password
-func (s *AuthService) LoginUser(ctx context.Context, req models.LoginRequest) (*models.UserResponse, error) {
+// login checks the credentials of an active user, applying the login limits,
+// and audits the attempt
+func (s *AuthService) login(ctx context.Context, req models.LoginRequest) (_ *models.User, err error) {
+	var userID int
+	defer func() {
+		s.audit(ctx, models.AuthEvent{Type: models.EventLogin, ActorID: userID, TargetID: userID, Email: req.Email}, err)
+	}()
+
+	req.Email = models.NormalizeEmail(req.Email)
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	// Throttle failed logins per account, and per client IP when the
+	// context carries one
+	now := time.Now()
+	keys := []string{accountKey(req.Email)}
+	if client := clientKeyFrom(ctx); client != "" {
+		keys = append(keys, clientKey(client))
+	}
+	if err := s.limiter.check(ctx, now, keys...); err != nil {
+		return nil, err
+	}
+
+	user, err := s.authenticate(ctx, req)
+	if errors.Is(err, ErrInvalidCredentials) {
+		for i, key := range keys {
+			locked, recordErr := s.limiter.recordFailure(ctx, now, key, i == 0)
+			if recordErr != nil {
+				return nil, recordErr
+			}
+			if locked {
+				s.audit(ctx, models.AuthEvent{Type: models.EventLockout, Email: req.Email}, nil)
+			}
+		}
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+	userID = user.ID
+
+	if err := s.limiter.reset(ctx, keys[0]); err != nil {
+		return nil, err
+	}
+
+	// Check if user is active
+	if !user.IsActive {
+		return nil, ErrUserDeactivated
+	}
+	return user, nil
+}
+
+// authenticate returns the user with the request's email if the password matches
+func (s *AuthService) authenticate(ctx context.Context, req models.LoginRequest) (*models.User, error) {
 	// Find user by email
-	user, exists := s.users[req.Email]
-	if !exists {
-		return nil, errors.New("invalid email or password")
+	user, err := s.users.GetByEmail(ctx, req.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	} else if err != nil {
+		return nil, err
 	}
 
 	// Check password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, errors.New("invalid email or password")
+	if ok, err := s.checkPassword(ctx, user, req.Password); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	s.rehashIfNeeded(ctx, user, req.Password)
+	return user, nil
+}
+
+// ValidateToken verifies an access token and returns the user it was issued to.
+// It fails with ErrTokenExpired or ErrInvalidToken for bad tokens, and also if
+// the user no longer exists or has been deactivated.
+func (s *AuthService) ValidateToken(ctx context.Context, token string) (_ *models.UserResponse, err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	claims, err := s.tokens.parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check that the user still exists
+	user, err := s.users.GetByID(ctx, claims.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: user no longer exists", ErrInvalidToken)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Reject tokens issued before the last password change. Token times
+	// have one second resolution, so compare whole seconds.
+	if claims.IssuedAt != nil && claims.IssuedAt.Time.Before(user.PasswordChangedAt.Truncate(time.Second)) {
+		return nil, fmt.Errorf("%w: issued before the last password change", ErrInvalidToken)
 	}
 
 	// Check if user is active
 	if !user.IsActive {
-		return nil, errors.New("user account is deactivated")
+		return nil, ErrUserDeactivated
 	}
 
-	// Return user response
 	response := user.ToResponse()
 	return &response, nil
 }
 
-// GetUserByEmail retrieves a user by their email address
-func (s *AuthService) GetUserByEmail(ctx context.Context, email string) (*models.UserResponse, error) {
-	user, exists := s.users[email]
-	if !exists {
-		return nil, errors.New("user not found")
+// GetUserByEmail retrieves a user by their email address, ignoring case
+func (s *AuthService) GetUserByEmail(ctx context.Context, email string) (_ *models.UserResponse, err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByEmail(ctx, models.NormalizeEmail(email))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
 	}
 
 	response := user.ToResponse()
 	return &response, nil
 }
 
-// GetAllUsers returns all users (for demo purposes)
-func (s *AuthService) GetAllUsers(ctx context.Context) ([]models.UserResponse, error) {
-	var users []models.UserResponse
-	for _, user := range s.users {
-		users = append(users, user.ToResponse())
+// Listing limits applied by ListUsers
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// ErrInvalidListParams is returned by ListUsers and SearchUsers for an
+// unknown sort field or direction, a negative limit or offset, or an
+// empty creation date range
+var ErrInvalidListParams = errors.New("invalid list parameters")
+
+// ListUsers returns a page of users. A zero Limit means 20 and larger
+// limits are capped at 100; users are sorted by creation time ascending
+// unless SortBy and SortDir say otherwise, with ties broken by ID, so pages
+// are stable between calls.
+func (s *AuthService) ListUsers(ctx context.Context, params models.ListUsersParams) (_ *models.UserPage, err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := normalizeListParams(&params); err != nil {
+		return nil, err
+	}
+	// Listings may come from a read replica, slightly behind the primary
+	ctx = db.WithReadOnly(ctx)
+	stored, total, err := s.users.List(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return userPage(stored, total, params), nil
+}
+
+// SearchUsers returns a page of the users matching params, paged and sorted
+// as by ListUsers, and the total number of matches. Empty criteria match
+// every user.
+func (s *AuthService) SearchUsers(ctx context.Context, params models.SearchParams) (_ *models.UserPage, err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := normalizeListParams(&params.ListUsersParams); err != nil {
+		return nil, err
+	}
+	if params.Role != "" && params.Role != models.RoleUser && params.Role != models.RoleAdmin {
+		return nil, fmt.Errorf("%w: got %q", ErrInvalidRole, params.Role)
+	}
+	if !params.CreatedAfter.IsZero() && !params.CreatedBefore.IsZero() &&
+		!params.CreatedAfter.Before(params.CreatedBefore) {
+		return nil, fmt.Errorf("%w: created_after must be before created_before", ErrInvalidListParams)
+	}
+
+	ctx = db.WithReadOnly(ctx)
+	stored, total, err := s.users.Search(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return userPage(stored, total, params.ListUsersParams), nil
+}
+
+// normalizeListParams validates the paging and sorting of params and fills
+// in their defaults
+func normalizeListParams(params *models.ListUsersParams) error {
+	switch {
+	case params.Limit < 0:
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidListParams)
+	case params.Limit == 0:
+		params.Limit = defaultListLimit
+	case params.Limit > maxListLimit:
+		params.Limit = maxListLimit
+	}
+	if params.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidListParams)
+	}
+	if params.SortBy == "" {
+		params.SortBy = repository.DefaultSortField
+	} else if !slices.Contains(repository.SortFields, params.SortBy) {
+		return fmt.Errorf("%w: cannot sort by %q, use one of %s",
+			ErrInvalidListParams, params.SortBy, strings.Join(repository.SortFields, ", "))
+	}
+	switch params.SortDir {
+	case "":
+		params.SortDir = "asc"
+	case "asc", "desc":
+	default:
+		return fmt.Errorf("%w: sort direction must be asc or desc", ErrInvalidListParams)
+	}
+	return nil
+}
+
+// userPage builds the page of stored users selected by params
+func userPage(stored []*models.User, total int, params models.ListUsersParams) *models.UserPage {
+	items := make([]models.UserResponse, 0, len(stored))
+	for _, user := range stored {
+		items = append(items, user.ToResponse())
+	}
+	return &models.UserPage{
+		Items:  items,
+		Total:  total,
+		Limit:  params.Limit,
+		Offset: params.Offset,
 	}
-	return users, nil
 }
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/validation"
+)
+
+func TestCreateUserConcurrent(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	const users = 100
+	ids := make(chan int, users)
+	var wg sync.WaitGroup
+	for i := 0; i < users; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			user, err := s.CreateUser(ctx, models.CreateUserRequest{
+				Email:    fmt.Sprintf("user%d@example.com", i),
+				Username: fmt.Sprintf("user%d", i),
+				Password: "secret1",
+			})
+			if err != nil {
+				t.Errorf("CreateUser(%d): %v", i, err)
+				return
+			}
+			ids <- user.ID
+		}(i)
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Errorf("ID %d assigned twice", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != users {
+		t.Errorf("%d distinct IDs, want %d", len(seen), users)
+	}
+}
+
+func TestCreateUserConcurrentDuplicateEmail(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	// Each email is registered by several goroutines at once
+	const emails, attempts = 10, 5
+	var (
+		mu        sync.Mutex
+		successes = make(map[string]int)
+		wg        sync.WaitGroup
+	)
+	for i := 0; i < emails*attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			email := fmt.Sprintf("dup%d@example.com", i%emails)
+			_, err := s.CreateUser(ctx, models.CreateUserRequest{
+				Email:    email,
+				Username: fmt.Sprintf("racer%d", i),
+				Password: "secret1",
+			})
+			switch {
+			case err == nil:
+				mu.Lock()
+				successes[email]++
+				mu.Unlock()
+			case !errors.Is(err, ErrUserExists):
+				t.Errorf("CreateUser(%s): %v, want success or ErrUserExists", email, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < emails; i++ {
+		email := fmt.Sprintf("dup%d@example.com", i)
+		if successes[email] != 1 {
+			t.Errorf("%s registered %d times, want exactly once", email, successes[email])
+		}
+	}
+}
+
+// createUsers registers n users named user00, user01, ... in order
+func createUsers(t *testing.T, s *AuthService, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("user%02d", i)
+		if _, err := s.CreateUser(context.Background(), models.CreateUserRequest{Email: name + "@example.com", Username: name, Password: "secret1"}); err != nil {
+			t.Fatalf("CreateUser(%s): %v", name, err)
+		}
+	}
+}
+
+// usernames returns the usernames on page
+func usernames(page *models.UserPage) string {
+	names := make([]string, len(page.Items))
+	for i, user := range page.Items {
+		names[i] = user.Username
+	}
+	return strings.Join(names, ",")
+}
+
+func TestListUsersPages(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	createUsers(t, s, 25)
+
+	// Paging through the users visits each of them once, in creation order
+	var all []string
+	for offset := 0; offset <= 30; offset += 10 {
+		page, err := s.ListUsers(ctx, models.ListUsersParams{Limit: 10, Offset: offset})
+		if err != nil {
+			t.Fatalf("ListUsers(offset %d): %v", offset, err)
+		}
+		if page.Total != 25 || page.Limit != 10 || page.Offset != offset {
+			t.Errorf("page at %d = total %d, limit %d, offset %d; want 25, 10, %d", offset, page.Total, page.Limit, page.Offset, offset)
+		}
+		if want := min(10, max(0, 25-offset)); len(page.Items) != want {
+			t.Errorf("page at %d has %d items, want %d", offset, len(page.Items), want)
+		}
+		if names := usernames(page); names != "" {
+			all = append(all, names)
+		}
+	}
+	var want []string
+	for i := 0; i < 25; i++ {
+		want = append(want, fmt.Sprintf("user%02d", i))
+	}
+	if got := strings.Join(all, ","); got != strings.Join(want, ",") {
+		t.Errorf("paged users = %s, want each user once in creation order", got)
+	}
+
+	// The same request returns the same page
+	first, _ := s.ListUsers(ctx, models.ListUsersParams{Limit: 5, Offset: 7, SortBy: "email", SortDir: "desc"})
+	second, _ := s.ListUsers(ctx, models.ListUsersParams{Limit: 5, Offset: 7, SortBy: "email", SortDir: "desc"})
+	if usernames(first) != "user17,user16,user15,user14,user13" || usernames(first) != usernames(second) {
+		t.Errorf("pages = %s and %s, want user17 to user13 both times", usernames(first), usernames(second))
+	}
+}
+
+func TestListUsersLimits(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	createUsers(t, s, 105)
+
+	for _, tc := range []struct {
+		limit, want int
+	}{
+		{0, defaultListLimit},
+		{1, 1},
+		{500, maxListLimit},
+	} {
+		page, err := s.ListUsers(ctx, models.ListUsersParams{Limit: tc.limit})
+		if err != nil {
+			t.Fatalf("ListUsers(limit %d): %v", tc.limit, err)
+		}
+		if page.Limit != tc.want || len(page.Items) != tc.want || page.Total != 105 {
+			t.Errorf("limit %d: got limit %d with %d of %d items, want %d of 105", tc.limit, page.Limit, len(page.Items), page.Total, tc.want)
+		}
+	}
+}
+
+func TestListUsersFilters(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	createUsers(t, s, 12)
+	deactivated, err := s.users.GetByEmail(ctx, "user11@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeactivateUser(ctx, deactivated.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		params models.ListUsersParams
+		want   string
+		total  int
+	}{
+		{"email contains", models.ListUsersParams{EmailContains: "USER1"}, "user10,user11", 2},
+		{"active only", models.ListUsersParams{EmailContains: "user1", ActiveOnly: true}, "user10", 1},
+		{"no match", models.ListUsersParams{EmailContains: "nobody"}, "", 0},
+		{"filtered page", models.ListUsersParams{EmailContains: "user0", Limit: 3, Offset: 8}, "user08,user09", 10},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := s.ListUsers(ctx, tc.params)
+			if err != nil {
+				t.Fatalf("ListUsers: %v", err)
+			}
+			if usernames(page) != tc.want || page.Total != tc.total {
+				t.Errorf("ListUsers = %s of %d, want %s of %d", usernames(page), page.Total, tc.want, tc.total)
+			}
+		})
+	}
+}
+
+func TestListUsersInvalidParams(t *testing.T) {
+	s := newTestService(t)
+	for _, tc := range []struct {
+		name   string
+		params models.ListUsersParams
+	}{
+		{"negative limit", models.ListUsersParams{Limit: -1}},
+		{"negative offset", models.ListUsersParams{Offset: -1}},
+		{"unknown sort field", models.ListUsersParams{SortBy: "password"}},
+		{"unknown sort direction", models.ListUsersParams{SortDir: "up"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := s.ListUsers(context.Background(), tc.params); !errors.Is(err, ErrInvalidListParams) {
+				t.Fatalf("ListUsers = %v, want ErrInvalidListParams", err)
+			}
+		})
+	}
+}
+
+func TestRequestsValidated(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, WithLoginLimits(LoginLimitConfig{MaxAttempts: 1, Window: time.Minute}, nil))
+
+	var invalid *validation.Error
+	if _, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "user@example", Username: "u", Password: "secret1"}); !errors.As(err, &invalid) || len(invalid.Fields) != 2 {
+		t.Errorf("CreateUser(invalid) = %v, want a *validation.Error for the email and username", err)
+	}
+	loginTestUser(t, s)
+
+	// Invalid logins are rejected before counting as failed logins
+	for i := 0; i < 3; i++ {
+		if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com"}); !errors.As(err, &invalid) {
+			t.Fatalf("LoginUser(no password) = %v, want a *validation.Error", err)
+		}
+	}
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"}); err != nil {
+		t.Errorf("LoginUser after invalid requests: %v", err)
+	}
+}
+
+func TestCaseInsensitiveUniqueness(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	alice, err := s.CreateUser(ctx, models.CreateUserRequest{Email: " Alice@Example.com ", Username: " Alice ", Password: "secret1"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if alice.Email != "alice@example.com" || alice.Username != "Alice" {
+		t.Errorf("stored %q and %q, want the email lowercased and the username trimmed", alice.Email, alice.Username)
+	}
+
+	for _, tc := range []struct {
+		name string
+		req  models.CreateUserRequest
+		want error
+	}{
+		{"email in another case", models.CreateUserRequest{Email: "alice@EXAMPLE.com", Username: "other", Password: "secret1"}, ErrUserExists},
+		{"username in another case", models.CreateUserRequest{Email: "bob@example.com", Username: "ALICE", Password: "secret1"}, ErrUsernameTaken},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := s.CreateUser(ctx, tc.req); !errors.Is(err, tc.want) {
+				t.Fatalf("CreateUser = %v, want %v", err, tc.want)
+			}
+		})
+	}
+
+	// Lookups normalize the same way
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "ALICE@example.COM", Password: "secret1"}); err != nil {
+		t.Errorf("LoginUser in another case: %v", err)
+	}
+	if got, err := s.GetUserByEmail(ctx, " ALICE@EXAMPLE.COM"); err != nil || got.ID != alice.ID {
+		t.Errorf("GetUserByEmail in another case = %v, %v; want user %d", got, err, alice.ID)
+	}
+
+	// Renaming onto another user's username fails, but changing one's own case works
+	bob, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "bob@example.com", Username: "bob", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := "alice"
+	if _, err := s.UpdateUser(ctx, bob.ID, models.UpdateUserRequest{Username: &name}); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("UpdateUser(taken username) = %v, want ErrUsernameTaken", err)
+	}
+	name = "BOB"
+	if got, err := s.UpdateUser(ctx, bob.ID, models.UpdateUserRequest{Username: &name}); err != nil || got.Username != "BOB" {
+		t.Errorf("UpdateUser(own username in another case) = %v, %v; want BOB", got, err)
+	}
+}
+
+func TestConcurrentMixedCaseRegistrations(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	const attempts = 30
+	var (
+		mu        sync.Mutex
+		successes int
+		wg        sync.WaitGroup
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			email := "race@example.com"
+			if i%2 == 0 {
+				email = "RACE@Example.com"
+			}
+			_, err := s.CreateUser(ctx, models.CreateUserRequest{Email: email, Username: fmt.Sprintf("racer%d", i), Password: "secret1"})
+			switch {
+			case err == nil:
+				mu.Lock()
+				successes++
+				mu.Unlock()
+			case !errors.Is(err, ErrUserExists):
+				t.Errorf("CreateUser(%s): %v, want success or ErrUserExists", email, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if successes != 1 {
+		t.Errorf("%d registrations succeeded, want exactly 1", successes)
+	}
+}
+
+func TestSearchUsers(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	createUsers(t, s, 12)
+
+	// Empty criteria list like ListUsers
+	params := models.ListUsersParams{Limit: 5, Offset: 2, SortBy: "email", SortDir: "desc"}
+	searched, err := s.SearchUsers(ctx, models.SearchParams{ListUsersParams: params})
+	if err != nil {
+		t.Fatalf("SearchUsers: %v", err)
+	}
+	listed, err := s.ListUsers(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usernames(searched) != usernames(listed) || searched.Total != listed.Total || searched.Limit != listed.Limit {
+		t.Errorf("SearchUsers = %s of %d, want the ListUsers page %s of %d", usernames(searched), searched.Total, usernames(listed), listed.Total)
+	}
+
+	page, err := s.SearchUsers(ctx, models.SearchParams{UsernameContains: "USER1", Role: models.RoleUser})
+	if err != nil || usernames(page) != "user10,user11" || page.Total != 2 {
+		t.Errorf("SearchUsers(username, role) = %v, %v; want user10 and user11", page, err)
+	}
+
+	now := time.Now()
+	for _, tc := range []struct {
+		name   string
+		params models.SearchParams
+		want   error
+	}{
+		{"unknown role", models.SearchParams{Role: "root"}, ErrInvalidRole},
+		{"empty date range", models.SearchParams{CreatedAfter: now, CreatedBefore: now}, ErrInvalidListParams},
+		{"reversed date range", models.SearchParams{CreatedAfter: now, CreatedBefore: now.Add(-time.Hour)}, ErrInvalidListParams},
+		{"unknown sort field", models.SearchParams{ListUsersParams: models.ListUsersParams{SortBy: "password"}}, ErrInvalidListParams},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := s.SearchUsers(ctx, tc.params); !errors.Is(err, tc.want) {
+				t.Fatalf("SearchUsers = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestListUsersDeterministic(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 40 {
+		// Many users share a creation time, so only the ID tie-break orders them
+		user := &models.User{
+			Email:     fmt.Sprintf("user%02d@example.com", i),
+			Username:  fmt.Sprintf("user%02d", i),
+			CreatedAt: at.Add(time.Duration(i%3) * time.Hour),
+			IsActive:  true,
+			Role:      models.RoleUser,
+		}
+		if err := s.users.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var first []byte
+	for i := range 50 {
+		page, err := s.ListUsers(ctx, models.ListUsersParams{Limit: 100})
+		if err != nil {
+			t.Fatalf("ListUsers: %v", err)
+		}
+		encoded, err := json.Marshal(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			first = encoded
+			if page.Items[0].ID != 1 || page.Items[1].ID != 4 || page.Items[14].ID != 2 {
+				t.Fatalf("ListUsers starts %v, want creation time then ID order", usernames(page))
+			}
+			continue
+		}
+		if !bytes.Equal(encoded, first) {
+			t.Fatalf("call %d returned\n%s\nwant\n%s", i+1, encoded, first)
+		}
+	}
+}
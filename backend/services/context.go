@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrRequestCancelled is returned when the caller's context is canceled or
+// its deadline passes before an operation completes. The returned error is a
+// *RequestCancelledError, which also matches the context's error.
+var ErrRequestCancelled = errors.New("request cancelled")
+
+// RequestCancelledError reports an operation abandoned because its context ended
+type RequestCancelledError struct {
+	// Cause is context.Canceled or context.DeadlineExceeded
+	Cause error
+}
+
+// Error implements the error interface
+func (e *RequestCancelledError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrRequestCancelled, e.Cause)
+}
+
+// Unwrap returns ErrRequestCancelled and the cause so errors.Is matches both
+func (e *RequestCancelledError) Unwrap() []error {
+	return []error{ErrRequestCancelled, e.Cause}
+}
+
+// checkContext returns a *RequestCancelledError if ctx is done
+func checkContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return &RequestCancelledError{Cause: err}
+	}
+	return nil
+}
+
+// wrapCancellation replaces a context error in *err, e.g. one returned by a
+// repository, with a *RequestCancelledError. Methods defer it on their
+// named error result.
+func wrapCancellation(err *error) {
+	if *err == nil || errors.Is(*err, ErrRequestCancelled) {
+		return
+	}
+	switch {
+	case errors.Is(*err, context.Canceled):
+		*err = &RequestCancelledError{Cause: context.Canceled}
+	case errors.Is(*err, context.DeadlineExceeded):
+		*err = &RequestCancelledError{Cause: context.DeadlineExceeded}
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// cancellingHasher cancels the request while hashing, as if the client
+// went away during the slow step
+type cancellingHasher struct {
+	PasswordHasher
+	cancel context.CancelFunc
+}
+
+func (h cancellingHasher) Hash(password string) (string, error) {
+	h.cancel()
+	return h.PasswordHasher.Hash(password)
+}
+
+// requireCancelled fails the test unless err is a *RequestCancelledError
+// caused by cause
+func requireCancelled(t *testing.T, err, cause error) {
+	t.Helper()
+	var cancelled *RequestCancelledError
+	if !errors.As(err, &cancelled) || !errors.Is(err, ErrRequestCancelled) || !errors.Is(err, cause) {
+		t.Fatalf("err = %v, want a *RequestCancelledError caused by %v", err, cause)
+	}
+}
+
+func TestCancelledDuringHashing(t *testing.T) {
+	s := newTestService(t)
+	id := loginTestUser(t, s).User.ID
+
+	// cancelling returns a context and a service that cancels it mid-hash
+	cancelling := func() (context.Context, *AuthService) {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		return ctx, NewAuthServiceWithConfig(s.users, DefaultTokenConfig(),
+			WithPasswordHasher(cancellingHasher{s.hasher, cancel}), WithAuditLogger(nopAuditLogger{}))
+	}
+
+	ctx, service := cancelling()
+	_, err := service.CreateUser(ctx, models.CreateUserRequest{Email: "new@example.com", Username: "new", Password: "secret1"})
+	requireCancelled(t, err, context.Canceled)
+	if _, err := s.users.GetByEmail(context.Background(), "new@example.com"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("cancelled CreateUser stored the user: %v", err)
+	}
+
+	ctx, service = cancelling()
+	requireCancelled(t, service.ChangePassword(ctx, id, "secret1", "secret2"), context.Canceled)
+	if _, err := s.LoginUser(context.Background(), models.LoginRequest{Email: "user@example.com", Password: "secret1"}); err != nil {
+		t.Errorf("cancelled ChangePassword changed the password: %v", err)
+	}
+}
+
+func TestCancelledBeforeStart(t *testing.T) {
+	s := newTestService(t)
+	id := loginTestUser(t, s).User.ID
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithTimeout(context.Background(), -time.Second)
+	defer cancelExpired()
+
+	for _, tc := range []struct {
+		name  string
+		ctx   context.Context
+		cause error
+	}{
+		{"cancelled", cancelled, context.Canceled},
+		{"deadline passed", expired, context.DeadlineExceeded},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := map[string]func() error{
+				"LoginUser": func() error {
+					_, err := s.LoginUser(tc.ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"})
+					return err
+				},
+				"CreateUser": func() error {
+					_, err := s.CreateUser(tc.ctx, models.CreateUserRequest{Email: "other@example.com", Username: "other", Password: "secret1"})
+					return err
+				},
+				"ListUsers": func() error {
+					_, err := s.ListUsers(tc.ctx, models.ListUsersParams{})
+					return err
+				},
+				"DeactivateUser": func() error { return s.DeactivateUser(tc.ctx, id) },
+				"ChangePassword": func() error { return s.ChangePassword(tc.ctx, id, "secret1", "secret2") },
+			}
+			for name, call := range calls {
+				if err := call(); !errors.Is(err, ErrRequestCancelled) || !errors.Is(err, tc.cause) {
+					t.Errorf("%s = %v, want ErrRequestCancelled caused by %v", name, err, tc.cause)
+				}
+			}
+		})
+	}
+
+	// Nothing changed
+	if _, err := s.LoginUser(context.Background(), models.LoginRequest{Email: "user@example.com", Password: "secret1"}); err != nil {
+		t.Errorf("LoginUser after cancelled calls: %v", err)
+	}
+	if page, _ := s.ListUsers(context.Background(), models.ListUsersParams{}); page.Total != 1 {
+		t.Errorf("%d users after cancelled calls, want 1", page.Total)
+	}
+}
+
+func TestCancelledLoginReturnsPromptly(t *testing.T) {
+	// At the production cost verifying takes hundreds of milliseconds
+	hasher, err := NewBcryptHasher(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestService(t, WithPasswordHasher(hasher))
+	if _, err := s.CreateUser(context.Background(), models.CreateUserRequest{Email: "user@example.com", Username: "user", Password: "secret1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	_, err = s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"})
+	requireCancelled(t, err, context.Canceled)
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("cancelled LoginUser took %v, want it to skip the password check", elapsed)
+	}
+}
+
+func TestWrapCancellation(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		err   error
+		cause error
+	}{
+		{"canceled", context.Canceled, context.Canceled},
+		{"wrapped deadline", errors.Join(errors.New("query failed"), context.DeadlineExceeded), context.DeadlineExceeded},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.err
+			wrapCancellation(&err)
+			requireCancelled(t, err, tc.cause)
+		})
+	}
+
+	other := errors.New("boom")
+	err := other
+	wrapCancellation(&err)
+	if err != other {
+		t.Errorf("wrapCancellation changed %v to %v", other, err)
+	}
+}
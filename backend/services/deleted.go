@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// defaultDeletedRetention is how long deleted users can be restored unless
+// WithDeletedRetention says otherwise
+const defaultDeletedRetention = 30 * 24 * time.Hour
+
+// WithDeletedRetention sets how long deleted users can be restored before
+// PurgeDeletedUsers removes them for good
+func WithDeletedRetention(retention time.Duration) Option {
+	return func(s *AuthService) {
+		s.deletedRetention = retention
+	}
+}
+
+// ListDeletedUsers returns the deleted users not yet purged, oldest deletion first
+func (s *AuthService) ListDeletedUsers(ctx context.Context) (_ []models.UserResponse, err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	stored, err := s.users.ListDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]models.UserResponse, 0, len(stored))
+	for _, user := range stored {
+		users = append(users, user.ToResponse())
+	}
+	return users, nil
+}
+
+// RestoreUser undeletes a user deleted within the retention window. It fails
+// with ErrUserNotFound if there is no such user, and with ErrUserExists or
+// ErrUsernameTaken if the email or username has been registered since.
+// Tokens revoked on deletion stay revoked.
+func (s *AuthService) RestoreUser(ctx context.Context, userID int) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuthEvent{Type: models.EventRestore, TargetID: userID}, err)
+	}()
+
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	err = s.users.Restore(ctx, userID, time.Now().Add(-s.deletedRetention))
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrUserNotFound
+	case errors.Is(err, repository.ErrDuplicateEmail):
+		return ErrUserExists
+	case errors.Is(err, repository.ErrDuplicateUsername):
+		return ErrUsernameTaken
+	}
+	return err
+}
+
+// PurgeDeletedUsers removes the users deleted longer ago than the retention
+// window and returns how many it removed
+func (s *AuthService) PurgeDeletedUsers(ctx context.Context) (_ int, err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return 0, err
+	}
+
+	return s.users.Purge(ctx, time.Now().Add(-s.deletedRetention))
+}
+
+// RunPurgeJob calls PurgeDeletedUsers and PruneRefreshTokens every interval
+// until ctx is done. Failures are logged and retried at the next interval.
+func (s *AuthService) RunPurgeJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeDeletedUsers(ctx)
+			if err != nil {
+				if !errors.Is(err, ErrRequestCancelled) {
+					log.Printf("failed to purge deleted users: %v", err)
+				}
+			} else if purged > 0 {
+				log.Printf("purged %d deleted users", purged)
+			}
+
+			if _, err := s.PruneRefreshTokens(ctx); err != nil && !errors.Is(err, ErrRequestCancelled) {
+				log.Printf("failed to prune refresh tokens: %v", err)
+			}
+		}
+	}
+}
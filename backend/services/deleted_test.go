@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"GateKeeper/models"
+)
+
+func TestDeletedUserCannotLogIn(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	login := loginTestUser(t, s)
+
+	if err := s.DeleteUser(ctx, login.User.ID, "secret1"); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("LoginUser = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := s.GetUserByEmail(ctx, "user@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("GetUserByEmail = %v, want ErrUserNotFound", err)
+	}
+	if page, err := s.ListUsers(ctx, models.ListUsersParams{}); err != nil || page.Total != 0 {
+		t.Errorf("ListUsers = %+v, %v; want no users", page, err)
+	}
+
+	deleted, err := s.ListDeletedUsers(ctx)
+	if err != nil {
+		t.Fatalf("ListDeletedUsers: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != login.User.ID || deleted[0].DeletedAt == nil {
+		t.Errorf("ListDeletedUsers = %+v, want the deleted user with DeletedAt set", deleted)
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	ctx := context.Background()
+	audit := &recordingAuditLogger{}
+	s := newTestService(t, WithAuditLogger(audit))
+	login := loginTestUser(t, s)
+	id := login.User.ID
+	if err := s.DeleteUser(ctx, id, "secret1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.RestoreUser(ctx, id); err != nil {
+		t.Fatalf("RestoreUser: %v", err)
+	}
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"}); err != nil {
+		t.Errorf("LoginUser after restore: %v", err)
+	}
+	if deleted, _ := s.ListDeletedUsers(ctx); len(deleted) != 0 {
+		t.Errorf("ListDeletedUsers = %+v, want none after restore", deleted)
+	}
+	if audit.count(models.EventRestore) != 1 {
+		t.Errorf("recorded %d restore events, want 1", audit.count(models.EventRestore))
+	}
+
+	// Tokens revoked on deletion stay revoked
+	if _, err := s.RefreshToken(ctx, login.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("RefreshToken after restore = %v, want ErrInvalidRefreshToken", err)
+	}
+
+	if err := s.RestoreUser(ctx, id); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("RestoreUser(active user) = %v, want ErrUserNotFound", err)
+	}
+	if err := s.RestoreUser(ctx, id+100); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("RestoreUser(unknown) = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestRestoreUserConflicts(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	for _, tc := range []struct {
+		name     string
+		email    string
+		username string
+		want     error
+	}{
+		{"email taken", "USER@example.com", "someone", ErrUserExists},
+		{"username taken", "someone@example.com", "User", ErrUsernameTaken},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			user, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "user@example.com", Username: "user", Password: "secret1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.DeleteUser(ctx, user.ID, "secret1"); err != nil {
+				t.Fatal(err)
+			}
+			other, err := s.CreateUser(ctx, models.CreateUserRequest{Email: tc.email, Username: tc.username, Password: "secret1"})
+			if err != nil {
+				t.Fatalf("registering over a deleted user: %v", err)
+			}
+
+			if err := s.RestoreUser(ctx, user.ID); !errors.Is(err, tc.want) {
+				t.Fatalf("RestoreUser = %v, want %v", err, tc.want)
+			}
+			if err := s.DeleteUser(ctx, other.ID, "secret1"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestRestoreUserAfterRetention(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, WithDeletedRetention(-time.Second))
+	login := loginTestUser(t, s)
+	if err := s.DeleteUser(ctx, login.User.ID, "secret1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.RestoreUser(ctx, login.User.ID); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("RestoreUser after the retention window = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy DeletionPolicy
+	}{
+		{"retain", DeleteRetain},
+		{"anonymize", DeleteAnonymize},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestService(t, WithDeletionPolicy(tc.policy))
+			login := loginTestUser(t, s)
+			if err := s.DeleteUser(ctx, login.User.ID, "secret1"); err != nil {
+				t.Fatal(err)
+			}
+
+			// Within the retention window nothing is purged
+			if purged, err := s.PurgeDeletedUsers(ctx); err != nil || purged != 0 {
+				t.Fatalf("PurgeDeletedUsers = %d, %v; want nothing purged", purged, err)
+			}
+
+			s.deletedRetention = -time.Second
+			if purged, err := s.PurgeDeletedUsers(ctx); err != nil || purged != 1 {
+				t.Fatalf("PurgeDeletedUsers = %d, %v; want the deleted user purged", purged, err)
+			}
+			if deleted, _ := s.ListDeletedUsers(ctx); len(deleted) != 0 {
+				t.Errorf("ListDeletedUsers = %+v, want none after purging", deleted)
+			}
+			if err := s.RestoreUser(ctx, login.User.ID); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("RestoreUser(purged) = %v, want ErrUserNotFound", err)
+			}
+
+			// The purged email and username can be registered again
+			user, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "user@example.com", Username: "user", Password: "secret1"})
+			if err != nil {
+				t.Fatalf("registering a purged email: %v", err)
+			}
+			if user.ID == login.User.ID {
+				t.Errorf("re-registered user reuses ID %d", user.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// LoginExternal logs in a user authenticated by an external identity
+// provider that verified email. The user with that email is logged in; if
+// their email was unverified, it is marked verified and the account's
+// password, tokens and sessions are dropped (see linkUnverifiedUser). If
+// there is none, a user without a password is created, who can only log in
+// through a provider.
+// username is used for new users, falling back to the email's local part.
+func (s *AuthService) LoginExternal(ctx context.Context, email, username string) (_ *models.LoginResponse, err error) {
+	var userID int
+	defer func() {
+		s.audit(ctx, models.AuthEvent{Type: models.EventExternalLogin, ActorID: userID, TargetID: userID, Email: email}, err)
+	}()
+
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	email = models.NormalizeEmail(email)
+	if err := validateEmail(email); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		if user, err = s.createExternalUser(ctx, email, username); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case !user.IsActive:
+		return nil, ErrUserDeactivated
+	case !user.EmailVerified:
+		if err := s.linkUnverifiedUser(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	userID = user.ID
+	return s.issueTokens(ctx, user, "")
+}
+
+// linkUnverifiedUser marks the email of a user verified by a provider.
+// Whoever registered the email with a password before it was verified may
+// not own it, so the password is cleared and every token and session issued
+// so far is revoked: only the provider's user can log in afterwards.
+func (s *AuthService) linkUnverifiedUser(ctx context.Context, user *models.User) error {
+	now := time.Now()
+	unmodifiedSince := user.UpdatedAt
+	user.EmailVerified = true
+	user.Password = ""
+	user.PasswordChangedAt = now
+	// Safe now that the account only answers to the provider's user
+	if user.Role != models.RoleAdmin {
+		user.Role = s.roleFor(user.Email)
+	}
+	user.UpdatedAt = now
+	if err := s.users.Update(ctx, user, unmodifiedSince); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return ErrUpdateConflict
+		}
+		return err
+	}
+
+	// The password is gone; revoke even if the caller has gone, so no
+	// session outlives the link
+	return s.RevokeAllForUser(context.WithoutCancel(ctx), user.ID)
+}
+
+// externalUsernameAttempts bounds the suffixes tried when an external
+// user's username is taken
+const externalUsernameAttempts = 5
+
+// createExternalUser creates a verified, passwordless user. A taken username
+// gets a random numeric suffix.
+func (s *AuthService) createExternalUser(ctx context.Context, email, username string) (*models.User, error) {
+	username = models.NormalizeUsername(username)
+	if validateUsername(username) != nil {
+		username, _, _ = strings.Cut(email, "@")
+		if len(username) < 3 {
+			username += "_user"
+		}
+		if len(username) > 50 {
+			username = username[:50]
+		}
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Email:         email,
+		Username:      username,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		IsActive:      true,
+		EmailVerified: true,
+		Role:          s.roleFor(email),
+
+		PasswordChangedAt: now,
+	}
+	for attempt := 1; ; attempt++ {
+		err := s.users.Create(ctx, user)
+		switch {
+		case err == nil:
+			return user, nil
+		case errors.Is(err, repository.ErrDuplicateEmail):
+			return nil, ErrUserExists
+		case errors.Is(err, repository.ErrDuplicateUsername) && attempt < externalUsernameAttempts:
+			suffix := fmt.Sprintf("_%04d", rand.IntN(10000))
+			user.Username = username[:min(len(username), 50-len(suffix))] + suffix
+		case errors.Is(err, repository.ErrDuplicateUsername):
+			return nil, ErrUsernameTaken
+		default:
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"GateKeeper/models"
+)
+
+// preRegister registers email with a password, as someone claiming it
+// before its owner, and returns a refresh token, a session ID and an
+// access token from two seconds ago. Token times have one second
+// resolution, so the registration is backdated to tell the access token
+// apart from a later credential change.
+func preRegister(t *testing.T, s *AuthService, email string) (refreshToken, sessionID, accessToken string) {
+	t.Helper()
+	ctx := context.Background()
+	req := models.LoginRequest{Email: email, Password: "secret1"}
+	if _, err := s.CreateUser(ctx, models.CreateUserRequest{Email: email, Username: "squatter", Password: req.Password}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	login, err := s.LoginUser(ctx, req)
+	if err != nil {
+		t.Fatalf("LoginUser: %v", err)
+	}
+	session, err := s.CreateSession(ctx, req)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	user, err := s.users.GetByID(ctx, login.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unmodifiedSince := user.UpdatedAt
+	user.PasswordChangedAt = user.PasswordChangedAt.Add(-time.Hour)
+	user.UpdatedAt = user.UpdatedAt.Add(-time.Hour)
+	if err := s.users.Update(ctx, user, unmodifiedSince); err != nil {
+		t.Fatal(err)
+	}
+	accessToken, _, err = s.tokens.issueToken(user.ID, email, time.Now().Add(-2*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ValidateToken(ctx, accessToken); err != nil {
+		t.Fatalf("ValidateToken before the link: %v", err)
+	}
+	return login.RefreshToken, session.SessionID, accessToken
+}
+
+// checkCredentialsRevoked checks that the pre-registered credentials no
+// longer give access
+func checkCredentialsRevoked(t *testing.T, s *AuthService, email, refreshToken, sessionID, accessToken string) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: email, Password: "secret1"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("login with the pre-registered password = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := s.ValidateToken(ctx, accessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ValidateToken(earlier access token) = %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.RefreshToken(ctx, refreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("RefreshToken(earlier refresh token) = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := s.ResolveSession(ctx, sessionID); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("ResolveSession(earlier session) = %v, want ErrInvalidSession", err)
+	}
+}
+
+func TestLoginExternalRevokesPreRegisteredAccount(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	refreshToken, sessionID, accessToken := preRegister(t, s, "victim@example.com")
+
+	login, err := s.LoginExternal(ctx, "victim@example.com", "victim")
+	if err != nil {
+		t.Fatalf("LoginExternal: %v", err)
+	}
+	if !login.User.EmailVerified {
+		t.Errorf("user = %+v, want the email verified", login.User)
+	}
+	checkCredentialsRevoked(t, s, "victim@example.com", refreshToken, sessionID, accessToken)
+
+	// The provider's tokens work
+	if _, err := s.ValidateToken(ctx, login.AccessToken); err != nil {
+		t.Errorf("ValidateToken(provider login): %v", err)
+	}
+	if _, err := s.RefreshToken(ctx, login.RefreshToken); err != nil {
+		t.Errorf("RefreshToken(provider login): %v", err)
+	}
+}
+
+func TestLoginExternalVerifiedAccountKeepsCredentials(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	if _, err := s.LoginExternal(ctx, "user@example.com", "user"); err != nil {
+		t.Fatalf("LoginExternal: %v", err)
+	}
+	first, err := s.LoginExternal(ctx, "user@example.com", "user")
+	if err != nil {
+		t.Fatalf("LoginExternal: %v", err)
+	}
+	if _, err := s.LoginExternal(ctx, "user@example.com", "user"); err != nil {
+		t.Fatalf("LoginExternal: %v", err)
+	}
+
+	// Logging in again on another device does not end the first one
+	if _, err := s.RefreshToken(ctx, first.RefreshToken); err != nil {
+		t.Errorf("RefreshToken(first login): %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"GateKeeper/models"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hash errors returned by PasswordHasher implementations
+var (
+	// ErrMalformedHash is returned when a stored hash cannot be parsed
+	ErrMalformedHash = errors.New("malformed password hash")
+
+	// ErrUnsafeHashParams is returned when hashing parameters are below the safe minimums
+	ErrUnsafeHashParams = errors.New("unsafe password hashing parameters")
+)
+
+// PasswordHasher hashes passwords into self-describing strings. Bcrypt
+// hashes use the modular crypt format ($2a$...) and Argon2id hashes the PHC
+// string format ($argon2id$...), so the algorithm and parameters of a stored
+// hash can always be recovered from the hash itself.
+type PasswordHasher interface {
+	// Hash returns the hash of password
+	Hash(password string) (string, error)
+
+	// NeedsRehash reports whether hash was produced by another algorithm or
+	// with other parameters than this hasher's
+	NeedsRehash(hash string) bool
+}
+
+// verifyPassword checks password against a hash produced by any supported
+// hasher. It fails with ErrMalformedHash if the hash cannot be parsed.
+func verifyPassword(hash, password string) (bool, error) {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		params, salt, key, err := parseArgon2id(hash)
+		if err != nil {
+			return false, err
+		}
+		computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(computed, key) == 1, nil
+	case strings.HasPrefix(hash, "$2"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		} else if err != nil {
+			return false, fmt.Errorf("%w: %v", ErrMalformedHash, err)
+		}
+		return true, nil
+	}
+	return false, ErrMalformedHash
+}
+
+// BcryptHasher hashes passwords with bcrypt. The zero value uses
+// bcrypt.DefaultCost.
+type BcryptHasher struct {
+	cost int
+}
+
+// Ensure BcryptHasher implements PasswordHasher interface
+var _ PasswordHasher = BcryptHasher{}
+
+// NewBcryptHasher creates a hasher using cost, which must be between
+// bcrypt.MinCost and bcrypt.MaxCost
+func NewBcryptHasher(cost int) (BcryptHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return BcryptHasher{}, fmt.Errorf("%w: bcrypt cost must be between %d and %d",
+			ErrUnsafeHashParams, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return BcryptHasher{cost: cost}, nil
+}
+
+// Cost returns the cost of new hashes
+func (h BcryptHasher) Cost() int {
+	if h.cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return h.cost
+}
+
+// Hash returns the bcrypt hash of password
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost())
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// NeedsRehash reports whether hash is not a bcrypt hash or has a lower cost
+// than this hasher's. Hashes with a higher cost are kept.
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < h.Cost()
+}
+
+// Argon2Params are the Argon2id cost parameters
+type Argon2Params struct {
+	// Memory is the memory cost in KiB
+	Memory uint32
+
+	// Iterations is the number of passes over the memory
+	Iterations uint32
+
+	// Parallelism is the number of lanes
+	Parallelism uint8
+
+	// SaltLength and KeyLength are the salt and derived key sizes in bytes
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// Minimum Argon2id parameters accepted by NewArgon2idHasher, following the
+// OWASP recommendation of 19 MiB of memory and 2 iterations
+const (
+	minArgon2Memory     = 19 * 1024
+	minArgon2Iterations = 2
+	minArgon2SaltLength = 16
+	minArgon2KeyLength  = 16
+)
+
+// DefaultArgon2Params returns 64 MiB of memory, 3 iterations, 2 lanes, a
+// 16 byte salt and a 32 byte key
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Argon2idHasher hashes passwords with Argon2id
+type Argon2idHasher struct {
+	params Argon2Params
+}
+
+// Ensure Argon2idHasher implements PasswordHasher interface
+var _ PasswordHasher = (*Argon2idHasher)(nil)
+
+// NewArgon2idHasher creates a hasher using params, which must not be below
+// the safe minimums
+func NewArgon2idHasher(params Argon2Params) (*Argon2idHasher, error) {
+	switch {
+	case params.Memory < minArgon2Memory:
+		return nil, fmt.Errorf("%w: memory must be at least %d KiB", ErrUnsafeHashParams, minArgon2Memory)
+	case params.Iterations < minArgon2Iterations:
+		return nil, fmt.Errorf("%w: iterations must be at least %d", ErrUnsafeHashParams, minArgon2Iterations)
+	case params.Parallelism < 1:
+		return nil, fmt.Errorf("%w: parallelism must be at least 1", ErrUnsafeHashParams)
+	case params.SaltLength < minArgon2SaltLength:
+		return nil, fmt.Errorf("%w: salt must be at least %d bytes", ErrUnsafeHashParams, minArgon2SaltLength)
+	case params.KeyLength < minArgon2KeyLength:
+		return nil, fmt.Errorf("%w: key must be at least %d bytes", ErrUnsafeHashParams, minArgon2KeyLength)
+	}
+	return &Argon2idHasher{params: params}, nil
+}
+
+// Hash returns the Argon2id hash of password in PHC string format
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// NeedsRehash reports whether hash is not an Argon2id hash with this hasher's parameters
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.params.Memory ||
+		params.Iterations != h.params.Iterations ||
+		params.Parallelism != h.params.Parallelism ||
+		uint32(len(salt)) != h.params.SaltLength ||
+		uint32(len(key)) != h.params.KeyLength
+}
+
+// parseArgon2id splits a PHC-formatted Argon2id hash into its parameters,
+// salt and key
+func parseArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, fmt.Errorf("%w: bad version", ErrMalformedHash)
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("%w: unsupported version %d", ErrMalformedHash, version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("%w: bad parameters", ErrMalformedHash)
+	}
+	if params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, fmt.Errorf("%w: bad parameters", ErrMalformedHash)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("%w: bad salt", ErrMalformedHash)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, fmt.Errorf("%w: bad key", ErrMalformedHash)
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// WithPasswordHasher sets the hasher used for new and changed passwords,
+// e.g. a BcryptHasher from NewBcryptHasher to choose the bcrypt cost.
+// Stored hashes of any supported algorithm still verify, and are re-hashed
+// with hasher on the user's next successful login.
+func WithPasswordHasher(hasher PasswordHasher) Option {
+	return func(s *AuthService) {
+		s.hasher = hasher
+	}
+}
+
+// checkPassword reports whether password matches the user's stored hash.
+// A malformed stored hash is logged and never matches. Verifying is slow, so
+// it fails with a *RequestCancelledError if ctx ends before or during it.
+func (s *AuthService) checkPassword(ctx context.Context, user *models.User, password string) (bool, error) {
+	if err := checkContext(ctx); err != nil {
+		return false, err
+	}
+	if user.Password == "" {
+		return false, nil
+	}
+	ok, err := verifyPassword(user.Password, password)
+	if err != nil {
+		log.Printf("cannot verify password of user %d: %v", user.ID, err)
+		return false, nil
+	}
+	return ok, checkContext(ctx)
+}
+
+// hashPassword hashes password with the configured hasher. Hashing is slow,
+// so it fails with a *RequestCancelledError if ctx ends before or during it.
+func (s *AuthService) hashPassword(ctx context.Context, password string) (string, error) {
+	if err := checkContext(ctx); err != nil {
+		return "", err
+	}
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return "", err
+	}
+	return hash, checkContext(ctx)
+}
+
+// rehashIfNeeded re-hashes the user's password with the configured hasher
+// if the stored hash uses another algorithm or other parameters. It runs
+// after a successful login, so a failure is only logged and the old hash
+// stays in place.
+func (s *AuthService) rehashIfNeeded(ctx context.Context, user *models.User, password string) {
+	if !s.hasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hash, err := s.hashPassword(ctx, password)
+	if errors.Is(err, ErrRequestCancelled) {
+		return
+	} else if err != nil {
+		log.Printf("failed to rehash password of user %d: %v", user.ID, err)
+		return
+	}
+
+	unmodifiedSince := user.UpdatedAt
+	updated := *user
+	updated.Password = hash
+	updated.UpdatedAt = time.Now()
+	if err := s.users.Update(ctx, &updated, unmodifiedSince); err != nil {
+		log.Printf("failed to store rehashed password of user %d: %v", user.ID, err)
+		return
+	}
+	*user = updated
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"GateKeeper/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params are the cheapest parameters NewArgon2idHasher accepts
+var testArgon2Params = Argon2Params{
+	Memory:      minArgon2Memory,
+	Iterations:  minArgon2Iterations,
+	Parallelism: 1,
+	SaltLength:  minArgon2SaltLength,
+	KeyLength:   minArgon2KeyLength,
+}
+
+// testHashers returns a cheap hasher of each supported algorithm
+func testHashers(t *testing.T) map[string]PasswordHasher {
+	t.Helper()
+	bcryptHasher, err := NewBcryptHasher(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	argon2Hasher, err := NewArgon2idHasher(testArgon2Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]PasswordHasher{"bcrypt": bcryptHasher, "argon2id": argon2Hasher}
+}
+
+func TestHashersVerify(t *testing.T) {
+	hashers := testHashers(t)
+	for name, hasher := range hashers {
+		t.Run(name, func(t *testing.T) {
+			hash, err := hasher.Hash("secret1")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if ok, err := verifyPassword(hash, "secret1"); !ok || err != nil {
+				t.Errorf("verifyPassword(right password) = %v, %v; want true", ok, err)
+			}
+			if ok, err := verifyPassword(hash, "secret2"); ok || err != nil {
+				t.Errorf("verifyPassword(wrong password) = %v, %v; want false", ok, err)
+			}
+			if again, _ := hasher.Hash("secret1"); again == hash {
+				t.Error("two hashes of one password are equal, want distinct salts")
+			}
+
+			// A hasher keeps its own hashes and replaces the others'
+			if hasher.NeedsRehash(hash) {
+				t.Error("NeedsRehash(own hash) = true")
+			}
+			for other, otherHasher := range hashers {
+				if other != name && !otherHasher.NeedsRehash(hash) {
+					t.Errorf("%s NeedsRehash(%s hash) = false", other, name)
+				}
+			}
+		})
+	}
+}
+
+func TestArgon2idNeedsRehashOnParamChange(t *testing.T) {
+	hasher, _ := NewArgon2idHasher(testArgon2Params)
+	hash, err := hasher.Hash("secret1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stronger := testArgon2Params
+	stronger.Iterations++
+	strongerHasher, _ := NewArgon2idHasher(stronger)
+	if !strongerHasher.NeedsRehash(hash) {
+		t.Error("NeedsRehash = false for a hash with fewer iterations")
+	}
+}
+
+func TestVerifyMalformedHash(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		hash string
+	}{
+		{"empty", ""},
+		{"plain text", "secret1"},
+		{"unknown algorithm", "$scrypt$ln=15,r=8,p=1$c2FsdA$a2V5"},
+		{"truncated argon2id", "$argon2id$v=19$m=19456,t=2,p=1$c2FsdHNhbHRzYWx0c2FsdA"},
+		{"bad argon2id version", "$argon2id$v=16$m=19456,t=2,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5aw"},
+		{"bad argon2id parameters", "$argon2id$v=19$m=19456,t=0,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5aw"},
+		{"bad argon2id salt", "$argon2id$v=19$m=19456,t=2,p=1$!!!$a2V5a2V5a2V5a2V5a2V5aw"},
+		{"truncated bcrypt", "$2a$04$abc"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if ok, err := verifyPassword(tc.hash, "secret1"); ok || !errors.Is(err, ErrMalformedHash) {
+				t.Fatalf("verifyPassword = %v, %v; want ErrMalformedHash", ok, err)
+			}
+		})
+	}
+}
+
+func TestNewArgon2idHasherRejectsUnsafeParams(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		change func(*Argon2Params)
+	}{
+		{"memory", func(p *Argon2Params) { p.Memory = 1024 }},
+		{"iterations", func(p *Argon2Params) { p.Iterations = 1 }},
+		{"parallelism", func(p *Argon2Params) { p.Parallelism = 0 }},
+		{"salt", func(p *Argon2Params) { p.SaltLength = 8 }},
+		{"key", func(p *Argon2Params) { p.KeyLength = 8 }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := testArgon2Params
+			tc.change(&params)
+			if _, err := NewArgon2idHasher(params); !errors.Is(err, ErrUnsafeHashParams) {
+				t.Fatalf("NewArgon2idHasher = %v, want ErrUnsafeHashParams", err)
+			}
+		})
+	}
+	if _, err := NewBcryptHasher(3); !errors.Is(err, ErrUnsafeHashParams) {
+		t.Errorf("NewBcryptHasher(3) = %v, want ErrUnsafeHashParams", err)
+	}
+}
+
+func TestLoginUpgradesHash(t *testing.T) {
+	ctx := context.Background()
+	bcryptService := newTestService(t)
+	id := loginTestUser(t, bcryptService).User.ID
+
+	// The same users behind a service configured for Argon2id
+	argon2Service := NewAuthServiceWithConfig(bcryptService.users, DefaultTokenConfig(),
+		WithPasswordHasher(testHashers(t)["argon2id"]), WithAuditLogger(nopAuditLogger{}))
+	login := models.LoginRequest{Email: "user@example.com", Password: "secret1"}
+
+	if _, err := argon2Service.LoginUser(ctx, models.LoginRequest{Email: login.Email, Password: "wrong12"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("LoginUser(wrong password) = %v, want ErrInvalidCredentials", err)
+	}
+	if user, _ := argon2Service.users.GetByID(ctx, id); !strings.HasPrefix(user.Password, "$2") {
+		t.Fatalf("stored hash %q changed after a failed login", user.Password)
+	}
+
+	if _, err := argon2Service.LoginUser(ctx, login); err != nil {
+		t.Fatalf("LoginUser against the bcrypt hash: %v", err)
+	}
+	if user, _ := argon2Service.users.GetByID(ctx, id); !strings.HasPrefix(user.Password, "$argon2id$") {
+		t.Fatalf("stored hash = %q after login, want it upgraded to Argon2id", user.Password)
+	}
+
+	// Both services verify the upgraded hash
+	for name, s := range map[string]*AuthService{"argon2id": argon2Service, "bcrypt": bcryptService} {
+		if _, err := s.LoginUser(ctx, login); err != nil {
+			t.Errorf("%s service: LoginUser against the Argon2id hash: %v", name, err)
+		}
+	}
+}
+
+func TestLoginMalformedStoredHash(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	id := loginTestUser(t, s).User.ID
+
+	user, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.Password = "$argon2id$garbage"
+	if err := s.users.Update(ctx, user, user.UpdatedAt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("LoginUser = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+// storedCost returns the bcrypt cost of the stored hash of the user with id
+func storedCost(t *testing.T, s *AuthService, id int) int {
+	t.Helper()
+	user, err := s.users.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cost, err := bcrypt.Cost([]byte(user.Password))
+	if err != nil {
+		t.Fatalf("stored hash %q: %v", user.Password, err)
+	}
+	return cost
+}
+
+func TestBcryptCost(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	id := loginTestUser(t, s).User.ID
+	if cost := storedCost(t, s, id); cost != 4 {
+		t.Fatalf("stored cost = %d, want the configured 4", cost)
+	}
+
+	login := models.LoginRequest{Email: "user@example.com", Password: "secret1"}
+	for _, tc := range []struct {
+		name       string
+		cost, want int
+	}{
+		{"higher cost upgrades", 5, 5},
+		{"lower cost keeps the hash", 4, 5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			hasher, err := NewBcryptHasher(tc.cost)
+			if err != nil {
+				t.Fatal(err)
+			}
+			service := NewAuthServiceWithConfig(s.users, DefaultTokenConfig(), WithPasswordHasher(hasher), WithAuditLogger(nopAuditLogger{}))
+			if _, err := service.LoginUser(ctx, login); err != nil {
+				t.Fatalf("LoginUser: %v", err)
+			}
+			if cost := storedCost(t, s, id); cost != tc.want {
+				t.Errorf("stored cost = %d, want %d", cost, tc.want)
+			}
+		})
+	}
+
+	if cost := (BcryptHasher{}).Cost(); cost != bcrypt.DefaultCost {
+		t.Errorf("zero BcryptHasher cost = %d, want bcrypt.DefaultCost", cost)
+	}
+	if _, err := NewBcryptHasher(bcrypt.MaxCost + 1); !errors.Is(err, ErrUnsafeHashParams) {
+		t.Errorf("NewBcryptHasher(MaxCost+1) = %v, want ErrUnsafeHashParams", err)
+	}
+}
+
+// failingHasher wants to rehash everything but cannot hash
+type failingHasher struct{}
+
+func (failingHasher) Hash(string) (string, error) { return "", errors.New("hasher unavailable") }
+func (failingHasher) NeedsRehash(string) bool     { return true }
+
+func TestRehashFailureKeepsHash(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	id := loginTestUser(t, s).User.ID
+	before, _ := s.users.GetByID(ctx, id)
+
+	failing := NewAuthServiceWithConfig(s.users, DefaultTokenConfig(), WithPasswordHasher(failingHasher{}), WithAuditLogger(nopAuditLogger{}))
+	if _, err := failing.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"}); err != nil {
+		t.Fatalf("LoginUser with a failing rehash: %v", err)
+	}
+	if after, _ := s.users.GetByID(ctx, id); after.Password != before.Password {
+		t.Error("the stored hash changed although rehashing failed")
+	}
+}
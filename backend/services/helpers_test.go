@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// nopAuditLogger discards audit events
+type nopAuditLogger struct{}
+
+func (nopAuditLogger) Log(context.Context, models.AuthEvent) error { return nil }
+
+// recordingAuditLogger keeps the audit events it is given
+type recordingAuditLogger struct {
+	mu     sync.Mutex
+	events []models.AuthEvent
+}
+
+func (l *recordingAuditLogger) Log(_ context.Context, event models.AuthEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+	return nil
+}
+
+// count returns the number of events of type eventType
+func (l *recordingAuditLogger) count(eventType models.AuthEventType) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, event := range l.events {
+		if event.Type == eventType {
+			n++
+		}
+	}
+	return n
+}
+
+// newTestService creates a service with an in-memory user store, a cheap
+// password hasher and no audit output, configured further by opts
+func newTestService(t *testing.T, opts ...Option) *AuthService {
+	t.Helper()
+	hasher, err := NewBcryptHasher(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = append([]Option{WithPasswordHasher(hasher), WithAuditLogger(nopAuditLogger{})}, opts...)
+	return NewAuthServiceWithConfig(repository.NewMemoryUserRepository(), DefaultTokenConfig(), opts...)
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// UnlockNotifier tells users their account was unlocked
+type UnlockNotifier interface {
+	// AccountUnlocked notifies the user that an admin unlocked their account
+	AccountUnlocked(ctx context.Context, user *models.User) error
+}
+
+// WithUnlockNotifier sets the UnlockNotifier told about unlocked accounts.
+// Without one, users are not notified.
+func WithUnlockNotifier(notifier UnlockNotifier) Option {
+	return func(s *AuthService) {
+		s.unlockNotifier = notifier
+	}
+}
+
+// GetLockoutStatus returns the failed login history of the account with
+// email, whether or not a user has that email
+func (s *AuthService) GetLockoutStatus(ctx context.Context, email string) (_ *models.LockoutStatus, err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	email = models.NormalizeEmail(email)
+	state, err := s.limiter.store.Get(ctx, accountKey(email))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	status := &models.LockoutStatus{
+		Email:               email,
+		ConsecutiveFailures: state.ConsecutiveFailures,
+		Lockouts:            state.Lockouts,
+		RecentAttempts:      slices.Clone(state.Recent),
+	}
+	if now.Sub(state.WindowStart) < s.limiter.config.Window {
+		status.FailedAttempts = state.Failures
+	}
+	if state.LockedUntil.After(now) {
+		lockedUntil := state.LockedUntil
+		status.LockedUntil = &lockedUntil
+	}
+	if status.RecentAttempts == nil {
+		status.RecentAttempts = []models.LoginAttempt{}
+	}
+	return status, nil
+}
+
+// UnlockAccount clears the failed login history of the account with email,
+// lifting any lockout, on behalf of the admin with adminID. The password,
+// tokens and sessions are left alone. If the account belongs to a user and
+// an UnlockNotifier is set, the user is notified; a failure to notify is
+// only logged.
+func (s *AuthService) UnlockAccount(ctx context.Context, email string, adminID int) (err error) {
+	email = models.NormalizeEmail(email)
+	var userID int
+	defer func() {
+		s.audit(ctx, models.AuthEvent{Type: models.EventUnlock, ActorID: adminID, TargetID: userID, Email: email}, err)
+	}()
+
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	if user != nil {
+		userID = user.ID
+	}
+
+	if err := s.limiter.reset(ctx, accountKey(email)); err != nil {
+		return err
+	}
+
+	if user != nil && s.unlockNotifier != nil {
+		if err := s.unlockNotifier.AccountUnlocked(ctx, user); err != nil {
+			log.Printf("failed to notify user %d of unlock: %v", user.ID, err)
+		}
+	}
+	return nil
+}
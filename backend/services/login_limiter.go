@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"GateKeeper/models"
+)
+
+// ErrTooManyAttempts is returned by LoginUser while login attempts for the
+// account or client are throttled or the account is locked. The returned
+// error is a *TooManyAttemptsError carrying the retry delay.
+var ErrTooManyAttempts = errors.New("too many login attempts")
+
+// TooManyAttemptsError reports a throttled login and when to retry
+type TooManyAttemptsError struct {
+	// RetryAfter is how long to wait before trying again
+	RetryAfter time.Duration
+
+	// Locked is true if the account is locked rather than throttled
+	Locked bool
+}
+
+// Error implements the error interface
+func (e *TooManyAttemptsError) Error() string {
+	// Round up so a short wait is not reported as 0s
+	wait := (e.RetryAfter + time.Second - 1).Truncate(time.Second)
+	if e.Locked {
+		return fmt.Sprintf("account temporarily locked, retry after %v", wait)
+	}
+	return fmt.Sprintf("%v, retry after %v", ErrTooManyAttempts, wait)
+}
+
+// Unwrap returns ErrTooManyAttempts so errors.Is matches it
+func (e *TooManyAttemptsError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// LoginLimitConfig configures login throttling and account lockout
+type LoginLimitConfig struct {
+	// MaxAttempts is the number of failed logins allowed per account and per
+	// client within Window; further attempts are throttled until the window
+	// ends. Zero disables throttling.
+	MaxAttempts int
+
+	// Window is the period over which failed logins are counted
+	Window time.Duration
+
+	// LockoutThreshold is the number of consecutive failed logins after which
+	// the account is locked. Zero disables lockout.
+	LockoutThreshold int
+
+	// LockoutDuration is the length of the first lockout; each further
+	// lockout doubles it
+	LockoutDuration time.Duration
+
+	// MaxLockoutDuration caps the lockout length. Lockout history is
+	// forgotten after this long without failures.
+	MaxLockoutDuration time.Duration
+}
+
+// DefaultLoginLimitConfig returns a config allowing 5 failed logins per
+// 15 minutes and locking the account after 10 consecutive failures, for
+// 15 minutes at first and at most 24 hours.
+func DefaultLoginLimitConfig() LoginLimitConfig {
+	return LoginLimitConfig{
+		MaxAttempts:        5,
+		Window:             15 * time.Minute,
+		LockoutThreshold:   10,
+		LockoutDuration:    15 * time.Minute,
+		MaxLockoutDuration: 24 * time.Hour,
+	}
+}
+
+// AttemptState is the failed login history of one account or client
+type AttemptState struct {
+	// Failures counts failed logins since WindowStart
+	Failures    int
+	WindowStart time.Time
+
+	// ConsecutiveFailures counts failed logins since the last success or lockout
+	ConsecutiveFailures int
+
+	// Lockouts counts lockouts since the last successful login
+	Lockouts    int
+	LockedUntil time.Time
+
+	// Recent holds the latest failed logins, oldest first, at most
+	// maxRecentAttempts of them
+	Recent []models.LoginAttempt
+}
+
+// maxRecentAttempts bounds AttemptState.Recent
+const maxRecentAttempts = 10
+
+// AttemptStore stores login attempt state by key, so it can be shared
+// between replicas (e.g. in Redis)
+type AttemptStore interface {
+	// Get returns the state stored for key, or the zero state if there is none
+	Get(ctx context.Context, key string) (AttemptState, error)
+
+	// Set stores the state for key, to be forgotten after ttl
+	Set(ctx context.Context, key string, state AttemptState, ttl time.Duration) error
+
+	// Delete forgets the state for key
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryAttemptStore is an in-process AttemptStore
+type MemoryAttemptStore struct {
+	mu        sync.Mutex
+	entries   map[string]attemptEntry
+	lastPrune time.Time
+}
+
+// attemptEntry is a stored state with its expiry
+type attemptEntry struct {
+	state     AttemptState
+	expiresAt time.Time
+}
+
+// Ensure MemoryAttemptStore implements AttemptStore interface
+var _ AttemptStore = (*MemoryAttemptStore)(nil)
+
+// NewMemoryAttemptStore creates an empty in-memory attempt store
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{entries: make(map[string]attemptEntry)}
+}
+
+// Get returns the state stored for key, or the zero state if there is none
+func (m *MemoryAttemptStore) Get(ctx context.Context, key string) (AttemptState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return AttemptState{}, nil
+	}
+	return entry.state, nil
+}
+
+// Set stores the state for key, to be forgotten after ttl
+func (m *MemoryAttemptStore) Set(ctx context.Context, key string, state AttemptState, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.entries[key] = attemptEntry{state: state, expiresAt: now.Add(ttl)}
+
+	// Drop expired entries at most once a minute
+	if now.Sub(m.lastPrune) > time.Minute {
+		for k, entry := range m.entries {
+			if now.After(entry.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+		m.lastPrune = now
+	}
+	return nil
+}
+
+// Delete forgets the state for key
+func (m *MemoryAttemptStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// loginLimiter applies a LoginLimitConfig to the states in an AttemptStore
+type loginLimiter struct {
+	config LoginLimitConfig
+	store  AttemptStore
+
+	// mu serializes read-modify-write cycles against the store
+	mu sync.Mutex
+}
+
+// newLoginLimiter creates a limiter, using an in-memory store if store is nil
+func newLoginLimiter(config LoginLimitConfig, store AttemptStore) *loginLimiter {
+	if store == nil {
+		store = NewMemoryAttemptStore()
+	}
+	return &loginLimiter{config: config, store: store}
+}
+
+// accountKey returns the attempt store key of the account with email
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// clientKey returns the attempt store key of a client
+func clientKey(key string) string {
+	return "client:" + key
+}
+
+// check returns a *TooManyAttemptsError if any of keys is locked or throttled
+func (l *loginLimiter) check(ctx context.Context, now time.Time, keys ...string) error {
+	var blocked *TooManyAttemptsError
+	for _, key := range keys {
+		state, err := l.store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		var wait time.Duration
+		locked := now.Before(state.LockedUntil)
+		if locked {
+			wait = state.LockedUntil.Sub(now)
+		} else if l.config.MaxAttempts > 0 && state.Failures >= l.config.MaxAttempts {
+			if windowEnd := state.WindowStart.Add(l.config.Window); now.Before(windowEnd) {
+				wait = windowEnd.Sub(now)
+			}
+		}
+		if wait > 0 && (blocked == nil || wait > blocked.RetryAfter) {
+			blocked = &TooManyAttemptsError{RetryAfter: wait, Locked: locked}
+		}
+	}
+	if blocked != nil {
+		return blocked
+	}
+	return nil
+}
+
+// recordFailure counts a failed login against key and reports whether it
+// locked key. Only keys with lockable set (accounts) are locked after
+// LockoutThreshold consecutive failures.
+func (l *loginLimiter) recordFailure(ctx context.Context, now time.Time, key string, lockable bool) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, err := l.store.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	if now.Sub(state.WindowStart) >= l.config.Window {
+		state.Failures = 0
+		state.WindowStart = now
+	}
+	state.Failures++
+	state.ConsecutiveFailures++
+	// Clip so appending never writes into an array the store still holds
+	state.Recent = append(slices.Clip(state.Recent), models.LoginAttempt{
+		Time:      now,
+		IP:        clientKeyFrom(ctx),
+		UserAgent: userAgentFrom(ctx),
+	})
+	if len(state.Recent) > maxRecentAttempts {
+		state.Recent = state.Recent[len(state.Recent)-maxRecentAttempts:]
+	}
+
+	locked := lockable && l.config.LockoutThreshold > 0 && state.ConsecutiveFailures >= l.config.LockoutThreshold
+	if locked {
+		duration := l.lockoutDuration(state.Lockouts)
+		state.Lockouts++
+		state.LockedUntil = now.Add(duration)
+		state.ConsecutiveFailures = 0
+		log.Printf("%s locked for %v after %d consecutive failed logins (lockout %d)",
+			key, duration, l.config.LockoutThreshold, state.Lockouts)
+	}
+
+	ttl := l.config.Window
+	if state.LockedUntil.After(now) {
+		ttl = state.LockedUntil.Sub(now)
+	}
+	return locked, l.store.Set(ctx, key, state, ttl+l.config.MaxLockoutDuration)
+}
+
+// lockoutDuration returns the length of a lockout after previous lockouts
+func (l *loginLimiter) lockoutDuration(previous int) time.Duration {
+	duration := l.config.LockoutDuration
+	for i := 0; i < previous && duration < l.config.MaxLockoutDuration; i++ {
+		duration *= 2
+	}
+	if l.config.MaxLockoutDuration > 0 && duration > l.config.MaxLockoutDuration {
+		duration = l.config.MaxLockoutDuration
+	}
+	return duration
+}
+
+// reset forgets the history of key after a successful login
+func (l *loginLimiter) reset(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.store.Delete(ctx, key)
+}
+
+// clientKeyContextKey is the context key under which WithClientKey stores the key
+type clientKeyContextKey struct{}
+
+// WithClientKey returns a copy of ctx carrying key, usually the caller's IP
+// address, so LoginUser can throttle failed logins per client as well as per
+// account
+func WithClientKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, clientKeyContextKey{}, key)
+}
+
+// clientKeyFrom returns the key stored by WithClientKey, or ""
+func clientKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(clientKeyContextKey{}).(string)
+	return key
+}
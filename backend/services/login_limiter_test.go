@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"GateKeeper/models"
+)
+
+// newLimitedService returns a service with the login limits in config and a
+// registered user@example.com with password secret1
+func newLimitedService(t *testing.T, config LoginLimitConfig, opts ...Option) *AuthService {
+	t.Helper()
+	s := newTestService(t, append(opts, WithLoginLimits(config, nil))...)
+	if _, err := s.CreateUser(context.Background(), models.CreateUserRequest{Email: "user@example.com", Username: "user", Password: "secret1"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return s
+}
+
+// tryLogin logs in as user@example.com with password
+func tryLogin(ctx context.Context, s *AuthService, password string) error {
+	_, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: password})
+	return err
+}
+
+// requireTooMany fails the test unless err is a *TooManyAttemptsError with
+// the given lock state and a retry delay of at most max
+func requireTooMany(t *testing.T, err error, locked bool, max time.Duration) {
+	t.Helper()
+	var tooMany *TooManyAttemptsError
+	if !errors.As(err, &tooMany) || !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("err = %v, want a *TooManyAttemptsError", err)
+	}
+	if tooMany.Locked != locked || tooMany.RetryAfter <= 0 || tooMany.RetryAfter > max {
+		t.Fatalf("err = %+v, want Locked %v and RetryAfter in (0, %v]", tooMany, locked, max)
+	}
+}
+
+func TestLoginThrottling(t *testing.T) {
+	ctx := context.Background()
+	window := 150 * time.Millisecond
+	s := newLimitedService(t, LoginLimitConfig{MaxAttempts: 3, Window: window})
+
+	for i := 0; i < 3; i++ {
+		if err := tryLogin(ctx, s, "wrong12"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("failure %d: err = %v, want ErrInvalidCredentials", i+1, err)
+		}
+	}
+	requireTooMany(t, tryLogin(ctx, s, "wrong12"), false, window)
+
+	// The password is not checked while throttled
+	requireTooMany(t, tryLogin(ctx, s, "secret1"), false, window)
+
+	time.Sleep(window)
+	if err := tryLogin(ctx, s, "secret1"); err != nil {
+		t.Fatalf("login after the window: %v", err)
+	}
+}
+
+func TestLoginSuccessResetsCounters(t *testing.T) {
+	ctx := context.Background()
+	s := newLimitedService(t, LoginLimitConfig{MaxAttempts: 3, Window: time.Minute})
+
+	for _, password := range []string{"wrong12", "wrong12", "secret1", "wrong12", "wrong12", "secret1"} {
+		err := tryLogin(ctx, s, password)
+		if password == "secret1" && err != nil {
+			t.Fatalf("login: %v", err)
+		}
+		if password != "secret1" && !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("failed login: err = %v, want ErrInvalidCredentials", err)
+		}
+	}
+}
+
+func TestLoginThrottlingPerClient(t *testing.T) {
+	s := newLimitedService(t, LoginLimitConfig{MaxAttempts: 2, Window: time.Minute})
+	attacker := WithClientKey(context.Background(), "192.0.2.1")
+
+	// Failures against different accounts add up for the client
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		if _, err := s.LoginUser(attacker, models.LoginRequest{Email: email, Password: "wrong12"}); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("%s: err = %v, want ErrInvalidCredentials", email, err)
+		}
+	}
+	requireTooMany(t, tryLogin(attacker, s, "secret1"), false, time.Minute)
+
+	// Other clients can still log in to the account
+	if err := tryLogin(WithClientKey(context.Background(), "192.0.2.2"), s, "secret1"); err != nil {
+		t.Fatalf("login from another client: %v", err)
+	}
+}
+
+func TestAccountLockout(t *testing.T) {
+	ctx := context.Background()
+	audit := &recordingAuditLogger{}
+	lockout := 100 * time.Millisecond
+	s := newLimitedService(t, LoginLimitConfig{
+		LockoutThreshold:   3,
+		LockoutDuration:    lockout,
+		MaxLockoutDuration: time.Second,
+	}, WithAuditLogger(audit))
+
+	// fail records threshold failed logins, the last of which locks the account
+	fail := func() {
+		t.Helper()
+		for i := 0; i < 3; i++ {
+			if err := tryLogin(ctx, s, "wrong12"); !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("failure %d: err = %v, want ErrInvalidCredentials", i+1, err)
+			}
+		}
+	}
+
+	fail()
+	requireTooMany(t, tryLogin(ctx, s, "secret1"), true, lockout)
+	if n := audit.count(models.EventLockout); n != 1 {
+		t.Errorf("%d lockout events audited, want 1", n)
+	}
+
+	// The second lockout lasts twice as long
+	time.Sleep(lockout)
+	fail()
+	err := tryLogin(ctx, s, "secret1")
+	requireTooMany(t, err, true, 2*lockout)
+	var tooMany *TooManyAttemptsError
+	errors.As(err, &tooMany)
+	if tooMany.RetryAfter <= lockout {
+		t.Errorf("second lockout RetryAfter = %v, want more than %v", tooMany.RetryAfter, lockout)
+	}
+	if n := audit.count(models.EventLockout); n != 2 {
+		t.Errorf("%d lockout events audited, want 2", n)
+	}
+
+	time.Sleep(2 * lockout)
+	if err := tryLogin(ctx, s, "secret1"); err != nil {
+		t.Fatalf("login after the lockout: %v", err)
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	l := newLoginLimiter(LoginLimitConfig{LockoutDuration: time.Minute, MaxLockoutDuration: 10 * time.Minute}, nil)
+	for previous, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute} {
+		if got := l.lockoutDuration(previous); got != want {
+			t.Errorf("lockoutDuration(%d) = %v, want %v", previous, got, want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// Password policy limits
+const (
+	minPasswordLength = 6
+
+	// maxPasswordBytes is the longest password bcrypt hashes in full; it
+	// applies to every hasher so users can switch between them
+	maxPasswordBytes = 72
+)
+
+// Password errors returned by CreateUser and ChangePassword
+var (
+	// ErrWeakPassword is returned when a password does not meet the password policy
+	ErrWeakPassword = errors.New("password does not meet the password policy")
+
+	// ErrPasswordReused is returned when the new password equals the current one
+	ErrPasswordReused = errors.New("new password must differ from the current password")
+)
+
+// validatePassword checks password against the password policy
+func validatePassword(password string) error {
+	switch {
+	case len(password) < minPasswordLength:
+		return fmt.Errorf("%w: must be at least %d characters", ErrWeakPassword, minPasswordLength)
+	case len(password) > maxPasswordBytes:
+		return fmt.Errorf("%w: must be at most %d bytes", ErrWeakPassword, maxPasswordBytes)
+	}
+	return nil
+}
+
+// ChangePassword replaces the user's password after confirming the current
+// one. A wrong current password, or an unknown user, fails with
+// ErrInvalidCredentials. On success all of the user's refresh tokens are
+// revoked and access tokens issued before the change stop validating, so
+// every session, including the caller's, has to log in again.
+func (s *AuthService) ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuthEvent{Type: models.EventPasswordChange, ActorID: userID, TargetID: userID}, err)
+	}()
+
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidCredentials
+	} else if err != nil {
+		return err
+	}
+
+	// Check current password
+	if ok, err := s.checkPassword(ctx, user, currentPassword); err != nil {
+		return err
+	} else if !ok {
+		return ErrInvalidCredentials
+	}
+
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+	if reused, err := s.checkPassword(ctx, user, newPassword); err != nil {
+		return err
+	} else if reused {
+		return ErrPasswordReused
+	}
+
+	hashedPassword, err := s.hashPassword(ctx, newPassword)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	unmodifiedSince := user.UpdatedAt
+	user.Password = hashedPassword
+	user.UpdatedAt = now
+	user.PasswordChangedAt = now
+	if err := s.users.Update(ctx, user, unmodifiedSince); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return ErrUpdateConflict
+		}
+		return err
+	}
+
+	// The new password is stored; revoke even if the caller has gone, so no
+	// session outlives the change
+	return s.RevokeAllForUser(context.WithoutCancel(ctx), userID)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"GateKeeper/models"
+)
+
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	login := loginTestUser(t, s)
+	id := login.User.ID
+
+	// Token times have one second resolution, so backdate the registration
+	// and use an access token from two seconds ago to tell it apart from
+	// the change
+	user, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unmodifiedSince := user.UpdatedAt
+	user.PasswordChangedAt = user.PasswordChangedAt.Add(-time.Hour)
+	user.UpdatedAt = user.UpdatedAt.Add(-time.Hour)
+	if err := s.users.Update(ctx, user, unmodifiedSince); err != nil {
+		t.Fatal(err)
+	}
+	oldToken, _, err := s.tokens.issueToken(id, login.User.Email, time.Now().Add(-2*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ValidateToken(ctx, oldToken); err != nil {
+		t.Fatalf("ValidateToken before the change: %v", err)
+	}
+
+	if err := s.ChangePassword(ctx, id, "secret1", "secret2"); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+
+	if changed, err := s.users.GetByID(ctx, id); err != nil || !changed.UpdatedAt.After(user.UpdatedAt) {
+		t.Errorf("UpdatedAt = %v, want it bumped past %v", changed.UpdatedAt, user.UpdatedAt)
+	}
+	if _, err := s.ValidateToken(ctx, oldToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ValidateToken(old access token) = %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.RefreshToken(ctx, login.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("RefreshToken(old refresh token) = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("login with the old password = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret2"}); err != nil {
+		t.Errorf("login with the new password: %v", err)
+	}
+}
+
+func TestChangePasswordRejected(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	id := loginTestUser(t, s).User.ID
+
+	for _, tc := range []struct {
+		name             string
+		userID           int
+		current, updated string
+		want             error
+	}{
+		{"wrong current password", id, "wrong12", "secret2", ErrInvalidCredentials},
+		{"unknown user", id + 100, "secret1", "secret2", ErrInvalidCredentials},
+		{"too short", id, "secret1", "short", ErrWeakPassword},
+		{"too long", id, "secret1", strings.Repeat("x", maxPasswordBytes+1), ErrWeakPassword},
+		{"reused", id, "secret1", "secret1", ErrPasswordReused},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := s.ChangePassword(ctx, tc.userID, tc.current, tc.updated); !errors.Is(err, tc.want) {
+				t.Fatalf("ChangePassword = %v, want %v", err, tc.want)
+			}
+		})
+	}
+
+	// The wrong current password and the unknown user fail alike
+	wrong := s.ChangePassword(ctx, id, "wrong12", "secret2")
+	unknown := s.ChangePassword(ctx, id+100, "secret1", "secret2")
+	if wrong.Error() != unknown.Error() {
+		t.Errorf("errors %q and %q differ, want a generic message", wrong, unknown)
+	}
+
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"}); err != nil {
+		t.Errorf("rejected changes altered the password: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+	"GateKeeper/validation"
+)
+
+// Profile errors returned by UpdateUser
+var (
+	// ErrInvalidEmail is returned when an email is not a valid address
+	ErrInvalidEmail = errors.New("email must be a valid email address")
+
+	// ErrInvalidUsername is returned when a username is too short or too long
+	ErrInvalidUsername = errors.New("username must be between 3 and 50 characters")
+
+	// ErrUpdateConflict is returned when the user was modified by another
+	// request while being updated; retry with fresh data
+	ErrUpdateConflict = errors.New("user was modified concurrently, retry the update")
+)
+
+// EmailVerifier starts the verification of a user's email address
+type EmailVerifier interface {
+	// SendVerification asks the user to confirm user.Email
+	SendVerification(ctx context.Context, user *models.User) error
+}
+
+// logEmailVerifier is the default EmailVerifier; it only logs the request
+type logEmailVerifier struct{}
+
+// SendVerification logs that the user's email needs verifying
+func (logEmailVerifier) SendVerification(ctx context.Context, user *models.User) error {
+	log.Printf("email verification required for user %d", user.ID)
+	return nil
+}
+
+// WithEmailVerifier sets the EmailVerifier notified when a user's email changes
+func WithEmailVerifier(verifier EmailVerifier) Option {
+	return func(s *AuthService) {
+		s.verifier = verifier
+	}
+}
+
+// validateEmail checks that email is a bare email address
+func validateEmail(email string) error {
+	if !validation.Email(email) {
+		return ErrInvalidEmail
+	}
+	return nil
+}
+
+// validateUsername checks the username length
+func validateUsername(username string) error {
+	if length := len(strings.TrimSpace(username)); length < 3 || length > 50 {
+		return ErrInvalidUsername
+	}
+	return nil
+}
+
+// UpdateUser applies the non-nil fields of req to the user's profile.
+// Changing the email clears EmailVerified and sends a new verification
+// through the EmailVerifier; an email owned by another user fails with
+// ErrUserExists and a username owned by another user, ignoring case, with
+// ErrUsernameTaken. Concurrent modifications fail with ErrUpdateConflict
+// rather than overwriting each other.
+func (s *AuthService) UpdateUser(ctx context.Context, userID int, req models.UpdateUserRequest) (_ *models.UserResponse, err error) {
+	defer func() {
+		s.audit(ctx, models.AuthEvent{Type: models.EventProfileUpdate, ActorID: userID, TargetID: userID}, err)
+	}()
+
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.Email != nil {
+		email := models.NormalizeEmail(*req.Email)
+		if err := validateEmail(email); err != nil {
+			return nil, err
+		}
+		req.Email = &email
+	}
+	if req.Username != nil {
+		username := models.NormalizeUsername(*req.Username)
+		if err := validateUsername(username); err != nil {
+			return nil, err
+		}
+		req.Username = &username
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	unmodifiedSince := user.UpdatedAt
+	emailChanged := req.Email != nil && *req.Email != user.Email
+	if emailChanged {
+		user.Email = *req.Email
+		user.EmailVerified = false
+	}
+	if req.Username != nil {
+		user.Username = *req.Username
+	}
+	user.UpdatedAt = time.Now()
+
+	if err := s.users.Update(ctx, user, unmodifiedSince); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateEmail):
+			return nil, ErrUserExists
+		case errors.Is(err, repository.ErrDuplicateUsername):
+			return nil, ErrUsernameTaken
+		case errors.Is(err, repository.ErrConflict):
+			return nil, ErrUpdateConflict
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if emailChanged {
+		if err := s.verifier.SendVerification(ctx, user); err != nil {
+			return nil, fmt.Errorf("email updated but verification could not be sent: %w", err)
+		}
+	}
+
+	response := user.ToResponse()
+	return &response, nil
+}
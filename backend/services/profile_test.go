@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// recordingVerifier records the users asked to verify their email
+type recordingVerifier struct {
+	mu    sync.Mutex
+	users []models.User
+}
+
+func (v *recordingVerifier) SendVerification(_ context.Context, user *models.User) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.users = append(v.users, *user)
+	return nil
+}
+
+// markVerified sets EmailVerified on the user with id
+func markVerified(t *testing.T, s *AuthService, id int) {
+	t.Helper()
+	ctx := context.Background()
+	user, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.EmailVerified = true
+	if err := s.users.Update(ctx, user, user.UpdatedAt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	ctx := context.Background()
+	verifier := &recordingVerifier{}
+	s := newTestService(t, WithEmailVerifier(verifier))
+	id := loginTestUser(t, s).User.ID
+	markVerified(t, s, id)
+
+	// Only the username changes; the email stays verified
+	username := "renamed"
+	user, err := s.UpdateUser(ctx, id, models.UpdateUserRequest{Username: &username})
+	if err != nil {
+		t.Fatalf("UpdateUser(username): %v", err)
+	}
+	if user.Username != "renamed" || user.Email != "user@example.com" || !user.EmailVerified {
+		t.Errorf("after a username update = %+v, want only the username changed", user)
+	}
+	if len(verifier.users) != 0 {
+		t.Errorf("verification sent for a username update")
+	}
+
+	// Setting the same email, in another case, is not a change
+	same := "USER@example.com"
+	if user, err := s.UpdateUser(ctx, id, models.UpdateUserRequest{Email: &same}); err != nil || !user.EmailVerified {
+		t.Errorf("UpdateUser(same email) = %+v, %v; want it still verified", user, err)
+	}
+
+	email := " New@Example.com "
+	user, err = s.UpdateUser(ctx, id, models.UpdateUserRequest{Email: &email})
+	if err != nil {
+		t.Fatalf("UpdateUser(email): %v", err)
+	}
+	if user.Email != "new@example.com" || user.Username != "renamed" || user.EmailVerified {
+		t.Errorf("after an email update = %+v, want the normalized email, unverified", user)
+	}
+	if len(verifier.users) != 1 || verifier.users[0].Email != "new@example.com" {
+		t.Errorf("verifications sent = %+v, want one for new@example.com", verifier.users)
+	}
+	if _, err := s.LoginUser(ctx, models.LoginRequest{Email: "new@example.com", Password: "secret1"}); err != nil {
+		t.Errorf("login with the new email: %v", err)
+	}
+}
+
+func TestUpdateUserRejected(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	id := loginTestUser(t, s).User.ID
+	if _, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "other@example.com", Username: "other", Password: "secret1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	str := func(s string) *string { return &s }
+	for _, tc := range []struct {
+		name   string
+		userID int
+		req    models.UpdateUserRequest
+		want   error
+	}{
+		{"duplicate email", id, models.UpdateUserRequest{Email: str("Other@example.com")}, ErrUserExists},
+		{"duplicate username", id, models.UpdateUserRequest{Username: str("OTHER")}, ErrUsernameTaken},
+		{"invalid email", id, models.UpdateUserRequest{Email: str("not-an-email")}, ErrInvalidEmail},
+		{"short username", id, models.UpdateUserRequest{Username: str("ab")}, ErrInvalidUsername},
+		{"unknown user", id + 100, models.UpdateUserRequest{Username: str("nobody")}, ErrUserNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := s.UpdateUser(ctx, tc.userID, tc.req); !errors.Is(err, tc.want) {
+				t.Fatalf("UpdateUser = %v, want %v", err, tc.want)
+			}
+		})
+	}
+
+	user, err := s.users.GetByID(ctx, id)
+	if err != nil || user.Email != "user@example.com" || user.Username != "user" {
+		t.Errorf("after rejected updates = %+v, %v; want the profile unchanged", user, err)
+	}
+}
+
+// racingRepository modifies each user it reads before returning it, as if
+// another request updated the user in between
+type racingRepository struct {
+	repository.UserRepository
+}
+
+func (r racingRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	user, err := r.UserRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	concurrent := *user
+	concurrent.UpdatedAt = user.UpdatedAt.Add(1)
+	if err := r.UserRepository.Update(ctx, &concurrent, user.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func TestUpdateUserConflict(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	id := loginTestUser(t, s).User.ID
+
+	racing := NewAuthServiceWithConfig(racingRepository{s.users}, DefaultTokenConfig(), WithAuditLogger(nopAuditLogger{}))
+	username := "renamed"
+	if _, err := racing.UpdateUser(ctx, id, models.UpdateUserRequest{Username: &username}); !errors.Is(err, ErrUpdateConflict) {
+		t.Fatalf("UpdateUser = %v, want ErrUpdateConflict", err)
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// Refresh token errors returned by RefreshToken and RevokeRefreshToken
+var (
+	// ErrInvalidRefreshToken is returned for unknown or revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+	// ErrRefreshTokenExpired is returned when a refresh token is past its expiry
+	ErrRefreshTokenExpired = errors.New("refresh token has expired")
+
+	// ErrRefreshTokenReused is returned when an already rotated refresh token
+	// is presented again; the whole token family is revoked
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// WithRefreshTokens sets the store holding issued refresh tokens, by
+// default an in-memory store
+func WithRefreshTokens(store repository.RefreshTokenStore) Option {
+	return func(s *AuthService) {
+		s.refreshTokens = store
+	}
+}
+
+// issueTokens signs an access token and issues a refresh token in family
+// (a new family if empty) for the user. Only the SHA-256 hash of the
+// refresh token is stored, so a leaked store cannot be replayed.
+func (s *AuthService) issueTokens(ctx context.Context, user *models.User, family string) (*models.LoginResponse, error) {
+	now := time.Now()
+	accessToken, expiresAt, err := s.tokens.issueToken(user.ID, user.Email, now)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	if family == "" {
+		if family, err = randomToken(); err != nil {
+			return nil, err
+		}
+	}
+	refreshExpiresAt := now.Add(s.tokens.RefreshTTL)
+
+	err = s.refreshTokens.Create(ctx, &models.RefreshToken{
+		Hash:      hashToken(refreshToken),
+		UserID:    user.ID,
+		Family:    family,
+		CreatedAt: now,
+		ExpiresAt: refreshExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResponse{
+		User:             user.ToResponse(),
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// RefreshToken exchanges a refresh token for a new access and refresh token
+// pair. The presented token is rotated out once the new pair is issued, so
+// a failure leaves it usable for a retry; presenting it again after that
+// revokes every token descended from the same login.
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (_ *models.LoginResponse, err error) {
+	var userID int
+	defer func() {
+		s.audit(ctx, models.AuthEvent{Type: models.EventTokenRefresh, ActorID: userID, TargetID: userID}, err)
+	}()
+
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	hash := hashToken(refreshToken)
+	record, err := s.refreshTokens.Get(ctx, hash)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	} else if err != nil {
+		return nil, err
+	}
+	if record.Revoked {
+		return nil, ErrInvalidRefreshToken
+	}
+	userID = record.UserID
+	if record.Rotated {
+		return nil, s.reuseDetected(ctx, record)
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return nil, ErrRefreshTokenExpired
+	}
+
+	// Check that the user still exists and is active
+	user, err := s.users.GetByID(ctx, record.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: user no longer exists", ErrInvalidRefreshToken)
+	} else if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrUserDeactivated
+	}
+
+	response, err := s.issueTokens(ctx, user, record.Family)
+	if err != nil {
+		return nil, err
+	}
+	switch err := s.refreshTokens.Rotate(ctx, hash); {
+	case errors.Is(err, repository.ErrTokenRotated):
+		// A concurrent refresh rotated the token first
+		return nil, s.reuseDetected(ctx, record)
+	case err != nil:
+		// Withdraw the new token; the presented one stays usable
+		_ = s.refreshTokens.Revoke(context.WithoutCancel(ctx), hashToken(response.RefreshToken))
+		return nil, err
+	}
+	return response, nil
+}
+
+// reuseDetected revokes the family of a rotated token presented again and
+// returns ErrRefreshTokenReused
+func (s *AuthService) reuseDetected(ctx context.Context, record *models.RefreshToken) error {
+	revoked, err := s.refreshTokens.RevokeFamily(context.WithoutCancel(ctx), record.Family)
+	if err != nil {
+		return fmt.Errorf("%w, but revoking its family failed: %w", ErrRefreshTokenReused, err)
+	}
+	log.Printf("refresh token reuse detected for user %d: revoked %d tokens", record.UserID, revoked)
+	return ErrRefreshTokenReused
+}
+
+// RevokeRefreshToken revokes a single refresh token, e.g. on logout
+func (s *AuthService) RevokeRefreshToken(ctx context.Context, refreshToken string) (err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	err = s.refreshTokens.Revoke(ctx, hashToken(refreshToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidRefreshToken
+	}
+	return err
+}
+
+// RevokeAllForUser revokes every refresh token and ends every session of a
+// user, e.g. after a password change or a suspected compromise
+func (s *AuthService) RevokeAllForUser(ctx context.Context, userID int) (err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	if err := s.refreshTokens.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.sessions.DeleteAllForUser(ctx, userID)
+}
+
+// PruneRefreshTokens removes the expired refresh tokens and returns how
+// many it removed
+func (s *AuthService) PruneRefreshTokens(ctx context.Context) (_ int, err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return 0, err
+	}
+
+	return s.refreshTokens.DeleteExpired(ctx, time.Now())
+}
+
+// randomToken returns a random 256-bit URL-safe token
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the hex SHA-256 hash a refresh token is stored under
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// loginTestUser registers a user and logs them in
+func loginTestUser(t *testing.T, s *AuthService) *models.LoginResponse {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "user@example.com", Username: "user", Password: "secret1"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	login, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"})
+	if err != nil {
+		t.Fatalf("LoginUser: %v", err)
+	}
+	return login
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	login := loginTestUser(t, s)
+
+	refreshed, err := s.RefreshToken(ctx, login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Fatal("RefreshToken returned the presented token")
+	}
+	if _, err := s.ValidateToken(ctx, refreshed.AccessToken); err != nil {
+		t.Fatalf("ValidateToken(refreshed access token): %v", err)
+	}
+
+	// Presenting the rotated token again revokes its whole family
+	if _, err := s.RefreshToken(ctx, login.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("RefreshToken(rotated) = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := s.RefreshToken(ctx, refreshed.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("RefreshToken(descendant) = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRefreshTokenExpired(t *testing.T) {
+	tokens := DefaultTokenConfig()
+	tokens.RefreshTTL = -time.Second
+	s := NewAuthServiceWithConfig(repository.NewMemoryUserRepository(), tokens, WithAuditLogger(nopAuditLogger{}))
+	login := loginTestUser(t, s)
+
+	if _, err := s.RefreshToken(context.Background(), login.RefreshToken); !errors.Is(err, ErrRefreshTokenExpired) {
+		t.Fatalf("RefreshToken = %v, want ErrRefreshTokenExpired", err)
+	}
+}
+
+func TestRevokeRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	login := loginTestUser(t, s)
+
+	if err := s.RevokeRefreshToken(ctx, login.RefreshToken); err != nil {
+		t.Fatalf("RevokeRefreshToken: %v", err)
+	}
+	if _, err := s.RefreshToken(ctx, login.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("RefreshToken(revoked) = %v, want ErrInvalidRefreshToken", err)
+	}
+	if err := s.RevokeRefreshToken(ctx, "unknown"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("RevokeRefreshToken(unknown) = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRevokeAllForUser(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	first := loginTestUser(t, s)
+	second, err := s.LoginUser(ctx, models.LoginRequest{Email: "user@example.com", Password: "secret1"})
+	if err != nil {
+		t.Fatalf("LoginUser: %v", err)
+	}
+
+	if err := s.RevokeAllForUser(ctx, first.User.ID); err != nil {
+		t.Fatalf("RevokeAllForUser: %v", err)
+	}
+	for _, token := range []string{first.RefreshToken, second.RefreshToken} {
+		if _, err := s.RefreshToken(ctx, token); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("RefreshToken = %v, want ErrInvalidRefreshToken", err)
+		}
+	}
+}
+
+// flakyUsers fails GetByID while failing is set
+type flakyUsers struct {
+	repository.UserRepository
+	failing bool
+}
+
+func (f *flakyUsers) GetByID(ctx context.Context, id int) (*models.User, error) {
+	if f.failing {
+		return nil, errors.New("connection reset")
+	}
+	return f.UserRepository.GetByID(ctx, id)
+}
+
+func TestRefreshTokenSurvivesFailedRefresh(t *testing.T) {
+	ctx := context.Background()
+	users := &flakyUsers{UserRepository: repository.NewMemoryUserRepository()}
+	s := NewAuthServiceWithConfig(users, DefaultTokenConfig(), WithAuditLogger(nopAuditLogger{}))
+	login := loginTestUser(t, s)
+
+	users.failing = true
+	if _, err := s.RefreshToken(ctx, login.RefreshToken); err == nil {
+		t.Fatal("RefreshToken succeeded while the user lookup fails")
+	}
+
+	// The retry is not mistaken for reuse
+	users.failing = false
+	if _, err := s.RefreshToken(ctx, login.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken after a failed attempt: %v", err)
+	}
+}
+
+func TestRefreshTokensSharedThroughStore(t *testing.T) {
+	ctx := context.Background()
+	users := repository.NewMemoryUserRepository()
+	store := repository.NewMemoryRefreshTokenStore()
+	first := NewAuthServiceWithConfig(users, DefaultTokenConfig(), WithRefreshTokens(store), WithAuditLogger(nopAuditLogger{}))
+	second := NewAuthServiceWithConfig(users, DefaultTokenConfig(), WithRefreshTokens(store), WithAuditLogger(nopAuditLogger{}))
+	login := loginTestUser(t, first)
+
+	if _, err := second.RefreshToken(ctx, login.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken on another instance: %v", err)
+	}
+	if _, err := first.RefreshToken(ctx, login.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("RefreshToken(rotated) = %v, want ErrRefreshTokenReused", err)
+	}
+}
+
+func TestPruneRefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryRefreshTokenStore()
+	tokens := DefaultTokenConfig()
+	tokens.RefreshTTL = -time.Second
+	s := NewAuthServiceWithConfig(repository.NewMemoryUserRepository(), tokens,
+		WithRefreshTokens(store), WithAuditLogger(nopAuditLogger{}))
+	login := loginTestUser(t, s)
+
+	pruned, err := s.PruneRefreshTokens(ctx)
+	if err != nil || pruned != 1 {
+		t.Fatalf("PruneRefreshTokens = %d, %v, want 1", pruned, err)
+	}
+	if _, err := store.Get(ctx, hashToken(login.RefreshToken)); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("Get(pruned) = %v, want ErrNotFound", err)
+	}
+}
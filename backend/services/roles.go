@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// ErrInvalidRole is returned by SetUserRole for an unknown role
+var ErrInvalidRole = errors.New("role must be user or admin")
+
+// WithAdminEmails makes users with one of emails admins once the email is
+// verified by an OAuth provider. A password registration is never made an
+// admin, since anyone could register with an unclaimed address: an account
+// registered before the provider verified its email is promoted only after
+// its password, tokens and sessions are dropped.
+func WithAdminEmails(emails ...string) Option {
+	return func(s *AuthService) {
+		for _, email := range emails {
+			s.adminEmails[models.NormalizeEmail(email)] = true
+		}
+	}
+}
+
+// roleFor returns the role of a user with the normalized email, which must
+// have been verified
+func (s *AuthService) roleFor(email string) string {
+	if s.adminEmails[email] {
+		return models.RoleAdmin
+	}
+	return models.RoleUser
+}
+
+// SetUserRole makes the user a regular user or an admin. The change applies
+// to the user's next request, as tokens carry no role.
+func (s *AuthService) SetUserRole(ctx context.Context, userID int, role string) (err error) {
+	defer func() {
+		s.audit(ctx, models.AuthEvent{Type: models.EventRoleChange, TargetID: userID}, err)
+	}()
+
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return fmt.Errorf("%w: got %q", ErrInvalidRole, role)
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+	if user.Role == role {
+		return nil
+	}
+
+	unmodifiedSince := user.UpdatedAt
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	if err := s.users.Update(ctx, user, unmodifiedSince); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return ErrUpdateConflict
+		}
+		return err
+	}
+	return nil
+}
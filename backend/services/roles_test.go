@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"GateKeeper/models"
+)
+
+// newRolesTestService creates a service making admin@example.com an admin
+func newRolesTestService(t *testing.T) *AuthService {
+	return newTestService(t, WithAdminEmails("Admin@Example.com"))
+}
+
+func TestPasswordRegistrationIsNeverAdmin(t *testing.T) {
+	ctx := context.Background()
+	s := newRolesTestService(t)
+
+	user, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "admin@example.com", Username: "squatter", Password: "secret1"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if user.Role != models.RoleUser {
+		t.Fatalf("role = %q, want %q for an unverified admin email", user.Role, models.RoleUser)
+	}
+}
+
+func TestExternalLoginPromotesVerifiedAdminEmail(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("new user", func(t *testing.T) {
+		s := newRolesTestService(t)
+		resp, err := s.LoginExternal(ctx, "admin@example.com", "admin")
+		if err != nil {
+			t.Fatalf("LoginExternal: %v", err)
+		}
+		if resp.User.Role != models.RoleAdmin {
+			t.Fatalf("role = %q, want %q", resp.User.Role, models.RoleAdmin)
+		}
+	})
+
+	t.Run("existing unverified user", func(t *testing.T) {
+		s := newRolesTestService(t)
+		if _, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "admin@example.com", Username: "admin", Password: "secret1"}); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		resp, err := s.LoginExternal(ctx, "admin@example.com", "admin")
+		if err != nil {
+			t.Fatalf("LoginExternal: %v", err)
+		}
+		if !resp.User.EmailVerified || resp.User.Role != models.RoleAdmin {
+			t.Fatalf("user = %+v, want a verified admin", resp.User)
+		}
+	})
+
+	t.Run("pre-registered admin email", func(t *testing.T) {
+		s := newRolesTestService(t)
+		refreshToken, sessionID, accessToken := preRegister(t, s, "admin@example.com")
+		resp, err := s.LoginExternal(ctx, "admin@example.com", "admin")
+		if err != nil {
+			t.Fatalf("LoginExternal: %v", err)
+		}
+		if resp.User.Role != models.RoleAdmin {
+			t.Fatalf("role = %q, want %q", resp.User.Role, models.RoleAdmin)
+		}
+		// Only the provider's login holds the admin account
+		checkCredentialsRevoked(t, s, "admin@example.com", refreshToken, sessionID, accessToken)
+		if user, err := s.ValidateToken(ctx, resp.AccessToken); err != nil || user.Role != models.RoleAdmin {
+			t.Errorf("ValidateToken(provider login) = %+v, %v; want the admin", user, err)
+		}
+	})
+
+	t.Run("other email", func(t *testing.T) {
+		s := newRolesTestService(t)
+		resp, err := s.LoginExternal(ctx, "someone@example.com", "someone")
+		if err != nil {
+			t.Fatalf("LoginExternal: %v", err)
+		}
+		if resp.User.Role != models.RoleUser {
+			t.Fatalf("role = %q, want %q", resp.User.Role, models.RoleUser)
+		}
+	})
+}
+
+func TestSetUserRole(t *testing.T) {
+	ctx := context.Background()
+	s := newRolesTestService(t)
+	user, err := s.CreateUser(ctx, models.CreateUserRequest{Email: "user@example.com", Username: "user", Password: "secret1"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	if err := s.SetUserRole(ctx, user.ID, models.RoleAdmin); err != nil {
+		t.Fatalf("SetUserRole: %v", err)
+	}
+	got, err := s.GetUserByEmail(ctx, "user@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail: %v", err)
+	}
+	if got.Role != models.RoleAdmin {
+		t.Fatalf("role = %q, want %q", got.Role, models.RoleAdmin)
+	}
+
+	if err := s.SetUserRole(ctx, user.ID, "root"); !errors.Is(err, ErrInvalidRole) {
+		t.Fatalf("SetUserRole(root) = %v, want ErrInvalidRole", err)
+	}
+	if err := s.SetUserRole(ctx, user.ID+100, models.RoleUser); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("SetUserRole(unknown) = %v, want ErrUserNotFound", err)
+	}
+}
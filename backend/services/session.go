@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// ErrInvalidSession is returned for unknown, expired or idle sessions
+var ErrInvalidSession = errors.New("invalid or expired session")
+
+// SessionConfig configures server-side sessions
+type SessionConfig struct {
+	// IdleTimeout ends a session after this long without requests
+	IdleTimeout time.Duration
+
+	// AbsoluteTimeout ends a session this long after login, however active
+	AbsoluteTimeout time.Duration
+}
+
+// DefaultSessionConfig returns a config with a 30 minute idle timeout and
+// a 12 hour absolute timeout
+func DefaultSessionConfig() SessionConfig {
+	return SessionConfig{
+		IdleTimeout:     30 * time.Minute,
+		AbsoluteTimeout: 12 * time.Hour,
+	}
+}
+
+// WithSessions sets the session timeouts and the store holding sessions.
+// A nil store keeps sessions in memory.
+func WithSessions(config SessionConfig, store repository.SessionStore) Option {
+	return func(s *AuthService) {
+		if store == nil {
+			store = repository.NewMemorySessionStore()
+		}
+		s.sessionConfig = config
+		s.sessions = store
+	}
+}
+
+// CreateSession logs the user in like LoginUser, but instead of tokens
+// creates a server-side session and returns its opaque ID, to be sent to the
+// client as a cookie
+func (s *AuthService) CreateSession(ctx context.Context, req models.LoginRequest) (_ *models.SessionResponse, err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	user, err := s.login(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &models.Session{
+		ID:         hashToken(id),
+		UserID:     user.ID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.sessionConfig.AbsoluteTimeout),
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return &models.SessionResponse{
+		User:      user.ToResponse(),
+		SessionID: id,
+		ExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// ResolveSession returns the user owning the session and slides its idle
+// timeout forward. Sessions past their idle or absolute timeout are deleted
+// and fail with ErrInvalidSession.
+func (s *AuthService) ResolveSession(ctx context.Context, sessionID string) (_ *models.UserResponse, err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	id := hashToken(sessionID)
+	session, err := s.sessions.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidSession
+	} else if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if now.After(session.ExpiresAt) || now.Sub(session.LastSeenAt) > s.sessionConfig.IdleTimeout {
+		if err := s.sessions.Delete(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidSession
+	}
+
+	user, err := s.users.GetByID(ctx, session.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidSession
+	} else if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrUserDeactivated
+	}
+
+	if err := s.sessions.Refresh(ctx, id, now); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidSession
+		}
+		return nil, err
+	}
+
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// DeleteSession ends a session, e.g. on logout. Unknown sessions are ignored.
+func (s *AuthService) DeleteSession(ctx context.Context, sessionID string) (err error) {
+	defer wrapCancellation(&err)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	id := hashToken(sessionID)
+	session, err := s.sessions.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = s.sessions.Delete(ctx, id)
+	s.audit(ctx, models.AuthEvent{Type: models.EventLogout, ActorID: session.UserID, TargetID: session.UserID}, err)
+	return err
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"GateKeeper/models"
+	"GateKeeper/repository"
+)
+
+// newSessionService returns a service keeping sessions in store with the
+// given timeouts, and a registered user@example.com with password secret1
+func newSessionService(t *testing.T, config SessionConfig, store repository.SessionStore) *AuthService {
+	t.Helper()
+	s := newTestService(t, WithSessions(config, store))
+	if _, err := s.CreateUser(context.Background(), models.CreateUserRequest{Email: "user@example.com", Username: "user", Password: "secret1"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return s
+}
+
+// createSession logs user@example.com in with a session
+func createSession(t *testing.T, s *AuthService) *models.SessionResponse {
+	t.Helper()
+	session, err := s.CreateSession(context.Background(), models.LoginRequest{Email: "user@example.com", Password: "secret1"})
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	return session
+}
+
+func TestCreateSession(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemorySessionStore()
+	s := newSessionService(t, DefaultSessionConfig(), store)
+
+	session := createSession(t, s)
+	if session.SessionID == "" || session.User.Email != "user@example.com" {
+		t.Fatalf("CreateSession = %+v, want a session ID for the user", session)
+	}
+	if want := time.Now().Add(12 * time.Hour); session.ExpiresAt.After(want) || session.ExpiresAt.Before(want.Add(-time.Minute)) {
+		t.Errorf("ExpiresAt = %v, want the absolute timeout from now", session.ExpiresAt)
+	}
+
+	// Only the hash of the ID is stored
+	if _, err := store.Get(ctx, session.SessionID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("store holds the raw session ID")
+	}
+
+	user, err := s.ResolveSession(ctx, session.SessionID)
+	if err != nil || user.Email != "user@example.com" {
+		t.Fatalf("ResolveSession = %v, %v; want the user", user, err)
+	}
+	if _, err := s.CreateSession(ctx, models.LoginRequest{Email: "user@example.com", Password: "wrong12"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("CreateSession(wrong password) = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := s.ResolveSession(ctx, "unknown"); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("ResolveSession(unknown) = %v, want ErrInvalidSession", err)
+	}
+}
+
+func TestSessionIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemorySessionStore()
+	idle := time.Minute
+	s := newSessionService(t, SessionConfig{IdleTimeout: idle, AbsoluteTimeout: time.Hour}, store)
+	session := createSession(t, s)
+	id := hashToken(session.SessionID)
+
+	// Activity within the idle timeout slides it forward
+	if err := store.Refresh(ctx, id, time.Now().Add(-idle+time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ResolveSession(ctx, session.SessionID); err != nil {
+		t.Fatalf("ResolveSession within the idle timeout: %v", err)
+	}
+	stored, err := store.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if since := time.Since(stored.LastSeenAt); since > time.Second {
+		t.Errorf("LastSeenAt is %v old after a request, want it refreshed", since)
+	}
+
+	// A session idle for longer is deleted
+	if err := store.Refresh(ctx, id, time.Now().Add(-idle-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ResolveSession(ctx, session.SessionID); !errors.Is(err, ErrInvalidSession) {
+		t.Fatalf("ResolveSession after the idle timeout = %v, want ErrInvalidSession", err)
+	}
+	if _, err := store.Get(ctx, id); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("idle session still stored: %v", err)
+	}
+}
+
+func TestSessionAbsoluteTimeout(t *testing.T) {
+	ctx := context.Background()
+	absolute := 100 * time.Millisecond
+	s := newSessionService(t, SessionConfig{IdleTimeout: time.Hour, AbsoluteTimeout: absolute}, nil)
+	session := createSession(t, s)
+
+	// Activity does not extend the absolute timeout
+	deadline := time.Now().Add(absolute + 50*time.Millisecond)
+	for time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		if _, err := s.ResolveSession(ctx, session.SessionID); errors.Is(err, ErrInvalidSession) {
+			if time.Now().Before(session.ExpiresAt) {
+				t.Fatalf("session ended before its absolute timeout")
+			}
+			return
+		} else if err != nil {
+			t.Fatalf("ResolveSession: %v", err)
+		}
+	}
+	t.Fatal("session outlived its absolute timeout")
+}
+
+func TestDeleteSession(t *testing.T) {
+	ctx := context.Background()
+	s := newSessionService(t, DefaultSessionConfig(), nil)
+	first, second := createSession(t, s), createSession(t, s)
+
+	if err := s.DeleteSession(ctx, first.SessionID); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+	if _, err := s.ResolveSession(ctx, first.SessionID); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("ResolveSession after logout = %v, want ErrInvalidSession", err)
+	}
+	if _, err := s.ResolveSession(ctx, second.SessionID); err != nil {
+		t.Errorf("logout ended another session: %v", err)
+	}
+	if err := s.DeleteSession(ctx, first.SessionID); err != nil {
+		t.Errorf("DeleteSession(ended session) = %v, want it ignored", err)
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	ctx := context.Background()
+	s := newSessionService(t, DefaultSessionConfig(), nil)
+	sessions := []*models.SessionResponse{createSession(t, s), createSession(t, s)}
+
+	if err := s.RevokeAllForUser(ctx, sessions[0].User.ID); err != nil {
+		t.Fatalf("RevokeAllForUser: %v", err)
+	}
+	for i, session := range sessions {
+		if _, err := s.ResolveSession(ctx, session.SessionID); !errors.Is(err, ErrInvalidSession) {
+			t.Errorf("session %d: ResolveSession = %v, want ErrInvalidSession", i, err)
+		}
+	}
+
+	// Deactivation ends sessions too
+	session := createSession(t, s)
+	if err := s.DeactivateUser(ctx, session.User.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ResolveSession(ctx, session.SessionID); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("ResolveSession after deactivation = %v, want ErrInvalidSession", err)
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token errors returned by ValidateToken
+var (
+	// ErrTokenExpired is returned when a token is past its expiry (plus clock skew)
+	ErrTokenExpired = errors.New("token has expired")
+
+	// ErrInvalidToken is returned when a token is malformed or its signature does not verify
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// TokenConfig configures access token issuance
+type TokenConfig struct {
+	// Secret is the HS256 signing key
+	Secret []byte
+
+	// TTL is how long an issued token stays valid
+	TTL time.Duration
+
+	// RefreshTTL is how long an issued refresh token stays valid
+	RefreshTTL time.Duration
+
+	// ClockSkew is the leeway allowed when checking expiry and issue time
+	ClockSkew time.Duration
+
+	// Issuer is set as the token's iss claim, if not empty
+	Issuer string
+}
+
+// DefaultTokenConfig returns a config with a random per-process secret,
+// a 15 minute TTL, 7 day refresh tokens and 30 seconds of clock skew.
+// Tokens signed with a random secret do not survive a restart, so set
+// Secret in production.
+func DefaultTokenConfig() TokenConfig {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("failed to generate token secret: %v", err))
+	}
+	return TokenConfig{
+		Secret:     secret,
+		TTL:        15 * time.Minute,
+		RefreshTTL: 7 * 24 * time.Hour,
+		ClockSkew:  30 * time.Second,
+	}
+}
+
+// AccessClaims are the claims carried by an access token
+type AccessClaims struct {
+	UserID int    `json:"uid"`
+	Email  string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// issueToken signs an access token for the user and returns it with its expiry
+func (c TokenConfig) issueToken(userID int, email string, now time.Time) (string, time.Time, error) {
+	expiresAt := now.Add(c.TTL)
+	claims := AccessClaims{
+		UserID: userID,
+		Email:  email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			Issuer:    c.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(c.Secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signed, expiresAt, nil
+}
+
+// parseToken verifies the token's signature and expiry and returns its claims
+func (c TokenConfig) parseToken(tokenString string) (*AccessClaims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithLeeway(c.ClockSkew),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if c.Issuer != "" {
+		options = append(options, jwt.WithIssuer(c.Issuer))
+	}
+
+	claims := &AccessClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return c.Secret, nil
+	}, options...)
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return nil, ErrTokenExpired
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return claims, nil
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestLoginIssuesAccessToken(t *testing.T) {
+	s := newTestService(t)
+	before := time.Now()
+	login := loginTestUser(t, s)
+
+	if login.AccessToken == "" || login.TokenType != "Bearer" {
+		t.Fatalf("login returned token %q of type %q, want a bearer token", login.AccessToken, login.TokenType)
+	}
+	wantExpiry := before.Add(s.tokens.TTL)
+	if login.ExpiresAt.Before(wantExpiry.Add(-time.Second)) || login.ExpiresAt.After(wantExpiry.Add(time.Second)) {
+		t.Errorf("ExpiresAt = %v, want about %v", login.ExpiresAt, wantExpiry)
+	}
+
+	claims, err := s.tokens.parseToken(login.AccessToken)
+	if err != nil {
+		t.Fatalf("parseToken: %v", err)
+	}
+	if claims.UserID != login.User.ID || claims.Email != login.User.Email || claims.Subject == "" {
+		t.Errorf("claims = %+v, want the user's ID and email", claims)
+	}
+
+	user, err := s.ValidateToken(context.Background(), login.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if user.ID != login.User.ID || user.Email != login.User.Email {
+		t.Errorf("ValidateToken = %+v, want the logged in user", user)
+	}
+}
+
+// tamperPayload rewrites the claims of a signed token without re-signing it
+func tamperPayload(t *testing.T, token string, replace func(string) string) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(replace(string(payload))))
+	return strings.Join(parts, ".")
+}
+
+func TestValidateToken(t *testing.T) {
+	s := newTestService(t)
+	login := loginTestUser(t, s)
+	id, email := login.User.ID, login.User.Email
+
+	// issue signs a token for the test user with config, issued at now+offset
+	issue := func(config TokenConfig, userID int, offset time.Duration) string {
+		token, _, err := config.issueToken(userID, email, time.Now().Add(offset))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	otherSecret := s.tokens
+	otherSecret.Secret = []byte("another-secret")
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, AccessClaims{
+		UserID:           id,
+		Email:            email,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"valid", login.AccessToken, nil},
+		{"expired", issue(s.tokens, id, -s.tokens.TTL-time.Minute), ErrTokenExpired},
+		{"issued in the future", issue(s.tokens, id, time.Hour), ErrInvalidToken},
+		{"tampered claims", tamperPayload(t, login.AccessToken, func(p string) string {
+			return strings.Replace(p, email, "admin@example.com", 1)
+		}), ErrInvalidToken},
+		{"tampered signature", login.AccessToken[:len(login.AccessToken)-4] + "AAAA", ErrInvalidToken},
+		{"wrong secret", issue(otherSecret, id, 0), ErrInvalidToken},
+		{"unsigned", none, ErrInvalidToken},
+		{"malformed", "not-a-token", ErrInvalidToken},
+		{"unknown user", issue(s.tokens, id+100, 0), ErrInvalidToken},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.ValidateToken(context.Background(), tc.token)
+			if tc.want == nil && err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("ValidateToken = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestTokenClockSkew(t *testing.T) {
+	tokens := DefaultTokenConfig()
+	tokens.ClockSkew = 30 * time.Second
+	issue := func(offset time.Duration) string {
+		token, _, err := tokens.issueToken(1, "user@example.com", time.Now().Add(offset))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	// Expired, or issued in the future, by less than the skew
+	if _, err := tokens.parseToken(issue(-tokens.TTL - 10*time.Second)); err != nil {
+		t.Errorf("parseToken(expired 10s ago) = %v, want it accepted", err)
+	}
+	if _, err := tokens.parseToken(issue(10 * time.Second)); err != nil {
+		t.Errorf("parseToken(issued 10s ahead) = %v, want it accepted", err)
+	}
+	if _, err := tokens.parseToken(issue(-tokens.TTL - time.Minute)); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("parseToken(expired 1m ago) = %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestValidateTokenDeactivatedUser(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	login := loginTestUser(t, s)
+
+	if err := s.DeactivateUser(ctx, login.User.ID); err != nil {
+		t.Fatalf("DeactivateUser: %v", err)
+	}
+	if _, err := s.ValidateToken(ctx, login.AccessToken); !errors.Is(err, ErrUserDeactivated) {
+		t.Fatalf("ValidateToken = %v, want ErrUserDeactivated", err)
+	}
+
+	if err := s.ReactivateUser(ctx, login.User.ID); err != nil {
+		t.Fatalf("ReactivateUser: %v", err)
+	}
+	if _, err := s.ValidateToken(ctx, login.AccessToken); err != nil {
+		t.Fatalf("ValidateToken after reactivation: %v", err)
+	}
+}
+
+func TestValidateTokenIssuer(t *testing.T) {
+	tokens := DefaultTokenConfig()
+	tokens.Issuer = "gatekeeper"
+	token, _, err := tokens.issueToken(1, "user@example.com", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tokens.parseToken(token); err != nil {
+		t.Fatalf("parseToken: %v", err)
+	}
+
+	other := tokens
+	other.Issuer = "someone-else"
+	if _, err := other.parseToken(token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("parseToken with another issuer = %v, want ErrInvalidToken", err)
+	}
+}
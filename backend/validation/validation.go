@@ -0,0 +1,203 @@
+// Package validation enforces the validate struct tags on request models.
+//
+// The supported rules are:
+//
+//	required   the field is not empty (blank strings are empty)
+//	omitempty  skip the other rules if the field is empty or a nil pointer
+//	email      the field is a bare email address with a dotted domain
+//	min=N      strings have at least N characters, slices at least N
+//	           elements, numbers are at least N
+//	max=N      the same upper bound
+//
+// Rules are separated by commas, e.g. validate:"required,min=3,max=50".
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalid is wrapped by every *Error
+var ErrInvalid = errors.New("validation failed")
+
+// FieldError describes one field failing one rule
+type FieldError struct {
+	// Field is the field's JSON name
+	Field string `json:"field"`
+
+	// Rule is the failing rule and Param its parameter, e.g. min and 3
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+
+	// Message explains the failure, e.g. "username must be at least 3 characters"
+	Message string `json:"message"`
+}
+
+// Error lists every failing field of a request, in field order
+type Error struct {
+	Fields []FieldError
+}
+
+// Error joins the messages of the failing fields
+func (e *Error) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Message)
+	}
+	return ErrInvalid.Error() + ": " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns ErrInvalid
+func (e *Error) Unwrap() error {
+	return ErrInvalid
+}
+
+// Struct checks the validate tags of the fields of v, a struct or a pointer
+// to one. It returns nil or an *Error listing the first failing rule of each
+// invalid field, and panics on a malformed tag.
+func Struct(v interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: %T is not a struct", v))
+	}
+
+	var failures []FieldError
+	structType := value.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		if failure, failed := checkField(jsonName(field), value.Field(i), tag); failed {
+			failures = append(failures, failure)
+		}
+	}
+
+	if len(failures) > 0 {
+		return &Error{Fields: failures}
+	}
+	return nil
+}
+
+// Email reports whether s is a bare email address, without a display name
+// or angle brackets, whose domain has at least two labels
+func Email(s string) bool {
+	if len(s) > 254 {
+		return false
+	}
+	address, err := mail.ParseAddress(s)
+	if err != nil || address.Address != s {
+		return false
+	}
+
+	at := strings.LastIndex(s, "@")
+	local, domain := s[:at], s[at+1:]
+	if len(local) > 64 {
+		return false
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+	}
+	return true
+}
+
+// checkField applies the rules in tag to value, returning the first failure
+func checkField(name string, value reflect.Value, tag string) (FieldError, bool) {
+	rules := strings.Split(tag, ",")
+	if isEmpty(value) && slices.Contains(rules, "omitempty") {
+		return FieldError{}, false
+	}
+	if value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	for _, rule := range rules {
+		rule, param, _ := strings.Cut(rule, "=")
+		failure := FieldError{Field: name, Rule: rule, Param: param}
+		switch rule {
+		case "omitempty":
+			continue
+		case "required":
+			if isEmpty(value) {
+				failure.Message = name + " is required"
+				return failure, true
+			}
+		case "email":
+			if value.Kind() != reflect.String {
+				panic(fmt.Sprintf("validation: email rule on non-string field %s", name))
+			}
+			if !Email(value.String()) {
+				failure.Message = name + " must be a valid email address"
+				return failure, true
+			}
+		case "min", "max":
+			limit, err := strconv.ParseInt(param, 10, 64)
+			if err != nil {
+				panic(fmt.Sprintf("validation: bad %s parameter %q on field %s", rule, param, name))
+			}
+			size, unit := measure(name, value)
+			if (rule == "min" && size < limit) || (rule == "max" && size > limit) {
+				bound := "at least"
+				if rule == "max" {
+					bound = "at most"
+				}
+				failure.Message = fmt.Sprintf("%s must be %s %d%s", name, bound, limit, unit)
+				return failure, true
+			}
+		default:
+			panic(fmt.Sprintf("validation: unknown rule %q on field %s", rule, name))
+		}
+	}
+	return FieldError{}, false
+}
+
+// measure returns the size min and max compare against and its unit
+func measure(name string, value reflect.Value) (int64, string) {
+	switch value.Kind() {
+	case reflect.String:
+		return int64(utf8.RuneCountInString(value.String())), " characters"
+	case reflect.Slice, reflect.Map:
+		return int64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), ""
+	default:
+		panic(fmt.Sprintf("validation: cannot measure field %s of kind %s", name, value.Kind()))
+	}
+}
+
+// isEmpty reports whether value is a nil pointer, a blank string, an empty
+// slice or map, or a zero number
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Pointer:
+		return value.IsNil()
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	default:
+		return value.IsZero()
+	}
+}
+
+// jsonName returns the name the field has in JSON
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
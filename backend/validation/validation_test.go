@@ -0,0 +1,161 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"GateKeeper/models"
+)
+
+// requireField fails the test unless err is an *Error whose only failing
+// field is field, failing rule; an empty field expects no error
+func requireField(t *testing.T, err error, field, rule string) {
+	t.Helper()
+	if field == "" {
+		if err != nil {
+			t.Fatalf("Struct = %v, want no error", err)
+		}
+		return
+	}
+	var invalid *Error
+	if !errors.As(err, &invalid) || !errors.Is(err, ErrInvalid) {
+		t.Fatalf("Struct = %v, want an *Error", err)
+	}
+	if len(invalid.Fields) != 1 || invalid.Fields[0].Field != field || invalid.Fields[0].Rule != rule {
+		t.Fatalf("failing fields = %+v, want only %s failing %s", invalid.Fields, field, rule)
+	}
+	if !strings.HasPrefix(invalid.Fields[0].Message, field+" ") {
+		t.Errorf("message %q does not name the field", invalid.Fields[0].Message)
+	}
+}
+
+func TestCreateUserRequest(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		req   models.CreateUserRequest
+		field string
+		rule  string
+	}{
+		{"valid", models.CreateUserRequest{Email: "a@b.com", Username: "abc", Password: "123456"}, "", ""},
+		{"multibyte username", models.CreateUserRequest{Email: "a@b.com", Username: "ééé", Password: "123456"}, "", ""},
+		{"missing email", models.CreateUserRequest{Username: "abc", Password: "secret"}, "email", "required"},
+		{"blank email", models.CreateUserRequest{Email: "  ", Username: "abc", Password: "secret"}, "email", "required"},
+		{"undotted domain", models.CreateUserRequest{Email: "a@b", Username: "abc", Password: "secret"}, "email", "email"},
+		{"display name", models.CreateUserRequest{Email: "x <a@b.com>", Username: "abc", Password: "secret"}, "email", "email"},
+		{"hyphenated label", models.CreateUserRequest{Email: "a@-b.com", Username: "abc", Password: "secret"}, "email", "email"},
+		{"missing username", models.CreateUserRequest{Email: "a@b.com", Password: "secret"}, "username", "required"},
+		{"short username", models.CreateUserRequest{Email: "a@b.com", Username: "ab", Password: "secret"}, "username", "min"},
+		{"long username", models.CreateUserRequest{Email: "a@b.com", Username: strings.Repeat("x", 51), Password: "secret"}, "username", "max"},
+		{"missing password", models.CreateUserRequest{Email: "a@b.com", Username: "abc"}, "password", "required"},
+		{"short password", models.CreateUserRequest{Email: "a@b.com", Username: "abc", Password: "12345"}, "password", "min"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			requireField(t, Struct(tc.req), tc.field, tc.rule)
+		})
+	}
+}
+
+func TestLoginRequest(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		req   models.LoginRequest
+		field string
+		rule  string
+	}{
+		{"valid", models.LoginRequest{Email: "a@b.com", Password: "x"}, "", ""},
+		{"missing email", models.LoginRequest{Password: "x"}, "email", "required"},
+		{"invalid email", models.LoginRequest{Email: "bad", Password: "x"}, "email", "email"},
+		{"missing password", models.LoginRequest{Email: "a@b.com"}, "password", "required"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			requireField(t, Struct(&tc.req), tc.field, tc.rule)
+		})
+	}
+}
+
+func TestOptionalFields(t *testing.T) {
+	str := func(s string) *string { return &s }
+	for _, tc := range []struct {
+		name  string
+		req   interface{}
+		field string
+		rule  string
+	}{
+		{"no fields", models.UpdateUserRequest{}, "", ""},
+		{"valid fields", models.UpdateUserRequest{Email: str("a@b.com"), Username: str("abc")}, "", ""},
+		{"empty email", models.UpdateUserRequest{Email: str("")}, "email", "email"},
+		{"short username", models.UpdateUserRequest{Username: str("ab")}, "username", "min"},
+		{"negative number", &models.CreateAPIKeyRequest{Name: "k", TTLSeconds: -1}, "ttl_seconds", "min"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			requireField(t, Struct(tc.req), tc.field, tc.rule)
+		})
+	}
+}
+
+func TestStructListsEveryField(t *testing.T) {
+	err := Struct(models.CreateUserRequest{Username: "ab"})
+	var invalid *Error
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Struct = %v, want an *Error", err)
+	}
+	var got []string
+	for _, field := range invalid.Fields {
+		got = append(got, field.Field+":"+field.Rule)
+	}
+	if want := "email:required username:min password:required"; strings.Join(got, " ") != want {
+		t.Errorf("failing fields = %v, want %s in field order", got, want)
+	}
+	if !strings.Contains(err.Error(), "username must be at least 3 characters") {
+		t.Errorf("Error() = %q, want every message", err)
+	}
+}
+
+func TestStructPanicsOnMalformedTag(t *testing.T) {
+	for name, v := range map[string]interface{}{
+		"unknown rule": struct {
+			A string `validate:"uuid"`
+		}{},
+		"bad parameter": struct {
+			A string `validate:"min=x"`
+		}{},
+		"non-string email": struct {
+			A int `validate:"email"`
+		}{A: 1},
+		"not a struct": "string",
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Struct did not panic")
+				}
+			}()
+			Struct(v)
+		})
+	}
+}
+
+func TestEmail(t *testing.T) {
+	for _, tc := range []struct {
+		email string
+		want  bool
+	}{
+		{"ada@example.com", true},
+		{"ada.lovelace+tag@mail.example.co.uk", true},
+		{"ada@example", false},
+		{"ada@.example.com", false},
+		{"ada@example..com", false},
+		{"ada@example-.com", false},
+		{"Ada <ada@example.com>", false},
+		{"<ada@example.com>", false},
+		{"@example.com", false},
+		{"ada", false},
+		{strings.Repeat("a", 65) + "@example.com", false},
+		{"ada@" + strings.Repeat("a", 64) + ".com", false},
+	} {
+		if got := Email(tc.email); got != tc.want {
+			t.Errorf("Email(%q) = %v, want %v", tc.email, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"data-plane/internal/transport/interfaces"
+	"data-plane/internal/transport/middleware"
+)
+
+// ConnBuilder provides a fluent interface for configuring a gRPC
+// connection and the resiliency applied to its calls.
+// Errors are recorded and reported by Build.
+type ConnBuilder struct {
+	target      string
+	creds       credentials.TransportCredentials
+	dialOptions []grpclib.DialOption
+	metadata    metadata.MD
+	timeout     time.Duration
+	err         error
+
+	// Resiliency configuration
+	retryPolicy    interfaces.IRetryPolicy
+	circuitBreaker interfaces.ICircuitBreaker
+	rateLimiter    interfaces.IRateLimiter
+	bulkhead       interfaces.IBulkhead
+}
+
+// NewBuilder creates a new ConnBuilder with sensible defaults.
+// Connections use TLS with the system roots and a 30 second per-call deadline.
+func NewBuilder() *ConnBuilder {
+	return &ConnBuilder{
+		creds:    credentials.NewTLS(&tls.Config{}),
+		metadata: metadata.MD{},
+		timeout:  30 * time.Second,
+	}
+}
+
+// Target sets the dial target (e.g., "api.example.com:443" or "dns:///svc:50051").
+func (b *ConnBuilder) Target(target string) *ConnBuilder {
+	if b.err != nil {
+		return b
+	}
+	if target == "" {
+		b.err = fmt.Errorf("target cannot be empty")
+		return b
+	}
+	b.target = target
+	return b
+}
+
+// TLS secures the connection with the given TLS configuration.
+func (b *ConnBuilder) TLS(config *tls.Config) *ConnBuilder {
+	b.creds = credentials.NewTLS(config)
+	return b
+}
+
+// Insecure disables transport security, e.g. for in-cluster plaintext targets.
+func (b *ConnBuilder) Insecure() *ConnBuilder {
+	b.creds = insecure.NewCredentials()
+	return b
+}
+
+// Metadata adds a metadata header sent with every call.
+func (b *ConnBuilder) Metadata(key, value string) *ConnBuilder {
+	b.metadata.Append(key, value)
+	return b
+}
+
+// Timeout sets the default per-call deadline. Zero disables it.
+func (b *ConnBuilder) Timeout(timeout time.Duration) *ConnBuilder {
+	if b.err != nil {
+		return b
+	}
+	if timeout < 0 {
+		b.err = fmt.Errorf("timeout cannot be negative")
+		return b
+	}
+	b.timeout = timeout
+	return b
+}
+
+// DialOption adds a raw dial option, e.g. interceptors or keepalive settings.
+func (b *ConnBuilder) DialOption(opts ...grpclib.DialOption) *ConnBuilder {
+	b.dialOptions = append(b.dialOptions, opts...)
+	return b
+}
+
+// WithRetry configures retry policy for calls.
+func (b *ConnBuilder) WithRetry(policy interfaces.IRetryPolicy) *ConnBuilder {
+	b.retryPolicy = policy
+	return b
+}
+
+// WithCircuitBreaker configures circuit breaker for calls.
+func (b *ConnBuilder) WithCircuitBreaker(cb interfaces.ICircuitBreaker) *ConnBuilder {
+	b.circuitBreaker = cb
+	return b
+}
+
+// WithRateLimiter configures rate limiting for calls.
+func (b *ConnBuilder) WithRateLimiter(limiter interfaces.IRateLimiter) *ConnBuilder {
+	b.rateLimiter = limiter
+	return b
+}
+
+// WithBulkhead configures bulkhead pattern for calls.
+func (b *ConnBuilder) WithBulkhead(bulkhead interfaces.IBulkhead) *ConnBuilder {
+	b.bulkhead = bulkhead
+	return b
+}
+
+// Build creates the connection. The connection is established lazily on the
+// first call. Resiliency is applied in the same order as for HTTP clients:
+// rate limit, bulkhead, circuit breaker, then retry outermost.
+func (b *ConnBuilder) Build() (*Conn, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.target == "" {
+		return nil, fmt.Errorf("target is required")
+	}
+
+	opts := append([]grpclib.DialOption{grpclib.WithTransportCredentials(b.creds)}, b.dialOptions...)
+	cc, err := grpclib.NewClient(b.target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection: %w", err)
+	}
+
+	client := NewClient(cc, b.timeout, b.metadata.Copy())
+	var invoker interfaces.IInvoker = client
+	if b.rateLimiter != nil {
+		invoker = middleware.NewRateLimiterInvoker(invoker, b.rateLimiter)
+	}
+	if b.bulkhead != nil {
+		invoker = middleware.NewBulkheadInvoker(invoker, b.bulkhead)
+	}
+	if b.circuitBreaker != nil {
+		invoker = middleware.NewCircuitBreakerInvoker(invoker, b.circuitBreaker)
+	}
+	if b.retryPolicy != nil {
+		invoker = middleware.NewRetryInvoker(invoker, b.retryPolicy)
+	}
+
+	return &Conn{client: client, invoker: invoker}, nil
+}
+
+// Conn is a configured gRPC connection whose calls go through the
+// resiliency invokers set on the builder.
+// It implements the IInvoker interface.
+type Conn struct {
+	client  *Client
+	invoker interfaces.IInvoker
+}
+
+// Ensure Conn implements IInvoker interface
+var _ interfaces.IInvoker = (*Conn)(nil)
+
+// Invoke executes the call through the configured resiliency invokers.
+func (c *Conn) Invoke(ctx context.Context, call interfaces.ICall) error {
+	return c.invoker.Invoke(ctx, call)
+}
+
+// Unary invokes method with request and decodes the response into reply.
+func (c *Conn) Unary(ctx context.Context, method string, request, reply interface{}) error {
+	return c.Invoke(ctx, NewCall(method, request, reply))
+}
+
+// Client returns the undecorated client.
+func (c *Conn) Client() *Client {
+	return c.client
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"time"
+
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// Call is a unary gRPC call: the method, the request message and the
+// message the reply is decoded into.
+// It implements the ICall interface.
+type Call struct {
+	// Method is the full method name, e.g. "/package.Service/Method"
+	Method string
+
+	// Request is the request message
+	Request interface{}
+
+	// Reply receives the response message
+	Reply interface{}
+
+	// Metadata is sent as headers in addition to the connection's metadata
+	Metadata metadata.MD
+
+	// TimeoutVal is the per-call deadline; zero uses the connection's default
+	TimeoutVal time.Duration
+
+	// CallOptions are passed to the underlying invocation
+	CallOptions []grpclib.CallOption
+}
+
+// Ensure Call implements ICall interface
+var _ interfaces.ICall = (*Call)(nil)
+
+// NewCall creates a call of method that decodes the response into reply.
+func NewCall(method string, request, reply interface{}) *Call {
+	return &Call{
+		Method:   method,
+		Request:  request,
+		Reply:    reply,
+		Metadata: metadata.MD{},
+	}
+}
+
+// Header adds a metadata header to the call.
+func (c *Call) Header(key, value string) *Call {
+	if c.Metadata == nil {
+		c.Metadata = metadata.MD{}
+	}
+	c.Metadata.Append(key, value)
+	return c
+}
+
+// WithTimeout sets the per-call deadline.
+func (c *Call) WithTimeout(timeout time.Duration) *Call {
+	c.TimeoutVal = timeout
+	return c
+}
+
+// Operation returns the full method name.
+func (c *Call) Operation() string {
+	return c.Method
+}
+
+// Timeout returns the per-call deadline.
+func (c *Call) Timeout() time.Duration {
+	return c.TimeoutVal
+}
@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// Client performs unary gRPC calls over a single connection.
+// Like HTTPClient it only performs the call - resiliency is added by the
+// invoker decorators wrapping it.
+// It implements the IInvoker interface.
+type Client struct {
+	conn     *grpclib.ClientConn
+	timeout  time.Duration
+	metadata metadata.MD
+}
+
+// Ensure Client implements IInvoker interface
+var _ interfaces.IInvoker = (*Client)(nil)
+
+// NewClient creates a client over an existing connection. timeout is the
+// default per-call deadline (zero for none) and md is sent with every call.
+func NewClient(conn *grpclib.ClientConn, timeout time.Duration, md metadata.MD) *Client {
+	return &Client{
+		conn:     conn,
+		timeout:  timeout,
+		metadata: md,
+	}
+}
+
+// Invoke executes a unary call. call must be a *Call. The call's timeout,
+// or the client's default, bounds the call; failures are returned as
+// *StatusError.
+func (c *Client) Invoke(ctx context.Context, call interfaces.ICall) error {
+	unary, ok := call.(*Call)
+	if !ok || unary == nil {
+		return fmt.Errorf("unsupported call type %T", call)
+	}
+
+	timeout := unary.Timeout()
+	if timeout == 0 {
+		timeout = c.timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if md := metadata.Join(c.metadata, unary.Metadata); md.Len() > 0 {
+		if outgoing, ok := metadata.FromOutgoingContext(ctx); ok {
+			md = metadata.Join(outgoing, md)
+		}
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+
+	if err := c.conn.Invoke(ctx, unary.Method, unary.Request, unary.Reply, unary.CallOptions...); err != nil {
+		return newStatusError(unary.Method, err)
+	}
+	return nil
+}
+
+// Conn returns the underlying connection.
+func (c *Client) Conn() *grpclib.ClientConn {
+	return c.conn
+}
+
+// Close closes the underlying connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
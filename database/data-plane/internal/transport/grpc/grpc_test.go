@@ -0,0 +1,218 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+	"data-plane/internal/transport/resiliency"
+)
+
+const echoMethod = "/test.Echo/Echo"
+
+// echoServer answers Echo calls according to the request value:
+//   - "flaky" fails with UNAVAILABLE on the first two calls
+//   - "invalid" fails with INVALID_ARGUMENT
+//   - "slow" waits for the deadline, failing at once without one
+//   - "tenant" echoes the x-tenant metadata header
+//   - anything else is echoed back
+type echoServer struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+// echo handles one call.
+func (s *echoServer) echo(ctx context.Context, in *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	s.mu.Lock()
+	s.calls[in.Value]++
+	calls := s.calls[in.Value]
+	s.mu.Unlock()
+
+	switch in.Value {
+	case "flaky":
+		if calls <= 2 {
+			return nil, status.Error(codes.Unavailable, "warming up")
+		}
+	case "invalid":
+		return nil, status.Error(codes.InvalidArgument, "bad request")
+	case "slow":
+		if _, ok := ctx.Deadline(); !ok {
+			return nil, status.Error(codes.FailedPrecondition, "no deadline")
+		}
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case "tenant":
+		md, _ := metadata.FromIncomingContext(ctx)
+		return wrapperspb.String(md.Get("x-tenant")[0] + "/" + md.Get("x-call")[0]), nil
+	}
+	return in, nil
+}
+
+// callCount returns how many times value was sent.
+func (s *echoServer) callCount(value string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[value]
+}
+
+// serviceDesc registers echo without generated code.
+func (s *echoServer) serviceDesc() *grpclib.ServiceDesc {
+	return &grpclib.ServiceDesc{
+		ServiceName: "test.Echo",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpclib.MethodDesc{{
+			MethodName: "Echo",
+			Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpclib.UnaryServerInterceptor) (interface{}, error) {
+				in := new(wrapperspb.StringValue)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return s.echo(ctx, in)
+			},
+		}},
+	}
+}
+
+// newEchoServer serves echoServer over an in-process bufconn listener and
+// returns a builder dialing it.
+func newEchoServer(t *testing.T) (*echoServer, *ConnBuilder) {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	echo := &echoServer{calls: map[string]int{}}
+	server := grpclib.NewServer()
+	server.RegisterService(echo.serviceDesc(), echo)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	builder := NewBuilder().Target("passthrough:///bufnet").Insecure().
+		DialOption(grpclib.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}))
+	return echo, builder
+}
+
+// mustBuild builds the connection and closes it when the test ends.
+func mustBuild(t *testing.T, builder *ConnBuilder) *Conn {
+	t.Helper()
+	conn, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// echoCall sends value and returns the reply.
+func echoCall(conn *Conn, ctx context.Context, value string) (string, error) {
+	reply := new(wrapperspb.StringValue)
+	err := conn.Unary(ctx, echoMethod, wrapperspb.String(value), reply)
+	return reply.Value, err
+}
+
+func TestUnarySuccess(t *testing.T) {
+	_, builder := newEchoServer(t)
+	conn := mustBuild(t, builder.Metadata("x-tenant", "acme"))
+
+	if reply, err := echoCall(conn, context.Background(), "hello"); err != nil || reply != "hello" {
+		t.Fatalf("Echo = %q, %v; want hello", reply, err)
+	}
+
+	call := NewCall(echoMethod, wrapperspb.String("tenant"), new(wrapperspb.StringValue)).Header("x-call", "42")
+	if err := conn.Invoke(context.Background(), call); err != nil {
+		t.Fatal(err)
+	}
+	if got := call.Reply.(*wrapperspb.StringValue).Value; got != "acme/42" {
+		t.Errorf("server saw metadata %q, want acme/42", got)
+	}
+}
+
+func TestUnaryRetryableFailures(t *testing.T) {
+	echo, builder := newEchoServer(t)
+	policy := resiliency.NewRetryPolicyWithConfig(3, time.Millisecond, 5*time.Millisecond, 2)
+	conn := mustBuild(t, builder.WithRetry(policy))
+
+	// UNAVAILABLE is retried until the server recovers
+	if reply, err := echoCall(conn, context.Background(), "flaky"); err != nil || reply != "flaky" {
+		t.Fatalf("Echo = %q, %v; want success after retries", reply, err)
+	}
+	if calls := echo.callCount("flaky"); calls != 3 {
+		t.Errorf("flaky called %d times, want 3", calls)
+	}
+
+	// INVALID_ARGUMENT is not
+	_, err := echoCall(conn, context.Background(), "invalid")
+	statusErr, ok := AsStatusError(err)
+	if !ok || statusErr.Code() != codes.InvalidArgument {
+		t.Fatalf("error = %T %v, want an INVALID_ARGUMENT StatusError", err, err)
+	}
+	if calls := echo.callCount("invalid"); calls != 1 {
+		t.Errorf("invalid called %d times, want 1", calls)
+	}
+	if kind := models.Classify(err); kind != interfaces.KindClientStatus {
+		t.Errorf("Classify = %v, want %v", kind, interfaces.KindClientStatus)
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("status.Code = %v, want InvalidArgument", status.Code(err))
+	}
+}
+
+func TestUnaryDeadlinePropagation(t *testing.T) {
+	echo, builder := newEchoServer(t)
+	conn := mustBuild(t, builder.Timeout(50*time.Millisecond))
+
+	start := time.Now()
+	_, err := echoCall(conn, context.Background(), "slow")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("call took %v, the deadline was not applied", elapsed)
+	}
+	statusErr, ok := AsStatusError(err)
+	if !ok || statusErr.Code() != codes.DeadlineExceeded {
+		t.Fatalf("error = %T %v, want DEADLINE_EXCEEDED", err, err)
+	}
+	if models.Classify(err) != interfaces.KindTimeout || !statusErr.IsTemporary() {
+		t.Errorf("deadline error classified %v, temporary %v", models.Classify(err), statusErr.IsTemporary())
+	}
+
+	// A per-call timeout and the caller's context deadline also reach the server
+	call := NewCall(echoMethod, wrapperspb.String("slow"), new(wrapperspb.StringValue)).WithTimeout(20 * time.Millisecond)
+	if err := conn.Invoke(context.Background(), call); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("per-call timeout: error %v, want DEADLINE_EXCEEDED", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := echoCall(conn, ctx, "slow"); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("caller deadline: error %v, want DEADLINE_EXCEEDED", err)
+	}
+	if calls := echo.callCount("slow"); calls != 3 {
+		t.Errorf("slow called %d times, want 3 (none rejected for a missing deadline)", calls)
+	}
+}
+
+func TestUnaryCircuitBreaker(t *testing.T) {
+	echo, builder := newEchoServer(t)
+	breaker := resiliency.NewCircuitBreaker(2, time.Minute)
+	conn := mustBuild(t, builder.WithCircuitBreaker(breaker))
+
+	for range 2 {
+		echoCall(conn, context.Background(), "flaky")
+	}
+	_, err := echoCall(conn, context.Background(), "flaky")
+	if !errors.Is(err, resiliency.ErrCircuitOpen) {
+		t.Fatalf("error = %v, want ErrCircuitOpen after two UNAVAILABLE failures", err)
+	}
+	if calls := echo.callCount("flaky"); calls != 2 {
+		t.Errorf("flaky called %d times, want the open breaker to stop the third", calls)
+	}
+}
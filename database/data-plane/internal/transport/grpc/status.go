@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// StatusError is returned when a unary call fails. It carries the gRPC
+// status and maps its code into the transport's ErrorKind taxonomy, so
+// Classify, the retry policy and the circuit breaker treat it like an
+// HTTP failure of the same kind.
+type StatusError struct {
+	// Method is the full method name of the failed call
+	Method string
+
+	// Status is the gRPC status of the failure
+	Status *status.Status
+
+	// Err is the underlying error
+	Err error
+}
+
+// Ensure StatusError implements IKindedError interface
+var _ interfaces.IKindedError = (*StatusError)(nil)
+
+// newStatusError describes the failure of a call to method.
+func newStatusError(method string, err error) *StatusError {
+	return &StatusError{
+		Method: method,
+		Status: status.Convert(err),
+		Err:    err,
+	}
+}
+
+// Error implements the error interface for StatusError.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s failed: %s: %s", e.Method, e.Code(), e.Status.Message())
+}
+
+// Unwrap returns the underlying error.
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus returns the status, so status.FromError and status.Code
+// recognize the error.
+func (e *StatusError) GRPCStatus() *status.Status {
+	return e.Status
+}
+
+// Code returns the gRPC status code.
+func (e *StatusError) Code() codes.Code {
+	return e.Status.Code()
+}
+
+// ErrorKind returns the category of the status code.
+func (e *StatusError) ErrorKind() interfaces.ErrorKind {
+	return KindForCode(e.Code())
+}
+
+// IsTemporary returns true for UNAVAILABLE and DEADLINE_EXCEEDED, the
+// codes that are safe to retry.
+func (e *StatusError) IsTemporary() bool {
+	switch e.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// KindForCode maps a gRPC status code to an ErrorKind:
+//   - CANCELED → KindCanceled
+//   - DEADLINE_EXCEEDED → KindTimeout
+//   - RESOURCE_EXHAUSTED → KindRateLimited
+//   - UNAVAILABLE, INTERNAL, UNKNOWN, DATA_LOSS, UNIMPLEMENTED → KindServerStatus
+//   - other error codes → KindClientStatus
+func KindForCode(code codes.Code) interfaces.ErrorKind {
+	switch code {
+	case codes.OK:
+		return interfaces.KindUnknown
+	case codes.Canceled:
+		return interfaces.KindCanceled
+	case codes.DeadlineExceeded:
+		return interfaces.KindTimeout
+	case codes.ResourceExhausted:
+		return interfaces.KindRateLimited
+	case codes.Unavailable, codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented:
+		return interfaces.KindServerStatus
+	default:
+		return interfaces.KindClientStatus
+	}
+}
+
+// AsStatusError extracts a *StatusError from err's chain.
+func AsStatusError(err error) (*StatusError, bool) {
+	var statusErr *StatusError
+	ok := errors.As(err, &statusErr)
+	return statusErr, ok
+}
@@ -0,0 +1,38 @@
+package builder
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBasicAuth(t *testing.T) {
+	for _, tc := range []struct{ username, password string }{
+		{"ada", "secret"},
+		{"ada", ""},
+		{"user@example.com", "pa:ss wörd"},
+	} {
+		want, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+		want.SetBasicAuth(tc.username, tc.password)
+
+		request := mustBuild(t, newBuilder().GET().Host("example.com").BasicAuth(tc.username, tc.password))
+		if got := request.Header("Authorization"); got != want.Header.Get("Authorization") {
+			t.Errorf("BasicAuth(%q, %q) = %q, want %q", tc.username, tc.password, got, want.Header.Get("Authorization"))
+		}
+		if username, password, ok := request.HTTPRequest().BasicAuth(); !ok || username != tc.username || password != tc.password {
+			t.Errorf("BasicAuth(%q, %q) decodes to %q, %q", tc.username, tc.password, username, password)
+		}
+	}
+}
+
+func TestBasicAuthReplacesAuthorization(t *testing.T) {
+	request := mustBuild(t, newBuilder().GET().Host("example.com").BearerToken("token").BasicAuth("ada", "secret"))
+	if got := request.HTTPRequest().Header.Values("Authorization"); len(got) != 1 || got[0] != "Basic YWRhOnNlY3JldA==" {
+		t.Errorf("Authorization = %q, want only the Basic credentials", got)
+	}
+}
+
+func TestBasicAuthRejectsEmptyUsername(t *testing.T) {
+	if _, err := NewBuilder().GET().Host("example.com").BasicAuth("", "secret").Build(); err == nil {
+		t.Fatal("Build accepted an empty username")
+	}
+}
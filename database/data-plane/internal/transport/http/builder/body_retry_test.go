@@ -0,0 +1,89 @@
+package builder
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/models"
+)
+
+// newFlakyServer returns a server recording every request body and failing
+// the first one with 503.
+func newFlakyServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		first := len(bodies) == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(bodies)
+	}
+}
+
+func TestRetryReplaysBytesBody(t *testing.T) {
+	server, bodies := newFlakyServer(t)
+	payload := strings.Repeat("payload ", 10<<10)
+
+	resp := mustSync(t, newBuilder().POST().Scheme("http").Host(server.Listener.Addr().String()).
+		Body(bytes.NewReader([]byte(payload))).WithRetryPolicy(fastRetry{attempts: 3}))
+	if resp.StatusCode() != http.StatusOK {
+		t.Fatalf("status = %d, want 200 after the retry", resp.StatusCode())
+	}
+	got := bodies()
+	if len(got) != 2 || got[0] != payload || got[1] != payload {
+		t.Errorf("server received %d bodies of %v bytes, want the full payload twice", len(got), bodyLengths(got))
+	}
+}
+
+func TestRetryRejectsStreamedBody(t *testing.T) {
+	server, bodies := newFlakyServer(t)
+
+	start := time.Now()
+	_, err := newBuilder().POST().Scheme("http").Host(server.Listener.Addr().String()).
+		Body(oneShotReader{strings.NewReader("payload")}).WithRetryPolicy(slowRetry{attempts: 3}).Sync()
+	if !errors.Is(err, models.ErrBodyNotReplayable) {
+		t.Fatalf("Sync = %v, want ErrBodyNotReplayable", err)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Minute {
+		t.Errorf("Sync took %v, want it to fail before the backoff", elapsed)
+	}
+	// The retry is abandoned rather than sent with an empty body
+	if got := bodies(); len(got) != 1 || got[0] != "payload" {
+		t.Errorf("server received %q, want only the first attempt", got)
+	}
+}
+
+// slowRetry retries every failure after a minute.
+type slowRetry struct{ attempts int }
+
+func (p slowRetry) ShouldRetry(err error, attempt int) bool { return attempt < p.attempts }
+func (p slowRetry) GetDelay(attempt int) time.Duration      { return time.Minute }
+func (p slowRetry) MaxAttempts() int                        { return p.attempts }
+
+// bodyLengths returns the length of each body.
+func bodyLengths(bodies []string) []int {
+	lengths := make([]int, len(bodies))
+	for i, body := range bodies {
+		lengths[i] = len(body)
+	}
+	return lengths
+}
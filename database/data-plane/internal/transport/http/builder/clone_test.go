@@ -0,0 +1,65 @@
+package builder
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCloneConcurrent(t *testing.T) {
+	base := NewBuilder().Host("example.com").AddPath("v1").BearerToken("token").QueryParam("key", "k")
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request, err := base.Clone().GET().AddPath(fmt.Sprint(i)).QueryParam("i", fmt.Sprint(i)).Header("X-Index", fmt.Sprint(i)).Build()
+			if err != nil {
+				t.Errorf("Build clone %d: %v", i, err)
+				return
+			}
+			if got, want := request.URL(), fmt.Sprintf("https://example.com/v1/%d?i=%d&key=k", i, i); got != want {
+				t.Errorf("clone %d URL = %s, want %s", i, got, want)
+			}
+			headers := request.Headers()
+			if got := headers.Values("X-Index"); len(got) != 1 || got[0] != fmt.Sprint(i) {
+				t.Errorf("clone %d X-Index = %q, want only its own value", i, got)
+			}
+			if headers.Get("Authorization") != "Bearer token" {
+				t.Errorf("clone %d lost the base Authorization header", i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The base is unaffected by its clones
+	request := mustBuild(t, base.GET())
+	if got, want := request.URL(), "https://example.com/v1?key=k"; got != want {
+		t.Errorf("base URL = %s, want %s", got, want)
+	}
+	if request.Headers().Get("X-Index") != "" {
+		t.Error("a clone's header leaked into the base")
+	}
+}
+
+func TestCloneIsIndependentOfLaterBaseChanges(t *testing.T) {
+	base := NewBuilder().GET().Host("example.com").AddPath("v1").Header("X-Trace", "1")
+	clone := base.Clone()
+	base.AddPath("users").QueryParam("page", "2").Header("X-Trace", "2")
+
+	request := mustBuild(t, clone)
+	if got, want := request.URL(), "https://example.com/v1"; got != want {
+		t.Errorf("clone URL = %s, want %s", got, want)
+	}
+	if got := request.Headers().Values("X-Trace"); len(got) != 1 || got[0] != "1" {
+		t.Errorf("clone X-Trace = %q, want only the value set before cloning", got)
+	}
+}
+
+func TestCloneKeepsError(t *testing.T) {
+	base := NewBuilder().GET().Host("example.com").Port(0)
+	if _, err := base.Clone().Build(); err == nil {
+		t.Fatal("a clone of a builder with an error built successfully")
+	}
+}
@@ -0,0 +1,87 @@
+package builder
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/handler"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+func TestConditionalHeaders(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	for _, tc := range []struct {
+		name   string
+		rb     interfaces.IRequestBuilder
+		header string
+		want   string
+	}{
+		{"unquoted etag", newBuilder().IfNoneMatch("v1"), "If-None-Match", `"v1"`},
+		{"quoted etag", newBuilder().IfNoneMatch(`"v1"`), "If-None-Match", `"v1"`},
+		{"weak etag", newBuilder().IfNoneMatch(`W/"v1"`), "If-None-Match", `W/"v1"`},
+		{"any etag", newBuilder().IfNoneMatch("*"), "If-None-Match", "*"},
+		{"modified since", newBuilder().IfModifiedSince(time.Date(1994, 11, 6, 3, 49, 37, 500, newYork)), "If-Modified-Since", "Sun, 06 Nov 1994 08:49:37 GMT"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := mustBuild(t, tc.rb.GET().Host("example.com")).Header(tc.header); got != tc.want {
+				t.Errorf("%s = %q, want %q", tc.header, got, tc.want)
+			}
+		})
+	}
+
+	for name, rb := range map[string]interfaces.IRequestBuilder{
+		"empty etag": newBuilder().IfNoneMatch(""),
+		"zero time":  newBuilder().IfModifiedSince(time.Time{}),
+	} {
+		if _, err := rb.GET().Host("example.com").Build(); err == nil {
+			t.Errorf("%s: Build succeeded", name)
+		}
+	}
+}
+
+func TestConditionalRequestNotModified(t *testing.T) {
+	lastModified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` || r.Header.Get("If-Modified-Since") == lastModified.Format(http.TimeFormat) {
+			w.Header().Set("Cache-Control", "max-age=60, stale-while-revalidate=30")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"a":1}`))
+	}))
+	defer server.Close()
+	h := handler.NewResponseHandler().WithResponseType(map[string]int{}).Build()
+	base := newBuilder().GET().Scheme("http").Host(server.Listener.Addr().String())
+
+	for name, rb := range map[string]interfaces.IRequestBuilder{
+		"if-none-match":     base.Clone().IfNoneMatch("v1"),
+		"if-modified-since": base.Clone().IfModifiedSince(lastModified),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := h.Handle(mustSync(t, rb))
+			if !errors.Is(err, models.ErrNotModified) {
+				t.Fatalf("Handle = %v, want ErrNotModified", err)
+			}
+			// The revalidation headers stay readable on the error
+			httpErr, ok := models.AsHTTPError(err)
+			if !ok || httpErr.Response.Header("Cache-Control") != "max-age=60, stale-while-revalidate=30" {
+				t.Errorf("Handle error = %v, want the 304's Cache-Control on it", err)
+			}
+		})
+	}
+
+	// A stale tag gets the fresh body
+	v, err := h.Handle(mustSync(t, base.Clone().IfNoneMatch("v0")))
+	if err != nil || v.(map[string]int)["a"] != 1 {
+		t.Errorf("Handle = %v, %v; want the decoded body", v, err)
+	}
+}
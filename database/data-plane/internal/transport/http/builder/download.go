@@ -0,0 +1,319 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+const (
+	// PartialSuffix is appended to the destination path while a download is incomplete.
+	PartialSuffix = ".part"
+
+	// etagSuffix is appended to the partial file path to persist the ETag
+	// of the resource being downloaded, so a later call can resume it.
+	etagSuffix = ".etag"
+)
+
+// errResourceChanged reports that a range response belonged to a different
+// version of the resource than the partial file.
+var errResourceChanged = errors.New("resource changed since the partial download")
+
+// OnDownloadProgress sets a callback reporting the absolute file offset
+// and the total size (-1 if unknown) while DownloadResumable runs.
+func (rb *RequestBuilder) OnDownloadProgress(fn func(offset, total int64)) interfaces.IRequestBuilder {
+	rb.onProgress = fn
+	return rb
+}
+
+// DownloadResumable downloads the response body to path.
+//
+// The body is written to path+PartialSuffix and renamed to path once
+// complete. If a partial file exists, the download resumes with a Range
+// request guarded by If-Range on the original ETag; if the resource has
+// changed the partial file is discarded and the download restarts. When the
+// connection drops mid-body, the download resumes within the same call for
+// as long as the retry policy allows. Failures before the body starts are
+// retried by the usual decorators.
+func (rb *RequestBuilder) DownloadResumable(ctx context.Context, path string) (*interfaces.DownloadResult, error) {
+	if rb.method == "" {
+		rb.method = http.MethodGet
+	}
+	if rb.method != http.MethodGet {
+		return nil, fmt.Errorf("resumable downloads require GET, got %s", rb.method)
+	}
+
+	d := &download{
+		builder:  rb,
+		client:   rb.createClientWithResiliency(),
+		partPath: path + PartialSuffix,
+		etagPath: path + PartialSuffix + etagSuffix,
+		result:   &interfaces.DownloadResult{Path: path, ResumedFrom: -1},
+	}
+	d.etag = d.loadETag()
+
+	maxAttempts := 1
+	if rb.retryPolicy != nil {
+		maxAttempts = rb.retryPolicy.MaxAttempts()
+	}
+
+	start := time.Now()
+	for attempt := 0; ; {
+		d.result.Attempts++
+		resumable, err := d.transfer(ctx)
+		if err == nil {
+			break
+		}
+
+		switch {
+		case errors.Is(err, errResourceChanged) && d.result.Restarts == 0:
+			// Start over once; the partial file was already discarded
+			d.result.Restarts++
+			continue
+		case !resumable || attempt+1 >= maxAttempts || ctx.Err() != nil:
+			d.result.Duration = time.Since(start)
+			return d.result, err
+		}
+
+		select {
+		case <-time.After(rb.retryPolicy.GetDelay(attempt)):
+		case <-ctx.Done():
+			d.result.Duration = time.Since(start)
+			return d.result, &models.HTTPError{
+				Message:  "download cancelled during retry backoff",
+				Err:      ctx.Err(),
+				StageVal: interfaces.StageBackoff,
+				Duration: time.Since(start),
+			}
+		}
+		attempt++
+	}
+
+	if err := os.Rename(d.partPath, path); err != nil {
+		return d.result, fmt.Errorf("failed to move completed download: %w", err)
+	}
+	_ = os.Remove(d.etagPath)
+	d.result.Duration = time.Since(start)
+	return d.result, nil
+}
+
+// download holds the state of one DownloadResumable call.
+type download struct {
+	builder  *RequestBuilder
+	client   interfaces.IHTTPClient
+	partPath string
+	etagPath string
+	etag     string
+	result   *interfaces.DownloadResult
+}
+
+// transfer makes one request and appends its body to the partial file.
+// resumable reports whether the error was a dropped transfer worth resuming.
+func (d *download) transfer(ctx context.Context) (resumable bool, err error) {
+	file, err := os.OpenFile(d.partPath, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return false, fmt.Errorf("failed to open partial download: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat partial download: %w", err)
+	}
+	offset := info.Size()
+	if offset > 0 && d.etag == "" {
+		// Without a validator the partial file cannot be matched to the resource
+		offset = 0
+	}
+	if d.result.ResumedFrom < 0 {
+		d.result.ResumedFrom = offset
+	}
+
+	request, err := d.builder.Build()
+	if err != nil {
+		return false, err
+	}
+	httpReq := request.HTTPRequest().Clone(ctx)
+	if offset > 0 {
+		httpReq.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		httpReq.Header.Set("If-Range", d.etag)
+	}
+	request = &models.Request{HTTPReq: httpReq, TimeoutVal: request.Timeout()}
+
+	resp, err := d.client.Send(request)
+	if resp != nil {
+		defer resp.Close()
+	}
+	if err != nil {
+		if offset > 0 && isRangeComplete(err, offset) {
+			d.result.Size = offset
+			d.result.ETag = d.etag
+			return false, nil
+		}
+		return false, err
+	}
+
+	httpResp := resp.HTTPResponse()
+	total := int64(-1)
+	switch httpResp.StatusCode {
+	case http.StatusPartialContent:
+		start, size, rangeErr := parseContentRange(httpResp.Header.Get("Content-Range"))
+		if rangeErr != nil || start != offset {
+			return false, &models.HTTPError{
+				Request:    request,
+				Response:   resp,
+				StatusCode: httpResp.StatusCode,
+				Message:    fmt.Sprintf("unexpected Content-Range %q for offset %d", httpResp.Header.Get("Content-Range"), offset),
+				Err:        rangeErr,
+			}
+		}
+		if etag := httpResp.Header.Get("ETag"); etag != d.etag {
+			return false, d.discard(file, errResourceChanged)
+		}
+		total = size
+	case http.StatusOK:
+		// Full body: a fresh download, or If-Range found the resource changed
+		if offset > 0 {
+			d.result.Restarts++
+		}
+		if err := file.Truncate(0); err != nil {
+			return false, fmt.Errorf("failed to truncate partial download: %w", err)
+		}
+		offset = 0
+		d.etag = usableETag(httpResp.Header.Get("ETag"))
+		d.storeETag()
+		total = httpResp.ContentLength
+	default:
+		return false, &models.HTTPError{
+			Request:    request,
+			Response:   resp,
+			StatusCode: httpResp.StatusCode,
+			Message:    fmt.Sprintf("unexpected download status %d", httpResp.StatusCode),
+		}
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return false, fmt.Errorf("failed to seek partial download: %w", err)
+	}
+	writer := &progressWriter{file: file, offset: offset, total: total, fn: d.builder.onProgress}
+	if _, err := io.Copy(writer, httpResp.Body); err != nil {
+		return ctx.Err() == nil, &models.HTTPError{
+			Request:    request,
+			Response:   resp,
+			StatusCode: httpResp.StatusCode,
+			Message:    fmt.Sprintf("download interrupted at offset %d", writer.offset),
+			Err:        err,
+			KindVal:    interfaces.KindConnection,
+		}
+	}
+
+	d.result.Size = writer.offset
+	d.result.ETag = d.etag
+	return false, nil
+}
+
+// discard truncates the partial file and forgets its ETag, returning err.
+func (d *download) discard(file *os.File, err error) error {
+	if truncErr := file.Truncate(0); truncErr != nil {
+		return fmt.Errorf("failed to truncate partial download: %w", truncErr)
+	}
+	d.etag = ""
+	_ = os.Remove(d.etagPath)
+	return err
+}
+
+// loadETag returns the persisted ETag of the partial file, if any.
+func (d *download) loadETag() string {
+	if _, err := os.Stat(d.partPath); err != nil {
+		_ = os.Remove(d.etagPath)
+		return ""
+	}
+	data, err := os.ReadFile(d.etagPath)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// storeETag persists the current ETag next to the partial file.
+func (d *download) storeETag() {
+	if d.etag == "" {
+		_ = os.Remove(d.etagPath)
+		return
+	}
+	_ = os.WriteFile(d.etagPath, []byte(d.etag), 0o644)
+}
+
+// usableETag returns etag if it can validate a range request. Weak ETags
+// cannot be used with If-Range, so they are dropped.
+func usableETag(etag string) string {
+	if strings.HasPrefix(etag, "W/") {
+		return ""
+	}
+	return etag
+}
+
+// isRangeComplete reports whether err is a 416 response saying the
+// resource is exactly offset bytes long, i.e. the partial file is complete.
+func isRangeComplete(err error, offset int64) bool {
+	httpErr, ok := models.AsHTTPError(err)
+	if !ok || httpErr.StatusCode != http.StatusRequestedRangeNotSatisfiable || httpErr.Response == nil {
+		return false
+	}
+	contentRange := httpErr.Response.Header("Content-Range")
+	return contentRange == fmt.Sprintf("bytes */%d", offset)
+}
+
+// parseContentRange parses "bytes <start>-<end>/<size>", returning -1 for
+// an unknown ("*") size.
+func parseContentRange(value string) (start, size int64, err error) {
+	spec, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("unsupported Content-Range %q", value)
+	}
+	rangePart, sizePart, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed Content-Range %q", value)
+	}
+	startPart, _, ok := strings.Cut(rangePart, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed Content-Range %q", value)
+	}
+	if start, err = strconv.ParseInt(startPart, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("malformed Content-Range %q: %w", value, err)
+	}
+	if sizePart == "*" {
+		return start, -1, nil
+	}
+	if size, err = strconv.ParseInt(sizePart, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("malformed Content-Range %q: %w", value, err)
+	}
+	return start, size, nil
+}
+
+// progressWriter writes to the partial file and reports absolute offsets.
+type progressWriter struct {
+	file   *os.File
+	offset int64
+	total  int64
+	fn     func(offset, total int64)
+}
+
+// Write writes p and reports the new offset.
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.offset += int64(n)
+	if w.fn != nil && n > 0 {
+		w.fn(w.offset, w.total)
+	}
+	return n, err
+}
@@ -0,0 +1,237 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// artifact is a range-aware file server. It serves content with etag and
+// drops the connection after dropAfter bytes of the first dropFirst responses.
+type artifact struct {
+	content   []byte
+	etag      string
+	dropAfter int
+	dropFirst int
+
+	mu       sync.Mutex
+	requests int
+	ranges   []string
+	ifRanges []string
+}
+
+func newArtifact(t *testing.T, size int, etag string) (*artifact, *httptest.Server) {
+	t.Helper()
+	content := make([]byte, size)
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := range content {
+		content[i] = byte(rng.Uint32())
+	}
+	a := &artifact{content: content, etag: etag}
+	server := httptest.NewServer(a)
+	t.Cleanup(server.Close)
+	return a, server
+}
+
+func (a *artifact) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	a.requests++
+	drop := a.requests <= a.dropFirst
+	a.ranges = append(a.ranges, r.Header.Get("Range"))
+	a.ifRanges = append(a.ifRanges, r.Header.Get("If-Range"))
+	a.mu.Unlock()
+
+	w.Header().Set("ETag", a.etag)
+	if drop {
+		w = &droppingWriter{ResponseWriter: w, left: a.dropAfter}
+	}
+	http.ServeContent(w, r, "artifact.bin", time.Time{}, bytes.NewReader(a.content))
+}
+
+// droppingWriter aborts the connection once left bytes have been written.
+type droppingWriter struct {
+	http.ResponseWriter
+	left int
+}
+
+func (w *droppingWriter) Write(p []byte) (int, error) {
+	if len(p) <= w.left {
+		w.left -= len(p)
+		return w.ResponseWriter.Write(p)
+	}
+	w.ResponseWriter.Write(p[:w.left])
+	w.ResponseWriter.(http.Flusher).Flush()
+	panic(http.ErrAbortHandler)
+}
+
+// downloadBuilder returns a builder for the artifact served by server.
+func downloadBuilder(server *httptest.Server) *RequestBuilder {
+	rb := newBuilder()
+	rb.GET().Scheme("http").Host(server.Listener.Addr().String()).Path("/artifact.bin")
+	return rb
+}
+
+// writePartial leaves a partial download of content[:n] validated by etag.
+func writePartial(t *testing.T, path string, content []byte, etag string) {
+	t.Helper()
+	if err := os.WriteFile(path+PartialSuffix, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+PartialSuffix+etagSuffix, []byte(etag), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// requireDownloaded checks the file at path against want by checksum and
+// that no partial state was left behind.
+func requireDownloaded(t *testing.T, path string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sha256.Sum256(got) != sha256.Sum256(want) {
+		t.Errorf("downloaded %d bytes with a different checksum than the %d served", len(got), len(want))
+	}
+	for _, leftover := range []string{path + PartialSuffix, path + PartialSuffix + etagSuffix} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("%s was left behind", filepath.Base(leftover))
+		}
+	}
+}
+
+func TestDownloadResumesDroppedTransfer(t *testing.T) {
+	a, server := newArtifact(t, 256<<10, `"v1"`)
+	a.dropAfter, a.dropFirst = 100<<10, 1
+	path := filepath.Join(t.TempDir(), "artifact.bin")
+
+	var offsets []int64
+	var totals []int64
+	rb := downloadBuilder(server)
+	rb.WithRetry(3).OnDownloadProgress(func(offset, total int64) {
+		offsets = append(offsets, offset)
+		totals = append(totals, total)
+	})
+
+	result, err := rb.DownloadResumable(context.Background(), path)
+	if err != nil {
+		t.Fatalf("DownloadResumable: %v", err)
+	}
+	requireDownloaded(t, path, a.content)
+
+	if result.Attempts != 2 || result.Restarts != 0 || result.ResumedFrom != 0 ||
+		result.Size != int64(len(a.content)) || result.ETag != `"v1"` {
+		t.Errorf("result = %+v, want 2 attempts, no restart, the full size and the ETag", result)
+	}
+	if got := a.ranges; len(got) != 2 || got[0] != "" || got[1] != "bytes=102400-" {
+		t.Errorf("Range headers = %q, want none then bytes=102400-", got)
+	}
+	if got := a.ifRanges; len(got) != 2 || got[1] != `"v1"` {
+		t.Errorf("If-Range headers = %q, want the original ETag on the resumed request", got)
+	}
+
+	// Offsets are absolute: they keep rising across the resume
+	for i := 1; i < len(offsets); i++ {
+		if offsets[i] <= offsets[i-1] {
+			t.Fatalf("progress went from %d to %d", offsets[i-1], offsets[i])
+		}
+	}
+	if last := len(offsets) - 1; last < 0 || offsets[last] != int64(len(a.content)) || totals[last] != int64(len(a.content)) {
+		t.Errorf("final progress = %v of %v, want %d of %d", offsets, totals, len(a.content), len(a.content))
+	}
+}
+
+func TestDownloadWithoutRetryLeavesPartial(t *testing.T) {
+	a, server := newArtifact(t, 64<<10, `"v1"`)
+	a.dropAfter, a.dropFirst = 16<<10, 1
+	path := filepath.Join(t.TempDir(), "artifact.bin")
+
+	if _, err := downloadBuilder(server).DownloadResumable(context.Background(), path); err == nil {
+		t.Fatal("DownloadResumable succeeded despite the dropped connection")
+	}
+	if info, err := os.Stat(path + PartialSuffix); err != nil || info.Size() != 16<<10 {
+		t.Fatalf("partial file = %v, %v; want the 16KiB received", info, err)
+	}
+
+	// A later call picks up where the first left off
+	result, err := downloadBuilder(server).DownloadResumable(context.Background(), path)
+	if err != nil {
+		t.Fatalf("DownloadResumable: %v", err)
+	}
+	requireDownloaded(t, path, a.content)
+	if result.ResumedFrom != 16<<10 || result.Attempts != 1 {
+		t.Errorf("result = %+v, want one attempt resumed from 16KiB", result)
+	}
+}
+
+func TestDownloadResumesPartialFile(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		partial      int
+		partialETag  string
+		wantResumed  int64
+		wantRestarts int
+		wantRange    string
+	}{
+		{"same version", 40 << 10, `"v1"`, 40 << 10, 0, "bytes=40960-"},
+		{"changed version", 40 << 10, `"v0"`, 40 << 10, 1, "bytes=40960-"},
+		{"already complete", 64 << 10, `"v1"`, 64 << 10, 0, "bytes=65536-"},
+		{"no validator", 40 << 10, "", 0, 0, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, server := newArtifact(t, 64<<10, `"v1"`)
+			path := filepath.Join(t.TempDir(), "artifact.bin")
+			writePartial(t, path, a.content[:tc.partial], tc.partialETag)
+			if tc.partialETag == "" {
+				os.Remove(path + PartialSuffix + etagSuffix)
+			}
+
+			result, err := downloadBuilder(server).DownloadResumable(context.Background(), path)
+			if err != nil {
+				t.Fatalf("DownloadResumable: %v", err)
+			}
+			requireDownloaded(t, path, a.content)
+			if result.ResumedFrom != tc.wantResumed || result.Restarts != tc.wantRestarts || result.Size != int64(len(a.content)) {
+				t.Errorf("result = %+v, want resumed from %d with %d restarts", result, tc.wantResumed, tc.wantRestarts)
+			}
+			if len(a.ranges) != 1 || a.ranges[0] != tc.wantRange {
+				t.Errorf("Range headers = %q, want [%q]", a.ranges, tc.wantRange)
+			}
+		})
+	}
+}
+
+func TestDownloadRejectsNonGET(t *testing.T) {
+	rb := newBuilder()
+	rb.POST().Host("example.com")
+	if _, err := rb.DownloadResumable(context.Background(), filepath.Join(t.TempDir(), "x")); err == nil {
+		t.Error("DownloadResumable accepted a POST")
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	for _, tc := range []struct {
+		value       string
+		start, size int64
+		ok          bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-99/*", 0, -1, true},
+		{"items 0-9/10", 0, 0, false},
+		{"bytes 100-199", 0, 0, false},
+		{"bytes x-199/200", 0, 0, false},
+	} {
+		start, size, err := parseContentRange(tc.value)
+		if (err == nil) != tc.ok || start != tc.start || size != tc.size {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v; want %d, %d, ok=%v", tc.value, start, size, err, tc.start, tc.size, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"data-plane/internal/transport/http/handler"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+type graphQLUser struct {
+	User struct {
+		Name    string `json:"name"`
+		Friends []struct {
+			Name string `json:"name"`
+		} `json:"friends"`
+	} `json:"user"`
+}
+
+const userQuery = `query User($id: ID!) { user(id: $id) { name friends { name } } }`
+
+// userQueryHash is the persisted query hash of userQuery.
+var userQueryHash = fmt.Sprintf("%x", sha256.Sum256([]byte(userQuery)))
+
+// newGraphQLStub answers GraphQL envelopes by the query they carry:
+// "user" succeeds, "broken" fails without data and "partial" returns data
+// with an error for one field. A persisted query is only known by the hash
+// of userQuery. The last envelope received is stored in received.
+func newGraphQLStub(t *testing.T, received *models.GraphQLRequest) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*received = models.GraphQLRequest{}
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(received) != nil {
+			http.Error(w, "bad envelope", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+		query := received.Query
+		if persisted, ok := received.Extensions["persistedQuery"].(map[string]interface{}); ok {
+			if persisted["sha256Hash"] != userQueryHash {
+				w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound"}]}`))
+				return
+			}
+			query = userQuery
+		}
+
+		switch {
+		case strings.Contains(query, "broken"):
+			w.Write([]byte(`{"data":null,"errors":[{"message":"field \"broken\" not found","locations":[{"line":1,"column":3}],"extensions":{"code":"GRAPHQL_VALIDATION_FAILED"}}]}`))
+		case strings.Contains(query, "partial"):
+			w.Write([]byte(`{"data":{"user":{"name":"ada","friends":[{"name":"bob"},null]}},"errors":[{"message":"friend unavailable","path":["user","friends",1,"name"]}]}`))
+		default:
+			w.Write([]byte(`{"data":{"user":{"name":"ada","friends":[{"name":"bob"}]}}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// sendGraphQL posts query to the stub and unwraps the envelope into graphQLUser.
+func sendGraphQL(t *testing.T, server *httptest.Server, build func(interfaces.IRequestBuilder) interfaces.IRequestBuilder) (interface{}, error) {
+	t.Helper()
+	resp, err := build(NewBuilder().Scheme("http").Host(server.Listener.Addr().String()).Path("/graphql")).Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handler.GraphQL(graphQLUser{}).Handle(resp)
+}
+
+func TestGraphQLSuccess(t *testing.T) {
+	var received models.GraphQLRequest
+	server := newGraphQLStub(t, &received)
+
+	v, err := sendGraphQL(t, server, func(b interfaces.IRequestBuilder) interfaces.IRequestBuilder {
+		return b.GraphQL(userQuery, map[string]interface{}{"id": "42"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.Query != userQuery || received.Variables["id"] != "42" {
+		t.Errorf("server received %+v, want the query and variables", received)
+	}
+	user := v.(graphQLUser).User
+	if user.Name != "ada" || len(user.Friends) != 1 || user.Friends[0].Name != "bob" {
+		t.Errorf("decoded %+v", user)
+	}
+}
+
+func TestGraphQLErrorsOnly(t *testing.T) {
+	var received models.GraphQLRequest
+	server := newGraphQLStub(t, &received)
+
+	v, err := sendGraphQL(t, server, func(b interfaces.IRequestBuilder) interfaces.IRequestBuilder {
+		return b.GraphQL(`{ broken }`, nil)
+	})
+	var graphQLErr *models.GraphQLError
+	if !errors.As(err, &graphQLErr) {
+		t.Fatalf("Handle error = %T %v, want *GraphQLError", err, err)
+	}
+	if v != nil || graphQLErr.Partial {
+		t.Errorf("result %v, partial %v; want no data", v, graphQLErr.Partial)
+	}
+	detail := graphQLErr.Errors[0]
+	if detail.Message != `field "broken" not found` || detail.Locations[0].Line != 1 ||
+		detail.Extensions["code"] != "GRAPHQL_VALIDATION_FAILED" {
+		t.Errorf("error detail = %+v", detail)
+	}
+	if graphQLErr.Kind() != interfaces.KindGraphQL || graphQLErr.IsTemporary() {
+		t.Errorf("kind %v, temporary %v", graphQLErr.Kind(), graphQLErr.IsTemporary())
+	}
+	if err := graphQLErr.DecodeData(&graphQLUser{}); err == nil {
+		t.Error("DecodeData succeeded without data")
+	}
+}
+
+func TestGraphQLPartialData(t *testing.T) {
+	var received models.GraphQLRequest
+	server := newGraphQLStub(t, &received)
+
+	v, err := sendGraphQL(t, server, func(b interfaces.IRequestBuilder) interfaces.IRequestBuilder {
+		return b.GraphQL(`query partial { user { name friends { name } } }`, nil)
+	})
+	var graphQLErr *models.GraphQLError
+	if !errors.As(err, &graphQLErr) || !graphQLErr.Partial {
+		t.Fatalf("Handle error = %v, want a partial GraphQLError", err)
+	}
+	if user := v.(graphQLUser).User; user.Name != "ada" || len(user.Friends) != 2 {
+		t.Errorf("partial data = %+v, want the user with both friend slots", user)
+	}
+	if path := graphQLErr.Errors[0].PathString(); path != "user.friends.1.name" {
+		t.Errorf("PathString() = %q", path)
+	}
+	if !strings.Contains(err.Error(), "with partial data: friend unavailable (path: user.friends.1.name)") {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	var data graphQLUser
+	if err := graphQLErr.DecodeData(&data); err != nil || data.User.Name != "ada" {
+		t.Errorf("DecodeData = %+v, %v", data, err)
+	}
+}
+
+func TestGraphQLPersistedQuery(t *testing.T) {
+	var received models.GraphQLRequest
+	server := newGraphQLStub(t, &received)
+
+	v, err := sendGraphQL(t, server, func(b interfaces.IRequestBuilder) interfaces.IRequestBuilder {
+		return b.PersistedQuery(userQuery, map[string]interface{}{"id": "42"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.Query != "" || received.Variables["id"] != "42" {
+		t.Errorf("server received %+v, want only the hash and variables", received)
+	}
+	if v.(graphQLUser).User.Name != "ada" {
+		t.Errorf("decoded %+v", v)
+	}
+
+	_, err = sendGraphQL(t, server, func(b interfaces.IRequestBuilder) interfaces.IRequestBuilder {
+		return b.PersistedQuery(`{ unknown }`, nil)
+	})
+	var graphQLErr *models.GraphQLError
+	if !errors.As(err, &graphQLErr) || graphQLErr.Messages()[0] != "PersistedQueryNotFound" {
+		t.Errorf("unknown hash: error %v, want PersistedQueryNotFound", err)
+	}
+}
@@ -0,0 +1,70 @@
+package builder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/handler"
+	"data-plane/internal/transport/interfaces"
+)
+
+func TestHeadResponseBodyNotRead(t *testing.T) {
+	// The HEAD response advertises a body it does not send
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", "100")
+		if r.Method != http.MethodHead {
+			t.Errorf("server got %s, want HEAD", r.Method)
+		}
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name    string
+		handler interfaces.IResponseHandler
+		want    any
+	}{
+		{"typed", handler.NewResponseHandler().WithResponseType(map[string]int{}).WithChecksumVerification().Build(), map[string]int(nil)},
+		{"raw", handler.NewResponseHandler().Build(), []byte{}},
+		{"string", handler.String(), ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := mustSync(t, newBuilder().HEAD().Scheme("http").Host(server.Listener.Addr().String()).Timeout(2*time.Second))
+			if got := resp.Header("Content-Length"); got != "100" {
+				t.Fatalf("Content-Length = %q, want the advertised 100", got)
+			}
+			v, err := tc.handler.Handle(resp)
+			if err != nil {
+				t.Fatalf("Handle: %v", err)
+			}
+			if !reflect.DeepEqual(v, tc.want) {
+				t.Errorf("Handle = %#v, want %#v", v, tc.want)
+			}
+		})
+	}
+
+	resp := mustSync(t, newBuilder().HEAD().Scheme("http").Host(server.Listener.Addr().String()))
+	v, err := handler.Discard().Handle(resp)
+	if status, ok := v.(handler.ResponseStatus); err != nil || !ok || status.StatusCode != http.StatusOK {
+		t.Errorf("Discard = %#v, %v; want the status metadata", v, err)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions {
+			t.Errorf("server got %s, want OPTIONS", r.Method)
+		}
+		w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	resp := mustSync(t, newBuilder().OPTIONS().Scheme("http").Host(server.Listener.Addr().String()))
+	if resp.StatusCode() != http.StatusNoContent || resp.Header("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("OPTIONS got %d with Allow %q", resp.StatusCode(), resp.Header("Allow"))
+	}
+}
@@ -0,0 +1,86 @@
+package builder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostHeaderAndServerName(t *testing.T) {
+	var host, serverName string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, serverName = r.Host, r.TLS.ServerName
+	}))
+	defer server.Close()
+
+	// The second request reuses the transport derived for the server name
+	for range 2 {
+		resp, err := newBuilder().Client(server.Client()).GET().Host(server.Listener.Addr().String()).
+			HostHeader("api.internal.example.com").ServerName("example.com").Sync()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Close()
+		if host != "api.internal.example.com" || serverName != "example.com" {
+			t.Fatalf("server saw Host %q and server name %q", host, serverName)
+		}
+	}
+
+	// The certificate is verified against the server name
+	if _, err := newBuilder().Client(server.Client()).GET().Host(server.Listener.Addr().String()).
+		ServerName("wrong.test").Sync(); err == nil {
+		t.Fatal("request succeeded with a server name the certificate does not cover")
+	}
+}
+
+func TestServerNameRequiresTransport(t *testing.T) {
+	client := &http.Client{Transport: http.NewFileTransport(http.Dir("."))}
+	if _, err := newBuilder().Client(client).GET().Host("example.com").ServerName("a.example.com").Build(); err == nil {
+		t.Fatal("Build accepted a server name for a client without an *http.Transport")
+	}
+}
+
+func TestHostHeaderAndServerNameRejectEmpty(t *testing.T) {
+	if _, err := NewBuilder().GET().Host("example.com").HostHeader("").Build(); err == nil {
+		t.Error("Build accepted an empty host header")
+	}
+	if _, err := NewBuilder().GET().Host("example.com").ServerName("").Build(); err == nil {
+		t.Error("Build accepted an empty server name")
+	}
+}
+
+func TestHostAndPort(t *testing.T) {
+	for _, tc := range []struct {
+		host string
+		port int
+		want string
+	}{
+		{"localhost", 9090, "https://localhost:9090"},
+		{"localhost:8080", 0, "https://localhost:8080"},
+		{"localhost:8080", 9090, "https://localhost:9090"},
+		{"http://example.com/", 0, "https://example.com"},
+		{"::1", 80, "https://[::1]:80"},
+		{"[::1]:8080", 80, "https://[::1]:80"},
+	} {
+		rb := newBuilder().GET().Host(tc.host)
+		if tc.port != 0 {
+			rb.Port(tc.port)
+		}
+		if got := mustBuild(t, rb).URL(); got != tc.want {
+			t.Errorf("Host(%q).Port(%d) URL = %s, want %s", tc.host, tc.port, got, tc.want)
+		}
+	}
+}
+
+func TestHostAndPortRejectInvalid(t *testing.T) {
+	for _, host := range []string{"example.com:443/extra", "example.com?a=b", "example.com#top", "example.com:0", "example.com:70000"} {
+		if _, err := NewBuilder().GET().Host(host).Build(); err == nil {
+			t.Errorf("Build accepted host %q", host)
+		}
+	}
+	for _, port := range []int{0, -1, 65536} {
+		if _, err := NewBuilder().GET().Host("example.com").Port(port).Build(); err == nil {
+			t.Errorf("Build accepted port %d", port)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// uuidV4 matches a random (version 4) UUID.
+var uuidV4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestAutoIdempotencyKeySameAcrossRetries(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get(models.IdempotencyKeyHeader))
+		attempt := len(keys)
+		mu.Unlock()
+		if attempt < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	resp, err := NewBuilder().POST().Scheme("http").Host(server.Listener.Addr().String()).
+		BodyString("order").WithAutoIdempotencyKey().WithRetryPolicy(fastRetry{attempts: 3}).Sync()
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	resp.Close()
+	if resp.StatusCode() != http.StatusOK || len(keys) != 3 {
+		t.Fatalf("status %d after %d attempts, want 200 after 3", resp.StatusCode(), len(keys))
+	}
+	if !uuidV4.MatchString(keys[0]) || keys[1] != keys[0] || keys[2] != keys[0] {
+		t.Errorf("attempts sent keys %q, want the same UUID on each", keys)
+	}
+}
+
+func TestAutoIdempotencyKeyPerBuild(t *testing.T) {
+	rb := NewBuilder().POST().Host("example.com").WithAutoIdempotencyKey()
+	first := mustBuild(t, rb).Header(models.IdempotencyKeyHeader)
+	second := mustBuild(t, rb).Header(models.IdempotencyKeyHeader)
+	if !uuidV4.MatchString(first) || !uuidV4.MatchString(second) {
+		t.Fatalf("keys %q and %q, want UUIDs visible on the built request", first, second)
+	}
+	if first == second {
+		t.Errorf("two builds share the key %q, want one generated per build", first)
+	}
+}
+
+func TestIdempotencyKeyPrecedence(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rb   interfaces.IRequestBuilder
+		want string
+	}{
+		{"explicit key", newBuilder().WithIdempotencyKey("abc").WithAutoIdempotencyKey(), "abc"},
+		{"header", newBuilder().Header(models.IdempotencyKeyHeader, "from-header").WithAutoIdempotencyKey(), "from-header"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := mustBuild(t, tc.rb.POST().Host("example.com")).Header(models.IdempotencyKeyHeader); got != tc.want {
+				t.Errorf("Idempotency-Key = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
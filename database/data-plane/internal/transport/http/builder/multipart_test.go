@@ -0,0 +1,298 @@
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// synthetic is a deterministic reader of size bytes that is never held in memory.
+type synthetic struct {
+	pos, size int64
+}
+
+func (s *synthetic) Read(p []byte) (int, error) {
+	if s.pos >= s.size {
+		return 0, io.EOF
+	}
+	if remaining := s.size - s.pos; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	for i := range p {
+		p[i] = byte((s.pos + int64(i)) % 251)
+	}
+	s.pos += int64(len(p))
+	return len(p), nil
+}
+
+// syntheticSum returns the hex SHA-256 of a synthetic reader of size bytes.
+func syntheticSum(size int64) string {
+	h := sha256.New()
+	io.Copy(h, &synthetic{size: size})
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// upload is what the upload server received in one request.
+type upload struct {
+	chunked bool
+	fields  map[string]string
+	files   map[string]string // form name -> "<filename>:<size>:<sha256>"
+}
+
+// uploadServer parses multipart uploads as a stream, hashing file parts.
+// The first failFirst requests are answered with 503 after the body is read.
+type uploadServer struct {
+	failFirst int
+
+	mu      sync.Mutex
+	uploads []upload
+}
+
+func newUploadServer(t *testing.T, failFirst int) (*uploadServer, *httptest.Server) {
+	t.Helper()
+	s := &uploadServer{failFirst: failFirst}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func (s *uploadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	received := upload{
+		chunked: len(r.TransferEncoding) > 0 && r.TransferEncoding[0] == "chunked",
+		fields:  map[string]string{},
+		files:   map[string]string{},
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if part.FileName() == "" {
+			value, _ := io.ReadAll(part)
+			received.fields[part.FormName()] = string(value)
+			continue
+		}
+		h := sha256.New()
+		n, err := io.Copy(h, part)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received.files[part.FormName()] = fmt.Sprintf("%s:%d:%s", part.FileName(), n, hex.EncodeToString(h.Sum(nil)))
+	}
+
+	s.mu.Lock()
+	s.uploads = append(s.uploads, received)
+	fail := len(s.uploads) <= s.failFirst
+	s.mu.Unlock()
+	if fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
+
+// uploadBuilder returns a POST builder for server's /upload.
+func uploadBuilder(server *httptest.Server) *RequestBuilder {
+	rb := newBuilder()
+	rb.POST().Scheme("http").Host(server.Listener.Addr().String()).Path("/upload")
+	return rb
+}
+
+func TestMultipartStreamsLargeUpload(t *testing.T) {
+	const size = 100 << 20
+	s, server := newUploadServer(t, 0)
+
+	rb := uploadBuilder(server)
+	rb.Multipart(
+		models.FormField("name", "artifact"),
+		models.ReaderPart("file", "artifact.bin", &synthetic{size: size}),
+	)
+
+	// The Content-Type, boundary included, is known before anything is sent
+	contentType := rb.headers.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "multipart/form-data; boundary=") {
+		t.Fatalf("Content-Type = %q, want multipart/form-data with a boundary", contentType)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	resp, err := rb.Sync()
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	resp.Close()
+
+	// Allocations include the server's; both sides stream
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > size/10 {
+		t.Errorf("allocated %d MiB uploading %d MiB, want the body streamed", allocated>>20, size>>20)
+	}
+
+	if len(s.uploads) != 1 {
+		t.Fatalf("server received %d uploads, want 1", len(s.uploads))
+	}
+	got := s.uploads[0]
+	if !got.chunked {
+		t.Error("upload was not sent with chunked transfer encoding")
+	}
+	if got.fields["name"] != "artifact" {
+		t.Errorf("name field = %q, want artifact", got.fields["name"])
+	}
+	if want := fmt.Sprintf("artifact.bin:%d:%s", size, syntheticSum(size)); got.files["file"] != want {
+		t.Errorf("file part = %s, want %s", got.files["file"], want)
+	}
+}
+
+func TestStreamedBodyRetries(t *testing.T) {
+	const size = 1 << 20
+	want := fmt.Sprintf("artifact.bin:%d:%s", size, syntheticSum(size))
+
+	t.Run("reopened parts", func(t *testing.T) {
+		s, server := newUploadServer(t, 1)
+		opened := 0
+		rb := uploadBuilder(server)
+		rb.WithRetry(2).Multipart(models.FilePart("file", "artifact.bin", func() (io.ReadCloser, error) {
+			opened++
+			return io.NopCloser(&synthetic{size: size}), nil
+		}))
+
+		resp, err := rb.Sync()
+		if err != nil {
+			t.Fatalf("Sync: %v", err)
+		}
+		resp.Close()
+		if opened != 2 || len(s.uploads) != 2 {
+			t.Fatalf("opened the part %d times for %d uploads, want 2 and 2", opened, len(s.uploads))
+		}
+		if s.uploads[1].files["file"] != want {
+			t.Errorf("retried file part = %s, want %s", s.uploads[1].files["file"], want)
+		}
+	})
+
+	t.Run("one-shot part", func(t *testing.T) {
+		s, server := newUploadServer(t, 1)
+		rb := uploadBuilder(server)
+		rb.WithRetry(2).Multipart(models.ReaderPart("file", "artifact.bin", &synthetic{size: size}))
+
+		_, err := rb.Sync()
+		if !errors.Is(err, models.ErrBodyNotReplayable) {
+			t.Fatalf("Sync error = %v, want ErrBodyNotReplayable", err)
+		}
+		if httpErr, ok := models.AsHTTPError(err); !ok || !errors.Is(httpErr, models.ErrBodyNotReplayable) {
+			t.Errorf("Sync error = %T, want an HTTPError", err)
+		}
+		if len(s.uploads) != 1 {
+			t.Errorf("server received %d uploads, want only the first", len(s.uploads))
+		}
+	})
+
+	t.Run("body func", func(t *testing.T) {
+		var bodies []string
+		failed := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			if !failed {
+				failed = true
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		opened := 0
+		rb := uploadBuilder(server)
+		rb.WithRetry(2).BodyFunc(func() (io.ReadCloser, error) {
+			opened++
+			return io.NopCloser(strings.NewReader("payload")), nil
+		})
+		resp, err := rb.Sync()
+		if err != nil {
+			t.Fatalf("Sync: %v", err)
+		}
+		resp.Close()
+		if len(bodies) != 2 || bodies[0] != "payload" || bodies[1] != "payload" {
+			t.Errorf("server received %q, want the payload twice", bodies)
+		}
+		// Build opens the body once and the retry opens it again
+		if opened != 2 {
+			t.Errorf("opened the body %d times, want 2", opened)
+		}
+	})
+}
+
+func TestBodyFuncRejectsNil(t *testing.T) {
+	if _, err := NewBuilder().POST().Host("example.com").BodyFunc(nil).Build(); err == nil {
+		t.Error("Build accepted a nil body function")
+	}
+}
+
+func TestMultipartFieldsAndFiles(t *testing.T) {
+	const size = 64 << 10
+	s, server := newUploadServer(t, 1)
+	rb := uploadBuilder(server)
+	rb.WithRetry(2).
+		MultipartField("title", "report").
+		MultipartFile("file", "artifact.bin", &synthetic{size: size})
+
+	// The boundary in the Content-Type is the one the body uses
+	request := mustBuild(t, rb)
+	mediaType, params, err := mime.ParseMediaType(request.Header("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		t.Fatalf("Content-Type = %q, want multipart/form-data with a boundary", request.Header("Content-Type"))
+	}
+	body := readBody(t, request)
+	if !strings.HasPrefix(body, "--"+params["boundary"]+"\r\n") || !strings.HasSuffix(body, "--"+params["boundary"]+"--\r\n") {
+		t.Errorf("body is not delimited by the boundary %q", params["boundary"])
+	}
+
+	// The retry sends the complete body again
+	resp, err := rb.Sync()
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	resp.Close()
+	if len(s.uploads) != 2 {
+		t.Fatalf("server received %d uploads, want 2", len(s.uploads))
+	}
+	want := fmt.Sprintf("artifact.bin:%d:%s", size, syntheticSum(size))
+	for i, got := range s.uploads {
+		if got.fields["title"] != "report" || got.files["file"] != want {
+			t.Errorf("upload %d = %+v, want the title field and file %s", i, got, want)
+		}
+	}
+}
+
+func TestMultipartFieldsRejectOtherBodies(t *testing.T) {
+	for name, rb := range map[string]interfaces.IRequestBuilder{
+		"JSON then field":      newBuilder().POST().Host("example.com").JSON(map[string]int{"a": 1}).MultipartField("a", "1"),
+		"field then JSON":      newBuilder().POST().Host("example.com").MultipartField("a", "1").JSON(map[string]int{"a": 1}),
+		"body then file":       newBuilder().POST().Host("example.com").BodyString("x").MultipartFile("f", "f.txt", strings.NewReader("x")),
+		"file then body":       newBuilder().POST().Host("example.com").MultipartFile("f", "f.txt", strings.NewReader("x")).BodyString("x"),
+		"field then body func": newBuilder().POST().Host("example.com").MultipartField("a", "1").BodyFunc(func() (io.ReadCloser, error) { return nil, nil }),
+	} {
+		if _, err := rb.Build(); !errors.Is(err, errMixedMultipart) {
+			t.Errorf("%s: Build = %v, want errMixedMultipart", name, err)
+		}
+	}
+}
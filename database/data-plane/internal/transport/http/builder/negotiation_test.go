@@ -0,0 +1,85 @@
+package builder
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"data-plane/internal/transport/http/handler"
+)
+
+type negotiated struct {
+	XMLName xml.Name `xml:"item" json:"-"`
+	Name    string   `xml:"name" json:"name"`
+}
+
+func TestAcceptTypesNegotiation(t *testing.T) {
+	// The server answers in whichever format it is currently switched to
+	var contentType, accept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		w.Header().Set("Content-Type", contentType)
+		switch {
+		case strings.Contains(contentType, "json"):
+			json.NewEncoder(w).Encode(negotiated{Name: "json"})
+		case strings.Contains(contentType, "xml"):
+			xml.NewEncoder(w).Encode(negotiated{Name: "xml"})
+		default:
+			w.Write([]byte("name=plain"))
+		}
+	}))
+	defer server.Close()
+
+	h := handler.NewResponseHandler().
+		WithResponseType(negotiated{}).
+		WithMarshallers(handler.NewJSONMarshaller(), handler.NewXMLMarshaller()).
+		Build()
+
+	for _, tc := range []struct {
+		contentType, want string
+	}{
+		{"application/json", "json"},
+		{"application/xml", "xml"},
+		{"application/json; charset=utf-8", "json"},
+		{"text/xml; charset=utf-8", "xml"},
+		{"application/problem+json", "json"},
+	} {
+		contentType = tc.contentType
+		resp, err := NewBuilder().GET().Scheme("http").Host(server.Listener.Addr().String()).
+			AcceptTypes("application/json;q=1.0", "application/xml;q=0.8").Sync()
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := h.Handle(resp)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.contentType, err)
+		}
+		if got := v.(negotiated).Name; got != tc.want {
+			t.Errorf("%s: decoded %q, want %q", tc.contentType, got, tc.want)
+		}
+	}
+	if want := "application/json;q=1.0, application/xml;q=0.8"; accept != want {
+		t.Errorf("Accept = %q, want %q", accept, want)
+	}
+
+	contentType = "text/plain"
+	resp, err := NewBuilder().GET().Scheme("http").Host(server.Listener.Addr().String()).Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Handle(resp); err == nil || !strings.Contains(err.Error(), "no marshaller") {
+		t.Errorf("Handle error = %v, want a no marshaller error", err)
+	}
+}
+
+func TestAcceptTypesRejectsInvalid(t *testing.T) {
+	if _, err := NewBuilder().GET().Host("example.com").AcceptTypes().Build(); err == nil {
+		t.Error("Build accepted an empty accept type list")
+	}
+	if _, err := NewBuilder().GET().Host("example.com").AcceptTypes("application/json;q").Build(); err == nil {
+		t.Error("Build accepted a malformed accept type")
+	}
+}
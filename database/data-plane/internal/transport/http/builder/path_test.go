@@ -0,0 +1,47 @@
+package builder
+
+import (
+	"testing"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+func TestPathEscaping(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rb   interfaces.IRequestBuilder
+		want string
+	}{
+		{"plain", newBuilder().AddPath("users").AddPath("42"), "https://example.com/users/42"},
+		{"spaces", newBuilder().AddPath("user name"), "https://example.com/user%20name"},
+		{"reserved characters", newBuilder().AddPath("with?chars#and;more"), "https://example.com/with%3Fchars%23and%3Bmore"},
+		{"unicode", newBuilder().AddPath("café").AddPath("日本"), "https://example.com/caf%C3%A9/%E6%97%A5%E6%9C%AC"},
+		{"percent literal", newBuilder().AddPath("100%").AddPath("%41"), "https://example.com/100%25/%2541"},
+		{"embedded slash separates segments", newBuilder().AddPath("a/b c"), "https://example.com/a/b%20c"},
+		{"empty segments dropped", newBuilder().AddPath("/users//42/"), "https://example.com/users/42"},
+		{"escaped slash with raw path", newBuilder().AddPath("files").AddRawPath("a%2Fb"), "https://example.com/files/a%2Fb"},
+		{"path splits segments", newBuilder().Path("/posts/1"), "https://example.com/posts/1"},
+		{"path escapes segments", newBuilder().Path("/files/my doc.txt"), "https://example.com/files/my%20doc.txt"},
+		{"path then add", newBuilder().Path("/posts/1").AddPath("a b"), "https://example.com/posts/1/a%20b"},
+		{"path replaces", newBuilder().AddPath("old").Path("new"), "https://example.com/new"},
+		{"raw path", newBuilder().AddRawPath("/a%2Fb/c/"), "https://example.com/a%2Fb/c"},
+		{"raw after escaped", newBuilder().AddPath("x y").AddRawPath("caf%C3%A9"), "https://example.com/x%20y/caf%C3%A9"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request := mustBuild(t, tc.rb.GET().Host("example.com"))
+			if got := request.URL(); got != tc.want {
+				t.Errorf("URL = %s, want %s", got, tc.want)
+			}
+			// The encoding survives on the http.Request
+			if got := request.HTTPRequest().URL.String(); got != tc.want {
+				t.Errorf("http.Request URL = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAddRawPathInvalid(t *testing.T) {
+	if _, err := newBuilder().GET().Host("example.com").AddRawPath("%zz").Build(); err == nil {
+		t.Fatal("Build succeeded with an invalid percent-encoding")
+	}
+}
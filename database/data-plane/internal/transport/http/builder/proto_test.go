@@ -0,0 +1,46 @@
+package builder
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"data-plane/internal/transport/http/handler"
+)
+
+func TestBodyProtoRoundTrip(t *testing.T) {
+	// The server echoes the request body and content type
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer server.Close()
+
+	resp, err := NewBuilder().POST().Scheme("http").Host(server.Listener.Addr().String()).
+		BodyProto(wrapperspb.String("hello")).Sync()
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	defer resp.Close()
+	if got := resp.Header("Content-Type"); got != handler.ProtoContentType {
+		t.Errorf("request Content-Type = %q, want %s", got, handler.ProtoContentType)
+	}
+
+	v, err := handler.NewResponseHandler().WithMarshaller(handler.NewProtoMarshaller()).
+		WithResponseType(&wrapperspb.StringValue{}).Build().Handle(resp)
+	if err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if got, ok := v.(*wrapperspb.StringValue); !ok || got.GetValue() != "hello" {
+		t.Errorf("echoed message = %#v, want hello", v)
+	}
+}
+
+func TestBodyProtoNil(t *testing.T) {
+	if _, err := NewBuilder().POST().Host("example.com").BodyProto(nil).Build(); err == nil {
+		t.Fatal("Build succeeded with a nil message")
+	}
+}
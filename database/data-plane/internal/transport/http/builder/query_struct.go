@@ -0,0 +1,136 @@
+package builder
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// timeType is the reflect.Type of time.Time, encoded as RFC 3339.
+var timeType = reflect.TypeOf(time.Time{})
+
+// QueryStruct adds a query parameter for each exported field of the struct
+// v (or pointer to one), named by its `query:"name,omitempty"` tag or else
+// after the field. A field tagged "-" is skipped, and so is a zero value
+// tagged omitempty. Strings, bools, numbers, time.Time (as RFC 3339) and
+// pointers to them are supported; a slice of them repeats the key for each
+// element, and a nil pointer adds nothing. Embedded structs without a tag
+// are flattened. Any other field type is reported as an error naming the
+// field, and nothing is added.
+func (rb *RequestBuilder) QueryStruct(v interface{}) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		rb.err = fmt.Errorf("query struct must be a non-nil struct, got %T", v)
+		return rb
+	}
+
+	params := url.Values{}
+	if err := encodeQueryStruct(params, value); err != nil {
+		rb.err = err
+		return rb
+	}
+	for key, values := range params {
+		rb.queryParams[key] = append(rb.queryParams[key], values...)
+	}
+	return rb
+}
+
+// encodeQueryStruct adds the fields of the struct value to params.
+func encodeQueryStruct(params url.Values, value reflect.Value) error {
+	structType := value.Type()
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		tag, hasTag := field.Tag.Lookup("query")
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "-" && options == "" {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		if field.Anonymous && !hasTag {
+			// Flatten embedded structs, through a pointer if need be
+			for fieldValue.Kind() == reflect.Pointer && !fieldValue.IsNil() && fieldValue.Type().Elem().Kind() == reflect.Struct {
+				fieldValue = fieldValue.Elem()
+			}
+			if fieldValue.Kind() == reflect.Struct && fieldValue.Type() != timeType {
+				if err := encodeQueryStruct(params, fieldValue); err != nil {
+					return err
+				}
+				continue
+			}
+			if fieldValue.Kind() == reflect.Pointer && fieldValue.IsNil() {
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if options == "omitempty" && fieldValue.IsZero() {
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Slice || fieldValue.Kind() == reflect.Array {
+			for j := range fieldValue.Len() {
+				s, ok, err := formatQueryValue(fieldValue.Index(j))
+				if err != nil {
+					return fmt.Errorf("query field %s: %w", field.Name, err)
+				}
+				if ok {
+					params.Add(name, s)
+				}
+			}
+			continue
+		}
+		s, ok, err := formatQueryValue(fieldValue)
+		if err != nil {
+			return fmt.Errorf("query field %s: %w", field.Name, err)
+		}
+		if ok {
+			params.Add(name, s)
+		}
+	}
+	return nil
+}
+
+// formatQueryValue formats a scalar field value, reporting false for a nil
+// pointer.
+func formatQueryValue(value reflect.Value) (string, bool, error) {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return "", false, nil
+		}
+		value = value.Elem()
+	}
+	if value.Type() == timeType {
+		return value.Interface().(time.Time).Format(time.RFC3339), true, nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10), true, nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, value.Type().Bits()), true, nil
+	}
+	return "", false, fmt.Errorf("unsupported type %s", value.Type())
+}
@@ -0,0 +1,107 @@
+package builder
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type page struct {
+	Limit  int       `query:"limit,omitempty"`
+	Offset int       `query:"offset"`
+	Since  time.Time `query:"since,omitempty"`
+}
+
+type Sort struct {
+	By string `query:"sort_by,omitempty"`
+}
+
+type userFilter struct {
+	page
+	*Sort
+	Name    string   `query:"name,omitempty"`
+	Tags    []string `query:"tag"`
+	Active  *bool    `query:"active"`
+	Score   float64
+	Secret  string `query:"-"`
+	private string
+}
+
+func TestQueryStruct(t *testing.T) {
+	yes := true
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	for _, tc := range []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"zero values", userFilter{}, "Score=0&offset=0"},
+		{"omitempty set", userFilter{page: page{Limit: 10}, Name: "ada"}, "Score=0&limit=10&name=ada&offset=0"},
+		{"slice repeats key", userFilter{Tags: []string{"b", "a"}}, "Score=0&offset=0&tag=b&tag=a"},
+		{"pointer", userFilter{Active: &yes, Score: 1.5}, "Score=1.5&active=true&offset=0"},
+		{"embedded pointer", userFilter{Sort: &Sort{By: "name"}}, "Score=0&offset=0&sort_by=name"},
+		{"skipped fields", userFilter{Secret: "s", private: "p"}, "Score=0&offset=0"},
+		{"time in unexported embedded struct", userFilter{page: page{Since: at}}, "Score=0&offset=0&since=2024-01-02T03%3A04%3A05%2B01%3A00"},
+		{"unexported embedded pointer", struct{ *page }{&page{Limit: 5, Since: at}}, "limit=5&offset=0&since=2024-01-02T03%3A04%3A05%2B01%3A00"},
+		{"nil embedded pointer", struct{ *page }{}, ""},
+		{"pointer to struct", &page{Offset: 20}, "offset=20"},
+		{"numbers", struct {
+			I8  int8    `query:"i8"`
+			U   uint    `query:"u"`
+			F32 float32 `query:"f32"`
+		}{-1, 2, 0.25}, "f32=0.25&i8=-1&u=2"},
+		{"slice of pointers", struct {
+			IDs []*int `query:"id"`
+		}{[]*int{new(int), nil}}, "id=0"},
+		{"time pointer", struct {
+			At *time.Time `query:"at"`
+		}{&at}, "at=2024-01-02T03%3A04%3A05%2B01%3A00"},
+		{"nil time pointer", struct {
+			At *time.Time `query:"at"`
+		}{}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			want := "https://example.com"
+			if tc.want != "" {
+				want += "?" + tc.want
+			}
+			if got := mustBuild(t, newBuilder().GET().Host("example.com").QueryStruct(tc.v)).URL(); got != want {
+				t.Errorf("URL = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestQueryStructAppends(t *testing.T) {
+	rb := newBuilder().GET().Host("example.com").QueryParam("offset", "1").QueryStruct(page{Offset: 2})
+	if got, want := mustBuild(t, rb).URL(), "https://example.com?offset=1&offset=2"; got != want {
+		t.Errorf("URL = %s, want %s", got, want)
+	}
+}
+
+func TestQueryStructErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"map field", struct {
+			Filter map[string]string `query:"filter"`
+		}{}, "query field Filter: unsupported type map[string]string"},
+		{"struct field", struct {
+			Page page `query:"page"`
+		}{}, "query field Page: unsupported type builder.page"},
+		{"slice of structs", struct {
+			Pages []page `query:"page"`
+		}{[]page{{}}}, "query field Pages: unsupported type builder.page"},
+		{"not a struct", 3, "query struct must be a non-nil struct, got int"},
+		{"nil pointer", (*page)(nil), "query struct must be a non-nil struct, got *builder.page"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newBuilder().GET().Host("example.com").QueryParam("keep", "1").QueryStruct(tc.v).Build()
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Build error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package builder
+
+import (
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+func TestTypedQueryParams(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, tc := range []struct {
+		name string
+		rb   interfaces.IRequestBuilder
+		want string
+	}{
+		{"int", newBuilder().QueryParamInt("n", -3), "n=-3"},
+		{"bool", newBuilder().QueryParamBool("a", true).QueryParamBool("b", false), "a=true&b=false"},
+		{"time default layout", newBuilder().QueryParamTime("t", at, ""), "t=2024-01-02T03%3A04%3A05Z"},
+		{"time layout", newBuilder().QueryParamTime("t", at, time.DateOnly), "t=2024-01-02"},
+		{"slice repeat", newBuilder().QueryParamSlice("k", []string{"b", "a"}, interfaces.SliceRepeat), "k=b&k=a"},
+		{"slice comma", newBuilder().QueryParamSlice("k", []string{"b", "a"}, interfaces.SliceComma), "k=b%2Ca"},
+		{"slice pipe", newBuilder().QueryParamSlice("k", []string{"b", "a"}, interfaces.SlicePipe), "k=b%7Ca"},
+		{"slice single", newBuilder().QueryParamSlice("k", []string{"a"}, interfaces.SliceComma), "k=a"},
+		{"slice empty", newBuilder().QueryParamSlice("k", nil, interfaces.SliceComma), ""},
+		{"slice escapes values", newBuilder().QueryParamSlice("k", []string{"a b", "c&d"}, interfaces.SliceComma), "k=a+b%2Cc%26d"},
+		{"slice appends", newBuilder().QueryParam("k", "x").QueryParamSlice("k", []string{"b", "a"}, interfaces.SliceRepeat), "k=x&k=b&k=a"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			want := "https://example.com"
+			if tc.want != "" {
+				want += "?" + tc.want
+			}
+			// The encoding is the same on every build
+			for range 10 {
+				if got := mustBuild(t, tc.rb.GET().Host("example.com")).URL(); got != want {
+					t.Fatalf("URL = %s, want %s", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryParamSliceUnknownStyle(t *testing.T) {
+	if _, err := newBuilder().GET().Host("example.com").QueryParamSlice("k", []string{"a"}, interfaces.SliceStyle(9)).Build(); err == nil {
+		t.Fatal("Build succeeded with an unknown slice style")
+	}
+}
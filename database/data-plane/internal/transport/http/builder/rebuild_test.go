@@ -0,0 +1,79 @@
+package builder
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// oneShotReader hides the concrete reader type, so Body streams it rather
+// than copying it.
+type oneShotReader struct{ io.Reader }
+
+// readBody reads the body of a built request.
+func readBody(t *testing.T, request interfaces.IHTTPRequest) string {
+	t.Helper()
+	data, err := io.ReadAll(request.HTTPRequest().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBuildTwiceIndependent(t *testing.T) {
+	rb := newBuilder().POST().Host("example.com").Path("/a").Header("X-A", "1").QueryParam("q", "1").BodyString("one")
+	first := mustBuild(t, rb)
+
+	rb.Header("X-A", "2").AddPath("b").QueryParam("q", "2").BodyString("two").PUT()
+	second := mustBuild(t, rb)
+
+	if first.Method() != http.MethodPost || first.URL() != "https://example.com/a?q=1" {
+		t.Errorf("first request = %s %s, want it unaffected by later builder changes", first.Method(), first.URL())
+	}
+	if got := first.HTTPRequest().Header.Values("X-A"); len(got) != 1 || got[0] != "1" {
+		t.Errorf("first request X-A = %q, want only 1", got)
+	}
+	if second.Method() != http.MethodPut || second.URL() != "https://example.com/a/b?q=1&q=2" {
+		t.Errorf("second request = %s %s", second.Method(), second.URL())
+	}
+	if got, want := readBody(t, first), "one"; got != want {
+		t.Errorf("first body = %q, want %q", got, want)
+	}
+	if got, want := readBody(t, second), "two"; got != want {
+		t.Errorf("second body = %q, want %q", got, want)
+	}
+
+	// Changing a built request does not reach the builder
+	first.HTTPRequest().Header.Set("X-B", "leak")
+	if got := mustBuild(t, rb).Header("X-B"); got != "" {
+		t.Errorf("rebuilt request X-B = %q, want the headers not shared", got)
+	}
+}
+
+func TestBuildTwiceReplaysBytes(t *testing.T) {
+	rb := newBuilder().POST().Host("example.com").Body(strings.NewReader("payload"))
+	for i := range 2 {
+		if got := readBody(t, mustBuild(t, rb)); got != "payload" {
+			t.Errorf("build %d body = %q, want payload", i, got)
+		}
+	}
+}
+
+func TestBuildTwiceOneShotBody(t *testing.T) {
+	rb := newBuilder().POST().Host("example.com").Body(oneShotReader{strings.NewReader("payload")})
+	clone := rb.Clone()
+	mustBuild(t, rb)
+
+	if _, err := rb.Build(); !errors.Is(err, models.ErrBodyNotReplayable) {
+		t.Errorf("second Build = %v, want ErrBodyNotReplayable", err)
+	}
+	// A clone shares the reader, so it cannot build a second request either
+	if _, err := clone.Build(); !errors.Is(err, models.ErrBodyNotReplayable) {
+		t.Errorf("Build of a clone = %v, want ErrBodyNotReplayable", err)
+	}
+}
@@ -3,15 +3,26 @@ package builder
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"google.golang.org/protobuf/proto"
+
 	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/http/handler"
 	"data-plane/internal/transport/http/models"
 	"data-plane/internal/transport/interfaces"
 	"data-plane/internal/transport/middleware"
@@ -24,14 +35,27 @@ import (
 type RequestBuilder struct {
 	scheme      string
 	host        string
+	port        int
+	hostHeader  string
+	serverName  string
 	paths       []string
 	queryParams url.Values
 	headers     http.Header
 	body        io.Reader
+	bodyTaken   *atomic.Bool // Set once a request streams body; shared by clones
+	bodyBytes   []byte
+	bodyFunc    func() (io.ReadCloser, error)
+	replayable  bool
+	formParts   []interfaces.MultipartPart
+	userAgent   string
+	idemKey     string
+	autoIdemKey bool
 	method      string
 	timeout     time.Duration
 	ctx         context.Context
 	client      *http.Client
+	http3       bool
+	onProgress  func(offset, total int64)
 	err         error
 
 	// Factory for creating components (Dependency Injection)
@@ -70,8 +94,9 @@ func NewBuilderWithFactory(factory client.ClientFactory) interfaces.IRequestBuil
 	}
 }
 
-// Host sets the host for the request (e.g., "api.example.com").
-// The host should not include the scheme (http/https).
+// Host sets the host for the request (e.g., "api.example.com"), optionally
+// with a port (e.g., "localhost:9090"). The host should not include the
+// scheme (http/https); a path, query or fragment is rejected.
 func (rb *RequestBuilder) Host(host string) interfaces.IRequestBuilder {
 	if rb.err != nil {
 		return rb
@@ -84,10 +109,74 @@ func (rb *RequestBuilder) Host(host string) interfaces.IRequestBuilder {
 	host = strings.TrimPrefix(host, "http://")
 	host = strings.TrimPrefix(host, "https://")
 	// Remove trailing slash
-	rb.host = strings.TrimSuffix(host, "/")
+	host = strings.TrimSuffix(host, "/")
+	if strings.ContainsAny(host, "/?#") {
+		rb.err = fmt.Errorf("host must not contain a path, query or fragment, got: %s", host)
+		return rb
+	}
+	if _, port, err := net.SplitHostPort(host); err == nil {
+		if _, err := parsePort(port); err != nil {
+			rb.err = fmt.Errorf("invalid port in host %s: %w", host, err)
+			return rb
+		}
+	}
+	rb.host = host
 	return rb
 }
 
+// HostHeader sets the Host header sent, e.g. for routing through a load
+// balancer dialed by IP, while Host still sets where to connect. It does
+// not change the TLS server name; see ServerName.
+func (rb *RequestBuilder) HostHeader(host string) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if host == "" {
+		rb.err = fmt.Errorf("host header cannot be empty")
+		return rb
+	}
+	rb.hostHeader = host
+	return rb
+}
+
+// ServerName sets the TLS server name sent for SNI and used to verify the
+// server's certificate, instead of the host dialed. The client set with
+// Client, if any, must use an *http.Transport.
+func (rb *RequestBuilder) ServerName(sni string) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if sni == "" {
+		rb.err = fmt.Errorf("server name cannot be empty")
+		return rb
+	}
+	rb.serverName = sni
+	return rb
+}
+
+// Port sets the port to connect to, replacing any port given with Host.
+// Without it, the scheme's default port is used.
+func (rb *RequestBuilder) Port(port int) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if port < 1 || port > 65535 {
+		rb.err = fmt.Errorf("port must be between 1 and 65535, got: %d", port)
+		return rb
+	}
+	rb.port = port
+	return rb
+}
+
+// parsePort parses a port number in the range 1-65535.
+func parsePort(port string) (int, error) {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return 0, fmt.Errorf("port must be between 1 and 65535, got: %s", port)
+	}
+	return n, nil
+}
+
 // Scheme sets the URL scheme (http or https).
 // Defaults to https if not specified.
 func (rb *RequestBuilder) Scheme(scheme string) interfaces.IRequestBuilder {
@@ -102,17 +191,74 @@ func (rb *RequestBuilder) Scheme(scheme string) interfaces.IRequestBuilder {
 	return rb
 }
 
-// AddPath appends a path segment to the URL path.
-// Multiple calls will concatenate paths with proper "/" handling.
-// Leading and trailing slashes are handled automatically.
+// BaseURL sets the scheme, host, port and initial path from a full URL
+// such as "https://api.example.com:8443/v2". Later AddPath calls append
+// after its path, and its query parameters are added to any already set.
+// The URL must use http or https and must not carry credentials or a
+// fragment. A port in the URL replaces one set earlier with Port, which is
+// kept if the URL has none; a later Port call overrides either.
+func (rb *RequestBuilder) BaseURL(raw string) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		rb.err = fmt.Errorf("invalid base URL: %w", err)
+		return rb
+	}
+	if u.Host == "" {
+		rb.err = fmt.Errorf("base URL must be absolute, got: %s", raw)
+		return rb
+	}
+	if u.User != nil || u.Fragment != "" {
+		rb.err = fmt.Errorf("base URL must not contain credentials or a fragment")
+		return rb
+	}
+
+	rb.Scheme(u.Scheme)
+	rb.Host(u.Host)
+	rb.paths = nil
+	rb.AddRawPath(u.EscapedPath())
+	if u.Port() != "" {
+		rb.port = 0
+	}
+	for key, values := range u.Query() {
+		for _, value := range values {
+			rb.QueryParam(key, value)
+		}
+	}
+	return rb
+}
+
+// AddPath appends path to the URL path. It is split on "/" and each
+// segment escaped with url.PathEscape, so AddPath("users/42") adds two
+// segments and spaces, "?" or "%" in a segment are sent encoded. Empty
+// segments are dropped. Use AddRawPath to send a pre-encoded "/" (%2F)
+// inside a segment.
 func (rb *RequestBuilder) AddPath(path string) interfaces.IRequestBuilder {
 	if rb.err != nil {
 		return rb
 	}
-	if path == "" {
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			rb.paths = append(rb.paths, url.PathEscape(segment))
+		}
+	}
+	return rb
+}
+
+// AddRawPath appends an already percent-encoded path, which may contain
+// several "/"-separated segments, without escaping it again.
+// Leading and trailing slashes are trimmed.
+func (rb *RequestBuilder) AddRawPath(path string) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if _, err := url.PathUnescape(path); err != nil {
+		rb.err = fmt.Errorf("invalid raw path %q: %w", path, err)
 		return rb
 	}
-	// Clean the path segment
+	// Clean the path
 	path = strings.Trim(path, "/")
 	if path != "" {
 		rb.paths = append(rb.paths, path)
@@ -121,7 +267,8 @@ func (rb *RequestBuilder) AddPath(path string) interfaces.IRequestBuilder {
 }
 
 // Path sets the complete path, replacing any previously added paths.
-// This is useful when you want to set the entire path at once.
+// The path is split on "/" and each segment escaped as by AddPath, so
+// Path("/posts/1") adds the segments "posts" and "1".
 func (rb *RequestBuilder) Path(path string) interfaces.IRequestBuilder {
 	if rb.err != nil {
 		return rb
@@ -140,19 +287,73 @@ func (rb *RequestBuilder) QueryParam(key, value string) interfaces.IRequestBuild
 	return rb
 }
 
-// QueryParams sets multiple query parameters at once.
-// This replaces any previously set query parameters.
+// QueryParamInt adds a query parameter holding an integer.
+func (rb *RequestBuilder) QueryParamInt(key string, v int) interfaces.IRequestBuilder {
+	return rb.QueryParam(key, strconv.Itoa(v))
+}
+
+// QueryParamBool adds a query parameter holding "true" or "false".
+func (rb *RequestBuilder) QueryParamBool(key string, v bool) interfaces.IRequestBuilder {
+	return rb.QueryParam(key, strconv.FormatBool(v))
+}
+
+// QueryParamTime adds a query parameter holding t formatted with layout,
+// or with time.RFC3339 if layout is empty.
+func (rb *RequestBuilder) QueryParamTime(key string, t time.Time, layout string) interfaces.IRequestBuilder {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return rb.QueryParam(key, t.Format(layout))
+}
+
+// QueryParamSlice adds a query parameter holding values, in order: the key
+// repeated for each value (SliceRepeat), or once with the values joined by
+// commas (SliceComma) or pipes (SlicePipe). The separators are
+// percent-encoded like any other character. An empty slice adds nothing.
+func (rb *RequestBuilder) QueryParamSlice(key string, values []string, style interfaces.SliceStyle) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if len(values) == 0 {
+		return rb
+	}
+	switch style {
+	case interfaces.SliceRepeat:
+		for _, value := range values {
+			rb.queryParams.Add(key, value)
+		}
+	case interfaces.SliceComma:
+		rb.queryParams.Add(key, strings.Join(values, ","))
+	case interfaces.SlicePipe:
+		rb.queryParams.Add(key, strings.Join(values, "|"))
+	default:
+		rb.err = fmt.Errorf("unknown slice style %d for query parameter %q", style, key)
+	}
+	return rb
+}
+
+// QueryParams sets multiple query parameters at once. Each key in params
+// replaces the values set earlier for that key; other keys are kept.
+// Call ClearQueryParams first to replace them all instead.
 func (rb *RequestBuilder) QueryParams(params map[string]string) interfaces.IRequestBuilder {
 	if rb.err != nil {
 		return rb
 	}
-	rb.queryParams = url.Values{}
 	for key, value := range params {
 		rb.queryParams.Set(key, value)
 	}
 	return rb
 }
 
+// ClearQueryParams removes every query parameter set so far.
+func (rb *RequestBuilder) ClearQueryParams() interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	rb.queryParams = url.Values{}
+	return rb
+}
+
 // Header adds a header to the request.
 // Multiple values for the same header are supported.
 func (rb *RequestBuilder) Header(key, value string) interfaces.IRequestBuilder {
@@ -163,22 +364,37 @@ func (rb *RequestBuilder) Header(key, value string) interfaces.IRequestBuilder {
 	return rb
 }
 
-// Headers sets multiple headers at once.
-// This replaces any previously set headers.
+// Headers sets multiple headers at once. Each header in headers replaces
+// the values set earlier for it, e.g. by BearerToken or JSON; other
+// headers are kept. Call ClearHeaders first to replace them all instead.
 func (rb *RequestBuilder) Headers(headers map[string]string) interfaces.IRequestBuilder {
 	if rb.err != nil {
 		return rb
 	}
-	rb.headers = http.Header{}
 	for key, value := range headers {
 		rb.headers.Set(key, value)
 	}
 	return rb
 }
 
-// ContentType sets the Content-Type header.
+// ClearHeaders removes every header set so far, including those set by
+// ContentType, Accept, the authorization methods and the body setters.
+func (rb *RequestBuilder) ClearHeaders() interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	rb.headers = http.Header{}
+	return rb
+}
+
+// ContentType sets the Content-Type header, replacing any previous value,
+// e.g. to add a charset parameter after JSON or BodyXML.
 func (rb *RequestBuilder) ContentType(contentType string) interfaces.IRequestBuilder {
-	return rb.Header("Content-Type", contentType)
+	if rb.err != nil {
+		return rb
+	}
+	rb.headers.Set("Content-Type", contentType)
+	return rb
 }
 
 // Accept sets the Accept header.
@@ -186,6 +402,77 @@ func (rb *RequestBuilder) Accept(accept string) interfaces.IRequestBuilder {
 	return rb.Header("Accept", accept)
 }
 
+// AcceptTypes sets the Accept header from several media ranges for content negotiation.
+// Each value may carry a q-weight parameter, e.g. "application/xml;q=0.8".
+// This replaces any previously set Accept header.
+func (rb *RequestBuilder) AcceptTypes(types ...string) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if len(types) == 0 {
+		rb.err = fmt.Errorf("at least one accept type is required")
+		return rb
+	}
+	for _, t := range types {
+		if _, _, err := mime.ParseMediaType(t); err != nil {
+			rb.err = fmt.Errorf("invalid accept type %q: %w", t, err)
+			return rb
+		}
+	}
+	rb.headers.Set("Accept", strings.Join(types, ", "))
+	return rb
+}
+
+// UserAgent sets the User-Agent header, unless the request sets one with
+// Header or Headers. It takes precedence over the client's default.
+func (rb *RequestBuilder) UserAgent(ua string) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if ua == "" {
+		rb.err = fmt.Errorf("user agent cannot be empty")
+		return rb
+	}
+	rb.userAgent = ua
+	return rb
+}
+
+// IfNoneMatch makes the request conditional on the resource no longer
+// matching etag, as returned in an earlier response's ETag header; "*"
+// matches any version. An unquoted tag is quoted. If the resource still
+// matches, the server answers 304 and response handlers report
+// models.ErrNotModified.
+func (rb *RequestBuilder) IfNoneMatch(etag string) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if etag == "" {
+		rb.err = fmt.Errorf("etag cannot be empty")
+		return rb
+	}
+	if etag != "*" && !strings.HasSuffix(etag, `"`) {
+		etag = strconv.Quote(etag)
+	}
+	rb.headers.Set("If-None-Match", etag)
+	return rb
+}
+
+// IfModifiedSince makes the request conditional on the resource having
+// changed after t, which is sent as an HTTP date with second precision.
+// If it has not, the server answers 304 and response handlers report
+// models.ErrNotModified.
+func (rb *RequestBuilder) IfModifiedSince(t time.Time) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if t.IsZero() {
+		rb.err = fmt.Errorf("if-modified-since time cannot be zero")
+		return rb
+	}
+	rb.headers.Set("If-Modified-Since", t.UTC().Format(http.TimeFormat))
+	return rb
+}
+
 // Authorization sets the Authorization header.
 func (rb *RequestBuilder) Authorization(token string) interfaces.IRequestBuilder {
 	return rb.Header("Authorization", token)
@@ -196,15 +483,64 @@ func (rb *RequestBuilder) BearerToken(token string) interfaces.IRequestBuilder {
 	return rb.Header("Authorization", fmt.Sprintf("Bearer %s", token))
 }
 
+// BasicAuth sets the Authorization header for HTTP Basic authentication,
+// as http.Request.SetBasicAuth does, replacing any previous value. The
+// credentials are not encrypted; use it over https. Like other
+// Authorization credentials, the encoded value is masked in error messages.
+func (rb *RequestBuilder) BasicAuth(username, password string) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if username == "" {
+		rb.err = fmt.Errorf("basic auth username cannot be empty")
+		return rb
+	}
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	rb.headers.Set("Authorization", "Basic "+credentials)
+	return rb
+}
+
 // Body sets the request body from an io.Reader.
+// The content of a *bytes.Reader, *bytes.Buffer or *strings.Reader is
+// copied immediately, so every request built can be retried; any other
+// reader is streamed once, so only one request can be built with it, and
+// retrying that request fails with models.ErrBodyNotReplayable. Use
+// BodyFunc to retry streamed bodies.
 func (rb *RequestBuilder) Body(body io.Reader) interfaces.IRequestBuilder {
 	if rb.err != nil {
 		return rb
 	}
-	rb.body = body
+	if len(rb.formParts) > 0 {
+		rb.err = errMixedMultipart
+		return rb
+	}
+	rb.body, rb.bodyBytes = body, nil
+	if data, ok := byteBacked(body); ok {
+		rb.body, rb.bodyBytes = nil, data
+	}
+	rb.bodyTaken = new(atomic.Bool)
+	rb.bodyFunc = nil
 	return rb
 }
 
+// byteBacked returns the unread content of an in-memory reader without
+// consuming it, reporting false for other readers.
+func byteBacked(body io.Reader) ([]byte, bool) {
+	switch reader := body.(type) {
+	case *bytes.Buffer:
+		return append([]byte{}, reader.Bytes()...), true
+	case *bytes.Reader:
+		snapshot := *reader
+		data, _ := io.ReadAll(&snapshot)
+		return data, true
+	case *strings.Reader:
+		snapshot := *reader
+		data, _ := io.ReadAll(&snapshot)
+		return data, true
+	}
+	return nil, false
+}
+
 // BodyBytes sets the request body from a byte slice.
 func (rb *RequestBuilder) BodyBytes(data []byte) interfaces.IRequestBuilder {
 	return rb.Body(bytes.NewReader(data))
@@ -230,6 +566,171 @@ func (rb *RequestBuilder) JSON(v interface{}) interfaces.IRequestBuilder {
 	return rb.BodyBytes(data)
 }
 
+// BodyXML sets the request body from an XML-encodable object.
+// It automatically sets the Content-Type to application/xml.
+func (rb *RequestBuilder) BodyXML(v interface{}) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	data, err := xml.Marshal(v)
+	if err != nil {
+		rb.err = fmt.Errorf("failed to marshal XML body: %w", err)
+		return rb
+	}
+	rb.ContentType("application/xml")
+	return rb.BodyBytes(data)
+}
+
+// BodyProto sets the request body from a protobuf message in the binary
+// wire format. It automatically sets the Content-Type to application/x-protobuf.
+func (rb *RequestBuilder) BodyProto(msg proto.Message) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if msg == nil {
+		rb.err = fmt.Errorf("protobuf message cannot be nil")
+		return rb
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		rb.err = fmt.Errorf("failed to marshal protobuf body: %w", err)
+		return rb
+	}
+	rb.ContentType(handler.ProtoContentType)
+	return rb.BodyBytes(data)
+}
+
+// BodyFunc streams the request body from the reader open returns, with
+// chunked transfer encoding, so the body is never held in memory.
+// open is called again to replay the body when the request is retried.
+func (rb *RequestBuilder) BodyFunc(open func() (io.ReadCloser, error)) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if open == nil {
+		rb.err = fmt.Errorf("body function cannot be nil")
+		return rb
+	}
+	if len(rb.formParts) > 0 {
+		rb.err = errMixedMultipart
+		return rb
+	}
+	rb.body, rb.bodyBytes = nil, nil
+	rb.bodyFunc = open
+	rb.replayable = true
+	return rb
+}
+
+// Multipart streams a multipart/form-data body built from parts, copying
+// each part from its reader while the request is sent. The Content-Type
+// header, boundary included, is set immediately. The request can be retried
+// only if every part has an Open function.
+func (rb *RequestBuilder) Multipart(parts ...interfaces.MultipartPart) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	body := models.NewMultipartBody(parts...)
+	rb.ContentType(body.ContentType())
+	rb.BodyFunc(body.Open)
+	rb.replayable = body.Replayable()
+	return rb
+}
+
+// errMixedMultipart is reported when a chain sets both a body and
+// MultipartField or MultipartFile parts.
+var errMixedMultipart = errors.New("cannot combine multipart fields with another request body")
+
+// MultipartField adds a plain form value to a multipart/form-data body that
+// Build assembles, setting the Content-Type header, boundary included.
+// The parts are held in memory by the builder and encoded again for every
+// request built or retried. It cannot be combined with Body, JSON or the
+// other body setters.
+func (rb *RequestBuilder) MultipartField(name, value string) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if name == "" {
+		rb.err = fmt.Errorf("multipart field name cannot be empty")
+		return rb
+	}
+	return rb.addFormPart(models.FormField(name, value))
+}
+
+// MultipartFile adds a file read from r to a multipart/form-data body that
+// Build assembles, like MultipartField. r is read to the end when
+// MultipartFile is called and the whole file is kept in memory for as long
+// as the builder and its requests, so the body can be replayed when the
+// request is retried; use Multipart with a FilePart to stream large files
+// instead.
+func (rb *RequestBuilder) MultipartFile(fieldName, fileName string, r io.Reader) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if fieldName == "" {
+		rb.err = fmt.Errorf("multipart field name cannot be empty")
+		return rb
+	}
+	if r == nil {
+		rb.err = fmt.Errorf("multipart file reader cannot be nil")
+		return rb
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		rb.err = fmt.Errorf("failed to read multipart file %q: %w", fileName, err)
+		return rb
+	}
+	return rb.addFormPart(models.FilePart(fieldName, fileName, func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}))
+}
+
+// addFormPart appends part to the multipart body Build assembles.
+func (rb *RequestBuilder) addFormPart(part interfaces.MultipartPart) interfaces.IRequestBuilder {
+	if rb.body != nil || rb.bodyBytes != nil || rb.bodyFunc != nil {
+		rb.err = errMixedMultipart
+		return rb
+	}
+	rb.formParts = append(rb.formParts, part)
+	return rb
+}
+
+// GraphQL makes the request a POST of the standard GraphQL envelope,
+// {"query": ..., "variables": ...}, accepting a JSON response.
+// Pair it with the GraphQL response handler to unwrap the result.
+func (rb *RequestBuilder) GraphQL(query string, variables map[string]interface{}) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if query == "" {
+		rb.err = fmt.Errorf("GraphQL query cannot be empty")
+		return rb
+	}
+	return rb.graphQL(models.GraphQLRequest{Query: query, Variables: variables})
+}
+
+// PersistedQuery makes the request a POST of a GraphQL automatic persisted
+// query: the envelope carries the SHA-256 hash of query in the
+// persistedQuery extension instead of the query text.
+func (rb *RequestBuilder) PersistedQuery(query string, variables map[string]interface{}) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if query == "" {
+		rb.err = fmt.Errorf("GraphQL query cannot be empty")
+		return rb
+	}
+	return rb.graphQL(models.NewPersistedQuery(query, variables))
+}
+
+// graphQL sets up a POST of the given GraphQL envelope.
+func (rb *RequestBuilder) graphQL(request models.GraphQLRequest) interfaces.IRequestBuilder {
+	rb.method = http.MethodPost
+	if rb.headers.Get("Accept") == "" {
+		rb.Accept("application/json")
+	}
+	return rb.JSON(request)
+}
+
 // Timeout sets the request timeout duration.
 func (rb *RequestBuilder) Timeout(timeout time.Duration) interfaces.IRequestBuilder {
 	if rb.err != nil {
@@ -267,20 +768,50 @@ func (rb *RequestBuilder) Client(client *http.Client) interfaces.IRequestBuilder
 	return rb
 }
 
+// Clone returns an independent copy of the builder, so a base configuration
+// can be shared by many requests: paths, query parameters, headers and
+// middlewares added to the copy do not affect the original, or the other
+// way round. The circuit breaker, rate limiter and bulkhead are shared, so
+// their limits apply across all copies, as are the context, the client and
+// a body streamed from a reader set with Body, which can be read only once.
+func (rb *RequestBuilder) Clone() interfaces.IRequestBuilder {
+	clone := *rb
+	clone.paths = slices.Clone(rb.paths)
+	clone.queryParams = url.Values{}
+	for key, values := range rb.queryParams {
+		clone.queryParams[key] = slices.Clone(values)
+	}
+	clone.headers = rb.headers.Clone()
+	clone.formParts = slices.Clone(rb.formParts)
+	clone.middlewares = slices.Clone(rb.middlewares)
+	return &clone
+}
+
 // buildURL constructs the complete URL from the builder's components.
 func (rb *RequestBuilder) buildURL() (string, error) {
 	if rb.host == "" {
 		return "", fmt.Errorf("host is required")
 	}
 
+	host := rb.host
+	if rb.port != 0 {
+		hostname := host
+		if name, _, err := net.SplitHostPort(host); err == nil {
+			hostname = name
+		}
+		host = net.JoinHostPort(strings.Trim(hostname, "[]"), strconv.Itoa(rb.port))
+	}
+
 	u := &url.URL{
 		Scheme: rb.scheme,
-		Host:   rb.host,
+		Host:   host,
 	}
 
 	// Build path
+	// The segments are escaped already; Path is their decoded form
 	if len(rb.paths) > 0 {
-		u.Path = "/" + strings.Join(rb.paths, "/")
+		u.RawPath = "/" + strings.Join(rb.paths, "/")
+		u.Path, _ = url.PathUnescape(u.RawPath)
 	}
 
 	// Add query parameters
@@ -293,6 +824,9 @@ func (rb *RequestBuilder) buildURL() (string, error) {
 
 // Build constructs the IHTTPRequest object.
 // Returns an error if any required fields are missing or invalid.
+// Each request is independent of the builder and of the other requests
+// built, so the builder can be changed and built again; only a body
+// streamed from a one-shot reader (see Body) cannot be built twice.
 func (rb *RequestBuilder) Build() (interfaces.IHTTPRequest, error) {
 	if rb.err != nil {
 		return nil, rb.err
@@ -307,13 +841,63 @@ func (rb *RequestBuilder) Build() (interfaces.IHTTPRequest, error) {
 		return nil, fmt.Errorf("failed to build URL: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(rb.ctx, rb.method, urlStr, rb.body)
+	if rb.serverName != "" {
+		if err := client.CheckServerName(rb.client); err != nil {
+			return nil, err
+		}
+	}
+
+	headers := rb.headers.Clone()
+	if _, set := headers["User-Agent"]; !set && rb.userAgent != "" {
+		headers.Set("User-Agent", rb.userAgent)
+	}
+	if headers.Get(models.IdempotencyKeyHeader) == "" {
+		switch {
+		case rb.idemKey != "":
+			headers.Set(models.IdempotencyKeyHeader, rb.idemKey)
+		case rb.autoIdemKey:
+			headers.Set(models.IdempotencyKeyHeader, models.NewIdempotencyKey())
+		}
+	}
+	body, bodyFunc, replayable := rb.body, rb.bodyFunc, rb.replayable
+	if body != nil && !rb.bodyTaken.CompareAndSwap(false, true) {
+		// A second request would share the reader the first one consumes
+		return nil, fmt.Errorf("%w: the body reader was used by an earlier Build", models.ErrBodyNotReplayable)
+	}
+	if rb.bodyBytes != nil {
+		// A fresh reader per request; http.NewRequest sets GetBody for it
+		body = bytes.NewReader(rb.bodyBytes)
+	}
+	if len(rb.formParts) > 0 {
+		form := models.NewMultipartBody(rb.formParts...)
+		headers.Set("Content-Type", form.ContentType())
+		bodyFunc, replayable = form.Open, true
+	}
+	if bodyFunc != nil {
+		stream, err := bodyFunc()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open request body: %w", err)
+		}
+		body = stream
+	}
+
+	httpReq, err := http.NewRequestWithContext(rb.ctx, rb.method, urlStr, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	if bodyFunc != nil {
+		// Unknown length: sent with chunked transfer encoding
+		httpReq.ContentLength = -1
+		if replayable {
+			httpReq.GetBody = bodyFunc
+		}
+	}
 
 	// Copy headers to request
-	httpReq.Header = rb.headers.Clone()
+	httpReq.Header = headers
+	if rb.hostHeader != "" {
+		httpReq.Host = rb.hostHeader
+	}
 
 	return &models.Request{
 		HTTPReq:    httpReq,
@@ -372,6 +956,33 @@ func (rb *RequestBuilder) WithBulkhead(maxConcurrency int) interfaces.IRequestBu
 	return rb
 }
 
+// WithIdempotencyKey sends key in the Idempotency-Key header, so a server
+// can recognise a retried POST it has already processed instead of, for
+// example, creating the resource twice. Every retry carries the same key.
+func (rb *RequestBuilder) WithIdempotencyKey(key string) interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if key == "" {
+		rb.err = fmt.Errorf("idempotency key cannot be empty")
+		return rb
+	}
+	rb.idemKey = key
+	rb.autoIdemKey = false
+	return rb
+}
+
+// WithAutoIdempotencyKey sends a random UUID in the Idempotency-Key header,
+// generated once per Build, so every retry of the request carries the same
+// key. A key set with WithIdempotencyKey or Header takes precedence.
+func (rb *RequestBuilder) WithAutoIdempotencyKey() interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	rb.autoIdemKey = rb.idemKey == ""
+	return rb
+}
+
 // WithLogging enables request/response logging.
 func (rb *RequestBuilder) WithLogging() interfaces.IRequestBuilder {
 	if rb.err != nil {
@@ -390,6 +1001,23 @@ func (rb *RequestBuilder) WithMetrics() interfaces.IRequestBuilder {
 	return rb
 }
 
+// WithHTTP3 sends the request over HTTP/3 (QUIC), falling back to HTTP/2 or
+// HTTP/1.1 if the QUIC handshake fails. Timeouts, retries and the TLS
+// configuration of a client set with Client apply unchanged.
+// Requires building with the http3 tag; otherwise Build reports
+// client.ErrHTTP3Unsupported.
+func (rb *RequestBuilder) WithHTTP3() interfaces.IRequestBuilder {
+	if rb.err != nil {
+		return rb
+	}
+	if !client.HTTP3Supported() {
+		rb.err = client.ErrHTTP3Unsupported
+		return rb
+	}
+	rb.http3 = true
+	return rb
+}
+
 // WithMiddleware adds custom middleware to the request.
 func (rb *RequestBuilder) WithMiddleware(middleware interfaces.IMiddleware) interfaces.IRequestBuilder {
 	if rb.err != nil {
@@ -414,7 +1042,7 @@ func (rb *RequestBuilder) Execute() (interfaces.IHTTPResponse, error) {
 		}
 	}
 
-	httpClient := rb.client
+	httpClient := rb.httpClient()
 	if httpClient == nil {
 		httpClient = &http.Client{
 			Timeout: rb.timeout,
@@ -483,6 +1111,20 @@ func (rb *RequestBuilder) DELETE() interfaces.IRequestBuilder {
 	return rb
 }
 
+// HEAD sets the HTTP method to HEAD and returns the builder.
+// Response handlers do not read the body of a HEAD response.
+func (rb *RequestBuilder) HEAD() interfaces.IRequestBuilder {
+	rb.method = http.MethodHead
+	return rb
+}
+
+// OPTIONS sets the HTTP method to OPTIONS and returns the builder.
+// Call Build() after this to create the request.
+func (rb *RequestBuilder) OPTIONS() interfaces.IRequestBuilder {
+	rb.method = http.MethodOptions
+	return rb
+}
+
 // Method sets a custom HTTP method and returns the builder.
 // Call Build() after this to create the request.
 func (rb *RequestBuilder) Method(method string) interfaces.IRequestBuilder {
@@ -547,12 +1189,40 @@ func (rb *RequestBuilder) Async() <-chan interfaces.AsyncResult {
 	return resultChan
 }
 
+// httpClient returns the *http.Client requests are sent with: the configured
+// client, or a new one with the builder's timeout, set up for the TLS server
+// name and switched to HTTP/3 if requested.
+func (rb *RequestBuilder) httpClient() *http.Client {
+	httpClient := rb.client
+	if rb.serverName == "" && !rb.http3 {
+		return httpClient
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout: rb.timeout,
+		}
+	}
+	if rb.serverName != "" {
+		// Build has checked that the transport supports it
+		if sniClient, err := client.WithServerName(httpClient, rb.serverName); err == nil {
+			httpClient = sniClient
+		}
+	}
+	if !rb.http3 {
+		return httpClient
+	}
+	if h3Client, err := client.WithHTTP3(httpClient); err == nil {
+		return h3Client
+	}
+	return httpClient
+}
+
 // createClientWithResiliency creates an HTTPClient with all configured resiliency features.
 // Uses the Decorator pattern to wrap the base client with resiliency layers.
 // This follows the Single Responsibility Principle - each decorator has one job.
 func (rb *RequestBuilder) createClientWithResiliency() interfaces.IHTTPClient {
 	// 1. Create base HTTP client (single responsibility: HTTP calls only)
-	httpClient := rb.factory.CreateHTTPClient(rb.client, rb.timeout)
+	httpClient := rb.factory.CreateHTTPClient(rb.httpClient(), rb.timeout)
 
 	// 2. Apply decorators in order (innermost to outermost):
 	// Order matters: Middleware → Rate Limit → Bulkhead → Circuit Breaker → Retry → Logging/Metrics
@@ -0,0 +1,99 @@
+package builder
+
+import (
+	"testing"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// mustBuild builds rb, failing the test on error.
+func mustBuild(t *testing.T, rb interfaces.IRequestBuilder) interfaces.IHTTPRequest {
+	t.Helper()
+	request, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	return request
+}
+
+// newBuilder returns a new *RequestBuilder, for the methods only it has.
+func newBuilder() *RequestBuilder {
+	return NewBuilder().(*RequestBuilder)
+}
+
+func TestQueryParamsMerge(t *testing.T) {
+	request := mustBuild(t, NewBuilder().GET().Host("example.com").
+		QueryParam("key", "k").
+		QueryParam("tag", "a").
+		QueryParam("tag", "b").
+		QueryParams(map[string]string{"page": "2"}).
+		QueryParams(map[string]string{"page": "3", "sort": "name"}))
+
+	// Encoded in key order; repeated values keep the order they were added in
+	if got, want := request.URL(), "https://example.com?key=k&page=3&sort=name&tag=a&tag=b"; got != want {
+		t.Fatalf("URL = %s, want %s", got, want)
+	}
+}
+
+func TestQueryParamsReplaceKey(t *testing.T) {
+	request := mustBuild(t, NewBuilder().GET().Host("example.com").
+		QueryParam("tag", "a").
+		QueryParam("tag", "b").
+		QueryParams(map[string]string{"tag": "c"}))
+
+	if got, want := request.URL(), "https://example.com?tag=c"; got != want {
+		t.Fatalf("URL = %s, want %s", got, want)
+	}
+}
+
+func TestClearQueryParams(t *testing.T) {
+	request := mustBuild(t, NewBuilder().GET().Host("example.com").
+		QueryParam("key", "k").
+		ClearQueryParams().
+		QueryParams(map[string]string{"page": "1"}))
+
+	if got, want := request.URL(), "https://example.com?page=1"; got != want {
+		t.Fatalf("URL = %s, want %s", got, want)
+	}
+}
+
+func TestHeadersMerge(t *testing.T) {
+	request := mustBuild(t, NewBuilder().GET().Host("example.com").
+		BearerToken("token").
+		Header("X-Trace", "1").
+		Header("X-Trace", "2").
+		Headers(map[string]string{"authorization": "Bearer other", "X-Extra": "yes"}))
+
+	headers := request.Headers()
+	if got := headers.Values("Authorization"); len(got) != 1 || got[0] != "Bearer other" {
+		t.Errorf("Authorization = %q, want only the value from Headers", got)
+	}
+	if got := headers.Values("X-Trace"); len(got) != 2 || got[0] != "1" || got[1] != "2" {
+		t.Errorf("X-Trace = %q, want both values in order", got)
+	}
+	if got := headers.Get("X-Extra"); got != "yes" {
+		t.Errorf("X-Extra = %q", got)
+	}
+}
+
+func TestHeadersReplaceContentType(t *testing.T) {
+	request := mustBuild(t, NewBuilder().POST().Host("example.com").
+		JSON(map[string]int{"a": 1}).
+		Headers(map[string]string{"Content-Type": "application/vnd.api+json"}))
+
+	if got := request.Headers().Values("Content-Type"); len(got) != 1 || got[0] != "application/vnd.api+json" {
+		t.Fatalf("Content-Type = %q, want one value", got)
+	}
+}
+
+func TestClearHeaders(t *testing.T) {
+	request := mustBuild(t, NewBuilder().GET().Host("example.com").
+		Header("X-Trace", "1").
+		ClearHeaders().
+		Headers(map[string]string{"X-Extra": "yes"}))
+
+	headers := request.Headers()
+	if headers.Get("X-Trace") != "" || headers.Get("X-Extra") != "yes" {
+		t.Fatalf("headers = %v", headers)
+	}
+}
@@ -0,0 +1,126 @@
+package builder
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/interfaces"
+	"data-plane/internal/transport/transporttest"
+)
+
+// These tests repeat the request builder tests against a transporttest stub
+// server, checking the requests that are sent rather than the built request.
+
+// stubBuilder returns a builder addressing srv.
+func stubBuilder(srv *transporttest.StubServer) interfaces.IRequestBuilder {
+	return NewBuilder().Scheme(srv.Scheme()).Host(srv.Host())
+}
+
+// mustSync sends rb, failing the test on error.
+func mustSync(t *testing.T, rb interfaces.IRequestBuilder) interfaces.IHTTPResponse {
+	t.Helper()
+	resp, err := rb.Sync()
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	t.Cleanup(func() { resp.Close() })
+	return resp
+}
+
+func TestStubQueryParamsMerge(t *testing.T) {
+	srv := transporttest.NewStubServer(t)
+	srv.Expect("GET", "/items").
+		WithQuery("key", "k").
+		WithQuery("page", "3").
+		WithQuery("sort", "name").
+		WithQuery("tag", "a").
+		WithQuery("tag", "b").
+		Reply(http.StatusOK, nil)
+
+	mustSync(t, stubBuilder(srv).GET().Path("/items").
+		QueryParam("key", "k").
+		QueryParam("tag", "a").
+		QueryParam("tag", "b").
+		QueryParams(map[string]string{"page": "2"}).
+		QueryParams(map[string]string{"page": "3", "sort": "name"}))
+	srv.Verify(t)
+}
+
+func TestStubQueryParamsReplaceKey(t *testing.T) {
+	srv := transporttest.NewStubServer(t)
+	srv.Expect("GET", "/items").WithQuery("tag", "c").Reply(http.StatusOK, nil)
+
+	mustSync(t, stubBuilder(srv).GET().Path("/items").
+		QueryParam("tag", "a").
+		QueryParam("tag", "b").
+		QueryParams(map[string]string{"tag": "c"}))
+	srv.Verify(t)
+}
+
+func TestStubHeadersMerge(t *testing.T) {
+	srv := transporttest.NewStubServer(t)
+	srv.Expect("GET", "/me").
+		WithHeader("Authorization", "Bearer other").
+		WithHeader("X-Trace", "1").
+		WithHeader("X-Trace", "2").
+		WithHeader("X-Extra", "yes").
+		ReplyJSON(http.StatusOK, map[string]string{"name": "ada"})
+
+	resp := mustSync(t, stubBuilder(srv).GET().Path("/me").
+		BearerToken("token").
+		Header("X-Trace", "1").
+		Header("X-Trace", "2").
+		Headers(map[string]string{"authorization": "Bearer other", "X-Extra": "yes"}))
+	if got := resp.Header("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want the stub's JSON reply", got)
+	}
+	srv.Verify(t)
+}
+
+func TestStubHeadersReplaceContentType(t *testing.T) {
+	srv := transporttest.NewStubServer(t)
+	srv.Expect("POST", "/items").WithHeader("Content-Type", "application/vnd.api+json").Reply(http.StatusCreated, nil)
+
+	mustSync(t, stubBuilder(srv).POST().Path("/items").
+		JSON(map[string]int{"a": 1}).
+		Headers(map[string]string{"Content-Type": "application/vnd.api+json"}))
+	srv.Verify(t)
+}
+
+func TestStubRetrySequence(t *testing.T) {
+	srv := transporttest.NewStubServer(t)
+	users := srv.Expect("GET", "/users/1").
+		WithHeader("Authorization", "Bearer x").
+		Reply(http.StatusServiceUnavailable, nil).
+		Reply(http.StatusBadGateway, nil).
+		ReplyJSON(http.StatusOK, map[string]string{"name": "ada"})
+
+	resp := mustSync(t, stubBuilder(srv).GET().Path("/users/1").
+		BearerToken("x").
+		WithRetryPolicy(fastRetry{attempts: 3}))
+	if resp.StatusCode() != http.StatusOK || users.Calls() != 3 {
+		t.Errorf("status %d after %d calls, want 200 after 3", resp.StatusCode(), users.Calls())
+	}
+	srv.Verify(t)
+}
+
+func TestStubTimeout(t *testing.T) {
+	srv := transporttest.NewStubServer(t)
+	srv.Expect("GET", "/slow").WithLatency(time.Second).Reply(http.StatusOK, nil)
+
+	start := time.Now()
+	if _, err := stubBuilder(srv).GET().Path("/slow").Timeout(50 * time.Millisecond).Sync(); err == nil {
+		t.Fatal("Sync succeeded despite the latency exceeding the timeout")
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("Sync took %v, want it to give up after the 50ms timeout", elapsed)
+	}
+}
+
+// fastRetry retries every failure without delay.
+type fastRetry struct{ attempts int }
+
+func (p fastRetry) ShouldRetry(err error, attempt int) bool { return attempt < p.attempts }
+func (p fastRetry) GetDelay(attempt int) time.Duration      { return 0 }
+func (p fastRetry) MaxAttempts() int                        { return p.attempts }
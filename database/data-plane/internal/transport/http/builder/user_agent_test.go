@@ -0,0 +1,60 @@
+package builder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"data-plane/internal/transport/http/client"
+)
+
+func TestUserAgentPrecedence(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.UserAgent()
+	}))
+	defer server.Close()
+
+	c := client.NewHTTPClient().(*client.HTTPClient)
+	c.SetDefaultUserAgent("client/1.0")
+
+	for _, tc := range []struct {
+		name      string
+		userAgent string
+		header    string
+		want      string
+	}{
+		{"client default", "", "", "client/1.0"},
+		{"builder overrides client", "builder/1.0", "", "builder/1.0"},
+		{"header overrides builder", "builder/1.0", "header/1.0", "header/1.0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rb := NewBuilder().GET().Scheme("http").Host(server.Listener.Addr().String())
+			if tc.userAgent != "" {
+				rb = rb.UserAgent(tc.userAgent)
+			}
+			if tc.header != "" {
+				rb = rb.Header("user-agent", tc.header)
+			}
+			request := mustBuild(t, rb)
+			resp, err := c.Send(request)
+			if err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			resp.Close()
+			if got != tc.want {
+				t.Errorf("server saw User-Agent %q, want %q", got, tc.want)
+			}
+			// The client default is applied on the wire, not to the request
+			if tc.userAgent == "" && request.Header("User-Agent") != "" {
+				t.Errorf("request User-Agent = %q, want the client default left off it", request.Header("User-Agent"))
+			}
+		})
+	}
+}
+
+func TestUserAgentEmpty(t *testing.T) {
+	if _, err := NewBuilder().GET().Host("example.com").UserAgent("").Build(); err == nil {
+		t.Fatal("Build succeeded with an empty user agent")
+	}
+}
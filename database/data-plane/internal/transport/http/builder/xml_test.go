@@ -0,0 +1,59 @@
+package builder
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"data-plane/internal/transport/http/handler"
+)
+
+func TestBodyXMLRoundTrip(t *testing.T) {
+	// The server echoes the body with the content type it is switched to
+	var contentType, received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = strings.Join(r.Header.Values("Content-Type"), ", ")
+		w.Header().Set("Content-Type", contentType)
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer server.Close()
+	h := handler.NewResponseHandler().WithResponseType(negotiated{}).Build()
+
+	for _, tc := range []struct {
+		name     string
+		override string
+		response string
+	}{
+		{"application/xml", "", "application/xml"},
+		{"charset", "application/xml; charset=utf-8", "application/xml; charset=utf-8"},
+		{"text/xml response", "", "text/xml"},
+		{"text/xml response with charset", "", "text/xml; charset=utf-8"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			contentType = tc.response
+			rb := NewBuilder().POST().Scheme("http").Host(server.Listener.Addr().String()).BodyXML(negotiated{Name: "ada"})
+			want := "application/xml"
+			if tc.override != "" {
+				rb, want = rb.ContentType(tc.override), tc.override
+			}
+			v, err := h.Handle(mustSync(t, rb))
+			if err != nil {
+				t.Fatalf("Handle: %v", err)
+			}
+			if received != want {
+				t.Errorf("request Content-Type = %q, want %q", received, want)
+			}
+			if got := v.(negotiated); got.Name != "ada" || got.XMLName.Local != "item" {
+				t.Errorf("decoded %+v, want the echoed item", got)
+			}
+		})
+	}
+}
+
+func TestBodyXMLRejectsUnencodable(t *testing.T) {
+	if _, err := NewBuilder().POST().Host("example.com").BodyXML(map[string]string{"a": "b"}).Build(); err == nil {
+		t.Fatal("Build accepted a value XML cannot encode")
+	}
+}
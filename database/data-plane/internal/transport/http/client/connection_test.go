@@ -0,0 +1,97 @@
+package client
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"testing"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+	"data-plane/internal/transport/resiliency"
+)
+
+// closedPort returns the address of a port nothing listens on.
+func closedPort(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+// newResetServer reads each request, writes reply and then resets the
+// connection instead of closing it gracefully.
+func newResetServer(t *testing.T, reply string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if _, err := http.ReadRequest(bufio.NewReader(conn)); err == nil {
+				conn.Write([]byte(reply))
+			}
+			conn.(*net.TCPConn).SetLinger(0) // Close with RST
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// requireNetworkError asserts err is an HTTPError for which check holds,
+// retried by the default policy exactly when retryable.
+func requireNetworkError(t *testing.T, err error, check func(*models.HTTPError) bool, retryable bool) {
+	t.Helper()
+	httpErr, ok := err.(*models.HTTPError)
+	if !ok || !check(httpErr) {
+		t.Fatalf("error = %T %v, not the expected network failure", err, err)
+	}
+	if httpErr.Kind() != interfaces.KindConnection {
+		t.Errorf("Kind() = %v, want %v", httpErr.Kind(), interfaces.KindConnection)
+	}
+	if got := resiliency.NewRetryPolicy(3).ShouldRetry(err, 0); got != retryable {
+		t.Errorf("ShouldRetry() = %v, want %v", got, retryable)
+	}
+}
+
+func TestSendConnectionRefused(t *testing.T) {
+	_, err := NewHTTPClient().Send(newTestRequest(t, "http://"+closedPort(t)))
+	requireNetworkError(t, err, (*models.HTTPError).IsConnectionRefused, true)
+	if err.(*models.HTTPError).IsConnectionReset() {
+		t.Error("refused connection reported as reset")
+	}
+}
+
+func TestSendConnectionReset(t *testing.T) {
+	_, err := NewHTTPClient().Send(newTestRequest(t, "http://"+newResetServer(t, "")))
+	requireNetworkError(t, err, (*models.HTTPError).IsConnectionReset, true)
+	if err.(*models.HTTPError).IsConnectionRefused() {
+		t.Error("reset connection reported as refused")
+	}
+}
+
+func TestReadBodyConnectionReset(t *testing.T) {
+	partial := "HTTP/1.1 200 OK\r\nContent-Length: 1000\r\n\r\npartial"
+	resp, err := NewHTTPClient().Send(newTestRequest(t, "http://"+newResetServer(t, partial)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Close()
+
+	// Part of the response was consumed, so the request is not retried
+	_, err = resp.Body()
+	requireNetworkError(t, err, (*models.HTTPError).IsConnectionReset, false)
+	if stage := err.(*models.HTTPError).Stage(); stage != interfaces.StageReadBody {
+		t.Errorf("Stage() = %v, want %v", stage, interfaces.StageReadBody)
+	}
+}
@@ -0,0 +1,136 @@
+//go:build http3
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+const (
+	// http3Supported reports whether HTTP/3 support is compiled in.
+	http3Supported = true
+
+	// http3HandshakeTimeout bounds the QUIC handshake, so a network that
+	// blocks UDP costs at most this long before falling back.
+	http3HandshakeTimeout = 3 * time.Second
+
+	// http3BrokenFor is how long a host whose QUIC handshake failed is
+	// served by the fallback transport before QUIC is tried again.
+	http3BrokenFor = 5 * time.Minute
+)
+
+// http3RoundTripper sends requests over HTTP/3, falling back to another
+// transport for hosts whose QUIC handshake fails.
+type http3RoundTripper struct {
+	h3       *http3.Transport
+	fallback http.RoundTripper
+
+	mu     sync.Mutex
+	broken map[string]time.Time // host -> when QUIC may be retried
+}
+
+// quicDialError marks a failure to establish the QUIC connection, before
+// any part of the request was sent.
+type quicDialError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *quicDialError) Error() string {
+	return "QUIC handshake failed: " + e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *quicDialError) Unwrap() error {
+	return e.err
+}
+
+// newHTTP3RoundTripper creates an HTTP/3 round tripper that uses the TLS
+// configuration of fallback, if it is an *http.Transport.
+func newHTTP3RoundTripper(fallback http.RoundTripper) (http.RoundTripper, error) {
+	var tlsConfig *tls.Config
+	if transport, ok := fallback.(*http.Transport); ok && transport.TLSClientConfig != nil {
+		tlsConfig = transport.TLSClientConfig.Clone()
+	}
+
+	return &http3RoundTripper{
+		h3: &http3.Transport{
+			TLSClientConfig: tlsConfig,
+			QUICConfig:      &quic.Config{HandshakeIdleTimeout: http3HandshakeTimeout},
+			Dial: func(ctx context.Context, addr string, tlsConf *tls.Config, conf *quic.Config) (*quic.Conn, error) {
+				conn, err := quic.DialAddrEarly(ctx, addr, tlsConf, conf)
+				if err != nil {
+					return nil, &quicDialError{err: err}
+				}
+				return conn, nil
+			},
+		},
+		fallback: fallback,
+		broken:   make(map[string]time.Time),
+	}, nil
+}
+
+// RoundTrip sends HTTPS requests over HTTP/3 unless the host's QUIC
+// handshake failed recently; other requests use the fallback transport.
+func (rt *http3RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" || rt.isBroken(req.URL.Host) {
+		return rt.fallback.RoundTrip(req)
+	}
+
+	resp, err := rt.h3.RoundTrip(req)
+	var dialErr *quicDialError
+	if err == nil || !errors.As(err, &dialErr) || req.Context().Err() != nil {
+		return resp, err
+	}
+
+	// Nothing was sent: retry over the fallback with a fresh body
+	rt.markBroken(req.URL.Host)
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, err
+		}
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+	return rt.fallback.RoundTrip(req)
+}
+
+// isBroken reports whether host recently failed the QUIC handshake.
+func (rt *http3RoundTripper) isBroken(host string) bool {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	until, ok := rt.broken[host]
+	if ok && time.Now().After(until) {
+		delete(rt.broken, host)
+		return false
+	}
+	return ok
+}
+
+// markBroken serves host by the fallback transport for a while.
+func (rt *http3RoundTripper) markBroken(host string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.broken[host] = time.Now().Add(http3BrokenFor)
+}
+
+// CloseIdleConnections closes idle connections of both transports.
+func (rt *http3RoundTripper) CloseIdleConnections() {
+	rt.h3.CloseIdleConnections()
+	if closer, ok := rt.fallback.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !http3
+
+package client
+
+import "net/http"
+
+// http3Supported reports whether HTTP/3 support is compiled in.
+const http3Supported = false
+
+// newHTTP3RoundTripper reports that HTTP/3 support is not compiled in.
+func newHTTP3RoundTripper(fallback http.RoundTripper) (http.RoundTripper, error) {
+	return nil, ErrHTTP3Unsupported
+}
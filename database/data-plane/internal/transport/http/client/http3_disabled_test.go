@@ -0,0 +1,20 @@
+//go:build !http3
+
+package client
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHTTP3Unsupported(t *testing.T) {
+	if HTTP3Supported() {
+		t.Fatal("HTTP3Supported() = true without the http3 build tag")
+	}
+	if _, err := WithHTTP3(nil); !errors.Is(err, ErrHTTP3Unsupported) {
+		t.Errorf("WithHTTP3 error = %v, want ErrHTTP3Unsupported", err)
+	}
+	if err := NewHTTPClient().(*HTTPClient).EnableHTTP3(); !errors.Is(err, ErrHTTP3Unsupported) {
+		t.Errorf("EnableHTTP3 error = %v, want ErrHTTP3Unsupported", err)
+	}
+}
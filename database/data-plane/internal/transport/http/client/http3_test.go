@@ -0,0 +1,104 @@
+//go:build http3
+
+package client
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// newDualServer starts an HTTPS server and, if withQUIC is set, an HTTP/3
+// server on the same port, both answering with the protocol they served.
+func newDualServer(t *testing.T, withQUIC bool) *httptest.Server {
+	t.Helper()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Proto)
+	})
+	server := httptest.NewUnstartedServer(handler)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	if !withQUIC {
+		return server
+	}
+
+	udp, err := net.ListenPacket("udp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h3 := &http3.Server{
+		Handler:   handler,
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{Certificates: server.TLS.Certificates}),
+	}
+	go h3.Serve(udp)
+	t.Cleanup(func() {
+		h3.Close()
+		udp.Close()
+	})
+	return server
+}
+
+// newHTTP3Client returns a client trusting server that prefers HTTP/3.
+func newHTTP3Client(t *testing.T, server *httptest.Server) *HTTPClient {
+	t.Helper()
+	c := NewHTTPClientWithTimeout(10 * time.Second).(*HTTPClient)
+	c.SetHTTPClient(&http.Client{Transport: server.Client().Transport.(*http.Transport).Clone()})
+	if err := c.EnableHTTP3(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// protocolOf sends a request and returns the negotiated protocol and the
+// protocol the server saw.
+func protocolOf(t *testing.T, c *HTTPClient, url string) (negotiated, served string) {
+	t.Helper()
+	resp, err := c.Send(newTestRequest(t, url))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Close()
+	body, err := resp.Body()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Protocol(), string(body)
+}
+
+func TestHTTP3Negotiated(t *testing.T) {
+	server := newDualServer(t, true)
+	c := newHTTP3Client(t, server)
+
+	negotiated, served := protocolOf(t, c, server.URL)
+	if negotiated != "HTTP/3.0" || served != "HTTP/3.0" {
+		t.Errorf("protocol = %q, served %q; want HTTP/3.0", negotiated, served)
+	}
+}
+
+func TestHTTP3FallbackWhenQUICBlocked(t *testing.T) {
+	server := newDualServer(t, false)
+	c := newHTTP3Client(t, server)
+
+	for range 2 {
+		start := time.Now()
+		negotiated, served := protocolOf(t, c, server.URL)
+		if negotiated != "HTTP/1.1" || served != "HTTP/1.1" {
+			t.Errorf("protocol = %q, served %q; want the HTTP/1.1 fallback", negotiated, served)
+		}
+		if elapsed := time.Since(start); elapsed > http3HandshakeTimeout+time.Second {
+			t.Errorf("fallback took %v", elapsed)
+		}
+	}
+
+	// The host is now served by the fallback without trying QUIC again
+	rt := c.GetHTTPClient().Transport.(*http3RoundTripper)
+	if !rt.isBroken(server.Listener.Addr().String()) {
+		t.Error("host was not marked as QUIC-broken")
+	}
+}
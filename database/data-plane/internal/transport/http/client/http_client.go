@@ -14,8 +14,10 @@ import (
 // This follows the Single Responsibility Principle - it only performs HTTP calls.
 // Resiliency features (retry, circuit breaker, etc.) are handled by decorators.
 type HTTPClient struct {
-	httpClient *http.Client
-	timeout    time.Duration
+	httpClient     *http.Client
+	timeout        time.Duration
+	errorBodyLimit int
+	userAgent      string
 }
 
 // Ensure HTTPClient implements IHTTPClient interface
@@ -58,21 +60,36 @@ func (c *HTTPClient) Send(request interfaces.IHTTPRequest) (interfaces.IHTTPResp
 		}
 	}
 
-	// Create context with timeout if configured
+	// Apply the default User-Agent without touching the caller's headers
+	if _, set := httpReq.Header["User-Agent"]; !set && c.userAgent != "" {
+		httpReq = httpReq.Clone(httpReq.Context())
+		httpReq.Header.Set("User-Agent", c.userAgent)
+	}
+
+	// Create context with timeout if configured.
+	// The timeout must also cover reading the body, so cancellation is
+	// deferred until the body is closed rather than when Send returns.
 	ctx := httpReq.Context()
+	cancel := context.CancelFunc(func() {})
 	if c.timeout > 0 {
-		timeoutCtx, cancel := context.WithTimeout(ctx, c.timeout)
-		defer cancel()
+		var timeoutCtx context.Context
+		timeoutCtx, cancel = context.WithTimeout(ctx, c.timeout)
 		httpReq = httpReq.WithContext(timeoutCtx)
 	}
 
 	// Execute HTTP request
+	start := time.Now()
 	httpResp, err := c.httpClient.Do(httpReq)
 	if err != nil {
+		cancel()
 		return nil, &models.HTTPError{
-			Request: request,
-			Message: fmt.Sprintf("%s request failed", request.Method()),
-			Err:     err,
+			Request:   request,
+			Message:   models.NetworkErrorMessage(request.Method(), err),
+			Err:       err,
+			KindVal:   models.Classify(err),
+			StageVal:  interfaces.StageRequest,
+			RequestID: models.RequestIDOf(request, nil),
+			Duration:  time.Since(start),
 		}
 	}
 
@@ -80,14 +97,22 @@ func (c *HTTPClient) Send(request interfaces.IHTTPRequest) (interfaces.IHTTPResp
 		HttpResp:   httpResp,
 		RequestRef: request,
 	}
+	resp.BindCancel(cancel)
 
 	// Check for HTTP errors (4xx, 5xx)
 	if httpResp.StatusCode >= 400 {
+		snippet, truncated := models.CaptureBodySnippet(resp, c.snippetLimit())
 		return resp, &models.HTTPError{
-			Request:    request,
-			Response:   resp,
-			StatusCode: httpResp.StatusCode,
-			Message:    fmt.Sprintf("%s request returned error status %d", request.Method(), httpResp.StatusCode),
+			Request:     request,
+			Response:    resp,
+			StatusCode:  httpResp.StatusCode,
+			Message:     fmt.Sprintf("%s request returned error status %d", request.Method(), httpResp.StatusCode),
+			RetryAfter:  models.ParseRetryAfter(httpResp.Header.Get("Retry-After")),
+			KindVal:     models.ClassifyStatus(httpResp.StatusCode),
+			RequestID:   models.RequestIDOf(request, resp),
+			BodySnippet: snippet,
+			Truncated:   truncated,
+			Duration:    time.Since(start),
 		}
 	}
 
@@ -139,6 +164,27 @@ func (c *HTTPClient) SetHTTPClient(client *http.Client) {
 	}
 }
 
+// SetErrorBodyLimit sets how many bytes of an error response body are
+// captured on the HTTPError. Zero restores DefaultErrorBodyLimit and a
+// negative limit disables capturing, leaving the body unread.
+func (c *HTTPClient) SetErrorBodyLimit(limit int) {
+	c.errorBodyLimit = limit
+}
+
+// SetDefaultUserAgent sets the User-Agent header sent with requests that
+// do not set one, instead of Go's default. An empty value restores Go's.
+func (c *HTTPClient) SetDefaultUserAgent(userAgent string) {
+	c.userAgent = userAgent
+}
+
+// snippetLimit returns the effective error body capture limit.
+func (c *HTTPClient) snippetLimit() int {
+	if c.errorBodyLimit == 0 {
+		return models.DefaultErrorBodyLimit
+	}
+	return c.errorBodyLimit
+}
+
 // GetHTTPClient returns the underlying http.Client.
 func (c *HTTPClient) GetHTTPClient() *http.Client {
 	return c.httpClient
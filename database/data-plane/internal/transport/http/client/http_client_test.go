@@ -0,0 +1,138 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+
+	"data-plane/internal/transport/http/handler"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// newTestRequest returns a GET request for url.
+func newTestRequest(t *testing.T, url string) *models.Request {
+	t.Helper()
+	httpReq, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.Request{HTTPReq: httpReq}
+}
+
+func TestSendCapturesErrorBodySnippet(t *testing.T) {
+	body := strings.Repeat("e", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient().(*HTTPClient)
+	client.SetErrorBodyLimit(10)
+	resp, err := client.Send(newTestRequest(t, server.URL))
+	httpErr, ok := models.AsHTTPError(err)
+	if !ok {
+		t.Fatalf("Send = %v, want an HTTPError", err)
+	}
+	if string(httpErr.BodySnippet) != body[:10] || !httpErr.Truncated {
+		t.Fatalf("snippet = %q, truncated = %v", httpErr.BodySnippet, httpErr.Truncated)
+	}
+
+	// The response body still reads in full after the snippet was taken
+	got, readErr := resp.BodyString()
+	if readErr != nil || got != body {
+		t.Fatalf("BodyString = %q, %v, want the whole body", got, readErr)
+	}
+}
+
+func TestSendWithHandlerDiscardReusesConnection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 64<<10)))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient()
+	var reused []bool
+	for range 2 {
+		request := newTestRequest(t, server.URL)
+		trace := &httptrace.ClientTrace{GotConn: func(info httptrace.GotConnInfo) {
+			reused = append(reused, info.Reused)
+		}}
+		request.HTTPReq = request.HTTPReq.WithContext(httptrace.WithClientTrace(request.HTTPReq.Context(), trace))
+
+		v, err := client.SendWithHandler(request, handler.Discard())
+		if err != nil {
+			t.Fatal(err)
+		}
+		status := v.(handler.ResponseStatus)
+		if status.StatusCode != http.StatusOK || status.BytesDiscarded != 64<<10 {
+			t.Fatalf("status = %+v", status)
+		}
+	}
+	if len(reused) != 2 || !reused[1] {
+		t.Fatalf("connection reuse = %v, want the second request to reuse the first's connection", reused)
+	}
+}
+
+func TestSendWithHandlerStringAndBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("héllo"))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient()
+	if v, err := client.SendWithHandler(newTestRequest(t, server.URL), handler.String()); err != nil || v != "héllo" {
+		t.Fatalf("String = %#v, %v", v, err)
+	}
+	if v, err := client.SendWithHandler(newTestRequest(t, server.URL), handler.Bytes()); err != nil || string(v.([]byte)) != "héllo" {
+		t.Fatalf("Bytes = %#v, %v", v, err)
+	}
+
+	// The accepted status codes are honoured
+	if v, err := handler.String(http.StatusNotFound).Handle(mustSend(t, client, server.URL+"/missing")); err != nil || !strings.Contains(v.(string), "not found") {
+		t.Fatalf("String(404) = %#v, %v", v, err)
+	}
+	if _, err := handler.String(http.StatusOK).Handle(mustSend(t, client, server.URL+"/missing")); err == nil {
+		t.Fatal("String(200) accepted a 404 response")
+	}
+}
+
+// mustSend sends a GET to url and returns the response, whatever its status.
+func mustSend(t *testing.T, client interfaces.IHTTPClient, url string) interfaces.IHTTPResponse {
+	t.Helper()
+	resp, err := client.Send(newTestRequest(t, url))
+	if resp == nil {
+		t.Fatalf("Send: %v", err)
+	}
+	return resp
+}
+
+func TestSendReportsUnresolvableHost(t *testing.T) {
+	// The .invalid TLD is reserved and never resolves (RFC 2606)
+	_, err := NewHTTPClient().Send(newTestRequest(t, "http://gatekeeper-test.invalid/"))
+
+	httpErr, ok := err.(*models.HTTPError)
+	if !ok {
+		t.Fatalf("Send error = %T %v, want *HTTPError", err, err)
+	}
+	if !httpErr.IsDNSError() {
+		t.Fatalf("IsDNSError() = false for %v", err)
+	}
+	if !httpErr.IsDNSNotFound() {
+		t.Skipf("resolver did not report NXDOMAIN: %v", httpErr.DNSError())
+	}
+	if httpErr.Kind() != interfaces.KindDNS {
+		t.Errorf("Kind() = %v, want %v", httpErr.Kind(), interfaces.KindDNS)
+	}
+	if !strings.Contains(err.Error(), `cannot resolve host "gatekeeper-test.invalid"`) {
+		t.Errorf("Error() = %q does not name the host", err.Error())
+	}
+}
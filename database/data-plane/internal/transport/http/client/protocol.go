@@ -0,0 +1,74 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// ErrHTTP3Unsupported is returned when HTTP/3 is requested from a binary
+// built without the http3 build tag.
+var ErrHTTP3Unsupported = errors.New("HTTP/3 support requires building with -tags http3")
+
+// HTTP3Supported reports whether the binary was built with HTTP/3 support
+// (the http3 build tag).
+func HTTP3Supported() bool {
+	return http3Supported
+}
+
+// WithHTTP3 returns a copy of client that sends HTTPS requests over HTTP/3
+// (QUIC). When the QUIC handshake with a host fails, requests fall back to
+// the client's own transport (HTTP/2 or HTTP/1.1), and the host is not
+// retried over QUIC for a while. The client's TLS configuration and timeout
+// apply unchanged. The QUIC dependency is only compiled in with the http3
+// build tag; otherwise ErrHTTP3Unsupported is returned.
+func WithHTTP3(client *http.Client) (*http.Client, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	fallback := client.Transport
+	if fallback == nil {
+		fallback = http.DefaultTransport
+	}
+	transport, err := http3Transport(fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	h3Client := *client
+	h3Client.Transport = transport
+	return &h3Client, nil
+}
+
+// http3Transports shares one HTTP/3 round tripper per fallback transport, so
+// QUIC connections (and their UDP sockets) are reused across clients.
+var http3Transports sync.Map // http.RoundTripper -> http.RoundTripper
+
+// http3Transport returns the HTTP/3 round tripper for fallback.
+func http3Transport(fallback http.RoundTripper) (http.RoundTripper, error) {
+	comparable := reflect.TypeOf(fallback).Comparable()
+	if comparable {
+		if transport, ok := http3Transports.Load(fallback); ok {
+			return transport.(http.RoundTripper), nil
+		}
+	}
+
+	transport, err := newHTTP3RoundTripper(fallback)
+	if err != nil || !comparable {
+		return transport, err
+	}
+	actual, _ := http3Transports.LoadOrStore(fallback, transport)
+	return actual.(http.RoundTripper), nil
+}
+
+// EnableHTTP3 switches the client to HTTP/3 with fallback; see WithHTTP3.
+func (c *HTTPClient) EnableHTTP3() error {
+	h3Client, err := WithHTTP3(c.httpClient)
+	if err != nil {
+		return err
+	}
+	c.httpClient = h3Client
+	return nil
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"container/list"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// maxServerNameTransports bounds how many derived transports are kept for
+// reuse; the least recently used one is dropped, and its idle connections
+// closed, to make room.
+const maxServerNameTransports = 64
+
+// serverNameKey identifies a transport derived for a TLS server name.
+type serverNameKey struct {
+	base       *http.Transport
+	serverName string
+}
+
+// serverNameEntry is a cached derived transport.
+type serverNameEntry struct {
+	key       serverNameKey
+	transport *http.Transport
+}
+
+// serverNameCache shares one transport per base transport and server name,
+// so connections are reused across requests, keeping at most limit of them.
+type serverNameCache struct {
+	mu      sync.Mutex
+	limit   int
+	order   *list.List // of *serverNameEntry, most recently used first
+	entries map[serverNameKey]*list.Element
+}
+
+// serverNameTransports is the cache WithServerName derives transports through.
+var serverNameTransports = newServerNameCache(maxServerNameTransports)
+
+// newServerNameCache creates a cache keeping at most limit transports.
+func newServerNameCache(limit int) *serverNameCache {
+	return &serverNameCache{
+		limit:   limit,
+		order:   list.New(),
+		entries: make(map[serverNameKey]*list.Element),
+	}
+}
+
+// get returns the transport derived from base for serverName, deriving it
+// if it is not cached.
+func (c *serverNameCache) get(base *http.Transport, serverName string) *http.Transport {
+	key := serverNameKey{base: base, serverName: serverName}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		return element.Value.(*serverNameEntry).transport
+	}
+
+	derived := deriveServerNameTransport(base, serverName)
+	c.entries[key] = c.order.PushFront(&serverNameEntry{key: key, transport: derived})
+	for c.order.Len() > c.limit {
+		oldest := c.order.Remove(c.order.Back()).(*serverNameEntry)
+		delete(c.entries, oldest.key)
+		oldest.transport.CloseIdleConnections()
+	}
+	return derived
+}
+
+// CheckServerName reports whether WithServerName can set a TLS server name
+// on client: its transport must be an *http.Transport, or unset.
+func CheckServerName(client *http.Client) error {
+	_, err := serverNameBase(client)
+	return err
+}
+
+// WithServerName returns a copy of client whose TLS handshakes send
+// serverName for SNI and verify the certificate against it, whatever host
+// the request URL dials. The client's transport must be an *http.Transport
+// (or unset, for http.DefaultTransport); its other settings are kept.
+func WithServerName(client *http.Client, serverName string) (*http.Client, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+	base, err := serverNameBase(client)
+	if err != nil {
+		return nil, err
+	}
+
+	return withTransport(client, serverNameTransports.get(base, serverName)), nil
+}
+
+// serverNameBase returns the transport a server name is set on for client.
+func serverNameBase(client *http.Client) (*http.Transport, error) {
+	var base http.RoundTripper = http.DefaultTransport
+	if client != nil && client.Transport != nil {
+		base = client.Transport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("server name requires an *http.Transport, got %T", base)
+	}
+	return transport, nil
+}
+
+// deriveServerNameTransport returns a copy of base sending serverName.
+func deriveServerNameTransport(base *http.Transport, serverName string) *http.Transport {
+	derived := base.Clone()
+	if derived.TLSClientConfig == nil {
+		derived.TLSClientConfig = &tls.Config{}
+	}
+	derived.TLSClientConfig.ServerName = serverName
+	return derived
+}
+
+// withTransport returns a copy of client using transport.
+func withTransport(client *http.Client, transport http.RoundTripper) *http.Client {
+	copied := *client
+	copied.Transport = transport
+	return &copied
+}
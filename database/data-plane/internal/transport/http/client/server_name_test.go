@@ -0,0 +1,72 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestWithServerName(t *testing.T) {
+	base := &http.Transport{}
+	client := &http.Client{Transport: base}
+
+	derived, err := WithServerName(client, "api.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := derived.Transport.(*http.Transport)
+	if transport == base || transport.TLSClientConfig.ServerName != "api.example.com" {
+		t.Fatalf("derived transport = %p with server name %q", transport, transport.TLSClientConfig.ServerName)
+	}
+	if base.TLSClientConfig != nil && base.TLSClientConfig.ServerName != "" {
+		t.Error("server name set on the base transport")
+	}
+
+	// The derived transport is reused, so its connections are shared
+	again, err := WithServerName(client, "api.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Transport != transport {
+		t.Error("transport derived again for the same server name")
+	}
+}
+
+func TestWithServerNameRequiresTransport(t *testing.T) {
+	client := &http.Client{Transport: roundTripperFunc(nil)}
+	if _, err := WithServerName(client, "api.example.com"); err == nil {
+		t.Fatal("WithServerName accepted a custom round tripper")
+	}
+	if err := CheckServerName(client); err == nil {
+		t.Fatal("CheckServerName accepted a custom round tripper")
+	}
+	if err := CheckServerName(nil); err != nil {
+		t.Fatalf("CheckServerName(nil) = %v", err)
+	}
+}
+
+func TestServerNameCacheBounded(t *testing.T) {
+	cache := newServerNameCache(2)
+	base := &http.Transport{}
+
+	first := cache.get(base, "a.example.com")
+	cache.get(base, "b.example.com")
+	cache.get(base, "a.example.com") // a is now the most recently used
+	cache.get(base, "c.example.com") // evicts b
+
+	if cache.order.Len() != 2 {
+		t.Fatalf("cache holds %d transports, want 2", cache.order.Len())
+	}
+	if cache.get(base, "a.example.com") != first {
+		t.Error("recently used transport was evicted")
+	}
+	if _, ok := cache.entries[serverNameKey{base: base, serverName: "b.example.com"}]; ok {
+		t.Error("least recently used transport was kept")
+	}
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
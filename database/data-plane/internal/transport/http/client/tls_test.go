@@ -0,0 +1,101 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+	"data-plane/internal/transport/resiliency"
+)
+
+// tlsClient returns a client whose transport uses config and handshakeTimeout.
+func tlsClient(config *tls.Config, handshakeTimeout time.Duration) interfaces.IHTTPClient {
+	c := NewHTTPClient()
+	c.SetHTTPClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig:     config,
+		TLSHandshakeTimeout: handshakeTimeout,
+	}})
+	return c
+}
+
+// trusting returns a TLS config that trusts server's certificate.
+func trusting(server *httptest.Server) *tls.Config {
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	return &tls.Config{RootCAs: roots}
+}
+
+// newSilentListener accepts connections and never answers, so a TLS
+// handshake against it times out.
+func newSilentListener(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestSendReportsTLSFailures(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	expired := trusting(server)
+	expired.Time = func() time.Time { return server.Certificate().NotAfter.Add(time.Hour) }
+	mismatch := trusting(server)
+	mismatch.ServerName = "wrong.example"
+
+	tests := []struct {
+		name      string
+		client    interfaces.IHTTPClient
+		url       string
+		reason    models.TLSReason
+		retryable bool
+	}{
+		{"unknown authority", tlsClient(&tls.Config{}, 0), server.URL, models.TLSReasonUnknownAuthority, false},
+		{"hostname mismatch", tlsClient(mismatch, 0), server.URL, models.TLSReasonHostnameMismatch, false},
+		{"expired", tlsClient(expired, 0), server.URL, models.TLSReasonExpired, false},
+		{"handshake timeout", tlsClient(&tls.Config{}, 50*time.Millisecond), "https://" + newSilentListener(t), models.TLSReasonHandshakeTimeout, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.client.Send(newTestRequest(t, tt.url))
+			httpErr, ok := err.(*models.HTTPError)
+			if !ok || !httpErr.IsTLSError() {
+				t.Fatalf("Send error = %T %v, want a TLS HTTPError", err, err)
+			}
+
+			details, ok := httpErr.TLSDetails()
+			if !ok || details.Reason != tt.reason {
+				t.Errorf("TLSDetails() = %+v, %v; want reason %v", details, ok, tt.reason)
+			}
+			if tt.reason != models.TLSReasonHandshakeTimeout {
+				cert := server.Certificate()
+				if details.Subject != cert.Subject.String() || !details.NotAfter.Equal(cert.NotAfter) {
+					t.Errorf("peer certificate = %q until %v, want %q until %v",
+						details.Subject, details.NotAfter, cert.Subject, cert.NotAfter)
+				}
+			}
+
+			if got := resiliency.NewRetryPolicy(3).ShouldRetry(err, 0); got != tt.retryable {
+				t.Errorf("ShouldRetry() = %v, want %v", got, tt.retryable)
+			}
+		})
+	}
+}
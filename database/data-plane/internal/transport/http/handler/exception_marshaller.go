@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// ErrorConstructor builds an error from a failed response.
+type ErrorConstructor func(response interfaces.IHTTPResponse) error
+
+// StatusMapExceptionMarshaller maps HTTP status codes to error constructors.
+// It is installed as the default exception marshaller on every ResponseHandler.
+// Status codes without a mapping fall back to a plain HTTPError, which still
+// matches the status sentinels (ErrNotFound, ErrConflict, ...) via errors.Is.
+type StatusMapExceptionMarshaller struct {
+	constructors map[int]ErrorConstructor
+}
+
+// Ensure StatusMapExceptionMarshaller implements IExceptionMarshaller interface
+var _ interfaces.IExceptionMarshaller = (*StatusMapExceptionMarshaller)(nil)
+
+// NewStatusMapExceptionMarshaller creates a marshaller with the default mappings:
+// 401 → ErrUnauthorized, 404 → ErrNotFound, 409 → ErrConflict and
+// 429 → ErrTooManyRequests (carrying the Retry-After delay).
+func NewStatusMapExceptionMarshaller() *StatusMapExceptionMarshaller {
+	return &StatusMapExceptionMarshaller{
+		constructors: map[int]ErrorConstructor{
+			http.StatusUnauthorized:    NewStatusError,
+			http.StatusNotFound:        NewStatusError,
+			http.StatusConflict:        NewStatusError,
+			http.StatusTooManyRequests: NewStatusError,
+		},
+	}
+}
+
+// Map registers (or replaces) the error constructor for a status code.
+func (m *StatusMapExceptionMarshaller) Map(statusCode int, constructor ErrorConstructor) *StatusMapExceptionMarshaller {
+	if constructor != nil {
+		m.constructors[statusCode] = constructor
+	}
+	return m
+}
+
+// CanMarshal returns true for any 4xx or 5xx response.
+func (m *StatusMapExceptionMarshaller) CanMarshal(response interfaces.IHTTPResponse) bool {
+	return response != nil && response.IsError()
+}
+
+// Marshal converts the response into the mapped error, or an HTTPError if unmapped.
+func (m *StatusMapExceptionMarshaller) Marshal(response interfaces.IHTTPResponse) error {
+	if constructor, ok := m.constructors[response.StatusCode()]; ok {
+		return constructor(response)
+	}
+	return NewStatusError(response)
+}
+
+// NewStatusError is the default ErrorConstructor.
+// It produces an HTTPError carrying the status code, body and Retry-After delay.
+func NewStatusError(response interfaces.IHTTPResponse) error {
+	body, _ := response.BodyString()
+	return &models.HTTPError{
+		Request:    response.Request(),
+		Response:   response,
+		StatusCode: response.StatusCode(),
+		Message:    fmt.Sprintf("HTTP %d: %s", response.StatusCode(), body),
+		RetryAfter: models.ParseRetryAfter(response.Header("Retry-After")),
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// ProtoContentType is the content type of protobuf-encoded bodies.
+const ProtoContentType = "application/x-protobuf"
+
+// NotProtoMessageError is returned by ProtoMarshaller for values that are
+// not protobuf messages.
+type NotProtoMessageError struct {
+	// Type is the type of the rejected value
+	Type reflect.Type
+}
+
+// Error implements the error interface.
+func (e *NotProtoMessageError) Error() string {
+	return fmt.Sprintf("%v is not a protobuf message", e.Type)
+}
+
+// ProtoMarshaller marshals protobuf messages in the binary wire format.
+// Unmarshal accepts a message, or a pointer to a message pointer, which is
+// what a response handler passes for a response type such as &pb.User{}.
+type ProtoMarshaller struct{}
+
+// Ensure ProtoMarshaller implements IMarshaller interface
+var _ interfaces.IMarshaller = (*ProtoMarshaller)(nil)
+
+// NewProtoMarshaller creates a new protobuf marshaller.
+func NewProtoMarshaller() interfaces.IMarshaller {
+	return &ProtoMarshaller{}
+}
+
+// Marshal converts a protobuf message to bytes.
+func (m *ProtoMarshaller) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, &NotProtoMessageError{Type: reflect.TypeOf(v)}
+	}
+	return proto.Marshal(msg)
+}
+
+// Unmarshal converts bytes to a protobuf message.
+func (m *ProtoMarshaller) Unmarshal(data []byte, v interface{}) error {
+	if msg, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, msg)
+	}
+
+	// A pointer to a message pointer: allocate the message and set it
+	target := reflect.ValueOf(v)
+	if target.Kind() == reflect.Pointer && !target.IsNil() && target.Elem().Kind() == reflect.Pointer {
+		elem := reflect.New(target.Elem().Type().Elem())
+		if msg, ok := elem.Interface().(proto.Message); ok {
+			if err := proto.Unmarshal(data, msg); err != nil {
+				return err
+			}
+			target.Elem().Set(elem)
+			return nil
+		}
+	}
+	return &NotProtoMessageError{Type: reflect.TypeOf(v)}
+}
+
+// ContentType returns the content type this marshaller handles.
+func (m *ProtoMarshaller) ContentType() string {
+	return ProtoContentType
+}
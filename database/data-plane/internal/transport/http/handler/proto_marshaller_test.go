@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtoMarshallerRoundTrip(t *testing.T) {
+	m := NewProtoMarshaller()
+	data, err := m.Marshal(wrapperspb.String("hello"))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	// A message and a pointer to a message pointer both decode
+	var msg wrapperspb.StringValue
+	if err := m.Unmarshal(data, &msg); err != nil || msg.GetValue() != "hello" {
+		t.Errorf("Unmarshal into a message = %q, %v; want hello", msg.GetValue(), err)
+	}
+	var ptr *wrapperspb.StringValue
+	if err := m.Unmarshal(data, &ptr); err != nil || ptr.GetValue() != "hello" {
+		t.Errorf("Unmarshal into a message pointer = %q, %v; want hello", ptr.GetValue(), err)
+	}
+}
+
+func TestProtoMarshallerRejectsNonProto(t *testing.T) {
+	m := NewProtoMarshaller()
+	var notProto *NotProtoMessageError
+	if _, err := m.Marshal(item{ID: 1}); !errors.As(err, &notProto) || notProto.Type.String() != "handler.item" {
+		t.Errorf("Marshal(item) = %v, want a NotProtoMessageError for handler.item", err)
+	}
+	for _, v := range []any{&item{}, nil, new(*int)} {
+		if err := m.Unmarshal(nil, v); !errors.As(err, &notProto) {
+			t.Errorf("Unmarshal into %T = %v, want a NotProtoMessageError", v, err)
+		}
+	}
+}
+
+func TestHandleProtoResponse(t *testing.T) {
+	data, err := proto.Marshal(wrapperspb.Int64(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewResponseHandler().WithMarshaller(NewProtoMarshaller()).WithResponseType(&wrapperspb.Int64Value{}).Build()
+	v, err := h.Handle(newTestResponse(200, ProtoContentType, string(data)))
+	if err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if got, ok := v.(*wrapperspb.Int64Value); !ok || got.GetValue() != 42 {
+		t.Errorf("Handle = %#v, want the decoded message", v)
+	}
+
+	// A non-proto response type fails with the typed error
+	h = NewResponseHandler().WithMarshaller(NewProtoMarshaller()).WithResponseType(item{}).Build()
+	var notProto *NotProtoMessageError
+	if _, err := h.Handle(newTestResponse(200, ProtoContentType, string(data))); !errors.As(err, &notProto) {
+		t.Errorf("Handle into item = %v, want a NotProtoMessageError", err)
+	}
+}
@@ -1,9 +1,18 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
+	"net/http"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 
 	"data-plane/internal/transport/http/models"
 	"data-plane/internal/transport/interfaces"
@@ -11,11 +20,58 @@ import (
 
 // ResponseHandler provides a generic type-safe response handler.
 // It handles marshalling responses into specific types.
+//
+// A built ResponseHandler is immutable and safe for concurrent use:
+// Handle never writes to handler state, so one handler can be shared
+// across goroutines for an endpoint.
 type ResponseHandler struct {
 	responseType        reflect.Type
 	marshaller          interfaces.IMarshaller
+	explicitMarshaller  bool
+	marshallers         map[string]interfaces.IMarshaller
 	exceptionMarshaller interfaces.IExceptionMarshaller
-	acceptedStatusCodes []int
+	acceptedStatusCodes map[int]struct{}
+	pooledBuffers       bool
+	streamDecoding      bool
+	verifyChecksum      bool
+	expectedChecksum    *models.Checksum
+	mode                bodyMode
+}
+
+// bodyMode selects how a handler produces its result from the body.
+type bodyMode int
+
+const (
+	// modeDecode unmarshals into the response type (or returns raw bytes if unset)
+	modeDecode bodyMode = iota
+	// modeString returns the body as a string
+	modeString
+	// modeBytes returns the body as a byte slice
+	modeBytes
+	// modeDiscard drains the body and returns only status metadata
+	modeDiscard
+	// modeGraphQL unwraps a GraphQL envelope and decodes its data member
+	modeGraphQL
+)
+
+// decodeBufferPool recycles buffers used to read bodies before unmarshalling.
+var decodeBufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// ResponseStatus carries the status metadata returned by the Discard handler.
+type ResponseStatus struct {
+	StatusCode     int
+	Status         string
+	Headers        http.Header
+	BytesDiscarded int64
+}
+
+// checksumVerifier is implemented by responses that support body integrity verification.
+type checksumVerifier interface {
+	VerifyChecksum(expected *models.Checksum) error
 }
 
 // Ensure ResponseHandler implements IResponseHandler interface
@@ -31,7 +87,9 @@ func NewResponseHandler() *ResponseHandlerBuilder {
 	return &ResponseHandlerBuilder{
 		handler: &ResponseHandler{
 			marshaller:          NewJSONMarshaller(),
-			acceptedStatusCodes: []int{200, 201, 202, 204},
+			exceptionMarshaller: NewStatusMapExceptionMarshaller(),
+			acceptedStatusCodes: statusCodeSet(200, 201, 202, 204),
+			streamDecoding:      true,
 		},
 	}
 }
@@ -42,15 +100,37 @@ func (b *ResponseHandlerBuilder) WithResponseType(responseType interface{}) *Res
 	return b
 }
 
-// WithMarshaller sets a custom marshaller.
+// WithMarshaller sets a custom marshaller, used whatever the response's
+// Content-Type. Without one, XML responses are decoded with the XML
+// marshaller and all others with the JSON marshaller.
 func (b *ResponseHandlerBuilder) WithMarshaller(marshaller interfaces.IMarshaller) *ResponseHandlerBuilder {
 	if marshaller != nil {
 		b.handler.marshaller = marshaller
+		b.handler.explicitMarshaller = true
+	}
+	return b
+}
+
+// WithMarshallers registers marshallers keyed by their ContentType().
+// When any are registered, the decoder is chosen from the response's
+// Content-Type instead of always using the default marshaller, and a response
+// whose content type has no registered marshaller fails with an error.
+// For example, WithMarshallers(NewJSONMarshaller(), NewXMLMarshaller())
+// decodes whichever of JSON or XML the server returns.
+func (b *ResponseHandlerBuilder) WithMarshallers(marshallers ...interfaces.IMarshaller) *ResponseHandlerBuilder {
+	if b.handler.marshallers == nil {
+		b.handler.marshallers = make(map[string]interfaces.IMarshaller, len(marshallers))
+	}
+	for _, m := range marshallers {
+		if m != nil {
+			b.handler.marshallers[normalizeMediaType(m.ContentType())] = m
+		}
 	}
 	return b
 }
 
 // WithExceptionMarshaller sets a custom exception marshaller.
+// Defaults to a StatusMapExceptionMarshaller; pass nil to disable exception marshalling.
 func (b *ResponseHandlerBuilder) WithExceptionMarshaller(exceptionMarshaller interfaces.IExceptionMarshaller) *ResponseHandlerBuilder {
 	b.handler.exceptionMarshaller = exceptionMarshaller
 	return b
@@ -58,13 +138,72 @@ func (b *ResponseHandlerBuilder) WithExceptionMarshaller(exceptionMarshaller int
 
 // WithAcceptedStatusCodes sets which HTTP status codes are considered successful.
 func (b *ResponseHandlerBuilder) WithAcceptedStatusCodes(codes ...int) *ResponseHandlerBuilder {
-	b.handler.acceptedStatusCodes = codes
+	b.handler.acceptedStatusCodes = statusCodeSet(codes...)
+	return b
+}
+
+// WithBufferPool reuses pooled buffers when a body has to be buffered before
+// unmarshalling (i.e. when stream decoding is disabled or unsupported).
+// The buffered bytes are not cached on the response, and the marshaller
+// must not retain the byte slice passed to Unmarshal.
+func (b *ResponseHandlerBuilder) WithBufferPool() *ResponseHandlerBuilder {
+	b.handler.pooledBuffers = true
+	return b
+}
+
+// WithStreamDecoding controls whether typed responses are decoded directly
+// from the body reader instead of buffering the whole payload first.
+// Streaming is enabled by default and only applies when a response type is
+// set and the marshaller implements IStreamMarshaller; error bodies are
+// always buffered. A streamed body is consumed by Handle and not cached, so
+// disable streaming if callers need response.Body() afterwards; a body
+// already read with Body() is decoded from the cached bytes.
+// WithStreamDecoding(false) buffers every body, e.g. for WithBufferPool.
+func (b *ResponseHandlerBuilder) WithStreamDecoding(enabled bool) *ResponseHandlerBuilder {
+	b.handler.streamDecoding = enabled
+	return b
+}
+
+// WithChecksumVerification verifies the response body against the digest
+// advertised in the Content-MD5 or x-amz-checksum-* headers.
+// Responses without a checksum header are accepted as-is.
+func (b *ResponseHandlerBuilder) WithChecksumVerification() *ResponseHandlerBuilder {
+	b.handler.verifyChecksum = true
+	return b
+}
+
+// WithExpectedChecksum verifies the response body against an explicit digest,
+// regardless of any checksum headers on the response.
+func (b *ResponseHandlerBuilder) WithExpectedChecksum(algorithm models.ChecksumAlgorithm, digest []byte) *ResponseHandlerBuilder {
+	b.handler.verifyChecksum = true
+	b.handler.expectedChecksum = &models.Checksum{Algorithm: algorithm, Digest: digest}
+	return b
+}
+
+// WithGraphQL makes the handler unwrap the GraphQL response envelope: the
+// data member is decoded into the response type (raw JSON if unset) and an
+// errors array is returned as a *models.GraphQLError. When the response
+// carries both, Handle returns the decoded partial data along with the error.
+func (b *ResponseHandlerBuilder) WithGraphQL() *ResponseHandlerBuilder {
+	b.handler.mode = modeGraphQL
 	return b
 }
 
 // Build creates the ResponseHandler.
+// The returned handler is a snapshot: further calls on the builder do not affect it.
 func (b *ResponseHandlerBuilder) Build() interfaces.IResponseHandler {
-	return b.handler
+	handler := *b.handler
+	handler.acceptedStatusCodes = make(map[int]struct{}, len(b.handler.acceptedStatusCodes))
+	for code := range b.handler.acceptedStatusCodes {
+		handler.acceptedStatusCodes[code] = struct{}{}
+	}
+	if b.handler.marshallers != nil {
+		handler.marshallers = make(map[string]interfaces.IMarshaller, len(b.handler.marshallers))
+		for contentType, m := range b.handler.marshallers {
+			handler.marshallers[contentType] = m
+		}
+	}
+	return &handler
 }
 
 // Handle processes the response and returns a typed result.
@@ -73,52 +212,328 @@ func (h *ResponseHandler) Handle(response interfaces.IHTTPResponse) (interface{}
 		return nil, fmt.Errorf("response is nil")
 	}
 
+	// A conditional request's cached copy is still valid: there is no body
+	if response.StatusCode() == http.StatusNotModified {
+		return nil, notModified(response)
+	}
+
 	// Check if status code is accepted
 	if !h.isAcceptedStatusCode(response.StatusCode()) {
 		return nil, h.HandleError(response)
 	}
 
-	// Read response body
-	body, err := response.Body()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	// A HEAD response has no body, whatever its Content-Length says
+	if isHead(response) {
+		return h.head(response), nil
+	}
+
+	// Enable body integrity verification if configured
+	if h.verifyChecksum {
+		verifier, ok := response.(checksumVerifier)
+		if !ok {
+			return nil, fmt.Errorf("response does not support checksum verification")
+		}
+		if err := verifier.VerifyChecksum(h.expectedChecksum); err != nil {
+			return nil, fmt.Errorf("checksum verification failed: %w", err)
+		}
 	}
 
-	// If no response type specified, return raw body
-	if h.responseType == nil {
+	switch h.mode {
+	case modeString:
+		body, err := response.BodyString()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		return body, nil
+	case modeDiscard:
+		return h.discard(response)
+	case modeGraphQL:
+		return h.graphQL(response)
+	}
+
+	// If no response type specified (or raw bytes requested), return raw body
+	if h.responseType == nil || h.mode == modeBytes {
+		body, err := response.Body()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
 		return body, nil
 	}
 
 	// Create new instance of response type
 	result := reflect.New(h.responseType).Interface()
 
-	// Unmarshal into result
-	if err := h.marshaller.Unmarshal(body, result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	// Decode into result
+	if err := h.decode(response, result); err != nil {
+		return nil, err
 	}
 
 	// Return the dereferenced value
 	return reflect.ValueOf(result).Elem().Interface(), nil
 }
 
+// isHead reports whether response answers a HEAD request.
+func isHead(response interfaces.IHTTPResponse) bool {
+	request := response.Request()
+	return request != nil && request.Method() == http.MethodHead
+}
+
+// head returns the result for a HEAD response without reading its body:
+// the status metadata for Discard, an empty string or byte slice, or the
+// zero value of the response type.
+func (h *ResponseHandler) head(response interfaces.IHTTPResponse) interface{} {
+	response.Close()
+	switch {
+	case h.mode == modeDiscard:
+		return ResponseStatus{
+			StatusCode: response.StatusCode(),
+			Status:     response.Status(),
+			Headers:    response.Headers(),
+		}
+	case h.mode == modeString:
+		return ""
+	case h.responseType == nil || h.mode == modeBytes:
+		return []byte{}
+	}
+	return reflect.Zero(h.responseType).Interface()
+}
+
+// decode unmarshals the response body into v.
+// When streaming is enabled and supported by the marshaller, the body is
+// decoded straight from the reader so the raw bytes are never held in memory.
+// Otherwise the body is buffered (and cached on the response) first.
+func (h *ResponseHandler) decode(response interfaces.IHTTPResponse, v interface{}) error {
+	marshaller, err := h.marshallerFor(response)
+	if err != nil {
+		return err
+	}
+
+	if streamer, ok := marshaller.(interfaces.IStreamMarshaller); ok && h.streamDecoding {
+		reader := response.Reader()
+		if reader == nil {
+			return fmt.Errorf("failed to read response body: response body is nil")
+		}
+		defer reader.Close()
+
+		// Keep the start of the stream so a decode failure can show the payload
+		prefix := &prefixBuffer{limit: streamPrefixLimit}
+		if err := streamer.Decode(io.TeeReader(reader, prefix), v); err != nil {
+			return models.NewDecodeError("failed to decode response", response, v, prefix.Bytes(), err)
+		}
+
+		// Drain trailing bytes so the underlying connection can be reused
+		// (and so any checksum verification reaches EOF)
+		if _, err := io.Copy(io.Discard, reader); err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+		return nil
+	}
+
+	if h.pooledBuffers {
+		return decodePooled(marshaller, response, v)
+	}
+
+	body, err := response.Body()
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if err := marshaller.Unmarshal(body, v); err != nil {
+		return models.NewDecodeError("failed to unmarshal response", response, v, body, err)
+	}
+	return nil
+}
+
+// streamPrefixLimit bounds how much of a streamed body is kept for decode errors.
+const streamPrefixLimit = 64 << 10
+
+// prefixBuffer keeps the first limit bytes written to it and discards the rest.
+type prefixBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+// Write implements io.Writer; it never fails.
+func (b *prefixBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(room, len(p))])
+	}
+	return len(p), nil
+}
+
+// decodePooled reads the body into a pooled buffer and unmarshals from it.
+func decodePooled(marshaller interfaces.IMarshaller, response interfaces.IHTTPResponse, v interface{}) error {
+	reader := response.Reader()
+	if reader == nil {
+		return fmt.Errorf("failed to read response body: response body is nil")
+	}
+	defer reader.Close()
+
+	buf := decodeBufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		decodeBufferPool.Put(buf)
+	}()
+
+	if _, err := buf.ReadFrom(reader); err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if err := marshaller.Unmarshal(buf.Bytes(), v); err != nil {
+		return models.NewDecodeError("failed to unmarshal response", response, v, buf.Bytes(), err)
+	}
+	return nil
+}
+
+// xmlMarshaller decodes XML responses when no marshaller is configured.
+var xmlMarshaller = NewXMLMarshaller()
+
+// marshallerFor selects the marshaller for the response's Content-Type.
+// Without a registry the configured marshaller is used, or if none was
+// configured, the XML marshaller for XML and the JSON one for the rest.
+func (h *ResponseHandler) marshallerFor(response interfaces.IHTTPResponse) (interfaces.IMarshaller, error) {
+	contentType := normalizeMediaType(response.ContentType())
+	if len(h.marshallers) == 0 {
+		if !h.explicitMarshaller && isXMLMediaType(contentType) {
+			return xmlMarshaller, nil
+		}
+		return h.marshaller, nil
+	}
+
+	if m, ok := h.marshallers[contentType]; ok {
+		return m, nil
+	}
+
+	// text/xml is the legacy name of application/xml
+	if contentType == "text/xml" {
+		if m, ok := h.marshallers["application/xml"]; ok {
+			return m, nil
+		}
+	}
+
+	// Structured syntax suffixes (RFC 6839), e.g. application/problem+json
+	if i := strings.LastIndex(contentType, "+"); i >= 0 {
+		if m, ok := h.marshallers["application/"+contentType[i+1:]]; ok {
+			return m, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no marshaller registered for content type %q", response.ContentType())
+}
+
+// isXMLMediaType reports whether a normalized media type is XML, including
+// text/xml and structured syntax suffixes such as application/atom+xml.
+func isXMLMediaType(mediaType string) bool {
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// normalizeMediaType strips parameters (e.g. charset) and lowercases a content type.
+func normalizeMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
+
+// discard drains and closes the body so the connection can be reused,
+// returning only the status metadata.
+func (h *ResponseHandler) discard(response interfaces.IHTTPResponse) (interface{}, error) {
+	status := ResponseStatus{
+		StatusCode: response.StatusCode(),
+		Status:     response.Status(),
+		Headers:    response.Headers(),
+	}
+
+	reader := response.Reader()
+	if reader == nil {
+		return status, nil
+	}
+	defer reader.Close()
+
+	n, err := io.Copy(io.Discard, reader)
+	status.BytesDiscarded = n
+	if err != nil {
+		return nil, fmt.Errorf("failed to drain response body: %w", err)
+	}
+	return status, nil
+}
+
+// graphQL unwraps a GraphQL envelope, decoding its data into the response type.
+func (h *ResponseHandler) graphQL(response interfaces.IHTTPResponse) (interface{}, error) {
+	envelope, err := readGraphQLEnvelope(response)
+	if err != nil {
+		return nil, err
+	}
+
+	var result interface{}
+	switch {
+	case h.responseType == nil:
+		if envelope.HasData() {
+			result = envelope.Data
+		}
+	case envelope.HasData():
+		v := reflect.New(h.responseType).Interface()
+		if err := json.Unmarshal(envelope.Data, v); err != nil {
+			return nil, models.NewDecodeError("failed to decode GraphQL data", response, v, envelope.Data, err)
+		}
+		result = reflect.ValueOf(v).Elem().Interface()
+	case len(envelope.Errors) == 0:
+		result = reflect.Zero(h.responseType).Interface()
+	}
+
+	if len(envelope.Errors) > 0 {
+		return result, models.NewGraphQLError(response, envelope)
+	}
+	return result, nil
+}
+
+// readGraphQLEnvelope reads and decodes a GraphQL response envelope.
+func readGraphQLEnvelope(response interfaces.IHTTPResponse) (*models.GraphQLResponse, error) {
+	body, err := response.Body()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	envelope := &models.GraphQLResponse{}
+	if err := json.Unmarshal(body, envelope); err != nil {
+		return nil, models.NewDecodeError("failed to decode GraphQL response", response, envelope, body, err)
+	}
+	return envelope, nil
+}
+
+// notModified closes a 304 response and returns an HTTPError matching
+// ErrNotModified; the response stays on the error for its headers.
+func notModified(response interfaces.IHTTPResponse) error {
+	response.Close()
+	return &models.HTTPError{
+		Request:    response.Request(),
+		Response:   response,
+		StatusCode: response.StatusCode(),
+		Message:    "resource not modified",
+	}
+}
+
 // HandleError processes error responses.
 func (h *ResponseHandler) HandleError(response interfaces.IHTTPResponse) error {
 	if response == nil {
 		return fmt.Errorf("response is nil")
 	}
 
+	// GraphQL servers may reject a request with an error status and an envelope
+	if h.mode == modeGraphQL {
+		if envelope, err := readGraphQLEnvelope(response); err == nil && len(envelope.Errors) > 0 {
+			return models.NewGraphQLError(response, envelope)
+		}
+	}
+
 	// Try exception marshaller first
 	if h.exceptionMarshaller != nil && h.exceptionMarshaller.CanMarshal(response) {
 		return h.exceptionMarshaller.Marshal(response)
 	}
 
 	// Default error handling
-	body, _ := response.BodyString()
-	return &models.HTTPError{
-		Response:   response,
-		StatusCode: response.StatusCode(),
-		Message:    fmt.Sprintf("HTTP %d: %s", response.StatusCode(), body),
-	}
+	return NewStatusError(response)
 }
 
 // CanHandle determines if this handler can process the given response.
@@ -131,19 +546,67 @@ func (h *ResponseHandler) CanHandle(response interfaces.IHTTPResponse) bool {
 }
 
 func (h *ResponseHandler) isAcceptedStatusCode(statusCode int) bool {
-	for _, code := range h.acceptedStatusCodes {
-		if code == statusCode {
-			return true
-		}
+	_, ok := h.acceptedStatusCodes[statusCode]
+	return ok
+}
+
+// statusCodeSet converts a list of status codes into a set for O(1) lookups.
+func statusCodeSet(codes ...int) map[int]struct{} {
+	set := make(map[int]struct{}, len(codes))
+	for _, code := range codes {
+		set[code] = struct{}{}
+	}
+	return set
+}
+
+// ============= PREBUILT HANDLERS =============
+
+// String returns a handler that yields the response body as a string.
+// If no status codes are given, the default accepted codes are used.
+func String(acceptedStatusCodes ...int) interfaces.IResponseHandler {
+	return newModeHandler(modeString, acceptedStatusCodes)
+}
+
+// Bytes returns a handler that yields the response body as a []byte.
+// If no status codes are given, the default accepted codes are used.
+func Bytes(acceptedStatusCodes ...int) interfaces.IResponseHandler {
+	return newModeHandler(modeBytes, acceptedStatusCodes)
+}
+
+// Discard returns a handler that fully drains and closes the response body,
+// yielding only a ResponseStatus. Draining lets the connection be reused,
+// which makes it suitable for probe-style calls.
+// If no status codes are given, the default accepted codes are used.
+func Discard(acceptedStatusCodes ...int) interfaces.IResponseHandler {
+	return newModeHandler(modeDiscard, acceptedStatusCodes)
+}
+
+// GraphQL returns a handler that unwraps a GraphQL response envelope and
+// decodes its data member into a value of dataType's type; see WithGraphQL.
+// If no status codes are given, the default accepted codes are used.
+func GraphQL(dataType interface{}, acceptedStatusCodes ...int) interfaces.IResponseHandler {
+	builder := NewResponseHandler().WithResponseType(dataType).WithGraphQL()
+	if len(acceptedStatusCodes) > 0 {
+		builder.WithAcceptedStatusCodes(acceptedStatusCodes...)
 	}
-	return false
+	return builder.Build()
+}
+
+// newModeHandler builds a reflection-free handler for the given body mode.
+func newModeHandler(mode bodyMode, acceptedStatusCodes []int) interfaces.IResponseHandler {
+	builder := NewResponseHandler()
+	if len(acceptedStatusCodes) > 0 {
+		builder.WithAcceptedStatusCodes(acceptedStatusCodes...)
+	}
+	builder.handler.mode = mode
+	return builder.Build()
 }
 
 // JSONMarshaller is a default JSON marshaller implementation.
 type JSONMarshaller struct{}
 
-// Ensure JSONMarshaller implements IMarshaller interface
-var _ interfaces.IMarshaller = (*JSONMarshaller)(nil)
+// Ensure JSONMarshaller implements IStreamMarshaller interface
+var _ interfaces.IStreamMarshaller = (*JSONMarshaller)(nil)
 
 // NewJSONMarshaller creates a new JSON marshaller.
 func NewJSONMarshaller() interfaces.IMarshaller {
@@ -160,7 +623,88 @@ func (m *JSONMarshaller) Unmarshal(data []byte, v interface{}) error {
 	return json.Unmarshal(data, v)
 }
 
+// errJSONTruncated is the error Unmarshal reports for a truncated document.
+var errJSONTruncated = errors.New("unexpected end of JSON input")
+
+// Decode reads JSON from the reader and decodes it into an object.
+// It fails like Unmarshal does: on a truncated or empty document, and on
+// anything but whitespace after the value.
+func (m *JSONMarshaller) Decode(r io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(v); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return errJSONTruncated
+		}
+		return err
+	}
+	return checkJSONTrailer(io.MultiReader(decoder.Buffered(), r))
+}
+
+// checkJSONTrailer reads r to the first byte that is not JSON whitespace
+// and reports it with the error json.Unmarshal gives for trailing data.
+func checkJSONTrailer(r io.Reader) error {
+	var buf [512]byte
+	for {
+		n, err := r.Read(buf[:])
+		for _, c := range buf[:n] {
+			switch c {
+			case ' ', '\t', '\r', '\n':
+				continue
+			}
+			return fmt.Errorf("invalid character %s after top-level value", quoteJSONChar(c))
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// quoteJSONChar formats c as encoding/json does in syntax errors.
+func quoteJSONChar(c byte) string {
+	switch c {
+	case '\'':
+		return `'\''`
+	case '"':
+		return `'"'`
+	}
+	quoted := strconv.Quote(string(c))
+	return "'" + quoted[1:len(quoted)-1] + "'"
+}
+
 // ContentType returns the content type this marshaller handles.
 func (m *JSONMarshaller) ContentType() string {
 	return "application/json"
 }
+
+// XMLMarshaller is a default XML marshaller implementation.
+type XMLMarshaller struct{}
+
+// Ensure XMLMarshaller implements IStreamMarshaller interface
+var _ interfaces.IStreamMarshaller = (*XMLMarshaller)(nil)
+
+// NewXMLMarshaller creates a new XML marshaller.
+func NewXMLMarshaller() interfaces.IMarshaller {
+	return &XMLMarshaller{}
+}
+
+// Marshal converts an object to XML bytes.
+func (m *XMLMarshaller) Marshal(v interface{}) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+// Unmarshal converts XML bytes to an object.
+func (m *XMLMarshaller) Unmarshal(data []byte, v interface{}) error {
+	return xml.Unmarshal(data, v)
+}
+
+// Decode reads XML from the reader and decodes it into an object.
+func (m *XMLMarshaller) Decode(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// ContentType returns the content type this marshaller handles.
+func (m *XMLMarshaller) ContentType() string {
+	return "application/xml"
+}
@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// newTestResponse returns a response with the given status, content type and body.
+func newTestResponse(status int, contentType, body string) *models.Response {
+	return newTestResponseReader(status, contentType, io.NopCloser(strings.NewReader(body)))
+}
+
+// newTestResponseReader returns a response whose body is read from body.
+func newTestResponseReader(status int, contentType string, body io.ReadCloser) *models.Response {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &models.Response{HttpResp: &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     header,
+		Body:       body,
+	}}
+}
+
+type item struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestStreamDecodingParity(t *testing.T) {
+	buffered := NewResponseHandler().WithResponseType(item{}).WithStreamDecoding(false).Build()
+	streamed := NewResponseHandler().WithResponseType(item{}).Build()
+
+	for _, body := range []string{
+		`{"id":1,"name":"a"}`,
+		" \n{\"id\":2}\t\r\n",
+		`{"id":1} x`,
+		`{"id":1}{"id":2}`,
+		`{"id":1} "s"`,
+		`{"id":"one"}`,
+		`{"id":`,
+		``,
+		`null`,
+	} {
+		want, wantErr := buffered.Handle(newTestResponse(200, "application/json", body))
+		got, gotErr := streamed.Handle(newTestResponse(200, "application/json", body))
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: streamed result %#v, buffered %#v", body, got, want)
+		}
+		if (gotErr == nil) != (wantErr == nil) {
+			t.Errorf("%q: streamed error %v, buffered %v", body, gotErr, wantErr)
+			continue
+		}
+		if wantErr != nil && decodeCause(gotErr) != decodeCause(wantErr) {
+			t.Errorf("%q: streamed cause %q, buffered %q", body, decodeCause(gotErr), decodeCause(wantErr))
+		}
+	}
+}
+
+// decodeCause returns the message of the decoder error behind err.
+func decodeCause(err error) string {
+	var decodeErr *models.DecodeError
+	if errors.As(err, &decodeErr) && decodeErr.Err != nil {
+		return decodeErr.Err.Error()
+	}
+	return err.Error()
+}
+
+func TestBodyCachedWithoutStreamDecoding(t *testing.T) {
+	response := newTestResponse(200, "application/json", `{"id":7}`)
+	result, err := NewResponseHandler().WithResponseType(item{}).WithStreamDecoding(false).Build().Handle(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.(item).ID != 7 {
+		t.Fatalf("result = %#v", result)
+	}
+
+	body, err := response.Body()
+	if err != nil || string(body) != `{"id":7}` {
+		t.Fatalf("Body() after Handle = %q, %v", body, err)
+	}
+}
+
+func TestStreamDecodingByDefault(t *testing.T) {
+	response := newTestResponse(200, "application/json", `{"id":7}`)
+	result, err := NewResponseHandler().WithResponseType(item{}).Build().Handle(response)
+	if err != nil || result.(item).ID != 7 {
+		t.Fatalf("Handle = %#v, %v", result, err)
+	}
+	// The body was decoded from the reader, not cached
+	if body, _ := response.Body(); len(body) != 0 {
+		t.Errorf("Body() after Handle = %q, want the streamed body not cached", body)
+	}
+}
+
+func TestStreamDecodingUsesCachedBody(t *testing.T) {
+	response := newTestResponse(200, "application/json", `{"id":7}`)
+	if _, err := response.Body(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewResponseHandler().WithResponseType(item{}).Build().Handle(response)
+	if err != nil || result.(item).ID != 7 {
+		t.Fatalf("Handle = %#v, %v", result, err)
+	}
+	if body, err := response.Body(); err != nil || string(body) != `{"id":7}` {
+		t.Fatalf("Body() = %q, %v", body, err)
+	}
+}
+
+func TestStreamDecodingErrorBodyReadable(t *testing.T) {
+	response := newTestResponse(500, "application/json", `{"message":"boom"}`)
+	_, err := NewResponseHandler().WithResponseType(item{}).Build().Handle(response)
+	var httpErr interfaces.IHTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Handle = %v, want an HTTP error", err)
+	}
+	if body, err := response.Body(); err != nil || !strings.Contains(string(body), "boom") {
+		t.Fatalf("error body = %q, %v", body, err)
+	}
+}
+
+// largeJSONArray returns a JSON array of n items of about 50 bytes each.
+func largeJSONArray(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i := range n {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`{"id":12345,"name":"a reasonably long item name"}`)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes()
+}
+
+// BenchmarkDecodeLargeResponse decodes a 50MB response with and without
+// stream decoding.
+func BenchmarkDecodeLargeResponse(b *testing.B) {
+	payload := largeJSONArray(50 << 20 / 50)
+	for _, bench := range []struct {
+		name   string
+		stream bool
+	}{{"buffered", false}, {"streamed", true}} {
+		b.Run(bench.name, func(b *testing.B) {
+			handler := NewResponseHandler().WithResponseType([]item{}).WithStreamDecoding(bench.stream).Build()
+			b.SetBytes(int64(len(payload)))
+			b.ReportAllocs()
+			for b.Loop() {
+				response := newTestResponseReader(200, "application/json", io.NopCloser(bytes.NewReader(payload)))
+				if _, err := handler.Handle(response); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestChecksumVerification(t *testing.T) {
+	body := `{"id":1}`
+	sum := sha256.Sum256([]byte(body))
+	digest := base64.StdEncoding.EncodeToString(sum[:])
+	h := NewResponseHandler().WithResponseType(item{}).WithChecksumVerification().Build()
+
+	resp := newTestResponse(200, "application/json", body)
+	resp.HttpResp.Header.Set("x-amz-checksum-sha256", digest)
+	if v, err := h.Handle(resp); err != nil || v.(item).ID != 1 {
+		t.Fatalf("matching checksum: %v, %v", v, err)
+	}
+
+	resp = newTestResponse(200, "application/json", `{"id":2}`)
+	resp.HttpResp.Header.Set("x-amz-checksum-sha256", digest)
+	if _, err := h.Handle(resp); !errors.Is(err, models.ErrChecksumMismatch) {
+		t.Fatalf("mismatching checksum: %v, want ErrChecksumMismatch", err)
+	}
+
+	// Without a checksum header the body is accepted as-is
+	if _, err := h.Handle(newTestResponse(200, "application/json", body)); err != nil {
+		t.Fatalf("absent checksum: %v", err)
+	}
+
+	explicit := NewResponseHandler().WithExpectedChecksum(models.ChecksumSHA256, sum[:]).Build()
+	if _, err := explicit.Handle(newTestResponse(200, "application/json", `{"id":2}`)); !errors.Is(err, models.ErrChecksumMismatch) {
+		t.Fatalf("explicit checksum: %v, want ErrChecksumMismatch", err)
+	}
+}
+
+// TestHandlerConcurrentReuse shares one handler across goroutines; run it
+// with -race to check that Handle writes no shared state.
+func TestHandlerConcurrentReuse(t *testing.T) {
+	handlers := map[string]interfaces.IResponseHandler{
+		"buffered": NewResponseHandler().WithResponseType(item{}).WithStreamDecoding(false).Build(),
+		"pooled":   NewResponseHandler().WithResponseType(item{}).WithStreamDecoding(false).WithBufferPool().Build(),
+		"streamed": NewResponseHandler().WithResponseType(item{}).Build(),
+	}
+	for name, h := range handlers {
+		var wg sync.WaitGroup
+		errs := make(chan error, 50)
+		for g := range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 20 {
+					id := g*100 + i
+					v, err := h.Handle(newTestResponse(200, "application/json", fmt.Sprintf(`{"id":%d,"name":"n"}`, id)))
+					if err != nil {
+						errs <- err
+						return
+					}
+					if got := v.(item); got.ID != id || got.Name != "n" {
+						errs <- fmt.Errorf("decoded %+v, want id %d", got, id)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestBuildSnapshot(t *testing.T) {
+	builder := NewResponseHandler().WithAcceptedStatusCodes(http.StatusOK)
+	h := builder.Build()
+	builder.WithAcceptedStatusCodes(http.StatusTeapot)
+
+	if !h.CanHandle(newTestResponse(http.StatusOK, "", "")) {
+		t.Error("built handler rejects its accepted status")
+	}
+	if _, err := h.Handle(newTestResponse(http.StatusTeapot, "", "")); err == nil {
+		t.Error("a later builder call changed the built handler's accepted statuses")
+	}
+}
+
+func BenchmarkHandle(b *testing.B) {
+	body := `{"id":1,"name":"` + strings.Repeat("n", 4<<10) + `"}`
+	for name, h := range map[string]interfaces.IResponseHandler{
+		"default": NewResponseHandler().WithResponseType(item{}).Build(),
+		"pooled":  NewResponseHandler().WithResponseType(item{}).WithStreamDecoding(false).WithBufferPool().Build(),
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := h.Handle(newTestResponse(200, "application/json", body)); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestHandleDecodeError(t *testing.T) {
+	body := `{"id":"seven","name":"` + strings.Repeat("n", 200) + `"}`
+	for _, stream := range []bool{false, true} {
+		h := NewResponseHandler().WithResponseType(item{}).WithStreamDecoding(stream).Build()
+		_, err := h.Handle(newTestResponse(200, "application/json", body))
+
+		var decodeErr *models.DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Fatalf("stream=%v: Handle error = %T %v, want *DecodeError", stream, err, err)
+		}
+		if decodeErr.Target != "handler.item" || decodeErr.Kind() != interfaces.KindDecode {
+			t.Errorf("stream=%v: Target %q, Kind %v", stream, decodeErr.Target, decodeErr.Kind())
+		}
+		if decodeErr.Offset != int64(len(`{"id":"seven"`)) {
+			t.Errorf("stream=%v: Offset = %d, want the end of the id value", stream, decodeErr.Offset)
+		}
+		if !bytes.HasPrefix(decodeErr.Snippet, []byte(`{"id":"seven"`)) {
+			t.Errorf("stream=%v: Snippet = %q, want it to start at the id field", stream, decodeErr.Snippet)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+type xmlItem struct {
+	XMLName xml.Name `xml:"item"`
+	ID      int      `xml:"id"`
+}
+
+func TestXMLContentTypesUseXMLMarshaller(t *testing.T) {
+	handlers := map[string]interfaces.IResponseHandler{
+		"default":     NewResponseHandler().WithResponseType(xmlItem{}).Build(),
+		"buffered":    NewResponseHandler().WithResponseType(xmlItem{}).WithStreamDecoding(false).Build(),
+		"marshallers": NewResponseHandler().WithResponseType(xmlItem{}).WithMarshallers(NewJSONMarshaller(), NewXMLMarshaller()).Build(),
+	}
+	for _, contentType := range []string{
+		"application/xml",
+		"application/xml; charset=utf-8",
+		"Application/XML; Charset=UTF-8",
+		"text/xml",
+		"text/xml; charset=utf-8",
+		"application/atom+xml; charset=utf-8",
+	} {
+		for name, h := range handlers {
+			v, err := h.Handle(newTestResponse(200, contentType, `<?xml version="1.0" encoding="UTF-8"?><item><id>7</id></item>`))
+			if err != nil {
+				t.Errorf("%s, %s: Handle: %v", name, contentType, err)
+				continue
+			}
+			if got := v.(xmlItem).ID; got != 7 {
+				t.Errorf("%s, %s: decoded ID %d, want 7", name, contentType, got)
+			}
+		}
+	}
+}
+
+func TestExplicitMarshallerIgnoresXMLContentType(t *testing.T) {
+	h := NewResponseHandler().WithResponseType(item{}).WithMarshaller(NewJSONMarshaller()).Build()
+	v, err := h.Handle(newTestResponse(200, "text/xml; charset=utf-8", `{"id":7}`))
+	if err != nil || v.(item).ID != 7 {
+		t.Errorf("Handle = %#v, %v; want the JSON marshaller used", v, err)
+	}
+}
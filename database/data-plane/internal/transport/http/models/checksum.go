@@ -0,0 +1,135 @@
+package models
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+)
+
+// ChecksumAlgorithm identifies a digest algorithm used for body verification.
+type ChecksumAlgorithm string
+
+const (
+	// ChecksumMD5 verifies bodies against an MD5 digest (Content-MD5).
+	ChecksumMD5 ChecksumAlgorithm = "MD5"
+
+	// ChecksumSHA1 verifies bodies against a SHA-1 digest (x-amz-checksum-sha1).
+	ChecksumSHA1 ChecksumAlgorithm = "SHA-1"
+
+	// ChecksumSHA256 verifies bodies against a SHA-256 digest (x-amz-checksum-sha256).
+	ChecksumSHA256 ChecksumAlgorithm = "SHA-256"
+)
+
+// checksumHeaders lists the supported digest headers, strongest first.
+var checksumHeaders = []struct {
+	header    string
+	algorithm ChecksumAlgorithm
+}{
+	{"x-amz-checksum-sha256", ChecksumSHA256},
+	{"x-amz-checksum-sha1", ChecksumSHA1},
+	{"Content-MD5", ChecksumMD5},
+}
+
+// ErrChecksumMismatch is the sentinel matched by ChecksumMismatchError via errors.Is.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ChecksumMismatchError is returned when a response body does not match its
+// expected digest. Expected and Actual are base64-encoded.
+type ChecksumMismatchError struct {
+	Algorithm ChecksumAlgorithm
+	Expected  string
+	Actual    string
+}
+
+// Error implements the error interface.
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch: expected %s, got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+// Is reports whether target is ErrChecksumMismatch.
+func (e *ChecksumMismatchError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+// Checksum is an expected digest for a response body.
+type Checksum struct {
+	Algorithm ChecksumAlgorithm
+	Digest    []byte
+}
+
+// ChecksumFromHeaders returns the expected checksum advertised by the given
+// headers. When several are present the strongest algorithm wins.
+func ChecksumFromHeaders(headers http.Header) (*Checksum, error) {
+	for _, candidate := range checksumHeaders {
+		value := headers.Get(candidate.header)
+		if value == "" {
+			continue
+		}
+		digest, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s header: %w", candidate.header, err)
+		}
+		return &Checksum{Algorithm: candidate.algorithm, Digest: digest}, nil
+	}
+	return nil, nil
+}
+
+// newHash creates the hash implementation for the checksum's algorithm.
+func (c *Checksum) newHash() (hash.Hash, error) {
+	switch c.Algorithm {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA1:
+		return sha1.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm: %s", c.Algorithm)
+	}
+}
+
+// verify compares the computed digest with the expected one.
+func (c *Checksum) verify(actual []byte) error {
+	if bytes.Equal(actual, c.Digest) {
+		return nil
+	}
+	return &ChecksumMismatchError{
+		Algorithm: c.Algorithm,
+		Expected:  base64.StdEncoding.EncodeToString(c.Digest),
+		Actual:    base64.StdEncoding.EncodeToString(actual),
+	}
+}
+
+// checksumReader computes a digest incrementally while the body is read
+// and reports a mismatch in place of io.EOF.
+type checksumReader struct {
+	reader   io.ReadCloser
+	hash     hash.Hash
+	expected *Checksum
+}
+
+// Read reads from the wrapped body and feeds the digest.
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.hash.Write(p[:n])
+	}
+	if err == io.EOF {
+		if verifyErr := r.expected.verify(r.hash.Sum(nil)); verifyErr != nil {
+			return n, verifyErr
+		}
+	}
+	return n, err
+}
+
+// Close closes the wrapped body.
+func (r *checksumReader) Close() error {
+	return r.reader.Close()
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const checksumBody = "artifact contents"
+
+// newChecksumResponse returns a response carrying body with the given header set.
+func newChecksumResponse(header, value string) *Response {
+	h := http.Header{}
+	if header != "" {
+		h.Set(header, value)
+	}
+	return &Response{HttpResp: &http.Response{
+		StatusCode: 200,
+		Header:     h,
+		Body:       io.NopCloser(strings.NewReader(checksumBody)),
+	}}
+}
+
+func b64(digest []byte) string {
+	return base64.StdEncoding.EncodeToString(digest)
+}
+
+func TestVerifyChecksumMatching(t *testing.T) {
+	md5Sum := md5.Sum([]byte(checksumBody))
+	sha1Sum := sha1.Sum([]byte(checksumBody))
+	sha256Sum := sha256.Sum256([]byte(checksumBody))
+
+	for header, digest := range map[string][]byte{
+		"Content-MD5":           md5Sum[:],
+		"x-amz-checksum-sha1":   sha1Sum[:],
+		"x-amz-checksum-sha256": sha256Sum[:],
+	} {
+		resp := newChecksumResponse(header, b64(digest))
+		if err := resp.VerifyChecksum(nil); err != nil {
+			t.Fatalf("%s: VerifyChecksum: %v", header, err)
+		}
+		body, err := resp.Body()
+		if err != nil || string(body) != checksumBody {
+			t.Errorf("%s: Body = %q, %v", header, body, err)
+		}
+	}
+}
+
+func TestVerifyChecksumMismatch(t *testing.T) {
+	wrong := sha256.Sum256([]byte("something else"))
+	resp := newChecksumResponse("x-amz-checksum-sha256", b64(wrong[:]))
+	if err := resp.VerifyChecksum(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := resp.Body()
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Body error = %v, want ErrChecksumMismatch", err)
+	}
+	var mismatch *ChecksumMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("Body error = %T, want *ChecksumMismatchError", err)
+	}
+	actual := sha256.Sum256([]byte(checksumBody))
+	if mismatch.Algorithm != ChecksumSHA256 || mismatch.Expected != b64(wrong[:]) || mismatch.Actual != b64(actual[:]) {
+		t.Errorf("mismatch = %+v", mismatch)
+	}
+}
+
+func TestVerifyChecksumStreamed(t *testing.T) {
+	wrong := md5.Sum([]byte("something else"))
+	resp := newChecksumResponse("Content-MD5", b64(wrong[:]))
+	if err := resp.VerifyChecksum(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := resp.WriteTo(&buf); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("WriteTo error = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestVerifyChecksumAbsentHeader(t *testing.T) {
+	resp := newChecksumResponse("", "")
+	if err := resp.VerifyChecksum(nil); err != nil {
+		t.Fatal(err)
+	}
+	if body, err := resp.Body(); err != nil || string(body) != checksumBody {
+		t.Errorf("Body = %q, %v", body, err)
+	}
+}
+
+func TestVerifyChecksumExplicitDigest(t *testing.T) {
+	// The explicit digest wins over a (wrong) header
+	wrong := sha256.Sum256([]byte("something else"))
+	resp := newChecksumResponse("x-amz-checksum-sha256", b64(wrong[:]))
+	digest := sha1.Sum([]byte(checksumBody))
+	if err := resp.VerifyChecksum(&Checksum{Algorithm: ChecksumSHA1, Digest: digest[:]}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resp.Body(); err != nil {
+		t.Fatalf("Body: %v", err)
+	}
+
+	// A body already read is verified at once
+	if err := resp.VerifyChecksum(&Checksum{Algorithm: ChecksumSHA256, Digest: wrong[:]}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("VerifyChecksum on a cached body = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestChecksumFromHeaders(t *testing.T) {
+	md5Sum := md5.Sum([]byte(checksumBody))
+	sha256Sum := sha256.Sum256([]byte(checksumBody))
+	header := http.Header{}
+	header.Set("Content-MD5", b64(md5Sum[:]))
+	header.Set("x-amz-checksum-sha256", b64(sha256Sum[:]))
+
+	checksum, err := ChecksumFromHeaders(header)
+	if err != nil || checksum.Algorithm != ChecksumSHA256 {
+		t.Fatalf("ChecksumFromHeaders = %+v, %v; want the strongest algorithm", checksum, err)
+	}
+
+	header = http.Header{}
+	header.Set("Content-MD5", "not base64!")
+	if _, err := ChecksumFromHeaders(header); err == nil {
+		t.Error("ChecksumFromHeaders accepted an invalid digest")
+	}
+}
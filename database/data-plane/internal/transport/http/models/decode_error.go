@@ -0,0 +1,133 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// decodeSnippetRadius is how many body bytes around the failure offset a
+// DecodeError keeps.
+const decodeSnippetRadius = 40
+
+// DecodeError is returned when a response body cannot be decoded into the
+// target type. Besides the underlying decoder error it records the target
+// type, the byte offset of the failure and a bounded snippet of the raw body
+// around it, so the payload can be inspected after the body is consumed.
+// It implements the IHTTPError interface with Kind KindDecode and is never retried.
+type DecodeError struct {
+	HTTPError
+
+	// Target is the name of the type being decoded into
+	Target string
+
+	// Offset is the byte offset of the failure in the body, or -1 if unknown
+	Offset int64
+
+	// Snippet is up to 2*40 bytes of the body around Offset
+	// (the start of the body if the offset is unknown)
+	Snippet []byte
+
+	// SnippetOffset is the body offset at which Snippet starts
+	SnippetOffset int64
+}
+
+// Ensure DecodeError implements IHTTPError interface
+var _ interfaces.IHTTPError = (*DecodeError)(nil)
+
+// NewDecodeError describes the failure to decode body into target.
+// body may be nil, or only a prefix of a streamed body; the snippet is
+// empty if the failure lies beyond it.
+func NewDecodeError(message string, response interfaces.IHTTPResponse, target interface{}, body []byte, err error) *DecodeError {
+	decodeErr := &DecodeError{
+		HTTPError: HTTPError{
+			Response: response,
+			Message:  message,
+			Err:      err,
+			KindVal:  interfaces.KindDecode,
+		},
+		Target: typeName(target),
+		Offset: decodeOffset(err),
+	}
+	if response != nil {
+		decodeErr.Request = response.Request()
+		decodeErr.StatusCode = response.StatusCode()
+	}
+
+	if len(body) > 0 && decodeErr.Offset <= int64(len(body)) {
+		start, end := int64(0), int64(len(body))
+		if decodeErr.Offset >= 0 {
+			start = max(decodeErr.Offset-decodeSnippetRadius, 0)
+			end = min(decodeErr.Offset+decodeSnippetRadius, end)
+		} else {
+			end = min(2*decodeSnippetRadius, end)
+		}
+		decodeErr.Snippet = append([]byte(nil), body[start:end]...)
+		decodeErr.SnippetOffset = start
+	}
+	return decodeErr
+}
+
+// Error implements the error interface for DecodeError.
+func (e *DecodeError) Error() string {
+	message := fmt.Sprintf("%s into %s", e.Message, e.Target)
+	if e.Offset >= 0 {
+		message = fmt.Sprintf("%s at offset %d", message, e.Offset)
+	}
+	message = fmt.Sprintf("%s: %v", message, e.Err)
+	if len(e.Snippet) > 0 && isTextual("", e.Snippet) {
+		message = fmt.Sprintf("%s (near %q)", message, e.Snippet)
+	}
+	return redactRequest(message, e.Request)
+}
+
+// Is reports no status sentinel matches: a decode failure is not a status error.
+func (e *DecodeError) Is(target error) bool {
+	return false
+}
+
+// IsTemporary returns false: decoding the same payload again fails the same way.
+func (e *DecodeError) IsTemporary() bool {
+	return false
+}
+
+// IsClientError returns false: the request was answered successfully.
+func (e *DecodeError) IsClientError() bool {
+	return false
+}
+
+// IsServerError returns false: the request was answered successfully.
+func (e *DecodeError) IsServerError() bool {
+	return false
+}
+
+// decodeOffset returns the body offset reported by a decoder error, or -1.
+func decodeOffset(err error) int64 {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		return syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		return typeErr.Offset
+	default:
+		return -1
+	}
+}
+
+// typeName returns the name of the type target points to.
+func typeName(target interface{}) string {
+	t := reflect.TypeOf(target)
+	if t == nil {
+		return "<nil>"
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.String()
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+type account struct {
+	ID      int      `json:"id"`
+	Tags    []string `json:"tags"`
+	Balance int      `json:"balance"`
+}
+
+// mismatchedAccount is an account payload whose balance is a string; the
+// padding pushes the problem field past the start of the snippet window.
+var mismatchedAccount = `{"id":7,"tags":["` + strings.Repeat("x", 100) + `"],"balance":"lots","note":"` + strings.Repeat("y", 100) + `"}`
+
+// newJSONResponse returns a 200 response with body.
+func newJSONResponse(body string) *Response {
+	return &Response{HttpResp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}}
+}
+
+func TestResponseJSONDecodeError(t *testing.T) {
+	var target account
+	err := newJSONResponse(mismatchedAccount).JSON(&target)
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("JSON error = %T %v, want *DecodeError", err, err)
+	}
+	if decodeErr.Target != "models.account" {
+		t.Errorf("Target = %q, want models.account", decodeErr.Target)
+	}
+
+	// The offset points just past the offending value
+	field := strings.Index(mismatchedAccount, `"balance":"lots"`)
+	if decodeErr.Offset <= int64(field) || decodeErr.Offset > int64(field+len(`"balance":"lots"`)) {
+		t.Errorf("Offset = %d, want inside the balance field at %d", decodeErr.Offset, field)
+	}
+	if !bytes.Contains(decodeErr.Snippet, []byte(`"balance":"lots"`)) {
+		t.Errorf("Snippet = %q, want it to show the balance field", decodeErr.Snippet)
+	}
+	if len(decodeErr.Snippet) > 2*decodeSnippetRadius {
+		t.Errorf("Snippet is %d bytes, want at most %d", len(decodeErr.Snippet), 2*decodeSnippetRadius)
+	}
+	if got := mismatchedAccount[decodeErr.SnippetOffset:]; !strings.HasPrefix(got, string(decodeErr.Snippet)) {
+		t.Errorf("SnippetOffset %d does not locate the snippet", decodeErr.SnippetOffset)
+	}
+
+	if decodeErr.Kind() != interfaces.KindDecode || decodeErr.IsTemporary() || decodeErr.IsClientError() {
+		t.Errorf("kind %v, temporary %v: want a non-retryable decode error", decodeErr.Kind(), decodeErr.IsTemporary())
+	}
+	if errors.Is(err, ErrNotFound) || decodeErr.GetStatusCode() != http.StatusOK {
+		t.Errorf("decode error matched a status sentinel or lost the status: %v", err)
+	}
+	if !strings.Contains(err.Error(), "into models.account at offset") || !strings.Contains(err.Error(), "lots") {
+		t.Errorf("Error() = %q, want the target, offset and payload", err.Error())
+	}
+}
+
+func TestDecodeErrorSnippetBounds(t *testing.T) {
+	// Truncated input fails at the end of the body
+	body := `{"id":` + strings.Repeat(" ", 200)
+	decodeErr := NewDecodeError("failed", nil, &account{}, []byte(body), errors.New("opaque"))
+	if decodeErr.Offset != -1 || string(decodeErr.Snippet) != body[:2*decodeSnippetRadius] {
+		t.Errorf("unknown offset: Offset %d, Snippet %q; want -1 and the start of the body", decodeErr.Offset, decodeErr.Snippet)
+	}
+
+	// A failure beyond a streamed prefix leaves the snippet empty
+	err := newJSONResponse(mismatchedAccount).JSON(&account{})
+	var full *DecodeError
+	errors.As(err, &full)
+	prefix := NewDecodeError("failed", nil, &account{}, []byte(mismatchedAccount[:50]), full.Err)
+	if len(prefix.Snippet) != 0 {
+		t.Errorf("Snippet = %q for a failure past the prefix, want none", prefix.Snippet)
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"data-plane/internal/transport/interfaces"
 )
@@ -17,20 +21,125 @@ type HTTPError struct {
 	StatusCode int
 	Message    string
 	Err        error
+
+	// RetryAfter is the delay requested by the server via Retry-After (zero if absent).
+	RetryAfter time.Duration
+
+	// KindVal is the machine-readable error category (KindUnknown if unset).
+	KindVal interfaces.ErrorKind
+
+	// StageVal is the stage at which the request was interrupted (StageUnknown if unset).
+	StageVal interfaces.ErrorStage
+
+	// RequestID is the request/correlation ID of the failed exchange.
+	// If empty, GetRequestID falls back to the request and response headers.
+	RequestID string
+
+	// BodySnippet holds the start of the error response body, captured
+	// eagerly so it is available even after the response is closed.
+	BodySnippet []byte
+
+	// Truncated reports whether BodySnippet is shorter than the actual body.
+	Truncated bool
+
+	// Duration is the time from the start of the request to the failure.
+	Duration time.Duration
 }
 
 // Ensure HTTPError implements IHTTPError interface
 var _ interfaces.IHTTPError = (*HTTPError)(nil)
 
+// Status sentinel errors.
+// An HTTPError matches the sentinel for its status code via errors.Is.
+var (
+	// ErrNotFound matches HTTP 404 errors.
+	ErrNotFound = errors.New("not found")
+
+	// ErrUnauthorized matches HTTP 401 errors.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrConflict matches HTTP 409 errors.
+	ErrConflict = errors.New("conflict")
+
+	// ErrTooManyRequests matches HTTP 429 errors.
+	ErrTooManyRequests = errors.New("too many requests")
+
+	// ErrNotModified matches HTTP 304 responses to conditional requests,
+	// which response handlers report instead of decoding the empty body.
+	ErrNotModified = errors.New("not modified")
+)
+
+// statusSentinels maps status codes to their sentinel errors.
+var statusSentinels = map[int]error{
+	http.StatusNotFound:        ErrNotFound,
+	http.StatusUnauthorized:    ErrUnauthorized,
+	http.StatusConflict:        ErrConflict,
+	http.StatusTooManyRequests: ErrTooManyRequests,
+	http.StatusNotModified:     ErrNotModified,
+}
+
 // Error implements the error interface for HTTPError.
 func (e *HTTPError) Error() string {
+	message := e.Message
 	if e.Err != nil {
-		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+		message = fmt.Sprintf("%s: %v", e.Message, e.Err)
+	} else if e.StatusCode > 0 {
+		message = fmt.Sprintf("%s (status: %d)", e.Message, e.StatusCode)
+	}
+	if snippet := e.messageSnippet(); snippet != "" {
+		message = fmt.Sprintf("%s: %s", message, snippet)
+	}
+
+	message = redactRequest(message, e.Request)
+
+	// Wrapped HTTPErrors already report the timing and ID
+	var inner *HTTPError
+	if errors.As(e.Err, &inner) {
+		return message
+	}
+
+	var annotations []string
+	if e.Duration > 0 {
+		annotations = append(annotations, "elapsed: "+roundDuration(e.Duration).String())
+	}
+	if id := e.GetRequestID(); id != "" {
+		annotations = append(annotations, "request_id: "+id)
+	}
+	if len(annotations) > 0 {
+		message = fmt.Sprintf("%s [%s]", message, strings.Join(annotations, ", "))
+	}
+	return message
+}
+
+// roundDuration rounds d for display: to the millisecond, or to the
+// microsecond for sub-millisecond durations.
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+// messageSnippet returns the body snippet for inclusion in Error(), or an
+// empty string if it is binary or too large to be useful there.
+func (e *HTTPError) messageSnippet() string {
+	if len(e.BodySnippet) == 0 || len(e.BodySnippet) > maxSnippetInMessage {
+		return ""
+	}
+
+	contentType := ""
+	if e.Response != nil && e.Response.HTTPResponse() != nil {
+		contentType = e.Response.Header("Content-Type")
+	}
+	if !isTextual(contentType, e.BodySnippet) {
+		return ""
 	}
-	if e.StatusCode > 0 {
-		return fmt.Sprintf("%s (status: %d)", e.Message, e.StatusCode)
+
+	snippet := strings.TrimSpace(string(e.BodySnippet))
+	if e.Truncated {
+		snippet += "..."
 	}
-	return e.Message
+	return snippet
 }
 
 // Unwrap returns the underlying error for error chain support.
@@ -38,62 +147,94 @@ func (e *HTTPError) Unwrap() error {
 	return e.Err
 }
 
-// IsTimeout returns true if the error was caused by a timeout.
-func (e *HTTPError) IsTimeout() bool {
-	if e.Err == nil {
-		return false
+// Is reports whether target is the status sentinel matching this error's status code.
+// This lets callers write errors.Is(err, ErrNotFound) regardless of how the error was built.
+func (e *HTTPError) Is(target error) bool {
+	sentinel, ok := statusSentinels[e.StatusCode]
+	return ok && sentinel == target
+}
+
+// Kind returns the machine-readable error category.
+// An explicitly set KindVal wins; otherwise the status code decides, and
+// failing that the underlying error.
+func (e *HTTPError) Kind() interfaces.ErrorKind {
+	if e.KindVal != interfaces.KindUnknown {
+		return e.KindVal
 	}
+	if kind := ClassifyStatus(e.StatusCode); kind != interfaces.KindUnknown {
+		return kind
+	}
+	return Classify(e.Err)
+}
 
-	var netErr net.Error
-	if errors.As(e.Err, &netErr) {
-		return netErr.Timeout()
+// Stage returns the stage at which the request was interrupted.
+// If unset on this error, the stage of a wrapped HTTPError is reported.
+func (e *HTTPError) Stage() interfaces.ErrorStage {
+	if e.StageVal != interfaces.StageUnknown {
+		return e.StageVal
 	}
+	var inner *HTTPError
+	if errors.As(e.Err, &inner) {
+		return inner.Stage()
+	}
+	return interfaces.StageUnknown
+}
 
-	return false
+// IsTimeout returns true if the error was caused by a timeout.
+func (e *HTTPError) IsTimeout() bool {
+	return e.Kind() == interfaces.KindTimeout
 }
 
-// IsTemporary returns true if the error is temporary and the request can be retried.
-// For network errors, checks if it's a timeout. 5xx errors are considered temporary.
-func (e *HTTPError) IsTemporary() bool {
-	if e.Err == nil {
-		// 5xx errors are typically temporary
-		if e.StatusCode >= 500 && e.StatusCode < 600 {
-			return true
-		}
-		return false
-	}
+// IsDeadlineExceeded returns true if a deadline or timeout expired,
+// as opposed to the caller cancelling the request.
+func (e *HTTPError) IsDeadlineExceeded() bool {
+	return e.Kind() == interfaces.KindTimeout
+}
 
-	// Timeout errors are temporary
-	if e.IsTimeout() {
-		return true
-	}
+// IsCanceled returns true if the caller cancelled the request.
+func (e *HTTPError) IsCanceled() bool {
+	return e.Kind() == interfaces.KindCanceled
+}
 
-	// 5xx errors are typically temporary
-	if e.StatusCode >= 500 && e.StatusCode < 600 {
+// IsTemporary returns true if the error is temporary and the request can be retried.
+// Timeouts and 5xx errors are considered temporary.
+func (e *HTTPError) IsTemporary() bool {
+	switch e.Kind() {
+	case interfaces.KindTimeout, interfaces.KindServerStatus:
 		return true
+	default:
+		return false
 	}
-
-	return false
 }
 
 // IsClientError returns true if the error is a 4xx client error.
 func (e *HTTPError) IsClientError() bool {
-	return e.StatusCode >= 400 && e.StatusCode < 500
+	switch e.Kind() {
+	case interfaces.KindClientStatus:
+		return true
+	case interfaces.KindRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	default:
+		return false
+	}
 }
 
 // IsServerError returns true if the error is a 5xx server error.
 func (e *HTTPError) IsServerError() bool {
-	return e.StatusCode >= 500 && e.StatusCode < 600
+	return e.Kind() == interfaces.KindServerStatus
 }
 
 // IsNetworkError returns true if the error is a network-related error.
 func (e *HTTPError) IsNetworkError() bool {
-	if e.Err == nil {
+	switch e.Kind() {
+	case interfaces.KindDNS, interfaces.KindConnection, interfaces.KindTLS:
+		return true
+	case interfaces.KindTimeout:
+		var netErr net.Error
+		return errors.As(e.Err, &netErr)
+	default:
 		return false
 	}
-
-	var netErr net.Error
-	return errors.As(e.Err, &netErr)
 }
 
 // GetRequest returns the request that caused this error.
@@ -111,9 +252,23 @@ func (e *HTTPError) GetStatusCode() int {
 	return e.StatusCode
 }
 
-// GetMessage returns a human-readable error message.
+// GetRequestID returns the request/correlation ID of the failed exchange,
+// or an empty string if none is known.
+func (e *HTTPError) GetRequestID() string {
+	if e.RequestID != "" {
+		return e.RequestID
+	}
+	return RequestIDOf(e.Request, e.Response)
+}
+
+// GetDuration returns the time from the start of the request to the failure.
+func (e *HTTPError) GetDuration() time.Duration {
+	return e.Duration
+}
+
+// GetMessage returns a human-readable error message, with secrets redacted.
 func (e *HTTPError) GetMessage() string {
-	return e.Message
+	return redactRequest(e.Message, e.Request)
 }
 
 // GetError returns the underlying error if available.
@@ -122,13 +277,29 @@ func (e *HTTPError) GetError() error {
 }
 
 // GetResponseBody attempts to read and return the response body if available.
+// A captured BodySnippet is returned in preference to re-reading the response;
+// check Truncated to know whether it is complete.
 func (e *HTTPError) GetResponseBody() (string, error) {
+	if e.BodySnippet != nil {
+		return string(e.BodySnippet), nil
+	}
 	if e.Response == nil {
 		return "", fmt.Errorf("no response available")
 	}
 	return e.Response.BodyString()
 }
 
+// AsHTTPError finds the first HTTPError in err's chain, looking through
+// wrappers such as RetryExhaustedError and fmt.Errorf("%w"). Prefer it (or
+// errors.As) over a type assertion, which misses wrapped errors.
+func AsHTTPError(err error) (*HTTPError, bool) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr, true
+	}
+	return nil, false
+}
+
 // NewHTTPError creates a new HTTPError with the given message.
 func NewHTTPError(message string) *HTTPError {
 	return &HTTPError{
@@ -151,3 +322,26 @@ func WrapError(message string, err error) *HTTPError {
 		Err:     err,
 	}
 }
+
+// ParseRetryAfter parses a Retry-After header value, which may be either a
+// number of seconds or an HTTP date. Returns zero if the value is absent or invalid.
+func ParseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// ProblemContentType is the media type of an RFC 7807 problem document.
+const ProblemContentType = "application/problem+json"
+
+// errorJSON is the stable JSON shape of transport errors.
+// URLs and messages are redacted; the request and response are never included.
+type errorJSON struct {
+	Kind       string `json:"kind"`
+	StatusCode int    `json:"status_code"`
+	Message    string `json:"message"`
+	RequestID  string `json:"request_id"`
+	URL        string `json:"url"`
+	Method     string `json:"method"`
+	Attempts   int    `json:"attempts"`
+	Retriable  bool   `json:"retriable"`
+}
+
+// describe builds the JSON shape of an HTTPError after attempts sends.
+func (e *HTTPError) describe(attempts int) errorJSON {
+	doc := errorJSON{
+		Kind:       e.Kind().String(),
+		StatusCode: e.StatusCode,
+		Message:    e.Error(),
+		RequestID:  e.GetRequestID(),
+		Attempts:   attempts,
+		Retriable:  retriable(e.Kind(), e.StatusCode),
+	}
+	if e.Request != nil && e.Request.HTTPRequest() != nil {
+		doc.Message = redactMessage(doc.Message, e.Request.URL())
+		doc.URL = RedactURL(e.Request.URL())
+		doc.Method = e.Request.Method()
+	}
+	return doc
+}
+
+// MarshalJSON encodes the error in a stable shape with secrets redacted:
+// kind, status_code, message, request_id, url, method, attempts, retriable.
+func (e *HTTPError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.describe(1))
+}
+
+// describe builds the JSON shape of the exhausted request.
+func (e *RetryExhaustedError) describe() errorJSON {
+	var doc errorJSON
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		doc = httpErr.describe(e.Attempts)
+	} else {
+		doc = errorJSON{Kind: e.Kind().String(), Attempts: e.Attempts}
+	}
+
+	doc.Message = e.Error()
+	if request := e.GetRequest(); request != nil && request.HTTPRequest() != nil {
+		doc.Message = redactMessage(doc.Message, request.URL())
+	}
+	return doc
+}
+
+// MarshalJSON encodes the error in the same shape as HTTPError, reporting
+// the last attempt and the total number of attempts.
+func (e *RetryExhaustedError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.describe())
+}
+
+// retriable reports whether a failure of this kind is worth retrying later.
+func retriable(kind interfaces.ErrorKind, statusCode int) bool {
+	switch kind {
+	case interfaces.KindTimeout, interfaces.KindServerStatus, interfaces.KindRateLimited,
+		interfaces.KindConnection, interfaces.KindCircuitOpen, interfaces.KindBulkhead,
+		interfaces.KindDraining:
+		return true
+	default:
+		return statusCode == http.StatusRequestTimeout
+	}
+}
+
+// ProblemDetails is an RFC 7807 problem document describing a transport
+// error, suitable for returning upstream failures to a gateway's callers.
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance,omitempty"`
+
+	// Extension members
+	Kind           string `json:"kind"`
+	UpstreamStatus int    `json:"upstream_status,omitempty"`
+	RequestID      string `json:"request_id,omitempty"`
+	Method         string `json:"method,omitempty"`
+	URL            string `json:"url,omitempty"`
+	Attempts       int    `json:"attempts,omitempty"`
+	Retriable      bool   `json:"retriable"`
+}
+
+// NewProblemDetails describes err as an RFC 7807 problem. Status is the
+// status a gateway should answer with: upstream 4xx statuses pass through,
+// timeouts map to 504, local rejections to 429 or 503, and other failures
+// to 502. Secrets in URLs and messages are redacted.
+func NewProblemDetails(err error) ProblemDetails {
+	var doc errorJSON
+	var exhausted *RetryExhaustedError
+	var httpErr *HTTPError
+	switch {
+	case err == nil:
+		doc = errorJSON{Kind: interfaces.KindUnknown.String()}
+	case errors.As(err, &exhausted):
+		doc = exhausted.describe()
+	case errors.As(err, &httpErr):
+		doc = httpErr.describe(1)
+		doc.Message = err.Error()
+		if doc.URL != "" {
+			doc.Message = redactMessage(doc.Message, httpErr.Request.URL())
+		}
+	default:
+		doc = errorJSON{Kind: Classify(err).String(), Message: err.Error()}
+	}
+
+	status := problemStatus(Classify(err), doc.StatusCode)
+	return ProblemDetails{
+		Type:           "urn:transport:error:" + doc.Kind,
+		Title:          http.StatusText(status),
+		Status:         status,
+		Detail:         doc.Message,
+		Kind:           doc.Kind,
+		UpstreamStatus: doc.StatusCode,
+		RequestID:      doc.RequestID,
+		Method:         doc.Method,
+		URL:            doc.URL,
+		Attempts:       doc.Attempts,
+		Retriable:      doc.Retriable,
+	}
+}
+
+// problemStatus maps an error kind to the status a gateway should return.
+func problemStatus(kind interfaces.ErrorKind, upstreamStatus int) int {
+	switch kind {
+	case interfaces.KindClientStatus:
+		if upstreamStatus > 0 {
+			return upstreamStatus
+		}
+		return http.StatusBadGateway
+	case interfaces.KindTimeout:
+		return http.StatusGatewayTimeout
+	case interfaces.KindRateLimited:
+		return http.StatusTooManyRequests
+	case interfaces.KindCircuitOpen, interfaces.KindBulkhead, interfaces.KindDraining:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadGateway
+	}
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// Format implements fmt.Formatter. %v and %s print the compact Error() form;
+// %+v prints the full cause chain, one error per block with its details on
+// indented lines.
+func (e *HTTPError) Format(f fmt.State, verb rune) {
+	FormatError(f, verb, e)
+}
+
+// Format implements fmt.Formatter; see HTTPError.Format.
+func (e *RetryExhaustedError) Format(f fmt.State, verb rune) {
+	FormatError(f, verb, e)
+}
+
+// Format implements fmt.Formatter; see HTTPError.Format.
+func (e *DecodeError) Format(f fmt.State, verb rune) {
+	FormatError(f, verb, e)
+}
+
+// Format implements fmt.Formatter; see HTTPError.Format.
+func (e *GraphQLError) Format(f fmt.State, verb rune) {
+	FormatError(f, verb, e)
+}
+
+// FormatError writes err for the given verb, expanding the chain for %+v.
+// Error types in other packages that embed HTTPError call it from their own
+// Format method, since the promoted HTTPError.Format would print only the
+// embedded error.
+func FormatError(f fmt.State, verb rune, err error) {
+	switch {
+	case verb == 'v' && f.Flag('+'):
+		writeChain(f, err)
+	case verb == 'q':
+		fmt.Fprintf(f, "%q", err.Error())
+	default:
+		io.WriteString(f, err.Error())
+	}
+}
+
+// writeChain writes every error in err's chain, outermost first.
+func writeChain(w io.Writer, err error) {
+	attempt := 0
+	var request interfaces.IHTTPRequest
+	for depth := 0; err != nil; depth++ {
+		if depth > 0 {
+			io.WriteString(w, "\ncaused by: ")
+		}
+
+		switch e := err.(type) {
+		case *RetryExhaustedError:
+			fmt.Fprintf(w, "request failed after %d attempt(s)", e.Attempts)
+			writeField(w, "elapsed", roundDuration(e.Elapsed))
+			request = e.GetRequest()
+			for i, attemptErr := range e.Errors {
+				label := fmt.Sprintf("attempt %d", i+1)
+				if i < len(e.Durations) {
+					label = fmt.Sprintf("%s (%v)", label, roundDuration(e.Durations[i]))
+				}
+				writeField(w, label, attemptErr.Error())
+			}
+			attempt = e.Attempts
+			err = e.Last()
+
+		case *DecodeError:
+			fmt.Fprintf(w, "%s into %s", e.Message, e.Target)
+			if e.Offset >= 0 {
+				writeField(w, "offset", e.Offset)
+			}
+			if len(e.Snippet) > 0 && isTextual("", e.Snippet) {
+				writeField(w, "near", fmt.Sprintf("%q", e.Snippet))
+			}
+			writeHTTPFields(w, &e.HTTPError, 0)
+			request = e.Request
+			err = e.Err
+
+		case *GraphQLError:
+			io.WriteString(w, e.Message)
+			if e.Partial {
+				io.WriteString(w, " with partial data")
+			}
+			for i, detail := range e.Errors {
+				writeField(w, fmt.Sprintf("error %d", i+1), detail)
+			}
+			writeHTTPFields(w, &e.HTTPError, attempt)
+			attempt = 0
+			request = e.Request
+			err = e.Err
+
+		case *HTTPError:
+			io.WriteString(w, e.GetMessage())
+			writeHTTPFields(w, e, attempt)
+			attempt = 0
+			if e.Request != nil {
+				request = e.Request
+			}
+			err = e.Err
+
+		default:
+			// Descend only to reach a transport error further down; a plain
+			// cause is printed whole rather than split into fragments
+			next := errors.Unwrap(err)
+			var inner *HTTPError
+			if !errors.As(next, &inner) {
+				io.WriteString(w, redactRequest(err.Error(), request))
+				return
+			}
+			io.WriteString(w, redactRequest(ownMessage(err, next), request))
+			err = next
+		}
+	}
+}
+
+// writeHTTPFields writes the details of an HTTPError, skipping unset ones.
+func writeHTTPFields(w io.Writer, e *HTTPError, attempt int) {
+	writeField(w, "kind", e.Kind())
+	if e.StageVal != interfaces.StageUnknown {
+		writeField(w, "stage", e.StageVal)
+	}
+	if attempt > 0 {
+		writeField(w, "attempt", attempt)
+	}
+	if e.StatusCode > 0 {
+		writeField(w, "status", e.StatusCode)
+	}
+	if snippet := e.messageSnippet(); snippet != "" {
+		writeField(w, "body", snippet)
+	}
+	if e.Request != nil && e.Request.HTTPRequest() != nil {
+		writeField(w, "url", e.Request.Method()+" "+RedactURL(e.Request.URL()))
+	}
+	if id := e.GetRequestID(); id != "" {
+		writeField(w, "request_id", id)
+	}
+	if e.Duration > 0 {
+		writeField(w, "elapsed", roundDuration(e.Duration))
+	}
+}
+
+// writeField writes one indented "name: value" line.
+func writeField(w io.Writer, name string, value interface{}) {
+	fmt.Fprintf(w, "\n    %s: %v", name, value)
+}
+
+// ownMessage returns the part of err's message that is not repeated from
+// the error it wraps, e.g. "handler" for fmt.Errorf("handler: %w", next).
+func ownMessage(err, next error) string {
+	message := err.Error()
+	if next == nil {
+		return message
+	}
+	if own, ok := strings.CutSuffix(message, next.Error()); ok {
+		if own = strings.TrimSuffix(strings.TrimSpace(own), ":"); own != "" {
+			return own
+		}
+	}
+	return message
+}
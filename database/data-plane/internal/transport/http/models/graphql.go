@@ -0,0 +1,166 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// GraphQLRequest is the standard GraphQL-over-HTTP request envelope.
+type GraphQLRequest struct {
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// NewPersistedQuery creates an automatic persisted query request: only the
+// SHA-256 hash of query is sent, in the persistedQuery extension, so the
+// server must already know the query.
+func NewPersistedQuery(query string, variables map[string]interface{}) GraphQLRequest {
+	hash := sha256.Sum256([]byte(query))
+	return GraphQLRequest{
+		Variables: variables,
+		Extensions: map[string]interface{}{
+			"persistedQuery": map[string]interface{}{
+				"version":    1,
+				"sha256Hash": hex.EncodeToString(hash[:]),
+			},
+		},
+	}
+}
+
+// GraphQLResponse is the standard GraphQL response envelope.
+type GraphQLResponse struct {
+	Data       json.RawMessage        `json:"data"`
+	Errors     []GraphQLErrorDetail   `json:"errors"`
+	Extensions map[string]interface{} `json:"extensions"`
+}
+
+// HasData reports whether the response carries a non-null data member.
+func (r *GraphQLResponse) HasData() bool {
+	return len(r.Data) > 0 && string(r.Data) != "null"
+}
+
+// GraphQLErrorDetail is one entry of a GraphQL errors array.
+type GraphQLErrorDetail struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Locations  []GraphQLLocation      `json:"locations,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLLocation is a position in the query document.
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// PathString returns the path in dotted form, e.g. "user.friends.0.name".
+func (d GraphQLErrorDetail) PathString() string {
+	parts := make([]string, len(d.Path))
+	for i, segment := range d.Path {
+		switch v := segment.(type) {
+		case float64:
+			parts[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			parts[i] = fmt.Sprint(v)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// String returns the message, followed by the path if there is one.
+func (d GraphQLErrorDetail) String() string {
+	if path := d.PathString(); path != "" {
+		return fmt.Sprintf("%s (path: %s)", d.Message, path)
+	}
+	return d.Message
+}
+
+// GraphQLError is returned when a GraphQL response carries an errors array.
+// If the response also carried data, Partial is set and the raw data is
+// kept so it can still be decoded.
+// It implements the IHTTPError interface with Kind KindGraphQL.
+type GraphQLError struct {
+	HTTPError
+
+	// Errors holds the entries of the errors array
+	Errors []GraphQLErrorDetail
+
+	// Data is the raw data member, if any
+	Data json.RawMessage
+
+	// Partial is true if the response carried data alongside the errors
+	Partial bool
+}
+
+// Ensure GraphQLError implements IHTTPError interface
+var _ interfaces.IHTTPError = (*GraphQLError)(nil)
+
+// NewGraphQLError describes the errors of a GraphQL response.
+func NewGraphQLError(response interfaces.IHTTPResponse, envelope *GraphQLResponse) *GraphQLError {
+	graphQLErr := &GraphQLError{
+		HTTPError: HTTPError{
+			Response: response,
+			Message:  "GraphQL request failed",
+			KindVal:  interfaces.KindGraphQL,
+		},
+		Errors:  envelope.Errors,
+		Partial: envelope.HasData(),
+	}
+	if graphQLErr.Partial {
+		graphQLErr.Data = envelope.Data
+	}
+	if response != nil {
+		graphQLErr.Request = response.Request()
+		graphQLErr.StatusCode = response.StatusCode()
+		graphQLErr.RequestID = RequestIDOf(response.Request(), response)
+	}
+	return graphQLErr
+}
+
+// Error implements the error interface for GraphQLError.
+func (e *GraphQLError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, detail := range e.Errors {
+		messages[i] = detail.String()
+	}
+
+	prefix := e.Message
+	if e.Partial {
+		prefix += " with partial data"
+	}
+	message := fmt.Sprintf("%s: %s", prefix, strings.Join(messages, "; "))
+	return redactRequest(message, e.Request)
+}
+
+// Messages returns the message of each error.
+func (e *GraphQLError) Messages() []string {
+	messages := make([]string, len(e.Errors))
+	for i, detail := range e.Errors {
+		messages[i] = detail.Message
+	}
+	return messages
+}
+
+// DecodeData decodes the partial data into v.
+func (e *GraphQLError) DecodeData(v interface{}) error {
+	if !e.Partial {
+		return fmt.Errorf("GraphQL response carried no data")
+	}
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return NewDecodeError("failed to decode GraphQL data", e.Response, v, e.Data, err)
+	}
+	return nil
+}
+
+// IsTemporary returns false: the server processed the request and reported errors.
+func (e *GraphQLError) IsTemporary() bool {
+	return false
+}
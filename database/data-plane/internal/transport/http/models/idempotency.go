@@ -0,0 +1,22 @@
+package models
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// IdempotencyKeyHeader is the header carrying the idempotency key that lets
+// a server recognise a retried request it has already processed.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// NewIdempotencyKey returns a random (version 4) UUID.
+func NewIdempotencyKey() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return fmt.Sprintf("idem-%d", time.Now().UnixNano())
+	}
+	buf[6] = buf[6]&0x0f | 0x40 // Version 4
+	buf[8] = buf[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:16])
+}
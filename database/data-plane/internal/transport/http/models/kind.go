@@ -0,0 +1,138 @@
+package models
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// Classify returns the category of any error produced by the transport.
+// It is the single place where errors are categorized: the client, the
+// resiliency decorators and the retry policy all rely on it.
+func Classify(err error) interfaces.ErrorKind {
+	if err == nil {
+		return interfaces.KindUnknown
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Kind()
+	}
+
+	// Errors embedding an HTTPError, e.g. DecodeError and GraphQLError
+	var typedErr interfaces.IHTTPError
+	if errors.As(err, &typedErr) {
+		return typedErr.Kind()
+	}
+	return classifyCause(err)
+}
+
+// ClassifyStatus returns the category of an HTTP error status code,
+// or KindUnknown for non-error statuses.
+func ClassifyStatus(statusCode int) interfaces.ErrorKind {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return interfaces.KindRateLimited
+	case statusCode >= 400 && statusCode < 500:
+		return interfaces.KindClientStatus
+	case statusCode >= 500 && statusCode < 600:
+		return interfaces.KindServerStatus
+	default:
+		return interfaces.KindUnknown
+	}
+}
+
+// classifyCause categorizes an underlying (non-HTTPError) error.
+func classifyCause(err error) interfaces.ErrorKind {
+	if err == nil {
+		return interfaces.KindUnknown
+	}
+
+	// Errors that know their own kind, e.g. resiliency rejections
+	var kinded interfaces.IKindedError
+	if errors.As(err, &kinded) {
+		return kinded.ErrorKind()
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return interfaces.KindCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return interfaces.KindTimeout
+	case isDNSError(err):
+		return interfaces.KindDNS
+	case isTLSError(err):
+		return interfaces.KindTLS
+	case isDecodeError(err):
+		return interfaces.KindDecode
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return interfaces.KindTimeout
+	}
+
+	if isConnectionError(err) {
+		return interfaces.KindConnection
+	}
+	return interfaces.KindUnknown
+}
+
+// isDNSError reports whether err is a name resolution failure.
+func isDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// isTLSError reports whether err is a TLS handshake or certificate failure.
+func isTLSError(err error) bool {
+	var (
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	return isTLSHandshakeTimeout(err) ||
+		errors.As(err, &recordErr) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr)
+}
+
+// isDecodeError reports whether err came from decoding a JSON or XML body.
+func isDecodeError(err error) bool {
+	var (
+		syntaxErr    *json.SyntaxError
+		typeErr      *json.UnmarshalTypeError
+		xmlSyntaxErr *xml.SyntaxError
+	)
+	return errors.As(err, &syntaxErr) ||
+		errors.As(err, &typeErr) ||
+		errors.As(err, &xmlSyntaxErr)
+}
+
+// isConnectionError reports whether err is a failure to establish or keep a connection.
+func isConnectionError(err error) bool {
+	if isConnectionRefused(err) ||
+		isConnectionReset(err) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, net.ErrClosed) {
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
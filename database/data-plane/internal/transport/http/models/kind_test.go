@@ -0,0 +1,110 @@
+package models
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// kindedError is an error that reports its own kind.
+type kindedError struct{ kind interfaces.ErrorKind }
+
+func (e kindedError) Error() string                   { return "kinded" }
+func (e kindedError) ErrorKind() interfaces.ErrorKind { return e.kind }
+
+func TestClassify(t *testing.T) {
+	var syntaxErr *json.SyntaxError
+	decodeErr := json.Unmarshal([]byte("{"), &struct{}{})
+	if !errors.As(decodeErr, &syntaxErr) {
+		t.Fatalf("expected a json syntax error, got %T", decodeErr)
+	}
+	dial := func(err error) error { return &net.OpError{Op: "dial", Net: "tcp", Err: err} }
+
+	tests := []struct {
+		name string
+		err  error
+		want interfaces.ErrorKind
+	}{
+		{"nil", nil, interfaces.KindUnknown},
+		{"plain", errors.New("boom"), interfaces.KindUnknown},
+		{"canceled", fmt.Errorf("send: %w", context.Canceled), interfaces.KindCanceled},
+		{"deadline", context.DeadlineExceeded, interfaces.KindTimeout},
+		{"os timeout", dial(os.ErrDeadlineExceeded), interfaces.KindTimeout},
+		{"dns", &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, interfaces.KindDNS},
+		{"refused", dial(os.NewSyscallError("connect", syscall.ECONNREFUSED)), interfaces.KindConnection},
+		{"reset", fmt.Errorf("read: %w", syscall.ECONNRESET), interfaces.KindConnection},
+		{"closed", net.ErrClosed, interfaces.KindConnection},
+		{"tls", x509.UnknownAuthorityError{}, interfaces.KindTLS},
+		{"decode", decodeErr, interfaces.KindDecode},
+		{"self-kinded", fmt.Errorf("wrapped: %w", kindedError{interfaces.KindBulkhead}), interfaces.KindBulkhead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPErrorKind(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *HTTPError
+		want interfaces.ErrorKind
+	}{
+		{"404", &HTTPError{StatusCode: http.StatusNotFound}, interfaces.KindClientStatus},
+		{"429", &HTTPError{StatusCode: http.StatusTooManyRequests}, interfaces.KindRateLimited},
+		{"503", &HTTPError{StatusCode: http.StatusServiceUnavailable}, interfaces.KindServerStatus},
+		{"cause", &HTTPError{Err: context.DeadlineExceeded}, interfaces.KindTimeout},
+		{"explicit", &HTTPError{StatusCode: http.StatusNotFound, KindVal: interfaces.KindCircuitOpen}, interfaces.KindCircuitOpen},
+		{"nested", &HTTPError{Err: &HTTPError{StatusCode: http.StatusBadGateway}}, interfaces.KindServerStatus},
+		{"empty", &HTTPError{}, interfaces.KindUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Kind(); got != tt.want {
+				t.Errorf("Kind() = %v, want %v", got, tt.want)
+			}
+			if got := Classify(fmt.Errorf("outer: %w", tt.err)); got != tt.want {
+				t.Errorf("Classify of the wrapped error = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	decode := &DecodeError{HTTPError: HTTPError{Err: errors.New("bad"), KindVal: interfaces.KindDecode}}
+	if got := Classify(decode); got != interfaces.KindDecode {
+		t.Errorf("Classify(DecodeError) = %v, want %v", got, interfaces.KindDecode)
+	}
+}
+
+func TestBooleanHelpersFollowKind(t *testing.T) {
+	tests := []struct {
+		err                                        *HTTPError
+		timeout, canceled, temporary, clientStatus bool
+	}{
+		{err: &HTTPError{Err: context.DeadlineExceeded}, timeout: true, temporary: true},
+		{err: &HTTPError{Err: context.Canceled}, canceled: true},
+		{err: &HTTPError{StatusCode: http.StatusBadGateway}, temporary: true},
+		{err: &HTTPError{StatusCode: http.StatusNotFound}, clientStatus: true},
+		{err: &HTTPError{StatusCode: http.StatusTooManyRequests}, clientStatus: true},
+		{err: &HTTPError{KindVal: interfaces.KindRateLimited}},
+	}
+	for _, tt := range tests {
+		e := tt.err
+		if e.IsTimeout() != tt.timeout || e.IsDeadlineExceeded() != tt.timeout ||
+			e.IsCanceled() != tt.canceled || e.IsTemporary() != tt.temporary ||
+			e.IsClientError() != tt.clientStatus {
+			t.Errorf("%+v: helpers disagree with kind %v", e, e.Kind())
+		}
+	}
+}
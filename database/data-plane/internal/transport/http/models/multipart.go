@@ -0,0 +1,157 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"sync"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// ErrBodyNotReplayable is returned when a request has to be re-sent (e.g.
+// retried) but its body was streamed from a one-shot reader.
+var ErrBodyNotReplayable = errors.New("request body cannot be replayed")
+
+// FormField returns a multipart part carrying a plain form value.
+func FormField(name, value string) interfaces.MultipartPart {
+	return interfaces.MultipartPart{
+		FieldName: name,
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(value)), nil
+		},
+	}
+}
+
+// FilePart returns a replayable multipart file part; open is called every
+// time the body is sent, e.g. func() (io.ReadCloser, error) { return os.Open(path) }.
+func FilePart(fieldName, fileName string, open func() (io.ReadCloser, error)) interfaces.MultipartPart {
+	return interfaces.MultipartPart{
+		FieldName: fieldName,
+		FileName:  fileName,
+		Open:      open,
+	}
+}
+
+// ReaderPart returns a multipart file part streamed from reader. It can be
+// sent only once: a retry fails with ErrBodyNotReplayable.
+func ReaderPart(fieldName, fileName string, reader io.Reader) interfaces.MultipartPart {
+	return interfaces.MultipartPart{
+		FieldName: fieldName,
+		FileName:  fileName,
+		Reader:    reader,
+	}
+}
+
+// MultipartBody streams a multipart/form-data body through an io.Pipe, so
+// parts are copied from their readers as the request is written and memory
+// use does not depend on the part sizes.
+type MultipartBody struct {
+	boundary string
+	parts    []interfaces.MultipartPart
+
+	mu     sync.Mutex
+	opened bool
+}
+
+// NewMultipartBody creates a MultipartBody with a random boundary.
+func NewMultipartBody(parts ...interfaces.MultipartPart) *MultipartBody {
+	return &MultipartBody{
+		boundary: multipart.NewWriter(io.Discard).Boundary(),
+		parts:    parts,
+	}
+}
+
+// ContentType returns the multipart/form-data Content-Type, boundary included.
+func (b *MultipartBody) ContentType() string {
+	return "multipart/form-data; boundary=" + b.boundary
+}
+
+// Replayable reports whether every part can be re-opened.
+func (b *MultipartBody) Replayable() bool {
+	for _, part := range b.parts {
+		if part.Open == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Open returns a reader streaming the encoded body. It fails with
+// ErrBodyNotReplayable if a one-shot part has already been sent.
+func (b *MultipartBody) Open() (io.ReadCloser, error) {
+	b.mu.Lock()
+	if b.opened && !b.Replayable() {
+		b.mu.Unlock()
+		return nil, ErrBodyNotReplayable
+	}
+	b.opened = true
+	b.mu.Unlock()
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(b.write(writer))
+	}()
+	return reader, nil
+}
+
+// write encodes every part to w.
+func (b *MultipartBody) write(w io.Writer) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+	for _, part := range b.parts {
+		if err := writePart(mw, part); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// writePart copies one part into mw.
+func writePart(mw *multipart.Writer, part interfaces.MultipartPart) error {
+	header := make(textproto.MIMEHeader)
+	disposition := fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(part.FieldName))
+	if part.FileName != "" {
+		disposition += fmt.Sprintf(`; filename="%s"`, escapeQuotes(part.FileName))
+	}
+	header.Set("Content-Disposition", disposition)
+	switch {
+	case part.ContentType != "":
+		header.Set("Content-Type", part.ContentType)
+	case part.FileName != "":
+		header.Set("Content-Type", "application/octet-stream")
+	}
+
+	dst, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	src := part.Reader
+	if part.Open != nil {
+		opened, err := part.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open multipart part %q: %w", part.FieldName, err)
+		}
+		defer opened.Close()
+		src = opened
+	}
+	if src == nil {
+		return nil
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// quoteEscaper escapes quotes and backslashes in Content-Disposition parameters.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes escapes s for use in a quoted header parameter.
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
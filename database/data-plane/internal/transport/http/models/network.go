@@ -0,0 +1,224 @@
+package models
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// ============= DNS =============
+
+// DNSError returns the name resolution failure behind the error, or nil.
+// Its IsNotFound and IsTemporary fields tell NXDOMAIN apart from transient
+// resolver failures such as SERVFAIL or timeouts.
+func (e *HTTPError) DNSError() *net.DNSError {
+	var dnsErr *net.DNSError
+	if errors.As(e.Err, &dnsErr) {
+		return dnsErr
+	}
+	return nil
+}
+
+// IsDNSError returns true if the host name could not be resolved.
+func (e *HTTPError) IsDNSError() bool {
+	return e.DNSError() != nil
+}
+
+// IsDNSNotFound returns true if the host name does not exist (NXDOMAIN).
+func (e *HTTPError) IsDNSNotFound() bool {
+	dnsErr := e.DNSError()
+	return dnsErr != nil && dnsErr.IsNotFound
+}
+
+// isRetryableDNS reports whether a resolution failure may succeed on retry:
+// transient resolver failures are retried, missing hosts are not.
+func isRetryableDNS(dnsErr *net.DNSError) bool {
+	return !dnsErr.IsNotFound && (dnsErr.IsTemporary || dnsErr.IsTimeout)
+}
+
+// ============= TLS =============
+
+// TLSReason is the structured cause of a TLS failure.
+type TLSReason int
+
+const (
+	// TLSReasonUnknown is any other handshake or certificate failure.
+	TLSReasonUnknown TLSReason = iota
+
+	// TLSReasonExpired means the peer certificate is expired or not yet valid.
+	TLSReasonExpired
+
+	// TLSReasonHostnameMismatch means the certificate is not valid for the host.
+	TLSReasonHostnameMismatch
+
+	// TLSReasonUnknownAuthority means the certificate is signed by an untrusted CA.
+	TLSReasonUnknownAuthority
+
+	// TLSReasonHandshakeTimeout means the TLS handshake did not complete in time.
+	TLSReasonHandshakeTimeout
+)
+
+// tlsReasonNames holds the String form of each reason.
+var tlsReasonNames = map[TLSReason]string{
+	TLSReasonUnknown:          "unknown",
+	TLSReasonExpired:          "expired",
+	TLSReasonHostnameMismatch: "hostname_mismatch",
+	TLSReasonUnknownAuthority: "unknown_authority",
+	TLSReasonHandshakeTimeout: "handshake_timeout",
+}
+
+// String returns the reason's name.
+func (r TLSReason) String() string {
+	if name, ok := tlsReasonNames[r]; ok {
+		return name
+	}
+	return tlsReasonNames[TLSReasonUnknown]
+}
+
+// TLSDetails describes a TLS failure. Subject and NotAfter come from the
+// peer certificate and are empty if it was not available.
+type TLSDetails struct {
+	Reason   TLSReason
+	Subject  string
+	NotAfter time.Time
+}
+
+// IsTLSError returns true if the TLS handshake or certificate verification failed.
+func (e *HTTPError) IsTLSError() bool {
+	return isTLSError(e.Err)
+}
+
+// TLSDetails returns the structured cause of a TLS failure.
+// The boolean is false if the error is not a TLS failure.
+func (e *HTTPError) TLSDetails() (TLSDetails, bool) {
+	if !isTLSError(e.Err) {
+		return TLSDetails{}, false
+	}
+	return tlsDetailsOf(e.Err), true
+}
+
+// tlsDetailsOf extracts the reason and peer certificate of a TLS failure.
+func tlsDetailsOf(err error) TLSDetails {
+	var (
+		details      TLSDetails
+		cert         *x509.Certificate
+		invalidErr   x509.CertificateInvalidError
+		hostnameErr  x509.HostnameError
+		authorityErr x509.UnknownAuthorityError
+		verifyErr    *tls.CertificateVerificationError
+	)
+
+	switch {
+	case isTLSHandshakeTimeout(err):
+		details.Reason = TLSReasonHandshakeTimeout
+	case errors.As(err, &invalidErr):
+		cert = invalidErr.Cert
+		if invalidErr.Reason == x509.Expired {
+			details.Reason = TLSReasonExpired
+		}
+	case errors.As(err, &hostnameErr):
+		cert = hostnameErr.Certificate
+		details.Reason = TLSReasonHostnameMismatch
+	case errors.As(err, &authorityErr):
+		cert = authorityErr.Cert
+		details.Reason = TLSReasonUnknownAuthority
+	}
+
+	if cert == nil && errors.As(err, &verifyErr) && len(verifyErr.UnverifiedCertificates) > 0 {
+		cert = verifyErr.UnverifiedCertificates[0]
+	}
+	if cert != nil {
+		details.Subject = cert.Subject.String()
+		details.NotAfter = cert.NotAfter
+	}
+	return details
+}
+
+// isTLSHandshakeTimeout reports whether err is net/http's TLS handshake
+// timeout, which has no exported type.
+func isTLSHandshakeTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout() &&
+		strings.Contains(err.Error(), "TLS handshake timeout")
+}
+
+// ============= CONNECTION =============
+
+// IsConnectionRefused returns true if the upstream refused the connection,
+// typically because the service is down or the port is wrong.
+func (e *HTTPError) IsConnectionRefused() bool {
+	return isConnectionRefused(e.Err)
+}
+
+// IsConnectionReset returns true if the upstream reset the connection,
+// typically because it crashed mid-request.
+func (e *HTTPError) IsConnectionReset() bool {
+	return isConnectionReset(e.Err)
+}
+
+// isConnectionRefused reports whether err is ECONNREFUSED. syscall.Errno
+// unwraps from net.OpError and os.SyscallError on both Linux and macOS.
+func isConnectionRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// isConnectionReset reports whether err is ECONNRESET.
+func isConnectionReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET)
+}
+
+// ============= RETRY CLASSIFICATION =============
+
+// IsRetryableNetworkError reports whether a network-level failure is worth
+// retrying. It is used by the retry policy for errors without a status code:
+// temporary DNS failures, TLS handshake timeouts, refused connections and
+// connections reset before any of the response body was read are retried.
+func IsRetryableNetworkError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return isRetryableDNS(dnsErr)
+	}
+	if isTLSError(err) {
+		return isTLSHandshakeTimeout(err)
+	}
+	if isConnectionRefused(err) {
+		return true
+	}
+	if isConnectionReset(err) {
+		// Only safe to retry if none of the response was consumed
+		var httpErr *HTTPError
+		return !errors.As(err, &httpErr) || httpErr.Stage() != interfaces.StageReadBody
+	}
+	return false
+}
+
+// NetworkErrorMessage returns the message for a request that failed before
+// a response was received, naming the specific network failure if known.
+func NetworkErrorMessage(method string, err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return fmt.Sprintf("%s request failed: cannot resolve host %q", method, dnsErr.Name)
+	}
+	if isConnectionRefused(err) {
+		return fmt.Sprintf("%s request failed: connection refused", method)
+	}
+	if isConnectionReset(err) {
+		return fmt.Sprintf("%s request failed: connection reset by peer", method)
+	}
+	if isTLSError(err) {
+		details := tlsDetailsOf(err)
+		if details.Subject != "" {
+			return fmt.Sprintf("%s request failed: TLS %s for certificate %q (expires %s)",
+				method, details.Reason, details.Subject, details.NotAfter.Format(time.RFC3339))
+		}
+		return fmt.Sprintf("%s request failed: TLS %s", method, details.Reason)
+	}
+	return fmt.Sprintf("%s request failed", method)
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// dnsFailure wraps dnsErr the way net/http reports a failed dial.
+func dnsFailure(dnsErr *net.DNSError) *HTTPError {
+	err := fmt.Errorf("Get %q: %w", "http://"+dnsErr.Name+"/", &net.OpError{Op: "dial", Net: "tcp", Err: dnsErr})
+	return &HTTPError{Message: NetworkErrorMessage("GET", err), Err: err}
+}
+
+func TestDNSErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		dnsErr    *net.DNSError
+		notFound  bool
+		retryable bool
+	}{
+		{"nxdomain", &net.DNSError{Err: "no such host", Name: "missing.example", IsNotFound: true}, true, false},
+		{"servfail", &net.DNSError{Err: "server misbehaving", Name: "flaky.example", IsTemporary: true}, false, true},
+		{"timeout", &net.DNSError{Err: "i/o timeout", Name: "slow.example", IsTimeout: true}, false, true},
+		{"permanent", &net.DNSError{Err: "bad name", Name: "odd.example"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dnsFailure(tt.dnsErr)
+			if !err.IsDNSError() || err.DNSError() != tt.dnsErr {
+				t.Errorf("DNSError() = %v, want the wrapped %v", err.DNSError(), tt.dnsErr)
+			}
+			if err.IsDNSNotFound() != tt.notFound {
+				t.Errorf("IsDNSNotFound() = %v, want %v", err.IsDNSNotFound(), tt.notFound)
+			}
+			if got := IsRetryableNetworkError(err); got != tt.retryable {
+				t.Errorf("IsRetryableNetworkError() = %v, want %v", got, tt.retryable)
+			}
+			if err.Kind() != interfaces.KindDNS {
+				t.Errorf("Kind() = %v, want %v", err.Kind(), interfaces.KindDNS)
+			}
+			if !strings.Contains(err.Error(), `cannot resolve host "`+tt.dnsErr.Name+`"`) {
+				t.Errorf("Error() = %q does not name the host", err.Error())
+			}
+		})
+	}
+
+	other := &HTTPError{Message: NetworkErrorMessage("GET", errors.New("refused")), Err: errors.New("refused")}
+	if other.IsDNSError() || other.IsDNSNotFound() || strings.Contains(other.Error(), "resolve") {
+		t.Errorf("non-DNS error reported as DNS: %v", other)
+	}
+}
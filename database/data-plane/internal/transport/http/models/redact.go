@@ -0,0 +1,121 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// redactedValue replaces secrets in redacted output.
+const redactedValue = "REDACTED"
+
+// sensitiveMu guards sensitiveQueryParams.
+var sensitiveMu sync.RWMutex
+
+// sensitiveQueryParams lists query parameters (lower-cased) whose values are
+// treated as secrets. Extend it with RedactQueryParams.
+var sensitiveQueryParams = map[string]bool{
+	"access_token":         true,
+	"api_key":              true,
+	"apikey":               true,
+	"key":                  true,
+	"password":             true,
+	"secret":               true,
+	"sig":                  true,
+	"signature":            true,
+	"token":                true,
+	"x-amz-credential":     true,
+	"x-amz-security-token": true,
+	"x-amz-signature":      true,
+}
+
+// RedactQueryParams adds query parameter names (case-insensitive) whose
+// values are masked wherever URLs are formatted.
+func RedactQueryParams(names ...string) {
+	sensitiveMu.Lock()
+	defer sensitiveMu.Unlock()
+
+	for _, name := range names {
+		sensitiveQueryParams[strings.ToLower(name)] = true
+	}
+}
+
+// isSensitiveQueryParam reports whether the named parameter holds a secret.
+func isSensitiveQueryParam(name string) bool {
+	sensitiveMu.RLock()
+	defer sensitiveMu.RUnlock()
+	return sensitiveQueryParams[strings.ToLower(name)]
+}
+
+// RedactURL returns rawURL with its userinfo password and the values of
+// sensitive query parameters masked. It only affects formatting; requests
+// are always sent with the original URL. Unparseable input is returned
+// unchanged.
+func RedactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	if parsed.User != nil {
+		if _, hasPassword := parsed.User.Password(); hasPassword {
+			parsed.User = url.UserPassword(parsed.User.Username(), redactedValue)
+		}
+	}
+
+	if parsed.RawQuery != "" {
+		query := parsed.Query()
+		changed := false
+		for name, values := range query {
+			if !isSensitiveQueryParam(name) {
+				continue
+			}
+			for i := range values {
+				values[i] = redactedValue
+			}
+			changed = true
+		}
+		if changed {
+			parsed.RawQuery = query.Encode()
+		}
+	}
+	return parsed.String()
+}
+
+// redactMessage masks every occurrence of rawURL in message.
+func redactMessage(message, rawURL string) string {
+	if rawURL == "" {
+		return message
+	}
+	return strings.ReplaceAll(message, rawURL, RedactURL(rawURL))
+}
+
+// minRedactedCredential is the shortest credential masked in messages;
+// shorter values would mask unrelated text.
+const minRedactedCredential = 8
+
+// redactRequest masks the request's URL secrets and any Authorization
+// credential that appears in message, including the password of Basic
+// credentials, which a server may echo decoded. The request itself is not
+// modified.
+func redactRequest(message string, request interfaces.IHTTPRequest) string {
+	if request == nil || request.HTTPRequest() == nil {
+		return message
+	}
+	message = redactMessage(message, request.URL())
+
+	if _, password, ok := request.HTTPRequest().BasicAuth(); ok && len(password) >= minRedactedCredential {
+		message = strings.ReplaceAll(message, password, redactedValue)
+	}
+
+	authorization := request.Header("Authorization")
+	if _, credential, found := strings.Cut(authorization, " "); found {
+		authorization = credential
+	}
+	if credential := strings.TrimSpace(authorization); len(credential) >= minRedactedCredential {
+		message = strings.ReplaceAll(message, credential, redactedValue)
+	}
+	return message
+}
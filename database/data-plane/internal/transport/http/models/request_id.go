@@ -0,0 +1,21 @@
+package models
+
+import "data-plane/internal/transport/interfaces"
+
+// RequestIDHeader is the header carrying the request/correlation ID.
+const RequestIDHeader = "X-Request-Id"
+
+// RequestIDOf returns the request/correlation ID of an exchange: the ID sent
+// on the request (typically set by RequestIDMiddleware) or, failing that, the
+// one echoed by the upstream on the response. Either argument may be nil.
+func RequestIDOf(request interfaces.IHTTPRequest, response interfaces.IHTTPResponse) string {
+	if request != nil && request.HTTPRequest() != nil {
+		if id := request.Header(RequestIDHeader); id != "" {
+			return id
+		}
+	}
+	if response != nil && response.HTTPResponse() != nil {
+		return response.Header(RequestIDHeader)
+	}
+	return ""
+}
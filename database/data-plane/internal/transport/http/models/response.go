@@ -1,6 +1,8 @@
 package models
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -93,7 +95,13 @@ func (r *Response) Body() ([]byte, error) {
 
 	data, err := io.ReadAll(r.HttpResp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, &HTTPError{
+			Request:  r.RequestRef,
+			Message:  "failed to read response body",
+			Err:      err,
+			KindVal:  Classify(err),
+			StageVal: interfaces.StageReadBody,
+		}
 	}
 
 	r.BodyData = data
@@ -118,7 +126,7 @@ func (r *Response) JSON(v interface{}) error {
 	}
 
 	if err := json.Unmarshal(body, v); err != nil {
-		return fmt.Errorf("failed to unmarshal JSON response: %w", err)
+		return NewDecodeError("failed to unmarshal JSON response", r, v, body, err)
 	}
 
 	return nil
@@ -132,6 +140,37 @@ func (r *Response) Close() error {
 	return nil
 }
 
+// BindCancel arranges for cancel to be called once the response body is closed.
+// Use this when the response outlives the call that created its context,
+// so the context stays alive while the body is being read.
+// If there is no body to close, cancel is called immediately.
+func (r *Response) BindCancel(cancel context.CancelFunc) {
+	if r.BodyRead || r.HttpResp == nil || r.HttpResp.Body == nil {
+		cancel()
+		return
+	}
+	r.HttpResp.Body = CancelOnClose(r.HttpResp.Body, cancel)
+}
+
+// CancelOnClose returns body wrapped so that closing it also calls cancel.
+// It is the building block of BindCancel for responses of other types.
+func CancelOnClose(body io.ReadCloser, cancel context.CancelFunc) io.ReadCloser {
+	return &cancelOnClose{ReadCloser: body, cancel: cancel}
+}
+
+// cancelOnClose releases a context once the body it guards is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the associated context.
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
 // Request returns the original IHTTPRequest that generated this response.
 func (r *Response) Request() interfaces.IHTTPRequest {
 	return r.RequestRef
@@ -150,14 +189,78 @@ func (r *Response) ContentLength() int64 {
 	return r.HttpResp.ContentLength
 }
 
+// Protocol returns the negotiated protocol, e.g. "HTTP/2.0".
+func (r *Response) Protocol() string {
+	if r.HttpResp == nil {
+		return ""
+	}
+	return r.HttpResp.Proto
+}
+
 // HTTPResponse returns the underlying *http.Response object.
 func (r *Response) HTTPResponse() *http.Response {
 	return r.HttpResp
 }
 
+// WriteTo streams the response body into w without buffering it in memory.
+// The body is closed once it has been fully copied.
+func (r *Response) WriteTo(w io.Writer) (int64, error) {
+	reader := r.Reader()
+	if reader == nil {
+		return 0, fmt.Errorf("response body is nil")
+	}
+	defer reader.Close()
+
+	return io.Copy(w, reader)
+}
+
+// VerifyChecksum enables integrity verification of the response body.
+// If expected is nil, the digest is taken from the Content-MD5 or
+// x-amz-checksum-* headers; when no such header is present this is a no-op.
+// The digest is computed incrementally as the body is read, and a
+// ChecksumMismatchError is returned at EOF if it does not match.
+func (r *Response) VerifyChecksum(expected *Checksum) error {
+	if expected == nil {
+		var err error
+		expected, err = ChecksumFromHeaders(r.Headers())
+		if err != nil {
+			return err
+		}
+		if expected == nil {
+			return nil
+		}
+	}
+
+	hasher, err := expected.newHash()
+	if err != nil {
+		return err
+	}
+
+	// Body already cached: verify the stored bytes directly
+	if r.BodyRead {
+		hasher.Write(r.BodyData)
+		return expected.verify(hasher.Sum(nil))
+	}
+
+	if r.HttpResp == nil || r.HttpResp.Body == nil {
+		return fmt.Errorf("response body is nil")
+	}
+
+	r.HttpResp.Body = &checksumReader{
+		reader:   r.HttpResp.Body,
+		hash:     hasher,
+		expected: expected,
+	}
+	return nil
+}
+
 // Reader returns an io.ReadCloser for streaming the response body.
 // Use this for large responses to avoid loading everything into memory.
+// If the body has already been read via Body(), the cached bytes are returned.
 func (r *Response) Reader() io.ReadCloser {
+	if r.BodyRead {
+		return io.NopCloser(bytes.NewReader(r.BodyData))
+	}
 	if r.HttpResp == nil {
 		return nil
 	}
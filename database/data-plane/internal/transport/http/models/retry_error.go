@@ -0,0 +1,213 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// RetryExhaustedError is the final error of a request that failed on every
+// attempt. It records the history of the attempts while still unwrapping to
+// the last attempt's error, so errors.Is and errors.As match the same
+// targets as they would for a single failure.
+// It implements the IHTTPError interface.
+type RetryExhaustedError struct {
+	// Attempts is the number of sends made
+	Attempts int
+
+	// Errors holds the error of each attempt, in order
+	Errors []error
+
+	// Durations holds how long each attempt took, in order
+	Durations []time.Duration
+
+	// Elapsed is the total time spent, including backoff delays
+	Elapsed time.Duration
+}
+
+// Ensure RetryExhaustedError implements IHTTPError interface
+var _ interfaces.IHTTPError = (*RetryExhaustedError)(nil)
+
+// Error implements the error interface for RetryExhaustedError.
+func (e *RetryExhaustedError) Error() string {
+	return fmt.Sprintf("request failed after %d attempt(s) in %v: %v",
+		e.Attempts, roundDuration(e.Elapsed), e.Last())
+}
+
+// Unwrap returns the last attempt's error.
+func (e *RetryExhaustedError) Unwrap() error {
+	return e.Last()
+}
+
+// Last returns the last attempt's error.
+func (e *RetryExhaustedError) Last() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e.Errors[len(e.Errors)-1]
+}
+
+// AttemptErrors returns every attempt's error joined with errors.Join.
+func (e *RetryExhaustedError) AttemptErrors() error {
+	return errors.Join(e.Errors...)
+}
+
+// lastHTTPError returns the last attempt's error as an HTTPError, if it is one.
+func (e *RetryExhaustedError) lastHTTPError() *HTTPError {
+	var httpErr *HTTPError
+	if errors.As(e.Last(), &httpErr) {
+		return httpErr
+	}
+	return nil
+}
+
+// Kind returns the category of the last attempt's error.
+func (e *RetryExhaustedError) Kind() interfaces.ErrorKind {
+	return Classify(e.Last())
+}
+
+// IsTimeout returns true if the last attempt timed out.
+func (e *RetryExhaustedError) IsTimeout() bool {
+	return e.Kind() == interfaces.KindTimeout
+}
+
+// Stage returns the stage at which the last attempt was interrupted.
+func (e *RetryExhaustedError) Stage() interfaces.ErrorStage {
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		return httpErr.Stage()
+	}
+	return interfaces.StageUnknown
+}
+
+// IsDeadlineExceeded returns true if the last attempt ran out of time.
+func (e *RetryExhaustedError) IsDeadlineExceeded() bool {
+	return e.Kind() == interfaces.KindTimeout
+}
+
+// IsCanceled returns true if the last attempt was cancelled by the caller.
+func (e *RetryExhaustedError) IsCanceled() bool {
+	return e.Kind() == interfaces.KindCanceled
+}
+
+// IsTemporary returns true if the last attempt failed with a temporary error.
+func (e *RetryExhaustedError) IsTemporary() bool {
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		return httpErr.IsTemporary()
+	}
+	return e.Kind() == interfaces.KindTimeout
+}
+
+// IsClientError returns true if the last attempt got a 4xx response.
+func (e *RetryExhaustedError) IsClientError() bool {
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		return httpErr.IsClientError()
+	}
+	return false
+}
+
+// IsServerError returns true if the last attempt got a 5xx response.
+func (e *RetryExhaustedError) IsServerError() bool {
+	return e.Kind() == interfaces.KindServerStatus
+}
+
+// IsNetworkError returns true if the last attempt failed at the network level.
+func (e *RetryExhaustedError) IsNetworkError() bool {
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		return httpErr.IsNetworkError()
+	}
+	switch e.Kind() {
+	case interfaces.KindDNS, interfaces.KindConnection, interfaces.KindTLS:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsDNSError returns true if the last attempt could not resolve the host name.
+func (e *RetryExhaustedError) IsDNSError() bool {
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		return httpErr.IsDNSError()
+	}
+	return false
+}
+
+// IsTLSError returns true if the last attempt failed the TLS handshake.
+func (e *RetryExhaustedError) IsTLSError() bool {
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		return httpErr.IsTLSError()
+	}
+	return false
+}
+
+// IsConnectionRefused returns true if the last attempt's connection was refused.
+func (e *RetryExhaustedError) IsConnectionRefused() bool {
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		return httpErr.IsConnectionRefused()
+	}
+	return false
+}
+
+// IsConnectionReset returns true if the last attempt's connection was reset.
+func (e *RetryExhaustedError) IsConnectionReset() bool {
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		return httpErr.IsConnectionReset()
+	}
+	return false
+}
+
+// GetRequest returns the request that failed.
+func (e *RetryExhaustedError) GetRequest() interfaces.IHTTPRequest {
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		return httpErr.Request
+	}
+	return nil
+}
+
+// GetResponse returns the last attempt's response, if any.
+func (e *RetryExhaustedError) GetResponse() interfaces.IHTTPResponse {
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		return httpErr.Response
+	}
+	return nil
+}
+
+// GetStatusCode returns the last attempt's status code (0 for network errors).
+func (e *RetryExhaustedError) GetStatusCode() int {
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		return httpErr.StatusCode
+	}
+	return 0
+}
+
+// GetRequestID returns the request/correlation ID of the failed request.
+func (e *RetryExhaustedError) GetRequestID() string {
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		return httpErr.GetRequestID()
+	}
+	return ""
+}
+
+// GetDuration returns the time from the first attempt to the final failure.
+func (e *RetryExhaustedError) GetDuration() time.Duration {
+	return e.Elapsed
+}
+
+// GetMessage returns a human-readable error message.
+func (e *RetryExhaustedError) GetMessage() string {
+	return fmt.Sprintf("request failed after %d attempt(s)", e.Attempts)
+}
+
+// GetError returns the last attempt's error.
+func (e *RetryExhaustedError) GetError() error {
+	return e.Last()
+}
+
+// GetResponseBody attempts to read and return the last response's body.
+func (e *RetryExhaustedError) GetResponseBody() (string, error) {
+	if httpErr := e.lastHTTPError(); httpErr != nil {
+		return httpErr.GetResponseBody()
+	}
+	return "", fmt.Errorf("no response available")
+}
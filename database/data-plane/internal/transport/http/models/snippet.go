@@ -0,0 +1,97 @@
+package models
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultErrorBodyLimit is how many bytes of an error response body are
+// captured on the HTTPError by default.
+const DefaultErrorBodyLimit = 4 << 10
+
+// maxSnippetInMessage is the largest snippet included in Error() output.
+const maxSnippetInMessage = 512
+
+// CaptureBodySnippet eagerly reads up to limit bytes of the response body,
+// so the snippet survives the response being closed or consumed elsewhere.
+// If the whole body fit, it is cached on the response and the original body
+// closed. Otherwise the response body still reads in full: the snippet
+// followed by the rest of the stream, and closing it closes the original.
+func CaptureBodySnippet(resp *Response, limit int) (snippet []byte, truncated bool) {
+	if resp == nil || resp.HttpResp == nil || resp.HttpResp.Body == nil || resp.BodyRead || limit <= 0 {
+		return nil, false
+	}
+
+	body := resp.HttpResp.Body
+	data, err := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+
+	truncated = len(data) > limit
+	switch {
+	case err != nil:
+		body.Close()
+		resp.HttpResp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), errReader{err}))
+	case truncated:
+		resp.HttpResp.Body = readCloser{
+			Reader: io.MultiReader(bytes.NewReader(data), body),
+			Closer: body,
+		}
+	default:
+		body.Close()
+		resp.BodyData = data
+		resp.BodyRead = true
+	}
+
+	if truncated {
+		data = data[:limit:limit]
+	}
+	return data, truncated
+}
+
+// readCloser combines a reader with the closer of the body it reads from.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// errReader is a reader that always fails with err.
+type errReader struct {
+	err error
+}
+
+// Read implements io.Reader.
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// isTextual reports whether a body with the given content type is text that
+// can be shown in an error message. Without a content type the bytes decide.
+func isTextual(contentType string, data []byte) bool {
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err == nil {
+			switch {
+			case strings.HasPrefix(mediaType, "text/"),
+				strings.HasSuffix(mediaType, "json"),
+				strings.HasSuffix(mediaType, "xml"),
+				mediaType == "application/x-www-form-urlencoded":
+				return true
+			default:
+				return false
+			}
+		}
+	}
+
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// closeTracker records whether the body was closed.
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+// newSnippetResponse returns a response with the given content type and body.
+func newSnippetResponse(contentType string, body io.ReadCloser) *Response {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &Response{HttpResp: &http.Response{StatusCode: 500, Header: header, Body: body}}
+}
+
+func TestCaptureBodySnippetWholeBody(t *testing.T) {
+	body := &closeTracker{Reader: strings.NewReader(`{"error":"boom"}`)}
+	resp := newSnippetResponse("application/json", body)
+
+	snippet, truncated := CaptureBodySnippet(resp, 64)
+	if string(snippet) != `{"error":"boom"}` || truncated {
+		t.Fatalf("snippet = %q, truncated = %v", snippet, truncated)
+	}
+	if !body.closed {
+		t.Error("original body not closed")
+	}
+
+	// The snippet survives the response being closed
+	resp.Close()
+	if got, err := resp.BodyString(); err != nil || got != `{"error":"boom"}` {
+		t.Errorf("BodyString = %q, %v", got, err)
+	}
+}
+
+func TestCaptureBodySnippetTruncated(t *testing.T) {
+	full := strings.Repeat("x", 100)
+	body := &closeTracker{Reader: strings.NewReader(full)}
+	resp := newSnippetResponse("text/plain", body)
+
+	snippet, truncated := CaptureBodySnippet(resp, 10)
+	if string(snippet) != full[:10] || !truncated {
+		t.Fatalf("snippet = %q, truncated = %v", snippet, truncated)
+	}
+	if body.closed {
+		t.Fatal("original body closed while its rest is unread")
+	}
+
+	// The response still reads in full, and closing it closes the original
+	if got, err := resp.BodyString(); err != nil || got != full {
+		t.Fatalf("BodyString = %q, %v, want the whole body", got, err)
+	}
+	if !body.closed {
+		t.Error("original body not closed after reading")
+	}
+	if string(snippet) != full[:10] {
+		t.Errorf("snippet changed to %q", snippet)
+	}
+}
+
+func TestHTTPErrorMessageSnippet(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		truncated   bool
+		want        string
+	}{
+		{name: "json", contentType: "application/json", body: []byte(`{"error":"boom"}`), want: `{"error":"boom"}`},
+		{name: "truncated", contentType: "text/plain", body: []byte("partial"), truncated: true, want: "partial..."},
+		{name: "binary content type", contentType: "application/octet-stream", body: []byte("text"), want: ""},
+		{name: "binary bytes", body: []byte{0x00, 0x01, 0xff}, want: ""},
+		{name: "too large", contentType: "text/plain", body: bytes.Repeat([]byte("a"), maxSnippetInMessage+1), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpErr := &HTTPError{
+				Response:    newSnippetResponse(tt.contentType, io.NopCloser(strings.NewReader(""))),
+				StatusCode:  500,
+				Message:     "request failed",
+				BodySnippet: tt.body,
+				Truncated:   tt.truncated,
+			}
+			if got := httpErr.messageSnippet(); got != tt.want {
+				t.Fatalf("messageSnippet = %q, want %q", got, tt.want)
+			}
+			if tt.want != "" && !strings.Contains(httpErr.Error(), tt.want) {
+				t.Fatalf("Error() = %q, want it to include %q", httpErr.Error(), tt.want)
+			}
+		})
+	}
+}
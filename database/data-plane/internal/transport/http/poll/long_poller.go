@@ -0,0 +1,252 @@
+package poll
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// Default long-poll settings.
+const (
+	// DefaultPollTimeout is the per-poll timeout; it must exceed the server's hold time.
+	DefaultPollTimeout = 60 * time.Second
+
+	// DefaultMinInterval is the least time between polls that return no new result.
+	DefaultMinInterval = time.Second
+
+	// DefaultErrorDelay is the first re-poll delay after an error.
+	DefaultErrorDelay = time.Second
+
+	// DefaultMaxErrorDelay caps the re-poll delay after consecutive errors.
+	DefaultMaxErrorDelay = 30 * time.Second
+
+	// jitterFraction spreads re-poll delays by up to ±20%.
+	jitterFraction = 0.2
+)
+
+// Result is a poll response that carried a new token.
+type Result struct {
+	// Token is the token extracted from the response
+	Token string
+
+	// StatusCode is the response status
+	StatusCode int
+
+	// Header holds the response headers
+	Header http.Header
+
+	// Body is the response body
+	Body []byte
+}
+
+// Extractor returns the token for the next poll from a response, e.g. the
+// X-Consul-Index header. An error counts as a failed poll.
+type Extractor func(resp interfaces.IHTTPResponse, body []byte) (string, error)
+
+// HeaderExtractor returns an Extractor reading the token from header.
+func HeaderExtractor(header string) Extractor {
+	return func(resp interfaces.IHTTPResponse, body []byte) (string, error) {
+		token := resp.Header(header)
+		if token == "" {
+			return "", fmt.Errorf("long poll response has no %s header", header)
+		}
+		return token, nil
+	}
+}
+
+// LongPoller repeatedly sends a request that the server holds open until
+// something changes (blocking queries). Each poll carries the token from
+// the previous response in a query parameter; a response is delivered only
+// when its token differs from the one sent.
+//
+// A poll that returns without a new token is not re-sent before the
+// minimum interval, and failed polls back off exponentially with jitter,
+// so a misbehaving server cannot make the poller spin.
+type LongPoller struct {
+	client        interfaces.IHTTPClient
+	request       interfaces.IHTTPRequest
+	param         string
+	extract       Extractor
+	token         string
+	pollTimeout   time.Duration
+	minInterval   time.Duration
+	errorDelay    time.Duration
+	maxErrorDelay time.Duration
+	onError       func(error)
+}
+
+// NewLongPoller creates a LongPoller that sends request through client,
+// passing the token in the query parameter param. The client's own
+// timeout must also exceed the server's hold time.
+func NewLongPoller(client interfaces.IHTTPClient, request interfaces.IHTTPRequest, param string, extract Extractor) *LongPoller {
+	return &LongPoller{
+		client:        client,
+		request:       request,
+		param:         param,
+		extract:       extract,
+		pollTimeout:   DefaultPollTimeout,
+		minInterval:   DefaultMinInterval,
+		errorDelay:    DefaultErrorDelay,
+		maxErrorDelay: DefaultMaxErrorDelay,
+	}
+}
+
+// WithToken sets the token sent with the first poll.
+func (p *LongPoller) WithToken(token string) *LongPoller {
+	p.token = token
+	return p
+}
+
+// WithPollTimeout sets the timeout of each poll.
+func (p *LongPoller) WithPollTimeout(timeout time.Duration) *LongPoller {
+	p.pollTimeout = timeout
+	return p
+}
+
+// WithMinInterval sets the least time between polls that return no new result.
+func (p *LongPoller) WithMinInterval(interval time.Duration) *LongPoller {
+	p.minInterval = interval
+	return p
+}
+
+// WithErrorBackoff sets the first and the maximum re-poll delay after errors.
+func (p *LongPoller) WithErrorBackoff(initial, max time.Duration) *LongPoller {
+	p.errorDelay = initial
+	p.maxErrorDelay = max
+	return p
+}
+
+// OnError registers a callback invoked with the error of every failed poll.
+func (p *LongPoller) OnError(fn func(error)) *LongPoller {
+	p.onError = fn
+	return p
+}
+
+// Run starts polling and returns a channel of results. The channel is
+// closed once ctx is cancelled.
+func (p *LongPoller) Run(ctx context.Context) <-chan Result {
+	results := make(chan Result)
+	go func() {
+		defer close(results)
+		p.loop(ctx, results)
+	}()
+	return results
+}
+
+// loop polls until ctx is cancelled.
+func (p *LongPoller) loop(ctx context.Context, results chan<- Result) {
+	failures := 0
+	for ctx.Err() == nil {
+		start := time.Now()
+		result, err := p.poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			failures++
+			if p.onError != nil {
+				p.onError(err)
+			}
+			sleep(ctx, max(jitter(p.backoff(failures)), p.minInterval-time.Since(start)))
+			continue
+		}
+		failures = 0
+
+		if result.Token == p.token {
+			// Hold expired without a change; never re-poll faster than minInterval
+			sleep(ctx, p.minInterval-time.Since(start))
+			continue
+		}
+		p.token = result.Token
+
+		select {
+		case results <- *result:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// poll sends one request and extracts the next token from its response.
+func (p *LongPoller) poll(ctx context.Context) (*Result, error) {
+	if p.request == nil || p.request.HTTPRequest() == nil {
+		return nil, &models.HTTPError{Message: "long poll request cannot be nil"}
+	}
+
+	pollCtx, cancel := context.WithTimeout(ctx, p.pollTimeout)
+	defer cancel()
+
+	httpReq := p.request.HTTPRequest().Clone(pollCtx)
+	if p.token != "" {
+		query := httpReq.URL.Query()
+		query.Set(p.param, p.token)
+		httpReq.URL.RawQuery = query.Encode()
+	}
+	request := &models.Request{HTTPReq: httpReq, TimeoutVal: p.pollTimeout}
+
+	resp, err := p.client.Send(request)
+	if resp != nil {
+		defer resp.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := resp.Body()
+	if err != nil {
+		return nil, &models.HTTPError{
+			Request:  request,
+			Response: resp,
+			Message:  "failed to read long poll response",
+			Err:      err,
+			KindVal:  models.Classify(err),
+		}
+	}
+
+	token, err := p.extract(resp, body)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Token:      token,
+		StatusCode: resp.StatusCode(),
+		Header:     resp.HTTPResponse().Header,
+		Body:       body,
+	}, nil
+}
+
+// backoff returns the re-poll delay after the given number of consecutive failures.
+func (p *LongPoller) backoff(failures int) time.Duration {
+	delay := p.errorDelay
+	for i := 1; i < failures && delay < p.maxErrorDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.maxErrorDelay {
+		delay = p.maxErrorDelay
+	}
+	return delay
+}
+
+// jitter spreads delay randomly by up to ±jitterFraction.
+func jitter(delay time.Duration) time.Duration {
+	spread := (rand.Float64()*2 - 1) * jitterFraction
+	return time.Duration(float64(delay) * (1 + spread))
+}
+
+// sleep waits for d or until ctx is cancelled.
+func sleep(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,193 @@
+package poll
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// pollLog records the index sent with every poll and when it arrived.
+type pollLog struct {
+	mu      sync.Mutex
+	indexes []string
+	times   []time.Time
+}
+
+func (l *pollLog) record(index string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.indexes = append(l.indexes, index)
+	l.times = append(l.times, time.Now())
+}
+
+func (l *pollLog) snapshot() ([]string, []time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.indexes...), append([]time.Time(nil), l.times...)
+}
+
+func newPollRequest(t *testing.T, url string) interfaces.IHTTPRequest {
+	t.Helper()
+	httpReq, err := http.NewRequest(http.MethodGet, url+"/v1/kv/config", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.Request{HTTPReq: httpReq}
+}
+
+func TestLongPollerCycles(t *testing.T) {
+	log := &pollLog{}
+	failures := 0
+	unchanged := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		index := r.URL.Query().Get("index")
+		log.record(index)
+		switch index {
+		case "":
+			w.Header().Set("X-Index", "1")
+			w.Write([]byte("one"))
+		case "1":
+			time.Sleep(20 * time.Millisecond) // hold, then report a change
+			w.Header().Set("X-Index", "2")
+			w.Write([]byte("two"))
+		case "2":
+			// Two failed polls, a hold that expires without a change, then a change
+			switch {
+			case failures < 2:
+				failures++
+				w.WriteHeader(http.StatusInternalServerError)
+			case !unchanged:
+				unchanged = true
+				w.Header().Set("X-Index", "2")
+			default:
+				w.Header().Set("X-Index", "3")
+				w.Write([]byte("three"))
+			}
+		default:
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+
+	var errs []error
+	var errMu sync.Mutex
+	poller := NewLongPoller(client.NewHTTPClient(), newPollRequest(t, server.URL), "index", HeaderExtractor("X-Index")).
+		WithPollTimeout(time.Second).
+		WithMinInterval(10*time.Millisecond).
+		WithErrorBackoff(50*time.Millisecond, time.Second).
+		OnError(func(err error) {
+			errMu.Lock()
+			errs = append(errs, err)
+			errMu.Unlock()
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	results := poller.Run(ctx)
+
+	for _, want := range []struct{ token, body string }{{"1", "one"}, {"2", "two"}, {"3", "three"}} {
+		result, ok := <-results
+		if !ok {
+			t.Fatalf("results closed before token %s", want.token)
+		}
+		if result.Token != want.token || string(result.Body) != want.body || result.StatusCode != http.StatusOK {
+			t.Errorf("result = %q %q %d, want %q %q 200", result.Token, result.Body, result.StatusCode, want.token, want.body)
+		}
+	}
+
+	indexes, times := log.snapshot()
+	wantIndexes := []string{"", "1", "2", "2", "2", "2"}
+	if len(indexes) < len(wantIndexes) {
+		t.Fatalf("polls sent indexes %q, want %q", indexes, wantIndexes)
+	}
+	for i, want := range wantIndexes {
+		if indexes[i] != want {
+			t.Errorf("poll %d sent index %q, want %q", i, indexes[i], want)
+		}
+	}
+
+	errMu.Lock()
+	var httpErr *models.HTTPError
+	if len(errs) != 2 || !errors.As(errs[0], &httpErr) || httpErr.GetStatusCode() != http.StatusInternalServerError {
+		t.Errorf("OnError received %v, want two 500 errors", errs)
+	}
+	errMu.Unlock()
+
+	// The delay after each failure doubles, give or take the jitter
+	if gap := times[3].Sub(times[2]); gap < 40*time.Millisecond {
+		t.Errorf("re-polled %v after the first error, want at least the jittered 50ms backoff", gap)
+	}
+	if gap := times[4].Sub(times[3]); gap < 80*time.Millisecond {
+		t.Errorf("re-polled %v after the second error, want at least the jittered 100ms backoff", gap)
+	}
+	if gap := times[5].Sub(times[4]); gap < 10*time.Millisecond {
+		t.Errorf("re-polled %v after an unchanged response, want at least the 10ms minimum interval", gap)
+	}
+
+	cancel()
+	for range results {
+		t.Error("received a result after cancellation")
+	}
+}
+
+func TestLongPollerDoesNotSpin(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"unchanged token", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Index", "7")
+		}},
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}},
+		{"missing token", func(w http.ResponseWriter, r *http.Request) {}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			log := &pollLog{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				log.record(r.URL.Query().Get("index"))
+				tc.handler(w, r)
+			}))
+			defer server.Close()
+
+			poller := NewLongPoller(client.NewHTTPClient(), newPollRequest(t, server.URL), "index", HeaderExtractor("X-Index")).
+				WithToken("7").
+				WithMinInterval(25*time.Millisecond).
+				WithErrorBackoff(25*time.Millisecond, 50*time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+			defer cancel()
+			for range poller.Run(ctx) {
+				t.Error("delivered a result without a new token")
+			}
+
+			// 250ms at no less than ~20ms per poll
+			if indexes, _ := log.snapshot(); len(indexes) > 13 {
+				t.Errorf("sent %d polls in 250ms, want the poller to back off", len(indexes))
+			}
+		})
+	}
+}
+
+func TestLongPollerBackoffCapped(t *testing.T) {
+	p := NewLongPoller(nil, nil, "index", nil).WithErrorBackoff(time.Second, 5*time.Second)
+	for failures, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := p.backoff(failures); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if got := jitter(time.Second); got < 800*time.Millisecond || got > 1200*time.Millisecond {
+			t.Fatalf("jitter(1s) = %v, want within ±20%%", got)
+		}
+	}
+}
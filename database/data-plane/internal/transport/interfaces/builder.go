@@ -4,6 +4,8 @@ import (
 	"context"
 	"io"
 	"time"
+
+	"google.golang.org/protobuf/proto"
 )
 
 // IRequestBuilder provides a fluent interface for building HTTP requests.
@@ -15,36 +17,99 @@ type IRequestBuilder interface {
 	// Scheme sets the URL scheme (http or https).
 	Scheme(scheme string) IRequestBuilder
 
-	// AddPath appends a path segment to the URL.
+	// Port sets the port, replacing any port given with Host.
+	Port(port int) IRequestBuilder
+
+	// HostHeader sets the Host header sent, independently of the host
+	// connected to.
+	HostHeader(host string) IRequestBuilder
+
+	// ServerName sets the TLS server name (SNI) used instead of the host
+	// connected to.
+	ServerName(sni string) IRequestBuilder
+
+	// BaseURL sets the scheme, host, port and initial path from a full URL
+	// and adds its query parameters.
+	BaseURL(raw string) IRequestBuilder
+
+	// AddPath appends a path to the URL, splitting it on "/" and escaping
+	// each segment.
 	AddPath(path string) IRequestBuilder
 
-	// Path sets the complete path, replacing previous paths.
+	// AddRawPath appends an already percent-encoded path without escaping it.
+	AddRawPath(path string) IRequestBuilder
+
+	// Path sets the complete path, replacing previous paths. It is split
+	// on "/" and each segment is escaped.
 	Path(path string) IRequestBuilder
 
 	// QueryParam adds a single query parameter.
 	QueryParam(key, value string) IRequestBuilder
 
-	// QueryParams sets multiple query parameters.
+	// QueryParamInt adds a query parameter holding an integer.
+	QueryParamInt(key string, v int) IRequestBuilder
+
+	// QueryParamBool adds a query parameter holding "true" or "false".
+	QueryParamBool(key string, v bool) IRequestBuilder
+
+	// QueryParamTime adds a query parameter holding t formatted with layout,
+	// or RFC 3339 if layout is empty.
+	QueryParamTime(key string, t time.Time, layout string) IRequestBuilder
+
+	// QueryParamSlice adds a query parameter holding a list in the given style.
+	QueryParamSlice(key string, values []string, style SliceStyle) IRequestBuilder
+
+	// QueryStruct adds a query parameter for each field of a struct, named
+	// by its `query:"name,omitempty"` tag.
+	QueryStruct(v interface{}) IRequestBuilder
+
+	// QueryParams sets multiple query parameters, replacing the values of
+	// each key given and keeping the other keys set earlier.
 	QueryParams(params map[string]string) IRequestBuilder
 
+	// ClearQueryParams removes every query parameter set so far.
+	ClearQueryParams() IRequestBuilder
+
 	// Header adds a header to the request.
 	Header(key, value string) IRequestBuilder
 
-	// Headers sets multiple headers.
+	// Headers sets multiple headers, replacing the values of each header
+	// given and keeping the other headers set earlier.
 	Headers(headers map[string]string) IRequestBuilder
 
-	// ContentType sets the Content-Type header.
+	// ClearHeaders removes every header set so far.
+	ClearHeaders() IRequestBuilder
+
+	// ContentType sets the Content-Type header, replacing any previous value.
 	ContentType(contentType string) IRequestBuilder
 
 	// Accept sets the Accept header.
 	Accept(accept string) IRequestBuilder
 
+	// AcceptTypes sets the Accept header from several media ranges,
+	// optionally with q-weights (e.g. "application/xml;q=0.8").
+	AcceptTypes(types ...string) IRequestBuilder
+
+	// UserAgent sets the User-Agent header unless one is set with Header.
+	UserAgent(ua string) IRequestBuilder
+
+	// IfNoneMatch makes the request conditional on the resource no longer
+	// matching etag.
+	IfNoneMatch(etag string) IRequestBuilder
+
+	// IfModifiedSince makes the request conditional on the resource having
+	// changed after t.
+	IfModifiedSince(t time.Time) IRequestBuilder
+
 	// Authorization sets the Authorization header.
 	Authorization(token string) IRequestBuilder
 
 	// BearerToken sets the Authorization header with Bearer token.
 	BearerToken(token string) IRequestBuilder
 
+	// BasicAuth sets the Authorization header for HTTP Basic authentication.
+	BasicAuth(username, password string) IRequestBuilder
+
 	// Body sets the request body from an io.Reader.
 	Body(body io.Reader) IRequestBuilder
 
@@ -57,6 +122,37 @@ type IRequestBuilder interface {
 	// JSON sets the request body from a JSON-encodable object.
 	JSON(v interface{}) IRequestBuilder
 
+	// BodyXML sets the request body from an XML-encodable object.
+	BodyXML(v interface{}) IRequestBuilder
+
+	// BodyProto sets the request body from a protobuf message.
+	BodyProto(msg proto.Message) IRequestBuilder
+
+	// BodyFunc streams the request body from the reader open returns.
+	// open is called again to replay the body when the request is retried.
+	BodyFunc(open func() (io.ReadCloser, error)) IRequestBuilder
+
+	// Multipart streams a multipart/form-data body built from parts and
+	// sets the Content-Type header, boundary included.
+	Multipart(parts ...MultipartPart) IRequestBuilder
+
+	// MultipartField adds a form value to a multipart/form-data body
+	// assembled by Build, which sets the Content-Type header.
+	MultipartField(name, value string) IRequestBuilder
+
+	// MultipartFile adds a file read from r to a multipart/form-data body
+	// assembled by Build. The whole of r is read into memory so the body
+	// can be retried.
+	MultipartFile(fieldName, fileName string, r io.Reader) IRequestBuilder
+
+	// GraphQL makes the request a POST of the standard GraphQL envelope
+	// carrying query and variables.
+	GraphQL(query string, variables map[string]interface{}) IRequestBuilder
+
+	// PersistedQuery makes the request a POST of a GraphQL automatic
+	// persisted query: only the SHA-256 hash of query is sent.
+	PersistedQuery(query string, variables map[string]interface{}) IRequestBuilder
+
 	// Timeout sets the request timeout.
 	Timeout(timeout time.Duration) IRequestBuilder
 
@@ -80,12 +176,24 @@ type IRequestBuilder interface {
 	// WithBulkhead configures bulkhead pattern (concurrency limiting).
 	WithBulkhead(maxConcurrency int) IRequestBuilder
 
+	// WithIdempotencyKey sends key in the Idempotency-Key header, the same
+	// on every retry.
+	WithIdempotencyKey(key string) IRequestBuilder
+
+	// WithAutoIdempotencyKey sends a UUID generated once per Build in the
+	// Idempotency-Key header, the same on every retry.
+	WithAutoIdempotencyKey() IRequestBuilder
+
 	// WithLogging enables request/response logging.
 	WithLogging() IRequestBuilder
 
 	// WithMetrics enables metrics collection.
 	WithMetrics() IRequestBuilder
 
+	// WithHTTP3 sends the request over HTTP/3, falling back to HTTP/2 or
+	// HTTP/1.1 if the QUIC handshake fails.
+	WithHTTP3() IRequestBuilder
+
 	// WithMiddleware adds custom middleware to the request.
 	WithMiddleware(middleware IMiddleware) IRequestBuilder
 
@@ -106,9 +214,19 @@ type IRequestBuilder interface {
 	// DELETE sets the HTTP method to DELETE and builds the request.
 	DELETE() IRequestBuilder
 
+	// HEAD sets the HTTP method to HEAD; response handlers skip its body.
+	HEAD() IRequestBuilder
+
+	// OPTIONS sets the HTTP method to OPTIONS.
+	OPTIONS() IRequestBuilder
+
 	// Method sets a custom HTTP method.
 	Method(method string) IRequestBuilder
 
+	// Clone returns an independent copy of the builder, so a base
+	// configuration can be shared by many requests.
+	Clone() IRequestBuilder
+
 	// Build constructs and returns the IHTTPRequest without executing it.
 	// This allows separation between request construction and execution.
 	Build() (IHTTPRequest, error)
@@ -120,4 +238,49 @@ type IRequestBuilder interface {
 	// Async executes the request asynchronously and returns a channel.
 	// The response will be sent to the channel when available.
 	Async() <-chan AsyncResult
+
+	// OnDownloadProgress sets a callback reporting the absolute file offset
+	// and the total size (-1 if unknown) while DownloadResumable runs.
+	OnDownloadProgress(fn func(offset, total int64)) IRequestBuilder
+
+	// DownloadResumable downloads the response body to path, resuming a
+	// partial download left by an earlier call with a Range request.
+	DownloadResumable(ctx context.Context, path string) (*DownloadResult, error)
+}
+
+// SliceStyle selects how QueryParamSlice encodes a list.
+type SliceStyle int
+
+const (
+	// SliceRepeat repeats the key for each value: k=a&k=b
+	SliceRepeat SliceStyle = iota
+	// SliceComma joins the values with commas: k=a,b
+	SliceComma
+	// SlicePipe joins the values with pipes: k=a|b
+	SlicePipe
+)
+
+// MultipartPart is one part of a streamed multipart/form-data body.
+// Open is called each time the body is sent, so parts can be replayed on
+// retry; a part with only Reader set can be sent once.
+type MultipartPart struct {
+	FieldName   string
+	FileName    string
+	ContentType string
+	Open        func() (io.ReadCloser, error)
+	Reader      io.Reader
+}
+
+// DownloadResult describes a completed (or failed) resumable download.
+// Attempts counts the requests made, Restarts the times the partial file
+// was discarded because the resource changed, and ResumedFrom the offset
+// the first request resumed from (0 for a fresh download).
+type DownloadResult struct {
+	Path        string
+	Size        int64
+	ETag        string
+	Attempts    int
+	Restarts    int
+	ResumedFrom int64
+	Duration    time.Duration
 }
@@ -1,6 +1,7 @@
 package interfaces
 
 import (
+	"context"
 	"net/http"
 	"time"
 )
@@ -26,3 +27,26 @@ type IHTTPClient interface {
 	// GetHTTPClient returns the underlying http.Client.
 	GetHTTPClient() *http.Client
 }
+
+// IDrainableClient is an HTTP client that can stop taking requests and wait
+// for those in flight, for a graceful shutdown.
+type IDrainableClient interface {
+	IHTTPClient
+
+	// Drain stops accepting requests and waits until the requests in flight,
+	// and the admitted work, complete or ctx is done; then it cancels the
+	// requests still in flight and returns ctx's error.
+	Drain(ctx context.Context) error
+
+	// Close stops accepting requests and cancels those in flight.
+	Close() error
+
+	// InFlight returns the number of requests and admitted work in flight.
+	InFlight() int
+
+	// Admit registers work that will send requests later, such as a queued
+	// submission, so that Drain waits for it. Requests sent under the
+	// returned context are accepted even while draining. release must be
+	// called once the work is done. Admit fails once the client is draining.
+	Admit(ctx context.Context) (admitted context.Context, release func(), err error)
+}
@@ -1,5 +1,7 @@
 package interfaces
 
+import "time"
+
 // IHTTPError represents the interface for HTTP request errors.
 // This interface extends the standard error interface with additional
 // context about HTTP failures, network issues, and retry capabilities.
@@ -16,15 +18,33 @@ type IHTTPError interface {
 	// GetStatusCode returns the HTTP status code if available (0 for network errors).
 	GetStatusCode() int
 
+	// GetRequestID returns the request/correlation ID, or "" if unknown.
+	GetRequestID() string
+
+	// GetDuration returns the time from the start of the request to the failure.
+	GetDuration() time.Duration
+
 	// GetMessage returns a human-readable error message.
 	GetMessage() string
 
 	// GetError returns the underlying error if available.
 	GetError() error
 
+	// Kind returns the machine-readable category of the error.
+	Kind() ErrorKind
+
+	// Stage returns the stage at which the request was interrupted.
+	Stage() ErrorStage
+
 	// IsTimeout returns true if the error was caused by a timeout.
 	IsTimeout() bool
 
+	// IsDeadlineExceeded returns true if a deadline or timeout expired.
+	IsDeadlineExceeded() bool
+
+	// IsCanceled returns true if the caller cancelled the request.
+	IsCanceled() bool
+
 	// IsTemporary returns true if the error is temporary and can be retried.
 	IsTemporary() bool
 
@@ -37,9 +57,146 @@ type IHTTPError interface {
 	// IsNetworkError returns true if this is a network-related error.
 	IsNetworkError() bool
 
+	// IsDNSError returns true if the host name could not be resolved.
+	IsDNSError() bool
+
+	// IsTLSError returns true if the TLS handshake or certificate verification failed.
+	IsTLSError() bool
+
+	// IsConnectionRefused returns true if the upstream refused the connection.
+	IsConnectionRefused() bool
+
+	// IsConnectionReset returns true if the upstream reset the connection.
+	IsConnectionReset() bool
+
 	// GetResponseBody attempts to read and return the response body if available.
 	GetResponseBody() (string, error)
 
 	// Unwrap returns the underlying error for error chain support.
 	Unwrap() error
 }
+
+// ErrorKind is a machine-readable category for transport errors.
+type ErrorKind int
+
+const (
+	// KindUnknown means the error could not be categorized.
+	KindUnknown ErrorKind = iota
+
+	// KindTimeout means a deadline or network timeout expired.
+	KindTimeout
+
+	// KindCanceled means the request's context was canceled.
+	KindCanceled
+
+	// KindDNS means the host name could not be resolved.
+	KindDNS
+
+	// KindConnection means the connection could not be established or was lost.
+	KindConnection
+
+	// KindTLS means the TLS handshake or certificate verification failed.
+	KindTLS
+
+	// KindClientStatus means the server answered with a 4xx status.
+	KindClientStatus
+
+	// KindServerStatus means the server answered with a 5xx status.
+	KindServerStatus
+
+	// KindRateLimited means the request was rejected by a rate limiter,
+	// locally or by the server (HTTP 429).
+	KindRateLimited
+
+	// KindCircuitOpen means the request was rejected by an open circuit breaker.
+	KindCircuitOpen
+
+	// KindBulkhead means the request was rejected because a bulkhead was full.
+	KindBulkhead
+
+	// KindDecode means the response body could not be decoded.
+	KindDecode
+
+	// KindGraphQL means a GraphQL server answered with an errors array.
+	KindGraphQL
+
+	// KindContract means a request or response did not conform to its API contract.
+	KindContract
+
+	// KindDraining means the request was rejected because the client is
+	// draining or closed.
+	KindDraining
+)
+
+// errorKindNames holds the String form of each kind.
+var errorKindNames = map[ErrorKind]string{
+	KindUnknown:      "unknown",
+	KindTimeout:      "timeout",
+	KindCanceled:     "canceled",
+	KindDNS:          "dns",
+	KindConnection:   "connection",
+	KindTLS:          "tls",
+	KindClientStatus: "client_status",
+	KindServerStatus: "server_status",
+	KindRateLimited:  "rate_limited",
+	KindCircuitOpen:  "circuit_open",
+	KindBulkhead:     "bulkhead",
+	KindDecode:       "decode",
+	KindGraphQL:      "graphql",
+	KindContract:     "contract",
+	KindDraining:     "draining",
+}
+
+// String returns the kind's name.
+func (k ErrorKind) String() string {
+	if name, ok := errorKindNames[k]; ok {
+		return name
+	}
+	return errorKindNames[KindUnknown]
+}
+
+// IKindedError is implemented by errors that know their own ErrorKind,
+// such as resiliency rejections.
+type IKindedError interface {
+	error
+
+	// ErrorKind returns the error's category.
+	ErrorKind() ErrorKind
+}
+
+// ErrorStage identifies where in the request lifecycle an error occurred.
+type ErrorStage int
+
+const (
+	// StageUnknown means the stage was not recorded.
+	StageUnknown ErrorStage = iota
+
+	// StageRateLimitWait means the request was waiting for a rate limiter.
+	StageRateLimitWait
+
+	// StageBackoff means the request was waiting between retry attempts.
+	StageBackoff
+
+	// StageRequest means the request was being sent or awaiting response headers.
+	StageRequest
+
+	// StageReadBody means the response body was being read.
+	StageReadBody
+)
+
+// errorStageNames holds the String form of each stage.
+var errorStageNames = map[ErrorStage]string{
+	StageUnknown:       "unknown",
+	StageRateLimitWait: "rate_limit_wait",
+	StageBackoff:       "backoff",
+	StageRequest:       "request",
+	StageReadBody:      "read_body",
+}
+
+// String returns the stage's name.
+func (s ErrorStage) String() string {
+	if name, ok := errorStageNames[s]; ok {
+		return name
+	}
+	return errorStageNames[StageUnknown]
+}
@@ -1,5 +1,7 @@
 package interfaces
 
+import "io"
+
 // IResponseHandler handles and transforms HTTP responses.
 // This interface allows for custom response processing, validation,
 // and transformation into domain-specific types.
@@ -36,3 +38,12 @@ type IMarshaller interface {
 	// ContentType returns the content type this marshaller handles.
 	ContentType() string
 }
+
+// IStreamMarshaller is implemented by marshallers that can decode directly
+// from a reader without materializing the whole body in memory first.
+type IStreamMarshaller interface {
+	IMarshaller
+
+	// Decode reads from r and decodes the payload into v.
+	Decode(r io.Reader, v interface{}) error
+}
@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+// ICall is a protocol-neutral request: a named operation with an optional
+// per-call timeout. Protocol packages (e.g. gRPC) provide implementations.
+type ICall interface {
+	// Operation returns the name of the operation, e.g. a gRPC full method name.
+	Operation() string
+
+	// Timeout returns the per-call deadline, or zero for none.
+	Timeout() time.Duration
+}
+
+// IInvoker executes protocol-neutral calls. The call carries its own request
+// and reply, so the invoker only reports success or failure, which lets the
+// resiliency invoker decorators wrap any protocol.
+type IInvoker interface {
+	// Invoke executes the call under ctx.
+	Invoke(ctx context.Context, call ICall) error
+}
@@ -64,6 +64,20 @@ type IRateLimiter interface {
 	Wait(ctx context.Context) error
 }
 
+// IRateLimiterRegistry hands out a shared rate limiter per key (typically a host),
+// so independent callers draw from the same budget for that key.
+type IRateLimiterRegistry interface {
+	// Limiter returns the rate limiter for key, creating it on first use.
+	Limiter(key string) IRateLimiter
+}
+
+// ICircuitBreakerRegistry hands out a shared circuit breaker per key (typically a host),
+// so one failing destination does not trip the breaker for the others.
+type ICircuitBreakerRegistry interface {
+	// Breaker returns the circuit breaker for key, creating it on first use.
+	Breaker(key string) ICircuitBreaker
+}
+
 // IBulkhead defines the interface for bulkhead pattern (concurrency limiting).
 type IBulkhead interface {
 	// Execute runs the function with bulkhead protection.
@@ -78,22 +92,58 @@ type IBulkhead interface {
 
 // IAsyncRequest defines the interface for asynchronous request execution.
 type IAsyncRequest interface {
-	// Execute sends the request asynchronously and returns a channel for the response.
-	Execute() <-chan AsyncResult
+	// Execute sends the request asynchronously and returns a channel that
+	// receives exactly one result and is then closed. Cancelling ctx delivers
+	// a cancellation result instead of waiting for the request.
+	Execute(ctx context.Context, request IHTTPRequest) <-chan AsyncResult
 
 	// ExecuteBatch sends multiple requests concurrently.
 	ExecuteBatch(requests []IHTTPRequest) <-chan AsyncResult
 
-	// ExecuteWithCallback sends the request and calls the callback when done.
-	ExecuteWithCallback(callback func(IHTTPResponse, error))
+	// ExecuteBatchWithContext sends multiple requests concurrently under ctx.
+	// Cancelling ctx stops launching new requests, cancels in-flight ones and
+	// emits a cancellation result for every request that never started.
+	ExecuteBatchWithContext(ctx context.Context, requests []IHTTPRequest) <-chan AsyncResult
+
+	// ExecuteEntries sends keyed batch entries concurrently under ctx.
+	// Each result carries the entry's Index and Key for attribution.
+	ExecuteEntries(ctx context.Context, entries []BatchEntry) <-chan AsyncResult
+
+	// ExecuteWithCallback sends the request and calls the callback exactly once
+	// with the response or error. The request is bounded by ctx; use
+	// context.WithTimeout to put a deadline on the callback.
+	ExecuteWithCallback(ctx context.Context, request IHTTPRequest, callback func(IHTTPResponse, error))
 }
 
 // AsyncResult represents the result of an async request.
+// Index is the position of the request in the submitted batch (0 for single
+// requests) and Key is the caller-supplied identifier, if any.
+// For batch and pool executions, Attempts counts the sends made for the
+// entry (0 if it never started) and FellBack reports whether the entry's
+// Fallback supplied the response. RequestID is the request/correlation ID
+// sent with the request or echoed by the upstream, if any.
 type AsyncResult struct {
-	Request  IHTTPRequest
-	Response IHTTPResponse
-	Error    error
-	Duration time.Duration
+	Request   IHTTPRequest
+	Response  IHTTPResponse
+	Error     error
+	Duration  time.Duration
+	Index     int
+	Key       string
+	Attempts  int
+	FellBack  bool
+	RequestID string
+}
+
+// BatchEntry is a single request submitted to a batch, with an optional
+// caller-supplied key used to attribute its result.
+// RetryPolicy, if set, retries this entry independently of other entries,
+// on top of any decorators already on the client. Fallback, if set, is
+// called with the final error and may supply a replacement response.
+type BatchEntry struct {
+	Key         string
+	Request     IHTTPRequest
+	RetryPolicy IRetryPolicy
+	Fallback    func(err error) (IHTTPResponse, error)
 }
 
 // IHealthChecker defines the interface for health checking.
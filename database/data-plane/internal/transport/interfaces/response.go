@@ -55,6 +55,9 @@ type IHTTPResponse interface {
 	// ContentLength returns the Content-Length header value.
 	ContentLength() int64
 
+	// Protocol returns the negotiated protocol, e.g. "HTTP/1.1", "HTTP/2.0" or "HTTP/3.0".
+	Protocol() string
+
 	// HTTPResponse returns the underlying *http.Response object.
 	HTTPResponse() *http.Response
 
@@ -1,7 +1,10 @@
 package middleware
 
 import (
-	"sync"
+	"context"
+	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"data-plane/internal/transport/http/models"
@@ -11,184 +14,480 @@ import (
 // AsyncRequest handles asynchronous request execution using goroutines.
 type AsyncRequest struct {
 	client interfaces.IHTTPClient
+	batch  []BatchOption
 }
 
 // Ensure AsyncRequest implements IAsyncRequest interface
 var _ interfaces.IAsyncRequest = (*AsyncRequest)(nil)
 
 // NewAsyncRequest creates a new async request handler.
-func NewAsyncRequest(client interfaces.IHTTPClient) *AsyncRequest {
+// The batch options apply to every batch it executes.
+func NewAsyncRequest(client interfaces.IHTTPClient, batchOpts ...BatchOption) *AsyncRequest {
 	return &AsyncRequest{
 		client: client,
+		batch:  batchOpts,
 	}
 }
 
 // Execute sends a single request asynchronously.
-func (ar *AsyncRequest) Execute() <-chan interfaces.AsyncResult {
-	// This method needs a request, so it's not fully implemented without context.
-	// The actual implementation will be in ResilientClient
+// The returned channel receives exactly one result and is then closed.
+// The request runs under a context derived from both ctx and the request's
+// own context; if ctx is cancelled first, a cancellation result is delivered
+// without waiting for the client to return.
+func (ar *AsyncRequest) Execute(ctx context.Context, request interfaces.IHTTPRequest) <-chan interfaces.AsyncResult {
 	resultChan := make(chan interfaces.AsyncResult, 1)
+	ctx, release := admitAsync(ctx, ar.client)
+
+	go func() {
+		defer close(resultChan)
+		defer release()
+		resultChan <- sendAsync(ctx, ar.client, request)
+	}()
+
 	return resultChan
 }
 
 // ExecuteBatch sends multiple requests concurrently using goroutines.
 func (ar *AsyncRequest) ExecuteBatch(requests []interfaces.IHTTPRequest) <-chan interfaces.AsyncResult {
-	resultChan := make(chan interfaces.AsyncResult, len(requests))
-
-	// Use WaitGroup to track goroutines
-	var wg sync.WaitGroup
-
-	// Launch a goroutine for each request
-	for _, req := range requests {
-		wg.Add(1)
-		go func(request interfaces.IHTTPRequest) {
-			defer wg.Done()
-
-			start := time.Now()
-			resp, err := ar.client.Send(request)
-			duration := time.Since(start)
-
-			resultChan <- interfaces.AsyncResult{
-				Request:  request,
-				Response: resp,
-				Error:    err,
-				Duration: duration,
-			}
-		}(req)
+	return ar.ExecuteBatchWithContext(context.Background(), requests)
+}
+
+// ExecuteBatchWithContext sends multiple requests concurrently under ctx.
+// Each request's context is derived from ctx, so cancelling it aborts
+// in-flight requests; requests not yet started are reported with a
+// cancellation error so that exactly one result is emitted per request.
+// The channel is closed once every in-flight request has settled.
+func (ar *AsyncRequest) ExecuteBatchWithContext(ctx context.Context, requests []interfaces.IHTTPRequest) <-chan interfaces.AsyncResult {
+	return RunBatch(ctx, ar.client, toEntries(requests), ar.batch...).Results()
+}
+
+// ExecuteEntries sends keyed batch entries concurrently under ctx.
+// Results stream in completion order; each carries the entry's Index and Key.
+// Use CollectOrdered to gather them in submission order instead.
+func (ar *AsyncRequest) ExecuteEntries(ctx context.Context, entries []interfaces.BatchEntry) <-chan interfaces.AsyncResult {
+	return RunBatch(ctx, ar.client, entries, ar.batch...).Results()
+}
+
+// ExecuteWithCallback sends a request in a goroutine and invokes the callback
+// exactly once with the real response or error.
+// The request is bounded by ctx (use context.WithTimeout for a deadline);
+// on cancellation the callback receives a cancellation error.
+// A panicking callback is recovered and logged rather than crashing the process.
+func (ar *AsyncRequest) ExecuteWithCallback(ctx context.Context, request interfaces.IHTTPRequest, callback func(interfaces.IHTTPResponse, error)) {
+	if callback == nil {
+		return
 	}
 
-	// Close channel when all goroutines complete
+	ctx, release := admitAsync(ctx, ar.client)
 	go func() {
-		wg.Wait()
-		close(resultChan)
+		defer release()
+		result := sendAsync(ctx, ar.client, request)
+		invokeCallback(callback, result.Response, result.Error)
 	}()
+}
 
-	return resultChan
+// admitAsync admits a request about to be sent in the background, so a
+// drain starting meanwhile waits for it. Once the client is draining it is
+// not admitted, and the client rejects it when sent.
+func admitAsync(ctx context.Context, client interfaces.IHTTPClient) (context.Context, func()) {
+	admitted, release, err := admit(ctx, client)
+	if err != nil {
+		return ctx, release
+	}
+	return admitted, release
 }
 
-// ExecuteWithCallback sends a request and calls the callback when done.
-func (ar *AsyncRequest) ExecuteWithCallback(callback func(interfaces.IHTTPResponse, error)) {
-	// This method needs a request, implemented in ResilientClient
-	// Placeholder implementation
-	go func() {
-		callback(nil, nil)
+// invokeCallback calls the callback, containing any panic it raises.
+func invokeCallback(callback func(interfaces.IHTTPResponse, error), resp interfaces.IHTTPResponse, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("async callback panicked: %v", r)
+		}
 	}()
+	callback(resp, err)
 }
 
 // ExecuteConcurrent executes requests with controlled concurrency.
-func ExecuteConcurrent(client interfaces.IHTTPClient, requests []interfaces.IHTTPRequest, maxConcurrency int) <-chan interfaces.AsyncResult {
-	resultChan := make(chan interfaces.AsyncResult, len(requests))
+// Further batch options (pacing, progress, error policy) may be supplied.
+func ExecuteConcurrent(client interfaces.IHTTPClient, requests []interfaces.IHTTPRequest, maxConcurrency int, opts ...BatchOption) <-chan interfaces.AsyncResult {
+	config := newBatchConfig(opts)
+	config.maxConcurrency = maxConcurrency
+	return runBatch(context.Background(), client, toEntries(requests), config).Results()
+}
 
-	// Create a semaphore channel to limit concurrency
-	semaphore := make(chan struct{}, maxConcurrency)
+// errFanOutLost is the cancellation cause for requests that lost a fan-out race.
+var errFanOutLost = errors.New("fan-out: another request won")
 
-	var wg sync.WaitGroup
+// FanOutResult reports the outcome of a FanOut call.
+type FanOutResult struct {
+	// Result is the winning result, or the last failure if no request succeeded
+	Result interfaces.AsyncResult
 
-	for _, req := range requests {
-		wg.Add(1)
+	// Winner is the index of the winning request, or -1 if none succeeded
+	Winner int
 
-		go func(request interfaces.IHTTPRequest) {
-			defer wg.Done()
+	// Attempts is the number of requests launched
+	Attempts int
 
-			// Acquire semaphore slot
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }() // Release slot
+	// Failed is the number of requests that completed with an error of their own
+	Failed int
 
-			start := time.Now()
-			resp, err := client.Send(request)
-			duration := time.Since(start)
+	// Cancelled is the number of requests aborted because another request won
+	Cancelled int
+}
 
-			resultChan <- interfaces.AsyncResult{
-				Request:  request,
-				Response: resp,
-				Error:    err,
-				Duration: duration,
-			}
-		}(req)
+// FanOut sends the requests concurrently and returns the first success.
+// As soon as a winner is chosen the remaining requests are cancelled and any
+// responses they produced are closed, so no connections or bodies leak.
+// FanOut returns only after every request has settled; because cancelled
+// requests return immediately, this wait is bounded by the winner.
+// Cancelling ctx aborts the whole fan-out.
+func FanOut(ctx context.Context, client interfaces.IHTTPClient, requests []interfaces.IHTTPRequest) FanOutResult {
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	// Close channel when all requests complete
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
-
-	return resultChan
-}
+	outcome := FanOutResult{Winner: -1, Attempts: len(requests)}
+	if len(requests) == 0 {
+		outcome.Result = interfaces.AsyncResult{
+			Error: &models.HTTPError{Message: "fan-out: no requests"},
+		}
+		return outcome
+	}
 
-// FanOut distributes a single request to multiple endpoints concurrently.
-func FanOut(client interfaces.IHTTPClient, requests []interfaces.IHTTPRequest) interfaces.AsyncResult {
+	// Each request gets its own loss signal so the winner is never cancelled
+	lost := make([]context.Context, len(requests))
+	losers := make([]context.CancelCauseFunc, len(requests))
 	results := make(chan interfaces.AsyncResult, len(requests))
-
-	// Launch all requests concurrently
-	for _, req := range requests {
-		go func(request interfaces.IHTTPRequest) {
-			start := time.Now()
-			resp, err := client.Send(request)
-			duration := time.Since(start)
-
-			results <- interfaces.AsyncResult{
-				Request:  request,
-				Response: resp,
-				Error:    err,
-				Duration: duration,
+	defer func() {
+		for i, lose := range losers {
+			if i == outcome.Winner {
+				// The winner's body is still to be read; release it once closed
+				releaseOnClose(outcome.Result.Response, func() { lose(context.Canceled) })
+				continue
 			}
-		}(req)
+			lose(context.Canceled)
+		}
+	}()
+
+	for i, req := range requests {
+		lost[i], losers[i] = context.WithCancelCause(context.Background())
+
+		go func(index int, request interfaces.IHTTPRequest) {
+			result := sendAsync(ctx, client, request, lost[index])
+			result.Index = index
+			results <- result
+		}(i, req)
 	}
 
-	// Return the first successful result (fan-in pattern)
-	for i := 0; i < len(requests); i++ {
+	for received := 0; received < len(requests); received++ {
 		result := <-results
-		if result.Error == nil {
-			return result
+
+		switch {
+		case outcome.Winner < 0 && result.Error == nil:
+			outcome.Winner = result.Index
+			outcome.Result = result
+			for i, lose := range losers {
+				if i != result.Index {
+					lose(errFanOutLost)
+				}
+			}
+
+		case outcome.Winner >= 0 && result.Error != nil && lost[result.Index].Err() != nil:
+			outcome.Cancelled++
+			closeResponse(result.Response)
+
+		case outcome.Winner >= 0:
+			// Settled on its own after the race was decided; discard it
+			if result.Error != nil {
+				outcome.Failed++
+			}
+			closeResponse(result.Response)
+
+		default:
+			outcome.Failed++
+			closeResponse(outcome.Result.Response)
+			outcome.Result = result
 		}
 	}
 
-	// All failed, return last result
-	return <-results
+	return outcome
 }
 
-// Pipeline creates a pipeline of async requests where each depends on the previous.
-func Pipeline(client interfaces.IHTTPClient, requestBuilders []func(prev interfaces.IHTTPResponse) interfaces.IHTTPRequest) <-chan interfaces.AsyncResult {
-	resultChan := make(chan interfaces.AsyncResult, 1)
+// closeResponse closes a response that will not be handed to the caller.
+func closeResponse(response interfaces.IHTTPResponse) {
+	if response != nil {
+		response.Close()
+	}
+}
+
+// PipelineOptions configures Pipeline.
+type PipelineOptions struct {
+	// KeepBodies buffers each intermediate response body so it stays readable
+	// through PipelineResult.Steps. Otherwise intermediate bodies are closed
+	// as soon as the next step's request has been built.
+	KeepBodies bool
+}
+
+// PipelineResult reports the outcome of a Pipeline run.
+type PipelineResult struct {
+	// Steps holds one result per executed step, in order
+	Steps []interfaces.AsyncResult
+
+	// FailedStep is the index of the step that failed, or -1 on success
+	FailedStep int
+
+	// Error is the failing step's error, or nil on success
+	Error error
+
+	// Response is the final step's response on success
+	Response interfaces.IHTTPResponse
+
+	// Duration is the total elapsed time across all steps
+	Duration time.Duration
+}
+
+// Pipeline runs requests in sequence where each step's request is built from
+// the previous step's response (nil for the first step). It stops at the
+// first failing step or when ctx is cancelled between steps.
+// The final (or failing) response is left open for the caller to close.
+func Pipeline(ctx context.Context, client interfaces.IHTTPClient, requestBuilders []func(prev interfaces.IHTTPResponse) interfaces.IHTTPRequest, opts PipelineOptions) <-chan PipelineResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	resultChan := make(chan PipelineResult, 1)
 
 	go func() {
 		defer close(resultChan)
 
+		start := time.Now()
+		outcome := PipelineResult{
+			Steps:      make([]interfaces.AsyncResult, 0, len(requestBuilders)),
+			FailedStep: -1,
+		}
+		fail := func(result interfaces.AsyncResult) {
+			outcome.Steps = append(outcome.Steps, result)
+			outcome.FailedStep = result.Index
+			outcome.Error = result.Error
+			outcome.Duration = time.Since(start)
+			resultChan <- outcome
+		}
+
 		var prevResp interfaces.IHTTPResponse
 
-		for _, builder := range requestBuilders {
+		for i, builder := range requestBuilders {
+			stepStart := time.Now()
+			if err := ctx.Err(); err != nil {
+				result := cancelledResult(nil, context.Cause(ctx), stepStart)
+				result.Index = i
+				fail(result)
+				return
+			}
+
 			request := builder(prevResp)
+			if i > 0 && !opts.KeepBodies {
+				closeResponse(prevResp)
+			}
 			if request == nil {
-				resultChan <- interfaces.AsyncResult{
-					Error: &models.HTTPError{Message: "pipeline: request builder returned nil"},
-				}
+				fail(interfaces.AsyncResult{
+					Index:    i,
+					Error:    &models.HTTPError{Message: fmt.Sprintf("pipeline: request builder for step %d returned nil", i)},
+					Duration: time.Since(stepStart),
+				})
 				return
 			}
 
-			start := time.Now()
-			resp, err := client.Send(request)
-			duration := time.Since(start)
+			result := sendAsync(ctx, client, request)
+			result.Index = i
+			if result.Error != nil {
+				fail(result)
+				return
+			}
 
-			if err != nil {
-				resultChan <- interfaces.AsyncResult{
-					Request:  request,
-					Response: resp,
-					Error:    err,
-					Duration: duration,
+			if opts.KeepBodies {
+				if _, err := result.Response.Body(); err != nil {
+					result.Error = &models.HTTPError{Request: request, Response: result.Response, Message: "pipeline: failed to buffer response body", Err: err}
+					fail(result)
+					return
 				}
-				return
 			}
 
-			prevResp = resp
+			outcome.Steps = append(outcome.Steps, result)
+			prevResp = result.Response
 		}
 
-		// Return final result
-		resultChan <- interfaces.AsyncResult{
-			Response: prevResp,
-			Duration: 0, // Total duration would need aggregation
-		}
+		outcome.Response = prevResp
+		outcome.Duration = time.Since(start)
+		resultChan <- outcome
 	}()
 
 	return resultChan
 }
+
+// sendAsync sends a request bound to ctx and reports the outcome as an AsyncResult.
+// Additional scopes (e.g. a pool's lifetime) also bound the request.
+// It returns as soon as any scope is done, even if the client has not
+// returned yet; a late response is closed in the background so its
+// connection is released.
+func sendAsync(ctx context.Context, client interfaces.IHTTPClient, request interfaces.IHTTPRequest, scopes ...context.Context) interfaces.AsyncResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	start := time.Now()
+	if request == nil || request.HTTPRequest() == nil {
+		return interfaces.AsyncResult{
+			Request:  request,
+			Error:    &models.HTTPError{Request: request, Message: "async request is nil"},
+			Duration: time.Since(start),
+		}
+	}
+
+	scope, releaseScope := mergeContexts(ctx, scopes...)
+	if scope.Err() != nil {
+		releaseScope()
+		return cancelledResult(request, context.Cause(scope), start)
+	}
+
+	bound, cancelBound := bindContext(scope, request)
+	release := func() {
+		cancelBound()
+		releaseScope()
+	}
+	done := make(chan interfaces.AsyncResult, 1)
+
+	go func() {
+		resp, err := client.Send(bound)
+		done <- interfaces.AsyncResult{
+			Request:   request,
+			Response:  resp,
+			Error:     err,
+			Duration:  time.Since(start),
+			RequestID: requestIDOf(request, resp, err),
+		}
+	}()
+
+	select {
+	case result := <-done:
+		releaseOnClose(result.Response, release)
+		stampDuration(result.Error, result.Duration)
+		return result
+
+	case <-scope.Done():
+		cause := context.Cause(scope)
+		release()
+		go func() {
+			if late := <-done; late.Response != nil {
+				late.Response.Close()
+			}
+		}()
+		return cancelledResult(request, cause, start)
+	}
+}
+
+// cancelledResult builds the result delivered for a cancelled async request.
+func cancelledResult(request interfaces.IHTTPRequest, err error, start time.Time) interfaces.AsyncResult {
+	result := interfaces.AsyncResult{
+		Request: request,
+		Error: &models.HTTPError{
+			Request:  request,
+			Message:  "async request cancelled",
+			Err:      err,
+			StageVal: interfaces.StageRequest,
+		},
+		Duration:  time.Since(start),
+		RequestID: requestIDOf(request, nil, nil),
+	}
+	stampDuration(result.Error, result.Duration)
+	return result
+}
+
+// stampDuration records the async result's duration on its error, so the
+// error's timing agrees with AsyncResult.Duration.
+func stampDuration(err error, duration time.Duration) {
+	var exhausted *models.RetryExhaustedError
+	var httpErr *models.HTTPError
+	switch {
+	case errors.As(err, &exhausted):
+		exhausted.Elapsed = duration
+	case errors.As(err, &httpErr):
+		httpErr.Duration = duration
+	}
+}
+
+// requestIDOf returns the request/correlation ID of a completed exchange,
+// preferring the one recorded on the error.
+func requestIDOf(request interfaces.IHTTPRequest, response interfaces.IHTTPResponse, err error) string {
+	var httpErr interfaces.IHTTPError
+	if errors.As(err, &httpErr) {
+		if id := httpErr.GetRequestID(); id != "" {
+			return id
+		}
+	}
+	if request == nil || request.HTTPRequest() == nil {
+		return ""
+	}
+	return models.RequestIDOf(request, response)
+}
+
+// bindContext returns a copy of the request whose context is cancelled when
+// either ctx or the request's original context is done.
+func bindContext(ctx context.Context, request interfaces.IHTTPRequest) (interfaces.IHTTPRequest, context.CancelFunc) {
+	httpReq := request.HTTPRequest()
+	merged, cancel := mergeContexts(ctx, httpReq.Context())
+
+	bound := &models.Request{
+		HTTPReq:    httpReq.WithContext(merged),
+		TimeoutVal: request.Timeout(),
+	}
+	return bound, cancel
+}
+
+// mergeContexts returns a context derived from primary that is also done as
+// soon as any of the other contexts is done; context.Cause reports the error
+// of whichever finished first. The returned cancel releases all resources.
+func mergeContexts(primary context.Context, others ...context.Context) (context.Context, context.CancelFunc) {
+	merged, cancel := context.WithCancelCause(primary)
+
+	stops := make([]func() bool, 0, len(others))
+	for _, other := range others {
+		if other == nil {
+			continue
+		}
+		other := other
+		stops = append(stops, context.AfterFunc(other, func() {
+			cancel(context.Cause(other))
+		}))
+	}
+
+	return merged, func() {
+		for _, stop := range stops {
+			stop()
+		}
+		cancel(context.Canceled)
+	}
+}
+
+// releaseOnClose keeps the request context alive until the response body is
+// closed, then releases it. Without a response or a body to close, the
+// context is released at once.
+func releaseOnClose(response interfaces.IHTTPResponse, cancel context.CancelFunc) {
+	switch resp := response.(type) {
+	case nil:
+		cancel()
+	case *models.Response:
+		if resp == nil {
+			cancel()
+			return
+		}
+		resp.BindCancel(cancel)
+	default:
+		// Other response types are released when their underlying body is closed
+		httpResp := resp.HTTPResponse()
+		if httpResp == nil || httpResp.Body == nil {
+			cancel()
+			return
+		}
+		httpResp.Body = models.CancelOnClose(httpResp.Body, cancel)
+	}
+}
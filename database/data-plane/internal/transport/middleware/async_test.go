@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// genericResponse is an IHTTPResponse of a type other than *models.Response.
+type genericResponse struct {
+	*models.Response
+}
+
+// genericClient answers every request with a genericResponse and records the
+// context the request was sent under.
+type genericClient struct {
+	interfaces.IHTTPClient
+	sentCtx context.Context
+}
+
+func (c *genericClient) Send(request interfaces.IHTTPRequest) (interfaces.IHTTPResponse, error) {
+	c.sentCtx = request.HTTPRequest().Context()
+	return &genericResponse{&models.Response{
+		HttpResp: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("ok")),
+		},
+		RequestRef: request,
+	}}, nil
+}
+
+func TestExecuteReleasesGenericResponseOnClose(t *testing.T) {
+	c := &genericClient{}
+	result := <-NewAsyncRequest(c).Execute(context.Background(), newRetryRequest(t))
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+
+	if err := c.sentCtx.Err(); err != nil {
+		t.Fatalf("request context released before the body was closed: %v", err)
+	}
+	if body, err := result.Response.BodyString(); err != nil || body != "ok" {
+		t.Fatalf("BodyString = %q, %v", body, err)
+	}
+	result.Response.Close()
+	if c.sentCtx.Err() == nil {
+		t.Fatal("request context not released after the body was closed")
+	}
+}
+
+func TestFanOutWinnerReadableLosersCancelled(t *testing.T) {
+	losersDone := make(chan struct{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			losersDone <- struct{}{}
+			return
+		}
+		io.WriteString(w, "fast")
+	}))
+	defer server.Close()
+
+	tracked := &bodyTracker{IHTTPClient: client.NewHTTPClient()}
+	outcome := FanOut(context.Background(), tracked, []interfaces.IHTTPRequest{
+		newServerRequest(t, server, "/slow"),
+		newServerRequest(t, server, "/fast"),
+		newServerRequest(t, server, "/slow"),
+	})
+	if outcome.Winner != 1 || outcome.Cancelled != 2 || outcome.Failed != 0 || outcome.Attempts != 3 {
+		t.Fatalf("outcome = %+v", outcome)
+	}
+
+	// The winner's context outlives FanOut until its body is closed
+	body, err := outcome.Result.Response.BodyString()
+	if err != nil || body != "fast" {
+		t.Fatalf("winner body = %q, %v", body, err)
+	}
+	outcome.Result.Response.Close()
+
+	for range 2 {
+		select {
+		case <-losersDone:
+		case <-time.After(time.Second):
+			t.Fatal("losing request was not cancelled")
+		}
+	}
+
+	if open := tracked.open(); open != 0 {
+		t.Errorf("%d response bodies left open", open)
+	}
+}
+
+// bodyTracker wraps a client and counts the response bodies still open.
+type bodyTracker struct {
+	interfaces.IHTTPClient
+	mu       sync.Mutex
+	unclosed int
+}
+
+func (b *bodyTracker) Send(request interfaces.IHTTPRequest) (interfaces.IHTTPResponse, error) {
+	resp, err := b.IHTTPClient.Send(request)
+	if r, ok := resp.(*models.Response); ok && r.HttpResp != nil && r.HttpResp.Body != nil {
+		b.mu.Lock()
+		b.unclosed++
+		b.mu.Unlock()
+		r.HttpResp.Body = models.CancelOnClose(r.HttpResp.Body, func() {
+			b.mu.Lock()
+			b.unclosed--
+			b.mu.Unlock()
+		})
+	}
+	return resp, err
+}
+
+func (b *bodyTracker) open() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.unclosed
+}
+
+// newAsyncServer serves "ok" on /ok, a 500 on /fail and blocks on /slow
+// until the request is cancelled.
+func newAsyncServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		case "/slow":
+			<-r.Context().Done()
+		default:
+			io.WriteString(w, "ok")
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newServerRequest returns a GET request for path on server.
+func newServerRequest(t *testing.T, server *httptest.Server, path string) interfaces.IHTTPRequest {
+	t.Helper()
+	httpReq, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.Request{HTTPReq: httpReq}
+}
+
+// receiveOne reads the single result from ch and checks that ch then closes.
+func receiveOne(t *testing.T, ch <-chan interfaces.AsyncResult) interfaces.AsyncResult {
+	t.Helper()
+	var result interfaces.AsyncResult
+	select {
+	case result = <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no result delivered")
+	}
+	select {
+	case _, open := <-ch:
+		if open {
+			t.Fatal("more than one result delivered")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("result channel not closed")
+	}
+	return result
+}
+
+func TestExecute(t *testing.T) {
+	server := newAsyncServer(t)
+	async := NewAsyncRequest(client.NewHTTPClient())
+
+	t.Run("success", func(t *testing.T) {
+		result := receiveOne(t, async.Execute(context.Background(), newServerRequest(t, server, "/ok")))
+		if result.Error != nil {
+			t.Fatal(result.Error)
+		}
+		if body, err := result.Response.BodyString(); err != nil || body != "ok" {
+			t.Fatalf("body = %q, %v", body, err)
+		}
+		if result.Duration <= 0 {
+			t.Error("duration not recorded")
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		result := receiveOne(t, async.Execute(context.Background(), newServerRequest(t, server, "/fail")))
+		if httpErr, ok := models.AsHTTPError(result.Error); !ok || httpErr.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("error = %v, want a 500 HTTPError", result.Error)
+		}
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := async.Execute(ctx, newServerRequest(t, server, "/slow"))
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		result := receiveOne(t, ch)
+		httpErr, ok := models.AsHTTPError(result.Error)
+		if !ok || !httpErr.IsCanceled() {
+			t.Fatalf("error = %v, want a cancellation error", result.Error)
+		}
+	})
+}
+
+func TestExecuteWithCallback(t *testing.T) {
+	server := newAsyncServer(t)
+	async := NewAsyncRequest(client.NewHTTPClient())
+
+	type outcome struct {
+		resp interfaces.IHTTPResponse
+		err  error
+	}
+	got := make(chan outcome, 1)
+	async.ExecuteWithCallback(context.Background(), newServerRequest(t, server, "/fail"), func(resp interfaces.IHTTPResponse, err error) {
+		got <- outcome{resp, err}
+	})
+	select {
+	case o := <-got:
+		if o.resp == nil || o.resp.StatusCode() != http.StatusInternalServerError {
+			t.Fatalf("callback response = %v, want the 500 response", o.resp)
+		}
+		if httpErr, ok := models.AsHTTPError(o.err); !ok || httpErr.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("callback error = %v, want a 500 HTTPError", o.err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("callback not invoked")
+	}
+
+	// A panicking callback is contained; the test process survives it
+	panicked := make(chan struct{})
+	async.ExecuteWithCallback(context.Background(), newServerRequest(t, server, "/ok"), func(resp interfaces.IHTTPResponse, err error) {
+		defer close(panicked)
+		resp.Close()
+		panic("callback failure")
+	})
+	select {
+	case <-panicked:
+	case <-time.After(2 * time.Second):
+		t.Fatal("callback not invoked")
+	}
+	result := receiveOne(t, async.Execute(context.Background(), newServerRequest(t, server, "/ok")))
+	if result.Error != nil {
+		t.Fatalf("request after a panicking callback: %v", result.Error)
+	}
+	result.Response.Close()
+}
@@ -0,0 +1,585 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// BatchOption configures batch execution.
+type BatchOption func(*batchConfig)
+
+// batchConfig holds batch execution settings.
+type batchConfig struct {
+	maxConcurrency int
+	limiter        interfaces.IRateLimiter
+	registry       interfaces.IRateLimiterRegistry
+	interval       time.Duration
+	onProgress     func(BatchProgress)
+	progressEvery  time.Duration
+	errorPolicy    ErrorPolicy
+	timeout        time.Duration
+}
+
+// newBatchConfig applies the options to a default (unbounded, unpaced) config.
+func newBatchConfig(opts []BatchOption) batchConfig {
+	var config batchConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// MaxConcurrency limits how many requests are in flight at once (0 means unbounded).
+func MaxConcurrency(n int) BatchOption {
+	return func(c *batchConfig) {
+		c.maxConcurrency = n
+	}
+}
+
+// RateLimit paces request launches through limiter, which may be shared
+// with other callers to enforce a global rate.
+func RateLimit(limiter interfaces.IRateLimiter) BatchOption {
+	return func(c *batchConfig) {
+		c.limiter = limiter
+	}
+}
+
+// RateLimitPerHost paces each request through the registry's limiter for
+// its target host, sharing the budget with other users of the registry.
+func RateLimitPerHost(registry interfaces.IRateLimiterRegistry) BatchOption {
+	return func(c *batchConfig) {
+		c.registry = registry
+	}
+}
+
+// PaceInterval enforces a fixed minimum interval between request launches.
+func PaceInterval(interval time.Duration) BatchOption {
+	return func(c *batchConfig) {
+		c.interval = interval
+	}
+}
+
+// ErrBatchAborted is the cause reported when an error policy stops a batch.
+var ErrBatchAborted = errors.New("batch aborted by error policy")
+
+// ErrorPolicy decides whether a batch keeps going after requests fail.
+type ErrorPolicy struct {
+	maxErrors int // 0 means never abort
+}
+
+var (
+	// ContinueAll attempts every request regardless of failures.
+	ContinueAll = ErrorPolicy{}
+
+	// FailFast aborts the batch on the first failure.
+	FailFast = ErrorPolicy{maxErrors: 1}
+)
+
+// Threshold aborts the batch once maxErrors requests have failed.
+// A non-positive maxErrors behaves like ContinueAll.
+func Threshold(maxErrors int) ErrorPolicy {
+	if maxErrors < 0 {
+		maxErrors = 0
+	}
+	return ErrorPolicy{maxErrors: maxErrors}
+}
+
+// WithErrorPolicy sets how the batch reacts to failures (default ContinueAll).
+// When the policy trips, outstanding requests are cancelled, unstarted ones
+// are skipped, and Batch.Err reports ErrBatchAborted with the failures.
+func WithErrorPolicy(policy ErrorPolicy) BatchOption {
+	return func(c *batchConfig) {
+		c.errorPolicy = policy
+	}
+}
+
+// ErrBatchDeadline is the cause reported when a batch exceeds its BatchTimeout.
+// It matches context.DeadlineExceeded via errors.Is.
+var ErrBatchDeadline = fmt.Errorf("batch deadline exceeded: %w", context.DeadlineExceeded)
+
+// BatchTimeout bounds the whole batch. When it passes, unstarted requests
+// are skipped, in-flight requests are cancelled, and the results channel
+// closes promptly with one result per request. A deadline on the batch
+// context has the same effect.
+func BatchTimeout(timeout time.Duration) BatchOption {
+	return func(c *batchConfig) {
+		c.timeout = timeout
+	}
+}
+
+// ExecuteBatchPaced executes requests concurrently while pacing their launch
+// rate through the configured RateLimit, RateLimitPerHost and PaceInterval
+// options, combined with the MaxConcurrency bound. Results stream in
+// completion order, tagged with their Index.
+func ExecuteBatchPaced(ctx context.Context, client interfaces.IHTTPClient, requests []interfaces.IHTTPRequest, opts ...BatchOption) <-chan interfaces.AsyncResult {
+	return RunBatch(ctx, client, toEntries(requests), opts...).Results()
+}
+
+// Batch is a batch execution in progress, started by RunBatch.
+type Batch struct {
+	ctx     context.Context
+	results chan interfaces.AsyncResult
+	done    chan struct{}
+	policy  ErrorPolicy
+	tracker *progressTracker
+
+	// stopCtx is cancelled when the batch is stopped early (error policy or
+	// deadline); it only gates launching. In-flight requests are cancelled
+	// individually so responses already delivered are never affected.
+	stopCtx context.Context
+	stop    context.CancelCauseFunc
+
+	mu       sync.Mutex
+	inflight map[int]context.CancelCauseFunc
+	errs     []error
+	stats    BatchStats
+	finished bool
+}
+
+// BatchStats breaks down how each request in a batch ended.
+type BatchStats struct {
+	Total int
+
+	// Succeeded and Failed requests ran to completion on their own
+	Succeeded int
+	Failed    int
+
+	// CancelledInFlight requests were started but cancelled by the batch
+	// context, its deadline or its error policy
+	CancelledInFlight int
+
+	// NotStarted requests were skipped because the batch had already stopped
+	NotStarted int
+}
+
+// Completed returns the number of requests that ran to completion.
+func (s BatchStats) Completed() int {
+	return s.Succeeded + s.Failed
+}
+
+// RunBatch executes entries concurrently under ctx and streams one result
+// per entry in completion order, tagged with the entry's Index and Key.
+// Concurrency, launch pacing, error handling and deadline follow opts.
+//
+// Once ctx is done, the error policy trips or the BatchTimeout passes, no
+// further requests are launched: every remaining request is reported as
+// never started, in-flight requests are cancelled, and the results channel
+// closes as soon as they settle.
+//
+// If client is an IDrainableClient, its Drain waits for the batch to
+// settle; a batch started while it is draining reports every request as
+// never started, with ErrClientDraining.
+func RunBatch(ctx context.Context, client interfaces.IHTTPClient, entries []interfaces.BatchEntry, opts ...BatchOption) *Batch {
+	return runBatch(ctx, client, entries, newBatchConfig(opts))
+}
+
+// runBatch starts a batch with an already-built config.
+func runBatch(ctx context.Context, client interfaces.IHTTPClient, entries []interfaces.BatchEntry, config batchConfig) *Batch {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	stopCtx, stop := context.WithCancelCause(context.Background())
+	b := &Batch{
+		ctx:      ctx,
+		results:  make(chan interfaces.AsyncResult, len(entries)),
+		done:     make(chan struct{}),
+		policy:   config.errorPolicy,
+		tracker:  newProgressTracker(len(entries), config.onProgress, config.progressEvery),
+		stopCtx:  stopCtx,
+		stop:     stop,
+		inflight: make(map[int]context.CancelCauseFunc),
+		stats:    BatchStats{Total: len(entries)},
+	}
+
+	var semaphore chan struct{}
+	if config.maxConcurrency > 0 {
+		semaphore = make(chan struct{}, config.maxConcurrency)
+	}
+
+	// Admit the batch now, so a drain starting meanwhile waits for it
+	ctx, release, admitErr := admit(ctx, client)
+
+	go func() {
+		defer release()
+		defer close(b.done)
+		defer b.tracker.close() // Deliver the final progress before Done
+		defer close(b.results)
+
+		if config.timeout > 0 {
+			deadline := time.AfterFunc(config.timeout, func() {
+				b.halt(fmt.Errorf("%w after %s", ErrBatchDeadline, config.timeout))
+			})
+			defer deadline.Stop()
+		}
+		defer b.finish()
+
+		// Cancel in-flight requests if the caller's context ends
+		stopOnCancel := context.AfterFunc(ctx, func() {
+			b.halt(context.Cause(ctx))
+		})
+		defer stopOnCancel()
+
+		// launchCtx gates launching on both the caller's context and stops
+		launchCtx, stopLaunch := mergeContexts(ctx, stopCtx)
+		defer stopLaunch()
+
+		if admitErr != nil {
+			for i, entry := range entries {
+				b.emit(tagResult(notStartedResult(entry.Request, admitErr), i, entry.Key), false, false)
+			}
+			return
+		}
+
+		var wg sync.WaitGroup
+		var lastLaunch time.Time
+		for i, entry := range entries {
+			var skipErr error
+			if !acquireSlot(launchCtx, semaphore) {
+				skipErr = context.Cause(launchCtx)
+			} else if err := config.pace(launchCtx, entry.Request, &lastLaunch); err != nil {
+				if semaphore != nil {
+					<-semaphore
+				}
+				skipErr = context.Cause(launchCtx)
+				if skipErr == nil {
+					skipErr = err
+				}
+			} else if err := b.stopCause(ctx); err != nil {
+				if semaphore != nil {
+					<-semaphore
+				}
+				skipErr = err
+			}
+			if skipErr != nil {
+				// Batch stopped: account for every request that never started
+				for j := i; j < len(entries); j++ {
+					b.emit(tagResult(notStartedResult(entries[j].Request, skipErr), j, entries[j].Key), false, false)
+				}
+				break
+			}
+
+			wg.Add(1)
+			go func(index int, entry interfaces.BatchEntry) {
+				defer wg.Done()
+				if semaphore != nil {
+					defer func() { <-semaphore }() // Release slot
+				}
+
+				// Each request has its own cancel so that stopping the batch
+				// never reaches responses that were already delivered
+				reqCtx, cancel := context.WithCancelCause(context.Background())
+				b.track(index, cancel)
+				result := sendEntry(ctx, client, entry, reqCtx)
+				b.untrack(index)
+
+				interrupted := result.Error != nil && (reqCtx.Err() != nil || ctx.Err() != nil)
+				b.emit(tagResult(result, index, entry.Key), true, interrupted)
+			}(i, entry)
+		}
+
+		wg.Wait()
+	}()
+
+	return b
+}
+
+// Results returns the channel of per-request results. It is buffered for
+// the whole batch, so the batch completes even if it is never drained.
+func (b *Batch) Results() <-chan interfaces.AsyncResult {
+	return b.results
+}
+
+// Done returns a channel that is closed once every request has settled.
+func (b *Batch) Done() <-chan struct{} {
+	return b.done
+}
+
+// Progress returns a snapshot of the batch's progress, for pollers.
+func (b *Batch) Progress() BatchProgress {
+	return b.tracker.snapshot()
+}
+
+// Stats returns how the batch's requests have ended so far.
+// After Done is closed the counts are final.
+func (b *Batch) Stats() BatchStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// Err waits for the batch to finish and returns the failures of requests
+// that ran to completion, joined with errors.Join and preceded by the reason
+// the batch stopped early, if it did. It returns nil if every request succeeded.
+func (b *Batch) Err() error {
+	<-b.done
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	errs := make([]error, 0, len(b.errs)+1)
+	if b.stopCtx.Err() != nil {
+		errs = append(errs, context.Cause(b.stopCtx))
+	} else if b.stats.CancelledInFlight+b.stats.NotStarted > 0 {
+		// The caller's context ended the batch before halt could record it
+		errs = append(errs, context.Cause(b.ctx))
+	}
+	errs = append(errs, b.errs...)
+	return errors.Join(errs...)
+}
+
+// stopCause returns why the batch stopped launching, or nil if it has not.
+// It reads the sources directly: launchCtx, merged from them, only learns of
+// a stop asynchronously, so a slot freed by a cancelled request could
+// otherwise start one more request after the stop.
+func (b *Batch) stopCause(ctx context.Context) error {
+	if err := context.Cause(b.stopCtx); err != nil {
+		return err
+	}
+	return context.Cause(ctx)
+}
+
+// track registers the cancel func of a request about to be sent.
+// If the batch has already stopped, the request is cancelled at once.
+func (b *Batch) track(index int, cancel context.CancelCauseFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stopCtx.Err() != nil {
+		cancel(context.Cause(b.stopCtx))
+		return
+	}
+	b.inflight[index] = cancel
+}
+
+// untrack forgets a settled request, so later stops leave it alone.
+func (b *Batch) untrack(index int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.inflight, index)
+}
+
+// halt stops the batch: nothing new launches and in-flight requests are
+// cancelled with cause. It has no effect once the batch has finished.
+func (b *Batch) halt(cause error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.haltLocked(cause)
+}
+
+// haltLocked is halt with b.mu already held.
+func (b *Batch) haltLocked(cause error) {
+	if b.finished || b.stopCtx.Err() != nil {
+		return
+	}
+	b.stop(cause)
+	for _, cancel := range b.inflight {
+		cancel(cause)
+	}
+}
+
+// finish marks the batch as settled so that late stops are ignored.
+func (b *Batch) finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.finished = true
+}
+
+// emit delivers a result, classifies it and applies the error policy.
+func (b *Batch) emit(result interfaces.AsyncResult, started, interrupted bool) {
+	b.results <- result
+	b.record(result, started, interrupted)
+	b.tracker.record(result)
+}
+
+// record updates the stats for a result and aborts the batch once the error
+// policy's failure limit is reached. Only requests that failed on their own
+// count towards the limit.
+func (b *Batch) record(result interfaces.AsyncResult, started, interrupted bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case !started:
+		b.stats.NotStarted++
+		return
+	case result.Error == nil:
+		b.stats.Succeeded++
+		return
+	case interrupted:
+		b.stats.CancelledInFlight++
+		return
+	}
+
+	b.stats.Failed++
+	b.errs = append(b.errs, labelError(result))
+
+	if b.policy.maxErrors > 0 && len(b.errs) >= b.policy.maxErrors {
+		b.haltLocked(fmt.Errorf("%w after %d failure(s)", ErrBatchAborted, len(b.errs)))
+	}
+}
+
+// sendEntry sends a batch entry, applying its own retry policy and fallback.
+// Without a RetryPolicy the entry goes through the client as-is, so any
+// decorators already on the client still apply.
+func sendEntry(ctx context.Context, client interfaces.IHTTPClient, entry interfaces.BatchEntry, scopes ...context.Context) interfaces.AsyncResult {
+	counter := &attemptCounter{IHTTPClient: client}
+
+	var sender interfaces.IHTTPClient = counter
+	if entry.RetryPolicy != nil {
+		sender = NewRetryDecorator(counter, entry.RetryPolicy)
+	}
+
+	result := sendAsync(ctx, sender, entry.Request, scopes...)
+	result.Attempts = int(atomic.LoadInt32(&counter.attempts))
+
+	if result.Error != nil && entry.Fallback != nil {
+		resp, err := entry.Fallback(result.Error)
+		if err != nil {
+			result.Error = errors.Join(result.Error, fmt.Errorf("fallback failed: %w", err))
+			return result
+		}
+		closeResponse(result.Response)
+		result.Response = resp
+		result.Error = nil
+		result.FellBack = true
+	}
+	return result
+}
+
+// attemptCounter counts the sends that reach the embedded client.
+type attemptCounter struct {
+	interfaces.IHTTPClient
+	attempts int32
+}
+
+// Send counts the attempt and delegates to the embedded client.
+func (c *attemptCounter) Send(request interfaces.IHTTPRequest) (interfaces.IHTTPResponse, error) {
+	atomic.AddInt32(&c.attempts, 1)
+	return c.IHTTPClient.Send(request)
+}
+
+// pace blocks until the next request may be launched under the configured
+// interval and rate limits. lastLaunch is updated when it returns nil.
+func (c *batchConfig) pace(ctx context.Context, request interfaces.IHTTPRequest, lastLaunch *time.Time) error {
+	if c.interval > 0 && !lastLaunch.IsZero() {
+		if wait := time.Until(lastLaunch.Add(c.interval)); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+	}
+
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if c.registry != nil && request != nil && request.HTTPRequest() != nil {
+		if err := c.registry.Limiter(request.HTTPRequest().URL.Host).Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	*lastLaunch = time.Now()
+	return nil
+}
+
+// acquireSlot waits for a concurrency slot (if limited) and reports whether
+// the request may start. It returns false once ctx is done.
+func acquireSlot(ctx context.Context, semaphore chan struct{}) bool {
+	if semaphore == nil {
+		return ctx.Err() == nil
+	}
+
+	select {
+	case semaphore <- struct{}{}:
+		if ctx.Err() != nil {
+			<-semaphore
+			return false
+		}
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// notStartedResult builds the result for a request skipped by a cancelled batch.
+func notStartedResult(request interfaces.IHTTPRequest, err error) interfaces.AsyncResult {
+	return interfaces.AsyncResult{
+		Request: request,
+		Error: &models.HTTPError{
+			Request: request,
+			Message: "batch cancelled before request started",
+			Err:     err,
+		},
+	}
+}
+
+// labelError prefixes a failed result's error with its batch position and key.
+func labelError(result interfaces.AsyncResult) error {
+	if result.Key != "" {
+		return fmt.Errorf("request %d (%s): %w", result.Index, result.Key, result.Error)
+	}
+	return fmt.Errorf("request %d: %w", result.Index, result.Error)
+}
+
+// tagResult attributes a result to its batch position and key.
+func tagResult(result interfaces.AsyncResult, index int, key string) interfaces.AsyncResult {
+	result.Index = index
+	result.Key = key
+	return result
+}
+
+// toEntries wraps plain requests as unkeyed batch entries.
+func toEntries(requests []interfaces.IHTTPRequest) []interfaces.BatchEntry {
+	entries := make([]interfaces.BatchEntry, len(requests))
+	for i, req := range requests {
+		entries[i] = interfaces.BatchEntry{Request: req}
+	}
+	return entries
+}
+
+// CollectOrdered drains the result channel and returns the results in
+// submission order (by Index). It returns an error if the channel does not
+// yield exactly one result for each of the n submitted requests.
+func CollectOrdered(results <-chan interfaces.AsyncResult, n int) ([]interfaces.AsyncResult, error) {
+	ordered := make([]interfaces.AsyncResult, 0, n)
+	seen := make(map[int]bool, n)
+	var errs []error
+
+	for result := range results {
+		if result.Index < 0 || result.Index >= n {
+			errs = append(errs, fmt.Errorf("result index %d out of range [0, %d)", result.Index, n))
+			continue
+		}
+		if seen[result.Index] {
+			errs = append(errs, fmt.Errorf("duplicate result for index %d", result.Index))
+			continue
+		}
+		seen[result.Index] = true
+		ordered = append(ordered, result)
+	}
+
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].Index < ordered[j].Index
+	})
+
+	if len(ordered) != n {
+		errs = append(errs, fmt.Errorf("expected %d results, got %d", n, len(ordered)))
+	}
+	return ordered, errors.Join(errs...)
+}
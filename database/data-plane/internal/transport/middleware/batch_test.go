@@ -0,0 +1,397 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+	"data-plane/internal/transport/resiliency"
+)
+
+// newBatchRequests returns n requests for path on server.
+func newBatchRequests(t *testing.T, server *httptest.Server, n int, path func(i int) string) []interfaces.IHTTPRequest {
+	t.Helper()
+	requests := make([]interfaces.IHTTPRequest, n)
+	for i := range requests {
+		requests[i] = newServerRequest(t, server, path(i))
+	}
+	return requests
+}
+
+// drainResults reads every result from ch, failing if it does not close in time.
+func drainResults(t *testing.T, ch <-chan interfaces.AsyncResult, timeout time.Duration) []interfaces.AsyncResult {
+	t.Helper()
+	var results []interfaces.AsyncResult
+	deadline := time.After(timeout)
+	for {
+		select {
+		case result, open := <-ch:
+			if !open {
+				return results
+			}
+			closeResponse(result.Response)
+			results = append(results, result)
+		case <-deadline:
+			t.Fatalf("result channel not closed after %v (%d results)", timeout, len(results))
+		}
+	}
+}
+
+func TestRunBatchCancelledHalfway(t *testing.T) {
+	arrived := make(chan struct{}, 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			arrived <- struct{}{}
+			<-r.Context().Done()
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	// The first half answers at once; the second half hangs until cancelled
+	requests := newBatchRequests(t, server, 100, func(i int) string {
+		if i < 50 {
+			return "/ok"
+		}
+		return "/slow"
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batch := RunBatch(ctx, client.NewHTTPClient(), toEntries(requests), MaxConcurrency(10))
+
+	for range 50 {
+		if result := <-batch.Results(); result.Error != nil {
+			t.Fatalf("request %d: %v", result.Index, result.Error)
+		}
+	}
+	for range 10 {
+		<-arrived
+	}
+	cancel()
+
+	start := time.Now()
+	results := drainResults(t, batch.Results(), 2*time.Second)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("batch took %v to settle after cancellation", elapsed)
+	}
+	if len(results) != 50 {
+		t.Fatalf("%d results after cancellation, want 50", len(results))
+	}
+	for _, result := range results {
+		if !errors.Is(result.Error, context.Canceled) {
+			t.Errorf("request %d: error %v, want context.Canceled", result.Index, result.Error)
+		}
+	}
+
+	<-batch.Done()
+	want := BatchStats{Total: 100, Succeeded: 50, CancelledInFlight: 10, NotStarted: 40}
+	if stats := batch.Stats(); stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+	if err := batch.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Err = %v, want context.Canceled", err)
+	}
+}
+
+func TestExecuteBatchWithContextCancelled(t *testing.T) {
+	server := newAsyncServer(t)
+	requests := newBatchRequests(t, server, 20, func(int) string { return "/slow" })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := NewAsyncRequest(client.NewHTTPClient()).ExecuteBatchWithContext(ctx, requests)
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	results := drainResults(t, ch, 2*time.Second)
+	if len(results) != len(requests) {
+		t.Fatalf("%d results, want %d", len(results), len(requests))
+	}
+	seen := make(map[int]bool)
+	for _, result := range results {
+		seen[result.Index] = true
+		if !errors.Is(result.Error, context.Canceled) {
+			t.Errorf("request %d: error %v, want context.Canceled", result.Index, result.Error)
+		}
+	}
+	if len(seen) != len(requests) {
+		t.Errorf("results cover %d distinct requests, want %d", len(seen), len(requests))
+	}
+}
+
+// newTagServer echoes each request's X-Tag header after waiting the
+// milliseconds given in its X-Delay header.
+func newTagServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if delay, err := time.ParseDuration(r.Header.Get("X-Delay") + "ms"); err == nil {
+			time.Sleep(delay)
+		}
+		io.WriteString(w, r.Header.Get("X-Tag"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBatchResultsAttributedByKey(t *testing.T) {
+	server := newTagServer(t)
+
+	// Every entry targets the same URL; later entries finish first
+	keys := []string{"alpha", "beta", "gamma", "delta", "epsilon"}
+	entries := make([]interfaces.BatchEntry, len(keys))
+	for i, key := range keys {
+		request := newServerRequest(t, server, "/same")
+		request.HTTPRequest().Header.Set("X-Tag", key)
+		request.HTTPRequest().Header.Set("X-Delay", strconv.Itoa((len(keys)-i)*10))
+		entries[i] = interfaces.BatchEntry{Key: key, Request: request}
+	}
+
+	ch := NewAsyncRequest(client.NewHTTPClient()).ExecuteEntries(context.Background(), entries)
+	results, err := CollectOrdered(ch, len(entries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		if result.Index != i || result.Key != keys[i] || result.Request != entries[i].Request {
+			t.Errorf("result %d attributed to index %d, key %q", i, result.Index, result.Key)
+		}
+		if body, err := result.Response.BodyString(); err != nil || body != keys[i] {
+			t.Errorf("result %d (%s) carries the response for %q, %v", i, keys[i], body, err)
+		}
+	}
+}
+
+func TestExecuteConcurrentIndexes(t *testing.T) {
+	server := newTagServer(t)
+	requests := newBatchRequests(t, server, 6, func(int) string { return "/same" })
+	for i, request := range requests {
+		request.HTTPRequest().Header.Set("X-Tag", strconv.Itoa(i))
+		request.HTTPRequest().Header.Set("X-Delay", strconv.Itoa((6-i)*5))
+	}
+
+	// Completion order is streamed as is; CollectOrdered restores submission order
+	results, err := CollectOrdered(ExecuteConcurrent(client.NewHTTPClient(), requests, 3), len(requests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		if body, _ := result.Response.BodyString(); body != strconv.Itoa(i) {
+			t.Errorf("result %d carries the response for %q", i, body)
+		}
+	}
+}
+
+func TestCollectOrderedReportsMissingResults(t *testing.T) {
+	ch := make(chan interfaces.AsyncResult, 3)
+	ch <- interfaces.AsyncResult{Index: 1}
+	ch <- interfaces.AsyncResult{Index: 1}
+	ch <- interfaces.AsyncResult{Index: 7}
+	close(ch)
+
+	results, err := CollectOrdered(ch, 2)
+	if len(results) != 1 || err == nil {
+		t.Fatalf("CollectOrdered = %d results, %v; want 1 result and an error", len(results), err)
+	}
+}
+
+// newFlakyServer fails the first two requests to each path with a 503.
+func newFlakyServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		n := hits[r.URL.Path]
+		mu.Unlock()
+		if n <= 2 {
+			http.Error(w, "flaky", http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBatchEntryRetryAndFallback(t *testing.T) {
+	server := newFlakyServer(t)
+	retry := resiliency.NewRetryPolicyWithConfig(3, time.Millisecond, 5*time.Millisecond, 2)
+	fallback := func(err error) (interfaces.IHTTPResponse, error) {
+		return &models.Response{HttpResp: &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}}, nil
+	}
+	entries := []interfaces.BatchEntry{
+		{Key: "retried", Request: newServerRequest(t, server, "/a"), RetryPolicy: retry},
+		{Key: "plain", Request: newServerRequest(t, server, "/b")},
+		{Key: "fallback", Request: newServerRequest(t, server, "/c"), Fallback: fallback},
+	}
+
+	results, err := CollectOrdered(NewAsyncRequest(client.NewHTTPClient()).ExecuteEntries(context.Background(), entries), len(entries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		closeResponse(result.Response)
+	}
+
+	if r := results[0]; r.Error != nil || r.Attempts != 3 || r.FellBack {
+		t.Errorf("retried entry: error %v after %d attempts", r.Error, r.Attempts)
+	}
+	if r := results[1]; r.Error == nil || r.Attempts != 1 {
+		t.Errorf("plain entry: error %v after %d attempts, want a failure after 1", r.Error, r.Attempts)
+	}
+	if r := results[2]; r.Error != nil || r.Attempts != 1 || !r.FellBack {
+		t.Errorf("fallback entry: error %v after %d attempts, fell back %v", r.Error, r.Attempts, r.FellBack)
+	}
+}
+
+func TestPoolEntryRetry(t *testing.T) {
+	server := newFlakyServer(t)
+	pool := NewRequestPool(client.NewHTTPClient(), 2, 2)
+	defer pool.Shutdown(context.Background())
+
+	ch, err := pool.SubmitEntry(context.Background(), interfaces.BatchEntry{
+		Key:         "retried",
+		Request:     newServerRequest(t, server, "/a"),
+		RetryPolicy: resiliency.NewRetryPolicyWithConfig(3, time.Millisecond, 5*time.Millisecond, 2),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := <-ch
+	closeResponse(result.Response)
+	if result.Error != nil || result.Attempts != 3 || result.Key != "retried" {
+		t.Errorf("pool entry: error %v after %d attempts, key %q", result.Error, result.Attempts, result.Key)
+	}
+}
+
+func TestBatchFailFast(t *testing.T) {
+	server := newAsyncServer(t)
+	requests := newBatchRequests(t, server, 5, func(i int) string {
+		if i == 0 {
+			return "/fail"
+		}
+		return "/slow"
+	})
+
+	batch := RunBatch(context.Background(), client.NewHTTPClient(), toEntries(requests), WithErrorPolicy(FailFast))
+	results := drainResults(t, batch.Results(), 2*time.Second)
+	if len(results) != len(requests) {
+		t.Fatalf("%d results, want %d", len(results), len(requests))
+	}
+
+	want := BatchStats{Total: 5, Failed: 1, CancelledInFlight: 4}
+	if stats := batch.Stats(); stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+	err := batch.Err()
+	if !errors.Is(err, ErrBatchAborted) {
+		t.Fatalf("Err = %v, want ErrBatchAborted", err)
+	}
+	if httpErr, ok := models.AsHTTPError(err); !ok || httpErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Err = %v, want it to list the 500 failure", err)
+	}
+}
+
+func TestBatchThreshold(t *testing.T) {
+	server := newAsyncServer(t)
+	requests := newBatchRequests(t, server, 10, func(int) string { return "/fail" })
+
+	batch := RunBatch(context.Background(), client.NewHTTPClient(), toEntries(requests),
+		WithErrorPolicy(Threshold(3)), MaxConcurrency(1))
+	drainResults(t, batch.Results(), 2*time.Second)
+
+	// The request launched as the policy trips is cancelled, not counted as failed
+	stats := batch.Stats()
+	if stats.Failed != 3 || stats.CancelledInFlight+stats.NotStarted != 7 {
+		t.Errorf("stats = %+v, want 3 failed and the other 7 cancelled or skipped", stats)
+	}
+	joined, ok := batch.Err().(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 4 {
+		t.Fatalf("Err = %v, want the abort cause and 3 failures", batch.Err())
+	}
+	if !errors.Is(joined.Unwrap()[0], ErrBatchAborted) {
+		t.Errorf("first error = %v, want ErrBatchAborted", joined.Unwrap()[0])
+	}
+}
+
+func TestBatchContinueAll(t *testing.T) {
+	server := newAsyncServer(t)
+	requests := newBatchRequests(t, server, 4, func(i int) string {
+		if i%2 == 0 {
+			return "/fail"
+		}
+		return "/ok"
+	})
+
+	batch := RunBatch(context.Background(), client.NewHTTPClient(), toEntries(requests))
+	drainResults(t, batch.Results(), 2*time.Second)
+
+	if stats := batch.Stats(); stats.Failed != 2 || stats.Succeeded != 2 {
+		t.Errorf("stats = %+v, want 2 failed and 2 succeeded", stats)
+	}
+	if err := batch.Err(); err == nil || errors.Is(err, ErrBatchAborted) {
+		t.Errorf("Err = %v, want the failures without an abort", err)
+	}
+}
+
+func TestBatchTimeout(t *testing.T) {
+	server := newAsyncServer(t)
+	requests := newBatchRequests(t, server, 20, func(int) string { return "/slow" })
+	c := client.NewHTTPClient()
+	c.SetTimeout(5 * time.Second)
+
+	start := time.Now()
+	batch := RunBatch(context.Background(), c, toEntries(requests), MaxConcurrency(4), BatchTimeout(100*time.Millisecond))
+	results := drainResults(t, batch.Results(), 2*time.Second)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("batch ended after %v, want shortly after its 100ms deadline", elapsed)
+	}
+	if len(results) != len(requests) {
+		t.Fatalf("%d results, want one per request", len(results))
+	}
+	for _, result := range results {
+		if !errors.Is(result.Error, context.DeadlineExceeded) {
+			t.Errorf("request %d: error %v, want a deadline error", result.Index, result.Error)
+		}
+	}
+
+	want := BatchStats{Total: 20, CancelledInFlight: 4, NotStarted: 16}
+	if stats := batch.Stats(); stats != want || stats.Completed() != 0 {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+	if err := batch.Err(); !errors.Is(err, ErrBatchDeadline) {
+		t.Errorf("Err = %v, want ErrBatchDeadline", err)
+	}
+}
+
+func TestBatchContextDeadline(t *testing.T) {
+	server := newAsyncServer(t)
+	requests := newBatchRequests(t, server, 6, func(i int) string {
+		if i < 2 {
+			return "/ok"
+		}
+		return "/slow"
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	batch := RunBatch(ctx, client.NewHTTPClient(), toEntries(requests))
+	drainResults(t, batch.Results(), 2*time.Second)
+
+	want := BatchStats{Total: 6, Succeeded: 2, CancelledInFlight: 4}
+	if stats := batch.Stats(); stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+	if err := batch.Err(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Err = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/interfaces"
+	"data-plane/internal/transport/resiliency"
+)
+
+// newStallServer answers /fail with a 503, stalls /slow before the headers
+// and /partial after writing part of the body, until the request ends.
+func newStallServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case "/partial":
+			io.WriteString(w, "partial")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			<-r.Context().Done()
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// interrupt returns a context that is cancelled, or whose deadline passes,
+// after d.
+func interrupt(t *testing.T, deadline bool, d time.Duration) context.Context {
+	t.Helper()
+	if deadline {
+		ctx, cancel := context.WithTimeout(context.Background(), d)
+		t.Cleanup(cancel)
+		return ctx
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	timer := time.AfterFunc(d, cancel)
+	t.Cleanup(func() {
+		timer.Stop()
+		cancel()
+	})
+	return ctx
+}
+
+// requireInterrupted asserts err is classified as a deadline or a
+// cancellation at the given stage.
+func requireInterrupted(t *testing.T, err error, deadline bool, stage interfaces.ErrorStage) {
+	t.Helper()
+	var httpErr interfaces.IHTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("error = %T %v, want an IHTTPError", err, err)
+	}
+	if httpErr.IsDeadlineExceeded() != deadline || httpErr.IsCanceled() == deadline {
+		t.Errorf("deadline=%v canceled=%v (kind %v), want deadline=%v",
+			httpErr.IsDeadlineExceeded(), httpErr.IsCanceled(), httpErr.Kind(), deadline)
+	}
+	if httpErr.Stage() != stage {
+		t.Errorf("stage = %v, want %v", httpErr.Stage(), stage)
+	}
+}
+
+func TestInterruptedStages(t *testing.T) {
+	server := newStallServer(t)
+
+	for _, deadline := range []bool{true, false} {
+		name := "canceled"
+		if deadline {
+			name = "deadline"
+		}
+
+		t.Run(name+"/request", func(t *testing.T) {
+			ctx := interrupt(t, deadline, 20*time.Millisecond)
+			_, err := client.NewHTTPClient().Send(contextRequest(t, ctx, server, "/slow"))
+			requireInterrupted(t, err, deadline, interfaces.StageRequest)
+		})
+
+		t.Run(name+"/backoff", func(t *testing.T) {
+			ctx := interrupt(t, deadline, 50*time.Millisecond)
+			policy := stubPolicy{attempts: 3, delay: time.Minute, retry: retryAll}
+			_, err := NewRetryDecorator(client.NewHTTPClient(), policy).Send(contextRequest(t, ctx, server, "/fail"))
+			requireInterrupted(t, err, deadline, interfaces.StageBackoff)
+		})
+
+		t.Run(name+"/read body", func(t *testing.T) {
+			ctx := interrupt(t, deadline, 50*time.Millisecond)
+			resp, err := client.NewHTTPClient().Send(contextRequest(t, ctx, server, "/partial"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Close()
+			_, err = resp.Body()
+			requireInterrupted(t, err, deadline, interfaces.StageReadBody)
+		})
+	}
+
+	// A deadline the limiter cannot meet is a rate-limit rejection, so only
+	// cancellation is interrupted while waiting
+	t.Run("canceled/rate limit wait", func(t *testing.T) {
+		limiter := resiliency.NewRateLimiter(0.1, 1)
+		limiter.Allow()
+		ctx := interrupt(t, false, 20*time.Millisecond)
+		_, err := NewRateLimiterDecorator(client.NewHTTPClient(), limiter).Send(contextRequest(t, ctx, server, "/fail"))
+		requireInterrupted(t, err, false, interfaces.StageRateLimitWait)
+	})
+}
+
+func TestMetricsOutcomeLabels(t *testing.T) {
+	server := newStallServer(t)
+	metrics := NewMetricsDecorator(client.NewHTTPClient()).(*MetricsDecorator)
+
+	send := func(ctx context.Context, path string) {
+		if resp, err := metrics.Send(contextRequest(t, ctx, server, path)); err == nil {
+			resp.Close()
+		}
+	}
+	send(interrupt(t, true, 20*time.Millisecond), "/slow")
+	send(interrupt(t, true, 20*time.Millisecond), "/slow")
+	send(interrupt(t, false, 20*time.Millisecond), "/slow")
+	send(context.Background(), "/fail")
+
+	want := map[string]int64{OutcomeDeadlineExceeded: 2, OutcomeCanceled: 1, OutcomeError: 1}
+	got := metrics.Outcomes()
+	for label, count := range want {
+		if got[label] != count {
+			t.Errorf("Outcomes()[%q] = %d, want %d (all: %v)", label, got[label], count, got)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"data-plane/internal/transport/http/models"
@@ -26,31 +28,54 @@ func NewRetryDecorator(wrapped interfaces.IHTTPClient, policy interfaces.IRetryP
 }
 
 // Send executes the request with retry logic.
+// If the request was retried and every attempt failed, the error is a
+// *models.RetryExhaustedError recording the attempt history and wrapping
+// the last attempt's error. A failure that was not retried is returned as
+// it is.
 func (d *RetryDecorator) Send(request interfaces.IHTTPRequest) (interfaces.IHTTPResponse, error) {
 	ctx := request.HTTPRequest().Context()
-	var lastErr error
+	start := time.Now()
+	history := &models.RetryExhaustedError{}
 
 	for attempt := 0; attempt < d.policy.MaxAttempts(); attempt++ {
 		// Check context cancellation before each attempt
 		select {
 		case <-ctx.Done():
 			return nil, &models.HTTPError{
-				Request: request,
-				Message: "request cancelled during retry",
-				Err:     ctx.Err(),
+				Request:  request,
+				Message:  "request cancelled during retry",
+				Err:      ctx.Err(),
+				StageVal: interfaces.StageRequest,
+				Duration: time.Since(start),
 			}
 		default:
 		}
 
+		if attempt > 0 {
+			if err := rewindBody(request, history.Errors[len(history.Errors)-1]); err != nil {
+				return nil, err
+			}
+		}
+
+		attemptStart := time.Now()
 		resp, err := d.wrapped.Send(request)
 		if err == nil {
 			return resp, nil
 		}
 
-		lastErr = err
-		if !d.policy.ShouldRetry(err, attempt) {
+		history.Attempts++
+		history.Errors = append(history.Errors, err)
+		history.Durations = append(history.Durations, time.Since(attemptStart))
+		if resp != nil {
+			resp.Close() // The failed attempt's response is discarded; release its connection
+		}
+		if !d.policy.ShouldRetry(err, attempt) || attempt == d.policy.MaxAttempts()-1 {
 			break
 		}
+		// Fail before the backoff if the body cannot be sent again
+		if err := checkReplayable(request, err); err != nil {
+			return nil, err
+		}
 
 		// Context-aware sleep with exponential backoff
 		delay := d.policy.GetDelay(attempt)
@@ -59,14 +84,26 @@ func (d *RetryDecorator) Send(request interfaces.IHTTPRequest) (interfaces.IHTTP
 			// Continue to next attempt
 		case <-ctx.Done():
 			return nil, &models.HTTPError{
-				Request: request,
-				Message: "request cancelled during retry backoff",
-				Err:     ctx.Err(),
+				Request:  request,
+				Message:  "request cancelled during retry backoff",
+				Err:      ctx.Err(),
+				StageVal: interfaces.StageBackoff,
+				Duration: time.Since(start),
 			}
 		}
 	}
 
-	return nil, lastErr
+	switch history.Attempts {
+	case 0:
+		return nil, &models.HTTPError{
+			Request: request,
+			Message: "retry policy allows no attempts",
+		}
+	case 1:
+		return nil, history.Errors[0]
+	}
+	history.Elapsed = time.Since(start)
+	return nil, history
 }
 
 // SendWithHandler delegates to wrapped client.
@@ -93,6 +130,72 @@ func (d *RetryDecorator) GetHTTPClient() *http.Client {
 	return d.wrapped.GetHTTPClient()
 }
 
+// checkReplayable reports whether the request's body can be sent again.
+// Streamed bodies without GetBody cannot be replayed, so the retry fails
+// with ErrBodyNotReplayable wrapping the previous attempt's error rather
+// than sending a truncated body.
+func checkReplayable(request interfaces.IHTTPRequest, lastErr error) error {
+	httpReq := request.HTTPRequest()
+	if httpReq.Body == nil || httpReq.Body == http.NoBody || httpReq.GetBody != nil {
+		return nil
+	}
+	return &models.HTTPError{
+		Request:  request,
+		Message:  "retry aborted",
+		Err:      fmt.Errorf("%w: %w", models.ErrBodyNotReplayable, lastErr),
+		KindVal:  models.Classify(lastErr),
+		StageVal: interfaces.StageRequest,
+	}
+}
+
+// rewindBody replaces a consumed request body with a fresh one from GetBody
+// before a retry, failing as checkReplayable does if there is none.
+func rewindBody(request interfaces.IHTTPRequest, lastErr error) error {
+	if err := checkReplayable(request, lastErr); err != nil {
+		return err
+	}
+	httpReq := request.HTTPRequest()
+	if httpReq.Body == nil || httpReq.Body == http.NoBody {
+		return nil
+	}
+
+	body, err := httpReq.GetBody()
+	if err != nil {
+		return &models.HTTPError{
+			Request:  request,
+			Message:  "failed to reopen request body for retry",
+			Err:      err,
+			StageVal: interfaces.StageRequest,
+		}
+	}
+	httpReq.Body = body
+	return nil
+}
+
+// wrapRejection wraps a resiliency rejection (an IKindedError) in an
+// HTTPError carrying the request and the rejection's kind, so that callers
+// can both match the sentinel with errors.Is and switch on Kind.
+// Other errors, and rejections already wrapped, are returned unchanged.
+func wrapRejection(request interfaces.IHTTPRequest, err error, stage interfaces.ErrorStage) error {
+	var rejection interfaces.IKindedError
+	if err == nil || !errors.As(err, &rejection) {
+		return err
+	}
+
+	var httpErr *models.HTTPError
+	if errors.As(err, &httpErr) {
+		return err
+	}
+
+	return &models.HTTPError{
+		Request:  request,
+		Message:  "request rejected",
+		Err:      err,
+		KindVal:  models.Classify(rejection),
+		StageVal: stage,
+	}
+}
+
 // ============= CIRCUIT BREAKER DECORATOR =============
 
 // CircuitBreakerDecorator wraps an HTTP client with circuit breaker logic.
@@ -113,9 +216,10 @@ func NewCircuitBreakerDecorator(wrapped interfaces.IHTTPClient, circuitBreaker i
 func (d *CircuitBreakerDecorator) Send(request interfaces.IHTTPRequest) (interfaces.IHTTPResponse, error) {
 	ctx := request.HTTPRequest().Context()
 
-	return d.circuitBreaker.Execute(ctx, func() (interfaces.IHTTPResponse, error) {
+	resp, err := d.circuitBreaker.Execute(ctx, func() (interfaces.IHTTPResponse, error) {
 		return d.wrapped.Send(request)
 	})
+	return resp, wrapRejection(request, err, interfaces.StageUnknown)
 }
 
 // SendWithHandler delegates to wrapped client.
@@ -166,16 +270,21 @@ func (d *RateLimiterDecorator) Send(request interfaces.IHTTPRequest) (interfaces
 	select {
 	case <-ctx.Done():
 		return nil, &models.HTTPError{
-			Request: request,
-			Message: "request cancelled before rate limiting",
-			Err:     ctx.Err(),
+			Request:  request,
+			Message:  "request cancelled before rate limiting",
+			Err:      ctx.Err(),
+			StageVal: interfaces.StageRateLimitWait,
 		}
 	default:
 		if err := d.rateLimiter.Wait(ctx); err != nil {
+			if rejected := wrapRejection(request, err, interfaces.StageRateLimitWait); rejected != err {
+				return nil, rejected
+			}
 			return nil, &models.HTTPError{
-				Request: request,
-				Message: "rate limit exceeded",
-				Err:     err,
+				Request:  request,
+				Message:  "request cancelled while waiting for rate limiter",
+				Err:      err,
+				StageVal: interfaces.StageRateLimitWait,
 			}
 		}
 	}
@@ -227,9 +336,10 @@ func NewBulkheadDecorator(wrapped interfaces.IHTTPClient, bulkhead interfaces.IB
 func (d *BulkheadDecorator) Send(request interfaces.IHTTPRequest) (interfaces.IHTTPResponse, error) {
 	ctx := request.HTTPRequest().Context()
 
-	return d.bulkhead.Execute(ctx, func() (interfaces.IHTTPResponse, error) {
+	resp, err := d.bulkhead.Execute(ctx, func() (interfaces.IHTTPResponse, error) {
 		return d.wrapped.Send(request)
 	})
+	return resp, wrapRejection(request, err, interfaces.StageUnknown)
 }
 
 // SendWithHandler delegates to wrapped client.
@@ -272,16 +382,21 @@ func NewLoggingDecorator(wrapped interfaces.IHTTPClient) interfaces.IHTTPClient
 
 // Send executes the request with logging.
 func (d *LoggingDecorator) Send(request interfaces.IHTTPRequest) (interfaces.IHTTPResponse, error) {
-	fmt.Printf("→ %s %s\n", request.Method(), request.URL())
+	url := models.RedactURL(request.URL())
+	fmt.Printf("→ %s %s\n", request.Method(), url)
 
 	startTime := time.Now()
 	resp, err := d.wrapped.Send(request)
 	duration := time.Since(startTime)
 
-	if err != nil {
-		fmt.Printf("← %s %s failed in %v: %v\n", request.Method(), request.URL(), duration, err)
+	var exhausted *models.RetryExhaustedError
+	if errors.As(err, &exhausted) {
+		fmt.Printf("← %s %s failed after %d attempt(s) in %v: %v\n",
+			request.Method(), url, exhausted.Attempts, duration, exhausted.Last())
+	} else if err != nil {
+		fmt.Printf("← %s %s failed in %v: %v\n", request.Method(), url, duration, err)
 	} else {
-		fmt.Printf("← %s %s returned %d in %v\n", request.Method(), request.URL(), resp.StatusCode(), duration)
+		fmt.Printf("← %s %s returned %d in %v\n", request.Method(), url, resp.StatusCode(), duration)
 	}
 
 	return resp, err
@@ -314,14 +429,30 @@ func (d *LoggingDecorator) GetHTTPClient() *http.Client {
 // ============= METRICS DECORATOR =============
 
 // MetricsDecorator wraps an HTTP client with metrics collection.
+// Outcomes are counted under distinct labels so that deadlines (a problem
+// on our side or upstream) are not confused with caller cancellations.
+// Responses are also counted per negotiated protocol.
 type MetricsDecorator struct {
-	wrapped interfaces.IHTTPClient
-}
+	wrapped   interfaces.IHTTPClient
+	mu        sync.Mutex
+	outcomes  map[string]int64
+	protocols map[string]int64
+}
+
+// Outcome labels recorded by MetricsDecorator.
+const (
+	OutcomeSuccess          = "success"
+	OutcomeDeadlineExceeded = "deadline_exceeded"
+	OutcomeCanceled         = "canceled"
+	OutcomeError            = "error"
+)
 
 // NewMetricsDecorator creates a new metrics decorator.
 func NewMetricsDecorator(wrapped interfaces.IHTTPClient) interfaces.IHTTPClient {
 	return &MetricsDecorator{
-		wrapped: wrapped,
+		wrapped:   wrapped,
+		outcomes:  make(map[string]int64),
+		protocols: make(map[string]int64),
 	}
 }
 
@@ -331,12 +462,63 @@ func (d *MetricsDecorator) Send(request interfaces.IHTTPRequest) (interfaces.IHT
 	resp, err := d.wrapped.Send(request)
 	duration := time.Since(startTime)
 
+	outcome := outcomeLabel(err)
+	protocol := ""
+	if resp != nil {
+		protocol = resp.Protocol()
+	}
+	d.mu.Lock()
+	d.outcomes[outcome]++
+	if protocol != "" {
+		d.protocols[protocol]++
+	}
+	d.mu.Unlock()
+
 	// Record metrics (placeholder for actual metrics implementation)
-	fmt.Printf("[METRICS] method=%s, duration=%v, error=%v\n", request.Method(), duration, err != nil)
+	fmt.Printf("[METRICS] method=%s, duration=%v, error=%v, outcome=%s, protocol=%s\n", request.Method(), duration, err != nil, outcome, protocol)
 
 	return resp, err
 }
 
+// Outcomes returns a snapshot of the request counts per outcome label.
+func (d *MetricsDecorator) Outcomes() map[string]int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	snapshot := make(map[string]int64, len(d.outcomes))
+	for label, count := range d.outcomes {
+		snapshot[label] = count
+	}
+	return snapshot
+}
+
+// Protocols returns a snapshot of the response counts per negotiated protocol.
+func (d *MetricsDecorator) Protocols() map[string]int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	snapshot := make(map[string]int64, len(d.protocols))
+	for protocol, count := range d.protocols {
+		snapshot[protocol] = count
+	}
+	return snapshot
+}
+
+// outcomeLabel returns the metrics label for a request's result.
+func outcomeLabel(err error) string {
+	if err == nil {
+		return OutcomeSuccess
+	}
+	switch models.Classify(err) {
+	case interfaces.KindTimeout:
+		return OutcomeDeadlineExceeded
+	case interfaces.KindCanceled:
+		return OutcomeCanceled
+	default:
+		return OutcomeError
+	}
+}
+
 // SendWithHandler delegates to wrapped client.
 func (d *MetricsDecorator) SendWithHandler(request interfaces.IHTTPRequest, handler interfaces.IResponseHandler) (interface{}, error) {
 	resp, err := d.Send(request)
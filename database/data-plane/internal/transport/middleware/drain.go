@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// ErrClientDraining is returned by Send, and reported by the pool and batch
+// helpers, once Drain or Close has been called on the client. It carries
+// the ErrorKind KindDraining.
+var ErrClientDraining error = &drainingError{}
+
+// drainingError is the rejection of a draining client.
+type drainingError struct{}
+
+// Ensure drainingError implements IKindedError interface
+var _ interfaces.IKindedError = (*drainingError)(nil)
+
+// Error implements the error interface.
+func (e *drainingError) Error() string {
+	return "client is draining"
+}
+
+// ErrorKind returns KindDraining.
+func (e *drainingError) ErrorKind() interfaces.ErrorKind {
+	return interfaces.KindDraining
+}
+
+// ============= DRAIN DECORATOR =============
+
+// DrainDecorator wraps an HTTP client with in-flight tracking for graceful
+// shutdown. A request is in flight from Send until its response body is
+// closed, or until Send returns if it fails. Once Drain or Close is called,
+// new requests are rejected with ErrClientDraining, except those sent under
+// a context returned by Admit.
+type DrainDecorator struct {
+	wrapped interfaces.IHTTPClient
+
+	// ctx bounds every request; it is cancelled if Drain's deadline expires
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	mu       sync.Mutex
+	draining bool
+	inFlight int
+	idle     chan struct{} // Closed once draining with nothing in flight
+}
+
+// Ensure DrainDecorator implements IDrainableClient interface
+var _ interfaces.IDrainableClient = (*DrainDecorator)(nil)
+
+// admittedContextKey is the context key under which Admit marks its context.
+type admittedContextKey struct{}
+
+// NewDrainDecorator creates a new drain decorator.
+func NewDrainDecorator(wrapped interfaces.IHTTPClient) *DrainDecorator {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	return &DrainDecorator{
+		wrapped: wrapped,
+		ctx:     ctx,
+		cancel:  cancel,
+		idle:    make(chan struct{}),
+	}
+}
+
+// Send executes the request unless the client is draining, cancelling it if
+// Drain gives up waiting.
+func (d *DrainDecorator) Send(request interfaces.IHTTPRequest) (interfaces.IHTTPResponse, error) {
+	if request == nil || request.HTTPRequest() == nil {
+		return d.wrapped.Send(request)
+	}
+
+	admitted := request.HTTPRequest().Context().Value(admittedContextKey{}) == d
+	if !d.acquire(admitted) {
+		return nil, wrapRejection(request, ErrClientDraining, interfaces.StageRequest)
+	}
+
+	bound, cancel := bindContext(d.ctx, request)
+	var once sync.Once
+	finish := func() {
+		once.Do(func() {
+			cancel()
+			d.release()
+		})
+	}
+
+	resp, err := d.wrapped.Send(bound)
+	if response, ok := resp.(*models.Response); ok && response != nil && err == nil {
+		response.BindCancel(finish)
+	} else {
+		finish()
+	}
+	return resp, err
+}
+
+// Admit registers work that will send requests through the client later;
+// see IDrainableClient.
+func (d *DrainDecorator) Admit(ctx context.Context) (context.Context, func(), error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !d.acquire(false) {
+		return ctx, func() {}, ErrClientDraining
+	}
+	var once sync.Once
+	return context.WithValue(ctx, admittedContextKey{}, d), func() { once.Do(d.release) }, nil
+}
+
+// acquire counts a request in flight, reporting false if the client is
+// draining and the request was not admitted.
+func (d *DrainDecorator) acquire(admitted bool) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.draining && !admitted {
+		return false
+	}
+	d.inFlight++
+	return true
+}
+
+// release counts a request as complete.
+func (d *DrainDecorator) release() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.inFlight--
+	d.signalIdleLocked()
+}
+
+// signalIdleLocked closes idle once draining with nothing in flight.
+func (d *DrainDecorator) signalIdleLocked() {
+	if !d.draining || d.inFlight > 0 {
+		return
+	}
+	select {
+	case <-d.idle:
+	default:
+		close(d.idle)
+	}
+}
+
+// Drain stops accepting requests and waits for those in flight, and the
+// admitted work, to complete. If ctx expires first, the remaining requests
+// are cancelled and ctx's error is returned.
+func (d *DrainDecorator) Drain(ctx context.Context) error {
+	d.mu.Lock()
+	d.draining = true
+	d.signalIdleLocked()
+	d.mu.Unlock()
+
+	select {
+	case <-d.idle:
+		return nil
+	case <-ctx.Done():
+		d.cancel(ErrClientDraining)
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting requests, cancels those in flight and closes the
+// idle connections of the underlying http.Client.
+func (d *DrainDecorator) Close() error {
+	d.mu.Lock()
+	d.draining = true
+	d.signalIdleLocked()
+	d.mu.Unlock()
+
+	d.cancel(ErrClientDraining)
+	if httpClient := d.wrapped.GetHTTPClient(); httpClient != nil {
+		httpClient.CloseIdleConnections()
+	}
+	return nil
+}
+
+// InFlight returns the number of requests and admitted work in flight.
+func (d *DrainDecorator) InFlight() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inFlight
+}
+
+// SendWithHandler delegates to wrapped client.
+func (d *DrainDecorator) SendWithHandler(request interfaces.IHTTPRequest, handler interfaces.IResponseHandler) (interface{}, error) {
+	resp, err := d.Send(request)
+	if err != nil {
+		return nil, err
+	}
+	return handler.Handle(resp)
+}
+
+// SetTimeout sets the timeout on the wrapped client.
+func (d *DrainDecorator) SetTimeout(timeout time.Duration) {
+	d.wrapped.SetTimeout(timeout)
+}
+
+// SetHTTPClient sets the HTTP client on the wrapped client.
+func (d *DrainDecorator) SetHTTPClient(client *http.Client) {
+	d.wrapped.SetHTTPClient(client)
+}
+
+// GetHTTPClient returns the HTTP client from the wrapped client.
+func (d *DrainDecorator) GetHTTPClient() *http.Client {
+	return d.wrapped.GetHTTPClient()
+}
+
+// admit holds a drainable client open for work about to send through it;
+// see IDrainableClient.Admit. Other clients admit everything.
+func admit(ctx context.Context, client interfaces.IHTTPClient) (context.Context, func(), error) {
+	if drainable, ok := client.(interfaces.IDrainableClient); ok {
+		return drainable.Admit(ctx)
+	}
+	return ctx, func() {}, nil
+}
@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// sendInBackground sends request through c, closing the response, and delivers
+// the error.
+func sendInBackground(c interfaces.IHTTPClient, request interfaces.IHTTPRequest) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		resp, err := c.Send(request)
+		if err == nil {
+			_, err = resp.Body()
+			resp.Close()
+		}
+		done <- err
+	}()
+	return done
+}
+
+// startDrain calls Drain in the background and waits until d rejects new
+// work, delivering Drain's result.
+func startDrain(t *testing.T, d *DrainDecorator, ctx context.Context) <-chan error {
+	t.Helper()
+	drained := make(chan error, 1)
+	go func() { drained <- d.Drain(ctx) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		_, release, err := d.Admit(context.Background())
+		release()
+		if errors.Is(err, ErrClientDraining) {
+			return drained
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the client never started draining")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// requireDraining checks that err is the typed draining rejection.
+func requireDraining(t *testing.T, what string, err error) {
+	t.Helper()
+	if !errors.Is(err, ErrClientDraining) || models.Classify(err) != interfaces.KindDraining {
+		t.Errorf("%s = %v, want ErrClientDraining of kind draining", what, err)
+	}
+}
+
+func TestDrainWaitsForInFlight(t *testing.T) {
+	server := newGateServer(t)
+	d := NewDrainDecorator(client.NewHTTPClient())
+
+	var sends []<-chan error
+	for range 3 {
+		sends = append(sends, sendInBackground(d, server.request(t)))
+	}
+	for range 3 {
+		<-server.arrived
+	}
+	if n := d.InFlight(); n != 3 {
+		t.Fatalf("InFlight = %d, want 3", n)
+	}
+
+	drained := startDrain(t, d, context.Background())
+	_, err := d.Send(server.request(t))
+	requireDraining(t, "Send while draining", err)
+	select {
+	case err := <-drained:
+		t.Fatalf("Drain returned %v with requests in flight", err)
+	default:
+	}
+
+	close(server.gate)
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Fatalf("Drain: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Drain did not return once the requests completed")
+	}
+	for _, send := range sends {
+		if err := <-send; err != nil {
+			t.Errorf("in-flight request failed: %v", err)
+		}
+	}
+	if n := d.InFlight(); n != 0 {
+		t.Errorf("InFlight after draining = %d, want 0", n)
+	}
+}
+
+func TestDrainDeadlineCancelsRemaining(t *testing.T) {
+	server := newGateServer(t)
+	d := NewDrainDecorator(client.NewHTTPClient())
+	send := sendInBackground(d, server.request(t))
+	<-server.arrived
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := d.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain = %v, want context.DeadlineExceeded", err)
+	}
+
+	select {
+	case err := <-send:
+		if err == nil {
+			t.Error("the request still in flight succeeded, want it cancelled")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the request still in flight was not cancelled")
+	}
+	if n := d.InFlight(); n != 0 {
+		t.Errorf("InFlight = %d, want 0", n)
+	}
+}
+
+func TestDrainIdleClient(t *testing.T) {
+	d := NewDrainDecorator(client.NewHTTPClient())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := d.Drain(ctx); err != nil {
+		t.Fatalf("Drain with nothing in flight: %v", err)
+	}
+	_, release, err := d.Admit(context.Background())
+	release()
+	requireDraining(t, "Admit after Drain", err)
+}
+
+func TestCloseCancelsInFlight(t *testing.T) {
+	server := newGateServer(t)
+	d := NewDrainDecorator(client.NewHTTPClient())
+	send := sendInBackground(d, server.request(t))
+	<-server.arrived
+
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	select {
+	case err := <-send:
+		if err == nil {
+			t.Error("the request in flight succeeded after Close, want it cancelled")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not cancel the request in flight")
+	}
+	_, err := d.Send(server.request(t))
+	requireDraining(t, "Send after Close", err)
+}
+
+func TestDrainPoolAndBatch(t *testing.T) {
+	server := newGateServer(t)
+	d := NewDrainDecorator(client.NewHTTPClient())
+	pool := NewRequestPool(d, 1, 5)
+	defer pool.Shutdown(context.Background())
+
+	// One submission runs and the other waits in the queue
+	running, err := pool.Submit(context.Background(), server.request(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	queued, err := pool.Submit(context.Background(), server.request(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-server.arrived
+
+	drained := startDrain(t, d, context.Background())
+	_, err = pool.Submit(context.Background(), server.request(t))
+	requireDraining(t, "Submit while draining", err)
+
+	batch := RunBatch(context.Background(), d, toEntries([]interfaces.IHTTPRequest{server.request(t)}))
+	if result := receiveOne(t, batch.Results()); !errors.Is(result.Error, ErrClientDraining) {
+		t.Errorf("batch result = %v, want ErrClientDraining", result.Error)
+	}
+	if stats := batch.Stats(); stats.NotStarted != 1 {
+		t.Errorf("batch stats = %+v, want the request not started", stats)
+	}
+	result := receiveOne(t, NewAsyncRequest(d).Execute(context.Background(), server.request(t)))
+	requireDraining(t, "async request while draining", result.Error)
+
+	// The queued submission was admitted before the drain, so it still runs
+	close(server.gate)
+	for name, ch := range map[string]<-chan interfaces.AsyncResult{"running": running, "queued": queued} {
+		result := receiveOne(t, ch)
+		if result.Error != nil {
+			t.Errorf("%s submission failed: %v", name, result.Error)
+			continue
+		}
+		result.Response.Close()
+	}
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Fatalf("Drain: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Drain did not return once the pool finished")
+	}
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// Invoker decorators apply the resiliency patterns to protocol-neutral
+// calls, reusing the same policies, breakers, limiters and bulkheads as the
+// HTTP client decorators.
+
+// ============= RETRY INVOKER =============
+
+// RetryInvoker wraps an invoker with retry logic.
+type RetryInvoker struct {
+	wrapped interfaces.IInvoker
+	policy  interfaces.IRetryPolicy
+}
+
+// NewRetryInvoker creates a new retry invoker.
+func NewRetryInvoker(wrapped interfaces.IInvoker, policy interfaces.IRetryPolicy) interfaces.IInvoker {
+	return &RetryInvoker{
+		wrapped: wrapped,
+		policy:  policy,
+	}
+}
+
+// Invoke executes the call, retrying failures the policy deems retryable.
+// If the call was retried and every attempt failed, it returns a
+// RetryExhaustedError holding every attempt's error, as the RetryDecorator
+// does for HTTP requests; a failure that was not retried is returned as it is.
+func (i *RetryInvoker) Invoke(ctx context.Context, call interfaces.ICall) error {
+	start := time.Now()
+	history := &models.RetryExhaustedError{}
+
+	for attempt := 0; attempt < i.policy.MaxAttempts(); attempt++ {
+		// Check context cancellation before each attempt
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("%s cancelled during retry: %w", call.Operation(), err)
+		}
+
+		attemptStart := time.Now()
+		err := i.wrapped.Invoke(ctx, call)
+		if err == nil {
+			return nil
+		}
+
+		history.Attempts++
+		history.Errors = append(history.Errors, err)
+		history.Durations = append(history.Durations, time.Since(attemptStart))
+		if !i.policy.ShouldRetry(err, attempt) || attempt == i.policy.MaxAttempts()-1 {
+			break
+		}
+
+		// Context-aware sleep with exponential backoff
+		select {
+		case <-time.After(i.policy.GetDelay(attempt)):
+		case <-ctx.Done():
+			return fmt.Errorf("%s cancelled during retry backoff: %w", call.Operation(), ctx.Err())
+		}
+	}
+
+	switch history.Attempts {
+	case 0:
+		return fmt.Errorf("retry policy allows no attempts")
+	case 1:
+		return history.Errors[0]
+	}
+	history.Elapsed = time.Since(start)
+	return history
+}
+
+// ============= CIRCUIT BREAKER INVOKER =============
+
+// CircuitBreakerInvoker wraps an invoker with circuit breaker protection.
+type CircuitBreakerInvoker struct {
+	wrapped        interfaces.IInvoker
+	circuitBreaker interfaces.ICircuitBreaker
+}
+
+// NewCircuitBreakerInvoker creates a new circuit breaker invoker.
+func NewCircuitBreakerInvoker(wrapped interfaces.IInvoker, circuitBreaker interfaces.ICircuitBreaker) interfaces.IInvoker {
+	return &CircuitBreakerInvoker{
+		wrapped:        wrapped,
+		circuitBreaker: circuitBreaker,
+	}
+}
+
+// Invoke executes the call through the circuit breaker.
+func (i *CircuitBreakerInvoker) Invoke(ctx context.Context, call interfaces.ICall) error {
+	_, err := i.circuitBreaker.Execute(ctx, func() (interfaces.IHTTPResponse, error) {
+		return nil, i.wrapped.Invoke(ctx, call)
+	})
+	return err
+}
+
+// ============= RATE LIMITER INVOKER =============
+
+// RateLimiterInvoker wraps an invoker with rate limiting.
+type RateLimiterInvoker struct {
+	wrapped     interfaces.IInvoker
+	rateLimiter interfaces.IRateLimiter
+}
+
+// NewRateLimiterInvoker creates a new rate limiter invoker.
+func NewRateLimiterInvoker(wrapped interfaces.IInvoker, rateLimiter interfaces.IRateLimiter) interfaces.IInvoker {
+	return &RateLimiterInvoker{
+		wrapped:     wrapped,
+		rateLimiter: rateLimiter,
+	}
+}
+
+// Invoke waits for the rate limiter, then executes the call.
+func (i *RateLimiterInvoker) Invoke(ctx context.Context, call interfaces.ICall) error {
+	if err := i.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+	return i.wrapped.Invoke(ctx, call)
+}
+
+// ============= BULKHEAD INVOKER =============
+
+// BulkheadInvoker wraps an invoker with bulkhead protection.
+type BulkheadInvoker struct {
+	wrapped  interfaces.IInvoker
+	bulkhead interfaces.IBulkhead
+}
+
+// NewBulkheadInvoker creates a new bulkhead invoker.
+func NewBulkheadInvoker(wrapped interfaces.IInvoker, bulkhead interfaces.IBulkhead) interfaces.IInvoker {
+	return &BulkheadInvoker{
+		wrapped:  wrapped,
+		bulkhead: bulkhead,
+	}
+}
+
+// Invoke executes the call within the bulkhead.
+func (i *BulkheadInvoker) Invoke(ctx context.Context, call interfaces.ICall) error {
+	_, err := i.bulkhead.Execute(ctx, func() (interfaces.IHTTPResponse, error) {
+		return nil, i.wrapped.Invoke(ctx, call)
+	})
+	return err
+}
@@ -2,10 +2,15 @@ package middleware
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"time"
 
+	"data-plane/internal/transport/http/models"
 	"data-plane/internal/transport/interfaces"
 )
 
@@ -29,7 +34,7 @@ func NewLoggingMiddleware(logger *log.Logger) *LoggingMiddleware {
 
 // Before logs the request before sending.
 func (lm *LoggingMiddleware) Before(ctx context.Context, request interfaces.IHTTPRequest) (context.Context, error) {
-	lm.logger.Printf("[HTTP] → %s %s", request.Method(), request.URL())
+	lm.logger.Printf("[HTTP] → %s %s", request.Method(), models.RedactURL(request.URL()))
 
 	// Store start time in context
 	ctx = context.WithValue(ctx, "start_time", time.Now())
@@ -46,9 +51,9 @@ func (lm *LoggingMiddleware) After(ctx context.Context, request interfaces.IHTTP
 	duration := time.Since(startTime)
 
 	if err != nil {
-		lm.logger.Printf("[HTTP] ← %s %s [ERROR] %v (took %v)", request.Method(), request.URL(), err, duration)
+		lm.logger.Printf("[HTTP] ← %s %s [ERROR] %v (took %v)", request.Method(), models.RedactURL(request.URL()), err, duration)
 	} else {
-		lm.logger.Printf("[HTTP] ← %s %s [%d] (took %v)", request.Method(), request.URL(), response.StatusCode(), duration)
+		lm.logger.Printf("[HTTP] ← %s %s [%d] (took %v)", request.Method(), models.RedactURL(request.URL()), response.StatusCode(), duration)
 	}
 
 	return nil
@@ -188,10 +193,77 @@ func (tm *TracingMiddleware) After(ctx context.Context, request interfaces.IHTTP
 	traceID := ctx.Value("trace_id")
 	spanID := ctx.Value("span_id")
 
-	log.Printf("[TRACE] TraceID=%v SpanID=%v Method=%s URL=%s", traceID, spanID, request.Method(), request.URL())
+	log.Printf("[TRACE] TraceID=%v SpanID=%v Method=%s URL=%s", traceID, spanID, request.Method(), models.RedactURL(request.URL()))
 	return nil
 }
 
+// RequestIDMiddleware stamps each request with a request/correlation ID
+// header so that failures can be joined against upstream access logs.
+// A request that already carries the header keeps its ID, so retries and
+// caller-supplied IDs are preserved.
+type RequestIDMiddleware struct {
+	header   string
+	generate func() string
+}
+
+// Ensure RequestIDMiddleware implements IMiddleware interface
+var _ interfaces.IMiddleware = (*RequestIDMiddleware)(nil)
+
+// NewRequestIDMiddleware creates a new request ID middleware.
+// An empty header defaults to X-Request-Id; a nil generate produces random hex IDs.
+func NewRequestIDMiddleware(header string, generate func() string) *RequestIDMiddleware {
+	if header == "" {
+		header = models.RequestIDHeader
+	}
+	if generate == nil {
+		generate = newRequestID
+	}
+	return &RequestIDMiddleware{
+		header:   header,
+		generate: generate,
+	}
+}
+
+// Before sets the request ID header if the request does not carry one yet.
+func (rm *RequestIDMiddleware) Before(ctx context.Context, request interfaces.IHTTPRequest) (context.Context, error) {
+	httpReq := request.HTTPRequest()
+	if httpReq == nil {
+		return ctx, nil
+	}
+	if httpReq.Header == nil {
+		httpReq.Header = make(http.Header)
+	}
+
+	id := httpReq.Header.Get(rm.header)
+	if id == "" {
+		id = rm.generate()
+		httpReq.Header.Set(rm.header, id)
+	}
+	ctx = context.WithValue(ctx, "request_id", id)
+	return ctx, nil
+}
+
+// After attaches the request ID to HTTPErrors that do not carry one yet,
+// which matters when a custom header name is used.
+func (rm *RequestIDMiddleware) After(ctx context.Context, request interfaces.IHTTPRequest, response interfaces.IHTTPResponse, err error) error {
+	var httpErr *models.HTTPError
+	if errors.As(err, &httpErr) && httpErr.RequestID == "" {
+		if id, ok := ctx.Value("request_id").(string); ok {
+			httpErr.RequestID = id
+		}
+	}
+	return nil
+}
+
+// newRequestID returns a random 16-byte hex request ID.
+func newRequestID() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return fmt.Sprintf("req-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf[:])
+}
+
 // MiddlewareChain executes multiple middleware in sequence.
 type MiddlewareChain struct {
 	middlewares []interfaces.IMiddleware
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/interfaces"
+	"data-plane/internal/transport/resiliency"
+)
+
+// stampServer records when each request arrives.
+type stampServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	times []time.Time
+}
+
+func newStampServer(t *testing.T) *stampServer {
+	t.Helper()
+	s := &stampServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.times = append(s.times, time.Now())
+		s.mu.Unlock()
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// meanGap returns the average interval between consecutive arrivals.
+// Individual gaps jitter with scheduling, but their mean follows the pace.
+func (s *stampServer) meanGap() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.times) < 2 {
+		return 0
+	}
+	return s.times[len(s.times)-1].Sub(s.times[0]) / time.Duration(len(s.times)-1)
+}
+
+// runPaced runs a paced batch of n requests to server and waits for it.
+func runPaced(t *testing.T, server *stampServer, n int, opts ...BatchOption) []interfaces.AsyncResult {
+	t.Helper()
+	requests := newBatchRequests(t, server.Server, n, func(int) string { return "/" })
+	results := drainResults(t, ExecuteBatchPaced(context.Background(), client.NewHTTPClient(), requests, opts...), 5*time.Second)
+	for _, result := range results {
+		if result.Error != nil {
+			t.Fatal(result.Error)
+		}
+	}
+	return results
+}
+
+// tolerance absorbs timer and scheduling jitter in the gap assertions.
+const tolerance = 3 * time.Millisecond
+
+func TestExecuteBatchPacedInterval(t *testing.T) {
+	server := newStampServer(t)
+	runPaced(t, server, 6, PaceInterval(25*time.Millisecond), MaxConcurrency(6))
+
+	if gap := server.meanGap(); gap < 25*time.Millisecond-tolerance {
+		t.Errorf("requests %v apart on average, want at least 25ms", gap)
+	}
+}
+
+func TestExecuteBatchPacedRateLimit(t *testing.T) {
+	server := newStampServer(t)
+	runPaced(t, server, 6, RateLimit(resiliency.NewRateLimiter(40, 1)))
+
+	// One token up front, then one every 25ms
+	if gap := server.meanGap(); gap < 25*time.Millisecond-tolerance {
+		t.Errorf("requests %v apart on average, want at least 25ms", gap)
+	}
+}
+
+func TestExecuteBatchPacedPerHost(t *testing.T) {
+	first, second := newStampServer(t), newStampServer(t)
+	requests := append(
+		newBatchRequests(t, first.Server, 4, func(int) string { return "/" }),
+		newBatchRequests(t, second.Server, 4, func(int) string { return "/" })...)
+
+	var mu sync.Mutex
+	var final BatchProgress
+	registry := resiliency.NewRateLimiterRegistry(40, 1)
+	batch := RunBatch(context.Background(), client.NewHTTPClient(), toEntries(requests),
+		RateLimitPerHost(registry), OnProgress(func(p BatchProgress) {
+			mu.Lock()
+			final = p
+			mu.Unlock()
+		}))
+	drainResults(t, batch.Results(), 5*time.Second)
+	<-batch.Done() // The final progress is delivered before Done
+
+	// Each host is paced on its own limiter
+	for _, server := range []*stampServer{first, second} {
+		if gap := server.meanGap(); gap < 25*time.Millisecond-tolerance {
+			t.Errorf("requests to one host %v apart on average, want at least 25ms", gap)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if final.Completed != len(requests) || final.Total != len(requests) {
+		t.Errorf("final progress = %d of %d, want %d of %d", final.Completed, final.Total, len(requests), len(requests))
+	}
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// newPipelineServer answers /step/N with "N" after 5ms, and 404 for /missing.
+func newPipelineServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, r.URL.Path[len("/step/"):])
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// pipelineSteps returns builders requesting each path in turn.
+func pipelineSteps(t *testing.T, server *httptest.Server, paths ...string) []func(interfaces.IHTTPResponse) interfaces.IHTTPRequest {
+	steps := make([]func(interfaces.IHTTPResponse) interfaces.IHTTPRequest, len(paths))
+	for i, path := range paths {
+		steps[i] = func(interfaces.IHTTPResponse) interfaces.IHTTPRequest {
+			return newServerRequest(t, server, path)
+		}
+	}
+	return steps
+}
+
+func TestPipelineReportsFailingStep(t *testing.T) {
+	server := newPipelineServer(t)
+	steps := pipelineSteps(t, server, "/step/0", "/missing", "/step/2")
+
+	outcome := <-Pipeline(context.Background(), client.NewHTTPClient(), steps, PipelineOptions{KeepBodies: true})
+	if outcome.FailedStep != 1 || len(outcome.Steps) != 2 {
+		t.Fatalf("failed step %d after %d steps, want step 1 after 2", outcome.FailedStep, len(outcome.Steps))
+	}
+	if httpErr, ok := models.AsHTTPError(outcome.Error); !ok || httpErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("error = %v, want the 404 of step 1", outcome.Error)
+	}
+	if outcome.Response != nil {
+		t.Error("a failed pipeline reported a final response")
+	}
+
+	var total time.Duration
+	for i, step := range outcome.Steps {
+		if step.Index != i || step.Request == nil || step.Duration < 5*time.Millisecond {
+			t.Errorf("step %d = index %d, duration %v", i, step.Index, step.Duration)
+		}
+		total += step.Duration
+	}
+	if outcome.Duration < total {
+		t.Errorf("total duration %v is less than the steps' %v", outcome.Duration, total)
+	}
+
+	// KeepBodies leaves intermediate bodies readable
+	if body, err := outcome.Steps[0].Response.BodyString(); err != nil || body != "0" {
+		t.Errorf("step 0 body = %q, %v", body, err)
+	}
+	outcome.Steps[1].Response.Close()
+}
+
+func TestPipelineSuccess(t *testing.T) {
+	server := newPipelineServer(t)
+	var seen []string
+	steps := pipelineSteps(t, server, "/step/0", "/step/1", "/step/2")
+	for i, step := range steps {
+		steps[i] = func(prev interfaces.IHTTPResponse) interfaces.IHTTPRequest {
+			if prev != nil {
+				body, _ := prev.BodyString()
+				seen = append(seen, body)
+			}
+			return step(prev)
+		}
+	}
+
+	outcome := <-Pipeline(context.Background(), client.NewHTTPClient(), steps, PipelineOptions{})
+	if outcome.Error != nil || outcome.FailedStep != -1 || len(outcome.Steps) != 3 {
+		t.Fatalf("outcome = %+v", outcome)
+	}
+	if len(seen) != 2 || seen[0] != "0" || seen[1] != "1" {
+		t.Errorf("builders saw %q, want each previous step's body", seen)
+	}
+	if body, err := outcome.Response.BodyString(); err != nil || body != "2" {
+		t.Errorf("final body = %q, %v", body, err)
+	}
+}
+
+func TestPipelineCancelledBetweenSteps(t *testing.T) {
+	server := newPipelineServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	steps := pipelineSteps(t, server, "/step/0", "/step/1", "/step/2")
+	next := steps[1]
+	steps[1] = func(prev interfaces.IHTTPResponse) interfaces.IHTTPRequest {
+		cancel()
+		return next(prev)
+	}
+
+	outcome := <-Pipeline(ctx, client.NewHTTPClient(), steps, PipelineOptions{})
+	if outcome.FailedStep != 1 || !errors.Is(outcome.Error, context.Canceled) {
+		t.Fatalf("failed step %d with %v, want step 1 cancelled", outcome.FailedStep, outcome.Error)
+	}
+}
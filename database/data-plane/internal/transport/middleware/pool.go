@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+var (
+	// ErrPoolQueueFull is returned by Submit when the pool's queue has no free capacity.
+	ErrPoolQueueFull = errors.New("request pool: queue is full")
+
+	// ErrPoolClosed is returned by Submit after Shutdown has been called.
+	ErrPoolClosed = errors.New("request pool: pool is shut down")
+)
+
+// RequestPool is a long-lived, bounded worker pool for sending requests.
+// Unlike ExecuteConcurrent, which builds a fresh semaphore per call, a single
+// pool can be shared across call sites to enforce a global concurrency cap.
+type RequestPool struct {
+	client  interfaces.IHTTPClient
+	queue   chan poolTask
+	workers int
+
+	mu     sync.RWMutex // Guards closed and sends on queue
+	closed bool
+	wg     sync.WaitGroup
+
+	// ctx bounds every request; it is cancelled if Shutdown's deadline expires
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	busy      int64
+	processed int64
+	rejected  int64
+
+	tracker *progressTracker
+}
+
+// poolTask is a queued submission.
+type poolTask struct {
+	ctx     context.Context
+	entry   interfaces.BatchEntry
+	result  chan interfaces.AsyncResult
+	release func() // Releases the task's admission to a draining client
+}
+
+// NewRequestPool creates a pool with the given number of workers and queue capacity.
+// Workers default to 10 if not positive; a queue size of 0 means submissions
+// are accepted only when a worker is idle. Of the batch options, only the
+// progress options (OnProgress, ProgressInterval) apply to a pool; its
+// progress Total counts accepted submissions.
+func NewRequestPool(client interfaces.IHTTPClient, workers, queueSize int, opts ...BatchOption) *RequestPool {
+	if workers <= 0 {
+		workers = 10 // Default
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	config := newBatchConfig(opts)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	pool := &RequestPool{
+		client:  client,
+		queue:   make(chan poolTask, queueSize),
+		workers: workers,
+		ctx:     ctx,
+		cancel:  cancel,
+		tracker: newProgressTracker(0, config.onProgress, config.progressEvery),
+	}
+
+	pool.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go pool.worker()
+	}
+	return pool
+}
+
+// Submit queues a request for execution and returns a channel that receives
+// exactly one result. It fails immediately with ErrPoolQueueFull when the
+// queue is at capacity, or ErrPoolClosed after Shutdown.
+func (p *RequestPool) Submit(ctx context.Context, request interfaces.IHTTPRequest) (<-chan interfaces.AsyncResult, error) {
+	return p.SubmitEntry(ctx, interfaces.BatchEntry{Request: request})
+}
+
+// SubmitEntry queues a batch entry, honouring its own RetryPolicy and
+// Fallback. The result carries the entry's Key and attempt count. If the
+// pool's client is an IDrainableClient, its Drain waits for the accepted
+// submissions, and once it is draining submissions fail with
+// ErrClientDraining.
+func (p *RequestPool) SubmitEntry(ctx context.Context, entry interfaces.BatchEntry) (<-chan interfaces.AsyncResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		atomic.AddInt64(&p.rejected, 1)
+		return nil, ErrPoolClosed
+	}
+
+	admitted, release, err := admit(ctx, p.client)
+	if err != nil {
+		atomic.AddInt64(&p.rejected, 1)
+		return nil, err
+	}
+
+	task := poolTask{
+		ctx:     admitted,
+		entry:   entry,
+		result:  make(chan interfaces.AsyncResult, 1),
+		release: release,
+	}
+
+	// Count the task before queueing so Completed never exceeds Total
+	p.tracker.addTotal(1)
+	select {
+	case p.queue <- task:
+		return task.result, nil
+	default:
+		p.tracker.addTotal(-1)
+		atomic.AddInt64(&p.rejected, 1)
+		release()
+		return nil, ErrPoolQueueFull
+	}
+}
+
+// worker processes queued tasks until the queue is closed and drained.
+func (p *RequestPool) worker() {
+	defer p.wg.Done()
+
+	for task := range p.queue {
+		atomic.AddInt64(&p.busy, 1)
+		result := sendEntry(task.ctx, p.client, task.entry, p.ctx)
+		result = tagResult(result, 0, task.entry.Key)
+		task.result <- result
+		close(task.result)
+		task.release()
+		p.tracker.record(result)
+		atomic.AddInt64(&p.busy, -1)
+		atomic.AddInt64(&p.processed, 1)
+	}
+}
+
+// Shutdown stops accepting submissions and waits for queued and in-flight
+// requests to finish. If ctx expires first, remaining requests are cancelled
+// (their results carry ErrPoolClosed) and ctx's error is returned.
+func (p *RequestPool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel(ErrPoolClosed)
+		p.tracker.close()
+		return nil
+	case <-ctx.Done():
+		p.cancel(ErrPoolClosed)
+		<-done
+		p.tracker.close()
+		return ctx.Err()
+	}
+}
+
+// Progress returns a snapshot of the pool's progress, for pollers.
+func (p *RequestPool) Progress() BatchProgress {
+	return p.tracker.snapshot()
+}
+
+// GetMetrics returns current pool statistics.
+func (p *RequestPool) GetMetrics() PoolMetrics {
+	return PoolMetrics{
+		Workers:       p.workers,
+		BusyWorkers:   int(atomic.LoadInt64(&p.busy)),
+		QueueDepth:    len(p.queue),
+		QueueCapacity: cap(p.queue),
+		Processed:     atomic.LoadInt64(&p.processed),
+		Rejected:      atomic.LoadInt64(&p.rejected),
+	}
+}
+
+// PoolMetrics contains request pool statistics.
+type PoolMetrics struct {
+	Workers       int
+	BusyWorkers   int
+	QueueDepth    int
+	QueueCapacity int
+	Processed     int64
+	Rejected      int64
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/interfaces"
+)
+
+// gateServer holds every request until gate is closed or the request is
+// cancelled, recording the highest number of requests in flight at once.
+type gateServer struct {
+	*httptest.Server
+	gate     chan struct{}
+	arrived  chan struct{}
+	inflight int32
+	peak     int32
+}
+
+func newGateServer(t *testing.T) *gateServer {
+	t.Helper()
+	s := &gateServer{gate: make(chan struct{}), arrived: make(chan struct{}, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&s.inflight, 1)
+		defer atomic.AddInt32(&s.inflight, -1)
+		for {
+			peak := atomic.LoadInt32(&s.peak)
+			if n <= peak || atomic.CompareAndSwapInt32(&s.peak, peak, n) {
+				break
+			}
+		}
+		s.arrived <- struct{}{}
+		select {
+		case <-s.gate:
+			io.WriteString(w, "ok")
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *gateServer) request(t *testing.T) interfaces.IHTTPRequest {
+	return newServerRequest(t, s.Server, "/")
+}
+
+func TestRequestPoolConcurrencyBound(t *testing.T) {
+	server := newGateServer(t)
+	pool := NewRequestPool(client.NewHTTPClient(), 3, 20)
+
+	var channels []<-chan interfaces.AsyncResult
+	for range 15 {
+		ch, err := pool.Submit(context.Background(), server.request(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		channels = append(channels, ch)
+	}
+	for range 3 {
+		<-server.arrived
+	}
+	if metrics := pool.GetMetrics(); metrics.BusyWorkers != 3 || metrics.QueueDepth != 12 {
+		t.Errorf("metrics = %+v, want 3 busy workers and 12 queued", metrics)
+	}
+	close(server.gate)
+
+	for _, ch := range channels {
+		if result := <-ch; result.Error != nil {
+			t.Fatal(result.Error)
+		} else {
+			result.Response.Close()
+		}
+	}
+	if peak := atomic.LoadInt32(&server.peak); peak > 3 {
+		t.Errorf("%d requests in flight at once, want at most 3", peak)
+	}
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if metrics := pool.GetMetrics(); metrics.Processed != 15 || metrics.Rejected != 0 {
+		t.Errorf("metrics = %+v, want 15 processed and none rejected", metrics)
+	}
+}
+
+func TestRequestPoolQueueFullAndDrain(t *testing.T) {
+	server := newGateServer(t)
+	pool := NewRequestPool(client.NewHTTPClient(), 1, 1)
+
+	inflight, err := pool.Submit(context.Background(), server.request(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-server.arrived
+	queued, err := pool.Submit(context.Background(), server.request(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Submit(context.Background(), server.request(t)); !errors.Is(err, ErrPoolQueueFull) {
+		t.Fatalf("Submit on a full queue = %v, want ErrPoolQueueFull", err)
+	}
+
+	// Shutdown drains the in-flight and queued requests
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- pool.Shutdown(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	if _, err := pool.Submit(context.Background(), server.request(t)); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("Submit after Shutdown = %v, want ErrPoolClosed", err)
+	}
+	close(server.gate)
+
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+	for _, ch := range []<-chan interfaces.AsyncResult{inflight, queued} {
+		result := <-ch
+		if result.Error != nil {
+			t.Fatalf("drained request failed: %v", result.Error)
+		}
+		result.Response.Close()
+	}
+	if metrics := pool.GetMetrics(); metrics.Processed != 2 || metrics.Rejected != 2 {
+		t.Errorf("metrics = %+v, want 2 processed and 2 rejected", metrics)
+	}
+}
+
+func TestRequestPoolShutdownDeadline(t *testing.T) {
+	server := newGateServer(t)
+	pool := NewRequestPool(client.NewHTTPClient(), 2, 2)
+
+	var wg sync.WaitGroup
+	results := make(chan interfaces.AsyncResult, 2)
+	for range 2 {
+		ch, err := pool.Submit(context.Background(), server.request(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-server.arrived
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- <-ch
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pool.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v, want context.DeadlineExceeded", err)
+	}
+	wg.Wait()
+	close(results)
+	for result := range results {
+		if !errors.Is(result.Error, ErrPoolClosed) {
+			t.Errorf("cancelled request error = %v, want ErrPoolClosed", result.Error)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// BatchProgress is a snapshot of how far a batch or pool has got.
+// Last is the most recently delivered result.
+type BatchProgress struct {
+	Completed int
+	Failed    int
+	Total     int
+	Last      interfaces.AsyncResult
+}
+
+// OnProgress registers a callback invoked as results are delivered.
+// The callback runs on its own goroutine and never blocks result delivery:
+// while it is busy, or throttled by ProgressInterval, further updates are
+// coalesced into the next call. Counts passed to successive calls never go
+// backwards, and a final call always reports the exact totals.
+func OnProgress(fn func(BatchProgress)) BatchOption {
+	return func(c *batchConfig) {
+		c.onProgress = fn
+	}
+}
+
+// ProgressInterval limits OnProgress to at most one call per interval.
+func ProgressInterval(interval time.Duration) BatchOption {
+	return func(c *batchConfig) {
+		c.progressEvery = interval
+	}
+}
+
+// progressTracker counts delivered results and reports them to a callback
+// from a dedicated goroutine, coalescing updates the callback can't keep up with.
+type progressTracker struct {
+	mu       sync.Mutex
+	progress BatchProgress
+
+	callback func(BatchProgress)
+	interval time.Duration
+	notify   chan struct{}
+	quit     chan struct{}
+	stopped  chan struct{}
+	once     sync.Once
+}
+
+// newProgressTracker creates a tracker and, if callback is set, starts its notifier.
+func newProgressTracker(total int, callback func(BatchProgress), interval time.Duration) *progressTracker {
+	t := &progressTracker{
+		progress: BatchProgress{Total: total},
+		callback: callback,
+		interval: interval,
+	}
+	if callback != nil {
+		t.notify = make(chan struct{}, 1)
+		t.quit = make(chan struct{})
+		t.stopped = make(chan struct{})
+		go t.run()
+	}
+	return t
+}
+
+// addTotal grows the expected total, for open-ended sources such as a pool.
+func (t *progressTracker) addTotal(n int) {
+	t.mu.Lock()
+	t.progress.Total += n
+	t.mu.Unlock()
+}
+
+// record counts a delivered result and wakes the notifier without blocking.
+func (t *progressTracker) record(result interfaces.AsyncResult) {
+	t.mu.Lock()
+	t.progress.Completed++
+	if result.Error != nil {
+		t.progress.Failed++
+	}
+	t.progress.Last = result
+	t.mu.Unlock()
+
+	if t.notify != nil {
+		select {
+		case t.notify <- struct{}{}:
+		default: // A notification is already pending; it will carry this update
+		}
+	}
+}
+
+// snapshot returns the current progress.
+func (t *progressTracker) snapshot() BatchProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.progress
+}
+
+// close stops the notifier after it has delivered the final progress.
+func (t *progressTracker) close() {
+	if t.callback == nil {
+		return
+	}
+	t.once.Do(func() { close(t.quit) })
+	<-t.stopped
+}
+
+// run delivers progress updates, throttled to one per interval.
+func (t *progressTracker) run() {
+	defer close(t.stopped)
+
+	var lastCall time.Time
+	delivered := -1
+	deliver := func() {
+		progress := t.snapshot()
+		if progress.Completed == delivered {
+			return
+		}
+		delivered = progress.Completed
+		lastCall = time.Now()
+		t.invoke(progress)
+	}
+
+	for {
+		select {
+		case <-t.notify:
+			if wait := t.interval - time.Since(lastCall); t.interval > 0 && wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-t.quit:
+					timer.Stop()
+					deliver()
+					return
+				}
+			}
+			deliver()
+
+		case <-t.quit:
+			deliver()
+			return
+		}
+	}
+}
+
+// invoke calls the callback, containing any panic it raises.
+func (t *progressTracker) invoke(progress BatchProgress) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("progress callback panicked: %v", r)
+		}
+	}()
+	t.callback(progress)
+}
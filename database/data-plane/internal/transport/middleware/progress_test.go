@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+)
+
+func TestBatchProgress(t *testing.T) {
+	server := newAsyncServer(t)
+	requests := newBatchRequests(t, server, 200, func(i int) string {
+		if i%10 == 0 {
+			return "/fail"
+		}
+		return "/ok"
+	})
+
+	var mu sync.Mutex
+	var calls []BatchProgress
+	batch := RunBatch(context.Background(), client.NewHTTPClient(), toEntries(requests),
+		MaxConcurrency(8), ProgressInterval(time.Millisecond),
+		OnProgress(func(p BatchProgress) {
+			// A slow callback must not hold up result delivery
+			time.Sleep(2 * time.Millisecond)
+			mu.Lock()
+			calls = append(calls, p)
+			mu.Unlock()
+		}))
+	drainResults(t, batch.Results(), 5*time.Second)
+	<-batch.Done()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) == 0 || len(calls) >= 200 {
+		t.Fatalf("%d progress calls, want them throttled and coalesced", len(calls))
+	}
+	for i := 1; i < len(calls); i++ {
+		if calls[i].Completed < calls[i-1].Completed || calls[i].Failed < calls[i-1].Failed {
+			t.Fatalf("progress went backwards: %+v after %+v", calls[i], calls[i-1])
+		}
+	}
+	final := calls[len(calls)-1]
+	if final.Completed != 200 || final.Failed != 20 || final.Total != 200 {
+		t.Errorf("final progress = %+v, want 200 completed, 20 failed of 200", final)
+	}
+	if snapshot := batch.Progress(); snapshot.Completed != 200 || snapshot.Failed != 20 {
+		t.Errorf("Progress() = %+v", snapshot)
+	}
+}
+
+func TestPoolProgress(t *testing.T) {
+	server := newAsyncServer(t)
+	var mu sync.Mutex
+	var final BatchProgress
+	pool := NewRequestPool(client.NewHTTPClient(), 4, 20, OnProgress(func(p BatchProgress) {
+		mu.Lock()
+		final = p
+		mu.Unlock()
+	}))
+
+	for i := range 10 {
+		path := "/ok"
+		if i < 3 {
+			path = "/fail"
+		}
+		if _, err := pool.Submit(context.Background(), newServerRequest(t, server, path)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if final.Completed != 10 || final.Failed != 3 || final.Total != 10 {
+		t.Errorf("final progress = %+v, want 10 completed, 3 failed of 10", final)
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// Promise represents a value of type T that becomes available asynchronously.
+// Stages chained with Then run once the previous stage resolves; errors
+// short-circuit through the chain to Catch or Await.
+type Promise[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// newPromise runs fn in a goroutine and resolves the promise with its result.
+// A panic inside fn rejects the promise instead of crashing the process.
+func newPromise[T any](fn func() (T, error)) *Promise[T] {
+	p := &Promise[T]{done: make(chan struct{})}
+
+	go func() {
+		defer close(p.done)
+		defer func() {
+			if r := recover(); r != nil {
+				p.err = fmt.Errorf("promise stage panicked: %v", r)
+			}
+		}()
+		p.value, p.err = fn()
+	}()
+
+	return p
+}
+
+// Resolved returns a promise already fulfilled with value.
+func Resolved[T any](value T) *Promise[T] {
+	p := &Promise[T]{done: make(chan struct{}), value: value}
+	close(p.done)
+	return p
+}
+
+// Rejected returns a promise already failed with err.
+func Rejected[T any](err error) *Promise[T] {
+	p := &Promise[T]{done: make(chan struct{}), err: err}
+	close(p.done)
+	return p
+}
+
+// ExecuteAsync sends the request asynchronously and returns a promise for the
+// handler's typed result. Error responses are passed through the handler's
+// HandleError, mirroring SendWithHandler. If handler is nil, the raw response
+// is resolved and T must be interfaces.IHTTPResponse.
+func ExecuteAsync[T any](ctx context.Context, client interfaces.IHTTPClient, request interfaces.IHTTPRequest, handler interfaces.IResponseHandler) *Promise[T] {
+	return newPromise(func() (T, error) {
+		var zero T
+		result := sendAsync(ctx, client, request)
+
+		if handler == nil {
+			if result.Error != nil {
+				return zero, result.Error
+			}
+			return assertType[T](result.Response)
+		}
+
+		if result.Error != nil {
+			if result.Response != nil && handler.CanHandle(result.Response) {
+				if handlerErr := handler.HandleError(result.Response); handlerErr != nil {
+					return zero, handlerErr
+				}
+			}
+			return zero, result.Error
+		}
+
+		value, err := handler.Handle(result.Response)
+		if err != nil {
+			return zero, err
+		}
+		return assertType[T](value)
+	})
+}
+
+// Then chains a stage that runs when p resolves successfully.
+// If p fails, fn is skipped and the error propagates to the returned promise.
+func Then[T, U any](p *Promise[T], fn func(T) (U, error)) *Promise[U] {
+	return newPromise(func() (U, error) {
+		<-p.done
+		if p.err != nil {
+			var zero U
+			return zero, p.err
+		}
+		return fn(p.value)
+	})
+}
+
+// Catch handles a failure of the promise. fn may return a replacement error,
+// or nil to recover, in which case the returned promise resolves to the zero
+// value of T. Successful values pass through unchanged.
+func (p *Promise[T]) Catch(fn func(error) error) *Promise[T] {
+	return newPromise(func() (T, error) {
+		<-p.done
+		if p.err == nil {
+			return p.value, nil
+		}
+		var zero T
+		return zero, fn(p.err)
+	})
+}
+
+// Await blocks until the promise settles or ctx is done.
+// Cancelling ctx abandons the wait; it does not cancel the underlying work.
+func (p *Promise[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-p.done:
+		return p.value, p.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Done returns a channel that is closed once the promise settles.
+func (p *Promise[T]) Done() <-chan struct{} {
+	return p.done
+}
+
+// assertType converts a handler result into T with a descriptive error on mismatch.
+func assertType[T any](value interface{}) (T, error) {
+	typed, ok := value.(T)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("expected result of type %s, got %T",
+			reflect.TypeOf((*T)(nil)).Elem(), value)
+	}
+	return typed, nil
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/http/handler"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+type promiseUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type promiseOrder struct {
+	Total int `json:"total"`
+}
+
+type promiseSummary struct {
+	Name  string `json:"name"`
+	Total int    `json:"total"`
+}
+
+// newShopServer serves a user, their orders and accepts posted summaries.
+func newShopServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/users/1":
+			json.NewEncoder(w).Encode(promiseUser{ID: 1, Name: "ann"})
+		case "/users/1/orders":
+			json.NewEncoder(w).Encode([]promiseOrder{{Total: 3}, {Total: 4}})
+		case "/summaries":
+			var summary promiseSummary
+			json.NewDecoder(r.Body).Decode(&summary)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(summary)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPromiseChain(t *testing.T) {
+	server := newShopServer(t)
+	c := client.NewHTTPClient()
+	ctx := context.Background()
+
+	summarize := func(ordersPath string) *Promise[promiseSummary] {
+		user := ExecuteAsync[promiseUser](ctx, c, newServerRequest(t, server, "/users/1"),
+			handler.NewResponseHandler().WithResponseType(promiseUser{}).Build())
+
+		total := Then(user, func(u promiseUser) (promiseSummary, error) {
+			orders, err := ExecuteAsync[[]promiseOrder](ctx, c, newServerRequest(t, server, ordersPath),
+				handler.NewResponseHandler().WithResponseType([]promiseOrder{}).Build()).Await(ctx)
+			summary := promiseSummary{Name: u.Name}
+			for _, o := range orders {
+				summary.Total += o.Total
+			}
+			return summary, err
+		})
+
+		return Then(total, func(s promiseSummary) (promiseSummary, error) {
+			body, _ := json.Marshal(s)
+			httpReq, err := http.NewRequest(http.MethodPost, server.URL+"/summaries", strings.NewReader(string(body)))
+			if err != nil {
+				return promiseSummary{}, err
+			}
+			return ExecuteAsync[promiseSummary](ctx, c, &models.Request{HTTPReq: httpReq},
+				handler.NewResponseHandler().WithResponseType(promiseSummary{}).Build()).Await(ctx)
+		})
+	}
+
+	summary, err := summarize("/users/1/orders").Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != (promiseSummary{Name: "ann", Total: 7}) {
+		t.Fatalf("summary = %+v", summary)
+	}
+
+	// A failing middle stage skips the rest of the chain and reaches Catch
+	var caught error
+	_, err = summarize("/users/1/missing").Catch(func(err error) error {
+		caught = err
+		return err
+	}).Await(ctx)
+	if !errors.Is(err, models.ErrNotFound) || !errors.Is(caught, models.ErrNotFound) {
+		t.Fatalf("chain error = %v, caught %v; want ErrNotFound", err, caught)
+	}
+}
+
+func TestPromiseCatchRecovers(t *testing.T) {
+	boom := errors.New("boom")
+	value, err := Rejected[int](boom).Catch(func(err error) error { return nil }).Await(context.Background())
+	if err != nil || value != 0 {
+		t.Fatalf("recovered promise = %d, %v", value, err)
+	}
+
+	value, err = Resolved(5).Catch(func(err error) error { return boom }).Await(context.Background())
+	if err != nil || value != 5 {
+		t.Fatalf("resolved promise through Catch = %d, %v", value, err)
+	}
+}
+
+func TestPromiseAwaitCancelled(t *testing.T) {
+	server := newAsyncServer(t)
+	reqCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	p := ExecuteAsync[interfaces.IHTTPResponse](reqCtx, client.NewHTTPClient(), newServerRequest(t, server, "/slow"), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Await = %v, want context.DeadlineExceeded", err)
+	}
+	select {
+	case <-p.Done():
+		t.Fatal("abandoning Await settled the promise")
+	default:
+	}
+}
+
+func TestPromiseStagePanic(t *testing.T) {
+	p := Then(Resolved(1), func(int) (int, error) { panic("stage failure") })
+	if _, err := p.Await(context.Background()); err == nil || !strings.Contains(err.Error(), "panicked") {
+		t.Fatalf("Await = %v, want a panic error", err)
+	}
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// captureStdout returns what fn prints to standard output.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+	fn()
+	writer.Close()
+	return <-output
+}
+
+func TestSecretsRedactedInEverySurface(t *testing.T) {
+	var sentKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sentKey = r.URL.Query().Get("api_key")
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	c := NewLoggingDecorator(NewMiddlewareDecorator(client.NewHTTPClient(), []interfaces.IMiddleware{
+		NewLoggingMiddleware(log.New(&logs, "", 0)),
+	}))
+
+	var err error
+	stdout := captureStdout(t, func() {
+		_, err = c.Send(newServerRequest(t, server, "/items?api_key=secret&page=2"))
+	})
+	if sentKey != "secret" {
+		t.Errorf("upstream received api_key %q, want the unredacted value", sentKey)
+	}
+
+	var httpErr *models.HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Send error = %T %v, want *HTTPError", err, err)
+	}
+	surfaces := map[string]string{
+		"Error()":            err.Error(),
+		"GetMessage()":       httpErr.GetMessage(),
+		"%+v":                fmt.Sprintf("%+v", err),
+		"logging decorator":  stdout,
+		"logging middleware": logs.String(),
+	}
+	for surface, text := range surfaces {
+		if strings.Contains(text, "secret") {
+			t.Errorf("%s leaks the api_key: %s", surface, text)
+		}
+	}
+	for _, surface := range []string{"%+v", "logging decorator", "logging middleware"} {
+		if !strings.Contains(surfaces[surface], "api_key=REDACTED") {
+			t.Errorf("%s does not show the masked URL: %s", surface, surfaces[surface])
+		}
+	}
+	if httpErr.Request.URL() != server.URL+"/items?api_key=secret&page=2" {
+		t.Errorf("request URL was modified: %s", httpErr.Request.URL())
+	}
+}
+
+func TestBasicAuthRedactedInLogs(t *testing.T) {
+	const username, password = "ada", "correct-horse-battery"
+	// The server echoes the decoded credentials in its error body
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		http.Error(w, "rejected "+user+":"+pass+" as "+r.Header.Get("Authorization"), http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	c := NewLoggingDecorator(NewMiddlewareDecorator(client.NewHTTPClient(), []interfaces.IMiddleware{
+		NewLoggingMiddleware(log.New(&logs, "", 0)),
+	}))
+	request := newServerRequest(t, server, "/me")
+	request.HTTPRequest().SetBasicAuth(username, password)
+	encoded := strings.TrimPrefix(request.Header("Authorization"), "Basic ")
+
+	var err error
+	stdout := captureStdout(t, func() {
+		_, err = c.Send(request)
+	})
+	if err == nil {
+		t.Fatal("Send succeeded, want the 401")
+	}
+	for surface, text := range map[string]string{
+		"Error()":            err.Error(),
+		"logging decorator":  stdout,
+		"logging middleware": logs.String(),
+	} {
+		if strings.Contains(text, password) || strings.Contains(text, encoded) {
+			t.Errorf("%s leaks the credentials: %s", surface, text)
+		}
+	}
+	if !strings.Contains(stdout, "REDACTED") {
+		t.Errorf("logging decorator does not show the masked credentials: %s", stdout)
+	}
+}
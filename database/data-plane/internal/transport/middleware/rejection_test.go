@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+	"data-plane/internal/transport/resiliency"
+)
+
+// decorate wraps the plain client in the full decorator stack.
+func decorate(cb *resiliency.CircuitBreaker, rl *resiliency.RateLimiter, bh *resiliency.Bulkhead) interfaces.IHTTPClient {
+	var c interfaces.IHTTPClient = client.NewHTTPClient()
+	c = NewBulkheadDecorator(c, bh)
+	c = NewRateLimiterDecorator(c, rl)
+	c = NewCircuitBreakerDecorator(c, cb)
+	c = NewMetricsDecorator(c)
+	return NewLoggingDecorator(c)
+}
+
+// contextRequest is newServerRequest bound to ctx.
+func contextRequest(t *testing.T, ctx context.Context, server *httptest.Server, path string) interfaces.IHTTPRequest {
+	t.Helper()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.Request{HTTPReq: httpReq}
+}
+
+// holdSlot occupies bh's only slot with a hanging request until the test ends.
+func holdSlot(t *testing.T, c interfaces.IHTTPClient, bh *resiliency.Bulkhead, server *httptest.Server) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if resp, err := c.Send(contextRequest(t, ctx, server, "/slow")); err == nil {
+			resp.Close()
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	for bh.ActiveRequests() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRejectionSentinelsThroughDecorators(t *testing.T) {
+	server := newAsyncServer(t)
+	ok := func(t *testing.T) interfaces.IHTTPRequest { return newServerRequest(t, server, "/ok") }
+
+	tests := []struct {
+		name     string
+		sentinel error
+		kind     interfaces.ErrorKind
+		setup    func(t *testing.T) (interfaces.IHTTPClient, interfaces.IHTTPRequest)
+	}{
+		{
+			name:     "circuit open",
+			sentinel: resiliency.ErrCircuitOpen,
+			kind:     interfaces.KindCircuitOpen,
+			setup: func(t *testing.T) (interfaces.IHTTPClient, interfaces.IHTTPRequest) {
+				cb := resiliency.NewCircuitBreaker(1, time.Minute)
+				cb.Trip()
+				return decorate(cb, resiliency.NewRateLimiter(100, 10), resiliency.NewBulkhead(1)), ok(t)
+			},
+		},
+		{
+			name:     "rate limited",
+			sentinel: resiliency.ErrRateLimited,
+			kind:     interfaces.KindRateLimited,
+			setup: func(t *testing.T) (interfaces.IHTTPClient, interfaces.IHTTPRequest) {
+				rl := resiliency.NewRateLimiter(0.1, 1)
+				if !rl.Allow() {
+					t.Fatal("limiter refused its burst")
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				t.Cleanup(cancel)
+				c := decorate(resiliency.NewCircuitBreaker(5, time.Minute), rl, resiliency.NewBulkhead(1))
+				return c, contextRequest(t, ctx, server, "/ok")
+			},
+		},
+		{
+			name:     "bulkhead full",
+			sentinel: resiliency.ErrBulkheadFull,
+			kind:     interfaces.KindBulkhead,
+			setup: func(t *testing.T) (interfaces.IHTTPClient, interfaces.IHTTPRequest) {
+				bh := resiliency.NewBulkhead(1)
+				c := decorate(resiliency.NewCircuitBreaker(5, time.Minute), resiliency.NewRateLimiter(100, 10), bh)
+				holdSlot(t, c, bh, server)
+				return c, ok(t)
+			},
+		},
+		{
+			name:     "bulkhead timeout",
+			sentinel: resiliency.ErrBulkheadTimeout,
+			kind:     interfaces.KindBulkhead,
+			setup: func(t *testing.T) (interfaces.IHTTPClient, interfaces.IHTTPRequest) {
+				bh := resiliency.NewBulkheadWithWait(1, 20*time.Millisecond)
+				c := decorate(resiliency.NewCircuitBreaker(5, time.Minute), resiliency.NewRateLimiter(100, 10), bh)
+				holdSlot(t, c, bh, server)
+				return c, ok(t)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, request := tt.setup(t)
+			resp, err := c.Send(request)
+			if err == nil {
+				resp.Close()
+				t.Fatal("request was admitted")
+			}
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("error %v does not match %v", err, tt.sentinel)
+			}
+			var httpErr *models.HTTPError
+			if !errors.As(err, &httpErr) || httpErr.Kind() != tt.kind {
+				t.Errorf("error = %T %v, want an HTTPError of kind %v", err, err, tt.kind)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// newEchoIDServer fails every request with a 500, echoing echo as X-Request-Id
+// when it is set and recording the request headers it saw.
+func newEchoIDServer(t *testing.T, echo string, seen *http.Header) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*seen = r.Header.Clone()
+		if echo != "" {
+			w.Header().Set(models.RequestIDHeader, echo)
+		}
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// fixedID returns an ID generator that always yields id.
+func fixedID(id string) func() string {
+	return func() string { return id }
+}
+
+// requireRequestID asserts err is an IHTTPError carrying id, also in its message.
+func requireRequestID(t *testing.T, err error, id string) {
+	t.Helper()
+	var httpErr interfaces.IHTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("error = %T %v, want an IHTTPError", err, err)
+	}
+	if got := httpErr.GetRequestID(); got != id {
+		t.Errorf("GetRequestID() = %q, want %q", got, id)
+	}
+	if !strings.Contains(err.Error(), id) {
+		t.Errorf("Error() = %q does not mention %q", err.Error(), id)
+	}
+}
+
+func TestRequestIDOnServerError(t *testing.T) {
+	var seen http.Header
+	server := newEchoIDServer(t, "", &seen)
+	c := NewMiddlewareDecorator(client.NewHTTPClient(), []interfaces.IMiddleware{
+		NewRequestIDMiddleware("", fixedID("req-42")),
+	})
+
+	_, err := c.Send(newServerRequest(t, server, "/"))
+	if seen.Get(models.RequestIDHeader) != "req-42" {
+		t.Errorf("upstream saw X-Request-Id %q, want req-42", seen.Get(models.RequestIDHeader))
+	}
+	requireRequestID(t, err, "req-42")
+
+	// A caller-supplied ID is kept
+	request := newServerRequest(t, server, "/")
+	request.HTTPRequest().Header.Set(models.RequestIDHeader, "caller-7")
+	_, err = c.Send(request)
+	requireRequestID(t, err, "caller-7")
+}
+
+func TestRequestIDCustomHeader(t *testing.T) {
+	var seen http.Header
+	server := newEchoIDServer(t, "", &seen)
+	c := NewMiddlewareDecorator(client.NewHTTPClient(), []interfaces.IMiddleware{
+		NewRequestIDMiddleware("X-Correlation-Id", fixedID("corr-1")),
+	})
+
+	_, err := c.Send(newServerRequest(t, server, "/"))
+	if seen.Get("X-Correlation-Id") != "corr-1" {
+		t.Errorf("upstream saw X-Correlation-Id %q, want corr-1", seen.Get("X-Correlation-Id"))
+	}
+	requireRequestID(t, err, "corr-1")
+}
+
+func TestRequestIDEchoedByUpstream(t *testing.T) {
+	var seen http.Header
+	server := newEchoIDServer(t, "upstream-9", &seen)
+
+	_, err := client.NewHTTPClient().Send(newServerRequest(t, server, "/"))
+	requireRequestID(t, err, "upstream-9")
+}
+
+func TestRequestIDPropagates(t *testing.T) {
+	var seen http.Header
+	server := newEchoIDServer(t, "", &seen)
+	c := NewMiddlewareDecorator(client.NewHTTPClient(), []interfaces.IMiddleware{
+		NewRequestIDMiddleware("", fixedID("req-async")),
+	})
+
+	result := receiveOne(t, NewAsyncRequest(c).Execute(context.Background(), newServerRequest(t, server, "/")))
+	if result.RequestID != "req-async" {
+		t.Errorf("AsyncResult.RequestID = %q, want req-async", result.RequestID)
+	}
+	requireRequestID(t, result.Error, "req-async")
+
+	policy := stubPolicy{attempts: 2, retry: retryAll}
+	_, err := NewRetryDecorator(c, policy).Send(newServerRequest(t, server, "/"))
+	var exhausted *models.RetryExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("error = %T %v, want *RetryExhaustedError", err, err)
+	}
+	if got := exhausted.GetRequestID(); got != "req-async" {
+		t.Errorf("RetryExhaustedError.GetRequestID() = %q, want req-async", got)
+	}
+}
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// stubPolicy retries while retry reports true, waiting delay between attempts.
+type stubPolicy struct {
+	attempts int
+	delay    time.Duration
+	retry    func(err error) bool
+}
+
+func (p stubPolicy) ShouldRetry(err error, attempt int) bool { return p.retry(err) }
+func (p stubPolicy) GetDelay(attempt int) time.Duration      { return p.delay }
+func (p stubPolicy) MaxAttempts() int                        { return p.attempts }
+
+// closeCounter counts how many response bodies were closed.
+type closeCounter struct {
+	io.Reader
+	closed *int
+}
+
+func (c closeCounter) Close() error {
+	*c.closed++
+	return nil
+}
+
+// scriptedClient fails every Send with the next error in errs, returning a
+// response alongside each failure as the HTTP client does for error statuses.
+type scriptedClient struct {
+	interfaces.IHTTPClient
+	errs   []error
+	sent   int
+	closed int
+}
+
+func (c *scriptedClient) Send(request interfaces.IHTTPRequest) (interfaces.IHTTPResponse, error) {
+	err := c.errs[c.sent]
+	c.sent++
+	resp := &models.Response{
+		HttpResp: &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       closeCounter{strings.NewReader("unavailable"), &c.closed},
+		},
+		RequestRef: request,
+	}
+	return resp, err
+}
+
+func newRetryRequest(t *testing.T) interfaces.IHTTPRequest {
+	t.Helper()
+	httpReq, err := http.NewRequest(http.MethodGet, "http://example.invalid/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.Request{HTTPReq: httpReq}
+}
+
+func retryAll(error) bool { return true }
+
+func TestRetryDecoratorExhausted(t *testing.T) {
+	errs := []error{
+		&models.HTTPError{Message: "refused", KindVal: interfaces.KindConnection},
+		&models.HTTPError{Message: "timeout", KindVal: interfaces.KindTimeout},
+		&models.HTTPError{Message: "unavailable", StatusCode: http.StatusServiceUnavailable},
+	}
+	wrapped := &scriptedClient{errs: errs}
+	// The delay would dominate the test if the decorator slept after the final attempt.
+	policy := stubPolicy{attempts: 3, delay: 50 * time.Millisecond, retry: retryAll}
+
+	start := time.Now()
+	_, err := NewRetryDecorator(wrapped, policy).Send(newRetryRequest(t))
+	if elapsed := time.Since(start); elapsed >= 150*time.Millisecond {
+		t.Errorf("Send took %v; the decorator slept after the final attempt", elapsed)
+	}
+
+	var exhausted *models.RetryExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("Send error = %T %v, want *RetryExhaustedError", err, err)
+	}
+	if exhausted.Attempts != 3 || len(exhausted.Errors) != 3 || len(exhausted.Durations) != 3 {
+		t.Errorf("history = %d attempts, %d errors, %d durations; want 3 of each",
+			exhausted.Attempts, len(exhausted.Errors), len(exhausted.Durations))
+	}
+	for i, want := range errs {
+		if exhausted.Errors[i] != want {
+			t.Errorf("Errors[%d] = %v, want %v", i, exhausted.Errors[i], want)
+		}
+	}
+	if !errors.Is(err, errs[2]) || exhausted.GetStatusCode() != http.StatusServiceUnavailable {
+		t.Errorf("exhausted error does not match the last attempt: %v", err)
+	}
+	if wrapped.closed != 3 {
+		t.Errorf("closed %d discarded responses, want 3", wrapped.closed)
+	}
+}
+
+func TestRetryDecoratorReturnsUnretriedFailure(t *testing.T) {
+	notFound := &models.HTTPError{Message: "not found", StatusCode: http.StatusNotFound}
+	wrapped := &scriptedClient{errs: []error{notFound}}
+	policy := stubPolicy{attempts: 3, retry: func(error) bool { return false }}
+
+	_, err := NewRetryDecorator(wrapped, policy).Send(newRetryRequest(t))
+	if err != notFound {
+		t.Fatalf("Send error = %T %v, want the original error", err, err)
+	}
+	var exhausted *models.RetryExhaustedError
+	if errors.As(err, &exhausted) {
+		t.Error("an unretried failure was wrapped in RetryExhaustedError")
+	}
+	if wrapped.sent != 1 || wrapped.closed != 1 {
+		t.Errorf("sent %d, closed %d; want 1 and 1", wrapped.sent, wrapped.closed)
+	}
+}
+
+// testCall is a minimal ICall.
+type testCall struct{}
+
+func (testCall) Operation() string      { return "/test.Service/Method" }
+func (testCall) Timeout() time.Duration { return 0 }
+
+// scriptedInvoker fails every Invoke with the next error in errs.
+type scriptedInvoker struct {
+	errs    []error
+	invoked int
+}
+
+func (i *scriptedInvoker) Invoke(ctx context.Context, call interfaces.ICall) error {
+	err := i.errs[i.invoked]
+	i.invoked++
+	return err
+}
+
+func TestRetryInvoker(t *testing.T) {
+	first, last := errors.New("first"), errors.New("last")
+
+	t.Run("exhausted", func(t *testing.T) {
+		wrapped := &scriptedInvoker{errs: []error{first, last}}
+		policy := stubPolicy{attempts: 2, delay: 100 * time.Millisecond, retry: retryAll}
+
+		start := time.Now()
+		err := NewRetryInvoker(wrapped, policy).Invoke(context.Background(), testCall{})
+		if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+			t.Errorf("Invoke took %v; the invoker slept after the final attempt", elapsed)
+		}
+		var exhausted *models.RetryExhaustedError
+		if !errors.As(err, &exhausted) || exhausted.Attempts != 2 {
+			t.Fatalf("Invoke error = %T %v, want *RetryExhaustedError after 2 attempts", err, err)
+		}
+		if !errors.Is(err, last) || !errors.Is(exhausted.AttemptErrors(), first) {
+			t.Errorf("exhausted error lost an attempt's error: %v", err)
+		}
+	})
+
+	t.Run("unretried", func(t *testing.T) {
+		wrapped := &scriptedInvoker{errs: []error{first}}
+		policy := stubPolicy{attempts: 3, retry: func(error) bool { return false }}
+
+		err := NewRetryInvoker(wrapped, policy).Invoke(context.Background(), testCall{})
+		if err != first || wrapped.invoked != 1 {
+			t.Errorf("Invoke error = %v after %d calls, want the original error after 1", err, wrapped.invoked)
+		}
+	})
+}
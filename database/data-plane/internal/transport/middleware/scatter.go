@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// ErrQuorumNotReached is returned by ScatterGather when too few replicas
+// succeeded (or agreed) to satisfy the quorum.
+var ErrQuorumNotReached = errors.New("scatter-gather: quorum not reached")
+
+// errQuorumReached is the cancellation cause for requests still in flight
+// once a quorum has been reached.
+var errQuorumReached = errors.New("scatter-gather: quorum already reached")
+
+// ScatterOption configures ScatterGather.
+type ScatterOption func(*scatterConfig)
+
+// scatterConfig holds ScatterGather settings.
+type scatterConfig struct {
+	quorum  int
+	equal   func(a, b interfaces.IHTTPResponse) bool
+	timeout time.Duration
+}
+
+// Quorum sets how many successful (and, with Agreement, agreeing) responses
+// are required. The default is a simple majority of the requests.
+func Quorum(k int) ScatterOption {
+	return func(c *scatterConfig) {
+		c.quorum = k
+	}
+}
+
+// Agreement makes the quorum agreement-based: responses count towards the
+// same quorum only if equal reports them as equivalent. Bodies are buffered
+// before comparison, so equal may call Body freely.
+func Agreement(equal func(a, b interfaces.IHTTPResponse) bool) ScatterOption {
+	return func(c *scatterConfig) {
+		c.equal = equal
+	}
+}
+
+// PerRequestTimeout bounds each replica request, including reading its body.
+func PerRequestTimeout(timeout time.Duration) ScatterOption {
+	return func(c *scatterConfig) {
+		c.timeout = timeout
+	}
+}
+
+// BodiesEqual reports whether two responses have identical bodies.
+// It is a convenience equality function for Agreement.
+func BodiesEqual(a, b interfaces.IHTTPResponse) bool {
+	bodyA, errA := a.Body()
+	bodyB, errB := b.Body()
+	return errA == nil && errB == nil && bytes.Equal(bodyA, bodyB)
+}
+
+// ScatterGatherResult reports the outcome of a ScatterGather call.
+// Every response it carries has its body already buffered.
+type ScatterGatherResult struct {
+	// Chosen is the first response of the winning quorum
+	Chosen interfaces.AsyncResult
+
+	// Agreeing holds every response in the winning quorum, including Chosen
+	Agreeing []interfaces.AsyncResult
+
+	// Dissenting holds successful responses that disagreed with the quorum
+	Dissenting []interfaces.AsyncResult
+
+	// Failed holds requests that completed with an error before the decision
+	Failed []interfaces.AsyncResult
+
+	// Responded lists the indices of replicas that responded successfully
+	Responded []int
+
+	// Error is non-nil (wrapping ErrQuorumNotReached) if no quorum was reached
+	Error error
+}
+
+// ScatterGather sends the requests concurrently and returns once a quorum of
+// them has succeeded, or agreed when Agreement is set. Requests still in
+// flight at that point are cancelled. It fails with ErrQuorumNotReached as
+// soon as the quorum becomes unreachable.
+func ScatterGather(ctx context.Context, client interfaces.IHTTPClient, requests []interfaces.IHTTPRequest, opts ...ScatterOption) ScatterGatherResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	config := scatterConfig{quorum: len(requests)/2 + 1}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	var outcome ScatterGatherResult
+	if config.quorum <= 0 || config.quorum > len(requests) {
+		outcome.Error = fmt.Errorf("%w: quorum %d is impossible with %d requests",
+			ErrQuorumNotReached, config.quorum, len(requests))
+		return outcome
+	}
+
+	gatherCtx, stop := context.WithCancelCause(ctx)
+	defer stop(errQuorumReached)
+
+	results := make(chan interfaces.AsyncResult, len(requests))
+	for i, req := range requests {
+		go func(index int, request interfaces.IHTTPRequest) {
+			deadline, release := replicaDeadline(config.timeout)
+			defer release()
+
+			result := sendAsync(gatherCtx, client, request, deadline)
+			result.Index = index
+			if result.Error == nil {
+				// Buffer the body so the response survives cancellation of the others
+				if _, err := result.Response.Body(); err != nil {
+					result.Error = &models.HTTPError{
+						Request:  request,
+						Response: result.Response,
+						Message:  "scatter-gather: failed to read response body",
+						Err:      err,
+					}
+				}
+			} else {
+				closeResponse(result.Response)
+			}
+			results <- result
+		}(i, req)
+	}
+
+	var groups [][]interfaces.AsyncResult
+	pending := len(requests)
+	winner := -1
+
+	for winner < 0 && pending > 0 {
+		result := <-results
+		pending--
+
+		if result.Error != nil {
+			outcome.Failed = append(outcome.Failed, result)
+		} else {
+			outcome.Responded = append(outcome.Responded, result.Index)
+			group := config.groupFor(groups, result)
+			if group < 0 {
+				groups = append(groups, nil)
+				group = len(groups) - 1
+			}
+			groups[group] = append(groups[group], result)
+			if len(groups[group]) >= config.quorum {
+				winner = group
+				break
+			}
+		}
+
+		if largestGroup(groups)+pending < config.quorum {
+			break
+		}
+	}
+
+	// Cancel stragglers and wait for them; cancelled requests return at once
+	stop(errQuorumReached)
+	for ; pending > 0; pending-- {
+		<-results
+	}
+
+	if winner < 0 {
+		outcome.Error = fmt.Errorf("%w: best agreement %d of %d required (%d failed)",
+			ErrQuorumNotReached, largestGroup(groups), config.quorum, len(outcome.Failed))
+		for _, group := range groups {
+			outcome.Dissenting = append(outcome.Dissenting, group...)
+		}
+		return outcome
+	}
+
+	outcome.Agreeing = groups[winner]
+	outcome.Chosen = groups[winner][0]
+	for i, group := range groups {
+		if i != winner {
+			outcome.Dissenting = append(outcome.Dissenting, group...)
+		}
+	}
+	return outcome
+}
+
+// groupFor returns the index of the group the result agrees with, or -1.
+// Without an equality function every success belongs to a single group.
+func (c *scatterConfig) groupFor(groups [][]interfaces.AsyncResult, result interfaces.AsyncResult) int {
+	for i, group := range groups {
+		if c.equal == nil || c.equal(group[0].Response, result.Response) {
+			return i
+		}
+	}
+	return -1
+}
+
+// largestGroup returns the size of the biggest agreeing group.
+func largestGroup(groups [][]interfaces.AsyncResult) int {
+	largest := 0
+	for _, group := range groups {
+		if len(group) > largest {
+			largest = len(group)
+		}
+	}
+	return largest
+}
+
+// replicaDeadline returns a scope bounding a single replica request, or a
+// scope that never ends if timeout is zero. Release it once the body is buffered.
+func replicaDeadline(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.Background(), func() {}
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/interfaces"
+)
+
+// newReplicaServer serves replica answers: /v1 and /v1-late agree, /v2
+// diverges, /fail errors and /slow hangs until cancelled, reporting it on
+// cancelled.
+func newReplicaServer(t *testing.T, cancelled chan<- struct{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1":
+			io.WriteString(w, "v1")
+		case "/v1-late":
+			time.Sleep(20 * time.Millisecond)
+			io.WriteString(w, "v1")
+		case "/v2":
+			io.WriteString(w, "v2")
+		case "/fail":
+			http.Error(w, "down", http.StatusServiceUnavailable)
+		case "/slow":
+			<-r.Context().Done()
+			if cancelled != nil {
+				cancelled <- struct{}{}
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func replicaRequests(t *testing.T, server *httptest.Server, paths ...string) []interfaces.IHTTPRequest {
+	requests := make([]interfaces.IHTTPRequest, len(paths))
+	for i, path := range paths {
+		requests[i] = newServerRequest(t, server, path)
+	}
+	return requests
+}
+
+func TestScatterGatherKOfN(t *testing.T) {
+	cancelled := make(chan struct{}, 1)
+	server := newReplicaServer(t, cancelled)
+
+	outcome := ScatterGather(context.Background(), client.NewHTTPClient(),
+		replicaRequests(t, server, "/v1", "/slow", "/v2"), Quorum(2))
+	if outcome.Error != nil {
+		t.Fatal(outcome.Error)
+	}
+	if len(outcome.Agreeing) != 2 || len(outcome.Responded) != 2 || len(outcome.Dissenting) != 0 {
+		t.Errorf("outcome = %d agreeing, responded %v, %d dissenting", len(outcome.Agreeing), outcome.Responded, len(outcome.Dissenting))
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the straggling replica was not cancelled once quorum was reached")
+	}
+}
+
+func TestScatterGatherAgreement(t *testing.T) {
+	server := newReplicaServer(t, nil)
+
+	outcome := ScatterGather(context.Background(), client.NewHTTPClient(),
+		replicaRequests(t, server, "/v2", "/v1", "/v1-late"), Quorum(2), Agreement(BodiesEqual))
+	if outcome.Error != nil {
+		t.Fatal(outcome.Error)
+	}
+	if body, _ := outcome.Chosen.Response.BodyString(); body != "v1" {
+		t.Errorf("chosen body = %q, want the agreed v1", body)
+	}
+	if len(outcome.Agreeing) != 2 || len(outcome.Dissenting) != 1 || outcome.Dissenting[0].Index != 0 {
+		t.Errorf("agreeing %d, dissenting %+v; want 2 agreeing and replica 0 dissenting", len(outcome.Agreeing), outcome.Dissenting)
+	}
+}
+
+func TestScatterGatherQuorumUnreachable(t *testing.T) {
+	server := newReplicaServer(t, nil)
+
+	outcome := ScatterGather(context.Background(), client.NewHTTPClient(),
+		replicaRequests(t, server, "/fail", "/v1", "/fail"), Quorum(2))
+	if !errors.Is(outcome.Error, ErrQuorumNotReached) || len(outcome.Failed) != 2 {
+		t.Fatalf("error %v with %d failed, want ErrQuorumNotReached with 2 failed", outcome.Error, len(outcome.Failed))
+	}
+
+	outcome = ScatterGather(context.Background(), client.NewHTTPClient(),
+		replicaRequests(t, server, "/v1"), Quorum(2))
+	if !errors.Is(outcome.Error, ErrQuorumNotReached) {
+		t.Fatalf("impossible quorum: %v, want ErrQuorumNotReached", outcome.Error)
+	}
+}
+
+func TestScatterGatherPerRequestTimeout(t *testing.T) {
+	server := newReplicaServer(t, nil)
+
+	start := time.Now()
+	outcome := ScatterGather(context.Background(), client.NewHTTPClient(),
+		replicaRequests(t, server, "/slow", "/v1", "/slow"), Quorum(2), PerRequestTimeout(20*time.Millisecond))
+	if !errors.Is(outcome.Error, ErrQuorumNotReached) {
+		t.Fatalf("error = %v, want ErrQuorumNotReached", outcome.Error)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ScatterGather took %v despite the per-request timeout", elapsed)
+	}
+}
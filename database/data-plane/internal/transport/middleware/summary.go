@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// BatchSummary aggregates the results of a batch.
+// Results are kept in submission order (by Index).
+type BatchSummary struct {
+	results   []interfaces.AsyncResult
+	successes []interfaces.AsyncResult
+	failures  []interfaces.AsyncResult
+	total     time.Duration
+	max       time.Duration
+}
+
+// CollectResults drains the results channel completely and summarizes it.
+// It always consumes every result, even if the caller only needs Err.
+func CollectResults(results <-chan interfaces.AsyncResult) BatchSummary {
+	var summary BatchSummary
+	for result := range results {
+		summary.results = append(summary.results, result)
+	}
+
+	sort.SliceStable(summary.results, func(i, j int) bool {
+		return summary.results[i].Index < summary.results[j].Index
+	})
+
+	for _, result := range summary.results {
+		if result.Error == nil {
+			summary.successes = append(summary.successes, result)
+		} else {
+			summary.failures = append(summary.failures, result)
+		}
+		summary.total += result.Duration
+		if result.Duration > summary.max {
+			summary.max = result.Duration
+		}
+	}
+	return summary
+}
+
+// Results returns every result in submission order.
+func (s BatchSummary) Results() []interfaces.AsyncResult {
+	return s.results
+}
+
+// Successes returns the results without an error, in submission order.
+func (s BatchSummary) Successes() []interfaces.AsyncResult {
+	return s.successes
+}
+
+// Failures returns the results with an error, in submission order.
+func (s BatchSummary) Failures() []interfaces.AsyncResult {
+	return s.failures
+}
+
+// Count returns the total number of results.
+func (s BatchSummary) Count() int {
+	return len(s.results)
+}
+
+// SuccessCount returns the number of successful results.
+func (s BatchSummary) SuccessCount() int {
+	return len(s.successes)
+}
+
+// FailureCount returns the number of failed results.
+func (s BatchSummary) FailureCount() int {
+	return len(s.failures)
+}
+
+// Err returns every failure joined with errors.Join, each prefixed with its
+// index and key, or nil if all requests succeeded.
+func (s BatchSummary) Err() error {
+	errs := make([]error, len(s.failures))
+	for i, failure := range s.failures {
+		errs[i] = labelError(failure)
+	}
+	return errors.Join(errs...)
+}
+
+// TotalDuration returns the sum of all request durations.
+func (s BatchSummary) TotalDuration() time.Duration {
+	return s.total
+}
+
+// MeanDuration returns the average request duration, or 0 for an empty batch.
+func (s BatchSummary) MeanDuration() time.Duration {
+	if len(s.results) == 0 {
+		return 0
+	}
+	return s.total / time.Duration(len(s.results))
+}
+
+// MaxDuration returns the longest request duration.
+func (s BatchSummary) MaxDuration() time.Duration {
+	return s.max
+}
+
+// DecodeAll runs handler over every successful response in the summary.
+// The returned slices are aligned with Successes: values[i] holds the decoded
+// value and errs[i] the error (nil on success) for the i-th success.
+func DecodeAll[T any](summary BatchSummary, handler interfaces.IResponseHandler) ([]T, []error) {
+	values := make([]T, len(summary.successes))
+	errs := make([]error, len(summary.successes))
+
+	for i, result := range summary.successes {
+		decoded, err := handler.Handle(result.Response)
+		if err != nil {
+			errs[i] = labelError(interfaces.AsyncResult{Index: result.Index, Key: result.Key, Error: err})
+			continue
+		}
+		values[i], errs[i] = assertType[T](decoded)
+	}
+	return values, errs
+}
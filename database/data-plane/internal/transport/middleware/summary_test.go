@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/http/handler"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// jsonResult returns a successful result whose response body is body.
+func jsonResult(index int, duration time.Duration, body string) interfaces.AsyncResult {
+	return interfaces.AsyncResult{
+		Index:    index,
+		Duration: duration,
+		Response: &models.Response{HttpResp: &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}},
+	}
+}
+
+func TestCollectResultsSummary(t *testing.T) {
+	boom := errors.New("boom")
+	ch := make(chan interfaces.AsyncResult, 5)
+	ch <- jsonResult(3, 40*time.Millisecond, `{"id":3}`)
+	ch <- interfaces.AsyncResult{Index: 1, Key: "one", Duration: 10 * time.Millisecond, Error: boom}
+	ch <- jsonResult(0, 20*time.Millisecond, `{"id":0}`)
+	ch <- jsonResult(4, 50*time.Millisecond, `{"id":"four"}`)
+	ch <- interfaces.AsyncResult{Index: 2, Duration: 30 * time.Millisecond, Error: boom}
+	close(ch)
+
+	summary := CollectResults(ch)
+	if summary.Count() != 5 || summary.SuccessCount() != 3 || summary.FailureCount() != 2 {
+		t.Fatalf("counts = %d/%d/%d, want 5/3/2", summary.Count(), summary.SuccessCount(), summary.FailureCount())
+	}
+	for i, result := range summary.Results() {
+		if result.Index != i {
+			t.Fatalf("results not in submission order: %d at %d", result.Index, i)
+		}
+	}
+	if summary.TotalDuration() != 150*time.Millisecond || summary.MeanDuration() != 30*time.Millisecond || summary.MaxDuration() != 50*time.Millisecond {
+		t.Errorf("durations = total %v, mean %v, max %v", summary.TotalDuration(), summary.MeanDuration(), summary.MaxDuration())
+	}
+
+	err := summary.Err()
+	if !errors.Is(err, boom) || !strings.Contains(err.Error(), "request 1 (one)") || !strings.Contains(err.Error(), "request 2") {
+		t.Errorf("Err = %v, want both labelled failures", err)
+	}
+
+	// Decoding runs over the successes, one value or error each
+	type record struct {
+		ID int `json:"id"`
+	}
+	values, errs := DecodeAll[record](summary, handler.NewResponseHandler().WithResponseType(record{}).Build())
+	if len(values) != 3 || len(errs) != 3 {
+		t.Fatalf("DecodeAll returned %d values and %d errors, want 3 of each", len(values), len(errs))
+	}
+	if errs[0] != nil || values[0].ID != 0 || errs[1] != nil || values[1].ID != 3 {
+		t.Errorf("decoded %+v with errors %v", values, errs)
+	}
+	if errs[2] == nil || !strings.Contains(errs[2].Error(), "request 4") {
+		t.Errorf("undecodable success error = %v, want it labelled with its index", errs[2])
+	}
+}
+
+func TestCollectResultsEmpty(t *testing.T) {
+	ch := make(chan interfaces.AsyncResult)
+	close(ch)
+	summary := CollectResults(ch)
+	if summary.Count() != 0 || summary.MeanDuration() != 0 || summary.Err() != nil {
+		t.Errorf("empty summary = %d results, mean %v, err %v", summary.Count(), summary.MeanDuration(), summary.Err())
+	}
+}
+
+func TestCollectResultsDrainsBatch(t *testing.T) {
+	server := newAsyncServer(t)
+	requests := newBatchRequests(t, server, 6, func(i int) string {
+		if i%3 == 0 {
+			return "/fail"
+		}
+		return "/ok"
+	})
+
+	batch := RunBatch(context.Background(), client.NewHTTPClient(), toEntries(requests))
+	summary := CollectResults(batch.Results())
+	select {
+	case <-batch.Done():
+	case <-time.After(time.Second):
+		t.Fatal("batch not done after its results were collected")
+	}
+	if summary.SuccessCount() != 4 || summary.FailureCount() != 2 || summary.Err() == nil {
+		t.Errorf("summary = %d successes, %d failures, err %v", summary.SuccessCount(), summary.FailureCount(), summary.Err())
+	}
+	for _, result := range summary.Successes() {
+		result.Response.Close()
+	}
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+const slowFailDelay = 40 * time.Millisecond
+
+// newSlowFailServer answers every request with a 500 after slowFailDelay.
+func newSlowFailServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(slowFailDelay)
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// requireBracketed asserts min <= got <= max.
+func requireBracketed(t *testing.T, what string, got, min, max time.Duration) {
+	t.Helper()
+	if got < min || got > max {
+		t.Errorf("%s = %v, want between %v and %v", what, got, min, max)
+	}
+}
+
+func TestErrorDuration(t *testing.T) {
+	server := newSlowFailServer(t)
+
+	start := time.Now()
+	_, err := client.NewHTTPClient().Send(newServerRequest(t, server, "/"))
+	elapsed := time.Since(start)
+
+	var httpErr interfaces.IHTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("error = %T %v, want an IHTTPError", err, err)
+	}
+	requireBracketed(t, "GetDuration()", httpErr.GetDuration(), slowFailDelay, elapsed)
+	if !strings.Contains(err.Error(), "[elapsed: ") {
+		t.Errorf("Error() = %q does not report the elapsed time", err.Error())
+	}
+}
+
+func TestRetryExhaustedDurations(t *testing.T) {
+	server := newSlowFailServer(t)
+	const backoff = 10 * time.Millisecond
+	policy := stubPolicy{attempts: 3, delay: backoff, retry: retryAll}
+
+	start := time.Now()
+	_, err := NewRetryDecorator(client.NewHTTPClient(), policy).Send(newServerRequest(t, server, "/"))
+	elapsed := time.Since(start)
+
+	var exhausted *models.RetryExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("error = %T %v, want *RetryExhaustedError", err, err)
+	}
+	requireBracketed(t, "GetDuration()", exhausted.GetDuration(), 3*slowFailDelay+2*backoff, elapsed)
+
+	if len(exhausted.Durations) != 3 {
+		t.Fatalf("recorded %d attempt durations, want 3", len(exhausted.Durations))
+	}
+	var total time.Duration
+	for _, d := range exhausted.Durations {
+		requireBracketed(t, "attempt duration", d, slowFailDelay, elapsed)
+		total += d
+	}
+	if total > exhausted.Elapsed {
+		t.Errorf("attempts took %v in total, more than the elapsed %v", total, exhausted.Elapsed)
+	}
+}
+
+func TestAsyncErrorDurationMatchesResult(t *testing.T) {
+	server := newSlowFailServer(t)
+	policy := stubPolicy{attempts: 2, delay: time.Millisecond, retry: retryAll}
+	async := NewAsyncRequest(NewRetryDecorator(client.NewHTTPClient(), policy))
+
+	for _, path := range []string{"/", "/again"} {
+		result := receiveOne(t, async.Execute(context.Background(), newServerRequest(t, server, path)))
+		var httpErr interfaces.IHTTPError
+		if !errors.As(result.Error, &httpErr) {
+			t.Fatalf("error = %T %v, want an IHTTPError", result.Error, result.Error)
+		}
+		if httpErr.GetDuration() != result.Duration {
+			t.Errorf("error duration %v disagrees with the result's %v", httpErr.GetDuration(), result.Duration)
+		}
+	}
+
+	// Cancelled before completion
+	ctx, cancel := context.WithTimeout(context.Background(), slowFailDelay/2)
+	defer cancel()
+	result := receiveOne(t, async.Execute(ctx, newServerRequest(t, server, "/")))
+	var httpErr interfaces.IHTTPError
+	if !errors.As(result.Error, &httpErr) || httpErr.GetDuration() != result.Duration {
+		t.Errorf("cancelled result error %v disagrees with the result's %v", result.Error, result.Duration)
+	}
+}
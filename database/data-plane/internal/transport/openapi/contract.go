@@ -0,0 +1,336 @@
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// ContractMode selects what the contract validator does with violations.
+type ContractMode int
+
+const (
+	// ContractEnforce fails the call with a *ContractError.
+	ContractEnforce ContractMode = iota
+
+	// ContractLogOnly logs violations and lets the call proceed.
+	ContractLogOnly
+)
+
+// Violation is one mismatch between a message and the spec. Pointer is a
+// JSON pointer into the message: "/path/<name>", "/query/<name>",
+// "/headers/<name>", "/status" or "/body/..." for the body document.
+type Violation struct {
+	Pointer string
+	Message string
+}
+
+// ContractError is returned when a request or response does not conform to
+// the spec.
+// It implements the IHTTPError interface with Kind KindContract.
+type ContractError struct {
+	models.HTTPError
+
+	// Operation is the operationId (or "METHOD /path") the message was checked against
+	Operation string
+
+	// Direction is "request" or "response"
+	Direction string
+
+	// Violations lists every mismatch found
+	Violations []Violation
+}
+
+// Ensure ContractError implements IHTTPError interface
+var _ interfaces.IHTTPError = (*ContractError)(nil)
+
+// Error implements the error interface for ContractError.
+func (e *ContractError) Error() string {
+	details := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		details[i] = violation.Pointer + ": " + violation.Message
+	}
+	return fmt.Sprintf("%s %s violates the contract of %s: %s",
+		e.Direction, e.Message, e.Operation, strings.Join(details, "; "))
+}
+
+// Format implements fmt.Formatter; see models.HTTPError.Format.
+func (e *ContractError) Format(f fmt.State, verb rune) {
+	models.FormatError(f, verb, e)
+}
+
+// ContractValidator is an HTTP client decorator that checks outgoing
+// requests and incoming responses against a Spec.
+//
+// Request bodies are validated only when they can be replayed (GetBody is
+// set), so streamed uploads are not buffered. Responses are buffered and
+// handed on unchanged. Requests that match no operation are reported as a
+// violation of "/path".
+type ContractValidator struct {
+	wrapped interfaces.IHTTPClient
+	spec    *Spec
+	mode    ContractMode
+	logger  *log.Logger
+	options *openapi3filter.Options
+}
+
+// Ensure ContractValidator implements IHTTPClient interface
+var _ interfaces.IHTTPClient = (*ContractValidator)(nil)
+
+// NewContractValidator wraps client with contract validation against spec.
+// A nil logger uses log.Default() in ContractLogOnly mode.
+func NewContractValidator(wrapped interfaces.IHTTPClient, spec *Spec, mode ContractMode, logger *log.Logger) *ContractValidator {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &ContractValidator{
+		wrapped: wrapped,
+		spec:    spec,
+		mode:    mode,
+		logger:  logger,
+		options: &openapi3filter.Options{
+			MultiError:            true,
+			IncludeResponseStatus: true,
+			AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		},
+	}
+}
+
+// Send validates the request, sends it and validates the response.
+func (v *ContractValidator) Send(request interfaces.IHTTPRequest) (interfaces.IHTTPResponse, error) {
+	httpReq := request.HTTPRequest()
+	ctx := httpReq.Context()
+
+	route, pathParams, err := v.spec.findRoute(httpReq)
+	if err != nil {
+		if contractErr := v.report(request, nil, nil, "request", fmt.Sprintf("%s %s", httpReq.Method, httpReq.URL.Path),
+			[]Violation{{Pointer: "/path", Message: err.Error()}}); contractErr != nil {
+			return nil, contractErr
+		}
+		return v.wrapped.Send(request)
+	}
+	operation := operationName(route)
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:    httpReq,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    v.options,
+	}
+	if restore, ok := v.replayBody(httpReq); ok {
+		err = openapi3filter.ValidateRequest(ctx, input)
+		restore()
+	} else {
+		options := *v.options
+		options.ExcludeRequestBody = true
+		input.Options = &options
+		err = openapi3filter.ValidateRequest(ctx, input)
+	}
+	if err != nil {
+		if contractErr := v.report(request, nil, nil, "request", operation, violations(err, "")); contractErr != nil {
+			return nil, contractErr
+		}
+	}
+
+	resp, sendErr := v.wrapped.Send(request)
+	if resp == nil || resp.HTTPResponse() == nil {
+		return resp, sendErr
+	}
+
+	httpResp := resp.HTTPResponse()
+	body, readErr := resp.Body()
+	if readErr != nil {
+		return resp, errors.Join(sendErr, readErr)
+	}
+	err = openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 httpResp.StatusCode,
+		Header:                 httpResp.Header,
+		Body:                   io.NopCloser(bytes.NewReader(body)),
+		Options:                v.options,
+	})
+	if err != nil {
+		if contractErr := v.report(request, resp, sendErr, "response", operation, violations(err, "")); contractErr != nil {
+			return resp, contractErr
+		}
+	}
+	return resp, sendErr
+}
+
+// SendWithHandler sends the request and processes the response with handler.
+func (v *ContractValidator) SendWithHandler(request interfaces.IHTTPRequest, handler interfaces.IResponseHandler) (interface{}, error) {
+	resp, err := v.Send(request)
+	if err != nil {
+		return nil, err
+	}
+	return handler.Handle(resp)
+}
+
+// SetTimeout sets the timeout on the wrapped client.
+func (v *ContractValidator) SetTimeout(timeout time.Duration) {
+	v.wrapped.SetTimeout(timeout)
+}
+
+// SetHTTPClient sets the HTTP client on the wrapped client.
+func (v *ContractValidator) SetHTTPClient(client *http.Client) {
+	v.wrapped.SetHTTPClient(client)
+}
+
+// GetHTTPClient returns the HTTP client from the wrapped client.
+func (v *ContractValidator) GetHTTPClient() *http.Client {
+	return v.wrapped.GetHTTPClient()
+}
+
+// replayBody swaps in a fresh copy of a replayable body for validation and
+// returns a function restoring the original. ok is false for streamed bodies.
+func (v *ContractValidator) replayBody(httpReq *http.Request) (restore func(), ok bool) {
+	if httpReq.Body == nil || httpReq.Body == http.NoBody {
+		return func() {}, true
+	}
+	if httpReq.GetBody == nil {
+		return nil, false
+	}
+	body, err := httpReq.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	original := httpReq.Body
+	httpReq.Body = body
+	return func() {
+		body.Close()
+		httpReq.Body = original
+	}, true
+}
+
+// report logs or returns the violations, depending on the mode.
+// cause, if any, is the error the call failed with anyway and is wrapped.
+func (v *ContractValidator) report(request interfaces.IHTTPRequest, response interfaces.IHTTPResponse, cause error, direction, operation string, found []Violation) error {
+	contractErr := &ContractError{
+		HTTPError: models.HTTPError{
+			Request:  request,
+			Response: response,
+			Message:  fmt.Sprintf("%s %s", request.Method(), models.RedactURL(request.URL())),
+			Err:      cause,
+			KindVal:  interfaces.KindContract,
+		},
+		Operation:  operation,
+		Direction:  direction,
+		Violations: found,
+	}
+	if response != nil {
+		contractErr.StatusCode = response.StatusCode()
+	}
+
+	if v.mode == ContractLogOnly {
+		v.logger.Printf("[CONTRACT] %s", contractErr.Error())
+		return nil
+	}
+	return contractErr
+}
+
+// findRoute matches a request to an operation of the spec.
+func (s *Spec) findRoute(httpReq *http.Request) (*routers.Route, map[string]string, error) {
+	s.routerOnce.Do(func() {
+		s.router, s.routerErr = legacy.NewRouter(s.doc)
+	})
+	if s.routerErr != nil {
+		return nil, nil, s.routerErr
+	}
+	return s.router.FindRoute(httpReq)
+}
+
+// operationName names the operation of route for reports.
+func operationName(route *routers.Route) string {
+	if route.Operation != nil && route.Operation.OperationID != "" {
+		return route.Operation.OperationID
+	}
+	return route.Method + " " + route.Path
+}
+
+// violations flattens the errors returned by openapi3filter into
+// violations with JSON pointers, below prefix.
+func violations(err error, prefix string) []Violation {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var found []Violation
+		for _, item := range e {
+			found = append(found, violations(item, prefix)...)
+		}
+		return found
+	case *openapi3filter.RequestError:
+		pointer := prefix
+		switch {
+		case e.Parameter != nil:
+			pointer = "/" + parameterLocation(e.Parameter.In) + "/" + escapePointer(e.Parameter.Name)
+		case e.RequestBody != nil:
+			pointer = "/body"
+		}
+		if e.Err == nil {
+			return []Violation{{Pointer: pointer, Message: e.Reason}}
+		}
+		return violations(e.Err, pointer)
+	case *openapi3filter.ResponseError:
+		pointer := responsePointer(e.Reason)
+		if e.Err == nil {
+			return []Violation{{Pointer: pointer, Message: e.Reason}}
+		}
+		return violations(e.Err, pointer)
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		pointer := prefix
+		for _, token := range schemaErr.JSONPointer() {
+			pointer += "/" + escapePointer(token)
+		}
+		return []Violation{{Pointer: pointer, Message: schemaErr.Reason}}
+	}
+	return []Violation{{Pointer: prefix, Message: err.Error()}}
+}
+
+// parameterLocation maps a parameter location to its pointer segment.
+func parameterLocation(in string) string {
+	if in == openapi3.ParameterInHeader {
+		return "headers"
+	}
+	return in
+}
+
+// responsePointer derives the pointer of a response error from its reason,
+// as openapi3filter does not report the failing part structurally.
+func responsePointer(reason string) string {
+	switch {
+	case strings.HasPrefix(reason, "status"):
+		return "/status"
+	case strings.HasPrefix(reason, "response header"):
+		if _, rest, ok := strings.Cut(reason, `"`); ok {
+			if name, _, ok := strings.Cut(rest, `"`); ok {
+				return "/headers/" + escapePointer(name)
+			}
+		}
+		return "/headers"
+	default:
+		return "/body"
+	}
+}
+
+// pointerEscaper escapes JSON pointer reference tokens (RFC 6901).
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// escapePointer escapes token for use in a JSON pointer.
+func escapePointer(token string) string {
+	return pointerEscaper.Replace(token)
+}
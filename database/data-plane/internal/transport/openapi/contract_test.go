@@ -0,0 +1,262 @@
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"data-plane/internal/transport/http/builder"
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/interfaces"
+)
+
+// stubReply is a canned response of the petstore stub.
+type stubReply struct {
+	status int
+	body   string
+}
+
+// newPetstoreStub serves replies keyed by "METHOD /path" and points spec at
+// it. hits counts the requests that reached the stub.
+func newPetstoreStub(t *testing.T, spec *Spec, replies map[string]stubReply) *int {
+	t.Helper()
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		reply, ok := replies[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if reply.body != "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(reply.status)
+		w.Write([]byte(reply.body))
+	}))
+	t.Cleanup(server.Close)
+	if _, err := spec.WithServer(server.URL + "/v3"); err != nil {
+		t.Fatal(err)
+	}
+	return &hits
+}
+
+// stubRequest builds a request to the stub bypassing the Operation checks,
+// so that invalid requests can be sent.
+func stubRequest(t *testing.T, spec *Spec, method, path string, configure func(interfaces.IRequestBuilder)) interfaces.IHTTPRequest {
+	t.Helper()
+	rb := builder.NewBuilder().Method(method).Scheme(spec.server.Scheme).Host(spec.server.Host).Path(spec.server.Path + path)
+	if configure != nil {
+		configure(rb)
+	}
+	request, err := rb.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return request
+}
+
+func TestContractValidator(t *testing.T) {
+	spec := loadPetstore(t)
+	hits := newPetstoreStub(t, spec, map[string]stubReply{
+		"GET /v3/pet/1":    {http.StatusOK, `{"id":1,"name":"rex"}`},
+		"GET /v3/pet/2":    {http.StatusOK, `{"id":"two"}`},
+		"POST /v3/pet":     {http.StatusOK, `{"id":3,"name":"tom"}`},
+		"DELETE /v3/pet/4": {http.StatusAccepted, ""},
+	})
+	validator := NewContractValidator(client.NewHTTPClient(), spec, ContractEnforce, nil)
+
+	for _, tc := range []struct {
+		name       string
+		request    interfaces.IHTTPRequest
+		direction  string
+		operation  string
+		violations []Violation
+		sent       bool
+	}{
+		{
+			name:    "valid call",
+			request: stubRequest(t, spec, "GET", "/pet/1", nil),
+			sent:    true,
+		},
+		{
+			name:      "invalid body",
+			request:   stubRequest(t, spec, "POST", "/pet", func(rb interfaces.IRequestBuilder) { rb.JSON(map[string]int{"name": 3}) }),
+			direction: "request",
+			operation: "addPet",
+			violations: []Violation{
+				{Pointer: "/body/name", Message: "value must be a string"},
+			},
+		},
+		{
+			name:      "invalid query",
+			request:   stubRequest(t, spec, "GET", "/pet/findByStatus", func(rb interfaces.IRequestBuilder) { rb.QueryParam("status", "gone") }),
+			direction: "request",
+			operation: "findPetsByStatus",
+			violations: []Violation{
+				{Pointer: "/query/status", Message: `value is not one of the allowed values ["available","pending","sold"]`},
+			},
+		},
+		{
+			name:      "unknown path",
+			request:   stubRequest(t, spec, "GET", "/store/inventory", nil),
+			direction: "request",
+			operation: "GET /v3/store/inventory",
+			violations: []Violation{
+				{Pointer: "/path", Message: "no matching operation was found"},
+			},
+		},
+		{
+			name:      "invalid stubbed response",
+			request:   stubRequest(t, spec, "GET", "/pet/2", nil),
+			direction: "response",
+			operation: "getPetById",
+			violations: []Violation{
+				{Pointer: "/body/id", Message: "value must be an integer"},
+				{Pointer: "/body/name", Message: `property "name" is missing`},
+			},
+			sent: true,
+		},
+		{
+			name: "undeclared status",
+			request: stubRequest(t, spec, "DELETE", "/pet/4", func(rb interfaces.IRequestBuilder) {
+				rb.Header("api_key", "secret")
+			}),
+			direction: "response",
+			operation: "deletePet",
+			violations: []Violation{
+				{Pointer: "/status", Message: "status is not supported"},
+			},
+			sent: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			before := *hits
+			resp, err := validator.Send(tc.request)
+			if resp != nil {
+				defer resp.Close()
+			}
+			if sent := *hits > before; sent != tc.sent {
+				t.Errorf("request reached the stub: %v, want %v", sent, tc.sent)
+			}
+
+			if tc.violations == nil {
+				if err != nil {
+					t.Fatalf("Send: %v", err)
+				}
+				return
+			}
+			var contractErr *ContractError
+			if !errors.As(err, &contractErr) {
+				t.Fatalf("Send error = %T %v, want *ContractError", err, err)
+			}
+			if contractErr.Kind() != interfaces.KindContract {
+				t.Errorf("Kind = %v, want KindContract", contractErr.Kind())
+			}
+			if contractErr.Direction != tc.direction || contractErr.Operation != tc.operation {
+				t.Errorf("violation of %s %s, want %s %s", contractErr.Direction, contractErr.Operation, tc.direction, tc.operation)
+			}
+			if !reflect.DeepEqual(contractErr.Violations, tc.violations) {
+				t.Errorf("Violations = %+v, want %+v", contractErr.Violations, tc.violations)
+			}
+		})
+	}
+}
+
+func TestContractValidatorKeepsBodies(t *testing.T) {
+	spec := loadPetstore(t)
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		buf.ReadFrom(r.Body)
+		received = buf.String()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":3,"name":"tom"}`))
+	}))
+	defer server.Close()
+	if _, err := spec.WithServer(server.URL + "/v3"); err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := spec.Operation("addPet").JSON(map[string]string{"name": "tom"}).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := NewContractValidator(client.NewHTTPClient(), spec, ContractEnforce, nil).Send(request)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	defer resp.Close()
+
+	// Validation must not consume the request body or the response body
+	if received != `{"name":"tom"}` {
+		t.Errorf("server received %q, want the full request body", received)
+	}
+	body, err := resp.Body()
+	if err != nil || string(body) != `{"id":3,"name":"tom"}` {
+		t.Errorf("response body = %q, %v; want it intact", body, err)
+	}
+}
+
+func TestContractValidatorLogOnly(t *testing.T) {
+	spec := loadPetstore(t)
+	hits := newPetstoreStub(t, spec, map[string]stubReply{
+		"GET /v3/pet/2": {http.StatusOK, `{"id":"two"}`},
+	})
+	var logs bytes.Buffer
+	validator := NewContractValidator(client.NewHTTPClient(), spec, ContractLogOnly, log.New(&logs, "", 0))
+
+	resp, err := validator.Send(stubRequest(t, spec, "GET", "/pet/2", nil))
+	if err != nil {
+		t.Fatalf("Send error = %v, want violations only logged", err)
+	}
+	resp.Close()
+	if *hits != 1 {
+		t.Errorf("stub received %d requests, want 1", *hits)
+	}
+	if got := logs.String(); !strings.HasPrefix(got, "[CONTRACT] response GET") || !strings.Contains(got, "/body/id: value must be an integer") {
+		t.Errorf("log = %q, want the response violations", got)
+	}
+}
+
+func BenchmarkContractValidator(b *testing.B) {
+	spec, err := LoadSpec("testdata/petstore.yaml")
+	if err != nil {
+		b.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"name":"rex"}`))
+	}))
+	defer server.Close()
+	if _, err := spec.WithServer(server.URL + "/v3"); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bc := range []struct {
+		name   string
+		client interfaces.IHTTPClient
+	}{
+		{"plain", client.NewHTTPClient()},
+		{"validated", NewContractValidator(client.NewHTTPClient(), spec, ContractEnforce, nil)},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				request, err := spec.Operation("getPetById").PathParam("petId", 1).Build()
+				if err != nil {
+					b.Fatal(err)
+				}
+				resp, err := bc.client.Send(request)
+				if err != nil {
+					b.Fatal(err)
+				}
+				resp.Close()
+			}
+		})
+	}
+}
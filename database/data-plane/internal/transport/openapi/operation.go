@@ -0,0 +1,247 @@
+package openapi
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"data-plane/internal/transport/http/builder"
+	"data-plane/internal/transport/interfaces"
+)
+
+// Operation collects the parameters of one call to an operation of a Spec
+// and turns them into a request builder. Like the request builder, it
+// records the first error and reports it from Builder or Build.
+type Operation struct {
+	spec       *Spec
+	id         string
+	ref        *operationRef
+	pathParams map[string]string
+	query      url.Values
+	headers    map[string]string
+	body       interface{}
+	err        error
+}
+
+// Operation starts a call to the operation with the given operationId.
+// An unknown ID is reported by Err, Builder and Build.
+func (s *Spec) Operation(id string) *Operation {
+	o := &Operation{
+		spec:       s,
+		id:         id,
+		pathParams: make(map[string]string),
+		query:      make(url.Values),
+		headers:    make(map[string]string),
+	}
+	ref, ok := s.operations[id]
+	if !ok {
+		o.err = fmt.Errorf("unknown OpenAPI operation %q", id)
+		return o
+	}
+	o.ref = ref
+	return o
+}
+
+// PathParam sets a path parameter declared by the operation.
+func (o *Operation) PathParam(name string, value interface{}) *Operation {
+	return o.set(openapi3.ParameterInPath, name, value)
+}
+
+// Query sets a query parameter declared by the operation.
+func (o *Operation) Query(name string, value interface{}) *Operation {
+	return o.set(openapi3.ParameterInQuery, name, value)
+}
+
+// Header sets a header parameter declared by the operation.
+func (o *Operation) Header(name string, value interface{}) *Operation {
+	return o.set(openapi3.ParameterInHeader, name, value)
+}
+
+// JSON sets the request body, sent as application/json.
+func (o *Operation) JSON(body interface{}) *Operation {
+	if o.err != nil {
+		return o
+	}
+	if o.ref.op.RequestBody == nil {
+		o.err = fmt.Errorf("operation %q does not accept a request body", o.id)
+		return o
+	}
+	o.body = body
+	return o
+}
+
+// Err returns the first error recorded while setting up the operation.
+func (o *Operation) Err() error {
+	return o.err
+}
+
+// Builder validates the parameters and returns a request builder
+// pre-populated with the server URL, method, path, query and headers.
+// Further options (resiliency, timeout, context) can be added to it.
+func (o *Operation) Builder() (interfaces.IRequestBuilder, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	if o.spec.server == nil || o.spec.server.Host == "" {
+		return nil, fmt.Errorf("OpenAPI spec has no absolute server URL; call WithServer")
+	}
+
+	rb := builder.NewBuilder().
+		Method(o.ref.method).
+		Scheme(o.spec.server.Scheme).
+		Host(o.spec.server.Host).
+		Path(o.spec.server.Path + "/" + o.expandPath())
+	for name, values := range o.query {
+		for _, value := range values {
+			rb.QueryParam(name, value)
+		}
+	}
+	for name, value := range o.headers {
+		rb.Header(name, value)
+	}
+	if o.body != nil {
+		rb.JSON(o.body)
+	}
+	return rb, nil
+}
+
+// Build validates the parameters and builds the request.
+func (o *Operation) Build() (interfaces.IHTTPRequest, error) {
+	rb, err := o.Builder()
+	if err != nil {
+		return nil, err
+	}
+	return rb.Build()
+}
+
+// set validates and records a parameter value.
+func (o *Operation) set(in, name string, value interface{}) *Operation {
+	if o.err != nil {
+		return o
+	}
+	param := o.parameter(in, name)
+	if param == nil {
+		o.err = fmt.Errorf("operation %q has no %s parameter %q", o.id, in, name)
+		return o
+	}
+
+	str := fmt.Sprint(value)
+	if err := checkEnum(param, str); err != nil {
+		o.err = fmt.Errorf("operation %q: %w", o.id, err)
+		return o
+	}
+
+	switch in {
+	case openapi3.ParameterInPath:
+		if strings.Contains(str, "/") {
+			o.err = fmt.Errorf("operation %q: path parameter %q cannot contain '/'", o.id, name)
+			return o
+		}
+		o.pathParams[name] = str
+	case openapi3.ParameterInQuery:
+		o.query.Add(name, str)
+	case openapi3.ParameterInHeader:
+		o.headers[name] = str
+	}
+	return o
+}
+
+// validate checks that every required parameter and body was supplied.
+func (o *Operation) validate() error {
+	if o.err != nil {
+		return o.err
+	}
+
+	var missing []string
+	for _, param := range o.parameters() {
+		if !param.Required {
+			continue
+		}
+		var supplied bool
+		switch param.In {
+		case openapi3.ParameterInPath:
+			_, supplied = o.pathParams[param.Name]
+		case openapi3.ParameterInQuery:
+			supplied = o.query.Has(param.Name)
+		case openapi3.ParameterInHeader:
+			_, supplied = o.headers[param.Name]
+		default:
+			continue
+		}
+		if !supplied {
+			missing = append(missing, fmt.Sprintf("%s parameter %q", param.In, param.Name))
+		}
+	}
+	if body := o.ref.op.RequestBody; body != nil && body.Value != nil && body.Value.Required && o.body == nil {
+		missing = append(missing, "request body")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("operation %q is missing required %s", o.id, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// parameter returns the declared parameter with the given location and name.
+func (o *Operation) parameter(in, name string) *openapi3.Parameter {
+	for _, param := range o.parameters() {
+		if param.In == in && param.Name == name {
+			return param
+		}
+	}
+	return nil
+}
+
+// parameters returns the operation's parameters, including those declared
+// on the path item that the operation does not override.
+func (o *Operation) parameters() []*openapi3.Parameter {
+	var params []*openapi3.Parameter
+	for _, ref := range o.ref.op.Parameters {
+		if ref.Value != nil {
+			params = append(params, ref.Value)
+		}
+	}
+	for _, ref := range o.ref.pathItem.Parameters {
+		if ref.Value != nil && o.ref.op.Parameters.GetByInAndName(ref.Value.In, ref.Value.Name) == nil {
+			params = append(params, ref.Value)
+		}
+	}
+	return params
+}
+
+// expandPath substitutes the path parameters into the path template.
+func (o *Operation) expandPath() string {
+	path := o.ref.path
+	for name, value := range o.pathParams {
+		path = strings.ReplaceAll(path, "{"+name+"}", value)
+	}
+	return path
+}
+
+// checkEnum reports an error if the parameter restricts its values to an
+// enum that does not contain value.
+func checkEnum(param *openapi3.Parameter, value string) error {
+	if param.Schema == nil || param.Schema.Value == nil {
+		return nil
+	}
+	schema := param.Schema.Value
+	if schema.Type.Is(openapi3.TypeArray) && schema.Items != nil && schema.Items.Value != nil {
+		schema = schema.Items.Value
+	}
+	if len(schema.Enum) == 0 {
+		return nil
+	}
+
+	allowed := make([]string, len(schema.Enum))
+	for i, option := range schema.Enum {
+		allowed[i] = fmt.Sprint(option)
+	}
+	if !slices.Contains(allowed, value) {
+		return fmt.Errorf("%s parameter %q must be one of [%s], got %q",
+			param.In, param.Name, strings.Join(allowed, ", "), value)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package openapi
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// loadPetstore loads testdata/petstore.yaml.
+func loadPetstore(t *testing.T) *Spec {
+	t.Helper()
+	spec, err := LoadSpec("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("LoadSpec: %v", err)
+	}
+	return spec
+}
+
+func TestLoadSpec(t *testing.T) {
+	spec := loadPetstore(t)
+
+	want := []string{"addPet", "deletePet", "findPetsByStatus", "findPetsByTags", "getPetById"}
+	if got := spec.OperationIDs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("OperationIDs = %v, want %v", got, want)
+	}
+	// Server variables resolve to their defaults
+	if got := spec.server.String(); got != "https://petstore.example.com/v3" {
+		t.Errorf("server = %s, want https://petstore.example.com/v3", got)
+	}
+
+	if _, err := LoadSpecData([]byte("openapi: 3.0.3\ninfo: {title: x}\npaths: {}\n")); err == nil {
+		t.Error("LoadSpecData accepted a spec without info.version")
+	}
+	if _, err := LoadSpec("testdata/missing.yaml"); err == nil {
+		t.Error("LoadSpec accepted a missing file")
+	}
+}
+
+func TestOperationBuildsRequests(t *testing.T) {
+	spec := loadPetstore(t)
+
+	for _, tc := range []struct {
+		name      string
+		operation *Operation
+		method    string
+		url       string
+		headers   map[string]string
+		body      string
+	}{
+		{
+			name:      "path and query parameters",
+			operation: spec.Operation("getPetById").PathParam("petId", 42).Query("expand", "profile"),
+			method:    "GET",
+			url:       "https://petstore.example.com/v3/pet/42?expand=profile",
+		},
+		{
+			name:      "path-level parameter only",
+			operation: spec.Operation("getPetById").PathParam("petId", 7),
+			method:    "GET",
+			url:       "https://petstore.example.com/v3/pet/7",
+		},
+		{
+			name:      "enum query parameter",
+			operation: spec.Operation("findPetsByStatus").Query("status", "sold"),
+			method:    "GET",
+			url:       "https://petstore.example.com/v3/pet/findByStatus?status=sold",
+		},
+		{
+			name:      "repeated array parameter",
+			operation: spec.Operation("findPetsByTags").Query("tags", "dog").Query("tags", "cat"),
+			method:    "GET",
+			url:       "https://petstore.example.com/v3/pet/findByTags?tags=dog&tags=cat",
+		},
+		{
+			name:      "header parameter",
+			operation: spec.Operation("deletePet").PathParam("petId", 3).Header("api_key", "secret"),
+			method:    "DELETE",
+			url:       "https://petstore.example.com/v3/pet/3",
+			headers:   map[string]string{"api_key": "secret"},
+		},
+		{
+			name:      "request body",
+			operation: spec.Operation("addPet").JSON(map[string]string{"name": "rex"}),
+			method:    "POST",
+			url:       "https://petstore.example.com/v3/pet",
+			headers:   map[string]string{"Content-Type": "application/json"},
+			body:      `{"name":"rex"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := tc.operation.Build()
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			if request.Method() != tc.method || request.URL() != tc.url {
+				t.Errorf("request = %s %s, want %s %s", request.Method(), request.URL(), tc.method, tc.url)
+			}
+			for name, want := range tc.headers {
+				if got := request.Header(name); got != want {
+					t.Errorf("header %s = %q, want %q", name, got, want)
+				}
+			}
+			if tc.body != "" {
+				body, _ := io.ReadAll(request.HTTPRequest().Body)
+				if string(body) != tc.body {
+					t.Errorf("body = %s, want %s", body, tc.body)
+				}
+			}
+		})
+	}
+}
+
+func TestOperationValidation(t *testing.T) {
+	spec := loadPetstore(t)
+
+	for _, tc := range []struct {
+		name      string
+		operation *Operation
+		immediate bool // reported by Err before Build
+		want      string
+	}{
+		{"unknown operation", spec.Operation("getUserById").PathParam("id", 42), true, `unknown OpenAPI operation "getUserById"`},
+		{"undeclared parameter", spec.Operation("getPetById").Query("verbose", true), true, `has no query parameter "verbose"`},
+		{"illegal enum value", spec.Operation("findPetsByStatus").Query("status", "gone"), true, "must be one of [available, pending, sold]"},
+		{"illegal array enum value", spec.Operation("findPetsByTags").Query("tags", "fish"), true, "must be one of [dog, cat, bird]"},
+		{"slash in path parameter", spec.Operation("getPetById").PathParam("petId", "1/2"), true, "cannot contain '/'"},
+		{"unexpected body", spec.Operation("getPetById").JSON(map[string]int{}), true, "does not accept a request body"},
+		{"missing path parameter", spec.Operation("getPetById").Query("expand", "profile"), false, `missing required path parameter "petId"`},
+		{"missing query parameter", spec.Operation("findPetsByStatus"), false, `missing required query parameter "status"`},
+		{"missing header and path", spec.Operation("deletePet"), false, `header parameter "api_key"`},
+		{"missing body", spec.Operation("addPet"), false, "missing required request body"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.operation.Err(); (err != nil) != tc.immediate {
+				t.Errorf("Err = %v, want an error before Build: %v", err, tc.immediate)
+			}
+			_, err := tc.operation.Build()
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Build error = %v, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestOperationWithServer(t *testing.T) {
+	spec := loadPetstore(t)
+	if _, err := spec.WithServer("http://127.0.0.1:8080/api/"); err != nil {
+		t.Fatal(err)
+	}
+	request, err := spec.Operation("getPetById").PathParam("petId", 1).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := request.URL(), "http://127.0.0.1:8080/api/pet/1"; got != want {
+		t.Errorf("URL = %s, want %s", got, want)
+	}
+
+	if _, err := spec.WithServer("/relative"); err == nil {
+		t.Error("WithServer accepted a relative URL")
+	}
+
+	relative, err := LoadSpecData([]byte("openapi: 3.0.3\ninfo: {title: x, version: '1'}\nservers: [{url: /api}]\n" +
+		"paths:\n  /ping:\n    get:\n      operationId: ping\n      responses: {'200': {description: ok}}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := relative.Operation("ping").Build(); err == nil || !strings.Contains(err.Error(), "WithServer") {
+		t.Errorf("Build error = %v, want a request to call WithServer", err)
+	}
+}
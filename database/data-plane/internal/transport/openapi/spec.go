@@ -0,0 +1,129 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// Spec is a loaded and validated OpenAPI 3 document, indexed by operation ID.
+type Spec struct {
+	doc        *openapi3.T
+	server     *url.URL
+	operations map[string]*operationRef
+
+	// router matches requests to operations for contract validation; it
+	// is built on first use
+	routerOnce sync.Once
+	router     routers.Router
+	routerErr  error
+}
+
+// operationRef locates an operation within the document.
+type operationRef struct {
+	method   string
+	path     string
+	pathItem *openapi3.PathItem
+	op       *openapi3.Operation
+}
+
+// LoadSpec loads the OpenAPI document at path (JSON or YAML) and validates it.
+func LoadSpec(path string) (*Spec, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI spec %s: %w", path, err)
+	}
+	return newSpec(doc)
+}
+
+// LoadSpecData parses an OpenAPI document (JSON or YAML) and validates it.
+func LoadSpecData(data []byte) (*Spec, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	return newSpec(doc)
+}
+
+// newSpec validates doc and indexes its operations.
+func newSpec(doc *openapi3.T) (*Spec, error) {
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+
+	spec := &Spec{
+		doc:        doc,
+		operations: make(map[string]*operationRef),
+	}
+	for path, pathItem := range doc.Paths.Map() {
+		for method, op := range pathItem.Operations() {
+			if op.OperationID == "" {
+				continue
+			}
+			spec.operations[op.OperationID] = &operationRef{
+				method:   method,
+				path:     path,
+				pathItem: pathItem,
+				op:       op,
+			}
+		}
+	}
+
+	if len(doc.Servers) > 0 {
+		server, err := serverURL(doc.Servers[0])
+		if err != nil {
+			return nil, err
+		}
+		spec.server = server
+	}
+	return spec, nil
+}
+
+// WithServer overrides the server URL taken from the document, e.g. to
+// point at a staging host or a test server. Call it before the Spec is
+// used for contract validation.
+func (s *Spec) WithServer(rawURL string) (*Spec, error) {
+	server, err := url.Parse(rawURL)
+	if err != nil || server.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q", rawURL)
+	}
+	s.server = server
+	s.doc.Servers = openapi3.Servers{{URL: strings.TrimSuffix(rawURL, "/")}}
+	return s, nil
+}
+
+// Document returns the underlying OpenAPI document.
+func (s *Spec) Document() *openapi3.T {
+	return s.doc
+}
+
+// OperationIDs returns the IDs of all operations, sorted.
+func (s *Spec) OperationIDs() []string {
+	ids := make([]string, 0, len(s.operations))
+	for id := range s.operations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// serverURL resolves server variables to their defaults and parses the URL.
+func serverURL(server *openapi3.Server) (*url.URL, error) {
+	rawURL := server.URL
+	for name, variable := range server.Variables {
+		rawURL = strings.ReplaceAll(rawURL, "{"+name+"}", variable.Default)
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL %q: %w", server.URL, err)
+	}
+	return parsed, nil
+}
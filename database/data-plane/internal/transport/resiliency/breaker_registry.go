@@ -0,0 +1,60 @@
+package resiliency
+
+import (
+	"sync"
+	"time"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// CircuitBreakerRegistry keeps one circuit breaker per key.
+// All breakers share the same failure threshold and open timeout.
+type CircuitBreakerRegistry struct {
+	mu               sync.Mutex
+	failureThreshold int
+	timeout          time.Duration
+	breakers         map[string]*CircuitBreaker
+}
+
+// Ensure CircuitBreakerRegistry implements ICircuitBreakerRegistry interface
+var _ interfaces.ICircuitBreakerRegistry = (*CircuitBreakerRegistry)(nil)
+
+// NewCircuitBreakerRegistry creates a registry whose breakers open after
+// failureThreshold consecutive failures and probe again after timeout.
+func NewCircuitBreakerRegistry(failureThreshold int, timeout time.Duration) *CircuitBreakerRegistry {
+	return &CircuitBreakerRegistry{
+		failureThreshold: failureThreshold,
+		timeout:          timeout,
+		breakers:         make(map[string]*CircuitBreaker),
+	}
+}
+
+// Breaker returns the circuit breaker for key, creating it on first use.
+func (r *CircuitBreakerRegistry) Breaker(key string) interfaces.ICircuitBreaker {
+	return r.Get(key)
+}
+
+// Get returns the concrete circuit breaker for key, creating it on first use.
+func (r *CircuitBreakerRegistry) Get(key string) *CircuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	breaker, ok := r.breakers[key]
+	if !ok {
+		breaker = NewCircuitBreaker(r.failureThreshold, r.timeout)
+		r.breakers[key] = breaker
+	}
+	return breaker
+}
+
+// Keys returns the keys that currently have a breaker.
+func (r *CircuitBreakerRegistry) Keys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]string, 0, len(r.breakers))
+	for key := range r.breakers {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -2,8 +2,8 @@ package resiliency
 
 import (
 	"context"
-	"errors"
 	"sync/atomic"
+	"time"
 
 	"data-plane/internal/transport/interfaces"
 )
@@ -13,7 +13,8 @@ import (
 type Bulkhead struct {
 	semaphore      chan struct{} // Channel-based semaphore
 	maxConcurrency int
-	activeCount    int64 // Atomic counter for active requests
+	activeCount    int64         // Atomic counter for active requests
+	maxWait        time.Duration // How long to wait for a slot (0 rejects at once)
 }
 
 // Ensure Bulkhead implements IBulkhead interface
@@ -32,29 +33,57 @@ func NewBulkhead(maxConcurrency int) *Bulkhead {
 	}
 }
 
+// NewBulkheadWithWait creates a bulkhead whose callers wait up to maxWait
+// for a free slot before being rejected with ErrBulkheadTimeout.
+func NewBulkheadWithWait(maxConcurrency int, maxWait time.Duration) *Bulkhead {
+	b := NewBulkhead(maxConcurrency)
+	b.maxWait = maxWait
+	return b
+}
+
 // Execute runs the function with bulkhead protection.
+// Without a wait limit a full bulkhead rejects with ErrBulkheadFull.
 func (b *Bulkhead) Execute(ctx context.Context, fn func() (interfaces.IHTTPResponse, error)) (interfaces.IHTTPResponse, error) {
 	// Try to acquire a slot
 	select {
 	case b.semaphore <- struct{}{}:
-		// Slot acquired
-		atomic.AddInt64(&b.activeCount, 1)
-		defer func() {
-			<-b.semaphore // Release slot
-			atomic.AddInt64(&b.activeCount, -1)
-		}()
-
-		// Execute the function
-		return fn()
+		return b.run(fn)
 
 	case <-ctx.Done():
 		// Context canceled while waiting
 		return nil, ctx.Err()
 
 	default:
-		// No slots available
-		return nil, errors.New("bulkhead: maximum concurrency reached, request rejected")
+		if b.maxWait <= 0 {
+			// No slots available
+			return nil, ErrBulkheadFull
+		}
 	}
+
+	// Wait a bounded time for a slot to free up
+	timer := time.NewTimer(b.maxWait)
+	defer timer.Stop()
+
+	select {
+	case b.semaphore <- struct{}{}:
+		return b.run(fn)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, ErrBulkheadTimeout
+	}
+}
+
+// run executes fn in an acquired slot and releases it afterwards.
+func (b *Bulkhead) run(fn func() (interfaces.IHTTPResponse, error)) (interfaces.IHTTPResponse, error) {
+	atomic.AddInt64(&b.activeCount, 1)
+	defer func() {
+		<-b.semaphore // Release slot
+		atomic.AddInt64(&b.activeCount, -1)
+	}()
+
+	// Execute the function
+	return fn()
 }
 
 // ActiveRequests returns the current number of active requests.
@@ -2,7 +2,6 @@ package resiliency
 
 import (
 	"context"
-	"errors"
 	"sync"
 	"time"
 
@@ -39,7 +38,7 @@ func NewCircuitBreaker(failureThreshold int, timeout time.Duration) *CircuitBrea
 func (cb *CircuitBreaker) Execute(ctx context.Context, fn func() (interfaces.IHTTPResponse, error)) (interfaces.IHTTPResponse, error) {
 	// Check if circuit allows execution
 	if !cb.canExecute() {
-		return nil, errors.New("circuit breaker is open: request rejected")
+		return nil, ErrCircuitOpen
 	}
 
 	// Execute the request
@@ -0,0 +1,40 @@
+package resiliency
+
+import (
+	"data-plane/internal/transport/interfaces"
+)
+
+// Rejection sentinels returned (possibly wrapped) by the resiliency components.
+// Match them with errors.Is; they also carry an ErrorKind.
+var (
+	// ErrCircuitOpen is returned when an open circuit breaker rejects a request.
+	ErrCircuitOpen error = &rejectionError{message: "circuit breaker is open", kind: interfaces.KindCircuitOpen}
+
+	// ErrBulkheadFull is returned when a bulkhead has no free slot.
+	ErrBulkheadFull error = &rejectionError{message: "bulkhead is full", kind: interfaces.KindBulkhead}
+
+	// ErrBulkheadTimeout is returned when no bulkhead slot frees up within the wait limit.
+	ErrBulkheadTimeout error = &rejectionError{message: "timed out waiting for a bulkhead slot", kind: interfaces.KindBulkhead}
+
+	// ErrRateLimited is returned when a rate limiter cannot admit a request in time.
+	ErrRateLimited error = &rejectionError{message: "rate limited", kind: interfaces.KindRateLimited}
+)
+
+// rejectionError is a resiliency rejection that knows its error kind.
+type rejectionError struct {
+	message string
+	kind    interfaces.ErrorKind
+}
+
+// Ensure rejectionError implements IKindedError interface
+var _ interfaces.IKindedError = (*rejectionError)(nil)
+
+// Error implements the error interface.
+func (e *rejectionError) Error() string {
+	return e.message
+}
+
+// ErrorKind returns the rejection's category.
+func (e *rejectionError) ErrorKind() interfaces.ErrorKind {
+	return e.kind
+}
@@ -0,0 +1,59 @@
+package resiliency
+
+import (
+	"sync"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// RateLimiterRegistry keeps one token-bucket rate limiter per key.
+// All limiters share the same rate and burst settings.
+type RateLimiterRegistry struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    int
+	limiters map[string]*RateLimiter
+}
+
+// Ensure RateLimiterRegistry implements IRateLimiterRegistry interface
+var _ interfaces.IRateLimiterRegistry = (*RateLimiterRegistry)(nil)
+
+// NewRateLimiterRegistry creates a registry whose limiters allow rate
+// requests per second with the given burst.
+func NewRateLimiterRegistry(rate float64, burst int) *RateLimiterRegistry {
+	return &RateLimiterRegistry{
+		rate:     rate,
+		burst:    burst,
+		limiters: make(map[string]*RateLimiter),
+	}
+}
+
+// Limiter returns the rate limiter for key, creating it on first use.
+func (r *RateLimiterRegistry) Limiter(key string) interfaces.IRateLimiter {
+	return r.Get(key)
+}
+
+// Get returns the concrete rate limiter for key, creating it on first use.
+func (r *RateLimiterRegistry) Get(key string) *RateLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limiter, ok := r.limiters[key]
+	if !ok {
+		limiter = NewRateLimiter(r.rate, r.burst)
+		r.limiters[key] = limiter
+	}
+	return limiter
+}
+
+// Keys returns the keys that currently have a limiter.
+func (r *RateLimiterRegistry) Keys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]string, 0, len(r.limiters))
+	for key := range r.limiters {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -47,6 +47,8 @@ func (rl *RateLimiter) Allow() bool {
 }
 
 // Wait blocks until a request is allowed or context is canceled.
+// If ctx has a deadline that will pass before a token becomes available,
+// it fails fast with ErrRateLimited instead of waiting in vain.
 func (rl *RateLimiter) Wait(ctx context.Context) error {
 	for {
 		if rl.Allow() {
@@ -55,6 +57,9 @@ func (rl *RateLimiter) Wait(ctx context.Context) error {
 
 		// Calculate wait time
 		waitTime := rl.calculateWaitTime()
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < waitTime {
+			return ErrRateLimited
+		}
 
 		select {
 		case <-ctx.Done():
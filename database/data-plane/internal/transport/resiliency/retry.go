@@ -1,6 +1,7 @@
 package resiliency
 
 import (
+	"errors"
 	"math"
 	"time"
 
@@ -43,37 +44,38 @@ func NewRetryPolicyWithConfig(maxAttempts int, initialDelay, maxDelay time.Durat
 }
 
 // ShouldRetry determines if a request should be retried.
+// Timeouts, 5xx responses, the configured retryable status codes and the
+// retryable network failures (see models.IsRetryableNetworkError) are
+// retried; missing hosts, certificate failures, cancellations, local
+// rejections and other client errors are not. Errors that are not
+// HTTPErrors are retried if they report themselves as temporary.
 func (rp *RetryPolicy) ShouldRetry(err error, attempt int) bool {
 	if attempt >= rp.maxAttempts {
 		return false
 	}
 
-	// Check if it's a retryable HTTP error
-	if httpErr, ok := err.(*models.HTTPError); ok {
-		// Retry on timeout or temporary errors
-		if httpErr.IsTimeout() || httpErr.IsTemporary() {
-			return true
-		}
-
-		// Retry on specific status codes
-		for _, code := range rp.retryableErrors {
-			if httpErr.StatusCode == code {
-				return true
-			}
-		}
-
-		// Don't retry client errors (4xx) except specific ones
-		if httpErr.IsClientError() {
-			return false
-		}
+	var httpErr *models.HTTPError
+	if !errors.As(err, &httpErr) {
+		// Errors of other protocols (e.g. gRPC status errors) say for themselves
+		var temporary interface{ IsTemporary() bool }
+		return errors.As(err, &temporary) && temporary.IsTemporary()
+	}
 
-		// Retry server errors (5xx)
-		if httpErr.IsServerError() {
+	// Retry on specific status codes
+	for _, code := range rp.retryableErrors {
+		if httpErr.StatusCode == code {
 			return true
 		}
 	}
 
-	return false
+	switch models.Classify(httpErr) {
+	case interfaces.KindTimeout, interfaces.KindServerStatus:
+		return true
+	case interfaces.KindDNS, interfaces.KindTLS, interfaces.KindConnection:
+		return models.IsRetryableNetworkError(httpErr)
+	default:
+		return false
+	}
 }
 
 // GetDelay calculates the delay for the next retry using exponential backoff.
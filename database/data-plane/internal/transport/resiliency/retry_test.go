@@ -0,0 +1,32 @@
+package resiliency
+
+import (
+	"net"
+	"testing"
+
+	"data-plane/internal/transport/http/models"
+)
+
+func TestShouldRetryDNS(t *testing.T) {
+	policy := NewRetryPolicy(3)
+	tests := []struct {
+		name   string
+		dnsErr *net.DNSError
+		want   bool
+	}{
+		{"nxdomain", &net.DNSError{Err: "no such host", Name: "missing.example", IsNotFound: true}, false},
+		{"servfail", &net.DNSError{Err: "server misbehaving", Name: "flaky.example", IsTemporary: true}, true},
+		{"timeout", &net.DNSError{Err: "i/o timeout", Name: "slow.example", IsTimeout: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &models.HTTPError{
+				Message: "GET request failed",
+				Err:     &net.OpError{Op: "dial", Net: "tcp", Err: tt.dnsErr},
+			}
+			if got := policy.ShouldRetry(err, 0); got != tt.want {
+				t.Errorf("ShouldRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
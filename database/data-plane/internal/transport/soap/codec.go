@@ -0,0 +1,247 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"data-plane/internal/transport/http/handler"
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+const (
+	// EnvelopeNamespace is the SOAP 1.1 envelope namespace.
+	EnvelopeNamespace = "http://schemas.xmlsoap.org/soap/envelope/"
+
+	// ContentType is the SOAP 1.1 request content type.
+	ContentType = "text/xml; charset=utf-8"
+)
+
+// Codec wraps request bodies in SOAP envelopes and unwraps responses.
+// Bodies are encoded with the XML marshaller, so body types control their
+// own element names and namespaces through xml struct tags.
+type Codec struct {
+	namespace  string
+	prefix     string
+	namespaces []xml.Attr
+	marshaller interfaces.IMarshaller
+}
+
+// NewCodec creates a Codec for SOAP 1.1 using the "soap" prefix.
+func NewCodec() *Codec {
+	return &Codec{
+		namespace:  EnvelopeNamespace,
+		prefix:     "soap",
+		marshaller: handler.NewXMLMarshaller(),
+	}
+}
+
+// WithEnvelopeNamespace sets the envelope namespace and its prefix.
+func (c *Codec) WithEnvelopeNamespace(prefix, namespace string) *Codec {
+	c.prefix = prefix
+	c.namespace = namespace
+	return c
+}
+
+// WithNamespace declares an additional namespace on the Envelope element,
+// e.g. WithNamespace("tns", "http://partner.example.com/quotes").
+func (c *Codec) WithNamespace(prefix, namespace string) *Codec {
+	c.namespaces = append(c.namespaces, xml.Attr{
+		Name:  xml.Name{Local: "xmlns:" + prefix},
+		Value: namespace,
+	})
+	return c
+}
+
+// Encode wraps body, and header if not nil, in a SOAP envelope.
+func (c *Codec) Encode(header, body interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	envelope := xml.StartElement{
+		Name: xml.Name{Local: c.prefix + ":Envelope"},
+		Attr: append([]xml.Attr{{Name: xml.Name{Local: "xmlns:" + c.prefix}, Value: c.namespace}}, c.namespaces...),
+	}
+	if err := c.writeStart(&buf, envelope); err != nil {
+		return nil, err
+	}
+	if header != nil {
+		if err := c.writeElement(&buf, "Header", header); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.writeElement(&buf, "Body", body); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&buf, "</%s:Envelope>", c.prefix)
+	return buf.Bytes(), nil
+}
+
+// writeStart writes a start element with its attributes.
+func (c *Codec) writeStart(w io.Writer, start xml.StartElement) error {
+	fmt.Fprintf(w, "<%s", start.Name.Local)
+	for _, attr := range start.Attr {
+		fmt.Fprintf(w, ` %s="`, attr.Name.Local)
+		if err := xml.EscapeText(w, []byte(attr.Value)); err != nil {
+			return err
+		}
+		io.WriteString(w, `"`)
+	}
+	_, err := io.WriteString(w, ">")
+	return err
+}
+
+// writeElement writes an envelope child element holding the marshalled content.
+func (c *Codec) writeElement(buf *bytes.Buffer, name string, content interface{}) error {
+	data, err := c.marshaller.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("failed to marshal SOAP %s: %w", name, err)
+	}
+	fmt.Fprintf(buf, "<%s:%s>", c.prefix, name)
+	buf.Write(data)
+	fmt.Fprintf(buf, "</%s:%s>", c.prefix, name)
+	return nil
+}
+
+// Request sets up builder to POST the envelope of header and body with the
+// given SOAPAction.
+func (c *Codec) Request(builder interfaces.IRequestBuilder, action string, header, body interface{}) (interfaces.IRequestBuilder, error) {
+	data, err := c.Encode(header, body)
+	if err != nil {
+		return builder, err
+	}
+	return builder.Method(http.MethodPost).
+		ContentType(ContentType).
+		Header("SOAPAction", fmt.Sprintf("%q", action)).
+		BodyBytes(data), nil
+}
+
+// Decode unwraps a SOAP envelope, decoding the first Body element into v.
+// If the body holds a Fault, a *Fault is returned instead. A nil v only
+// checks for a fault, so an empty Body is accepted.
+func (c *Codec) Decode(response interfaces.IHTTPResponse, data []byte, v interface{}) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	inBody := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return models.NewDecodeError("SOAP response has no Body", response, v, data, io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return models.NewDecodeError("failed to decode SOAP envelope", response, v, data, err)
+		}
+
+		switch t := token.(type) {
+		case xml.EndElement:
+			if inBody && c.isEnvelopeElement(t.Name, "Body") {
+				if v == nil {
+					return nil
+				}
+				return models.NewDecodeError("SOAP response has an empty Body", response, v, data, io.ErrUnexpectedEOF)
+			}
+		case xml.StartElement:
+			if !inBody {
+				inBody = c.isEnvelopeElement(t.Name, "Body")
+				continue
+			}
+			if c.isEnvelopeElement(t.Name, "Fault") {
+				fault := &faultXML{}
+				if err := decoder.DecodeElement(fault, &t); err != nil {
+					return models.NewDecodeError("failed to decode SOAP fault", response, fault, data, err)
+				}
+				return newFault(response, fault)
+			}
+			if v == nil {
+				return nil
+			}
+			if err := decoder.DecodeElement(v, &t); err != nil {
+				return models.NewDecodeError("failed to decode SOAP body", response, v, data, err)
+			}
+			return nil
+		}
+	}
+}
+
+// isEnvelopeElement reports whether name is the envelope element local.
+func (c *Codec) isEnvelopeElement(name xml.Name, local string) bool {
+	return name.Space == c.namespace && name.Local == local
+}
+
+// Handler returns a response handler that unwraps SOAP responses into a
+// value of responseType's type and returns faults as *Fault, including
+// faults sent with an error status. If no status codes are given, 200 and
+// 202 are accepted.
+func (c *Codec) Handler(responseType interface{}, acceptedStatusCodes ...int) interfaces.IResponseHandler {
+	if len(acceptedStatusCodes) == 0 {
+		acceptedStatusCodes = []int{http.StatusOK, http.StatusAccepted}
+	}
+	accepted := make(map[int]struct{}, len(acceptedStatusCodes))
+	for _, code := range acceptedStatusCodes {
+		accepted[code] = struct{}{}
+	}
+	return &responseHandler{
+		codec:        c,
+		responseType: reflect.TypeOf(responseType),
+		accepted:     accepted,
+	}
+}
+
+// responseHandler decodes SOAP responses.
+type responseHandler struct {
+	codec        *Codec
+	responseType reflect.Type
+	accepted     map[int]struct{}
+}
+
+// Ensure responseHandler implements IResponseHandler interface
+var _ interfaces.IResponseHandler = (*responseHandler)(nil)
+
+// Handle unwraps the envelope and decodes the body.
+func (h *responseHandler) Handle(response interfaces.IHTTPResponse) (interface{}, error) {
+	if response == nil {
+		return nil, fmt.Errorf("response is nil")
+	}
+	if _, ok := h.accepted[response.StatusCode()]; !ok {
+		return nil, h.HandleError(response)
+	}
+
+	body, err := response.Body()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if h.responseType == nil {
+		return nil, h.codec.Decode(response, body, nil)
+	}
+
+	result := reflect.New(h.responseType).Interface()
+	if err := h.codec.Decode(response, body, result); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(result).Elem().Interface(), nil
+}
+
+// HandleError returns the fault carried by an error response, or the
+// default status error if there is none.
+func (h *responseHandler) HandleError(response interfaces.IHTTPResponse) error {
+	if response == nil {
+		return fmt.Errorf("response is nil")
+	}
+
+	if body, err := response.Body(); err == nil {
+		var fault *Fault
+		if err := h.codec.Decode(response, body, nil); errors.As(err, &fault) {
+			return fault
+		}
+	}
+	return handler.NewStatusError(response)
+}
+
+// CanHandle returns true: faults are extracted from error responses too.
+func (h *responseHandler) CanHandle(response interfaces.IHTTPResponse) bool {
+	return response != nil
+}
@@ -0,0 +1,179 @@
+package soap
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"data-plane/internal/transport/http/builder"
+	"data-plane/internal/transport/interfaces"
+)
+
+const quotesNamespace = "http://partner.example.com/quotes"
+
+type getQuote struct {
+	XMLName xml.Name `xml:"tns:GetQuote"`
+	Symbol  string   `xml:"tns:Symbol"`
+}
+
+type authHeader struct {
+	XMLName xml.Name `xml:"tns:Auth"`
+	Token   string   `xml:"tns:Token"`
+}
+
+type getQuoteResponse struct {
+	XMLName xml.Name `xml:"http://partner.example.com/quotes GetQuoteResponse"`
+	Price   float64  `xml:"Price"`
+}
+
+// wireEnvelope decodes a received envelope, namespaces resolved.
+type wireEnvelope struct {
+	XMLName xml.Name
+	Header  struct {
+		Token string `xml:"http://partner.example.com/quotes Auth>Token"`
+	} `xml:"Header"`
+	Body struct {
+		Symbol string `xml:"http://partner.example.com/quotes GetQuote>Symbol"`
+	} `xml:"Body"`
+}
+
+const quoteResponse = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:q="http://partner.example.com/quotes">
+  <soap:Body><q:GetQuoteResponse><q:Price>12.5</q:Price></q:GetQuoteResponse></soap:Body>
+</soap:Envelope>`
+
+const faultResponse = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <soap:Fault>
+      <faultcode>soap:Client</faultcode>
+      <faultstring>Unknown symbol</faultstring>
+      <detail><q:Symbol xmlns:q="http://partner.example.com/quotes">ZZZZ</q:Symbol></detail>
+    </soap:Fault>
+  </soap:Body>
+</soap:Envelope>`
+
+// soapFixture is an httptest SOAP endpoint that records the last request
+// and answers with a fixed status and envelope.
+type soapFixture struct {
+	*httptest.Server
+	status   int
+	response string
+
+	action, contentType string
+	envelope            wireEnvelope
+}
+
+// newSOAPFixture starts a fixture answering status with response.
+func newSOAPFixture(t *testing.T, status int, response string) *soapFixture {
+	t.Helper()
+	f := &soapFixture{status: status, response: response}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.action = r.Header.Get("SOAPAction")
+		f.contentType = r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		f.envelope = wireEnvelope{}
+		if err := xml.Unmarshal(data, &f.envelope); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		w.WriteHeader(f.status)
+		io.WriteString(w, f.response)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// call sends a GetQuote for symbol through codec and handles the response.
+func (f *soapFixture) call(t *testing.T, codec *Codec, symbol string) (interface{}, error) {
+	t.Helper()
+	request, err := codec.Request(builder.NewBuilder().Scheme("http").Host(f.Listener.Addr().String()).Path("/quotes"),
+		"urn:GetQuote", authHeader{Token: "secret"}, getQuote{Symbol: symbol})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := request.Sync()
+	if resp == nil {
+		t.Fatalf("no response: %v", err)
+	}
+	return codec.Handler(getQuoteResponse{}).Handle(resp)
+}
+
+func TestSOAPRoundTrip(t *testing.T) {
+	fixture := newSOAPFixture(t, http.StatusOK, quoteResponse)
+	codec := NewCodec().WithNamespace("tns", quotesNamespace)
+
+	v, err := fixture.call(t, codec, "ACME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.(getQuoteResponse).Price; got != 12.5 {
+		t.Errorf("Price = %v, want 12.5", got)
+	}
+
+	if fixture.action != `"urn:GetQuote"` || fixture.contentType != ContentType {
+		t.Errorf("headers SOAPAction %s, Content-Type %q", fixture.action, fixture.contentType)
+	}
+	envelope := fixture.envelope
+	if envelope.XMLName.Space != EnvelopeNamespace || envelope.XMLName.Local != "Envelope" {
+		t.Errorf("root element = %v, want a SOAP 1.1 Envelope", envelope.XMLName)
+	}
+	if envelope.Header.Token != "secret" || envelope.Body.Symbol != "ACME" {
+		t.Errorf("server decoded %+v, want the header token and body symbol", envelope)
+	}
+}
+
+func TestSOAPEnvelopeNamespace(t *testing.T) {
+	const soap12 = "http://www.w3.org/2003/05/soap-envelope"
+	fixture := newSOAPFixture(t, http.StatusOK,
+		strings.ReplaceAll(quoteResponse, EnvelopeNamespace, soap12))
+	codec := NewCodec().WithEnvelopeNamespace("env", soap12).WithNamespace("tns", quotesNamespace)
+
+	v, err := fixture.call(t, codec, "ACME")
+	if err != nil || v.(getQuoteResponse).Price != 12.5 {
+		t.Fatalf("Handle = %+v, %v", v, err)
+	}
+	if fixture.envelope.XMLName.Space != soap12 {
+		t.Errorf("envelope namespace = %q, want %q", fixture.envelope.XMLName.Space, soap12)
+	}
+
+	// A SOAP 1.1 response does not match the configured namespace
+	fixture.response = quoteResponse
+	if _, err := fixture.call(t, codec, "ACME"); err == nil {
+		t.Error("decoded an envelope in the wrong namespace")
+	}
+}
+
+func TestSOAPFault(t *testing.T) {
+	fixture := newSOAPFixture(t, http.StatusInternalServerError, faultResponse)
+	codec := NewCodec().WithNamespace("tns", quotesNamespace)
+
+	v, err := fixture.call(t, codec, "ZZZZ")
+	var fault *Fault
+	if !errors.As(err, &fault) {
+		t.Fatalf("Handle error = %T %v, want *Fault", err, err)
+	}
+	if v != nil {
+		t.Errorf("result = %v alongside a fault", v)
+	}
+	if fault.Code != "soap:Client" || fault.String != "Unknown symbol" || !strings.Contains(fault.Detail, ">ZZZZ</q:Symbol>") {
+		t.Errorf("fault = %q %q %q", fault.Code, fault.String, fault.Detail)
+	}
+	if !fault.IsClientFault() || fault.Kind() != interfaces.KindClientStatus || fault.StatusCode != http.StatusInternalServerError {
+		t.Errorf("fault kind %v, status %d", fault.Kind(), fault.StatusCode)
+	}
+	if fault.Error() != "SOAP fault soap:Client: Unknown symbol" {
+		t.Errorf("Error() = %q", fault.Error())
+	}
+
+	// A fault with a success status is reported the same way
+	fixture.status = http.StatusOK
+	if _, err := fixture.call(t, codec, "ZZZZ"); !errors.As(err, &fault) {
+		t.Errorf("200 fault: error %v, want *Fault", err)
+	}
+}
@@ -0,0 +1,91 @@
+package soap
+
+import (
+	"fmt"
+	"strings"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// Fault is returned when a SOAP response body carries a Fault element.
+// Client faults are classified as KindClientStatus, all others as
+// KindServerStatus.
+// It implements the IHTTPError interface.
+type Fault struct {
+	models.HTTPError
+
+	// Code is the faultcode, e.g. "soap:Client"
+	Code string
+
+	// String is the faultstring
+	String string
+
+	// Actor is the faultactor, if any
+	Actor string
+
+	// Detail is the raw inner XML of the detail element, if any
+	Detail string
+}
+
+// Ensure Fault implements IHTTPError interface
+var _ interfaces.IHTTPError = (*Fault)(nil)
+
+// faultXML is the wire form of a SOAP 1.1 Fault.
+type faultXML struct {
+	Code   string `xml:"faultcode"`
+	String string `xml:"faultstring"`
+	Actor  string `xml:"faultactor"`
+	Detail struct {
+		Content string `xml:",innerxml"`
+	} `xml:"detail"`
+}
+
+// newFault describes the fault f of response.
+func newFault(response interfaces.IHTTPResponse, f *faultXML) *Fault {
+	fault := &Fault{
+		HTTPError: models.HTTPError{
+			Response: response,
+			Message:  "SOAP fault",
+			KindVal:  faultKind(f.Code),
+		},
+		Code:   strings.TrimSpace(f.Code),
+		String: strings.TrimSpace(f.String),
+		Actor:  strings.TrimSpace(f.Actor),
+		Detail: strings.TrimSpace(f.Detail.Content),
+	}
+	if response != nil {
+		fault.Request = response.Request()
+		fault.StatusCode = response.StatusCode()
+		fault.RequestID = models.RequestIDOf(response.Request(), response)
+	}
+	return fault
+}
+
+// Error implements the error interface for Fault.
+func (e *Fault) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Message, e.Code, e.String)
+}
+
+// Format implements fmt.Formatter; see models.HTTPError.Format.
+func (e *Fault) Format(f fmt.State, verb rune) {
+	models.FormatError(f, verb, e)
+}
+
+// IsClientFault returns true if the fault blames the request.
+func (e *Fault) IsClientFault() bool {
+	return e.KindVal == interfaces.KindClientStatus
+}
+
+// faultKind classifies a faultcode: Client (SOAP 1.1) and Sender (SOAP 1.2)
+// blame the request, anything else the server.
+func faultKind(code string) interfaces.ErrorKind {
+	code = strings.TrimSpace(code)
+	if i := strings.LastIndex(code, ":"); i >= 0 {
+		code = code[i+1:]
+	}
+	if code == "Client" || code == "Sender" || strings.HasPrefix(code, "Client.") {
+		return interfaces.KindClientStatus
+	}
+	return interfaces.KindServerStatus
+}
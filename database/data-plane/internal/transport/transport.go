@@ -4,15 +4,22 @@ package transport
 // This package provides a consistent interface for HTTP, gRPC, HTTPS, and other protocols.
 
 import (
+	"log"
 	"time"
 
+	"data-plane/internal/transport/grpc"
 	"data-plane/internal/transport/http/builder"
 	"data-plane/internal/transport/http/client"
 	"data-plane/internal/transport/http/handler"
 	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/http/poll"
 	"data-plane/internal/transport/interfaces"
 	"data-plane/internal/transport/middleware"
+	"data-plane/internal/transport/openapi"
 	"data-plane/internal/transport/resiliency"
+	"data-plane/internal/transport/soap"
+	"data-plane/internal/transport/webhook"
+	"data-plane/internal/transport/ws"
 )
 
 // ============= HTTP PROTOCOL =============
@@ -20,14 +27,25 @@ import (
 // HTTP provides convenient access to HTTP client components
 type HTTP struct{}
 
-// NewHTTPClient creates a new HTTP client with default configuration
-func (HTTP) NewClient() interfaces.IHTTPClient {
-	return client.NewHTTPClient()
+// NewHTTPClient creates a new HTTP client with default configuration.
+// Like every client created here it can be drained on shutdown.
+func (HTTP) NewClient() interfaces.IDrainableClient {
+	return middleware.NewDrainDecorator(client.NewHTTPClient())
 }
 
 // NewHTTPClientWithTimeout creates a new HTTP client with the specified timeout
-func (HTTP) NewClientWithTimeout(timeout time.Duration) interfaces.IHTTPClient {
-	return client.NewHTTPClientWithTimeout(timeout)
+func (HTTP) NewClientWithTimeout(timeout time.Duration) interfaces.IDrainableClient {
+	return middleware.NewDrainDecorator(client.NewHTTPClientWithTimeout(timeout))
+}
+
+// NewClientWithHTTP3 creates a new HTTP client that sends HTTPS requests over
+// HTTP/3 with fallback to HTTP/2 or HTTP/1.1 (requires the http3 build tag)
+func (HTTP) NewClientWithHTTP3() (interfaces.IDrainableClient, error) {
+	httpClient := client.NewHTTPClient().(*client.HTTPClient)
+	if err := httpClient.EnableHTTP3(); err != nil {
+		return nil, err
+	}
+	return middleware.NewDrainDecorator(httpClient), nil
 }
 
 // NewBuilder creates a new HTTP request builder
@@ -40,6 +58,72 @@ func (HTTP) NewResponseHandler() *handler.ResponseHandlerBuilder {
 	return handler.NewResponseHandler()
 }
 
+// NewLongPoller creates a long poller that re-sends request with the token
+// extracted from each response in the query parameter param
+func (HTTP) NewLongPoller(client interfaces.IHTTPClient, request interfaces.IHTTPRequest, param string, extract poll.Extractor) *poll.LongPoller {
+	return poll.NewLongPoller(client, request, param, extract)
+}
+
+// ============= GRPC PROTOCOL =============
+
+// GRPC provides convenient access to gRPC client components
+type GRPC struct{}
+
+// NewBuilder creates a new gRPC connection builder
+func (GRPC) NewBuilder() *grpc.ConnBuilder {
+	return grpc.NewBuilder()
+}
+
+// NewCall creates a unary call of method that decodes the response into reply
+func (GRPC) NewCall(method string, request, reply interface{}) *grpc.Call {
+	return grpc.NewCall(method, request, reply)
+}
+
+// ============= WEBSOCKET PROTOCOL =============
+
+// WebSocket provides convenient access to WebSocket client components
+type WebSocket struct{}
+
+// NewDialer creates a WebSocket dialer for a request built with the HTTP builder
+func (WebSocket) NewDialer(request interfaces.IHTTPRequest) *ws.Dialer {
+	return ws.NewDialer(request)
+}
+
+// ============= SOAP PROTOCOL =============
+
+// SOAP provides convenient access to SOAP envelope helpers
+type SOAP struct{}
+
+// NewCodec creates a SOAP 1.1 envelope codec
+func (SOAP) NewCodec() *soap.Codec {
+	return soap.NewCodec()
+}
+
+// ============= OPENAPI =============
+
+// OpenAPI provides convenient access to OpenAPI-driven request helpers
+type OpenAPI struct{}
+
+// LoadSpec loads and validates an OpenAPI 3 document (JSON or YAML)
+func (OpenAPI) LoadSpec(path string) (*openapi.Spec, error) {
+	return openapi.LoadSpec(path)
+}
+
+// NewContractValidator wraps client with request/response validation against spec
+func (OpenAPI) NewContractValidator(client interfaces.IHTTPClient, spec *openapi.Spec, mode openapi.ContractMode, logger *log.Logger) *openapi.ContractValidator {
+	return openapi.NewContractValidator(client, spec, mode, logger)
+}
+
+// ============= WEBHOOKS =============
+
+// Webhook provides convenient access to the signed webhook sender
+type Webhook struct{}
+
+// NewSender creates a webhook sender with retries and per-host circuit breakers
+func (Webhook) NewSender(client interfaces.IHTTPClient, policy interfaces.IRetryPolicy, breakers interfaces.ICircuitBreakerRegistry) *webhook.Sender {
+	return webhook.NewSender(client, policy, breakers)
+}
+
 // ============= RESILIENCY (Protocol-Agnostic) =============
 
 // Resiliency provides resiliency patterns that work with any protocol
@@ -65,6 +149,21 @@ func (Resiliency) NewBulkhead(maxConcurrency int) *resiliency.Bulkhead {
 	return resiliency.NewBulkhead(maxConcurrency)
 }
 
+// NewBulkheadWithWait creates a bulkhead whose callers wait up to maxWait for a slot
+func (Resiliency) NewBulkheadWithWait(maxConcurrency int, maxWait time.Duration) *resiliency.Bulkhead {
+	return resiliency.NewBulkheadWithWait(maxConcurrency, maxWait)
+}
+
+// NewRateLimiterRegistry creates a registry of per-host rate limiters
+func (Resiliency) NewRateLimiterRegistry(rate float64, burst int) *resiliency.RateLimiterRegistry {
+	return resiliency.NewRateLimiterRegistry(rate, burst)
+}
+
+// NewCircuitBreakerRegistry creates a registry of per-host circuit breakers
+func (Resiliency) NewCircuitBreakerRegistry(failureThreshold int, timeout time.Duration) *resiliency.CircuitBreakerRegistry {
+	return resiliency.NewCircuitBreakerRegistry(failureThreshold, timeout)
+}
+
 // ============= MIDDLEWARE (Protocol-Agnostic) =============
 
 // Middleware provides middleware components that work with any protocol
@@ -80,18 +179,232 @@ func (Middleware) NewMetricsMiddleware() *middleware.MetricsMiddleware {
 	return middleware.NewMetricsMiddleware()
 }
 
+// NewRequestIDMiddleware creates a middleware that stamps requests with an X-Request-Id header
+func (Middleware) NewRequestIDMiddleware() *middleware.RequestIDMiddleware {
+	return middleware.NewRequestIDMiddleware("", nil)
+}
+
 // NewAsyncRequest creates an async request handler
 func (Middleware) NewAsyncRequest(client interfaces.IHTTPClient) *middleware.AsyncRequest {
 	return middleware.NewAsyncRequest(client)
 }
 
+// NewRequestPool creates a shared worker pool with a bounded queue
+func (Middleware) NewRequestPool(client interfaces.IHTTPClient, workers, queueSize int, opts ...middleware.BatchOption) *middleware.RequestPool {
+	return middleware.NewRequestPool(client, workers, queueSize, opts...)
+}
+
 // ============= TYPE ALIASES FOR CONVENIENCE =============
 
 // HTTP Models
 type (
-	HTTPRequest  = models.Request
-	HTTPResponse = models.Response
-	HTTPError    = models.HTTPError
+	HTTPRequest           = models.Request
+	HTTPResponse          = models.Response
+	HTTPError             = models.HTTPError
+	Checksum              = models.Checksum
+	ChecksumAlgorithm     = models.ChecksumAlgorithm
+	ChecksumMismatchError = models.ChecksumMismatchError
+	RetryExhaustedError   = models.RetryExhaustedError
+	DecodeError           = models.DecodeError
+	ProblemDetails        = models.ProblemDetails
+	TLSDetails            = models.TLSDetails
+	TLSReason             = models.TLSReason
+	GraphQLRequest        = models.GraphQLRequest
+	GraphQLResponse       = models.GraphQLResponse
+	GraphQLError          = models.GraphQLError
+	GraphQLErrorDetail    = models.GraphQLErrorDetail
+	LongPoller            = poll.LongPoller
+	PollResult            = poll.Result
+	PollExtractor         = poll.Extractor
+)
+
+// gRPC Models
+type (
+	GRPCConn        = grpc.Conn
+	GRPCCall        = grpc.Call
+	GRPCStatusError = grpc.StatusError
+)
+
+// WebSocket Models
+type (
+	WSDialer     = ws.Dialer
+	WSConn       = ws.Conn
+	WSCloseError = ws.CloseError
+)
+
+// SOAP Models
+type (
+	SOAPCodec = soap.Codec
+	SOAPFault = soap.Fault
+)
+
+// OpenAPI Models
+type (
+	OpenAPISpec      = openapi.Spec
+	OpenAPIOperation = openapi.Operation
+	ContractError    = openapi.ContractError
+	ContractMode     = openapi.ContractMode
+	Violation        = openapi.Violation
+)
+
+// Contract validation modes
+const (
+	ContractEnforce = openapi.ContractEnforce
+	ContractLogOnly = openapi.ContractLogOnly
+)
+
+// Webhook Models
+type (
+	WebhookSender   = webhook.Sender
+	WebhookEndpoint = webhook.Endpoint
+	WebhookDelivery = webhook.Delivery
+)
+
+// Checksum algorithms supported for response integrity verification
+const (
+	ChecksumMD5    = models.ChecksumMD5
+	ChecksumSHA1   = models.ChecksumSHA1
+	ChecksumSHA256 = models.ChecksumSHA256
+)
+
+// TLS failure reasons reported by HTTPError.TLSDetails
+const (
+	TLSReasonUnknown          = models.TLSReasonUnknown
+	TLSReasonExpired          = models.TLSReasonExpired
+	TLSReasonHostnameMismatch = models.TLSReasonHostnameMismatch
+	TLSReasonUnknownAuthority = models.TLSReasonUnknownAuthority
+	TLSReasonHandshakeTimeout = models.TLSReasonHandshakeTimeout
+)
+
+// Error kinds reported by HTTPError.Kind
+type ErrorKind = interfaces.ErrorKind
+
+const (
+	KindUnknown      = interfaces.KindUnknown
+	KindTimeout      = interfaces.KindTimeout
+	KindCanceled     = interfaces.KindCanceled
+	KindDNS          = interfaces.KindDNS
+	KindConnection   = interfaces.KindConnection
+	KindTLS          = interfaces.KindTLS
+	KindClientStatus = interfaces.KindClientStatus
+	KindServerStatus = interfaces.KindServerStatus
+	KindRateLimited  = interfaces.KindRateLimited
+	KindCircuitOpen  = interfaces.KindCircuitOpen
+	KindBulkhead     = interfaces.KindBulkhead
+	KindDecode       = interfaces.KindDecode
+	KindGraphQL      = interfaces.KindGraphQL
+	KindContract     = interfaces.KindContract
+	KindDraining     = interfaces.KindDraining
+)
+
+// Request stages reported by HTTPError.Stage
+type ErrorStage = interfaces.ErrorStage
+
+const (
+	StageUnknown       = interfaces.StageUnknown
+	StageRateLimitWait = interfaces.StageRateLimitWait
+	StageBackoff       = interfaces.StageBackoff
+	StageRequest       = interfaces.StageRequest
+	StageReadBody      = interfaces.StageReadBody
+)
+
+// RequestIDHeader is the header carrying the request/correlation ID
+const RequestIDHeader = models.RequestIDHeader
+
+// IdempotencyKeyHeader is the header set by WithIdempotencyKey and WithAutoIdempotencyKey
+const IdempotencyKeyHeader = models.IdempotencyKeyHeader
+
+// AsHTTPError finds the HTTPError in err's chain, including errors wrapped by retries
+func AsHTTPError(err error) (*HTTPError, bool) {
+	return models.AsHTTPError(err)
+}
+
+// ClassifyError returns the category of any error produced by the transport
+func ClassifyError(err error) ErrorKind {
+	return models.Classify(err)
+}
+
+// DefaultErrorBodyLimit is how many bytes of an error response body HTTPError.BodySnippet captures by default
+const DefaultErrorBodyLimit = models.DefaultErrorBodyLimit
+
+// ProblemContentType is the media type of an RFC 7807 problem document
+const ProblemContentType = models.ProblemContentType
+
+// ErrorToProblemDetails describes err as an RFC 7807 problem+json document with secrets redacted
+func ErrorToProblemDetails(err error) ProblemDetails {
+	return models.NewProblemDetails(err)
+}
+
+// RedactURL masks credentials and sensitive query parameters in a URL for logging
+func RedactURL(rawURL string) string {
+	return models.RedactURL(rawURL)
+}
+
+// RedactQueryParams adds query parameters whose values are masked in errors and logs
+func RedactQueryParams(names ...string) {
+	models.RedactQueryParams(names...)
+}
+
+// ============= SENTINEL ERRORS =============
+
+var (
+	// ErrCircuitOpen is returned when an open circuit breaker rejects a request
+	ErrCircuitOpen = resiliency.ErrCircuitOpen
+
+	// ErrBulkheadFull is returned when a bulkhead has no free slot
+	ErrBulkheadFull = resiliency.ErrBulkheadFull
+
+	// ErrBulkheadTimeout is returned when no bulkhead slot frees up in time
+	ErrBulkheadTimeout = resiliency.ErrBulkheadTimeout
+
+	// ErrRateLimited is returned when a rate limiter cannot admit a request in time
+	ErrRateLimited = resiliency.ErrRateLimited
+
+	// ErrChecksumMismatch is returned when a response body fails integrity verification
+	ErrChecksumMismatch = models.ErrChecksumMismatch
+
+	// ErrNotFound matches HTTP 404 errors
+	ErrNotFound = models.ErrNotFound
+
+	// ErrUnauthorized matches HTTP 401 errors
+	ErrUnauthorized = models.ErrUnauthorized
+
+	// ErrConflict matches HTTP 409 errors
+	ErrConflict = models.ErrConflict
+
+	// ErrTooManyRequests matches HTTP 429 errors
+	ErrTooManyRequests = models.ErrTooManyRequests
+
+	// ErrNotModified matches HTTP 304 responses to conditional requests
+	ErrNotModified = models.ErrNotModified
+
+	// ErrPoolQueueFull is returned when a request pool cannot accept more work
+	ErrPoolQueueFull = middleware.ErrPoolQueueFull
+
+	// ErrPoolClosed is returned when submitting to a shut-down request pool
+	ErrPoolClosed = middleware.ErrPoolClosed
+
+	// ErrQuorumNotReached is returned when a scatter-gather cannot reach its quorum
+	ErrQuorumNotReached = middleware.ErrQuorumNotReached
+
+	// ErrBatchAborted is reported when a batch error policy stops a batch
+	ErrBatchAborted = middleware.ErrBatchAborted
+
+	// ErrBatchDeadline is reported when a batch exceeds its BatchTimeout
+	ErrBatchDeadline = middleware.ErrBatchDeadline
+
+	// ErrHTTP3Unsupported is returned when HTTP/3 is requested without the http3 build tag
+	ErrHTTP3Unsupported = client.ErrHTTP3Unsupported
+
+	// ErrWSClosed is returned by WebSocket reads and writes after Close
+	ErrWSClosed = ws.ErrClosed
+
+	// ErrClientDraining is returned by a client's Send after Drain or Close
+	ErrClientDraining = middleware.ErrClientDraining
+
+	// ErrBodyNotReplayable is reported when a request whose body was streamed
+	// from a one-shot reader has to be retried
+	ErrBodyNotReplayable = models.ErrBodyNotReplayable
 )
 
 // HTTP Client types
@@ -103,12 +416,25 @@ type (
 // Builder types
 type (
 	RequestBuilder = builder.RequestBuilder
+	SliceStyle     = interfaces.SliceStyle
+)
+
+// Query parameter list styles for QueryParamSlice
+const (
+	SliceRepeat = interfaces.SliceRepeat
+	SliceComma  = interfaces.SliceComma
+	SlicePipe   = interfaces.SlicePipe
 )
 
 // Handler types
 type (
-	ResponseHandler = handler.ResponseHandler
-	JSONMarshaller  = handler.JSONMarshaller
+	ResponseHandler              = handler.ResponseHandler
+	JSONMarshaller               = handler.JSONMarshaller
+	XMLMarshaller                = handler.XMLMarshaller
+	ProtoMarshaller              = handler.ProtoMarshaller
+	NotProtoMessageError         = handler.NotProtoMessageError
+	ResponseStatus               = handler.ResponseStatus
+	StatusMapExceptionMarshaller = handler.StatusMapExceptionMarshaller
 )
 
 // Resiliency types (Protocol-agnostic)
@@ -117,6 +443,9 @@ type (
 	CircuitBreaker = resiliency.CircuitBreaker
 	RateLimiter    = resiliency.RateLimiter
 	Bulkhead       = resiliency.Bulkhead
+
+	RateLimiterRegistry    = resiliency.RateLimiterRegistry
+	CircuitBreakerRegistry = resiliency.CircuitBreakerRegistry
 )
 
 // Middleware types (Protocol-agnostic)
@@ -126,6 +455,22 @@ type (
 	AuthMiddleware    = middleware.AuthMiddleware
 	TracingMiddleware = middleware.TracingMiddleware
 	AsyncRequest      = middleware.AsyncRequest
+	DrainDecorator    = middleware.DrainDecorator
+	RequestPool       = middleware.RequestPool
+	PoolMetrics       = middleware.PoolMetrics
+	FanOutResult      = middleware.FanOutResult
+	PipelineOptions   = middleware.PipelineOptions
+	PipelineResult    = middleware.PipelineResult
+
+	ScatterOption       = middleware.ScatterOption
+	ScatterGatherResult = middleware.ScatterGatherResult
+
+	BatchOption   = middleware.BatchOption
+	BatchProgress = middleware.BatchProgress
+	Batch         = middleware.Batch
+	ErrorPolicy   = middleware.ErrorPolicy
+	BatchStats    = middleware.BatchStats
+	BatchSummary  = middleware.BatchSummary
 )
 
 // ============= CONVENIENT GLOBALS =============
@@ -134,6 +479,21 @@ var (
 	// HTTPTransport provides HTTP-specific functions
 	HTTPTransport = HTTP{}
 
+	// GRPCTransport provides gRPC-specific functions
+	GRPCTransport = GRPC{}
+
+	// WebSocketTransport provides WebSocket-specific functions
+	WebSocketTransport = WebSocket{}
+
+	// SOAPTransport provides SOAP-specific functions
+	SOAPTransport = SOAP{}
+
+	// OpenAPIFeatures provides OpenAPI-driven request helpers
+	OpenAPIFeatures = OpenAPI{}
+
+	// WebhookTransport provides webhook delivery functions
+	WebhookTransport = Webhook{}
+
 	// ResiliencyFeatures provides protocol-agnostic resiliency
 	ResiliencyFeatures = Resiliency{}
 
@@ -144,12 +504,12 @@ var (
 // ============= CONVENIENCE FUNCTIONS (Backward Compatible) =============
 
 // NewHTTPClient creates a new HTTP client
-func NewHTTPClient() interfaces.IHTTPClient {
+func NewHTTPClient() interfaces.IDrainableClient {
 	return HTTPTransport.NewClient()
 }
 
 // NewHTTPClientWithTimeout creates an HTTP client with timeout
-func NewHTTPClientWithTimeout(timeout time.Duration) interfaces.IHTTPClient {
+func NewHTTPClientWithTimeout(timeout time.Duration) interfaces.IDrainableClient {
 	return HTTPTransport.NewClientWithTimeout(timeout)
 }
 
@@ -163,6 +523,32 @@ func NewHTTPResponseHandler() *handler.ResponseHandlerBuilder {
 	return HTTPTransport.NewResponseHandler()
 }
 
+// StringHandler creates a handler that returns the response body as a string
+func StringHandler(acceptedStatusCodes ...int) interfaces.IResponseHandler {
+	return handler.String(acceptedStatusCodes...)
+}
+
+// GraphQLHandler creates a handler that unwraps a GraphQL response and
+// decodes its data into dataType's type, reporting errors as GraphQLError
+func GraphQLHandler(dataType interface{}, acceptedStatusCodes ...int) interfaces.IResponseHandler {
+	return handler.GraphQL(dataType, acceptedStatusCodes...)
+}
+
+// BytesHandler creates a handler that returns the response body as bytes
+func BytesHandler(acceptedStatusCodes ...int) interfaces.IResponseHandler {
+	return handler.Bytes(acceptedStatusCodes...)
+}
+
+// DiscardHandler creates a handler that drains the body and returns only status metadata
+func DiscardHandler(acceptedStatusCodes ...int) interfaces.IResponseHandler {
+	return handler.Discard(acceptedStatusCodes...)
+}
+
+// NewStatusMapExceptionMarshaller creates the default status-to-error exception marshaller
+func NewStatusMapExceptionMarshaller() *handler.StatusMapExceptionMarshaller {
+	return handler.NewStatusMapExceptionMarshaller()
+}
+
 // NewRetryPolicy creates a retry policy
 func NewRetryPolicy(maxAttempts int) *resiliency.RetryPolicy {
 	return ResiliencyFeatures.NewRetryPolicy(maxAttempts)
@@ -0,0 +1,116 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+	"data-plane/internal/transport/middleware"
+)
+
+func TestStatusSentinelsThroughSendWithHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/locked":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/taken":
+			w.WriteHeader(http.StatusConflict)
+		case "/busy":
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusTeapot)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient()
+	handler := NewHTTPResponseHandler().WithResponseType(map[string]any{}).Build()
+	send := func(path string) error {
+		t.Helper()
+		request, err := HTTPTransport.NewBuilder().GET().BaseURL(server.URL).Path(path).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.SendWithHandler(request, handler)
+		return err
+	}
+
+	for path, sentinel := range map[string]error{
+		"/missing": ErrNotFound,
+		"/locked":  ErrUnauthorized,
+		"/taken":   ErrConflict,
+		"/busy":    ErrTooManyRequests,
+	} {
+		if err := send(path); !errors.Is(err, sentinel) {
+			t.Errorf("%s: error %v does not match %v", path, err, sentinel)
+		}
+	}
+
+	err := send("/busy")
+	if httpErr, ok := AsHTTPError(err); !ok || httpErr.RetryAfter != 7*time.Second {
+		t.Errorf("429 error = %v, want Retry-After of 7s", err)
+	}
+
+	// Unmapped codes fall back to an HTTPError matching none of the sentinels
+	err = send("/teapot")
+	if httpErr, ok := AsHTTPError(err); !ok || httpErr.StatusCode != http.StatusTeapot {
+		t.Errorf("418 error = %v, want an HTTPError with the status", err)
+	}
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) {
+		t.Errorf("418 error %v matches a status sentinel", err)
+	}
+}
+
+func TestAsHTTPErrorThroughWrappers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	send := func(client interfaces.IHTTPClient) error {
+		t.Helper()
+		request, err := HTTPTransport.NewBuilder().GET().BaseURL(server.URL).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.Send(request)
+		return err
+	}
+	retrying := middleware.NewRetryDecorator(NewHTTPClient(), ResiliencyFeatures.NewRetryPolicy(2))
+
+	for name, err := range map[string]error{
+		"plain":           send(NewHTTPClient()),
+		"retry exhausted": send(retrying),
+		"fmt wrapped":     fmt.Errorf("fetching config: %w", send(NewHTTPClient())),
+	} {
+		// The facade alias and the models type are the same type
+		var facadeErr *HTTPError
+		var modelsErr *models.HTTPError
+		if !errors.As(err, &facadeErr) || !errors.As(err, &modelsErr) || facadeErr != modelsErr {
+			t.Errorf("%s: errors.As failed for %T %v", name, err, err)
+			continue
+		}
+		if httpErr, ok := AsHTTPError(err); !ok || httpErr != facadeErr || httpErr.StatusCode != http.StatusBadGateway {
+			t.Errorf("%s: AsHTTPError = %v, %v", name, httpErr, ok)
+		}
+		var iface interfaces.IHTTPError
+		if !errors.As(err, &iface) || iface.GetStatusCode() != http.StatusBadGateway {
+			t.Errorf("%s: error does not satisfy IHTTPError with the status", name)
+		}
+		if _, isType := err.(*HTTPError); name != "plain" && isType {
+			t.Errorf("%s: expected a wrapped error", name)
+		}
+	}
+
+	if _, ok := AsHTTPError(errors.New("plain")); ok {
+		t.Error("AsHTTPError matched an unrelated error")
+	}
+}
@@ -0,0 +1,307 @@
+// Package transporttest provides a stub HTTP server with a fluent
+// expectation API for testing code built on the transport package.
+package transporttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// StubServer is an httptest server that answers requests from a list of
+// expectations and records every call, so Verify can report expectations
+// that were not met and calls that were not expected.
+//
+//	srv := transporttest.NewStubServer(t)
+//	srv.Expect("GET", "/users/1").
+//		WithHeader("Authorization", "Bearer x").
+//		Reply(500, nil).
+//		ReplyJSON(200, user)
+//	... builder.Scheme(srv.Scheme()).Host(srv.Host()) ...
+//	srv.Verify(t)
+type StubServer struct {
+	server *httptest.Server
+
+	mu           sync.Mutex
+	expectations []*Expectation
+	unexpected   []string
+}
+
+// NewStubServer starts a StubServer that is closed when the test ends.
+func NewStubServer(tb testing.TB) *StubServer {
+	tb.Helper()
+	s := &StubServer{}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// URL returns the base URL of the server, e.g. "http://127.0.0.1:41234".
+func (s *StubServer) URL() string {
+	return s.server.URL
+}
+
+// Host returns the host:port of the server, for the builder's Host.
+func (s *StubServer) Host() string {
+	return s.server.Listener.Addr().String()
+}
+
+// Scheme returns the scheme of the server ("http").
+func (s *StubServer) Scheme() string {
+	return "http"
+}
+
+// Client returns an *http.Client configured for the server.
+func (s *StubServer) Client() *http.Client {
+	return s.server.Client()
+}
+
+// Close shuts the server down.
+func (s *StubServer) Close() {
+	s.server.Close()
+}
+
+// Expect registers an expectation for requests with the given method and
+// path. Expectations are matched in registration order; once an
+// expectation has used all its replies the next matching one is used.
+func (s *StubServer) Expect(method, path string) *Expectation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := &Expectation{
+		mu:      &s.mu,
+		method:  strings.ToUpper(method),
+		path:    path,
+		headers: make(http.Header),
+		query:   make(url.Values),
+	}
+	s.expectations = append(s.expectations, e)
+	return e
+}
+
+// Verify fails the test if an expectation has replies left or if a request
+// matched no expectation.
+func (s *StubServer) Verify(tb testing.TB) {
+	tb.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var problems []string
+	for _, e := range s.expectations {
+		if e.calls < len(e.replies) {
+			problems = append(problems, fmt.Sprintf("unmet: %s (called %d of %d times)", e, e.calls, len(e.replies)))
+		}
+	}
+	for _, call := range s.unexpected {
+		problems = append(problems, "unexpected: "+call)
+	}
+	if len(problems) > 0 {
+		tb.Errorf("stub server expectations failed:\n  %s", strings.Join(problems, "\n  "))
+	}
+}
+
+// serve answers a request from the first matching expectation.
+func (s *StubServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	var (
+		reply   *stubReply
+		latency time.Duration
+	)
+	for _, e := range s.expectations {
+		if !e.matches(r) {
+			continue
+		}
+		if reply = e.next(); reply != nil {
+			latency = e.latency
+			break
+		}
+	}
+	if reply == nil {
+		s.unexpected = append(s.unexpected, describeRequest(r))
+	}
+	s.mu.Unlock()
+
+	if reply == nil {
+		http.Error(w, "transporttest: unexpected request "+describeRequest(r), http.StatusNotImplemented)
+		return
+	}
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	for key, values := range reply.headers {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(reply.status)
+	w.Write(reply.body)
+}
+
+// describeRequest formats a request for reports.
+func describeRequest(r *http.Request) string {
+	return r.Method + " " + r.URL.RequestURI()
+}
+
+// Expectation describes the requests an expectation matches and the
+// replies it sends, in order.
+type Expectation struct {
+	mu      *sync.Mutex
+	method  string
+	path    string
+	headers http.Header
+	query   url.Values
+	latency time.Duration
+	replies []*stubReply
+	repeat  bool
+	calls   int
+}
+
+// stubReply is one queued response.
+type stubReply struct {
+	status  int
+	headers http.Header
+	body    []byte
+}
+
+// WithHeader only matches requests carrying the header value.
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	e.headers.Add(key, value)
+	return e
+}
+
+// WithQuery only matches requests carrying the query parameter value.
+func (e *Expectation) WithQuery(key, value string) *Expectation {
+	e.query.Add(key, value)
+	return e
+}
+
+// WithLatency delays every reply by d, or until the client gives up.
+func (e *Expectation) WithLatency(d time.Duration) *Expectation {
+	e.latency = d
+	return e
+}
+
+// Reply queues a reply with the given status and body. Each call queues
+// one more reply, so Reply(500, nil).Reply(200, body) answers the first
+// matching request with 500 and the second with 200.
+func (e *Expectation) Reply(status int, body []byte) *Expectation {
+	e.replies = append(e.replies, &stubReply{
+		status:  status,
+		headers: make(http.Header),
+		body:    body,
+	})
+	return e
+}
+
+// ReplyString queues a text/plain reply.
+func (e *Expectation) ReplyString(status int, body string) *Expectation {
+	return e.Reply(status, []byte(body)).ReplyHeader("Content-Type", "text/plain; charset=utf-8")
+}
+
+// ReplyJSON queues a reply with v encoded as JSON. It panics if v cannot
+// be encoded, as that is a bug in the test.
+func (e *Expectation) ReplyJSON(status int, v interface{}) *Expectation {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("transporttest: cannot encode reply: %v", err))
+	}
+	return e.Reply(status, body).ReplyHeader("Content-Type", "application/json")
+}
+
+// ReplyHeader adds a header to the most recently queued reply.
+func (e *Expectation) ReplyHeader(key, value string) *Expectation {
+	if len(e.replies) == 0 {
+		e.Reply(http.StatusOK, nil)
+	}
+	e.replies[len(e.replies)-1].headers.Add(key, value)
+	return e
+}
+
+// Repeatedly makes the last reply answer every further matching request.
+func (e *Expectation) Repeatedly() *Expectation {
+	e.repeat = true
+	return e
+}
+
+// Calls returns how many requests the expectation has answered.
+func (e *Expectation) Calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls
+}
+
+// String describes the expectation for reports.
+func (e *Expectation) String() string {
+	description := e.method + " " + e.path
+	if len(e.query) > 0 {
+		description += "?" + e.query.Encode()
+	}
+	for key := range e.headers {
+		description += fmt.Sprintf(" [%s: %s]", key, e.headers.Get(key))
+	}
+	return description
+}
+
+// matches reports whether r satisfies the expectation.
+func (e *Expectation) matches(r *http.Request) bool {
+	if r.Method != e.method || r.URL.Path != e.path {
+		return false
+	}
+	for key, values := range e.headers {
+		for _, value := range values {
+			if !containsValue(r.Header.Values(key), value) {
+				return false
+			}
+		}
+	}
+	query := r.URL.Query()
+	for key, values := range e.query {
+		for _, value := range values {
+			if !containsValue(query[key], value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// next returns the reply for the next call, or nil if none is left.
+func (e *Expectation) next() *stubReply {
+	if len(e.replies) == 0 {
+		return nil
+	}
+	if e.calls >= len(e.replies) {
+		if !e.repeat {
+			return nil
+		}
+		e.calls++
+		return e.replies[len(e.replies)-1]
+	}
+	reply := e.replies[e.calls]
+	e.calls++
+	return reply
+}
+
+// containsValue reports whether values contains value.
+func containsValue(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package transporttest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recorder is a testing.TB that records errors instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// call sends a request to srv and returns the status and body.
+func call(t *testing.T, srv *StubServer, method, target string, header http.Header) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL()+target, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestStubServerSequencedReplies(t *testing.T) {
+	srv := NewStubServer(t)
+	users := srv.Expect("get", "/users/1").
+		WithHeader("Authorization", "Bearer x").
+		ReplyString(http.StatusInternalServerError, "try again").
+		ReplyJSON(http.StatusOK, map[string]string{"name": "ada"}).
+		ReplyHeader("ETag", `"v1"`)
+
+	auth := http.Header{"Authorization": {"Bearer x"}}
+	if status, body := call(t, srv, "GET", "/users/1", auth); status != 500 || body != "try again" {
+		t.Errorf("first call = %d %q, want 500 %q", status, body, "try again")
+	}
+	if status, body := call(t, srv, "GET", "/users/1", auth); status != 200 || body != `{"name":"ada"}` {
+		t.Errorf("second call = %d %q, want 200 with the JSON user", status, body)
+	}
+	if users.Calls() != 2 {
+		t.Errorf("Calls = %d, want 2", users.Calls())
+	}
+	srv.Verify(t)
+
+	// The replies are used up, so a third call is unexpected
+	if status, _ := call(t, srv, "GET", "/users/1", auth); status != http.StatusNotImplemented {
+		t.Errorf("third call = %d, want 501", status)
+	}
+	rec := &recorder{TB: t}
+	srv.Verify(rec)
+	if len(rec.errors) != 1 || !strings.Contains(rec.errors[0], "unexpected: GET /users/1") {
+		t.Errorf("Verify reported %q, want the unexpected call", rec.errors)
+	}
+}
+
+func TestStubServerMatching(t *testing.T) {
+	srv := NewStubServer(t)
+	srv.Expect("GET", "/search").WithQuery("q", "go").WithQuery("tag", "b").ReplyString(200, "found").Repeatedly()
+	srv.Expect("GET", "/search").ReplyString(200, "fallback").Repeatedly()
+
+	for _, tc := range []struct {
+		target, want string
+	}{
+		{"/search?q=go&tag=a&tag=b", "found"},
+		{"/search?q=go", "fallback"},
+		{"/search?q=rust&tag=b", "fallback"},
+	} {
+		if _, body := call(t, srv, "GET", tc.target, nil); body != tc.want {
+			t.Errorf("GET %s answered %q, want %q", tc.target, body, tc.want)
+		}
+	}
+	if status, _ := call(t, srv, "POST", "/search", nil); status != http.StatusNotImplemented {
+		t.Errorf("POST /search = %d, want 501 for the unmatched method", status)
+	}
+}
+
+func TestStubServerRepeatedly(t *testing.T) {
+	srv := NewStubServer(t)
+	health := srv.Expect("GET", "/health").Reply(503, nil).Reply(200, nil).Repeatedly()
+
+	var statuses []int
+	for i := 0; i < 4; i++ {
+		status, _ := call(t, srv, "GET", "/health", nil)
+		statuses = append(statuses, status)
+	}
+	if fmt.Sprint(statuses) != "[503 200 200 200]" {
+		t.Errorf("statuses = %v, want [503 200 200 200]", statuses)
+	}
+	if health.Calls() != 4 {
+		t.Errorf("Calls = %d, want 4", health.Calls())
+	}
+	srv.Verify(t)
+}
+
+func TestStubServerVerifyReportsUnmet(t *testing.T) {
+	srv := NewStubServer(t)
+	srv.Expect("GET", "/users/1").WithHeader("Authorization", "Bearer x").Reply(500, nil).Reply(200, nil)
+	srv.Expect("DELETE", "/users/1").Reply(204, nil)
+
+	// Without the header the first expectation does not match
+	call(t, srv, "GET", "/users/1", nil)
+	call(t, srv, "GET", "/users/1", http.Header{"Authorization": {"Bearer x"}})
+
+	rec := &recorder{TB: t}
+	srv.Verify(rec)
+	if len(rec.errors) != 1 {
+		t.Fatalf("Verify reported %d errors, want 1", len(rec.errors))
+	}
+	for _, want := range []string{
+		"unmet: GET /users/1 [Authorization: Bearer x] (called 1 of 2 times)",
+		"unmet: DELETE /users/1 (called 0 of 1 times)",
+		"unexpected: GET /users/1",
+	} {
+		if !strings.Contains(rec.errors[0], want) {
+			t.Errorf("Verify report is missing %q:\n%s", want, rec.errors[0])
+		}
+	}
+}
+
+func TestStubServerLatency(t *testing.T) {
+	srv := NewStubServer(t)
+	srv.Expect("GET", "/slow").WithLatency(100*time.Millisecond).Reply(200, nil).Repeatedly()
+
+	start := time.Now()
+	if status, _ := call(t, srv, "GET", "/slow", nil); status != 200 {
+		t.Errorf("status = %d, want 200", status)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("reply took %v, want at least the 100ms latency", elapsed)
+	}
+
+	client := srv.Client()
+	client.Timeout = 20 * time.Millisecond
+	if _, err := client.Get(srv.URL() + "/slow"); err == nil {
+		t.Error("a client with a shorter timeout got a reply")
+	}
+}
+
+func TestStubServerAddress(t *testing.T) {
+	srv := NewStubServer(t)
+	if want := srv.Scheme() + "://" + srv.Host(); srv.URL() != want {
+		t.Errorf("URL = %s, want %s", srv.URL(), want)
+	}
+}
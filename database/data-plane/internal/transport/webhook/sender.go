@@ -0,0 +1,241 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// Endpoint is a webhook destination and the secret shared with it.
+type Endpoint struct {
+	// URL is the absolute URL deliveries are POSTed to
+	URL string
+
+	// Secret is the HMAC key used to sign deliveries
+	Secret string
+}
+
+// Delivery is the outcome of sending one webhook payload.
+type Delivery struct {
+	// ID is the delivery ID sent in HeaderID
+	ID string
+
+	// URL is the endpoint URL
+	URL string
+
+	// Attempts is the number of attempts, including any rejected by the circuit breaker
+	Attempts int
+
+	// StatusCode is the status of the last response, or 0 if none was received
+	StatusCode int
+
+	// Duration is the time spent on the delivery, including backoff
+	Duration time.Duration
+
+	// Err is nil if the endpoint accepted the delivery
+	Err error
+}
+
+// Succeeded reports whether the endpoint accepted the delivery.
+func (d *Delivery) Succeeded() bool {
+	return d.Err == nil
+}
+
+// Sender POSTs signed webhook payloads. Each delivery is retried according
+// to the retry policy, and every attempt runs through the circuit breaker
+// of the destination host, so one failing endpoint stops consuming
+// attempts without affecting the others.
+type Sender struct {
+	client   interfaces.IHTTPClient
+	policy   interfaces.IRetryPolicy
+	breakers interfaces.ICircuitBreakerRegistry
+	timeout  time.Duration
+	onResult func(*Delivery)
+}
+
+// NewSender creates a Sender. A nil policy makes a single attempt and a nil
+// breaker registry disables circuit breaking.
+func NewSender(client interfaces.IHTTPClient, policy interfaces.IRetryPolicy, breakers interfaces.ICircuitBreakerRegistry) *Sender {
+	return &Sender{
+		client:   client,
+		policy:   policy,
+		breakers: breakers,
+	}
+}
+
+// WithTimeout sets the timeout of each attempt.
+func (s *Sender) WithTimeout(timeout time.Duration) *Sender {
+	s.timeout = timeout
+	return s
+}
+
+// OnDelivery registers a callback invoked with the result of every delivery.
+func (s *Sender) OnDelivery(fn func(*Delivery)) *Sender {
+	s.onResult = fn
+	return s
+}
+
+// SendJSON encodes payload as JSON and delivers it to endpoint.
+func (s *Sender) SendJSON(ctx context.Context, endpoint Endpoint, payload interface{}) *Delivery {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return s.finish(&Delivery{
+			URL: endpoint.URL,
+			Err: &models.HTTPError{
+				Message: "failed to encode webhook payload",
+				Err:     err,
+				KindVal: interfaces.KindDecode,
+			},
+		})
+	}
+	return s.Send(ctx, endpoint, body)
+}
+
+// Send delivers the JSON document body to endpoint. The returned Delivery is also passed to
+// the OnDelivery callback.
+func (s *Sender) Send(ctx context.Context, endpoint Endpoint, body []byte) *Delivery {
+	delivery := &Delivery{
+		ID:  newDeliveryID(),
+		URL: endpoint.URL,
+	}
+
+	target, err := url.Parse(endpoint.URL)
+	if err != nil || target.Host == "" {
+		delivery.Err = &models.HTTPError{
+			Message: fmt.Sprintf("invalid webhook URL %q", endpoint.URL),
+			Err:     err,
+		}
+		return s.finish(delivery)
+	}
+
+	// The timestamp is fixed per delivery so the signature and ID agree
+	// across retries; receivers dedupe on the ID.
+	timestamp := time.Now().Unix()
+	signature := Sign(endpoint.Secret, delivery.ID, timestamp, body)
+
+	var breaker interfaces.ICircuitBreaker
+	if s.breakers != nil {
+		breaker = s.breakers.Breaker(target.Host)
+	}
+
+	start := time.Now()
+
+	maxAttempts := 1
+	if s.policy != nil {
+		maxAttempts = s.policy.MaxAttempts()
+	}
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			delivery.Err = &models.HTTPError{
+				Message:  "webhook delivery cancelled",
+				Err:      ctxErr,
+				StageVal: interfaces.StageBackoff,
+			}
+			break
+		}
+
+		delivery.Attempts++
+		statusCode, sendErr := s.attempt(ctx, breaker, endpoint.URL, delivery.ID, timestamp, signature, body)
+		delivery.StatusCode = statusCode
+		delivery.Err = sendErr
+		if sendErr == nil || s.policy == nil || !s.policy.ShouldRetry(sendErr, attempt) || attempt == maxAttempts-1 {
+			break
+		}
+
+		select {
+		case <-time.After(s.policy.GetDelay(attempt)):
+		case <-ctx.Done():
+		}
+	}
+
+	delivery.Duration = time.Since(start)
+	return s.finish(delivery)
+}
+
+// attempt makes one request. A fresh request is built every time so the
+// body can be re-sent, and the attempt timeout is applied to its context
+// since the HTTP client only enforces its own timeout.
+func (s *Sender) attempt(ctx context.Context, breaker interfaces.ICircuitBreaker, target, id string, timestamp int64, signature string, body []byte) (int, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, &models.HTTPError{
+			Message: "failed to create webhook request",
+			Err:     err,
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(HeaderID, id)
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, signature)
+
+	request := &models.Request{HTTPReq: httpReq, TimeoutVal: s.timeout}
+	send := func() (interfaces.IHTTPResponse, error) {
+		return s.client.Send(request)
+	}
+
+	var resp interfaces.IHTTPResponse
+	if breaker != nil {
+		resp, err = breaker.Execute(ctx, send)
+	} else {
+		resp, err = send()
+	}
+
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode()
+		resp.Close()
+	}
+	if err != nil {
+		return statusCode, wrapRejection(request, err)
+	}
+	return statusCode, nil
+}
+
+// finish invokes the callback, if any, and returns delivery.
+func (s *Sender) finish(delivery *Delivery) *Delivery {
+	if s.onResult != nil {
+		s.onResult(delivery)
+	}
+	return delivery
+}
+
+// wrapRejection wraps a circuit breaker rejection in an HTTPError carrying
+// the request, matching the errors produced by the HTTP decorators.
+func wrapRejection(request interfaces.IHTTPRequest, err error) error {
+	if _, ok := models.AsHTTPError(err); ok {
+		return err
+	}
+	return &models.HTTPError{
+		Request:  request,
+		Message:  "webhook delivery rejected",
+		Err:      err,
+		KindVal:  models.Classify(err),
+		StageVal: interfaces.StageRequest,
+	}
+}
+
+// newDeliveryID returns a random 16-byte hex delivery ID.
+func newDeliveryID() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return fmt.Sprintf("whk-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf[:])
+}
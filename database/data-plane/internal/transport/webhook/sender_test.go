@@ -0,0 +1,182 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"data-plane/internal/transport/http/client"
+	"data-plane/internal/transport/interfaces"
+	"data-plane/internal/transport/resiliency"
+)
+
+const testSecret = "whsec-test"
+
+// receiver records the deliveries of an endpoint that verifies signatures
+// with Verify and answers with status.
+type receiver struct {
+	mu     sync.Mutex
+	ids    []string
+	errs   []error
+	bodies []string
+}
+
+func newReceiver(t *testing.T, status int) (*httptest.Server, *receiver) {
+	t.Helper()
+	rec := &receiver{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		rec.ids = append(rec.ids, r.Header.Get(HeaderID))
+		rec.errs = append(rec.errs, Verify(testSecret, r.Header, body, time.Minute))
+		rec.bodies = append(rec.bodies, string(body))
+		rec.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, rec
+}
+
+// fastPolicy retries up to attempts times with millisecond backoff.
+func fastPolicy(attempts int) interfaces.IRetryPolicy {
+	return resiliency.NewRetryPolicyWithConfig(attempts, time.Millisecond, 5*time.Millisecond, 2)
+}
+
+func TestSendSignsDelivery(t *testing.T) {
+	server, rec := newReceiver(t, http.StatusNoContent)
+
+	var results []*Delivery
+	sender := NewSender(client.NewHTTPClient(), fastPolicy(3), nil).
+		OnDelivery(func(d *Delivery) { results = append(results, d) })
+
+	delivery := sender.SendJSON(context.Background(), Endpoint{URL: server.URL, Secret: testSecret},
+		map[string]string{"event": "user.created"})
+	if !delivery.Succeeded() {
+		t.Fatalf("delivery failed: %v", delivery.Err)
+	}
+	if delivery.Attempts != 1 || delivery.StatusCode != http.StatusNoContent || delivery.Duration <= 0 {
+		t.Errorf("delivery = %d attempts, status %d, duration %v; want 1, 204 and a positive duration",
+			delivery.Attempts, delivery.StatusCode, delivery.Duration)
+	}
+	if len(results) != 1 || results[0] != delivery {
+		t.Errorf("callback received %d deliveries, want the returned one", len(results))
+	}
+
+	if len(rec.errs) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(rec.errs))
+	}
+	if rec.errs[0] != nil {
+		t.Errorf("Verify = %v, want a valid signature", rec.errs[0])
+	}
+	if rec.ids[0] != delivery.ID || rec.bodies[0] != `{"event":"user.created"}` {
+		t.Errorf("received id %q body %q, want %q and the encoded payload", rec.ids[0], rec.bodies[0], delivery.ID)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	body := []byte(`{"event":"user.created"}`)
+	now := time.Now().Unix()
+	signed := func(id string, timestamp int64, signature string) http.Header {
+		header := http.Header{}
+		header.Set(HeaderID, id)
+		header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+		header.Set(HeaderSignature, signature)
+		return header
+	}
+
+	for _, tc := range []struct {
+		name   string
+		secret string
+		header http.Header
+		body   []byte
+		want   error
+	}{
+		{"valid", testSecret, signed("id-1", now, Sign(testSecret, "id-1", now, body)), body, nil},
+		{"wrong secret", "other", signed("id-1", now, Sign(testSecret, "id-1", now, body)), body, ErrInvalidSignature},
+		{"modified body", testSecret, signed("id-1", now, Sign(testSecret, "id-1", now, body)), []byte(`{}`), ErrInvalidSignature},
+		{"replayed under new id", testSecret, signed("id-2", now, Sign(testSecret, "id-1", now, body)), body, ErrInvalidSignature},
+		{"stale timestamp", testSecret, signed("id-1", now-3600, Sign(testSecret, "id-1", now-3600, body)), body, ErrTimestampExpired},
+		{"missing headers", testSecret, http.Header{}, body, ErrMissingSignature},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := Verify(tc.secret, tc.header, tc.body, time.Minute); err != tc.want {
+				t.Errorf("Verify = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetriesThenBreakerOpens(t *testing.T) {
+	failing, failed := newReceiver(t, http.StatusServiceUnavailable)
+	healthy, _ := newReceiver(t, http.StatusOK)
+
+	breakers := resiliency.NewCircuitBreakerRegistry(3, time.Minute)
+	sender := NewSender(client.NewHTTPClient(), fastPolicy(3), breakers)
+	endpoint := Endpoint{URL: failing.URL, Secret: testSecret}
+
+	// The first delivery is retried until the policy gives up, which also
+	// trips the endpoint's breaker
+	first := sender.Send(context.Background(), endpoint, []byte(`{}`))
+	if first.Succeeded() || first.Attempts != 3 || first.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("first delivery = %d attempts, status %d, err %v; want 3 failed attempts with 503",
+			first.Attempts, first.StatusCode, first.Err)
+	}
+	if len(failed.ids) != 3 {
+		t.Fatalf("failing endpoint received %d requests, want 3", len(failed.ids))
+	}
+	for i, id := range failed.ids {
+		if id != first.ID || failed.errs[i] != nil {
+			t.Errorf("retry %d sent id %q (verify %v), want the delivery ID with a valid signature", i, id, failed.errs[i])
+		}
+	}
+
+	// The open breaker rejects the next delivery without reaching the endpoint
+	// and the rejection is not retried
+	second := sender.Send(context.Background(), endpoint, []byte(`{}`))
+	if !errors.Is(second.Err, resiliency.ErrCircuitOpen) || second.Attempts != 1 || second.StatusCode != 0 {
+		t.Errorf("second delivery = %d attempts, status %d, err %v; want one rejected attempt",
+			second.Attempts, second.StatusCode, second.Err)
+	}
+	if len(failed.ids) != 3 {
+		t.Errorf("failing endpoint received %d requests after the breaker opened, want 3", len(failed.ids))
+	}
+
+	// Other destinations have their own breaker
+	if other := sender.Send(context.Background(), Endpoint{URL: healthy.URL, Secret: testSecret}, []byte(`{}`)); !other.Succeeded() {
+		t.Errorf("healthy endpoint delivery failed: %v", other.Err)
+	}
+}
+
+func TestRetriesAttemptTimeouts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := NewSender(client.NewHTTPClient(), fastPolicy(3), nil).WithTimeout(50 * time.Millisecond)
+	delivery := sender.Send(context.Background(), Endpoint{URL: server.URL, Secret: testSecret}, []byte(`{}`))
+	if !delivery.Succeeded() || delivery.Attempts != 2 {
+		t.Errorf("delivery = %d attempts, err %v; want success on the second attempt", delivery.Attempts, delivery.Err)
+	}
+}
+
+func TestSendRejectsInvalidURL(t *testing.T) {
+	var called bool
+	sender := NewSender(client.NewHTTPClient(), nil, nil).OnDelivery(func(*Delivery) { called = true })
+	delivery := sender.Send(context.Background(), Endpoint{URL: "not a url", Secret: testSecret}, []byte(`{}`))
+	if delivery.Succeeded() || delivery.Attempts != 0 || !called {
+		t.Errorf("delivery = %d attempts, err %v, callback %v; want an error before any attempt",
+			delivery.Attempts, delivery.Err, called)
+	}
+}
@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderID carries the delivery ID. It stays the same across retries,
+	// so receivers can use it to drop duplicates.
+	HeaderID = "X-Webhook-Id"
+
+	// HeaderTimestamp carries the Unix time (seconds) the delivery was signed.
+	HeaderTimestamp = "X-Webhook-Timestamp"
+
+	// HeaderSignature carries the HMAC-SHA256 signature, as "sha256=<hex>".
+	HeaderSignature = "X-Signature"
+
+	// signaturePrefix names the algorithm in the signature header.
+	signaturePrefix = "sha256="
+)
+
+var (
+	// ErrMissingSignature is returned by Verify when a webhook header is absent.
+	ErrMissingSignature = errors.New("webhook signature headers missing")
+
+	// ErrInvalidSignature is returned by Verify when the signature does not match.
+	ErrInvalidSignature = errors.New("webhook signature invalid")
+
+	// ErrTimestampExpired is returned by Verify when the timestamp is outside the tolerance.
+	ErrTimestampExpired = errors.New("webhook timestamp outside tolerance")
+)
+
+// Sign returns the signature header value for a delivery. The HMAC covers
+// "<id>.<timestamp>.<body>", so a captured request cannot be replayed under
+// a new ID or timestamp without the secret.
+func Sign(secret, id string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id))
+	mac.Write([]byte{'.'})
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the webhook headers of a received delivery against body.
+// A positive tolerance rejects timestamps further than tolerance from now.
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	id := header.Get(HeaderID)
+	rawTimestamp := header.Get(HeaderTimestamp)
+	signature := header.Get(HeaderSignature)
+	if id == "" || rawTimestamp == "" || signature == "" {
+		return ErrMissingSignature
+	}
+
+	timestamp, err := strconv.ParseInt(rawTimestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		age := time.Since(time.Unix(timestamp, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrTimestampExpired
+		}
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+	expected := Sign(secret, id, timestamp, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
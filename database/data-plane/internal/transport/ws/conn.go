@@ -0,0 +1,244 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"data-plane/internal/transport/http/models"
+)
+
+// Message types, re-exported for callers.
+const (
+	TextMessage   = websocket.TextMessage
+	BinaryMessage = websocket.BinaryMessage
+)
+
+// ErrClosed is returned by reads and writes after Close.
+var ErrClosed = errors.New("websocket connection closed")
+
+// Conn is a WebSocket connection with keepalive, read limits and optional
+// auto-reconnect. One goroutine may read while others write.
+type Conn struct {
+	dialer *Dialer
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu sync.Mutex // guards ws
+	ws *websocket.Conn
+
+	writeMu sync.Mutex // serializes writes
+}
+
+// newConn wraps an established connection and closes it when ctx is done.
+func newConn(ctx context.Context, dialer *Dialer, ws *websocket.Conn) *Conn {
+	connCtx, cancel := context.WithCancel(ctx)
+	c := &Conn{
+		dialer: dialer,
+		parent: ctx,
+		ctx:    connCtx,
+		cancel: cancel,
+		ws:     ws,
+	}
+	c.setup(ws)
+
+	// Close promptly on cancellation so blocked reads return
+	go func() {
+		<-connCtx.Done()
+		c.closeCurrent(CloseGoingAway, "")
+	}()
+	return c
+}
+
+// setup applies the read limit and starts keepalive on a new connection.
+func (c *Conn) setup(ws *websocket.Conn) {
+	ws.SetReadLimit(c.dialer.readLimit)
+	if c.dialer.pingInterval <= 0 {
+		return
+	}
+
+	timeout := c.dialer.pongTimeout
+	ws.SetReadDeadline(time.Now().Add(timeout))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(timeout))
+	})
+	go c.keepalive(ws)
+}
+
+// keepalive pings ws until it fails or the connection is closed.
+func (c *Conn) keepalive(ws *websocket.Conn) {
+	ticker := time.NewTicker(c.dialer.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			deadline := time.Now().Add(c.dialer.writeTimeout)
+			if err := ws.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				return
+			}
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// current returns the underlying connection.
+func (c *Conn) current() *websocket.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws
+}
+
+// ReadMessage reads the next message. If the connection drops and
+// reconnect is enabled, it redials and continues reading; otherwise it
+// returns a *CloseError carrying the close code.
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	for {
+		messageType, data, err = c.current().ReadMessage()
+		if err == nil {
+			return messageType, data, nil
+		}
+		if ctxErr := c.closedErr(); ctxErr != nil {
+			return 0, nil, ctxErr
+		}
+
+		closeErr := newCloseError(err)
+		if errors.Is(err, websocket.ErrReadLimit) {
+			closeErr = &CloseError{Code: CloseMessageTooBig, Text: "read limit exceeded", Err: err}
+			c.closeCurrent(CloseMessageTooBig, closeErr.Text)
+		}
+		if err := c.reconnect(closeErr); err != nil {
+			return 0, nil, err
+		}
+	}
+}
+
+// ReadJSON reads the next message and decodes it into v.
+func (c *Conn) ReadJSON(v interface{}) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return models.NewDecodeError("failed to decode WebSocket message", nil, v, data, err)
+	}
+	return nil
+}
+
+// WriteMessage writes a message of the given type.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := c.closedErr(); err != nil {
+		return err
+	}
+
+	ws := c.current()
+	if c.dialer.writeTimeout > 0 {
+		ws.SetWriteDeadline(time.Now().Add(c.dialer.writeTimeout))
+	}
+	if err := ws.WriteMessage(messageType, data); err != nil {
+		if ctxErr := c.closedErr(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("websocket write failed: %w", err)
+	}
+	return nil
+}
+
+// WriteJSON encodes v as JSON and writes it as a text message.
+func (c *Conn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode WebSocket message: %w", err)
+	}
+	return c.WriteMessage(TextMessage, data)
+}
+
+// Close closes the connection with CloseNormalClosure.
+func (c *Conn) Close() error {
+	return c.CloseWithCode(CloseNormalClosure, "")
+}
+
+// CloseWithCode sends a close frame with the given code and reason, then
+// closes the connection. Reconnect is not attempted afterwards.
+func (c *Conn) CloseWithCode(code int, text string) error {
+	if c.ctx.Err() != nil {
+		return nil
+	}
+	err := c.closeCurrent(code, text)
+	c.cancel()
+	return err
+}
+
+// closeCurrent sends a close frame on the underlying connection and closes it.
+func (c *Conn) closeCurrent(code int, text string) error {
+	ws := c.current()
+	deadline := time.Now().Add(time.Second)
+	ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), deadline)
+	return ws.Close()
+}
+
+// closedErr returns the caller's context error if it is done, ErrClosed if
+// the connection was closed, or nil.
+func (c *Conn) closedErr() error {
+	if err := c.parent.Err(); err != nil {
+		return err
+	}
+	if c.ctx.Err() != nil {
+		return ErrClosed
+	}
+	return nil
+}
+
+// reconnect redials after cause, backing off per the reconnect policy.
+// It returns nil once reconnected, or the error to report.
+func (c *Conn) reconnect(cause error) error {
+	policy := c.dialer.reconnect
+	if policy == nil {
+		return cause
+	}
+
+	for attempt := 0; policy.ShouldRetry(cause, attempt); attempt++ {
+		select {
+		case <-time.After(policy.GetDelay(attempt)):
+		case <-c.ctx.Done():
+			return c.closedErr()
+		}
+
+		ws, err := c.dialer.dial(c.ctx)
+		if err != nil {
+			if ctxErr := c.closedErr(); ctxErr != nil {
+				return ctxErr
+			}
+			cause = err
+			continue
+		}
+
+		c.mu.Lock()
+		if c.ctx.Err() != nil {
+			c.mu.Unlock()
+			ws.Close()
+			return c.closedErr()
+		}
+		c.ws = ws
+		c.mu.Unlock()
+		c.setup(ws)
+
+		if c.dialer.onReconnect != nil {
+			if err := c.dialer.onReconnect(c); err != nil {
+				return fmt.Errorf("reconnect hook failed: %w", err)
+			}
+		}
+		return nil
+	}
+	return cause
+}
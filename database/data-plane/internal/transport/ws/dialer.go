@@ -0,0 +1,199 @@
+package ws
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/interfaces"
+)
+
+// Dialer opens WebSocket connections described by a request built with the
+// HTTP request builder, so the URL, headers and auth configured there are
+// reused for the handshake. An http or https URL is dialed as ws or wss.
+// Errors are recorded and reported by Connect.
+type Dialer struct {
+	request      interfaces.IHTTPRequest
+	url          string
+	tlsConfig    *tls.Config
+	proxy        func(*http.Request) (*url.URL, error)
+	readLimit    int64
+	pingInterval time.Duration
+	pongTimeout  time.Duration
+	writeTimeout time.Duration
+	reconnect    interfaces.IRetryPolicy
+	onReconnect  func(conn *Conn) error
+	err          error
+}
+
+// handshakeHeaders are set by the WebSocket library and must not be copied
+// from the request.
+var handshakeHeaders = []string{
+	"Upgrade", "Connection", "Sec-Websocket-Key", "Sec-Websocket-Version",
+	"Sec-Websocket-Extensions", "Content-Length", "Content-Type",
+}
+
+// NewDialer creates a Dialer for the given request.
+// By default messages are limited to 1MB, a ping is sent every 30 seconds
+// and the connection is considered dead if no pong arrives within 60 seconds.
+func NewDialer(request interfaces.IHTTPRequest) *Dialer {
+	d := &Dialer{
+		request:      request,
+		readLimit:    1 << 20,
+		pingInterval: 30 * time.Second,
+		pongTimeout:  60 * time.Second,
+		writeTimeout: 10 * time.Second,
+	}
+	if request == nil || request.HTTPRequest() == nil {
+		d.err = fmt.Errorf("request cannot be nil")
+		return d
+	}
+
+	u := *request.HTTPRequest().URL
+	switch strings.ToLower(u.Scheme) {
+	case "http", "ws":
+		u.Scheme = "ws"
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		d.err = fmt.Errorf("unsupported WebSocket scheme %q", u.Scheme)
+	}
+	d.url = u.String()
+	return d
+}
+
+// Client takes the TLS configuration and proxy of the given HTTP client's
+// transport, so the connection is configured like the client's requests.
+func (d *Dialer) Client(client *http.Client) *Dialer {
+	if client == nil {
+		return d
+	}
+	if transport, ok := client.Transport.(*http.Transport); ok {
+		d.tlsConfig = transport.TLSClientConfig
+		d.proxy = transport.Proxy
+	}
+	return d
+}
+
+// TLS sets the TLS configuration used for wss connections.
+func (d *Dialer) TLS(config *tls.Config) *Dialer {
+	d.tlsConfig = config
+	return d
+}
+
+// ReadLimit sets the maximum size in bytes of a received message.
+// A larger message closes the connection with CloseMessageTooBig.
+func (d *Dialer) ReadLimit(limit int64) *Dialer {
+	if d.err != nil {
+		return d
+	}
+	if limit <= 0 {
+		d.err = fmt.Errorf("read limit must be positive")
+		return d
+	}
+	d.readLimit = limit
+	return d
+}
+
+// Keepalive sends a ping every interval and fails reads if no pong (or other
+// message) arrives within timeout. A zero interval disables keepalive.
+func (d *Dialer) Keepalive(interval, timeout time.Duration) *Dialer {
+	if d.err != nil {
+		return d
+	}
+	if interval > 0 && timeout <= interval {
+		d.err = fmt.Errorf("pong timeout must be longer than the ping interval")
+		return d
+	}
+	d.pingInterval = interval
+	d.pongTimeout = timeout
+	return d
+}
+
+// WriteTimeout sets how long a single write may take.
+func (d *Dialer) WriteTimeout(timeout time.Duration) *Dialer {
+	d.writeTimeout = timeout
+	return d
+}
+
+// Reconnect enables auto-reconnect: when the connection drops or the peer
+// closes it with a temporary close code, reads redial with the policy's
+// backoff instead of failing. Messages in flight are not resent.
+func (d *Dialer) Reconnect(policy interfaces.IRetryPolicy) *Dialer {
+	d.reconnect = policy
+	return d
+}
+
+// OnReconnect sets a hook run after every successful reconnect, e.g. to
+// resubscribe. An error from the hook stops reconnecting and is returned
+// by the read that triggered the reconnect.
+func (d *Dialer) OnReconnect(fn func(conn *Conn) error) *Dialer {
+	d.onReconnect = fn
+	return d
+}
+
+// Connect dials the server and returns the connection. The connection is
+// closed when ctx is cancelled; reads and writes then fail with ctx's error.
+func (d *Dialer) Connect(ctx context.Context) (*Conn, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	ws, err := d.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(ctx, d, ws), nil
+}
+
+// dial performs the WebSocket handshake.
+func (d *Dialer) dial(ctx context.Context) (*websocket.Conn, error) {
+	dialer := websocket.Dialer{
+		Proxy:            d.proxy,
+		TLSClientConfig:  d.tlsConfig,
+		HandshakeTimeout: d.request.Timeout(),
+	}
+
+	header := d.request.Headers().Clone()
+	for _, key := range handshakeHeaders {
+		header.Del(key)
+	}
+
+	start := time.Now()
+	ws, resp, err := dialer.DialContext(ctx, d.url, header)
+	if err == nil {
+		return ws, nil
+	}
+
+	if resp == nil {
+		return nil, &models.HTTPError{
+			Request:  d.request,
+			Message:  models.NetworkErrorMessage("WebSocket", err),
+			Err:      err,
+			StageVal: interfaces.StageRequest,
+			Duration: time.Since(start),
+		}
+	}
+
+	response := &models.Response{HttpResp: resp, RequestRef: d.request}
+	snippet, truncated := models.CaptureBodySnippet(response, models.DefaultErrorBodyLimit)
+	return nil, &models.HTTPError{
+		StatusCode:  resp.StatusCode,
+		Request:     d.request,
+		Response:    response,
+		Message:     fmt.Sprintf("WebSocket handshake failed with status %d", resp.StatusCode),
+		Err:         err,
+		StageVal:    interfaces.StageRequest,
+		BodySnippet: snippet,
+		Truncated:   truncated,
+		RequestID:   models.RequestIDOf(d.request, response),
+		Duration:    time.Since(start),
+	}
+}
@@ -0,0 +1,89 @@
+package ws
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gorilla/websocket"
+
+	"data-plane/internal/transport/interfaces"
+)
+
+// Close codes defined by RFC 6455, re-exported for callers.
+const (
+	CloseNormalClosure     = websocket.CloseNormalClosure
+	CloseGoingAway         = websocket.CloseGoingAway
+	CloseProtocolError     = websocket.CloseProtocolError
+	ClosePolicyViolation   = websocket.ClosePolicyViolation
+	CloseMessageTooBig     = websocket.CloseMessageTooBig
+	CloseAbnormalClosure   = websocket.CloseAbnormalClosure
+	CloseInternalServerErr = websocket.CloseInternalServerErr
+	CloseServiceRestart    = websocket.CloseServiceRestart
+	CloseTryAgainLater     = websocket.CloseTryAgainLater
+)
+
+// CloseError is returned by reads once the connection has closed. Code is
+// the close code sent by the peer, or CloseAbnormalClosure if the connection
+// dropped without a close frame.
+// It implements the IKindedError interface with Kind KindConnection.
+type CloseError struct {
+	// Code is the close code
+	Code int
+
+	// Text is the close reason sent by the peer
+	Text string
+
+	// Err is the underlying error
+	Err error
+}
+
+// Ensure CloseError implements IKindedError interface
+var _ interfaces.IKindedError = (*CloseError)(nil)
+
+// newCloseError describes the read error err.
+func newCloseError(err error) *CloseError {
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return &CloseError{Code: closeErr.Code, Text: closeErr.Text, Err: err}
+	}
+	return &CloseError{Code: CloseAbnormalClosure, Err: err}
+}
+
+// Error implements the error interface for CloseError.
+func (e *CloseError) Error() string {
+	if e.Code == CloseAbnormalClosure && e.Err != nil {
+		return fmt.Sprintf("websocket closed abnormally: %v", e.Err)
+	}
+	if e.Text != "" {
+		return fmt.Sprintf("websocket closed with code %d: %s", e.Code, e.Text)
+	}
+	return fmt.Sprintf("websocket closed with code %d", e.Code)
+}
+
+// Unwrap returns the underlying error.
+func (e *CloseError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKind returns KindConnection.
+func (e *CloseError) ErrorKind() interfaces.ErrorKind {
+	return interfaces.KindConnection
+}
+
+// IsNormalClosure returns true if the peer closed the connection normally
+// or because it is going away.
+func (e *CloseError) IsNormalClosure() bool {
+	return e.Code == CloseNormalClosure || e.Code == CloseGoingAway
+}
+
+// IsTemporary returns true if reconnecting may succeed: the connection
+// dropped, the peer is going away or restarting, or asked to try again later.
+func (e *CloseError) IsTemporary() bool {
+	switch e.Code {
+	case CloseGoingAway, CloseAbnormalClosure, CloseInternalServerErr,
+		CloseServiceRestart, CloseTryAgainLater:
+		return true
+	default:
+		return false
+	}
+}
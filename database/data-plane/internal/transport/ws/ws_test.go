@@ -0,0 +1,192 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"data-plane/internal/transport/http/models"
+	"data-plane/internal/transport/resiliency"
+)
+
+type quote struct {
+	Symbol string  `json:"symbol"`
+	Price  float64 `json:"price"`
+}
+
+// newWSServer upgrades every request that carries the expected bearer token
+// and hands the connection to serve along with its 1-based sequence number.
+func newWSServer(t *testing.T, serve func(conn *websocket.Conn, n int)) *httptest.Server {
+	t.Helper()
+	var connections atomic.Int32
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		serve(conn, int(connections.Add(1)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newQuotesDialer returns a dialer for the server's quotes endpoint,
+// authenticated through the request's headers.
+func newQuotesDialer(t *testing.T, server *httptest.Server) *Dialer {
+	t.Helper()
+	httpReq, err := http.NewRequest(http.MethodGet, server.URL+"/quotes", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer token")
+	return NewDialer(&models.Request{HTTPReq: httpReq})
+}
+
+// mustConnect connects and closes the connection when the test ends.
+func mustConnect(t *testing.T, ctx context.Context, dialer *Dialer) *Conn {
+	t.Helper()
+	conn, err := dialer.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// echo writes back every message until the connection closes.
+func echo(conn *websocket.Conn, _ int) {
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.WriteMessage(messageType, data)
+	}
+}
+
+func TestEcho(t *testing.T) {
+	server := newWSServer(t, echo)
+	conn := mustConnect(t, context.Background(), newQuotesDialer(t, server))
+
+	sent := quote{Symbol: "ACME", Price: 12.5}
+	if err := conn.WriteJSON(sent); err != nil {
+		t.Fatal(err)
+	}
+	var got quote
+	if err := conn.ReadJSON(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != sent {
+		t.Errorf("echoed %+v, want %+v", got, sent)
+	}
+}
+
+func TestHandshakeUsesRequestHeaders(t *testing.T) {
+	server := newWSServer(t, echo)
+	httpReq, _ := http.NewRequest(http.MethodGet, server.URL+"/quotes", nil)
+
+	_, err := NewDialer(&models.Request{HTTPReq: httpReq}).Connect(context.Background())
+	httpErr, ok := models.AsHTTPError(err)
+	if !ok || httpErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Connect error = %v, want a 401 HTTPError", err)
+	}
+	if !strings.Contains(string(httpErr.BodySnippet), "unauthorized") {
+		t.Errorf("BodySnippet = %q, want the server's response", httpErr.BodySnippet)
+	}
+}
+
+func TestServerInitiatedClose(t *testing.T) {
+	server := newWSServer(t, func(conn *websocket.Conn, _ int) {
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(ClosePolicyViolation, "slow consumer"))
+		conn.ReadMessage() // Wait for the client's close frame
+	})
+	conn := mustConnect(t, context.Background(), newQuotesDialer(t, server))
+
+	_, _, err := conn.ReadMessage()
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("ReadMessage error = %T %v, want *CloseError", err, err)
+	}
+	if closeErr.Code != ClosePolicyViolation || closeErr.Text != "slow consumer" {
+		t.Errorf("close = %d %q, want %d \"slow consumer\"", closeErr.Code, closeErr.Text, ClosePolicyViolation)
+	}
+	if closeErr.IsTemporary() || closeErr.IsNormalClosure() {
+		t.Errorf("policy violation reported as temporary or normal: %v", closeErr)
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	server := newWSServer(t, func(conn *websocket.Conn, _ int) {
+		conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", 100)))
+		conn.ReadMessage()
+	})
+	conn := mustConnect(t, context.Background(), newQuotesDialer(t, server).ReadLimit(10))
+
+	_, _, err := conn.ReadMessage()
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != CloseMessageTooBig {
+		t.Errorf("ReadMessage error = %v, want a CloseMessageTooBig CloseError", err)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	server := newWSServer(t, func(conn *websocket.Conn, n int) {
+		if n == 1 {
+			// The first connection is dropped as if the server restarted
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(CloseServiceRestart, "restarting"))
+			return
+		}
+		conn.WriteJSON(quote{Symbol: "ACME", Price: float64(n)})
+		conn.ReadMessage()
+	})
+
+	var reconnects atomic.Int32
+	policy := resiliency.NewRetryPolicyWithConfig(3, time.Millisecond, 5*time.Millisecond, 2)
+	dialer := newQuotesDialer(t, server).Reconnect(policy).OnReconnect(func(*Conn) error {
+		reconnects.Add(1)
+		return nil
+	})
+	conn := mustConnect(t, context.Background(), dialer)
+
+	var got quote
+	if err := conn.ReadJSON(&got); err != nil {
+		t.Fatalf("ReadJSON error = %v, want the quote from the second connection", err)
+	}
+	if got.Price != 2 || reconnects.Load() != 1 {
+		t.Errorf("read %+v after %d reconnects, want price 2 after 1", got, reconnects.Load())
+	}
+}
+
+func TestContextCancellationClosesPromptly(t *testing.T) {
+	server := newWSServer(t, func(conn *websocket.Conn, _ int) {
+		conn.ReadMessage() // Never writes
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	conn := mustConnect(t, ctx, newQuotesDialer(t, server))
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	_, _, err := conn.ReadMessage()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadMessage error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("blocked read returned after %v", elapsed)
+	}
+	if err := conn.WriteJSON(quote{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("WriteJSON after cancel = %v, want context.Canceled", err)
+	}
+}
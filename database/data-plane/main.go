@@ -336,7 +336,7 @@ func testPoetryAPI() {
 	response, err := client.Send(request)
 
 	if err != nil {
-		if httpErr, ok := err.(*transport.HTTPError); ok {
+		if httpErr, ok := transport.AsHTTPError(err); ok {
 			if httpErr.IsTimeout() {
 				log.Printf("❌ Request timed out\n\n")
 				return